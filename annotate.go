@@ -0,0 +1,354 @@
+// This file is part of bkpdir
+//
+// Package main provides archive annotations: a note and tags recorded in a
+// metadata sidecar after an archive was created, and optionally renaming the
+// archive so its name carries the new note.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ ANNOTATE-001: Annotation sidecar suffix - 🔧
+const annotationSuffix = ".notes.json"
+
+// ⭐ ANNOTATE-001: Archive annotation record - 📝
+// ArchiveAnnotation is stored as .metadata/<archive>.notes.json next to the
+// archive. Its note replaces the note of the archive name in listings.
+type ArchiveAnnotation struct {
+	Note      string            `json:"note,omitempty"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	UpdatedAt time.Time         `json:"updated_at"`
+}
+
+// annotationPath returns the annotation location for an archive.
+func annotationPath(archivePath string) string {
+	return filepath.Join(filepath.Dir(archivePath), ".metadata", filepath.Base(archivePath)+annotationSuffix)
+}
+
+// ⭐ ANNOTATE-001: Annotation loading - 🔧
+// LoadArchiveAnnotation reads the annotation of an archive.
+// It returns nil without error if the archive was never annotated.
+func LoadArchiveAnnotation(archivePath string) (*ArchiveAnnotation, error) {
+	data, err := os.ReadFile(annotationPath(archivePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read annotation: %w", err)
+	}
+	var annotation ArchiveAnnotation
+	if err := json.Unmarshal(data, &annotation); err != nil {
+		return nil, fmt.Errorf("failed to decode annotation: %w", err)
+	}
+	return &annotation, nil
+}
+
+// ⭐ ANNOTATE-001: Annotation persistence - 🔧
+// StoreArchiveAnnotation writes the annotation of an archive to its .metadata directory.
+func StoreArchiveAnnotation(archivePath string, annotation *ArchiveAnnotation) error {
+	path := annotationPath(archivePath)
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+	data, err := json.MarshalIndent(annotation, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode annotation: %w", err)
+	}
+	return fileops.AtomicWriteFile(path, data, 0o644)
+}
+
+// applyArchiveAnnotation sets the note and tags of an archive from its
+// annotation, keeping the note of the name when none was annotated.
+func applyArchiveAnnotation(archive *Archive, annotation *ArchiveAnnotation) {
+	if annotation.Note != "" {
+		archive.Note = annotation.Note
+	}
+	archive.Tags = annotation.Tags
+}
+
+// formatArchiveTags returns tags as "k=v" pairs sorted by key.
+func formatArchiveTags(tags map[string]string) string {
+	pairs := make([]string, 0, len(tags))
+	for k, v := range tags {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// AnnotateOptions configures `bkpdir annotate`.
+type AnnotateOptions struct {
+	Config  *Config
+	Output  io.Writer
+	Archive string   // Archive name in the archive directory, or a path
+	Note    string   // New note, used when SetNote is set
+	SetNote bool     // Replace the note; an empty Note removes it
+	Tags    []string // KEY=VALUE tags to set; KEY= removes a tag
+	Rename  bool     // Rename the archive so its name carries the note
+	DryRun  bool
+}
+
+// ⭐ ANNOTATE-001: Archive annotation - 🔧
+// AnnotateArchive updates the note and tags recorded for an archive. The
+// archive itself is not changed, so its checksums and seal stay valid. With
+// Rename the archive and its sidecars are renamed to carry the note, the
+// seal is renewed for the new name and its SHA256SUMS entry is updated.
+func AnnotateArchive(opts AnnotateOptions) error {
+	cfg := opts.Config
+	archivePath, err := resolveRestoreArchive(cfg, opts.Archive)
+	if err != nil {
+		return err
+	}
+	// The atomic writer refuses paths with ".." elements
+	if archivePath, err = filepath.Abs(archivePath); err != nil {
+		return NewArchiveErrorWithCause("Failed to resolve archive path", 1, err)
+	}
+	tags, err := parseAnnotationTags(opts.Tags)
+	if err != nil {
+		return NewArchiveErrorWithCause("Invalid --tag", cfg.StatusConfigError, err)
+	}
+	if !opts.SetNote && len(tags) == 0 && !opts.Rename {
+		return NewArchiveError("Nothing to change; use --note, --tag or --rename", cfg.StatusConfigError)
+	}
+
+	annotation, err := LoadArchiveAnnotation(archivePath)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to load annotation", 1, err)
+	}
+	if annotation == nil {
+		annotation = &ArchiveAnnotation{}
+	}
+	if opts.SetNote {
+		annotation.Note = opts.Note
+	}
+	for k, v := range tags {
+		if v == "" {
+			delete(annotation.Tags, k)
+			continue
+		}
+		if annotation.Tags == nil {
+			annotation.Tags = make(map[string]string)
+		}
+		annotation.Tags[k] = v
+	}
+	annotation.UpdatedAt = time.Now().UTC()
+
+	// Without a note to carry, the name keeps the note it has
+	newPath := archivePath
+	if opts.Rename && (opts.SetNote || annotation.Note != "") {
+		if newPath, err = annotatedArchivePath(cfg, archivePath, annotation.Note); err != nil {
+			return err
+		}
+	}
+
+	name, newName := filepath.Base(archivePath), filepath.Base(newPath)
+	if opts.DryRun {
+		if newPath != archivePath {
+			fmt.Fprintf(opts.Output, "Would rename %s to %s\n", name, newName)
+		}
+		fmt.Fprintf(opts.Output, "Would annotate %s: %s\n", newName, describeAnnotation(annotation))
+		return nil
+	}
+
+	if newPath != archivePath {
+		if err := renameArchive(archivePath, newPath); err != nil {
+			return NewArchiveErrorWithCause(fmt.Sprintf("Failed to rename %s", name), 1, err)
+		}
+		fmt.Fprintf(opts.Output, "Renamed %s to %s\n", name, newName)
+	}
+	if err := StoreArchiveAnnotation(newPath, annotation); err != nil {
+		return NewArchiveErrorWithCause("Failed to store annotation", cfg.StatusDiskFull, err)
+	}
+	fmt.Fprintf(opts.Output, "Annotated %s: %s\n", newName, describeAnnotation(annotation))
+	return nil
+}
+
+// parseAnnotationTags parses KEY=VALUE tags. An empty value removes the tag.
+func parseAnnotationTags(args []string) (map[string]string, error) {
+	tags := make(map[string]string, len(args))
+	for _, arg := range args {
+		k, v, ok := strings.Cut(arg, "=")
+		if !ok || strings.TrimSpace(k) == "" {
+			return nil, fmt.Errorf("%q is not KEY=VALUE", arg)
+		}
+		tags[strings.TrimSpace(k)] = v
+	}
+	return tags, nil
+}
+
+// describeAnnotation summarizes an annotation for output.
+func describeAnnotation(annotation *ArchiveAnnotation) string {
+	note := "no annotated note"
+	if annotation.Note != "" {
+		note = fmt.Sprintf("note %q", annotation.Note)
+	}
+	if len(annotation.Tags) == 0 {
+		return note
+	}
+	return note + ", tags " + formatArchiveTags(annotation.Tags)
+}
+
+// ⭐ ANNOTATE-001: Renamed archive path - 🔍
+// annotatedArchivePath returns the path of the archive renamed so that its
+// name ends with note instead of its current note, following the naming
+// convention. Full archives with incremental archives are not renamed,
+// because the incremental names start with the name of their base.
+func annotatedArchivePath(cfg *Config, archivePath, note string) (string, error) {
+	if strings.ContainsAny(note, "/\\\n") || note == "." || note == ".." {
+		return "", NewArchiveError(fmt.Sprintf("Note %q cannot be used in an archive name", note), cfg.StatusConfigError)
+	}
+	archive := Archive{
+		Name:          filepath.Base(archivePath),
+		Path:          archivePath,
+		IsIncremental: strings.Contains(filepath.Base(archivePath), "_update="),
+	}
+	loadArchiveGitFields(&archive)
+
+	stem := archiveNameStem(archive)
+	name := stem
+	if note != "" {
+		name += "=" + note
+	}
+	newPath := filepath.Join(filepath.Dir(archivePath), name+".zip")
+	if newPath == archivePath {
+		return archivePath, nil
+	}
+	if pathExists(newPath) {
+		return "", NewArchiveError(fmt.Sprintf("Cannot rename to %s: the archive exists", filepath.Base(newPath)), 1)
+	}
+
+	if !archive.IsIncremental {
+		archives, err := listArchiveEntries(filepath.Dir(archivePath))
+		if err != nil {
+			return "", NewArchiveErrorWithCause("Failed to list archives", 1, err)
+		}
+		for _, a := range archives {
+			if a.BaseArchive == archive.Name {
+				return "", NewArchiveError(fmt.Sprintf(
+					"Cannot rename %s: incremental archives such as %s are named after it", archive.Name, a.Name), 1)
+			}
+		}
+	}
+	return newPath, nil
+}
+
+// archiveNameStem returns the name of an archive without its note and
+// extension. The note follows the Git branch and hash when the archive
+// recorded them, and otherwise follows the timestamp.
+func archiveNameStem(archive Archive) string {
+	stem := strings.TrimSuffix(archive.Name, ".zip")
+	if archive.GitBranch != "" && archive.GitHash != "" {
+		marker := "=" + archive.GitBranch + "=" + archive.GitHash
+		if i := strings.LastIndex(stem, marker); i >= 0 {
+			end := i + len(marker)
+			if strings.HasPrefix(stem[end:], "-dirty") {
+				end += len("-dirty")
+			}
+			return stem[:end]
+		}
+	}
+	start := 0
+	if i := strings.LastIndex(stem, "_update="); i >= 0 {
+		start = i + len("_update=")
+	}
+	if i := strings.Index(stem[start:], "="); i >= 0 {
+		return stem[:start+i]
+	}
+	return stem
+}
+
+// ⭐ ANNOTATE-001: Consistent archive rename - 🔧
+// renameArchive renames an archive together with its .metadata sidecars.
+// A seal is renewed for the new name, but only when the archive still
+// matches its current seal, and the SHA256SUMS entry of the archive
+// directory is renamed.
+func renameArchive(oldPath, newPath string) error {
+	// The atomic writer refuses paths with ".." elements
+	oldPath, err := filepath.Abs(oldPath)
+	if err != nil {
+		return err
+	}
+	if newPath, err = filepath.Abs(newPath); err != nil {
+		return err
+	}
+	oldName, newName := filepath.Base(oldPath), filepath.Base(newPath)
+	sealed := pathExists(sealPath(oldPath))
+	if sealed {
+		state, err := CheckArchiveSeal(oldPath)
+		if err != nil {
+			return fmt.Errorf("cannot renew the integrity seal: %w", err)
+		}
+		if state != SealValid {
+			return fmt.Errorf("archive does not match its integrity seal")
+		}
+	}
+
+	if err := fileops.Rename(oldPath, newPath); err != nil {
+		return err
+	}
+	metadataDir := filepath.Join(filepath.Dir(oldPath), ".metadata")
+	entries, err := os.ReadDir(metadataDir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read metadata directory: %w", err)
+	}
+	for _, entry := range entries {
+		suffix, ok := strings.CutPrefix(entry.Name(), oldName+".")
+		if !ok {
+			continue
+		}
+		if err := fileops.Rename(filepath.Join(metadataDir, entry.Name()),
+			filepath.Join(metadataDir, newName+"."+suffix)); err != nil {
+			return fmt.Errorf("failed to rename %s: %w", entry.Name(), err)
+		}
+	}
+
+	if sealed {
+		if err := SealArchive(newPath); err != nil {
+			return fmt.Errorf("failed to renew the integrity seal: %w", err)
+		}
+	}
+	return renameChecksumEntry(filepath.Join(filepath.Dir(oldPath), defaultChecksumFile), oldName, newName)
+}
+
+// renameChecksumEntry renames the entry of an archive in a checksum file.
+// A missing checksum file or entry is left alone.
+func renameChecksumEntry(path, oldName, newName string) error {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	entries, err := ParseChecksumFile(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("failed to parse %s: %w", filepath.Base(path), err)
+	}
+	var b strings.Builder
+	renamed := false
+	for _, e := range entries {
+		if e.Name == oldName {
+			e.Name, renamed = newName, true
+		}
+		b.WriteString(formatChecksumLine(e))
+	}
+	if !renamed {
+		return nil
+	}
+	return fileops.AtomicWriteFile(path, []byte(b.String()), 0o644)
+}
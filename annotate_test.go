@@ -0,0 +1,111 @@
+// This file is part of bkpdir
+
+// Package main provides tests for annotating archives.
+// It verifies that notes and tags are recorded in a sidecar and listed, and
+// that renaming keeps sidecars, seals and checksum files consistent.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ ANNOTATE-001: Archive annotation - 🧪
+func TestAnnotateArchive(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	t.Setenv(sealKeyEnvVar, strings.Repeat("ab", 32))
+
+	name := "project-2024-03-20-14-30=wip.zip"
+	archivePath := filepath.Join(archiveDir, name)
+	if err := os.WriteFile(archivePath, []byte("archive data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := SealArchive(archivePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := WriteChecksumFile(filepath.Join(archiveDir, defaultChecksumFile), []Archive{{Name: name, Path: archivePath}}); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	err := AnnotateArchive(AnnotateOptions{
+		Config: cfg, Output: &out, Archive: name,
+		Note: "release 1.2", SetNote: true, Tags: []string{"ticket=OPS-12", "keep=yes"},
+	})
+	if err != nil {
+		t.Fatalf("AnnotateArchive: %v", err)
+	}
+	archives, err := ListArchives(archiveDir)
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("ListArchives = %v, %v", archives, err)
+	}
+	if a := archives[0]; a.Name != name || a.Note != "release 1.2" || a.Tags["ticket"] != "OPS-12" {
+		t.Errorf("Annotation not listed: %+v", a)
+	}
+
+	// Removing a tag keeps the note
+	out.Reset()
+	if err := AnnotateArchive(AnnotateOptions{Config: cfg, Output: &out, Archive: name, Tags: []string{"keep="}}); err != nil {
+		t.Fatal(err)
+	}
+	annotation, err := LoadArchiveAnnotation(archivePath)
+	if err != nil || annotation.Note != "release 1.2" || len(annotation.Tags) != 1 {
+		t.Errorf("Unexpected annotation after removing a tag: %+v, %v", annotation, err)
+	}
+
+	out.Reset()
+	if err := AnnotateArchive(AnnotateOptions{Config: cfg, Output: &out, Archive: name, Rename: true}); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	newName := "project-2024-03-20-14-30=release 1.2.zip"
+	newPath := filepath.Join(archiveDir, newName)
+	if pathExists(archivePath) || !pathExists(newPath) {
+		t.Fatalf("Archive was not renamed:\n%s", out.String())
+	}
+	if annotation, _ := LoadArchiveAnnotation(newPath); annotation == nil || annotation.Tags["ticket"] != "OPS-12" {
+		t.Errorf("Annotation did not follow the rename: %+v", annotation)
+	}
+	if state, err := CheckArchiveSeal(newPath); err != nil || state != SealValid {
+		t.Errorf("Seal not renewed for the new name: %v, %v", state, err)
+	}
+	if pathExists(sealPath(archivePath)) {
+		t.Error("Seal of the old name was left behind")
+	}
+	var sums bytes.Buffer
+	if err := VerifyChecksumFile(&sums, filepath.Join(archiveDir, defaultChecksumFile)); err != nil {
+		t.Errorf("SHA256SUMS not updated: %v\n%s", err, sums.String())
+	}
+
+	// Full archives that incremental archives are based on keep their name
+	incremental := filepath.Join(archiveDir, "project-2024-03-20-14-30=release 1.2_update=2024-03-21-10-00.zip")
+	if err := os.WriteFile(incremental, []byte("update"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err = AnnotateArchive(AnnotateOptions{Config: cfg, Output: &out, Archive: newName, Note: "final", SetNote: true, Rename: true})
+	if err == nil || !strings.Contains(err.Error(), "incremental") {
+		t.Errorf("Expected the rename of a base archive to be refused, got %v", err)
+	}
+}
+
+func TestArchiveNameStem(t *testing.T) {
+	tests := []struct {
+		archive Archive
+		want    string
+	}{
+		{Archive{Name: "project-2024-03-20-14-30.zip"}, "project-2024-03-20-14-30"},
+		{Archive{Name: "project-2024-03-20-14-30_1=note.zip"}, "project-2024-03-20-14-30_1"},
+		{Archive{Name: "project-2024-03-20-14-30=main=abc123-dirty=a=b.zip", GitBranch: "main", GitHash: "abc123"},
+			"project-2024-03-20-14-30=main=abc123-dirty"},
+		{Archive{Name: "project-2024-03-20-14-30=base_update=2024-03-21-10-00=fix.zip"},
+			"project-2024-03-20-14-30=base_update=2024-03-21-10-00"},
+	}
+	for _, tt := range tests {
+		if got := archiveNameStem(tt.archive); got != tt.want {
+			t.Errorf("archiveNameStem(%s) = %q, want %q", tt.archive.Name, got, tt.want)
+		}
+	}
+}
@@ -9,10 +9,16 @@ package main
 
 import (
 	"archive/zip"
+	"bkpdir/pkg/fileops"
 	"bkpdir/pkg/formatter"
+	"bkpdir/pkg/git"
+	"bkpdir/pkg/processing"
+	"compress/flate"
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
@@ -30,12 +36,14 @@ type ArchiveConfig struct {
 	Timestamp          string
 	GitBranch          string
 	GitHash            string
+	GitDescribe        string // 🔶 GIT-007: Replaces the hash when set; carries its own -dirty marker
 	GitIsClean         bool
 	ShowGitDirtyStatus bool
 	Note               string
 	IsGit              bool
 	IsIncremental      bool
 	BaseName           string
+	Sequence           int // ⭐ ARCH-008: Collision sequence; 0 adds no suffix
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Consistent naming pattern - 📝
@@ -52,13 +60,25 @@ type Archive struct {
 	Note               string
 	BaseArchive        string // for incremental
 	VerificationStatus *VerificationStatus
+	// 🔶 GIT-007: Tag information loaded from Git metadata
+	GitDescribe string
+	GitTag      string
+	// ⭐ LIST-VERIFY-001: Result of the structural check done by list --verify-inline
+	StructureChecked bool
+	StructureError   string
+	// ⭐ TIER-001: Remote URL of an archive moved to cold storage; empty for local archives
+	Location string
+	// ⭐ ANNOTATE-001: Tags recorded with bkpdir annotate
+	Tags map[string]string
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-ready configuration - 🔍
 // ArchiveVerificationOptions holds configuration for archive verification
 type ArchiveVerificationOptions struct {
-	Path   string
-	Config ArchiveConfigInterface
+	// ⭐ TRACE-001: Optional; carries the trace of the archive run
+	Context context.Context
+	Path    string
+	Config  ArchiveConfigInterface
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based configuration abstraction - 🔍
@@ -69,12 +89,42 @@ type ArchiveConfigInterface interface {
 	GetExcludePatterns() []string
 	GetIncludeGitInfo() bool
 	GetShowGitDirtyStatus() bool
+	// 🔶 GIT-008: Git command, timeout and options for name lookups
+	GetGitConfig() *git.Config
 	GetSkipBrokenSymlinks() bool
 	GetVerification() *VerificationConfig
 	GetStatusCodes() map[string]int
 	GetStatusDirectoryNotFound() int
 	GetStatusDiskFull() int
 	GetStatusConfigError() int
+	// ⭐ ARCH-005: Timestamp clock settings for archive naming
+	GetTimestampTimezone() string
+	GetTimestampFormat() string
+	// ⭐ SEAL-001: Integrity seal toggle
+	GetIntegritySeal() bool
+	// ⭐ KEEP-GOING-001: Skip unreadable files and the exit code for partial archives
+	GetKeepGoing() bool
+	GetStatusPartialArchive() int
+	// ⭐ DEDUP-001: Per-file hashes in the manifest
+	GetManifestFileHashes() bool
+	// ⭐ CHANGES-001: File change journal toggle
+	GetChangeJournal() bool
+	// ⭐ PLUGIN-001: External processing stages
+	GetPlugins() []PluginConfig
+	// ⭐ SHARED-001: Per-user namespace of archive names
+	GetShared() *SharedConfig
+	// ⭐ BENCH-001: Deflate level of archive entries
+	GetCompressionLevel() int
+	// ⭐ JOBS-001: Concurrent jobs reading and deflating files
+	GetArchiveJobs() string
+	// ⭐ MEMORY-001: Memory limit of archive creation
+	GetMaxMemory() string
+	// ⭐ QUOTA-001: Quota of the archive directory and its exit code
+	GetQuota() string
+	GetQuotaPolicy() string
+	GetStatusQuotaExceeded() int
+	// ⭐ FOREIGN-001: Archive name pattern telling archives from foreign files
+	GetPatternArchiveFilename() string
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-ready configuration - 🔍
@@ -87,6 +137,14 @@ type ArchiveCreationOptions struct {
 	Config      ArchiveConfigInterface
 	Verify      bool
 	ResourceMgr *ResourceManager
+	// ⭐ HOOK-001: Database dumps stored in the archive, written below DumpDir
+	DumpDir string
+	Dumps   []DatabaseDump
+	// ⭐ CHANGES-001: Every file of the target when Files only holds changed
+	// files (incremental archives); nil means Files is the whole target
+	TargetFiles []string
+	// ⭐ MANIFEST-AUDIT-001: Files of the target left out, recorded in the manifest
+	Excluded []ManifestExclusion
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based formatter abstraction - 📝
@@ -105,7 +163,8 @@ type ConfigToArchiveConfigAdapter struct {
 }
 
 func (a *ConfigToArchiveConfigAdapter) GetArchiveDirPath() string {
-	return a.cfg.ArchiveDirPath
+	// ⭐ SHARED-001: Archives of the current user with the subdir namespace
+	return archiveRootDir(a.cfg, archiveUser())
 }
 
 func (a *ConfigToArchiveConfigAdapter) GetUseCurrentDirName() bool {
@@ -124,10 +183,22 @@ func (a *ConfigToArchiveConfigAdapter) GetShowGitDirtyStatus() bool {
 	return a.cfg.ShowGitDirtyStatus
 }
 
+func (a *ConfigToArchiveConfigAdapter) GetGitConfig() *git.Config {
+	return GetGitConfig(a.cfg)
+}
+
 func (a *ConfigToArchiveConfigAdapter) GetSkipBrokenSymlinks() bool {
 	return a.cfg.SkipBrokenSymlinks
 }
 
+func (a *ConfigToArchiveConfigAdapter) GetKeepGoing() bool {
+	return a.cfg.KeepGoing
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetStatusPartialArchive() int {
+	return a.cfg.StatusPartialArchive
+}
+
 func (a *ConfigToArchiveConfigAdapter) GetVerification() *VerificationConfig {
 	return a.cfg.Verification
 }
@@ -148,6 +219,62 @@ func (a *ConfigToArchiveConfigAdapter) GetStatusConfigError() int {
 	return a.cfg.StatusConfigError
 }
 
+func (a *ConfigToArchiveConfigAdapter) GetTimestampTimezone() string {
+	return a.cfg.TimestampTimezone
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetTimestampFormat() string {
+	return a.cfg.TimestampFormat
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetIntegritySeal() bool {
+	return a.cfg.IntegritySeal
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetManifestFileHashes() bool {
+	return a.cfg.ManifestFileHashes
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetChangeJournal() bool {
+	return a.cfg.ChangeJournal
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetPlugins() []PluginConfig {
+	return a.cfg.Plugins
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetShared() *SharedConfig {
+	return sharedSettings(a.cfg)
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetCompressionLevel() int {
+	return a.cfg.CompressionLevel
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetArchiveJobs() string {
+	return a.cfg.ArchiveJobs
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetMaxMemory() string {
+	return a.cfg.MaxMemory
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetQuota() string {
+	return a.cfg.Quota
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetQuotaPolicy() string {
+	return a.cfg.QuotaPolicy
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetStatusQuotaExceeded() int {
+	return a.cfg.StatusQuotaExceeded
+}
+
+func (a *ConfigToArchiveConfigAdapter) GetPatternArchiveFilename() string {
+	return a.cfg.PatternArchiveFilename
+}
+
 // 🔶 REFACTOR-005: Structure optimization - Interface wrapper for OutputFormatter backward compatibility - 🔍
 // OutputFormatterToArchiveFormatterAdapter adapts OutputFormatter to ArchiveFormatterInterface
 type OutputFormatterToArchiveFormatterAdapter struct {
@@ -223,13 +350,8 @@ func GenerateArchiveName(cfg ArchiveConfig) string {
 // generateIncrementalArchiveName generates name for incremental archives
 func generateIncrementalArchiveName(cfg ArchiveConfig) string {
 	baseName := strings.TrimSuffix(cfg.BaseName, ".zip")
-	name := baseName + "_update=" + cfg.Timestamp
-	if cfg.IsGit && cfg.GitBranch != "" && cfg.GitHash != "" {
-		name += "=" + cfg.GitBranch + "=" + cfg.GitHash
-		if !cfg.GitIsClean && cfg.ShowGitDirtyStatus {
-			name += "-dirty"
-		}
-	}
+	name := baseName + "_update=" + cfg.Timestamp + sequenceSuffix(cfg.Sequence)
+	name += gitNameFields(cfg)
 	if cfg.Note != "" {
 		name += "=" + cfg.Note
 	}
@@ -243,19 +365,12 @@ func generateIncrementalArchiveName(cfg ArchiveConfig) string {
 // 🔶 REFACTOR-005: Structure optimization - Consistent internal naming - 🔧
 // generateFullArchiveNameFromConfig generates name for full archives from config
 func generateFullArchiveNameFromConfig(cfg ArchiveConfig) string {
-	var name string
+	name := cfg.Timestamp + sequenceSuffix(cfg.Sequence)
 	if cfg.Prefix != "" {
-		name = cfg.Prefix + "-" + cfg.Timestamp
-	} else {
-		name = cfg.Timestamp
+		name = cfg.Prefix + "-" + name
 	}
 
-	if cfg.IsGit && cfg.GitBranch != "" && cfg.GitHash != "" {
-		name += "=" + cfg.GitBranch + "=" + cfg.GitHash
-		if !cfg.GitIsClean && cfg.ShowGitDirtyStatus {
-			name += "-dirty"
-		}
-	}
+	name += gitNameFields(cfg)
 
 	if cfg.Note != "" {
 		name += "=" + cfg.Note
@@ -264,6 +379,24 @@ func generateFullArchiveNameFromConfig(cfg ArchiveConfig) string {
 	return name + ".zip"
 }
 
+// 🔶 GIT-007: Git fields of archive names - 🔧
+// gitNameFields returns the "=BRANCH=HASH" part of an archive name. With
+// git.include_tags the describe output, such as v1.4.0-3-gabc1234-dirty,
+// takes the place of the hash.
+func gitNameFields(cfg ArchiveConfig) string {
+	if !cfg.IsGit || cfg.GitBranch == "" || cfg.GitHash == "" {
+		return ""
+	}
+	if cfg.GitDescribe != "" {
+		return "=" + cfg.GitBranch + "=" + cfg.GitDescribe
+	}
+	fields := "=" + cfg.GitBranch + "=" + cfg.GitHash
+	if !cfg.GitIsClean && cfg.ShowGitDirtyStatus {
+		fields += "-dirty"
+	}
+	return fields
+}
+
 // ⭐ ARCH-001: Archive naming with Git integration - 🔍
 // 🔺 GIT-001: Git information extraction for naming - 🔍
 // 🔺 GIT-003: Git status detection for naming - 🔍
@@ -274,8 +407,9 @@ func generateFullArchiveNameFromConfig(cfg ArchiveConfig) string {
 // GenerateFullArchiveName creates a full archive name with optional Git integration and note.
 // It uses the current directory name as prefix and includes Git branch/hash if available.
 func GenerateFullArchiveName(cfg *Config, cwd string, note string) (string, error) {
-	timestamp := time.Now().Format("2006-01-02-15-04")
-	prefix := filepath.Base(cwd)
+	// ⭐ ARCH-005: Configured clock and layout for the name timestamp
+	timestamp := FormatNameTimestamp(cfg.TimestampTimezone, cfg.TimestampFormat, time.Now())
+	prefix := archiveNamePrefix(cfg.Shared, cwd)
 
 	archiveConfig := ArchiveConfig{
 		Prefix:             prefix,
@@ -310,7 +444,22 @@ func generateFullArchiveName(cfg *Config, cwd string, note string) (string, erro
 // ListArchives lists all archives in the archive directory for the current source.
 // It returns a slice of Archive structs containing metadata for each archive found.
 func ListArchives(archiveDir string) ([]Archive, error) {
-	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range archives {
+		loadArchiveMetadata(&archives[i])
+	}
+	return archives, nil
+}
+
+// ⭐ LIST-001: Lazy archive listing - 🔧
+// listArchiveEntries returns the archives in archiveDir with only their name,
+// path and modification time. Sidecar metadata is left unloaded so callers
+// that print a page of a large directory only read the sidecars they need.
+func listArchiveEntries(archiveDir string) ([]Archive, error) {
+	if err := fileops.MkdirAll(archiveDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create archive directory: %w", err)
 	}
 
@@ -335,10 +484,8 @@ func ListArchives(archiveDir string) ([]Archive, error) {
 }
 
 // ⭐ ARCH-002: Archive metadata extraction - 🔧
-// IMMUTABLE-REF: Archive Naming Convention
-// TEST-REF: TestListArchives
-// DECISION-REF: DEC-001
-// createArchiveFromEntry creates an Archive from a directory entry.
+// createArchiveFromEntry builds an Archive from a directory entry without
+// reading its sidecar metadata.
 func createArchiveFromEntry(archiveDir string, entry os.DirEntry) (Archive, error) {
 	archivePath := filepath.Join(archiveDir, entry.Name())
 	fileInfo, err := entry.Info()
@@ -352,14 +499,36 @@ func createArchiveFromEntry(archiveDir string, entry os.DirEntry) (Archive, erro
 		IsIncremental: strings.Contains(entry.Name(), "_update="),
 		CreationTime:  fileInfo.ModTime(),
 	}
+	// ⭐ ARCH-007: Incremental names start with the name of their base archive
+	if base, _, found := strings.Cut(entry.Name(), "_update="); found {
+		archive.BaseArchive = base + ".zip"
+	}
+	return archive, nil
+}
 
+// loadArchiveMetadata fills in the verification status and Git metadata
+// recorded in the archive's sidecar files.
+func loadArchiveMetadata(archive *Archive) {
 	// Load verification status if available
-	status, err := LoadVerificationStatus(&archive)
+	status, err := LoadVerificationStatus(archive)
 	if err == nil && status != nil {
 		archive.VerificationStatus = status
 	}
+	loadArchiveGitFields(archive)
+	// ⭐ ANNOTATE-001: Notes and tags added after creation
+	if annotation, err := LoadArchiveAnnotation(archive.Path); err == nil && annotation != nil {
+		applyArchiveAnnotation(archive, annotation)
+	}
+}
 
-	return archive, nil
+// 🔶 GIT-007: Load Git metadata if it was recorded at creation time
+func loadArchiveGitFields(archive *Archive) {
+	if meta, err := LoadGitMetadata(archive); err == nil && meta != nil {
+		archive.GitBranch = meta.Branch
+		archive.GitHash = meta.Hash
+		archive.GitDescribe = meta.Describe
+		archive.GitTag = meta.Tag
+	}
 }
 
 // ⭐ ARCH-002: Archive creation with context - 🔧
@@ -379,11 +548,8 @@ func CreateArchiveWithContext(ctx context.Context, cfg *Config, note string, dry
 // collectFilesToArchive walks the directory and collects files to archive
 func collectFilesToArchive(ctx context.Context, cwd string, excludePatterns []string) ([]string, error) {
 	var files []string
-	err := filepath.Walk(cwd, func(path string, info os.FileInfo, err error) error {
-		if err := checkContextCancellation(ctx); err != nil {
-			return err
-		}
-
+	// ⭐ WALK-001: Directories are read in parallel, files visited in order
+	err := fileops.ParallelWalk(ctx, cwd, archiveScanOptions, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -393,7 +559,7 @@ func collectFilesToArchive(ctx context.Context, cwd string, excludePatterns []st
 			return err
 		}
 
-		if rel == "." || info.IsDir() || ShouldExcludeFile(rel, excludePatterns) {
+		if rel == "." || d.IsDir() || ShouldExcludeFile(rel, excludePatterns) {
 			return nil
 		}
 
@@ -403,6 +569,11 @@ func collectFilesToArchive(ctx context.Context, cwd string, excludePatterns []st
 	return files, err
 }
 
+// ⭐ WALK-001: Archive scanning - 🔧
+// archiveScanOptions walks in the order of filepath.Walk, so archives list
+// their entries in the same order whatever the parallelism.
+var archiveScanOptions = fileops.ParallelWalkOptions{Ordered: true}
+
 // checkContextCancellation checks if the context has been cancelled.
 func checkContextCancellation(ctx context.Context) error {
 	select {
@@ -415,17 +586,47 @@ func checkContextCancellation(ctx context.Context) error {
 
 // CreateFullArchiveWithContext creates a full archive with context support
 func CreateFullArchiveWithContext(ctx context.Context, cfg *Config, note string, dryRun bool, verify bool) error {
+	_, err := createFullArchive(ctx, cfg, note, nil, dryRun, verify)
+	return err
+}
+
+// ⭐ FROM-LIST-001: Archive listed files - 🔧
+// CreateFullArchiveFromList creates a full archive of the current directory
+// holding only the listed paths, relative to it or absolute within it,
+// instead of the files found by walking it. exclude_patterns do not apply.
+func CreateFullArchiveFromList(ctx context.Context, cfg *Config, note string, paths []string, dryRun bool, verify bool) error {
+	if paths == nil {
+		paths = []string{}
+	}
+	_, err := createFullArchive(ctx, cfg, note, paths, dryRun, verify)
+	return err
+}
+
+// createFullArchive creates a full archive of the listed paths, or of the
+// files found by walking the current directory when listed is nil, and
+// returns its path; a snapshot in a repository has none.
+func createFullArchive(ctx context.Context, cfg *Config, note string, listed []string, dryRun bool, verify bool) (archivePath string, err error) {
+	// ⭐ TRACE-001: Trace the pipeline stages when an OTLP endpoint is configured
+	ctx, trace := startArchiveTrace(ctx, cfg, "create.full")
+	defer func() { finishArchiveTrace(trace, err) }()
+	// ⭐ HEALTHCHECK-001: Ping the configured monitor around the run
+	ping := startHealthcheck(cfg, dryRun)
+	defer func() { ping.finish(err) }()
+
 	cwd, err := os.Getwd()
 	if err != nil {
-		return NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
+		return "", NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
 	}
+	// ⭐ LAST-001: Record the run for `bkpdir last`
+	run := startRunRecord(cfg, RunKindFull, cwd, dryRun)
+	defer func() { run.finish(archivePath, err) }()
 
 	if err := checkContextCancellation(ctx); err != nil {
-		return err
+		return "", err
 	}
 
 	if err := ValidateDirectoryPath(cwd, cfg); err != nil {
-		return err
+		return "", err
 	}
 
 	rm := NewResourceManager()
@@ -434,29 +635,92 @@ func CreateFullArchiveWithContext(ctx context.Context, cfg *Config, note string,
 	// 🔶 REFACTOR-005: Structure optimization - Use interface adapter for reduced coupling - 🔧
 	archiveConfig := &ConfigToArchiveConfigAdapter{cfg: cfg}
 
-	archiveDir, err := prepareArchiveDirectoryWithInterface(archiveConfig, cwd, dryRun)
+	var files, patterns []string
+	var scan *CachedScan
+	if listed != nil {
+		files, err = resolveFileList(cfg, cwd, listed, cfg.KeepGoing)
+		if err != nil {
+			return "", err
+		}
+	} else {
+		// ⭐ NESTED-DIR-001: Never archive the archive or backup directory into itself
+		patterns, err = excludeNestedDirs(cfg, cwd, archiveConfig.GetExcludePatterns())
+		if err != nil {
+			return "", err
+		}
+		// ⭐ SIZE-CACHE-001: Dry runs scan through the size estimate cache
+		if dryRun {
+			if scan, err = scanArchiveFilesCached(ctx, cwd, patterns); err == nil {
+				files = scan.Files
+			}
+		} else {
+			files, err = collectFilesToArchiveWithInterface(ctx, cwd, patterns)
+		}
+		if err != nil {
+			return "", NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+		}
+	}
+
+	// ⭐ LIMIT-001: Drop or refuse oversized candidates before archiving
+	candidates := files
+	files, err = enforceResourceLimits(cfg, cwd, files)
 	if err != nil {
-		return err
+		return "", err
+	}
+
+	// ⭐ CASE-001: Warn about entries that collide on case-insensitive filesystems
+	warnCaseCollisions(files)
+
+	// ⭐ MANIFEST-AUDIT-001: Record what the archive leaves out, before it
+	// reserves its name in the archive directory
+	var excluded []ManifestExclusion
+	if !dryRun && !repositoryEnabled(cfg) {
+		if excluded, err = collectManifestExclusions(ctx, cfg, cwd, patterns, candidates, files); err != nil {
+			return "", NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+		}
+	}
+
+	// ⭐ CDC-001: Store a deduplicated snapshot instead of a zip archive
+	if repositoryEnabled(cfg) {
+		// ⭐ HOOK-001: Snapshots hold directory files only
+		if databaseHooksConfigured(cfg) {
+			return "", NewArchiveError("Database hooks are not supported with a repository; "+
+				"remove the hooks section or repository.path", cfg.StatusConfigError)
+		}
+		// ⭐ PLUGIN-001: and store files unprocessed
+		if len(cfg.Plugins) > 0 {
+			return "", NewArchiveError("Plugins are not supported with a repository; "+
+				"remove the plugins section or repository.path", cfg.StatusConfigError)
+		}
+		return "", createRepositorySnapshot(cfg, cwd, files, fullArchiveNameConfig(archiveConfig, cwd, note), dryRun, verify)
 	}
 
-	files, err := collectFilesToArchiveWithInterface(ctx, cwd, archiveConfig.GetExcludePatterns())
+	archiveDir, err := prepareArchiveDirectoryWithInterface(archiveConfig, cwd, dryRun)
 	if err != nil {
-		return NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+		return "", err
 	}
 
-	archiveName, err := generateFullArchiveNameWithInterface(archiveConfig, cwd, note)
+	// ⭐ HOOK-001: Dump configured databases before anything is written
+	dumpDir, dumps, err := prepareDatabaseDumps(ctx, cfg, cwd, files, dryRun, rm)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	archivePath := filepath.Join(archiveDir, archiveName)
+	// ⭐ ARCH-008: Never reuse the name of an archive from the same minute
+	archivePath, err = claimArchivePath(archiveDir, fullArchiveNameConfig(archiveConfig, cwd, note), !dryRun)
+	if err != nil {
+		return "", NewArchiveErrorWithCause("Failed to reserve archive name", cfg.StatusDiskFull, err)
+	}
 
 	if dryRun {
-		printDryRunInfoWithInterface(files, archivePath, archiveConfig)
-		return nil
+		printDryRunInfoWithInterface(append(files, dumpEntries(dumps)...), archivePath, archiveConfig)
+		if scan != nil {
+			printSizeEstimate(os.Stdout, scan.Estimate(files))
+		}
+		return archivePath, nil
 	}
 
-	return createAndVerifyArchive(ArchiveCreationOptions{
+	err = createAndVerifyArchive(ArchiveCreationOptions{
 		Context:     ctx,
 		CWD:         cwd,
 		Path:        archivePath,
@@ -464,13 +728,32 @@ func CreateFullArchiveWithContext(ctx context.Context, cfg *Config, note string,
 		Config:      archiveConfig,
 		Verify:      verify,
 		ResourceMgr: rm,
+		DumpDir:     dumpDir,
+		Dumps:       dumps,
+		Excluded:    excluded,
 	})
+	// ⭐ EVENT-001: Report the archive outcome to the system log
+	emitArchiveEvent(cfg.EventLog, OperationCreate, archivePath, err)
+	// ⭐ AUDIT-001: Record the archive in the audit log
+	recordAudit(cfg, AuditActionCreate, archivePath, "", err)
+	if err == nil {
+		// ⭐ SHARED-001: Modes allowed by shared.umask
+		applySharedModes(cfg, archivePath)
+		// ⭐ SUDO-OWNER-001: Archives created through sudo belong to the invoking user
+		applyInvokingUserOwnership(cfg, archivePath)
+		applyChangeJournalOwnership(cfg, archivePath, cwd)
+	}
+	return archivePath, err
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based directory preparation - 🔍
 // prepareArchiveDirectoryWithInterface prepares the archive directory using interface abstractions
 func prepareArchiveDirectoryWithInterface(cfg ArchiveConfigInterface, cwd string, dryRun bool) (string, error) {
 	archiveDir := cfg.GetArchiveDirPath()
+	// ⭐ REMOTE-CACHE-001: Remote archive directories are only listed
+	if isRemoteLocation(archiveDir) {
+		return "", remoteArchiveDirError(archiveDir, cfg.GetStatusConfigError())
+	}
 	if cfg.GetUseCurrentDirName() {
 		archiveDir = filepath.Join(archiveDir, filepath.Base(cwd))
 	}
@@ -482,7 +765,7 @@ func prepareArchiveDirectoryWithInterface(cfg ArchiveConfigInterface, cwd string
 			}
 		} else {
 			// Fallback: create directory without config-specific error handling
-			if err := os.MkdirAll(archiveDir, 0755); err != nil {
+			if err := fileops.MkdirAll(archiveDir, 0755); err != nil {
 				return "", NewArchiveError(fmt.Sprintf("Failed to create directory: %s", archiveDir), 1)
 			}
 		}
@@ -493,12 +776,11 @@ func prepareArchiveDirectoryWithInterface(cfg ArchiveConfigInterface, cwd string
 // 🔶 REFACTOR-005: Structure optimization - Interface-based file collection - 🔍
 // collectFilesToArchiveWithInterface walks the directory and collects files to archive using interface abstractions
 func collectFilesToArchiveWithInterface(ctx context.Context, cwd string, excludePatterns []string) ([]string, error) {
-	var files []string
-	err := filepath.Walk(cwd, func(path string, info os.FileInfo, err error) error {
-		if err := checkContextCancellation(ctx); err != nil {
-			return err
-		}
-
+	// ⭐ TRACE-001: Scanning and filtering run as separate stages so each gets a span
+	_, span := startSpan(ctx, "scan")
+	var candidates []string
+	// ⭐ WALK-001: Directories are read in parallel, files visited in order
+	err := fileops.ParallelWalk(ctx, cwd, archiveScanOptions, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -508,21 +790,82 @@ func collectFilesToArchiveWithInterface(ctx context.Context, cwd string, exclude
 			return err
 		}
 
-		if rel == "." || info.IsDir() || ShouldExcludeFile(rel, excludePatterns) {
+		if rel == "." || d.IsDir() {
 			return nil
 		}
 
-		files = append(files, rel)
+		candidates = append(candidates, rel)
 		return nil
 	})
-	return files, err
+	span.SetAttr(traceAttrFilesScanned, len(candidates))
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	return filterExcludedFiles(ctx, candidates, excludePatterns), nil
+}
+
+// ⭐ TRACE-001: Filter stage - 🔍
+// filterExcludedFiles drops the candidates matching an exclusion pattern.
+func filterExcludedFiles(ctx context.Context, candidates []string, excludePatterns []string) []string {
+	_, span := startSpan(ctx, "filter")
+	var files []string
+	for _, rel := range candidates {
+		if !ShouldExcludeFile(rel, excludePatterns) {
+			files = append(files, rel)
+		}
+	}
+	span.SetAttr(traceAttrFilesIncluded, len(files))
+	span.SetAttr(traceAttrFilesExcluded, len(candidates)-len(files))
+	span.End(nil)
+	return files
+}
+
+// ⭐ TRACE-001: Compress stage - 🔧
+// compressArchiveStage writes the zip for files to tempFile inside a span
+// recording the file count and the uncompressed and compressed sizes. Files
+// skipped in keep-going mode are returned.
+func compressArchiveStage(cfg ArchiveCreationOptions, tempFile string) ([]FileFailure, error) {
+	ctx, span := startSpan(cfg.Context, "compress")
+	// ⭐ PLUGIN-001: Configured plugins see each file before it is compressed
+	pipeline, err := startPluginPipeline(ctx, cfg.Config.GetPlugins(), cfg.CWD, filepath.Base(cfg.Path))
+	if err != nil {
+		span.End(err)
+		return nil, err
+	}
+	failures, err := createZipArchiveWithContextAndConfig(withPluginPipeline(ctx, pipeline), cfg.CWD, tempFile, cfg.Files, cfg.Config, cfg.zipDumps()...)
+	if closeErr := pipeline.Close(); err == nil {
+		err = closeErr
+	}
+	if span != nil && err == nil {
+		span.SetAttr(traceAttrFilesIncluded, len(cfg.Files)+len(cfg.Dumps)-len(failures))
+		if r, openErr := zip.OpenReader(tempFile); openErr == nil {
+			var total uint64
+			for _, f := range r.File {
+				total += f.UncompressedSize64
+			}
+			r.Close()
+			span.SetAttr(traceAttrBytesIn, int64(total))
+		}
+		if info, statErr := os.Stat(tempFile); statErr == nil {
+			span.SetAttr(traceAttrBytesOut, info.Size())
+		}
+	}
+	span.End(err)
+	return failures, err
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based archive name generation - 📝
 // generateFullArchiveNameWithInterface creates a full archive name using interface abstractions
 func generateFullArchiveNameWithInterface(cfg ArchiveConfigInterface, cwd string, note string) (string, error) {
-	timestamp := time.Now().Format("2006-01-02-15-04")
-	prefix := filepath.Base(cwd)
+	return GenerateArchiveNameWithInterface(fullArchiveNameConfig(cfg, cwd, note)), nil
+}
+
+// fullArchiveNameConfig collects the name components of a full archive of cwd.
+func fullArchiveNameConfig(cfg ArchiveConfigInterface, cwd string, note string) ArchiveConfig {
+	// ⭐ ARCH-005: Configured clock and layout for the name timestamp
+	timestamp := FormatNameTimestamp(cfg.GetTimestampTimezone(), cfg.GetTimestampFormat(), time.Now())
+	prefix := archiveNamePrefix(cfg.GetShared(), cwd)
 
 	archiveConfig := ArchiveConfig{
 		Prefix:             prefix,
@@ -532,16 +875,16 @@ func generateFullArchiveNameWithInterface(cfg ArchiveConfigInterface, cwd string
 	}
 
 	if cfg.GetIncludeGitInfo() {
-		if IsGitRepository(cwd) {
-			branch, hash, isClean := GetGitInfoWithStatus(cwd)
+		// 🔶 GIT-008: Bounded lookup; only real Git failures are reported
+		if info, ok := gitNamingInfo(cwd, cfg.GetGitConfig()); ok {
 			archiveConfig.IsGit = true
-			archiveConfig.GitBranch = branch
-			archiveConfig.GitHash = hash
-			archiveConfig.GitIsClean = isClean
+			archiveConfig.GitBranch = info.Branch
+			archiveConfig.GitHash = info.Hash
+			archiveConfig.GitDescribe = info.Describe
+			archiveConfig.GitIsClean = info.IsClean
 		}
 	}
-
-	return GenerateArchiveNameWithInterface(archiveConfig), nil
+	return archiveConfig
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based dry run printing - 🔍
@@ -562,7 +905,13 @@ func printDryRunInfoWithInterface(files []string, archivePath string, cfg Archiv
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based verification - 📝
 // verifyArchiveWithInterface verifies an archive using interface abstractions
-func verifyArchiveWithInterface(cfg ArchiveVerificationOptions) error {
+func verifyArchiveWithInterface(cfg ArchiveVerificationOptions) (err error) {
+	// ⭐ TRACE-001: Verify stage
+	if cfg.Context != nil {
+		_, span := startSpan(cfg.Context, "verify")
+		defer func() { span.End(err) }()
+	}
+
 	status, err := VerifyArchive(cfg.Path)
 	if err != nil {
 		return NewArchiveErrorWithCause(
@@ -597,31 +946,20 @@ func printDryRunInfo(files []string, archivePath string, cfg *Config) {
 
 // createAndVerifyArchive creates and verifies an archive.
 func createAndVerifyArchive(cfg ArchiveCreationOptions) error {
-	tempFile := cfg.Path + ".tmp"
-	cfg.ResourceMgr.AddTempFile(tempFile)
+	// ⭐ ARCH-008: The name reservation is dropped on failure as well
+	cfg.ResourceMgr.AddTempFile(cfg.Path + ".tmp")
 
-	if err := createZipArchiveWithContextAndConfig(cfg.Context, cfg.CWD, tempFile, cfg.Files, cfg.Config); err != nil {
-		return NewArchiveErrorWithCause(
-			"Failed to create archive",
-			cfg.Config.GetStatusDiskFull(),
-			err,
-		)
-	}
-
-	if err := os.Rename(tempFile, cfg.Path); err != nil {
-		return NewArchiveErrorWithCause(
-			"Failed to finalize archive",
-			cfg.Config.GetStatusDiskFull(),
-			err,
-		)
+	failures, err := writeArchiveTransaction(cfg)
+	if err != nil {
+		return err
 	}
-
-	cfg.ResourceMgr.RemoveResource(&TempFile{Path: tempFile})
+	cfg.ResourceMgr.RemoveResource(&TempFile{Path: cfg.Path + ".tmp"})
 
 	if cfg.Verify {
 		verifyCfg := ArchiveVerificationOptions{
-			Path:   cfg.Path,
-			Config: cfg.Config,
+			Context: cfg.Context,
+			Path:    cfg.Path,
+			Config:  cfg.Config,
 		}
 		if err := verifyArchiveWithInterface(verifyCfg); err != nil {
 			return err
@@ -635,7 +973,80 @@ func createAndVerifyArchive(cfg ArchiveCreationOptions) error {
 		formatter.PrintCreatedArchiveWithStats(cfg.Path)
 	}
 
-	return nil
+	return partialArchiveError(cfg.Path, len(cfg.Files), failures, cfg.Config)
+}
+
+// ⭐ TXN-001: Transactional archive finalization - 🛡️
+// writeArchiveTransaction compresses the archive into a transaction, stages its
+// Git metadata, manifest and seal next to it and commits them together, so a
+// failed run leaves neither an archive without its sidecars nor sidecars
+// without their archive. Files skipped in keep-going mode are returned.
+func writeArchiveTransaction(cfg ArchiveCreationOptions) ([]FileFailure, error) {
+	txn, err := processing.NewTransaction(filepath.Dir(cfg.Path))
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to create archive", cfg.Config.GetStatusDiskFull(), err)
+	}
+	defer txn.Rollback()
+
+	stagedPath, err := txn.StagePath(cfg.Path)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to create archive", cfg.Config.GetStatusDiskFull(), err)
+	}
+	failures, err := compressArchiveStage(cfg, stagedPath)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to create archive", cfg.Config.GetStatusDiskFull(), err)
+	}
+
+	// ⭐ QUOTA-001: Refuse, or make room for, an archive over the quota before committing it
+	info, err := os.Stat(stagedPath)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to create archive", cfg.Config.GetStatusDiskFull(), err)
+	}
+	if err := enforceArchiveQuota(cfg.Config, cfg.Path, info.Size()); err != nil {
+		return nil, err
+	}
+
+	// ⭐ TRACE-001: Write stage covers committing the archive and its sidecars
+	_, writeSpan := startSpan(cfg.Context, "write")
+	writeSpan.SetAttr(traceAttrArchive, filepath.Base(cfg.Path))
+
+	// 🔶 GIT-007: Record Git metadata including describe and tag information
+	gitMeta := recordArchiveGitMetadata(txn, cfg.CWD, cfg.Path, cfg.Config)
+
+	// ⭐ MANIFEST-001: Record case collisions for restores on other systems
+	// ⭐ KEEP-GOING-001: and the files skipped in keep-going mode
+	// ⭐ DEDUP-001: and, when enabled, the hash of every archived file
+	var hashes []ManifestFile
+	if cfg.Config.GetManifestFileHashes() {
+		if hashes, err = hashArchiveEntries(stagedPath); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not hash the files of %s: %v\n", filepath.Base(cfg.Path), err)
+		}
+	}
+	// ⭐ SPLIT-001: and the run an archive of --split-by-dir belongs to
+	// ⭐ MANIFEST-AUDIT-001: and the files left out of it, with manifest_exclusions
+	// ⭐ RESTORE-001: and the names of the file owners
+	// 🔶 GIT-007: and the describe output and nearest tag
+	recordArchiveManifest(txn, cfg.Path, append(archivedFiles(cfg.Files, failures), dumpEntries(cfg.Dumps)...), failures, hashes,
+		splitRunID(cfg.Context), cfg.Excluded, archiveOwnerNames(stagedPath), gitMeta)
+
+	// ⭐ CHANGES-001: Journal the changes since the previous archive with it
+	if cfg.Config.GetChangeJournal() {
+		recordChangeJournal(txn, cfg, failures)
+	}
+
+	// ⭐ SEAL-001: Seal the finished archive before any verification
+	sealCreatedArchive(txn, cfg.Path, stagedPath, cfg.Config)
+
+	// 🔶 FILE-004: Flush the archive, its sidecars and their directory entries before reporting success
+	if err := txn.Commit(); err != nil {
+		writeSpan.End(err)
+		return nil, NewArchiveErrorWithCause("Failed to finalize archive", cfg.Config.GetStatusDiskFull(), err)
+	}
+	writeSpan.End(nil)
+
+	// ⭐ ARCH-008: Release the name reservation now that the archive exists
+	fileops.Remove(cfg.Path + ".tmp")
+	return failures, nil
 }
 
 // verifyArchive verifies an archive (backward compatibility).
@@ -661,6 +1072,10 @@ type IncrementalArchiveConfig struct {
 	DryRun  bool
 	Verify  bool
 	Context context.Context
+	// ⭐ ARCH-007: Full archive to diff against; empty selects the latest
+	Base string
+	// ⭐ VOLATILE-001: Archive changes of volatile files even when nothing else changed
+	IncludeVolatile bool
 }
 
 // CreateIncrementalArchive creates an incremental archive without context (backward compatibility)
@@ -676,11 +1091,29 @@ func CreateIncrementalArchive(cfg *Config, note string, dryRun bool, verify bool
 }
 
 // createIncrementalArchive is the core implementation for incremental archive creation
-func createIncrementalArchive(config IncrementalArchiveConfig) error {
+func createIncrementalArchive(config IncrementalArchiveConfig) (err error) {
+	// ⭐ TRACE-001: Trace the pipeline stages when an OTLP endpoint is configured
+	ctx, trace := startArchiveTrace(config.Context, config.Config, "create.incremental")
+	config.Context = ctx
+	defer func() { finishArchiveTrace(trace, err) }()
+	// ⭐ HEALTHCHECK-001: Ping the configured monitor around the run
+	ping := startHealthcheck(config.Config, config.DryRun)
+	defer func() { ping.finish(err) }()
+
+	// ⭐ CDC-001: Snapshots are deduplicated, so a repository has no incrementals
+	if repositoryEnabled(config.Config) {
+		return NewArchiveError("Incremental archives are not supported with a repository; "+
+			"full snapshots only store changed chunks", config.Config.StatusConfigError)
+	}
+
 	cwd, err := os.Getwd()
 	if err != nil {
 		return err
 	}
+	// ⭐ LAST-001: Record the run for `bkpdir last`
+	var archivePath string
+	run := startRunRecord(config.Config, RunKindIncremental, cwd, config.DryRun)
+	defer func() { run.finish(archivePath, err) }()
 
 	// 🔶 REFACTOR-005: Structure optimization - Use interface adapter for reduced coupling - 🔍
 	archiveConfig := &ConfigToArchiveConfigAdapter{cfg: config.Config}
@@ -690,16 +1123,44 @@ func createIncrementalArchive(config IncrementalArchiveConfig) error {
 		return err
 	}
 
-	latestFullArchive, err := findLatestFullArchive(archiveDir)
+	latestFullArchive, err := findBaseFullArchive(archiveDir, config.Base, config.Config.StatusConfigError)
+	if err != nil {
+		return err
+	}
+
+	// ⭐ FULL-AGE-001: Warn about or refuse an old base full archive
+	stale, err := checkFullArchiveAge(config.Config, latestFullArchive, time.Now(), config.DryRun)
+	if err != nil {
+		return err
+	}
+	if stale != nil {
+		defer func() {
+			if err == nil {
+				err = stale
+			}
+		}()
+	}
+
+	// ⭐ NESTED-DIR-001: Never archive the archive or backup directory into itself
+	patterns, err := excludeNestedDirs(config.Config, cwd, archiveConfig.GetExcludePatterns())
+	if err != nil {
+		return err
+	}
+	modifiedFiles, err := collectModifiedFiles(config.Context, cwd, latestFullArchive, patterns)
 	if err != nil {
 		return err
 	}
 
-	modifiedFiles, err := collectModifiedFiles(cwd, latestFullArchive, archiveConfig.GetExcludePatterns())
+	// ⭐ LIMIT-001: Drop or refuse oversized candidates before archiving
+	candidates := modifiedFiles
+	modifiedFiles, err = enforceResourceLimits(config.Config, cwd, modifiedFiles)
 	if err != nil {
 		return err
 	}
 
+	// ⭐ CASE-001: Warn about entries that collide on case-insensitive filesystems
+	warnCaseCollisions(modifiedFiles)
+
 	if len(modifiedFiles) == 0 {
 		// Use the adapter to get the original config for OutputFormatter
 		formatter := NewOutputFormatter(config.Config)
@@ -707,10 +1168,27 @@ func createIncrementalArchive(config IncrementalArchiveConfig) error {
 		return nil
 	}
 
-	archivePath, err := prepareIncrementalArchiveWithInterface(
-		cwd, latestFullArchive, archiveConfig, config.Note)
+	// ⭐ VOLATILE-001: Changes of volatile files alone are not worth an archive
+	if !config.IncludeVolatile && onlyVolatileChanges(modifiedFiles, config.Config.VolatilePatterns) {
+		printNoSignificantChanges(modifiedFiles)
+		return nil
+	}
+
+	// ⭐ MANIFEST-AUDIT-001: Record what the archive leaves out, before it
+	// reserves its name in the archive directory
+	var excluded []ManifestExclusion
+	if !config.DryRun {
+		excluded, err = collectManifestExclusions(config.Context, config.Config, cwd, patterns, candidates, modifiedFiles)
+		if err != nil {
+			return NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+		}
+	}
+
+	// ⭐ ARCH-008: Never reuse the name of an archive from the same minute
+	nameCfg := incrementalArchiveNameConfig(cwd, latestFullArchive, archiveConfig, config.Note)
+	archivePath, err = claimArchivePath(archiveDir, nameCfg, !config.DryRun)
 	if err != nil {
-		return err
+		return NewArchiveErrorWithCause("Failed to reserve archive name", config.Config.StatusDiskFull, err)
 	}
 
 	if config.DryRun {
@@ -718,59 +1196,94 @@ func createIncrementalArchive(config IncrementalArchiveConfig) error {
 		return nil
 	}
 
-	return createAndVerifyIncrementalArchive(ArchiveCreationOptions{
-		Context: config.Context,
-		CWD:     cwd,
-		Path:    archivePath,
-		Files:   modifiedFiles,
-		Config:  archiveConfig,
-		Verify:  config.Verify,
+	// ⭐ CHANGES-001: Deletions are only seen in the whole target
+	var targetFiles []string
+	if config.Config.ChangeJournal {
+		targetFiles, err = collectFilesToArchiveWithInterface(config.Context, cwd, patterns)
+		if err != nil {
+			return NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+		}
+	}
+
+	err = createAndVerifyIncrementalArchive(ArchiveCreationOptions{
+		Context:     config.Context,
+		CWD:         cwd,
+		Path:        archivePath,
+		Files:       modifiedFiles,
+		Config:      archiveConfig,
+		Verify:      config.Verify,
+		TargetFiles: targetFiles,
+		Excluded:    excluded,
 	})
+	// ⭐ EVENT-001: Report the archive outcome to the system log
+	emitArchiveEvent(config.Config.EventLog, OperationCreate, archivePath, err)
+	// ⭐ AUDIT-001: Record the archive in the audit log
+	recordAudit(config.Config, AuditActionCreate, archivePath, "incremental", err)
+	if err == nil {
+		// ⭐ SHARED-001: Modes allowed by shared.umask
+		applySharedModes(config.Config, archivePath)
+		// ⭐ SUDO-OWNER-001: Archives created through sudo belong to the invoking user
+		applyInvokingUserOwnership(config.Config, archivePath)
+		applyChangeJournalOwnership(config.Config, archivePath, cwd)
+	}
+	return err
 }
 
 // 🔶 REFACTOR-005: Structure optimization - Interface-based incremental archive preparation - 🔧
 // prepareIncrementalArchiveWithInterface prepares the archive name and path using interface abstractions
 func prepareIncrementalArchiveWithInterface(
 	cwd string, latestFullArchive *Archive, cfg ArchiveConfigInterface, note string) (string, error) {
-	isGit := IsGitRepository(cwd)
-	gitBranch, gitHash, gitIsClean := "", "", false
-	if isGit && cfg.GetIncludeGitInfo() {
-		gitBranch, gitHash, gitIsClean = GetGitInfoWithStatus(cwd)
+	nameCfg := incrementalArchiveNameConfig(cwd, latestFullArchive, cfg, note)
+	// Incremental archives are kept next to their base full archive
+	return filepath.Join(filepath.Dir(latestFullArchive.Path), GenerateArchiveNameWithInterface(nameCfg)), nil
+}
+
+// incrementalArchiveNameConfig collects the name components of an incremental
+// archive of cwd on top of latestFullArchive.
+func incrementalArchiveNameConfig(
+	cwd string, latestFullArchive *Archive, cfg ArchiveConfigInterface, note string) ArchiveConfig {
+	isGit := false
+	gitBranch, gitHash, gitDescribe, gitIsClean := "", "", "", false
+	if cfg.GetIncludeGitInfo() {
+		// 🔶 GIT-008: Bounded lookup; only real Git failures are reported
+		if info, ok := gitNamingInfo(cwd, cfg.GetGitConfig()); ok {
+			isGit = true
+			gitBranch, gitHash, gitDescribe, gitIsClean = info.Branch, info.Hash, info.Describe, info.IsClean
+		}
 	}
 
-	timestamp := time.Now().Format("2006-01-02-15-04")
-	nameCfg := ArchiveConfig{
+	// ⭐ ARCH-005: Configured clock and layout for the name timestamp
+	timestamp := FormatNameTimestamp(cfg.GetTimestampTimezone(), cfg.GetTimestampFormat(), time.Now())
+	return ArchiveConfig{
 		Prefix:             "",
 		Timestamp:          timestamp,
 		GitBranch:          gitBranch,
 		GitHash:            gitHash,
+		GitDescribe:        gitDescribe,
 		GitIsClean:         gitIsClean,
 		ShowGitDirtyStatus: cfg.GetShowGitDirtyStatus(),
 		Note:               note,
-		IsGit:              isGit && cfg.GetIncludeGitInfo(),
+		IsGit:              isGit,
 		IsIncremental:      true,
 		BaseName:           latestFullArchive.Name,
 	}
-	archiveName := GenerateArchiveNameWithInterface(nameCfg)
-	archivePath := filepath.Join(cfg.GetArchiveDirPath(), archiveName)
-	return archivePath, nil
 }
 
 // createAndVerifyIncrementalArchive creates and verifies an incremental archive
 func createAndVerifyIncrementalArchive(cfg ArchiveCreationOptions) error {
-	if err := createZipArchiveWithContextAndConfig(cfg.Context, cfg.CWD, cfg.Path, cfg.Files, cfg.Config); err != nil {
-		return NewArchiveErrorWithCause(
-			"Failed to create archive",
-			cfg.Config.GetStatusDiskFull(),
-			err,
-		)
+	failures, err := writeArchiveTransaction(cfg)
+	if err != nil {
+		// ⭐ ARCH-008: Drop the name reservation
+		fileops.Remove(cfg.Path + ".tmp")
+		return err
 	}
 
 	verificationConfig := cfg.Config.GetVerification()
 	if cfg.Verify || verificationConfig.VerifyOnCreate {
 		verifyCfg := ArchiveVerificationOptions{
-			Path:   cfg.Path,
-			Config: cfg.Config,
+			Context: cfg.Context,
+			Path:    cfg.Path,
+			Config:  cfg.Config,
 		}
 		if err := verifyArchiveWithInterface(verifyCfg); err != nil {
 			return err
@@ -783,7 +1296,7 @@ func createAndVerifyIncrementalArchive(cfg ArchiveCreationOptions) error {
 		formatter := NewFormatterAdapter(concreteCfg.cfg)
 		formatter.PrintIncrementalCreatedWithStats(cfg.Path)
 	}
-	return nil
+	return partialArchiveError(cfg.Path, len(cfg.Files), failures, cfg.Config)
 }
 
 // prepareIncrementalArchive prepares the archive name and path (backward compatibility)
@@ -795,15 +1308,20 @@ func prepareIncrementalArchive(
 }
 
 // collectModifiedFiles collects files modified since the last full archive
-func collectModifiedFiles(cwd string, latestFullArchive *Archive, excludePatterns []string) ([]string, error) {
+func collectModifiedFiles(ctx context.Context, cwd string, latestFullArchive *Archive, excludePatterns []string) ([]string, error) {
 	latestFullInfo, err := os.Stat(latestFullArchive.Path)
 	if err != nil {
 		return nil, err
 	}
 	latestFullTime := latestFullInfo.ModTime()
 
+	// ⭐ TRACE-001: Scanning and filtering run as separate stages so each gets a span
+	_, span := startSpan(ctx, "scan")
 	var modifiedFiles []string
-	err = filepath.Walk(cwd, func(path string, info os.FileInfo, err error) error {
+	// ⭐ WALK-001: Entries are also stat'ed in parallel for their modification times
+	opts := archiveScanOptions
+	opts.Info = true
+	err = fileops.ParallelWalk(ctx, cwd, opts, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
@@ -814,15 +1332,51 @@ func collectModifiedFiles(cwd string, latestFullArchive *Archive, excludePattern
 		if rel == "." {
 			return nil
 		}
-		if ShouldExcludeFile(rel, excludePatterns) {
-			return nil
+		info, err := d.Info()
+		if err != nil {
+			return err
 		}
 		if info.ModTime().After(latestFullTime) {
 			modifiedFiles = append(modifiedFiles, rel)
 		}
 		return nil
 	})
-	return modifiedFiles, err
+	span.SetAttr(traceAttrFilesScanned, len(modifiedFiles))
+	span.End(err)
+	if err != nil {
+		return nil, err
+	}
+	return filterExcludedFiles(ctx, modifiedFiles, excludePatterns), nil
+}
+
+// ⭐ ARCH-007: Explicit incremental base selection - 🔍
+// findBaseFullArchive returns the full archive named base, or the latest full
+// archive when base is empty. The name may omit the .zip extension.
+func findBaseFullArchive(archiveDir, base string, statusCode int) (*Archive, error) {
+	if base == "" {
+		return findLatestFullArchive(archiveDir)
+	}
+	if base != filepath.Base(base) {
+		return nil, NewArchiveError(fmt.Sprintf("--base must be an archive name, not a path: %s", base), statusCode)
+	}
+	if !strings.HasSuffix(base, ".zip") {
+		base += ".zip"
+	}
+
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	for i := range archives {
+		if archives[i].Name != base {
+			continue
+		}
+		if archives[i].IsIncremental {
+			return nil, NewArchiveError(fmt.Sprintf("%s is an incremental archive; --base must name a full archive", base), statusCode)
+		}
+		return &archives[i], nil
+	}
+	return nil, NewArchiveError(fmt.Sprintf("Base archive not found: %s", base), statusCode)
 }
 
 // findLatestFullArchive finds the most recent full archive in the archive directory.
@@ -867,7 +1421,7 @@ func createZipArchiveWithContext(ctx context.Context, sourceDir, archivePath str
 		return err
 	}
 
-	f, err := os.Create(archivePath)
+	f, err := fileops.Create(archivePath)
 	if err != nil {
 		return err
 	}
@@ -879,22 +1433,57 @@ func createZipArchiveWithContext(ctx context.Context, sourceDir, archivePath str
 	return addFilesToZip(ctx, sourceDir, files, zipw)
 }
 
-// createZipArchiveWithContextAndConfig creates a ZIP archive with context cancellation support and configuration
-func createZipArchiveWithContextAndConfig(ctx context.Context, sourceDir, archivePath string, files []string, cfg ArchiveConfigInterface) error {
+// createZipArchiveWithContextAndConfig creates a ZIP archive with context cancellation support and configuration.
+// It returns the files skipped in keep-going mode.
+// ⭐ HOOK-001: Database dumps, if any, are added after the directory files
+func createZipArchiveWithContextAndConfig(ctx context.Context, sourceDir, archivePath string, files []string,
+	cfg ArchiveConfigInterface, dumps ...zipDumps) ([]FileFailure, error) {
 	if err := checkContextCancellation(ctx); err != nil {
-		return err
+		return nil, err
+	}
+	// ⭐ BENCH-001: Entries are deflated at compression_level
+	level := cfg.GetCompressionLevel()
+	if level < flate.NoCompression || level > flate.BestCompression {
+		return nil, NewArchiveError(fmt.Sprintf("Invalid compression_level %d (use 0 to 9)", level), cfg.GetStatusConfigError())
 	}
+	// ⭐ JOBS-001: Files are read and deflated by archive_jobs jobs
+	jobs, adaptive, err := parseArchiveJobs(cfg.GetArchiveJobs())
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Invalid archive_jobs", cfg.GetStatusConfigError(), err)
+	}
+	// ⭐ MEMORY-001: Fewer jobs instead of more memory than max_memory
+	maxMemory, err := ParseByteSize(cfg.GetMaxMemory())
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Invalid max_memory", cfg.GetStatusConfigError(), err)
+	}
+	budget := newMemoryBudget(maxMemory)
+	jobs = budget.jobs(jobs)
 
-	f, err := os.Create(archivePath)
+	f, err := fileops.Create(archivePath)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer f.Close()
 
 	zipw := zip.NewWriter(f)
-	defer zipw.Close()
-
-	return addFilesToZipWithConfig(ctx, sourceDir, files, zipw, cfg)
+	zipw.RegisterCompressor(zip.Deflate, func(w io.Writer) (io.WriteCloser, error) {
+		return flate.NewWriter(w, level)
+	})
+	var failures []FileFailure
+	if jobs > 1 && pluginPipelineFrom(ctx) == nil {
+		failures, err = addFilesToZipConcurrently(ctx, sourceDir, files, zipw, cfg, jobs, adaptive, budget)
+	} else {
+		failures, err = addFilesToZipWithConfig(ctx, sourceDir, files, zipw, cfg)
+	}
+	for _, d := range dumps {
+		if err == nil {
+			err = addDumpsToZip(ctx, d.dir, d.dumps, zipw, cfg)
+		}
+	}
+	if closeErr := zipw.Close(); err == nil {
+		err = closeErr
+	}
+	return failures, err
 }
 
 // addFilesToZip adds files to a zip archive
@@ -912,17 +1501,37 @@ func addFilesToZip(ctx context.Context, sourceDir string, files []string, zipw *
 }
 
 // addFilesToZipWithConfig adds files to a zip archive with configuration support
-func addFilesToZipWithConfig(ctx context.Context, sourceDir string, files []string, zipw *zip.Writer, cfg ArchiveConfigInterface) error {
+func addFilesToZipWithConfig(
+	ctx context.Context, sourceDir string, files []string, zipw *zip.Writer, cfg ArchiveConfigInterface) ([]FileFailure, error) {
+	var failures []FileFailure
+	pipeline := pluginPipelineFrom(ctx)
 	for _, rel := range files {
 		if err := checkContextCancellation(ctx); err != nil {
-			return err
+			return failures, err
+		}
+
+		// ⭐ PLUGIN-001: Plugins may skip or replace the file
+		if pipeline != nil {
+			handled, err := pipeline.addFile(sourceDir, rel, zipw)
+			if err != nil {
+				return failures, err
+			}
+			if handled {
+				continue
+			}
 		}
 
 		if err := addFileToZipWithConfig(sourceDir, rel, zipw, cfg); err != nil {
-			return err
+			// ⭐ KEEP-GOING-001: Unreadable files are recorded; archive write errors still abort
+			var srcErr *fileSourceError
+			if cfg.GetKeepGoing() && errors.As(err, &srcErr) {
+				failures = append(failures, newFileFailure(rel, srcErr.Err))
+				continue
+			}
+			return failures, err
 		}
 	}
-	return nil
+	return failures, nil
 }
 
 // addFileToZip adds a single file to a zip archive
@@ -940,6 +1549,8 @@ func addFileToZip(sourceDir, rel string, zipw *zip.Writer) error {
 
 	hdr.Name = rel
 	hdr.Method = zip.Deflate
+	// ⭐ RESTORE-001: Record the owner ids for restores on other machines
+	hdr.Extra = append(hdr.Extra, ownerExtraField(info)...)
 	w, err := zipw.CreateHeader(hdr)
 	if err != nil {
 		return err
@@ -972,14 +1583,60 @@ func addFileToZip(sourceDir, rel string, zipw *zip.Writer) error {
 	return nil
 }
 
-// addFileToZipWithConfig adds a single file to a zip archive with configuration support for handling broken symlinks
+// addFileToZipWithConfig adds a single file to a zip archive with configuration support for handling broken symlinks.
+// Errors reading the file are returned as fileSourceError so keep-going mode can skip it.
 func addFileToZipWithConfig(sourceDir, rel string, zipw *zip.Writer, cfg ArchiveConfigInterface) error {
 	abs := filepath.Join(sourceDir, rel)
 	info, err := os.Lstat(abs)
 	if err != nil {
-		return err
+		return &fileSourceError{Err: err}
 	}
 
+	// ⭐ KEEP-GOING-001: Open the source before creating the entry so an
+	// unreadable file does not leave an empty entry behind
+	var content io.Reader
+	if info.Mode()&os.ModeSymlink != 0 {
+		// For symlinks, we store the link target, not the file content
+		linkTarget, err := os.Readlink(abs)
+		if err != nil {
+			// If we can't read the symlink and SkipBrokenSymlinks is enabled, skip this file
+			if cfg.GetSkipBrokenSymlinks() {
+				return nil
+			}
+			return &fileSourceError{Err: err}
+		}
+
+		// Check if the symlink target exists (to detect broken symlinks)
+		targetPath := linkTarget
+		if !filepath.IsAbs(linkTarget) {
+			targetPath = filepath.Join(filepath.Dir(abs), linkTarget)
+		}
+		if _, err := os.Stat(targetPath); os.IsNotExist(err) {
+			// This is a broken symlink
+			if cfg.GetSkipBrokenSymlinks() {
+				// Skip broken symlink
+				return nil
+			}
+			// Return error for broken symlink when not skipping
+			return &fileSourceError{Err: fmt.Errorf("broken symlink: %s -> %s", abs, linkTarget)}
+		}
+		content = strings.NewReader(linkTarget)
+	} else if !info.IsDir() {
+		// Regular file - open and copy content
+		rf, err := os.Open(abs)
+		if err != nil {
+			return &fileSourceError{Err: err}
+		}
+		defer rf.Close()
+		content = sourceReader{r: rf}
+	}
+
+	return writeZipEntry(zipw, info, rel, content)
+}
+
+// writeZipEntry stores content under rel with the metadata of info; content
+// is nil for directories.
+func writeZipEntry(zipw *zip.Writer, info os.FileInfo, rel string, content io.Reader) error {
 	hdr, err := zip.FileInfoHeader(info)
 	if err != nil {
 		return err
@@ -987,51 +1644,15 @@ func addFileToZipWithConfig(sourceDir, rel string, zipw *zip.Writer, cfg Archive
 
 	hdr.Name = rel
 	hdr.Method = zip.Deflate
+	// ⭐ RESTORE-001: Record the owner ids for restores on other machines
+	hdr.Extra = append(hdr.Extra, ownerExtraField(info)...)
 	w, err := zipw.CreateHeader(hdr)
 	if err != nil {
 		return err
 	}
 
-	if !info.IsDir() {
-		// Handle symbolic links specially
-		if info.Mode()&os.ModeSymlink != 0 {
-			// For symlinks, we store the link target, not the file content
-			linkTarget, err := os.Readlink(abs)
-			if err != nil {
-				// If we can't read the symlink and SkipBrokenSymlinks is enabled, skip this file
-				if cfg.GetSkipBrokenSymlinks() {
-					return nil
-				}
-				return err
-			}
-
-			// Check if the symlink target exists (to detect broken symlinks)
-			targetPath := linkTarget
-			if !filepath.IsAbs(linkTarget) {
-				targetPath = filepath.Join(filepath.Dir(abs), linkTarget)
-			}
-			if _, err := os.Stat(targetPath); os.IsNotExist(err) {
-				// This is a broken symlink
-				if cfg.GetSkipBrokenSymlinks() {
-					// Skip broken symlink
-					return nil
-				}
-				// Return error for broken symlink when not skipping
-				return fmt.Errorf("broken symlink: %s -> %s", abs, linkTarget)
-			}
-
-			_, err = w.Write([]byte(linkTarget))
-			return err
-		}
-
-		// Regular file - open and copy content
-		rf, err := os.Open(abs)
-		if err != nil {
-			return err
-		}
-		_, err = io.Copy(w, rf)
-		rf.Close()
-		if err != nil {
+	if content != nil {
+		if _, err := io.Copy(w, content); err != nil {
 			return err
 		}
 	}
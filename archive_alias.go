@@ -0,0 +1,102 @@
+// This file is part of bkpdir
+//
+// Package main provides archive aliases: symbolic names such as @latest,
+// @last-full and @last-verified that are accepted wherever a command takes
+// the name of an archive, so scripts need not look up timestamped names.
+// Aliases are resolved from the archives and their metadata in the archive
+// directory of the current directory.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ⭐ ALIAS-001: Archive aliases - 🔧
+const (
+	// ArchiveAliasPrefix starts every archive alias.
+	ArchiveAliasPrefix = "@"
+	// AliasLatest names the most recent archive.
+	AliasLatest = "@latest"
+	// AliasLastFull names the most recent full archive.
+	AliasLastFull = "@last-full"
+	// AliasLastVerified names the most recent archive whose last
+	// verification succeeded.
+	AliasLastVerified = "@last-verified"
+)
+
+// archiveAliases maps each alias to the archives it may name.
+var archiveAliases = map[string]func(Archive) bool{
+	AliasLatest:   func(Archive) bool { return true },
+	AliasLastFull: func(a Archive) bool { return !a.IsIncremental },
+	AliasLastVerified: func(a Archive) bool {
+		return a.VerificationStatus != nil && a.VerificationStatus.IsVerified
+	},
+}
+
+// isArchiveAlias reports whether name is meant as an archive alias.
+func isArchiveAlias(name string) bool {
+	return strings.HasPrefix(name, ArchiveAliasPrefix)
+}
+
+// ⭐ ALIAS-001: Alias resolution - 🔍
+// resolveArchiveAlias returns the name of the archive in archiveDir that the
+// alias name stands for, or name itself when it is not an alias. The most
+// recent archive is the one with the latest creation time; when several
+// archives share it the alias is ambiguous and an error lists them.
+func resolveArchiveAlias(cfg *Config, archiveDir, name string) (string, error) {
+	if !isArchiveAlias(name) {
+		return name, nil
+	}
+	matches, ok := archiveAliases[name]
+	if !ok {
+		return "", NewArchiveError(fmt.Sprintf("Unknown archive alias %s (use %s)", name,
+			strings.Join(archiveAliasNames(), ", ")), cfg.StatusConfigError)
+	}
+	archives, err := ListArchives(archiveDir)
+	if err != nil {
+		return "", NewArchiveErrorWithCause("Failed to list archives", cfg.StatusDirectoryNotFound, err)
+	}
+	// ⭐ FOREIGN-001: Aliases never name foreign files
+	archives = filterForeignArchives(cfg, archives)
+
+	var newest []Archive
+	for _, a := range archives {
+		if !matches(a) {
+			continue
+		}
+		switch {
+		case len(newest) == 0 || a.CreationTime.After(newest[0].CreationTime):
+			newest = []Archive{a}
+		case a.CreationTime.Equal(newest[0].CreationTime):
+			newest = append(newest, a)
+		}
+	}
+	switch len(newest) {
+	case 0:
+		return "", NewArchiveError(fmt.Sprintf("No archive matches %s in %s", name, archiveDir), cfg.StatusFileNotFound)
+	case 1:
+		return newest[0].Name, nil
+	}
+	names := make([]string, len(newest))
+	for i, a := range newest {
+		names[i] = a.Name
+	}
+	return "", NewArchiveError(fmt.Sprintf("Archive alias %s is ambiguous: %s were created at the same time; use a name",
+		name, strings.Join(names, ", ")), cfg.StatusConfigError)
+}
+
+// archiveAliasNames returns the known aliases in order.
+func archiveAliasNames() []string {
+	names := make([]string, 0, len(archiveAliases))
+	for name := range archiveAliases {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
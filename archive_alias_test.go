@@ -0,0 +1,90 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive aliases. It verifies which archive
+// each alias stands for and the errors for unknown and ambiguous aliases.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ ALIAS-001: Alias resolution - 🧪
+func TestResolveArchiveAlias(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := DefaultConfig()
+	base := time.Now().Add(-time.Hour)
+	archive := func(name string, age int, verified bool) {
+		path := filepath.Join(archiveDir, name)
+		os.WriteFile(path, []byte("zip"), 0o644)
+		mtime := base.Add(-time.Duration(age) * time.Minute)
+		os.Chtimes(path, mtime, mtime)
+		if verified {
+			if err := StoreVerificationStatus(&Archive{Name: name, Path: path},
+				&VerificationStatus{VerifiedAt: base, IsVerified: true}); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	archive("p-2024-03-01-10-00.zip", 30, true)
+	archive("p-2024-03-01-10-10=note.zip", 20, false)
+	archive("p-2024-03-01-10-10=note_update=2024-03-01-10-20.zip", 10, false)
+
+	for alias, want := range map[string]string{
+		"p-2024-03-01-10-00.zip": "p-2024-03-01-10-00.zip",
+		AliasLatest:              "p-2024-03-01-10-10=note_update=2024-03-01-10-20.zip",
+		AliasLastFull:            "p-2024-03-01-10-10=note.zip",
+		AliasLastVerified:        "p-2024-03-01-10-00.zip",
+	} {
+		if got, err := resolveArchiveAlias(cfg, archiveDir, alias); err != nil || got != want {
+			t.Errorf("%s = %q, %v; want %q", alias, got, err, want)
+		}
+	}
+
+	_, err := resolveArchiveAlias(cfg, archiveDir, "@newest")
+	if err == nil || !strings.Contains(err.Error(), AliasLastVerified) {
+		t.Errorf("unknown alias: %v", err)
+	}
+
+	// Two full archives created at the same time are ambiguous
+	archive("p-2024-03-01-10-05.zip", 20, false)
+	_, err = resolveArchiveAlias(cfg, archiveDir, AliasLastFull)
+	if ae, ok := err.(*ArchiveError); !ok || ae.StatusCode != cfg.StatusConfigError ||
+		!strings.Contains(err.Error(), "p-2024-03-01-10-05.zip, p-2024-03-01-10-10=note.zip") {
+		t.Errorf("ambiguous alias: %v", err)
+	}
+
+	_, err = resolveArchiveAlias(cfg, t.TempDir(), AliasLatest)
+	if ae, ok := err.(*ArchiveError); !ok || ae.StatusCode != cfg.StatusFileNotFound {
+		t.Errorf("no archives: %v", err)
+	}
+}
+
+// ⭐ ALIAS-001: Aliases in commands - 🧪
+func TestArchiveAliasInCommands(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ := ListArchives(archiveDir)
+	if len(archives) != 1 {
+		t.Fatalf("archives = %+v", archives)
+	}
+
+	if path, err := resolveRestoreArchive(cfg, AliasLatest); err != nil || path != archives[0].Path {
+		t.Errorf("restore @latest = %q, %v", path, err)
+	}
+	opts := VerifyOptions{Config: cfg, Formatter: NewOutputFormatter(cfg), ArchiveName: AliasLastFull}
+	if err := VerifyArchiveEnhanced(opts); err != nil {
+		t.Fatalf("verify @last-full: %v", err)
+	}
+	// The verification recorded above makes the archive @last-verified
+	if err := WriteArchiveChecksums(os.Stdout, cfg, []string{AliasLastVerified}, ""); err != nil {
+		t.Errorf("checksum write @last-verified: %v", err)
+	}
+}
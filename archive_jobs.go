@@ -0,0 +1,342 @@
+// This file is part of bkpdir
+//
+// Package main provides concurrent archive creation: with `archive_jobs`,
+// files are read and deflated by several jobs while a single writer adds
+// them to the archive in order. In the adaptive mode the number of jobs
+// follows the measured throughput.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/flate"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+)
+
+// ⭐ JOBS-001: Archive job settings - 🔧
+const (
+	// ArchiveJobsAuto adapts the number of jobs to the measured throughput.
+	ArchiveJobsAuto = "auto"
+	// parallelEntryLimit is the largest file a job reads into memory; larger
+	// files are streamed by the writer itself.
+	parallelEntryLimit = 8 << 20
+	// adaptiveInterval is how often the adaptive mode measures throughput.
+	adaptiveInterval = 250 * time.Millisecond
+)
+
+// parseArchiveJobs returns the number of jobs archive_jobs allows and whether
+// they adapt: "auto" adapts up to maxAdaptiveJobs.
+func parseArchiveJobs(value string) (int, bool, error) {
+	switch value {
+	case "":
+		return 1, false, nil
+	case ArchiveJobsAuto:
+		return maxAdaptiveJobs(), true, nil
+	}
+	jobs, err := strconv.Atoi(value)
+	if err != nil || jobs < 1 {
+		return 0, false, fmt.Errorf("%q is neither a positive number nor auto", value)
+	}
+	return jobs, false, nil
+}
+
+// maxAdaptiveJobs leaves a CPU for the writer and the rest of the system,
+// but allows two jobs so reading can overlap with compressing.
+func maxAdaptiveJobs() int {
+	return max(2, runtime.NumCPU()-1)
+}
+
+// jobLimiter bounds how many jobs work at the same time; the adaptive mode
+// changes the bound while they run.
+type jobLimiter struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	active int
+	limit  int
+}
+
+func newJobLimiter(limit int) *jobLimiter {
+	l := &jobLimiter{limit: limit}
+	l.cond = sync.NewCond(&l.mu)
+	return l
+}
+
+func (l *jobLimiter) acquire() {
+	l.mu.Lock()
+	for l.active >= l.limit {
+		l.cond.Wait()
+	}
+	l.active++
+	l.mu.Unlock()
+}
+
+func (l *jobLimiter) release() {
+	l.mu.Lock()
+	l.active--
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+func (l *jobLimiter) setLimit(limit int) {
+	l.mu.Lock()
+	l.limit = limit
+	l.mu.Unlock()
+	l.cond.Broadcast()
+}
+
+// ⭐ JOBS-001: Adaptive concurrency - 🔧
+// nextJobs is one step of the hill climb of the adaptive mode. The number of
+// jobs keeps moving by step while throughput improves by more than 5%, turns
+// around when it drops by more than 5%, and otherwise goes down, since a job
+// that adds nothing only loads the system.
+func nextJobs(jobs, step int, prevRate, rate float64, maxJobs int) (int, int) {
+	switch {
+	case prevRate == 0:
+	case rate > prevRate*1.05:
+	case rate < prevRate*0.95:
+		step = -step
+	default:
+		step = -1
+	}
+	if next := jobs + step; next < 1 || next > maxJobs {
+		step = -step
+	}
+	return min(max(jobs+step, 1), maxJobs), step
+}
+
+// adaptJobs measures the bytes written every adaptiveInterval and adjusts
+// limiter until ctx is done.
+func adaptJobs(ctx context.Context, limiter *jobLimiter, maxJobs int, written *atomic.Int64) {
+	ticker := time.NewTicker(adaptiveInterval)
+	defer ticker.Stop()
+	jobs, step := 1, 1
+	var prevRate float64
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		rate := float64(written.Swap(0)) / adaptiveInterval.Seconds()
+		jobs, step = nextJobs(jobs, step, prevRate, rate, maxJobs)
+		prevRate = rate
+		limiter.setLimit(jobs)
+	}
+}
+
+// preparedEntry is a file read and deflated by a job. Directories, symbolic
+// links and large files are left inline for the writer.
+type preparedEntry struct {
+	info   os.FileInfo
+	data   []byte
+	crc    uint32
+	size   int64
+	inline bool
+	err    error
+	// ⭐ MEMORY-001: Bytes of max_memory held until the entry is written
+	reserved int64
+}
+
+// jobWork is a file handed to a job with the memory reserved for it.
+type jobWork struct {
+	index    int
+	reserved int64
+}
+
+// prepareZipEntry reads and deflates sourceDir/rel at level, unless it is
+// larger than maxSize. Read errors are fileSourceErrors like those of
+// addFileToZipWithConfig.
+func prepareZipEntry(sourceDir, rel string, level int, maxSize int64) preparedEntry {
+	abs := filepath.Join(sourceDir, rel)
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return preparedEntry{err: &fileSourceError{Err: err}}
+	}
+	if !info.Mode().IsRegular() || info.Size() > maxSize {
+		return preparedEntry{info: info, inline: true}
+	}
+	f, err := os.Open(abs)
+	if err != nil {
+		return preparedEntry{err: &fileSourceError{Err: err}}
+	}
+	defer f.Close()
+
+	var buf bytes.Buffer
+	fw, err := flate.NewWriter(&buf, level)
+	if err != nil {
+		return preparedEntry{err: err}
+	}
+	crc := crc32.NewIEEE()
+	size, err := io.Copy(io.MultiWriter(fw, crc), sourceReader{r: f})
+	if err == nil {
+		err = fw.Close()
+	}
+	if err != nil {
+		return preparedEntry{err: err}
+	}
+	return preparedEntry{info: info, data: buf.Bytes(), crc: crc.Sum32(), size: size}
+}
+
+// writePreparedEntry adds a deflated entry with the header zip.Writer's
+// CreateHeader would write for it.
+func writePreparedEntry(zipw *zip.Writer, rel string, e preparedEntry) error {
+	hdr, err := zip.FileInfoHeader(e.info)
+	if err != nil {
+		return err
+	}
+	hdr.Name = rel
+	hdr.Method = zip.Deflate
+	hdr.CRC32 = e.crc
+	hdr.UncompressedSize64 = uint64(e.size)
+	hdr.CompressedSize64 = uint64(len(e.data))
+	hdr.CreatorVersion = hdr.CreatorVersion&0xff00 | 20
+	hdr.ReaderVersion = 20
+	if utf8.ValidString(rel) && !isPrintableASCII(rel) {
+		hdr.Flags |= 0x800
+	}
+	// ⭐ RESTORE-001: Owner ids first, as CreateHeader keeps given extra fields first
+	hdr.Extra = append(hdr.Extra, ownerExtraField(e.info)...)
+	// Extended timestamp with the modification time, as Info-ZIP writes it
+	extra := make([]byte, 9)
+	binary.LittleEndian.PutUint16(extra[0:], 0x5455)
+	binary.LittleEndian.PutUint16(extra[2:], 5)
+	extra[4] = 1
+	binary.LittleEndian.PutUint32(extra[5:], uint32(hdr.Modified.Unix()))
+	hdr.Extra = append(hdr.Extra, extra...)
+
+	w, err := zipw.CreateRaw(hdr)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(e.data)
+	return err
+}
+
+// isPrintableASCII reports whether s only holds printable ASCII characters
+// that need no UTF-8 flag in ZIP names.
+func isPrintableASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] > 0x7d || s[i] == '\\' {
+			return false
+		}
+	}
+	return true
+}
+
+// ⭐ JOBS-001: Concurrent readers and compressors - 🔧
+// addFilesToZipConcurrently adds files like addFilesToZipWithConfig, with up
+// to jobs files read and deflated at once, adapting their number when
+// adaptive is set. Entries are written in the order of files, and at most
+// twice as many prepared entries as jobs are held in memory. With a budget,
+// memory is reserved for each file in that order before a job reads it, so
+// jobs wait for written entries to free memory instead of exceeding it.
+func addFilesToZipConcurrently(ctx context.Context, sourceDir string, files []string, zipw *zip.Writer,
+	cfg ArchiveConfigInterface, jobs int, adaptive bool, budget *memoryBudget) ([]FileFailure, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	defer cancel()
+	defer budget.close()
+	maxSize := budget.maxEntrySize()
+
+	limiter := newJobLimiter(jobs)
+	var written atomic.Int64
+	if adaptive {
+		limiter.setLimit(1)
+		go adaptJobs(ctx, limiter, jobs, &written)
+	}
+
+	results := make([]chan preparedEntry, len(files))
+	for i := range results {
+		results[i] = make(chan preparedEntry, 1)
+	}
+	window := make(chan struct{}, 2*jobs)
+	work := make(chan jobWork)
+	go func() {
+		defer close(work)
+		for i := range files {
+			select {
+			case window <- struct{}{}:
+			case <-ctx.Done():
+				return
+			}
+			// ⭐ MEMORY-001: Reserve in file order, so the entry the writer
+			// waits for never waits for memory held by later entries
+			reserved := budget.entryMemory(filepath.Join(sourceDir, files[i]))
+			if !budget.reserve(reserved) {
+				return
+			}
+			select {
+			case work <- jobWork{index: i, reserved: reserved}:
+			case <-ctx.Done():
+				budget.release(reserved)
+				return
+			}
+		}
+	}()
+	level := cfg.GetCompressionLevel()
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range work {
+				limiter.acquire()
+				entry := prepareZipEntry(sourceDir, files[job.index], level, maxSize)
+				entry.reserved = budget.shrink(job.reserved, int64(len(entry.data)))
+				results[job.index] <- entry
+				limiter.release()
+			}
+		}()
+	}
+
+	var failures []FileFailure
+	for i, rel := range files {
+		var entry preparedEntry
+		select {
+		case entry = <-results[i]:
+		case <-ctx.Done():
+			return failures, checkContextCancellation(ctx)
+		}
+		<-window
+
+		var err error
+		switch {
+		case entry.inline:
+			err = addFileToZipWithConfig(sourceDir, rel, zipw, cfg)
+			written.Add(entry.info.Size())
+		case entry.err != nil:
+			err = entry.err
+		default:
+			err = writePreparedEntry(zipw, rel, entry)
+			written.Add(entry.size)
+		}
+		budget.release(entry.reserved)
+		if err != nil {
+			// ⭐ KEEP-GOING-001: Unreadable files are recorded; archive write errors still abort
+			var srcErr *fileSourceError
+			if cfg.GetKeepGoing() && errors.As(err, &srcErr) {
+				failures = append(failures, newFileFailure(rel, srcErr.Err))
+				continue
+			}
+			return failures, err
+		}
+	}
+	return failures, nil
+}
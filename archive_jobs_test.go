@@ -0,0 +1,164 @@
+// This file is part of bkpdir
+
+// Package main provides tests for concurrent archive creation.
+// It verifies that archives written by several jobs hold the same entries as
+// sequential ones, and the steps of the adaptive mode.
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ JOBS-001: Archives written by several jobs - 🧪
+func TestArchiveJobs(t *testing.T) {
+	files := map[string]string{"café.txt": "unicode name"}
+	for i := 0; i < 40; i++ {
+		files[filepath.Join("dir", fmt.Sprintf("file-%02d.txt", i))] = strings.Repeat(fmt.Sprintf("line %d\n", i), 1000*i)
+	}
+
+	for _, jobs := range []string{"1", "4", ArchiveJobsAuto} {
+		t.Run(jobs, func(t *testing.T) {
+			archiveDir := t.TempDir()
+			cfg := uploadTestConfig(t, archiveDir)
+			cfg.ArchiveJobs = jobs
+			for name, content := range files {
+				if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := os.Symlink("café.txt", "link"); err != nil {
+				t.Fatal(err)
+			}
+			if err := CreateFullArchive(cfg, "", false, false); err != nil {
+				t.Fatal(err)
+			}
+
+			archives, err := listArchiveEntries(archiveDir)
+			if err != nil || len(archives) != 1 {
+				t.Fatalf("archives = %+v, %v", archives, err)
+			}
+			r, err := zip.OpenReader(archives[0].Path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer r.Close()
+			var names []string
+			for _, f := range r.File {
+				names = append(names, f.Name)
+				want, ok := files[filepath.FromSlash(f.Name)]
+				if f.Name == "link" {
+					want, ok = "café.txt", f.Mode()&os.ModeSymlink != 0
+				}
+				rc, err := f.Open()
+				if err != nil {
+					t.Fatal(err)
+				}
+				got, err := io.ReadAll(rc)
+				rc.Close()
+				if err != nil || !ok || string(got) != want {
+					t.Errorf("%s: %d bytes, %v", f.Name, len(got), err)
+				}
+				if f.Name == "café.txt" && f.Flags&0x800 == 0 {
+					t.Error("UTF-8 name without the UTF-8 flag")
+				}
+			}
+			if len(names) != len(files)+1 || names[0] != "café.txt" || names[1] != "dir/file-00.txt" {
+				t.Errorf("entries = %v", names)
+			}
+		})
+	}
+
+	cfg := DefaultConfig()
+	cfg.ArchiveJobs = "many"
+	if _, _, err := parseArchiveJobs(cfg.ArchiveJobs); err == nil {
+		t.Error("archive_jobs: many should be rejected")
+	}
+}
+
+// ⭐ JOBS-001: Hill climb of the adaptive mode - 🧪
+func TestNextJobs(t *testing.T) {
+	tests := []struct {
+		name             string
+		jobs, step       int
+		prevRate, rate   float64
+		wantJobs, wantSt int
+	}{
+		{"first measurement", 1, 1, 0, 100, 2, 1},
+		{"improving", 2, 1, 100, 150, 3, 1},
+		{"worse turns around", 3, 1, 150, 100, 2, -1},
+		{"no gain goes down", 3, 1, 150, 152, 2, -1},
+		{"worse going down turns around", 2, -1, 150, 100, 3, 1},
+		{"bounded above", 4, 1, 100, 200, 3, -1},
+		{"bounded below", 1, -1, 100, 100, 2, 1},
+	}
+	for _, tt := range tests {
+		jobs, step := nextJobs(tt.jobs, tt.step, tt.prevRate, tt.rate, 4)
+		if jobs != tt.wantJobs || step != tt.wantSt {
+			t.Errorf("%s: nextJobs = %d, %d; want %d, %d", tt.name, jobs, step, tt.wantJobs, tt.wantSt)
+		}
+	}
+}
+
+// ⭐ MEMORY-001: Jobs within max_memory - 🧪
+func TestArchiveJobsMemoryBudget(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.ArchiveJobs = "8"
+	cfg.MaxMemory = "6MB"
+	want := make(map[string]int)
+	for i := 0; i < 12; i++ {
+		name := fmt.Sprintf("file-%02d.bin", i)
+		want[name] = i * 300 << 10
+		if err := os.WriteFile(name, make([]byte, want[name]), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("archives = %+v, %v", archives, err)
+	}
+	r, err := zip.OpenReader(archives[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if int(f.UncompressedSize64) != want[f.Name] {
+			t.Errorf("%s: %d bytes, want %d", f.Name, f.UncompressedSize64, want[f.Name])
+		}
+	}
+
+	budget := newMemoryBudget(6 << 20)
+	if jobs := budget.jobs(8); jobs != 2 {
+		t.Errorf("6MB allows %d jobs, want 2", jobs)
+	}
+	if !budget.reserve(3<<20) || !budget.reserve(2<<20) {
+		t.Fatal("reservations within the budget were refused")
+	}
+	done := make(chan bool)
+	go func() { done <- budget.reserve(1 << 20) }()
+	budget.release(budget.shrink(2<<20, 0))
+	if !<-done {
+		t.Error("a released reservation did not admit the waiting one")
+	}
+	go func() { done <- budget.reserve(4 << 20) }()
+	budget.close()
+	if <-done {
+		t.Error("a closed budget admitted a reservation")
+	}
+	if newMemoryBudget(0) != nil || newMemoryBudget(0).jobs(4) != 4 {
+		t.Error("no max_memory should not limit jobs")
+	}
+}
@@ -0,0 +1,104 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir create --from-list`, which archives the
+// paths listed in a file or on stdin instead of walking the directory, so
+// tools like find, fd or git ls-files decide what is backed up.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ⭐ FROM-LIST-001: File list parsing - 🔧
+// readFileList reads one path per line, or NUL-separated paths when nul is
+// set as with `find -print0`. Empty entries are skipped, and in the line
+// format so are trailing carriage returns.
+func readFileList(r io.Reader, nul bool) ([]string, error) {
+	sep := byte('\n')
+	if nul {
+		sep = 0
+	}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64<<10), 1<<20)
+	scanner.Split(func(data []byte, atEOF bool) (int, []byte, error) {
+		if i := bytes.IndexByte(data, sep); i >= 0 {
+			return i + 1, data[:i], nil
+		}
+		if atEOF && len(data) > 0 {
+			return len(data), data, nil
+		}
+		return 0, nil, nil
+	})
+	var paths []string
+	for scanner.Scan() {
+		path := scanner.Text()
+		if !nul {
+			path = strings.TrimSuffix(path, "\r")
+		}
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths, scanner.Err()
+}
+
+// loadFileList reads the list at path, where "-" is stdin.
+func loadFileList(path string, nul bool) ([]string, error) {
+	if path == "-" {
+		return readFileList(os.Stdin, nul)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return readFileList(f, nul)
+}
+
+// ⭐ FROM-LIST-001: Listed entries - 🛡️
+// resolveFileList turns listed paths, relative to dir or absolute, into the
+// paths relative to dir that collectFilesToArchive would return. Duplicates
+// and directories are dropped; directories are not expanded, so only listed
+// files are archived. Paths outside dir are refused, and so are missing
+// paths unless keepGoing is set, in which case they are kept and recorded
+// as unreadable while archiving.
+func resolveFileList(cfg *Config, dir string, paths []string, keepGoing bool) ([]string, error) {
+	seen := make(map[string]bool, len(paths))
+	var files []string
+	for _, path := range paths {
+		abs := path
+		if !filepath.IsAbs(abs) {
+			abs = filepath.Join(dir, abs)
+		}
+		rel, err := filepath.Rel(dir, abs)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil, NewArchiveError(fmt.Sprintf("Listed path %s is outside %s", path, dir), cfg.StatusConfigError)
+		}
+		if rel == "." || seen[rel] {
+			continue
+		}
+		info, err := os.Lstat(abs)
+		switch {
+		case err != nil && !keepGoing:
+			return nil, NewArchiveErrorWithCause(fmt.Sprintf("Listed path %s cannot be read", path), cfg.StatusFileNotFound, err)
+		case err == nil && info.IsDir():
+			continue
+		}
+		seen[rel] = true
+		files = append(files, rel)
+	}
+	if len(files) == 0 {
+		return nil, NewArchiveError("The file list names no files to archive", cfg.StatusFileNotFound)
+	}
+	return files, nil
+}
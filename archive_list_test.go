@@ -0,0 +1,82 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archiving an explicit file list.
+// It verifies list parsing, path resolution and the archived entries.
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// ⭐ FROM-LIST-001: List formats - 🧪
+func TestReadFileList(t *testing.T) {
+	paths, err := readFileList(strings.NewReader("a.txt\r\n\nsub/b c.txt\nlast"), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a.txt", "sub/b c.txt", "last"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("lines = %q, want %q", paths, want)
+	}
+	paths, err = readFileList(strings.NewReader("./a.txt\x00new\nline.txt\x00\x00"), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"./a.txt", "new\nline.txt"}; !reflect.DeepEqual(paths, want) {
+		t.Errorf("NUL-separated = %q, want %q", paths, want)
+	}
+}
+
+// ⭐ FROM-LIST-001: Archiving listed paths - 🧪
+func TestCreateFullArchiveFromList(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.ExcludePatterns = []string{"*.txt"}
+	cwd, _ := os.Getwd()
+	for name, content := range map[string]string{"a.txt": "a", "sub/b.txt": "b", "unlisted.txt": "u"} {
+		path := filepath.Join(cwd, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Excluded patterns do not apply to listed files; directories and
+	// duplicates are dropped
+	list := []string{"a.txt", "./sub/b.txt", filepath.Join(cwd, "a.txt"), "sub"}
+	if err := CreateFullArchiveFromList(context.Background(), cfg, "", list, false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("archives = %v (%v)", archives, err)
+	}
+	r, err := zip.OpenReader(archives[0].Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	var names []string
+	for _, f := range r.File {
+		names = append(names, f.Name)
+	}
+	sort.Strings(names)
+	if want := []string{"a.txt", "sub/b.txt"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("entries = %v, want %v", names, want)
+	}
+
+	for _, list := range [][]string{{"../outside.txt"}, {"missing.txt"}, {"sub"}, {}} {
+		if err := CreateFullArchiveFromList(context.Background(), cfg, "", list, true, false); err == nil {
+			t.Errorf("list %q should be refused", list)
+		}
+	}
+	if _, err := resolveFileList(cfg, cwd, []string{"missing.txt"}, true); err != nil {
+		t.Errorf("keep-going should keep missing paths: %v", err)
+	}
+}
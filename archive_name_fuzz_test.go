@@ -0,0 +1,113 @@
+// This file is part of bkpdir
+
+// Package main provides fuzz tests and tests for the parsing of archive
+// names. It verifies that untrusted names never crash parsing, are never
+// interpreted as templates and are listed as foreign when they do not match.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bkpdir/pkg/formatter"
+)
+
+// archiveNameSeeds are names of archives, foreign files and hostile input.
+var archiveNameSeeds = []string{
+	"proj-2024-03-20-14-30.zip",
+	"proj-2024-03-20-14-30_2=main=abc1234=before refactoring.zip",
+	"proj-2024-03-20-14-30=note_update=2024-03-21-09-00=fix.zip",
+	"photos.zip",
+	"-0000-00-00-00-00.zip",
+	"proj-2024-03-20-14-30={{printf \"%0999999999d\" 1}}.zip",
+	"proj-2024-03-20-14-30=%{path}%{note}.zip",
+	"\xff\xfe-2024-03-20-14-30.zip",
+	strings.Repeat("a-2024-03-20-14-30=", 200) + ".zip",
+}
+
+// ⭐ NAME-PATTERN-001: Archive name parsing - 🧪
+func FuzzExtractArchiveFilenameData(f *testing.F) {
+	for _, name := range archiveNameSeeds {
+		f.Add(name)
+	}
+	adapter := NewOutputFormatter(DefaultConfig())
+	f.Fuzz(func(t *testing.T, name string) {
+		data := adapter.ExtractArchiveFilenameData(name)
+		if len(name) > formatter.MaxPatternInputLength && len(data) != 0 {
+			t.Fatalf("parsed a name of %d bytes", len(name))
+		}
+		for group, value := range data {
+			if !strings.Contains(name, value) {
+				t.Fatalf("%s = %q is not part of %q", group, value, name)
+			}
+		}
+	})
+}
+
+// ⭐ NAME-PATTERN-001: Values are never template source - 🧪
+func FuzzFormatWithPlaceholders(f *testing.F) {
+	for _, name := range archiveNameSeeds {
+		f.Add(name, "before refactoring")
+	}
+	tf := NewTemplateFormatter(DefaultConfig())
+	f.Fuzz(func(t *testing.T, path, note string) {
+		got := tf.FormatWithPlaceholders("%{path} (%{note}) {{.note}}\n", map[string]string{"path": path, "note": note})
+		if want := path + " (" + note + ") " + note + "\n"; got != want {
+			t.Fatalf("got %q, want %q", got, want)
+		}
+	})
+}
+
+// ⭐ NAME-PATTERN-001: Timestamp migration of names - 🧪
+func FuzzMigrateArchiveName(f *testing.F) {
+	for _, name := range archiveNameSeeds {
+		f.Add(name)
+	}
+	f.Fuzz(func(t *testing.T, name string) {
+		migrated, ok := migrateArchiveName(name, "2006-01-02-15-04", "20060102T1504", time.UTC)
+		if ok && !strings.HasSuffix(migrated, ".zip") {
+			t.Fatalf("%q migrated to %q", name, migrated)
+		}
+	})
+}
+
+// ⭐ NAME-PATTERN-001: Foreign names in listings - 🧪
+func TestListForeignArchiveNames(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.TemplateListArchive = "%{prefix} on %{branch}: %{note}\n"
+	names := []string{
+		"proj-2024-03-20-14-30=main=abc1234=release.zip",
+		"proj-2024-03-20-14-31=main=abc1234={{printf \"%0999999999d\" 1}}.zip",
+		"photos.zip",
+	}
+	for _, name := range names {
+		os.WriteFile(filepath.Join(archiveDir, name), []byte("zip"), 0o644)
+	}
+
+	stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func(orig *os.File) { os.Stdout = orig }(os.Stdout)
+	os.Stdout = stdout
+	err = ListArchivesWithOptions(ListOptions{Config: cfg, Formatter: NewOutputFormatter(cfg), Foreign: true})
+	os.Stdout.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, _ := os.ReadFile(stdout.Name())
+
+	for _, want := range []string{
+		"proj on main: release [UNVERIFIED]",
+		"proj on main: {{printf \"%0999999999d\" 1}} [UNVERIFIED]",
+		"Foreign files (not created by bkpdir):\n  photos.zip (3B, ",
+	} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("listing lacks %q:\n%s", want, out)
+		}
+	}
+}
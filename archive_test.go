@@ -21,10 +21,13 @@ package main
 import (
 	"archive/zip"
 	"bytes"
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // Test archive name generation
@@ -78,6 +81,19 @@ func TestGenerateArchiveName(t *testing.T) {
 			},
 			expected: "test-2024-01-01-12-00=main=abc123-dirty.zip",
 		},
+		{
+			name: "archive with git describe",
+			config: ArchiveConfig{
+				Prefix:             "test",
+				Timestamp:          "2024-01-01-12-00",
+				IsGit:              true,
+				GitBranch:          "main",
+				GitHash:            "abc123",
+				GitDescribe:        "v1.4.0-3-gabc123-dirty",
+				ShowGitDirtyStatus: true,
+			},
+			expected: "test-2024-01-01-12-00=main=v1.4.0-3-gabc123-dirty.zip",
+		},
 		{
 			name: "archive with note only",
 			config: ArchiveConfig{
@@ -139,6 +155,26 @@ func TestGenerateArchiveName(t *testing.T) {
 			},
 			expected: "test-2024-01-01-10-00_update=2024-01-01-12-00=main=abc123-dirty.zip",
 		},
+		{
+			name: "archive with sequence suffix",
+			config: ArchiveConfig{
+				Prefix:    "test",
+				Timestamp: "2024-01-01-12-00",
+				Note:      "backup",
+				Sequence:  2,
+			},
+			expected: "test-2024-01-01-12-00_2=backup.zip",
+		},
+		{
+			name: "incremental archive with sequence suffix",
+			config: ArchiveConfig{
+				Timestamp:     "2024-01-01-12-00",
+				IsIncremental: true,
+				BaseName:      "test-2024-01-01-10-00_1.zip",
+				Sequence:      1,
+			},
+			expected: "test-2024-01-01-10-00_1_update=2024-01-01-12-00_1.zip",
+		},
 	}
 
 	for _, tt := range tests {
@@ -354,6 +390,35 @@ func TestCreateIncremental(t *testing.T) {
 	})
 }
 
+// ⭐ ARCH-003: Incremental archives use the per-directory archive directory - 🧪
+func TestCreateIncrementalCurrentDirName(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.UseCurrentDirName = true
+	cwd, _ := os.Getwd()
+	dirArchives := filepath.Join(archiveDir, filepath.Base(cwd))
+
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("b.txt", []byte("b"), 0o644)
+	if err := CreateIncrementalArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	archives, err := listArchiveEntries(dirArchives)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 {
+		t.Fatalf("%s holds %d archives, want the full and the incremental archive", dirArchives, len(archives))
+	}
+	if matches, _ := filepath.Glob(filepath.Join(archiveDir, "*.zip")); len(matches) != 0 {
+		t.Errorf("archives written to archive_dir_path itself: %v", matches)
+	}
+}
+
 func TestSkipBrokenSymlinks(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "bkpdir_symlink_test")
@@ -411,3 +476,49 @@ func TestSkipBrokenSymlinks(t *testing.T) {
 
 	zipw2.Close()
 }
+
+// TestFindBaseFullArchive tests base selection for incremental archives
+func TestFindBaseFullArchive(t *testing.T) {
+	// ⭐ ARCH-007: Explicit incremental base validation - 🔍
+	archiveDir := t.TempDir()
+	older := "proj-2024-01-01-10-00.zip"
+	newer := "proj-2024-02-01-10-00.zip"
+	inc := "proj-2024-01-01-10-00_update=2024-01-02-10-00.zip"
+	for i, name := range []string{older, newer, inc} {
+		path := filepath.Join(archiveDir, name)
+		if err := os.WriteFile(path, []byte("zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	latest, err := findBaseFullArchive(archiveDir, "", 2)
+	if err != nil || latest.Name != newer {
+		t.Fatalf("Expected latest full archive %s, got %v (%v)", newer, latest, err)
+	}
+	chosen, err := findBaseFullArchive(archiveDir, strings.TrimSuffix(older, ".zip"), 2)
+	if err != nil || chosen.Name != older {
+		t.Fatalf("Expected chosen base %s, got %v (%v)", older, chosen, err)
+	}
+
+	for _, base := range []string{inc, "missing.zip", "../" + older} {
+		_, err := findBaseFullArchive(archiveDir, base, 2)
+		var archiveErr *ArchiveError
+		if !errors.As(err, &archiveErr) || archiveErr.StatusCode != 2 {
+			t.Errorf("Expected config error for base %q, got %v", base, err)
+		}
+	}
+
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range archives {
+		if a.Name == inc && a.BaseArchive != older {
+			t.Errorf("BaseArchive = %q, want %q", a.BaseArchive, older)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// This file is part of bkpdir
+//
+// Package main provides the operation audit log: an append-only JSON lines
+// file recording who created, pruned, restored or reconfigured backups. Each
+// entry carries the SHA-256 of the previous one, so `bkpdir audit verify`
+// detects entries that were edited, inserted or removed after the fact.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ AUDIT-001: Audited actions - 🔧
+const (
+	AuditActionCreate    = "create"
+	AuditActionTrash     = "trash"
+	AuditActionPrune     = "prune"
+	AuditActionRestore   = "restore"
+	AuditActionTier      = "tier"
+	AuditActionConfigSet = "config-set"
+)
+
+// Outcomes of audited actions.
+const (
+	AuditOutcomeOK     = "ok"
+	AuditOutcomeFailed = "failed"
+)
+
+// auditLogName is the audit log in the .metadata directory of archive_dir_path.
+const auditLogName = "audit.jsonl"
+
+// ⭐ AUDIT-001: Audit log entry - 📝
+// AuditEntry is one line of the audit log. Hash is the SHA-256 of the entry
+// encoded without it, and Prev the Hash of the entry before, empty for the
+// first one.
+type AuditEntry struct {
+	Seq     int       `json:"seq"`
+	Time    time.Time `json:"time"`
+	Action  string    `json:"action"`
+	Target  string    `json:"target"`
+	User    string    `json:"user"`
+	Host    string    `json:"host"`
+	Outcome string    `json:"outcome"`
+	Detail  string    `json:"detail,omitempty"`
+	Prev    string    `json:"prev"`
+	Hash    string    `json:"hash,omitempty"`
+}
+
+// digest returns the hash of the entry with its Hash field cleared.
+func (e AuditEntry) digest() (string, error) {
+	e.Hash = ""
+	data, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// auditLogPath returns the audit log of cfg. It is kept under
+// archive_dir_path itself, so one log covers every archived directory.
+func auditLogPath(cfg *Config) string {
+	return filepath.Join(cfg.ArchiveDirPath, ".metadata", auditLogName)
+}
+
+// auditActor returns the user and host recorded with each entry.
+func auditActor() (string, string) {
+	name := os.Getenv("USER")
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	host, _ := os.Hostname()
+	return name, host
+}
+
+// ⭐ AUDIT-001: Audit recording - 🔧
+// recordAudit appends the outcome of action on target to the audit log when
+// audit_log is enabled. A failure only produces a warning, like lifecycle
+// events, because the action itself has already happened.
+func recordAudit(cfg *Config, action, target, detail string, actionErr error) {
+	if cfg == nil || !cfg.AuditLog || isRemoteLocation(cfg.ArchiveDirPath) {
+		return
+	}
+	entry := AuditEntry{
+		Time:    time.Now().UTC(),
+		Action:  action,
+		Target:  target,
+		Outcome: AuditOutcomeOK,
+		Detail:  detail,
+	}
+	entry.User, entry.Host = auditActor()
+	if actionErr != nil {
+		entry.Outcome = AuditOutcomeFailed
+		entry.Detail = strings.TrimPrefix(detail+"; "+actionErr.Error(), "; ")
+	}
+	if err := appendAuditEntry(auditLogPath(cfg), entry); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record %s in the audit log: %v\n", action, err)
+	} else {
+		// ⭐ SUDO-OWNER-001: Keep the log writable without sudo
+		applyInvokingUserOwnership(cfg, auditLogPath(cfg))
+	}
+}
+
+// appendAuditEntry chains entry to the last entry of the log at path and
+// appends it. The file is only ever opened for appending.
+func appendAuditEntry(path string, entry AuditEntry) error {
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	if err := fileops.CheckWrite(path); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	data, err := io.ReadAll(f)
+	if err != nil {
+		return err
+	}
+	if last := lastAuditLine(data); last != nil {
+		var prev AuditEntry
+		if err := json.Unmarshal(last, &prev); err != nil || prev.Hash == "" {
+			return errors.New("the last entry is damaged; run 'bkpdir audit verify'")
+		}
+		entry.Seq, entry.Prev = prev.Seq+1, prev.Hash
+	} else {
+		entry.Seq = 1
+	}
+	if entry.Hash, err = entry.digest(); err != nil {
+		return err
+	}
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	if len(data) > 0 && data[len(data)-1] != '\n' {
+		line = append([]byte{'\n'}, line...)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	return f.Sync()
+}
+
+// lastAuditLine returns the last non-empty line of data, or nil.
+func lastAuditLine(data []byte) []byte {
+	lines := bytes.Split(bytes.TrimRight(data, "\n"), []byte("\n"))
+	for i := len(lines) - 1; i >= 0; i-- {
+		if len(bytes.TrimSpace(lines[i])) > 0 {
+			return lines[i]
+		}
+	}
+	return nil
+}
+
+// ⭐ AUDIT-001: Audit log loading - 🔧
+// LoadAuditLog reads the entries of the log at path, oldest first. A missing
+// log has no entries. It does not check the chain; see VerifyAuditLog.
+func LoadAuditLog(path string) ([]AuditEntry, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit log: %w", err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	err = scanAuditLog(f, func(line int, raw []byte) error {
+		var e AuditEntry
+		if err := json.Unmarshal(raw, &e); err != nil {
+			return fmt.Errorf("failed to decode audit log line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+		return nil
+	})
+	return entries, err
+}
+
+// scanAuditLog calls fn with each non-empty line of r and its line number.
+func scanAuditLog(r io.Reader, fn func(line int, raw []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		if err := fn(line, scanner.Bytes()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+// ⭐ AUDIT-001: Audit chain verification - 🛡️
+// VerifyAuditLog checks every entry of the log at path: its hash, its link to
+// the entry before and its sequence number. It returns the number of entries
+// and the hash of the last one, which can be kept elsewhere to also detect
+// entries removed from the end.
+func VerifyAuditLog(path string) (int, string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	var prev AuditEntry
+	count := 0
+	err = scanAuditLog(f, func(line int, raw []byte) error {
+		dec := json.NewDecoder(bytes.NewReader(raw))
+		dec.DisallowUnknownFields()
+		var e AuditEntry
+		if err := dec.Decode(&e); err != nil {
+			return fmt.Errorf("line %d cannot be decoded: %w", line, err)
+		}
+		digest, err := e.digest()
+		if err != nil {
+			return err
+		}
+		switch {
+		case e.Hash != digest:
+			return fmt.Errorf("line %d (entry %d) was modified: its hash does not match its content", line, e.Seq)
+		case e.Prev != prev.Hash:
+			return fmt.Errorf("line %d (entry %d) does not follow entry %d: entries were removed, inserted or reordered", line, e.Seq, prev.Seq)
+		case e.Seq != prev.Seq+1:
+			return fmt.Errorf("line %d has sequence number %d, want %d", line, e.Seq, prev.Seq+1)
+		}
+		prev = e
+		count++
+		return nil
+	})
+	return count, prev.Hash, err
+}
+
+// AuditOptions configures `bkpdir audit show`.
+type AuditOptions struct {
+	Config *Config
+	Output io.Writer
+	Action string // Only show entries of this action
+	Limit  int    // Only show the newest entries; 0 shows all
+}
+
+// ⭐ AUDIT-001: Audit log display - 🔍
+// ShowAuditLog prints the entries of the audit log, oldest first.
+func ShowAuditLog(opts AuditOptions) error {
+	cfg := opts.Config
+	path := auditLogPath(cfg)
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return missingAuditLogError(cfg, path, err)
+	}
+	entries, err := LoadAuditLog(path)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to read audit log", 1, err)
+	}
+	if entries == nil {
+		fmt.Fprintf(opts.Output, "No actions recorded yet in %s\n", path)
+		return nil
+	}
+	var shown []AuditEntry
+	for _, e := range entries {
+		if opts.Action == "" || e.Action == opts.Action {
+			shown = append(shown, e)
+		}
+	}
+	if opts.Limit > 0 && len(shown) > opts.Limit {
+		shown = shown[len(shown)-opts.Limit:]
+	}
+	for _, e := range shown {
+		fmt.Fprintf(opts.Output, "%5d  %s  %-10s  %-6s  %s@%s  %s\n", e.Seq,
+			e.Time.Local().Format("2006-01-02 15:04:05"), e.Action, e.Outcome, e.User, e.Host, e.Target)
+		if e.Detail != "" {
+			fmt.Fprintf(opts.Output, "       %s\n", e.Detail)
+		}
+	}
+	return nil
+}
+
+// ⭐ AUDIT-001: Audit log verification command - 🛡️
+// CheckAuditLog verifies the audit log of cfg and reports the result.
+func CheckAuditLog(cfg *Config, w io.Writer) error {
+	path := auditLogPath(cfg)
+	count, head, err := VerifyAuditLog(path)
+	if os.IsNotExist(err) {
+		return missingAuditLogError(cfg, path, err)
+	}
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Audit log %s failed verification", path), 1, err)
+	}
+	if count == 0 {
+		fmt.Fprintf(w, "Audit log %s is empty\n", path)
+		return nil
+	}
+	fmt.Fprintf(w, "Audit log %s verified: %d entries, last hash %s\n", path, count, head)
+	return nil
+}
+
+// missingAuditLogError is the error audit show and audit verify return
+// when no audit log exists at path.
+func missingAuditLogError(cfg *Config, path string, err error) error {
+	msg := fmt.Sprintf("No audit log at %s", path)
+	if !cfg.AuditLog {
+		msg += "; set audit_log: true to record one"
+	}
+	return NewArchiveErrorWithCause(msg, cfg.StatusFileNotFound, err)
+}
+
+// auditConfigValue returns value as recorded for key, hiding secrets.
+func auditConfigValue(key, value string) string {
+	lower := strings.ToLower(key)
+	for _, word := range []string{"passphrase", "password", "secret", "token", "key"} {
+		if strings.Contains(lower, word) && !strings.HasPrefix(value, "secretref:") {
+			return "(redacted)"
+		}
+	}
+	return value
+}
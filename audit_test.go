@@ -0,0 +1,103 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the operation audit log.
+// It verifies that audited actions are appended as a hash chain and that
+// edited or removed entries are detected.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+// ⭐ AUDIT-001: Recording and chaining actions - 🧪
+func TestAuditLogChain(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.AuditLog = true
+	if err := os.WriteFile("notes.txt", []byte("audited"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	recordAudit(cfg, AuditActionConfigSet, ".bkpdir.yml", "encryption.passphrase = "+auditConfigValue("encryption.passphrase", "hunter2"), nil)
+	recordAudit(cfg, AuditActionRestore, "project.zip", "to /tmp/out", errors.New("disk full"))
+
+	path := auditLogPath(cfg)
+	entries, err := LoadAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 3 || entries[0].Action != AuditActionCreate || entries[2].Outcome != AuditOutcomeFailed {
+		t.Fatalf("entries = %+v", entries)
+	}
+	if entries[0].Prev != "" || entries[1].Prev != entries[0].Hash || entries[2].Seq != 3 {
+		t.Errorf("entries are not chained: %+v", entries)
+	}
+	if strings.Contains(entries[1].Detail, "hunter2") {
+		t.Errorf("secret value recorded: %q", entries[1].Detail)
+	}
+
+	count, head, err := VerifyAuditLog(path)
+	if err != nil || count != 3 || head != entries[2].Hash {
+		t.Fatalf("VerifyAuditLog = %d, %q, %v", count, head, err)
+	}
+	var out bytes.Buffer
+	if err := ShowAuditLog(AuditOptions{Config: cfg, Output: &out, Action: AuditActionRestore}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "disk full") || strings.Contains(out.String(), AuditActionCreate) {
+		t.Errorf("show --action restore:\n%s", out.String())
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.SplitAfter(strings.TrimSuffix(string(data), "\n"), "\n")
+
+	// An edited outcome breaks the hash of its entry
+	edited := strings.Replace(string(data), `"outcome":"failed"`, `"outcome":"ok"`, 1)
+	if err := os.WriteFile(path, []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := VerifyAuditLog(path); err == nil || !strings.Contains(err.Error(), "modified") {
+		t.Errorf("edited entry: %v", err)
+	}
+
+	// A removed entry breaks the link of the entry after it
+	removed := lines[0] + lines[2]
+	if err := os.WriteFile(path, []byte(removed), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := VerifyAuditLog(path); err == nil || !strings.Contains(err.Error(), "removed") {
+		t.Errorf("removed entry: %v", err)
+	}
+}
+
+// ⭐ AUDIT-001: Audit log disabled by default - 🧪
+func TestAuditLogDisabled(t *testing.T) {
+	cfg := uploadTestConfig(t, t.TempDir())
+	recordAudit(cfg, AuditActionCreate, "project.zip", "", nil)
+	if _, err := os.Stat(auditLogPath(cfg)); !os.IsNotExist(err) {
+		t.Errorf("audit log written without audit_log: %v", err)
+	}
+	showErr := ShowAuditLog(AuditOptions{Config: cfg, Output: &bytes.Buffer{}})
+	if showErr == nil {
+		t.Fatal("show without an audit log should point at audit_log")
+	}
+	verifyErr := CheckAuditLog(cfg, &bytes.Buffer{})
+	if verifyErr == nil {
+		t.Fatal("verify without an audit log should fail")
+	}
+	for name, err := range map[string]error{"show": showErr, "verify": verifyErr} {
+		var archiveErr *ArchiveError
+		if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusFileNotFound {
+			t.Errorf("%s without an audit log: got %v, want status %d", name, err, cfg.StatusFileNotFound)
+		}
+	}
+}
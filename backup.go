@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bkpdir/pkg/fileops"
 	"bkpdir/pkg/formatter"
 	"context"
 	"fmt"
@@ -122,6 +123,10 @@ type BackupOptions struct {
 	FilePath  string
 	Note      string
 	DryRun    bool
+
+	// ⭐ ENCRYPT-001: Resolved by the backup operation
+	key     *encryptionKey
+	encrypt bool
 }
 
 // ⭐ FILE-002: File backup creation implementation - 🔧
@@ -160,6 +165,10 @@ func createFileBackupInternal(opts BackupOptions) error {
 	}
 
 	if opts.DryRun {
+		// ⭐ ARCH-008: Show the name the backup would actually get
+		if backupPath, err = claimBackupPath(backupPath, opts.Note, false); err != nil {
+			return err
+		}
 		return handleDryRunBackup(opts.Formatter, backupPath)
 	}
 
@@ -210,8 +219,15 @@ func performBackupOperation(opts BackupOptions, backupPath string) error {
 	backupDir := filepath.Dir(backupPath)
 	baseFilename := filepath.Base(opts.FilePath)
 
+	// ⭐ ENCRYPT-001: Resolve the key before comparing with encrypted backups
+	var err error
+	if opts.key, opts.encrypt, err = fileBackupKey(opts.Config); err != nil {
+		return err
+	}
+
 	// Check for identical backup
-	if err := checkAndHandleIdenticalBackup(opts, backupDir, baseFilename); err != nil {
+	revision, err := checkAndHandleIdenticalBackup(opts, backupDir, baseFilename)
+	if err != nil {
 		return err
 	}
 
@@ -220,6 +236,14 @@ func performBackupOperation(opts BackupOptions, backupPath string) error {
 		return err
 	}
 
+	// ⭐ ARCH-008: Backups within the same minute get a sequence suffix
+	backupPath, err = claimBackupPath(backupPath, opts.Note, true)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to reserve backup name", opts.Config.StatusDiskFull, err)
+	}
+	if revision != nil {
+		return createMetadataRevision(opts, backupPath, revision.content, revision.current, revision.changes)
+	}
 	return executeBackupWithCleanup(opts, backupPath)
 }
 
@@ -227,10 +251,27 @@ func performBackupOperation(opts BackupOptions, backupPath string) error {
 // IMMUTABLE-REF: File Backup Operations, Identical File Detection
 // TEST-REF: TestCheckForIdenticalFileBackup
 // DECISION-REF: DEC-002
-// checkAndHandleIdenticalBackup checks if file is identical to existing backup
-func checkAndHandleIdenticalBackup(opts BackupOptions, backupDir, baseFilename string) error {
-	identical, existingBackup, err := CheckForIdenticalFileBackup(opts.FilePath, backupDir, baseFilename)
+// checkAndHandleIdenticalBackup checks if file is identical to existing backup.
+// It returns the metadata revision to store instead of a backup when only
+// the metadata of the file changed.
+func checkAndHandleIdenticalBackup(opts BackupOptions, backupDir, baseFilename string) (*metadataRevision, error) {
+	// ⭐ FILE-COMPARE-001: file_compare decides how the contents are compared
+	mode, err := fileCompareMode(opts.Config)
+	if err != nil {
+		return nil, err
+	}
+	identical, existingBackup, err := checkForIdenticalFileBackup(opts.FilePath, backupDir, baseFilename, opts.key, mode)
 	if err == nil && identical {
+		// ⭐ META-BACKUP-001: Changed metadata of identical content is a metadata revision
+		if opts.Config.BackupMetadataChanges {
+			changes, current, err := metadataChanges(opts.FilePath, existingBackup)
+			if err != nil {
+				return nil, NewArchiveErrorWithCause("Failed to read file metadata", 1, err)
+			}
+			if len(changes) > 0 {
+				return &metadataRevision{content: existingBackup, current: current, changes: changes}, nil
+			}
+		}
 		if opts.Formatter != nil {
 			opts.Formatter.PrintIdenticalBackup(existingBackup)
 		} else {
@@ -241,7 +282,15 @@ func checkAndHandleIdenticalBackup(opts BackupOptions, backupDir, baseFilename s
 		}
 		os.Exit(opts.Config.StatusFileIsIdenticalToExistingBackup)
 	}
-	return nil
+	return nil, nil
+}
+
+// metadataRevision is a file identical to its most recent backup, content,
+// apart from its metadata.
+type metadataRevision struct {
+	content string
+	current *FileMetadata
+	changes []string
 }
 
 // ⭐ FILE-002: Atomic backup execution with cleanup - 🔧
@@ -249,7 +298,11 @@ func checkAndHandleIdenticalBackup(opts BackupOptions, backupDir, baseFilename s
 // TEST-REF: TestCreateFileBackupWithCleanup
 // DECISION-REF: DEC-002
 // executeBackupWithCleanup performs backup with resource cleanup
-func executeBackupWithCleanup(opts BackupOptions, backupPath string) error {
+func executeBackupWithCleanup(opts BackupOptions, backupPath string) (err error) {
+	// ⭐ LAST-001: Record the run for `bkpdir last`
+	run := startRunRecord(opts.Config, RunKindBackup, opts.FilePath, false)
+	defer func() { run.finish(backupPath, err) }()
+
 	// Create resource manager for cleanup
 	rm := NewResourceManager()
 	defer rm.CleanupWithPanicRecovery()
@@ -259,17 +312,30 @@ func executeBackupWithCleanup(opts BackupOptions, backupPath string) error {
 	rm.AddTempFile(tempFile)
 
 	// Copy file to backup location
-	if err := copyFile(opts.FilePath, tempFile); err != nil {
+	// ⭐ ENCRYPT-001: Encrypted backups are sealed while copying
+	copyBackup := copyFile
+	if opts.encrypt {
+		copyBackup = func(src, dst string) error { return encryptFile(src, dst, opts.key) }
+	}
+	if err := copyBackup(opts.FilePath, tempFile); err != nil {
 		return NewArchiveErrorWithCause("Failed to create backup", opts.Config.StatusDiskFull, err)
 	}
 
 	// Atomic rename
-	if err := os.Rename(tempFile, backupPath); err != nil {
+	if err := fileops.Rename(tempFile, backupPath); err != nil {
 		return NewArchiveErrorWithCause("Failed to finalize backup", opts.Config.StatusDiskFull, err)
 	}
 
 	// Remove from cleanup list since operation succeeded
 	rm.RemoveResource(&TempFile{Path: tempFile})
+	// ⭐ FILE-COMPARE-001: Record the checksum that later comparisons use
+	if !opts.encrypt {
+		recordFileBackupChecksum(opts.Config, backupPath)
+	}
+	// ⭐ META-BACKUP-001: and the metadata that metadata revisions compare with
+	recordBackupMetadata(opts.Config, opts.FilePath, backupPath)
+	// ⭐ SUDO-OWNER-001: Backups created through sudo belong to the invoking user
+	applyInvokingUserOwnership(opts.Config, backupPath)
 
 	// Create formatter for output (fallback since this function doesn't have direct access to opts.Formatter)
 	formatter := NewOutputFormatter(opts.Config)
@@ -284,6 +350,13 @@ func CreateFileBackupEnhanced(opts BackupOptions) error {
 
 // CheckForIdenticalFileBackup checks if the file is identical to the most recent backup
 func CheckForIdenticalFileBackup(filePath, backupDir, baseFilename string) (bool, string, error) {
+	return checkForIdenticalFileBackup(filePath, backupDir, baseFilename, nil, FileCompareBytes)
+}
+
+// checkForIdenticalFileBackup compares the file with the most recent backup,
+// decrypting it with key when it is encrypted. Without a key an encrypted
+// backup never matches. Unencrypted backups are compared with mode.
+func checkForIdenticalFileBackup(filePath, backupDir, baseFilename string, key *encryptionKey, mode string) (bool, string, error) {
 	// Find most recent backup for this file
 	backups, err := ListFileBackups(backupDir, baseFilename)
 	if err != nil || len(backups) == 0 {
@@ -293,6 +366,15 @@ func CheckForIdenticalFileBackup(filePath, backupDir, baseFilename string) (bool
 	// Get the most recent backup
 	mostRecent := backups[0]
 
+	// ⭐ ENCRYPT-001: Encrypted backups are compared by their plaintext
+	if isEncryptedBackup(mostRecent.Path) {
+		if key == nil {
+			return false, "", nil
+		}
+		identical, err := sameBackupContent(filePath, mostRecent.Path, key)
+		return identical, mostRecent.Path, err
+	}
+
 	// Compare file sizes first (quick check)
 	fileInfo, err := os.Stat(filePath)
 	if err != nil {
@@ -309,7 +391,8 @@ func CheckForIdenticalFileBackup(filePath, backupDir, baseFilename string) (bool
 	}
 
 	// Compare file contents
-	identical, err := compareFiles(filePath, mostRecent.Path)
+	// ⭐ FILE-COMPARE-001: by modification time or recorded checksum when allowed
+	identical, err := sameFileBackup(filePath, mostRecent.Path, mode)
 	if err != nil {
 		return false, "", err
 	}
@@ -373,21 +456,9 @@ func ListFileBackups(backupDir, baseFilename string) ([]BackupInfo, error) {
 func ListFileBackupsEnhanced(cfg *Config, formatter formatter.OutputFormatterInterface, filePath string) error {
 	baseFilename := filepath.Base(filePath)
 
-	backupDir := cfg.BackupDirPath
-	if cfg.UseCurrentDirNameForFiles {
-		cwd, err := os.Getwd()
-		if err != nil {
-			return NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
-		}
-
-		// Maintain directory structure in backup path
-		relPath, err := filepath.Rel(cwd, filePath)
-		if err != nil {
-			// If we can't get relative path, use absolute path structure
-			relPath = strings.TrimPrefix(filePath, "/")
-		}
-
-		backupDir = filepath.Join(backupDir, filepath.Dir(relPath))
+	backupDir, err := fileBackupDir(cfg, filePath)
+	if err != nil {
+		return err
 	}
 
 	backups, err := ListFileBackups(backupDir, baseFilename)
@@ -407,13 +478,17 @@ func ListFileBackupsEnhanced(cfg *Config, formatter formatter.OutputFormatterInt
 
 	for _, backup := range backups {
 		creationTime := backup.CreationTime.Format("2006-01-02 15:04:05")
+		var output string
 		if formatterAdapter, ok := formatter.(*FormatterAdapter); ok {
-			output := formatterAdapter.FormatListBackupWithExtraction(backup.Path, creationTime)
-			fmt.Print(output)
+			output = formatterAdapter.FormatListBackupWithExtraction(backup.Path, creationTime)
 		} else {
-			output := formatter.FormatListBackup(backup.Path, creationTime)
-			fmt.Print(output)
+			output = formatter.FormatListBackup(backup.Path, creationTime)
+		}
+		// ⭐ META-BACKUP-001: Metadata revisions are marked
+		if note := metadataRevisionNote(backup.Path); note != "" {
+			output = strings.TrimSuffix(output, "\n") + note + "\n"
 		}
+		fmt.Print(output)
 	}
 
 	return nil
@@ -427,7 +502,7 @@ func copyFile(src, dst string) error {
 	}
 	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
+	destFile, err := fileops.Create(dst)
 	if err != nil {
 		return err
 	}
@@ -444,7 +519,7 @@ func copyFile(src, dst string) error {
 		return err
 	}
 
-	return os.Chmod(dst, sourceInfo.Mode())
+	return fileops.Chmod(dst, sourceInfo.Mode())
 }
 
 // compareFiles compares two files byte by byte with simplified logic
@@ -527,9 +602,17 @@ func validateFileForBackup(filePath string, cfg *Config) error {
 	return nil
 }
 
-// determineBackupPath determines the backup directory and filename
-func determineBackupPath(cfg *Config, filePath string) (string, error) {
+// fileBackupDir returns the directory that holds the backups of filePath.
+func fileBackupDir(cfg *Config, filePath string) (string, error) {
 	backupDir := cfg.BackupDirPath
+
+	// ⭐ HOME-REL-001: Files in the home directory are keyed by their home-relative path
+	if cfg.UseHomeRelativePaths {
+		if relPath, ok := homeRelativePath(filePath); ok {
+			return filepath.Join(homeBackupRoot(backupDir), relPath), nil
+		}
+	}
+
 	if cfg.UseCurrentDirNameForFiles {
 		cwd, err := os.Getwd()
 		if err != nil {
@@ -545,10 +628,59 @@ func determineBackupPath(cfg *Config, filePath string) (string, error) {
 
 		backupDir = filepath.Join(backupDir, filepath.Dir(relPath))
 	}
+	return backupDir, nil
+}
+
+// homeBackupRoot resolves backup_dir_path for home-relative backups. A ~/
+// or relative path is taken from the home directory, so the location does
+// not depend on where bkpdir runs.
+func homeBackupRoot(backupDirPath string) string {
+	backupDir := expandPath(backupDirPath)
+	if home, err := os.UserHomeDir(); err == nil && !filepath.IsAbs(backupDir) {
+		backupDir = filepath.Join(home, backupDir)
+	}
+	return backupDir
+}
+
+// ⭐ HOME-REL-001: Home-relative backup location - 🔧
+// homeRelativePath returns the path of filePath relative to the home
+// directory with leading dots removed from each element, so ~/.zshrc maps to
+// "zshrc" and ~/.config/git/config to "config/git/config". It reports false
+// for files outside the home directory.
+func homeRelativePath(filePath string) (string, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return "", false
+	}
+	relPath, err := filepath.Rel(filepath.Clean(home), absPath)
+	if err != nil || relPath == "." || relPath == ".." || strings.HasPrefix(relPath, ".."+string(filepath.Separator)) {
+		return "", false
+	}
+
+	parts := strings.Split(relPath, string(filepath.Separator))
+	for i, part := range parts {
+		if trimmed := strings.TrimLeft(part, "."); trimmed != "" {
+			parts[i] = trimmed
+		}
+	}
+	return filepath.Join(parts...), true
+}
+
+// determineBackupPath determines the backup directory and filename
+func determineBackupPath(cfg *Config, filePath string) (string, error) {
+	backupDir, err := fileBackupDir(cfg, filePath)
+	if err != nil {
+		return "", err
+	}
 
 	// Generate backup filename
 	baseFilename := filepath.Base(filePath)
-	timestamp := time.Now().Format("2006-01-02-15-04")
+	// ⭐ ARCH-005: Backups share the archive naming clock
+	timestamp := FormatNameTimestamp(cfg.TimestampTimezone, cfg.TimestampFormat, time.Now())
 	backupFilename := fmt.Sprintf("%s-%s", baseFilename, timestamp)
 
 	return filepath.Join(backupDir, backupFilename), nil
@@ -586,6 +718,10 @@ func createFileBackupWithContextInternal(opts BackupOptions) error {
 	}
 
 	if opts.DryRun {
+		// ⭐ ARCH-008: Show the name the backup would actually get
+		if backupPath, err = claimBackupPath(backupPath, opts.Note, false); err != nil {
+			return err
+		}
 		return handleDryRunBackup(opts.Formatter, backupPath)
 	}
 
@@ -597,8 +733,15 @@ func performContextAwareBackup(opts BackupOptions, backupPath string) error {
 	backupDir := filepath.Dir(backupPath)
 	baseFilename := filepath.Base(opts.FilePath)
 
+	// ⭐ ENCRYPT-001: Resolve the key before comparing with encrypted backups
+	var err error
+	if opts.key, opts.encrypt, err = fileBackupKey(opts.Config); err != nil {
+		return err
+	}
+
 	// Check for identical backup
-	if err := checkAndHandleIdenticalBackup(opts, backupDir, baseFilename); err != nil {
+	revision, err := checkAndHandleIdenticalBackup(opts, backupDir, baseFilename)
+	if err != nil {
 		return err
 	}
 
@@ -607,11 +750,23 @@ func performContextAwareBackup(opts BackupOptions, backupPath string) error {
 		return err
 	}
 
+	// ⭐ ARCH-008: Backups within the same minute get a sequence suffix
+	backupPath, err = claimBackupPath(backupPath, opts.Note, true)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to reserve backup name", opts.Config.StatusDiskFull, err)
+	}
+	if revision != nil {
+		return createMetadataRevision(opts, backupPath, revision.content, revision.current, revision.changes)
+	}
 	return executeContextAwareBackup(opts, backupPath)
 }
 
 // executeContextAwareBackup performs the backup with context support
-func executeContextAwareBackup(opts BackupOptions, backupPath string) error {
+func executeContextAwareBackup(opts BackupOptions, backupPath string) (err error) {
+	// ⭐ LAST-001: Record the run for `bkpdir last`
+	run := startRunRecord(opts.Config, RunKindBackup, opts.FilePath, false)
+	defer func() { run.finish(backupPath, err) }()
+
 	// Create resource manager for cleanup
 	rm := NewResourceManager()
 	defer rm.CleanupWithPanicRecovery()
@@ -621,17 +776,30 @@ func executeContextAwareBackup(opts BackupOptions, backupPath string) error {
 	rm.AddTempFile(tempFile)
 
 	// Copy file to backup location with context
-	if err := CopyFileWithContext(opts.Context, opts.FilePath, tempFile); err != nil {
+	// ⭐ ENCRYPT-001: Encrypted backups are sealed while copying
+	copyBackup := func(src, dst string) error { return CopyFileWithContext(opts.Context, src, dst) }
+	if opts.encrypt {
+		copyBackup = func(src, dst string) error { return encryptFile(src, dst, opts.key) }
+	}
+	if err := copyBackup(opts.FilePath, tempFile); err != nil {
 		return NewArchiveErrorWithCause("Failed to create backup", opts.Config.StatusDiskFull, err)
 	}
 
 	// Atomic rename
-	if err := os.Rename(tempFile, backupPath); err != nil {
+	if err := fileops.Rename(tempFile, backupPath); err != nil {
 		return NewArchiveErrorWithCause("Failed to finalize backup", opts.Config.StatusDiskFull, err)
 	}
 
 	// Remove from cleanup list since operation succeeded
 	rm.RemoveResource(&TempFile{Path: tempFile})
+	// ⭐ FILE-COMPARE-001: Record the checksum that later comparisons use
+	if !opts.encrypt {
+		recordFileBackupChecksum(opts.Config, backupPath)
+	}
+	// ⭐ META-BACKUP-001: and the metadata that metadata revisions compare with
+	recordBackupMetadata(opts.Config, opts.FilePath, backupPath)
+	// ⭐ SUDO-OWNER-001: Backups created through sudo belong to the invoking user
+	applyInvokingUserOwnership(opts.Config, backupPath)
 
 	// Create formatter for output (fallback since this function doesn't have direct access to opts.Formatter)
 	formatter := NewOutputFormatter(opts.Config)
@@ -658,7 +826,7 @@ func CopyFileWithContext(ctx context.Context, src, dst string) error {
 	}
 	defer sourceFile.Close()
 
-	destFile, err := os.Create(dst)
+	destFile, err := fileops.Create(dst)
 	if err != nil {
 		return err
 	}
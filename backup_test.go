@@ -937,3 +937,50 @@ func testPermissionDeniedDuringComparison(t *testing.T) {
 		t.Error("Should not report identical when backup file is not readable")
 	}
 }
+
+// ⭐ HOME-REL-001: Home-relative backup locations - 🧪
+func TestHomeRelativeBackupDir(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	if err := os.MkdirAll(filepath.Join(home, ".config", "git"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.BackupDirPath = "~/backups"
+	cfg.UseHomeRelativePaths = true
+
+	// The working directory must not change where dotfile backups go
+	originalWd, _ := os.Getwd()
+	defer os.Chdir(originalWd)
+	if err := os.Chdir(filepath.Join(home, ".config")); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		file string
+		want string
+	}{
+		{filepath.Join(home, ".zshrc"), filepath.Join(home, "backups", "zshrc")},
+		{"git/config", filepath.Join(home, "backups", "config", "git", "config")},
+	}
+	for _, tt := range tests {
+		got, err := fileBackupDir(cfg, tt.file)
+		if err != nil || got != tt.want {
+			t.Errorf("fileBackupDir(%s) = %s (%v), want %s", tt.file, got, err, tt.want)
+		}
+	}
+
+	path, err := determineBackupPath(cfg, filepath.Join(home, ".zshrc"))
+	if err != nil || filepath.Dir(path) != filepath.Join(home, "backups", "zshrc") ||
+		!strings.HasPrefix(filepath.Base(path), ".zshrc-") {
+		t.Errorf("determineBackupPath = %s (%v)", path, err)
+	}
+
+	// Files outside the home directory keep the working-directory layout
+	outside := filepath.Join(t.TempDir(), "hosts")
+	cfg.UseCurrentDirNameForFiles = false
+	if got, err := fileBackupDir(cfg, outside); err != nil || got != cfg.BackupDirPath {
+		t.Errorf("Expected the plain backup directory for %s, got %s (%v)", outside, got, err)
+	}
+}
@@ -0,0 +1,313 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir bench`, which measures how fast this machine
+// reads the current directory, deflates it at each compression level and
+// computes checksums, and recommends settings from the results.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"context"
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+const (
+	// defaultBenchSize is how much of the directory bench reads.
+	defaultBenchSize = "256MB"
+	// benchSampleLimit caps the data kept in memory for the compression and
+	// checksum measurements.
+	benchSampleLimit = 32 << 20
+	// benchMinDuration is how long each compression and checksum measurement
+	// repeats over the sample, so small samples still give stable rates.
+	benchMinDuration = 200 * time.Millisecond
+	// benchDefaultLevel is the level flate.DefaultCompression stands for.
+	benchDefaultLevel = 6
+)
+
+// benchLevels are the compression levels bench measures.
+var benchLevels = []int{flate.NoCompression, flate.BestSpeed, 3, benchDefaultLevel, flate.BestCompression}
+
+// benchChecksums are the checksum algorithms accepted by
+// verification.checksum_algorithm.
+var benchChecksums = []struct {
+	Name string
+	New  func() hash.Hash
+}{
+	{"sha256", sha256.New},
+	{"sha1", sha1.New},
+	{"md5", md5.New},
+}
+
+// BenchOptions holds the parameters of the bench command.
+type BenchOptions struct {
+	Config *Config
+	Output io.Writer
+	Dir    string // Directory read for the measurements
+	Size   string // Data read from Dir, e.g. "256MB"
+}
+
+// benchRate is an amount of data processed in a time.
+type benchRate struct {
+	Bytes   int64
+	Elapsed time.Duration
+}
+
+// perSecond returns the rate in bytes per second.
+func (r benchRate) perSecond() float64 {
+	if r.Elapsed <= 0 {
+		return 0
+	}
+	return float64(r.Bytes) / r.Elapsed.Seconds()
+}
+
+// String formats the rate like "412.3MB/s".
+func (r benchRate) String() string {
+	return formatHumanSize(int64(r.perSecond())) + "/s"
+}
+
+// compressionBench is the speed and ratio of one compression level.
+type compressionBench struct {
+	Level int
+	Rate  benchRate
+	Ratio float64 // Compressed size relative to the input
+}
+
+// checksumBench is the speed of one checksum algorithm.
+type checksumBench struct {
+	Algorithm string
+	Rate      benchRate
+}
+
+// benchReport holds the measurements of one bench run.
+type benchReport struct {
+	Dir         string
+	Files       int
+	Read        benchRate
+	Sample      int64
+	Compression []compressionBench
+	Checksums   []checksumBench
+	CPUs        int
+}
+
+// benchRecommendation is a recommended setting with the reason for it.
+type benchRecommendation struct {
+	Key    string
+	Value  string
+	Reason string
+}
+
+// ⭐ BENCH-001: Environment performance profile - 🔧
+// RunBench measures opts.Dir and prints the results and recommended settings.
+func RunBench(ctx context.Context, opts BenchOptions) error {
+	report, err := measureBench(ctx, opts)
+	if err != nil {
+		return err
+	}
+	printBenchReport(opts.Output, report, recommendBench(report))
+	return nil
+}
+
+// measureBench reads up to opts.Size of the files that would be archived and
+// runs the compression and checksum measurements on what it read.
+func measureBench(ctx context.Context, opts BenchOptions) (*benchReport, error) {
+	cfg := opts.Config
+	size := opts.Size
+	if size == "" {
+		size = defaultBenchSize
+	}
+	limit, err := ParseByteSize(size)
+	if err != nil || limit <= 0 {
+		return nil, NewArchiveError(fmt.Sprintf("Invalid --size %q", size), cfg.StatusConfigError)
+	}
+	// ⭐ MEMORY-001: The sample takes at most half of max_memory
+	sampleLimit := benchSampleLimit
+	if maxMemory, err := ParseByteSize(cfg.MaxMemory); err == nil && maxMemory > 0 {
+		sampleLimit = int(min(int64(sampleLimit), maxMemory/2))
+	}
+	files, err := collectFilesToArchive(ctx, opts.Dir, cfg.ExcludePatterns)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to list files", cfg.StatusDirectoryNotFound, err)
+	}
+
+	report := &benchReport{Dir: opts.Dir, CPUs: runtime.NumCPU()}
+	var sample bytes.Buffer
+	buf := make([]byte, 1<<20)
+	start := time.Now()
+	for _, rel := range files {
+		if report.Read.Bytes >= limit {
+			break
+		}
+		if err := checkContextCancellation(ctx); err != nil {
+			return nil, err
+		}
+		path := filepath.Join(opts.Dir, rel)
+		if info, err := os.Lstat(path); err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+		n, err := readBenchFile(path, buf, limit-report.Read.Bytes, &sample, sampleLimit)
+		if err != nil {
+			continue
+		}
+		report.Read.Bytes += n
+		report.Files++
+	}
+	report.Read.Elapsed = time.Since(start)
+	if sample.Len() == 0 {
+		return nil, NewArchiveError(fmt.Sprintf("No file data to measure in %s", opts.Dir), cfg.StatusDirectoryNotFound)
+	}
+	report.Sample = int64(sample.Len())
+
+	data := sample.Bytes()
+	for _, level := range benchLevels {
+		var compressed int64
+		rate := repeatBench(ctx, int64(len(data)), func() {
+			var counter countingWriter
+			w, _ := flate.NewWriter(&counter, level)
+			w.Write(data)
+			w.Close()
+			compressed = int64(counter)
+		})
+		report.Compression = append(report.Compression, compressionBench{
+			Level: level, Rate: rate, Ratio: float64(compressed) / float64(len(data)),
+		})
+	}
+	for _, alg := range benchChecksums {
+		rate := repeatBench(ctx, int64(len(data)), func() {
+			h := alg.New()
+			h.Write(data)
+			h.Sum(nil)
+		})
+		report.Checksums = append(report.Checksums, checksumBench{Algorithm: alg.Name, Rate: rate})
+	}
+	return report, nil
+}
+
+// readBenchFile reads at most max bytes of path, keeping the first
+// sampleLimit bytes of all files in sample.
+func readBenchFile(path string, buf []byte, max int64, sample *bytes.Buffer, sampleLimit int) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var total int64
+	for total < max {
+		chunk := buf
+		if rest := max - total; rest < int64(len(chunk)) {
+			chunk = chunk[:rest]
+		}
+		n, err := f.Read(chunk)
+		total += int64(n)
+		if room := sampleLimit - sample.Len(); room > 0 {
+			sample.Write(chunk[:min(n, room)])
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return total, err
+		}
+	}
+	return total, nil
+}
+
+// repeatBench runs fn, which processes size bytes, until benchMinDuration
+// has passed and returns the rate.
+func repeatBench(ctx context.Context, size int64, fn func()) benchRate {
+	var rate benchRate
+	start := time.Now()
+	for rate.Bytes == 0 || time.Since(start) < benchMinDuration {
+		fn()
+		rate.Bytes += size
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	rate.Elapsed = time.Since(start)
+	return rate
+}
+
+// ⭐ BENCH-001: Recommended settings - 🔍
+// recommendBench picks the highest compression level that keeps up with the
+// disk, or storing when the data barely compresses, keeps sha256 for
+// checksums and adaptive jobs on machines with several CPUs.
+func recommendBench(report *benchReport) []benchRecommendation {
+	var recs []benchRecommendation
+	byLevel := make(map[int]compressionBench)
+	for _, c := range report.Compression {
+		byLevel[c.Level] = c
+	}
+
+	read := report.Read.perSecond()
+	level, reason := flate.BestSpeed, "no level keeps up with the disk; this is the fastest that still compresses"
+	if def := byLevel[benchDefaultLevel]; def.Ratio > 0.95 {
+		level, reason = flate.NoCompression, fmt.Sprintf("the data only shrinks to %.0f%%, so storing it saves time", def.Ratio*100)
+	} else {
+		for _, c := range report.Compression {
+			if c.Level != flate.NoCompression && c.Rate.perSecond() >= read {
+				level, reason = c.Level, fmt.Sprintf("the highest level that keeps up with reading at %s", report.Read)
+			}
+		}
+	}
+	recs = append(recs, benchRecommendation{Key: "compression_level", Value: fmt.Sprint(level), Reason: reason})
+
+	for _, c := range report.Checksums {
+		if c.Algorithm != "sha256" {
+			continue
+		}
+		reason := "faster than reading the disk"
+		if c.Rate.perSecond() < read {
+			reason = "slower than reading the disk, but md5 and sha1 are not collision resistant"
+		}
+		recs = append(recs, benchRecommendation{Key: "verification.checksum_algorithm", Value: "sha256", Reason: reason})
+	}
+
+	// ⭐ JOBS-001: Jobs adapt to the throughput of each run
+	if report.CPUs > 1 {
+		recs = append(recs, benchRecommendation{Key: "archive_jobs", Value: ArchiveJobsAuto,
+			Reason: fmt.Sprintf("adjusts the jobs to the throughput while archiving, up to %d", maxAdaptiveJobs())})
+	} else {
+		recs = append(recs, benchRecommendation{Key: "archive_jobs", Value: "1", Reason: "a single CPU gains little from concurrent jobs"})
+	}
+	return recs
+}
+
+// printBenchReport writes the measurements and recommendations.
+func printBenchReport(w io.Writer, report *benchReport, recs []benchRecommendation) {
+	fmt.Fprintf(w, "Benchmark of %s (%d %s, %s read, CPUs: %d)\n\n",
+		report.Dir, report.Files, pluralFiles(report.Files), formatHumanSize(report.Read.Bytes), report.CPUs)
+	fmt.Fprintf(w, "Disk read        %12s\n\n", report.Read)
+
+	fmt.Fprintf(w, "Compression (deflate, %s sample)\n", formatHumanSize(report.Sample))
+	for _, c := range report.Compression {
+		label := fmt.Sprintf("level %d", c.Level)
+		if c.Level == flate.NoCompression {
+			label += " (store)"
+		}
+		fmt.Fprintf(w, "  %-14s %12s  %5.1f%% of the input\n", label, c.Rate, c.Ratio*100)
+	}
+
+	fmt.Fprintln(w, "\nChecksums")
+	for _, c := range report.Checksums {
+		fmt.Fprintf(w, "  %-14s %12s\n", c.Algorithm, c.Rate)
+	}
+
+	fmt.Fprintln(w, "\nRecommended settings")
+	for _, r := range recs {
+		fmt.Fprintf(w, "  %s: %s\n      %s\n", r.Key, r.Value, r.Reason)
+	}
+}
@@ -0,0 +1,80 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the bench command.
+// It verifies the measurements of a directory and the recommended settings.
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ BENCH-001: Measuring a directory - 🧪
+func TestRunBench(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "text.txt"), bytes.Repeat([]byte("bkpdir bench "), 20000), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "skipped.log"), []byte("excluded"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.ExcludePatterns = []string{"*.log"}
+
+	report, err := measureBench(context.Background(), BenchOptions{Config: cfg, Dir: dir, Size: "100KB"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Files != 1 || report.Read.Bytes != 100<<10 || report.Sample != 100<<10 {
+		t.Errorf("read %d files, %d bytes, sample %d", report.Files, report.Read.Bytes, report.Sample)
+	}
+	if len(report.Compression) != len(benchLevels) || len(report.Checksums) != len(benchChecksums) {
+		t.Fatalf("measurements = %+v", report)
+	}
+	if c := report.Compression[len(report.Compression)-1]; c.Ratio > 0.1 {
+		t.Errorf("repeated text compressed to %.2f at level 9", c.Ratio)
+	}
+
+	var out bytes.Buffer
+	if err := RunBench(context.Background(), BenchOptions{Config: cfg, Output: &out, Dir: dir}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "compression_level: ") || !strings.Contains(out.String(), "checksum_algorithm: sha256") {
+		t.Errorf("output:\n%s", out.String())
+	}
+
+	if _, err := measureBench(context.Background(), BenchOptions{Config: cfg, Dir: t.TempDir()}); err == nil {
+		t.Error("an empty directory should not be measured")
+	}
+}
+
+// ⭐ BENCH-001: Recommended compression level - 🧪
+func TestRecommendBench(t *testing.T) {
+	rate := func(mb int64) benchRate { return benchRate{Bytes: mb << 20, Elapsed: time.Second} }
+	report := &benchReport{
+		Read: rate(100),
+		Compression: []compressionBench{
+			{Level: 0, Rate: rate(5000), Ratio: 1},
+			{Level: 1, Rate: rate(300), Ratio: 0.5},
+			{Level: 3, Rate: rate(150), Ratio: 0.45},
+			{Level: 6, Rate: rate(60), Ratio: 0.4},
+			{Level: 9, Rate: rate(10), Ratio: 0.39},
+		},
+	}
+	if recs := recommendBench(report); recs[0].Value != "3" {
+		t.Errorf("compressible data: %+v", recs[0])
+	}
+	report.Read = rate(1000)
+	if recs := recommendBench(report); recs[0].Value != "1" {
+		t.Errorf("fast disk: %+v", recs[0])
+	}
+	report.Compression[3].Ratio = 0.99
+	if recs := recommendBench(report); recs[0].Value != "0" {
+		t.Errorf("incompressible data: %+v", recs[0])
+	}
+}
@@ -0,0 +1,234 @@
+// This file is part of bkpdir
+//
+// Package main checks the consistency of incremental chains: an incremental
+// archive only holds the files changed since its full archive, so it cannot
+// be restored on its own when that base was pruned, deleted or is corrupt.
+// `verify --chain` and `doctor` report such chains with the ways to repair
+// them.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// ⭐ CHAIN-CHECK-001: Chain problems - 🔧
+const (
+	// ChainBaseMissing means the full archive of the chain is gone.
+	ChainBaseMissing = "base missing"
+	// ChainBasePruned means the full archive of the chain is in the trash.
+	ChainBasePruned = "base in the trash"
+	// ChainBaseCorrupt means the full archive of the chain failed a check.
+	ChainBaseCorrupt = "base corrupt"
+)
+
+// chainUnusableTag is the annotation tag, set to chainUnusableValue, that
+// marks the incremental archives of a broken chain as known to be unusable.
+const (
+	chainUnusableTag   = "chain"
+	chainUnusableValue = "unusable"
+)
+
+// ⭐ CHAIN-CHECK-001: Chain check result - 🔍
+// ChainReport is the result of checking one incremental chain.
+type ChainReport struct {
+	Base         string   // Name of the full archive
+	Incrementals []string // Incremental archives left, oldest first
+	Problem      string   // One of the Chain* problems, "" for a usable base
+	Detail       string
+	// Corrupt lists the incremental archives that failed a check; each only
+	// holds its own changes, so the others stay usable
+	Corrupt []string
+	// Unusable is set when every incremental archive of a broken chain is
+	// annotated as unusable
+	Unusable    bool
+	Suggestions []string
+}
+
+// Broken reports whether the chain needs attention.
+func (r ChainReport) Broken() bool {
+	return !r.Unusable && (r.Problem != "" || len(r.Corrupt) > 0)
+}
+
+// archiveCheckError returns why a checked archive cannot be used, or "".
+// A recorded failed verification counts; archives in cold storage are not
+// read.
+func archiveCheckError(a Archive) string {
+	if a.VerificationStatus != nil && !a.VerificationStatus.IsVerified {
+		if len(a.VerificationStatus.Errors) > 0 {
+			return "failed verification: " + a.VerificationStatus.Errors[0]
+		}
+		return "failed verification"
+	}
+	if a.Location != "" {
+		return ""
+	}
+	if err := CheckArchiveStructure(a.Path); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// ⭐ CHAIN-CHECK-001: Chain consistency check - 🔍
+// CheckArchiveChains checks every chain of the archives in archiveDir that
+// has incremental archives, the most recent first. The structural check of
+// `verify --quick` decides whether an archive is corrupt, unless a failed
+// verification is recorded.
+func CheckArchiveChains(cfg *Config, archiveDir string) ([]ChainReport, error) {
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	archives = filterForeignArchives(cfg, archives)
+	cold, err := coldArchives(archiveDir, archives)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	archives = append(archives, cold...)
+	for i := range archives {
+		loadArchiveMetadata(&archives[i])
+	}
+
+	var pruned map[string]TrashItem
+	var reports []ChainReport
+	for _, chain := range listChains(archives) {
+		base := chainBase(chain)
+		incrementals := chain.Archives
+		if base != nil {
+			incrementals = incrementals[1:]
+		}
+		if len(incrementals) == 0 {
+			continue
+		}
+		report := ChainReport{Base: chain.Name, Unusable: true}
+		for _, a := range incrementals {
+			report.Incrementals = append(report.Incrementals, a.Name)
+			if a.Tags[chainUnusableTag] != chainUnusableValue {
+				report.Unusable = false
+			}
+			if archiveCheckError(a) != "" {
+				report.Corrupt = append(report.Corrupt, a.Name)
+			}
+		}
+		switch {
+		case base != nil:
+			if problem := archiveCheckError(*base); problem != "" {
+				report.Problem, report.Detail = ChainBaseCorrupt, problem
+			}
+		default:
+			if pruned == nil {
+				pruned = prunedArchives(cfg, archiveDir)
+			}
+			report.Problem = ChainBaseMissing
+			if item, ok := pruned[chain.Name]; ok {
+				report.Problem = ChainBasePruned
+				report.Detail = fmt.Sprintf("moved to the trash on %s as %s", item.TrashedAt.Format("2006-01-02"), item.Name)
+			}
+		}
+		if report.Problem == "" {
+			report.Unusable = false
+		}
+		report.Suggestions = chainSuggestions(report, pruned)
+		reports = append(reports, report)
+	}
+	return reports, nil
+}
+
+// chainSuggestions returns the commands that repair a chain, the least
+// lossy first.
+func chainSuggestions(r ChainReport, pruned map[string]TrashItem) []string {
+	var suggestions []string
+	switch {
+	case r.Unusable:
+	case r.Problem != "":
+		if item, ok := pruned[r.Base]; ok {
+			suggestions = append(suggestions, fmt.Sprintf("Recover the base from the trash: bkpdir trash restore %s", item.Name))
+		}
+		// Each incremental archive holds every file changed since the base
+		newest := ""
+		for i := len(r.Incrementals) - 1; i >= 0 && newest == ""; i-- {
+			if !slices.Contains(r.Corrupt, r.Incrementals[i]) {
+				newest = r.Incrementals[i]
+			}
+		}
+		if newest != "" {
+			suggestions = append(suggestions, fmt.Sprintf(
+				"Consolidate the surviving changes: bkpdir restore %s DIR, which holds every file changed since the base, then create a new full archive",
+				newest))
+		}
+		suggestions = append(suggestions, fmt.Sprintf("Mark the chain unusable: bkpdir annotate NAME --tag %s=%s for each of its %d incremental %s",
+			chainUnusableTag, chainUnusableValue, len(r.Incrementals), pluralArchives(len(r.Incrementals))))
+	default:
+		for _, name := range r.Corrupt {
+			suggestions = append(suggestions, fmt.Sprintf("Remove %s, whose changes are lost, and create a new incremental archive: bkpdir inc", name))
+		}
+	}
+	return suggestions
+}
+
+// writeChainReports writes the chain reports to w and returns the number
+// of chains that need attention.
+func writeChainReports(w io.Writer, reports []ChainReport) int {
+	broken := 0
+	for _, r := range reports {
+		count := fmt.Sprintf("%d incremental %s", len(r.Incrementals), pluralArchives(len(r.Incrementals)))
+		switch {
+		case r.Unusable:
+			fmt.Fprintf(w, "- %s: %s, %s marked unusable\n", r.Base, r.Problem, count)
+			continue
+		case !r.Broken():
+			fmt.Fprintf(w, "✓ %s: %s\n", r.Base, count)
+			continue
+		case r.Problem != "":
+			problem := r.Problem
+			if r.Detail != "" {
+				problem += " (" + r.Detail + ")"
+			}
+			fmt.Fprintf(w, "✗ %s: %s, %s cannot be restored\n", r.Base, problem, count)
+		default:
+			fmt.Fprintf(w, "✗ %s: %d corrupt of %s\n", r.Base, len(r.Corrupt), count)
+		}
+		broken++
+		if len(r.Corrupt) > 0 {
+			fmt.Fprintf(w, "  Corrupt: %s\n", strings.Join(r.Corrupt, ", "))
+		}
+		for _, s := range r.Suggestions {
+			fmt.Fprintf(w, "  → %s\n", s)
+		}
+	}
+	return broken
+}
+
+// ⭐ CHAIN-CHECK-001: verify --chain - 🛡️
+// VerifyArchiveChains checks the chains of the archive directory of the
+// current directory and fails when any chain needs attention.
+func VerifyArchiveChains(w io.Writer, cfg *Config) error {
+	if repositoryEnabled(cfg) {
+		return NewArchiveError("--chain checks incremental archives and is not supported with a repository", cfg.StatusConfigError)
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	reports, err := CheckArchiveChains(cfg, archiveDir)
+	if err != nil {
+		return err
+	}
+	if len(reports) == 0 {
+		fmt.Fprintf(w, "No incremental archives in %s\n", archiveDir)
+		return nil
+	}
+	fmt.Fprintf(w, "Checking %d incremental %s in %s\n", len(reports), pluralChains(len(reports)), filepath.Clean(archiveDir))
+	if broken := writeChainReports(w, reports); broken > 0 {
+		return NewArchiveError(fmt.Sprintf("%d %s need attention", broken, pluralChains(broken)), 1)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the incremental chain check.
+// It verifies that chains without a usable base and corrupt incremental
+// archives are reported with repairs, and that marked chains are not.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ CHAIN-CHECK-001: Broken chain detection - 🧪
+func TestCheckArchiveChains(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(journalEnvVar, filepath.Join(dir, "journal.json"))
+	archiveDir := filepath.Join(dir, "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.TrashDirPath = filepath.Join(dir, "trash")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	path := func(name string) string { return filepath.Join(archiveDir, name) }
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	for i, name := range []string{
+		"proj-2024-03-01-10-00.zip",
+		"proj-2024-03-01-10-00_update=2024-03-02-10-00.zip",
+		"proj-2024-03-01-10-00_update=2024-03-03-10-00.zip",
+		"proj-2024-03-04-10-00.zip",
+		"proj-2024-03-04-10-00_update=2024-03-05-10-00.zip",
+		"proj-2024-03-04-10-00_update=2024-03-06-10-00.zip",
+	} {
+		writeTestZip(t, path(name), map[string]string{"a.txt": name})
+		modTime := start.AddDate(0, 0, i)
+		if err := os.Chtimes(path(name), modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	verify := func() (string, error) {
+		t.Helper()
+		var out bytes.Buffer
+		err := VerifyArchiveChains(&out, cfg)
+		return out.String(), err
+	}
+	if out, err := verify(); err != nil || strings.Count(out, "✓") != 2 {
+		t.Fatalf("healthy chains: %v\n%s", err, out)
+	}
+
+	// A truncated incremental archive only loses its own changes
+	if err := os.WriteFile(path("proj-2024-03-01-10-00_update=2024-03-02-10-00.zip"), []byte("PK"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// The base of the newer chain is pruned
+	item, err := MoveToTrash(cfg, path("proj-2024-03-04-10-00.zip"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := verify()
+	if err == nil || !strings.Contains(err.Error(), "2 chains need attention") {
+		t.Errorf("error = %v", err)
+	}
+	for _, want := range []string{
+		"✗ proj-2024-03-04-10-00.zip: base in the trash (moved to the trash on ",
+		"→ Recover the base from the trash: bkpdir trash restore " + item.Name,
+		"→ Consolidate the surviving changes: bkpdir restore proj-2024-03-04-10-00_update=2024-03-06-10-00.zip DIR",
+		"→ Mark the chain unusable: bkpdir annotate NAME --tag chain=unusable for each of its 2 incremental archives",
+		"✗ proj-2024-03-01-10-00.zip: 1 corrupt of 2 incremental archives",
+		"Corrupt: proj-2024-03-01-10-00_update=2024-03-02-10-00.zip",
+		"→ Remove proj-2024-03-01-10-00_update=2024-03-02-10-00.zip",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report lacks %q:\n%s", want, out)
+		}
+	}
+
+	// Once deleted and marked, the chain is only listed
+	if err := os.Remove(filepath.Join(cfg.TrashDirPath, item.Name)); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(filepath.Join(cfg.TrashDirPath, item.Name+trashInfoSuffix))
+	for _, name := range []string{"proj-2024-03-04-10-00_update=2024-03-05-10-00.zip", "proj-2024-03-04-10-00_update=2024-03-06-10-00.zip"} {
+		if err := StoreArchiveAnnotation(path(name), &ArchiveAnnotation{Tags: map[string]string{"chain": "unusable"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Remove(path("proj-2024-03-01-10-00_update=2024-03-02-10-00.zip")); err != nil {
+		t.Fatal(err)
+	}
+	var doctor bytes.Buffer
+	if err := RunDoctor(&doctor, cfg); err != nil {
+		t.Errorf("doctor: %v\n%s", err, doctor.String())
+	}
+	for _, want := range []string{
+		"Incremental chains: 2",
+		"- proj-2024-03-04-10-00.zip: base missing, 2 incremental archives marked unusable",
+		"✓ proj-2024-03-01-10-00.zip: 1 incremental archive",
+		"No problems found",
+	} {
+		if !strings.Contains(doctor.String(), want) {
+			t.Errorf("doctor lacks %q:\n%s", want, doctor.String())
+		}
+	}
+}
@@ -0,0 +1,246 @@
+// This file is part of bkpdir
+//
+// Package main provides the file change journal: an append-only record, per
+// archived directory, of the files created, modified and deleted between
+// archive runs, read by `bkpdir history PATH --journal`.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/processing"
+)
+
+// ⭐ CHANGES-001: Change event kinds - 🔧
+const (
+	// ChangeCreated records a file seen for the first time.
+	ChangeCreated = "create"
+	// ChangeModified records a file whose size or modification time changed.
+	ChangeModified = "modify"
+	// ChangeDeleted records a file that is no longer in the directory.
+	ChangeDeleted = "delete"
+)
+
+// changeJournalSuffix names the journal of a directory in .metadata.
+const changeJournalSuffix = ".changes.jsonl"
+
+// ⭐ CHANGES-001: Change journal record - 📝
+// ChangeEvent is one line of the change journal. Size and ModTime describe
+// the file as archived and are empty for deletions.
+type ChangeEvent struct {
+	Time    time.Time  `json:"time"`
+	Archive string     `json:"archive"`
+	Event   string     `json:"event"`
+	Path    string     `json:"path"`
+	Size    int64      `json:"size,omitempty"`
+	ModTime *time.Time `json:"mtime,omitempty"`
+}
+
+// changeJournalPath returns the journal of the directory cwd, kept with the
+// metadata of its archives.
+func changeJournalPath(archiveDir, cwd string) string {
+	return filepath.Join(archiveDir, ".metadata", filepath.Base(cwd)+changeJournalSuffix)
+}
+
+// ⭐ CHANGES-001: Change journal loading - 🔧
+// LoadChangeJournal reads the events of a journal, oldest first. A missing
+// journal has no events.
+func LoadChangeJournal(path string) ([]ChangeEvent, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read change journal: %w", err)
+	}
+	return parseChangeJournal(data)
+}
+
+// parseChangeJournal decodes the JSON lines of a journal.
+func parseChangeJournal(data []byte) ([]ChangeEvent, error) {
+	var events []ChangeEvent
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var event ChangeEvent
+		if err := json.Unmarshal(scanner.Bytes(), &event); err != nil {
+			return nil, fmt.Errorf("failed to decode change journal line %d: %w", line, err)
+		}
+		events = append(events, event)
+	}
+	return events, scanner.Err()
+}
+
+// changeState replays events into the last known event of each file that
+// still exists.
+func changeState(events []ChangeEvent) map[string]ChangeEvent {
+	state := make(map[string]ChangeEvent)
+	for _, e := range events {
+		if e.Event == ChangeDeleted {
+			delete(state, e.Path)
+		} else {
+			state[e.Path] = e
+		}
+	}
+	return state
+}
+
+// ⭐ CHANGES-001: Change detection - 🔍
+// detectChanges compares the files of cwd with the journal state. Files in
+// skip could not be archived; they keep their previous state.
+func detectChanges(cwd string, files []string, state map[string]ChangeEvent, skip map[string]bool, archive string, now time.Time) []ChangeEvent {
+	var events []ChangeEvent
+	seen := make(map[string]bool, len(files))
+	for _, rel := range files {
+		if skip[rel] {
+			continue
+		}
+		info, err := os.Lstat(filepath.Join(cwd, rel))
+		if err != nil || info.IsDir() {
+			continue
+		}
+		seen[rel] = true
+		modTime := info.ModTime().UTC()
+		event := ChangeEvent{Time: now, Archive: archive, Path: rel, Size: info.Size(), ModTime: &modTime}
+		prev, known := state[rel]
+		switch {
+		case !known:
+			event.Event = ChangeCreated
+		case prev.Size != event.Size || prev.ModTime == nil || !prev.ModTime.Equal(modTime):
+			event.Event = ChangeModified
+		default:
+			continue
+		}
+		events = append(events, event)
+	}
+
+	var deleted []string
+	for rel := range state {
+		if !seen[rel] && !skip[rel] {
+			deleted = append(deleted, rel)
+		}
+	}
+	sort.Strings(deleted)
+	for _, rel := range deleted {
+		events = append(events, ChangeEvent{Time: now, Archive: archive, Event: ChangeDeleted, Path: rel})
+	}
+	return events
+}
+
+// ⭐ CHANGES-001: Change journal recording - 🔧
+// recordChangeJournal stages the journal with the changes since the previous
+// archive appended in txn, so the events are only kept with the archive. A
+// failure only produces a warning because the archive itself is complete.
+func recordChangeJournal(txn *processing.Transaction, cfg ArchiveCreationOptions, failures []FileFailure) {
+	path := changeJournalPath(filepath.Dir(cfg.Path), cfg.CWD)
+	err := func() error {
+		data, err := os.ReadFile(path)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		events, err := parseChangeJournal(data)
+		if err != nil {
+			return err
+		}
+		files := cfg.TargetFiles
+		if files == nil {
+			files = cfg.Files
+		}
+		skip := make(map[string]bool, len(failures))
+		for _, f := range failures {
+			skip[f.Path] = true
+		}
+		changes := detectChanges(cfg.CWD, files, changeState(events), skip, filepath.Base(cfg.Path), time.Now().UTC())
+		if len(changes) == 0 {
+			return nil
+		}
+
+		var buf bytes.Buffer
+		buf.Write(data)
+		enc := json.NewEncoder(&buf)
+		for _, e := range changes {
+			if err := enc.Encode(e); err != nil {
+				return err
+			}
+		}
+		return txn.WriteFile(path, buf.Bytes(), 0o644)
+	}()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record file changes for %s: %v\n", filepath.Base(cfg.Path), err)
+	}
+}
+
+// ⭐ SUDO-OWNER-001: Ownership of the change journal - 🛡️
+// applyChangeJournalOwnership gives the journal of cwd next to archivePath
+// back to the user who ran bkpdir through sudo.
+func applyChangeJournalOwnership(cfg *Config, archivePath, cwd string) {
+	if cfg.ChangeJournal {
+		applyInvokingUserOwnership(cfg, changeJournalPath(filepath.Dir(archivePath), cwd))
+	}
+}
+
+// ⭐ CHANGES-001: File history from the change journal - 🔍
+// showJournalHistory prints the journaled changes of rel, a file of cwd,
+// oldest first.
+func showJournalHistory(opts HistoryOptions, archiveDir, cwd, rel string) error {
+	journal := changeJournalPath(archiveDir, cwd)
+	events, err := LoadChangeJournal(journal)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to read change journal", 1, err)
+	}
+	if events == nil {
+		if !opts.Config.ChangeJournal {
+			return NewArchiveError("No change journal for this directory; set change_journal: true to record one", opts.Config.StatusConfigError)
+		}
+		fmt.Fprintf(opts.Output, "No changes journaled yet in %s\n", journal)
+		return nil
+	}
+
+	var matched []ChangeEvent
+	for _, e := range events {
+		if e.Path == rel {
+			matched = append(matched, e)
+		}
+	}
+	if len(matched) == 0 {
+		fmt.Fprintf(opts.Output, "No journaled changes of %s\n", rel)
+		return nil
+	}
+	fmt.Fprintf(opts.Output, "History of %s:\n", rel)
+	for _, e := range matched {
+		size := ""
+		if e.Event != ChangeDeleted {
+			size = formatHumanSize(e.Size)
+		}
+		fmt.Fprintf(opts.Output, "  %s  %-6s  %10s  %s\n",
+			e.Time.Local().Format("2006-01-02 15:04"), e.Event, size, e.Archive)
+	}
+	return nil
+}
+
+// historyRelPath returns path relative to cwd, the form used in the journal.
+func historyRelPath(cwd, path string) (string, error) {
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(cwd, path)
+	}
+	rel, err := filepath.Rel(cwd, filepath.Clean(path))
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is not a file of the current directory", path)
+	}
+	return rel, nil
+}
@@ -0,0 +1,141 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the file change journal.
+// It verifies that archive runs journal created, modified and deleted files
+// and that history shows the changes of one file.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ CHANGES-001: Change detection - 🧪
+func TestDetectChanges(t *testing.T) {
+	cwd := t.TempDir()
+	for name, content := range map[string]string{"same": "1", "grown": "22", "new": "3", "failed": "4"} {
+		if err := os.WriteFile(filepath.Join(cwd, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sameInfo, err := os.Stat(filepath.Join(cwd, "same"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	sameTime := sameInfo.ModTime().UTC()
+	state := map[string]ChangeEvent{
+		"same":   {Path: "same", Size: 1, ModTime: &sameTime},
+		"grown":  {Path: "grown", Size: 1, ModTime: &sameTime},
+		"gone":   {Path: "gone", Size: 1, ModTime: &sameTime},
+		"failed": {Path: "failed", Size: 9, ModTime: &sameTime},
+	}
+
+	events := detectChanges(cwd, []string{"same", "grown", "new", "failed"}, state,
+		map[string]bool{"failed": true}, "a.zip", time.Now())
+	got := map[string]string{}
+	for _, e := range events {
+		got[e.Path] = e.Event
+	}
+	want := map[string]string{"grown": ChangeModified, "new": ChangeCreated, "gone": ChangeDeleted}
+	if len(got) != len(want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	for path, event := range want {
+		if got[path] != event {
+			t.Errorf("%s: event %q, want %q", path, got[path], event)
+		}
+	}
+}
+
+// ⭐ CHANGES-001: Journal across archive runs - 🧪
+func TestChangeJournalHistory(t *testing.T) {
+	archiveDir := filepath.Join(t.TempDir(), "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.ChangeJournal = true
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(cwd, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("notes.txt", "one")
+	write("old.txt", "old")
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatalf("first archive: %v", err)
+	}
+
+	write("notes.txt", "one two")
+	if err := os.Remove(filepath.Join(cwd, "old.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatalf("second archive: %v", err)
+	}
+	// Nothing changed: no events
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatalf("third archive: %v", err)
+	}
+
+	events, err := LoadChangeJournal(changeJournalPath(archiveDir, cwd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 4 {
+		t.Fatalf("journal has %d events, want 4: %+v", len(events), events)
+	}
+
+	var out bytes.Buffer
+	if err := ShowFileHistory(HistoryOptions{Config: cfg, Output: &out, Path: "notes.txt", Journal: true}); err != nil {
+		t.Fatalf("ShowFileHistory: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 3 || !strings.Contains(lines[1], "create") || !strings.Contains(lines[2], "modify") {
+		t.Errorf("history:\n%s", out.String())
+	}
+
+	out.Reset()
+	if err := ShowFileHistory(HistoryOptions{Config: cfg, Output: &out, Path: filepath.Join(cwd, "old.txt"), Journal: true}); err != nil {
+		t.Fatalf("ShowFileHistory: %v", err)
+	}
+	if !strings.Contains(out.String(), "delete") {
+		t.Errorf("history of a deleted file:\n%s", out.String())
+	}
+
+	if err := ShowFileHistory(HistoryOptions{Config: cfg, Output: &out, Path: "../elsewhere", Journal: true}); err == nil {
+		t.Error("a path outside the directory should fail")
+	}
+}
+
+// ⭐ CHANGES-001: Incremental archives share the journal of the directory - 🧪
+func TestChangeJournalIncremental(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.UseCurrentDirName = true
+	cfg.ChangeJournal = true
+	cwd, _ := os.Getwd()
+
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("b.txt", []byte("b"), 0o644)
+	if err := CreateIncrementalArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	events, err := LoadChangeJournal(changeJournalPath(filepath.Join(archiveDir, filepath.Base(cwd)), cwd))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 2 || events[1].Path != "b.txt" || events[1].Event != ChangeCreated {
+		t.Errorf("journal events = %+v, want a.txt then b.txt created", events)
+	}
+}
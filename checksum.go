@@ -0,0 +1,252 @@
+// This file is part of bkpdir
+//
+// Package main provides sha256sum-compatible checksum files for archives, so
+// archives copied to other systems can also be checked with `sha256sum -c`.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	bkperrors "bkpdir/pkg/errors"
+	"bkpdir/pkg/fileops"
+)
+
+// defaultChecksumFile is the checksum file name used in the archive directory.
+const defaultChecksumFile = "SHA256SUMS"
+
+// ⭐ SUMS-001: Checksum file entry - 📝
+// ChecksumEntry is one line of a checksum file. Name is relative to the
+// directory of the checksum file unless it is absolute.
+type ChecksumEntry struct {
+	Sum  string
+	Name string
+}
+
+// ⭐ SUMS-001: Checksum file generation - 🔧
+// WriteChecksumFile hashes the archives and writes their checksums to path in
+// the format of sha256sum, naming them relative to the directory of path.
+func WriteChecksumFile(path string, archives []Archive) ([]ChecksumEntry, error) {
+	// The atomic writer refuses paths with ".." elements
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(path)
+	entries := make([]ChecksumEntry, 0, len(archives))
+	var b strings.Builder
+	for _, a := range archives {
+		_, sum, err := hashArchive(a.Path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to hash %s: %w", a.Name, err)
+		}
+		name := a.Path
+		if abs, err := filepath.Abs(a.Path); err == nil {
+			name = abs
+		}
+		if rel, err := filepath.Rel(dir, name); err == nil && !strings.HasPrefix(rel, "..") {
+			name = filepath.ToSlash(rel)
+		}
+		entry := ChecksumEntry{Sum: sum, Name: name}
+		entries = append(entries, entry)
+		b.WriteString(formatChecksumLine(entry))
+	}
+	if err := fileops.AtomicWriteFile(path, []byte(b.String()), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write checksum file: %w", err)
+	}
+	return entries, nil
+}
+
+// formatChecksumLine formats an entry like sha256sum, which escapes names
+// containing a backslash or newline and marks the line with a leading
+// backslash.
+func formatChecksumLine(e ChecksumEntry) string {
+	if !strings.ContainsAny(e.Name, "\\\n") {
+		return e.Sum + "  " + e.Name + "\n"
+	}
+	name := strings.NewReplacer("\\", "\\\\", "\n", "\\n").Replace(e.Name)
+	return "\\" + e.Sum + "  " + name + "\n"
+}
+
+// ⭐ SUMS-001: Checksum file parsing - 🔍
+// ParseChecksumFile reads sha256sum output in text or binary mode ("SUM  NAME"
+// or "SUM *NAME"), including escaped names. Blank lines and lines starting
+// with # are skipped.
+func ParseChecksumFile(r io.Reader) ([]ChecksumEntry, error) {
+	var entries []ChecksumEntry
+	scanner := bufio.NewScanner(r)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSuffix(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		escaped := strings.HasPrefix(line, "\\")
+		line = strings.TrimPrefix(line, "\\")
+
+		sum, name, ok := strings.Cut(line, " ")
+		if !ok || len(sum) != 64 || !isHex(sum) || name == "" || (name[0] != ' ' && name[0] != '*') || len(name) < 2 {
+			return nil, fmt.Errorf("line %d: not a SHA-256 checksum line", lineNo)
+		}
+		name = name[1:]
+		if escaped {
+			name = unescapeChecksumName(name)
+		}
+		entries = append(entries, ChecksumEntry{Sum: strings.ToLower(sum), Name: name})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// unescapeChecksumName reverses the escaping applied by formatChecksumLine.
+func unescapeChecksumName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+1 < len(name) {
+			i++
+			if name[i] == 'n' {
+				b.WriteByte('\n')
+				continue
+			}
+		}
+		b.WriteByte(name[i])
+	}
+	return b.String()
+}
+
+// isHex reports whether s only contains hexadecimal digits.
+func isHex(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// ⭐ SUMS-001: Checksum file verification - 🛡️
+// VerifyChecksumFile checks every file listed in the checksum file at path
+// and writes one "NAME: OK" or "NAME: FAILED" line per file to w, like
+// `sha256sum -c`. Relative names are resolved against the directory of path.
+// Mismatched and unreadable files are returned as a MultiError.
+func VerifyChecksumFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	entries, err := ParseChecksumFile(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("%s: no checksum lines found", path)
+	}
+
+	failures := bkperrors.NewMultiError("checksum verify")
+	for _, e := range entries {
+		target := filepath.FromSlash(e.Name)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(filepath.Dir(path), target)
+		}
+		_, sum, err := hashArchive(target)
+		switch {
+		case err != nil:
+			fmt.Fprintf(w, "%s: FAILED open or read\n", e.Name)
+			failures.Add(e.Name, err)
+		case sum != e.Sum:
+			fmt.Fprintf(w, "%s: FAILED\n", e.Name)
+			failures.Add(e.Name, fmt.Errorf("checksum mismatch: expected %s, got %s", e.Sum, sum))
+		default:
+			fmt.Fprintf(w, "%s: OK\n", e.Name)
+		}
+	}
+	return failures.ErrorOrNil()
+}
+
+// ⭐ SUMS-001: checksum write command - 🔧
+// WriteArchiveChecksums writes the checksum file for the named archives of the
+// current directory, or for all of them when names is empty. The file
+// defaults to SHA256SUMS in the archive directory.
+func WriteArchiveChecksums(w io.Writer, cfg *Config, names []string, file string) error {
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		file = filepath.Join(archiveDir, defaultChecksumFile)
+	}
+
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	if len(names) > 0 {
+		byName := make(map[string]Archive, len(archives))
+		for _, a := range archives {
+			byName[a.Name] = a
+		}
+		selected := make([]Archive, 0, len(names))
+		for _, name := range names {
+			// ⭐ ALIAS-001: Names such as @last-full stand for an archive
+			if name, err = resolveArchiveAlias(cfg, archiveDir, name); err != nil {
+				return err
+			}
+			if !strings.HasSuffix(name, ".zip") {
+				name += ".zip"
+			}
+			a, ok := byName[name]
+			if !ok {
+				return NewArchiveError(fmt.Sprintf("Archive not found: %s", name), cfg.StatusFileNotFound)
+			}
+			selected = append(selected, a)
+		}
+		archives = selected
+	}
+	if len(archives) == 0 {
+		return NewArchiveError(fmt.Sprintf("No archives found in %s", archiveDir), cfg.StatusFileNotFound)
+	}
+
+	entries, err := WriteChecksumFile(file, archives)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to write checksum file", cfg.StatusDiskFull, err)
+	}
+	fmt.Fprintf(w, "Wrote checksums for %d archives to %s\n", len(entries), file)
+	return nil
+}
+
+// ⭐ SUMS-001: checksum verify command - 🛡️
+// VerifyArchiveChecksums verifies a checksum file, by default SHA256SUMS in
+// the archive directory of the current directory.
+func VerifyArchiveChecksums(w io.Writer, cfg *Config, file string) error {
+	if file == "" {
+		archiveDir, err := getArchiveDirectory(cfg)
+		if err != nil {
+			return err
+		}
+		file = filepath.Join(archiveDir, defaultChecksumFile)
+	}
+	err := VerifyChecksumFile(w, file)
+	if os.IsNotExist(err) {
+		return NewArchiveError(fmt.Sprintf("Checksum file not found: %s", file), cfg.StatusFileNotFound)
+	}
+	var failures *bkperrors.MultiError
+	if errors.As(err, &failures) {
+		return NewArchiveErrorWithCause(
+			fmt.Sprintf("%d of the files listed in %s failed verification", failures.Len(), file), 1, err)
+	}
+	if err != nil {
+		return NewArchiveErrorWithCause("Invalid checksum file", cfg.StatusConfigError, err)
+	}
+	return nil
+}
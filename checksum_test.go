@@ -0,0 +1,91 @@
+// This file is part of bkpdir
+
+// Package main provides tests for sha256sum-compatible checksum files.
+// It verifies writing, parsing of sha256sum output and verification results.
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	bkperrors "bkpdir/pkg/errors"
+)
+
+// ⭐ SUMS-001: Checksum file round trip - 🧪
+func TestChecksumFile(t *testing.T) {
+	dir := t.TempDir()
+	var archives []Archive
+	for _, name := range []string{"proj-2024-03-20-14-30.zip", "proj-2024-03-21-09-00=a\\b.zip"} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, Archive{Name: name, Path: path})
+	}
+
+	sums := filepath.Join(dir, defaultChecksumFile)
+	if _, err := WriteChecksumFile(sums, archives); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(sums)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "  proj-2024-03-20-14-30.zip") ||
+		!strings.HasPrefix(lines[1], "\\") || !strings.HasSuffix(lines[1], "  proj-2024-03-21-09-00=a\\\\b.zip") {
+		t.Fatalf("Unexpected checksum file:\n%s", data)
+	}
+
+	var out bytes.Buffer
+	if err := VerifyChecksumFile(&out, sums); err != nil {
+		t.Fatalf("Expected all files to verify: %v\n%s", err, out.String())
+	}
+	if !strings.Contains(out.String(), "proj-2024-03-21-09-00=a\\b.zip: OK") {
+		t.Errorf("Expected unescaped names in the report, got:\n%s", out.String())
+	}
+
+	if err := os.WriteFile(archives[0].Path, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(archives[1].Path)
+	out.Reset()
+	err = VerifyChecksumFile(&out, sums)
+	var failures *bkperrors.MultiError
+	if !errors.As(err, &failures) || failures.Len() != 2 {
+		t.Fatalf("Expected two failures, got %v", err)
+	}
+	if !strings.Contains(out.String(), "proj-2024-03-20-14-30.zip: FAILED\n") ||
+		!strings.Contains(out.String(), ": FAILED open or read\n") {
+		t.Errorf("Unexpected report:\n%s", out.String())
+	}
+}
+
+// ⭐ SUMS-001: sha256sum output parsing - 🧪
+func TestParseChecksumFile(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	input := "# comment\n" + sum + "  text.zip\n" + strings.ToUpper(sum) + " *binary.zip\r\n\n\\" + sum + "  new\\nline.zip\n"
+	entries, err := ParseChecksumFile(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"text.zip", "binary.zip", "new\nline.zip"}
+	if len(entries) != len(want) {
+		t.Fatalf("Expected %d entries, got %+v", len(want), entries)
+	}
+	for i, e := range entries {
+		if e.Name != want[i] || e.Sum != sum {
+			t.Errorf("Entry %d = %+v, want %s", i, e, want[i])
+		}
+	}
+
+	for _, bad := range []string{"abc  short.zip\n", sum + "\n", sum + "-x.zip\n"} {
+		if _, err := ParseChecksumFile(strings.NewReader(bad)); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
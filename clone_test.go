@@ -0,0 +1,120 @@
+// This file is part of bkpdir
+
+// Package main provides tests for cloning archives between backends.
+// It verifies the name and age filters, that sidecars travel with their
+// archive, that present archives are skipped and that a destination that
+// does not return what was written fails the clone.
+package main
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bkpdir/pkg/remote"
+)
+
+// ⭐ CLONE-001: Archive replication - 🧪
+func TestCloneArchives(t *testing.T) {
+	srcDir, dstDir := t.TempDir(), t.TempDir()
+	old := time.Now().Add(-72 * time.Hour)
+	for name, content := range map[string]string{
+		"p-2024-01-01-10-00.zip":                "old archive",
+		"p-2024-03-01-10-00.zip":                "new archive",
+		".metadata/p-2024-01-01-10-00.zip.json": `{"is_verified":true}`,
+		"notes.txt":                             "not an archive",
+	} {
+		path := filepath.Join(srcDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Chtimes(filepath.Join(srcDir, "p-2024-01-01-10-00.zip"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	var out bytes.Buffer
+	opts := CloneOptions{Config: cfg, Output: &out, Source: srcDir, Dest: dstDir, OlderThan: 24 * time.Hour}
+	if err := CloneArchives(opts); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"p-2024-01-01-10-00.zip":                "old archive",
+		".metadata/p-2024-01-01-10-00.zip.json": `{"is_verified":true}`,
+	} {
+		got, err := os.ReadFile(filepath.Join(dstDir, filepath.FromSlash(name)))
+		if err != nil || string(got) != want {
+			t.Errorf("%s = %q, %v; want %q", name, got, err, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "p-2024-03-01-10-00.zip")); !os.IsNotExist(err) {
+		t.Errorf("--older-than did not leave out the new archive: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dstDir, "notes.txt")); !os.IsNotExist(err) {
+		t.Errorf("a file that is not an archive was cloned: %v", err)
+	}
+
+	out.Reset()
+	opts.OlderThan, opts.Match = 0, "p-2024-*"
+	if err := CloneArchives(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Already present: p-2024-01-01-10-00.zip") ||
+		!strings.Contains(out.String(), "Cloned p-2024-03-01-10-00.zip") {
+		t.Errorf("unexpected second clone:\n%s", out.String())
+	}
+
+	opts.Match = "["
+	if err := CloneArchives(opts); err == nil {
+		t.Error("an invalid --match pattern was accepted")
+	}
+	opts.Match, opts.Dest = "", srcDir
+	if err := CloneArchives(opts); err == nil {
+		t.Error("a clone onto its source was accepted")
+	}
+}
+
+// corruptingBackend returns altered content when objects are read back.
+type corruptingBackend struct {
+	remote.Backend
+}
+
+func (c corruptingBackend) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	return io.NopCloser(strings.NewReader("bit rot")), nil
+}
+
+// ⭐ CLONE-001: Copy verification - 🧪
+func TestCloneObjectVerifiesCopy(t *testing.T) {
+	src, err := remote.NewDirBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	dst, err := remote.NewDirBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(strings.TrimPrefix(src.URL(), "file://"), "a.zip"), []byte("archive"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	obj, err := src.Stat(ctx, "a.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cloneObject(ctx, src, dst, obj, remote.MinPartSize, ""); err != nil {
+		t.Fatalf("clone: %v", err)
+	}
+	err = cloneObject(ctx, src, corruptingBackend{dst}, obj, remote.MinPartSize, "")
+	if err == nil || !strings.Contains(err.Error(), "does not match") {
+		t.Errorf("a corrupted copy was not detected: %v", err)
+	}
+}
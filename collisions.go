@@ -0,0 +1,142 @@
+// This file is part of bkpdir
+//
+// Package main provides detection of archive entries whose paths differ only
+// by case, which overwrite each other when restored on case-insensitive
+// filesystems such as the macOS default.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ⭐ CASE-001: Restore collision error - 🔧
+// ErrCaseCollision is returned when entries would overwrite each other on a
+// case-insensitive destination and no rename strategy was requested.
+var ErrCaseCollision = errors.New("entries differ only by case")
+
+// ⭐ CASE-001: Case collision detection - 🔍
+// DetectCaseCollisions groups slash-separated paths that are equal when case
+// and Unicode normalization are ignored. Groups and their members are sorted
+// for stable output.
+func DetectCaseCollisions(paths []string) [][]string {
+	byFolded := make(map[string][]string)
+	for _, p := range paths {
+		key := foldPath(p)
+		byFolded[key] = append(byFolded[key], p)
+	}
+
+	var groups [][]string
+	for _, group := range byFolded {
+		if len(group) < 2 {
+			continue
+		}
+		sort.Strings(group)
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i][0] < groups[j][0]
+	})
+	return groups
+}
+
+// foldPath returns the comparison key of a path on case-insensitive,
+// normalization-insensitive filesystems such as APFS.
+func foldPath(p string) string {
+	// ⭐ UNICODE-001: NFC and NFD spellings of a name refer to the same file
+	return strings.ToLower(NormalizeNFC(filepath.ToSlash(p)))
+}
+
+// ⭐ CASE-001: Archive-time collision warning - 🔍
+// warnCaseCollisions reports collisions found while creating an archive. The
+// archive keeps every entry; only a case-insensitive restore is affected.
+func warnCaseCollisions(files []string) {
+	for _, group := range DetectCaseCollisions(files) {
+		fmt.Fprintf(os.Stderr,
+			"Warning: paths differ only by case or Unicode normalization and will collide on case-insensitive filesystems: %s\n",
+			strings.Join(group, ", "))
+	}
+}
+
+// ⭐ CASE-001: Restore rename strategy - 🔧
+// CaseCollisionRenames returns new names for every colliding entry except the
+// first of each group, so all entries can be restored side by side. The
+// suffix " (case N)" is inserted before the extension and skips names that
+// would collide again.
+func CaseCollisionRenames(paths []string) map[string]string {
+	taken := make(map[string]bool, len(paths))
+	for _, p := range paths {
+		taken[foldPath(p)] = true
+	}
+
+	renames := make(map[string]string)
+	for _, group := range DetectCaseCollisions(paths) {
+		for _, p := range group[1:] {
+			slashed := filepath.ToSlash(p)
+			ext := path.Ext(slashed)
+			base := strings.TrimSuffix(slashed, ext)
+			for n := 2; ; n++ {
+				candidate := fmt.Sprintf("%s (case %d)%s", base, n, ext)
+				if !taken[foldPath(candidate)] {
+					taken[foldPath(candidate)] = true
+					renames[p] = filepath.FromSlash(candidate)
+					break
+				}
+			}
+		}
+	}
+	return renames
+}
+
+// ⭐ CASE-001: Restore-time collision check - 🔧
+// CheckRestoreCaseCollisions validates entries before restoring into destDir.
+// On case-sensitive destinations it returns no renames. Otherwise colliding
+// entries are an ErrCaseCollision unless rename is set, in which case the
+// renames from CaseCollisionRenames are returned.
+func CheckRestoreCaseCollisions(destDir string, paths []string, rename bool) (map[string]string, error) {
+	if !IsCaseInsensitiveDir(destDir) {
+		return nil, nil
+	}
+	groups := DetectCaseCollisions(paths)
+	if len(groups) == 0 {
+		return nil, nil
+	}
+	if !rename {
+		var names []string
+		for _, group := range groups {
+			names = append(names, strings.Join(group, ", "))
+		}
+		return nil, fmt.Errorf("%w on a case-insensitive filesystem: %s", ErrCaseCollision, strings.Join(names, "; "))
+	}
+	return CaseCollisionRenames(paths), nil
+}
+
+// ⭐ CASE-001: Filesystem case sensitivity probe - 🔍
+// IsCaseInsensitiveDir reports whether dir lives on a case-insensitive
+// filesystem by creating a probe file and looking it up with swapped case.
+func IsCaseInsensitiveDir(dir string) bool {
+	probe, err := os.CreateTemp(dir, ".bkpdir-case-probe-")
+	if err != nil {
+		return false
+	}
+	name := probe.Name()
+	probe.Close()
+	defer os.Remove(name)
+
+	base := filepath.Base(name)
+	swapped := strings.ToUpper(base)
+	if swapped == base {
+		swapped = strings.ToLower(base)
+	}
+	_, err = os.Stat(filepath.Join(dir, swapped))
+	return err == nil
+}
@@ -0,0 +1,59 @@
+// This file is part of bkpdir
+
+// Package main provides tests for case collision detection.
+// It verifies grouping, the restore rename strategy and restore checks.
+package main
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// ⭐ CASE-001: Case collision tests - 🔍
+func TestDetectCaseCollisions(t *testing.T) {
+	files := []string{"README.md", "readme.md", "src/Main.go", "src/main.go", "src/MAIN.go", "docs/a.txt"}
+	got := DetectCaseCollisions(files)
+	want := [][]string{
+		{"README.md", "readme.md"},
+		{"src/MAIN.go", "src/Main.go", "src/main.go"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DetectCaseCollisions = %v, want %v", got, want)
+	}
+
+	if groups := DetectCaseCollisions([]string{"a.txt", "b.txt"}); len(groups) != 0 {
+		t.Errorf("Expected no collisions, got %v", groups)
+	}
+}
+
+func TestCaseCollisionRenames(t *testing.T) {
+	files := []string{"README.md", "readme.md", "readme (case 2).md", "Makefile", "makefile"}
+	got := CaseCollisionRenames(files)
+	want := map[string]string{
+		"readme.md": "readme (case 3).md",
+		"makefile":  "makefile (case 2)",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("CaseCollisionRenames = %v, want %v", got, want)
+	}
+}
+
+func TestCheckRestoreCaseCollisions(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{"A.txt", "a.txt"}
+
+	renames, err := CheckRestoreCaseCollisions(dir, files, false)
+	if !IsCaseInsensitiveDir(dir) {
+		if err != nil || renames != nil {
+			t.Errorf("Case-sensitive destination should accept collisions, got %v (%v)", renames, err)
+		}
+		return
+	}
+	if !errors.Is(err, ErrCaseCollision) {
+		t.Errorf("Expected ErrCaseCollision, got %v", err)
+	}
+	if renames, err := CheckRestoreCaseCollisions(dir, files, true); err != nil || renames["a.txt"] != "a (case 2).txt" {
+		t.Errorf("Expected rename strategy, got %v (%v)", renames, err)
+	}
+}
@@ -17,11 +17,15 @@ import (
 	"os"
 	"path/filepath"
 	"reflect"
+	"regexp"
 	"sort"
 	"strings"
 	"sync"
 	"time"
 
+	"bkpdir/pkg/config"
+	"bkpdir/pkg/git"
+
 	yaml "gopkg.in/yaml.v3"
 )
 
@@ -64,11 +68,132 @@ type Config struct {
 	ArchiveDirPath     string              `yaml:"archive_dir_path"`
 	UseCurrentDirName  bool                `yaml:"use_current_dir_name"`
 	ExcludePatterns    []string            `yaml:"exclude_patterns"`
+	ExcludeFrom        []string            `yaml:"exclude_from"`          // ⭐ EXCLUDE-001: Files with extra exclusion patterns
 	IncludeGitInfo     bool                `yaml:"include_git_info"`      // Legacy - use Git.IncludeInfo
 	ShowGitDirtyStatus bool                `yaml:"show_git_dirty_status"` // Legacy - use Git.ShowDirtyStatus
 	SkipBrokenSymlinks bool                `yaml:"skip_broken_symlinks"`
 	Verification       *VerificationConfig `yaml:"verification"`
 
+	// ⭐ ARCH-005: Archive timestamp clock configuration - 🔧
+	// TimestampTimezone selects the clock used in archive and backup names:
+	// "local", "UTC" or an IANA zone name such as "Europe/Berlin".
+	TimestampTimezone string `yaml:"timestamp_timezone"`
+	// TimestampFormat is the Go time layout used for the timestamp portion of names.
+	// It must produce names that the filename patterns can still parse.
+	TimestampFormat string `yaml:"timestamp_format"`
+
+	// ⭐ TRASH-001: Trash area for recoverable deletions - 🔧
+	// TrashDirPath is where removed archives are moved instead of being unlinked.
+	TrashDirPath string `yaml:"trash_dir_path"`
+	// TrashRetentionDays is how long trashed items are kept before `trash empty --expired` removes them.
+	TrashRetentionDays int `yaml:"trash_retention_days"`
+
+	// ⭐ SEAL-001: HMAC integrity seal for created archives - 🔧
+	// IntegritySeal writes an HMAC seal keyed from the OS keychain after each archive is created.
+	IntegritySeal bool `yaml:"integrity_seal"`
+
+	// ⭐ EVENT-001: Lifecycle events for system logs - 🔧
+	// EventLog selects where archive lifecycle events go: "none" or "syslog".
+	EventLog string `yaml:"event_log"`
+
+	// ⭐ UNICODE-001: Filename normalization on restore - 🔧
+	// RestoreUnicodeNormalization selects the form restored names are written
+	// in: "preserve" (original bytes), "nfc" or "nfd".
+	RestoreUnicodeNormalization string `yaml:"restore_unicode_normalization"`
+
+	// ⭐ RESTORE-GUARD-001: Restores refuse archives expanding more than this
+	// many times their size; 0 disables the check
+	RestoreMaxRatio int `yaml:"restore_max_ratio"`
+	// ⭐ RESTORE-GUARD-001: Restore symbolic links pointing outside the target
+	RestoreExternalSymlinks bool `yaml:"restore_external_symlinks"`
+
+	// ⭐ TRACE-001: OpenTelemetry trace export - 🔧
+	// OTLPEndpoint is the OTLP/HTTP collector base URL spans are sent to.
+	// Empty falls back to OTEL_EXPORTER_OTLP_ENDPOINT; tracing is off if both are unset.
+	OTLPEndpoint string `yaml:"otlp_endpoint"`
+
+	// ⭐ HEALTHCHECK-001: Archive run pings - 🔧
+	// HealthcheckURL is pinged at URL/start, URL and URL/fail around each full
+	// or incremental archive run. It is a secret: anyone with it can ping.
+	HealthcheckURL config.Secret `yaml:"healthcheck_url"`
+
+	// ⭐ LIMIT-001: Resource limits for archive candidates - 🛡️
+	// MaxFileSize and MaxTotalSize are sizes such as "500MB"; empty is unlimited.
+	MaxFileSize  string `yaml:"max_file_size"`
+	MaxTotalSize string `yaml:"max_total_size"`
+	// MaxFileCount limits the number of archived entries; 0 is unlimited.
+	MaxFileCount int `yaml:"max_file_count"`
+	// LimitAction is "warn" to skip oversized files and continue, or "fail" to abort.
+	LimitAction string `yaml:"limit_action"`
+
+	// ⭐ BENCH-001: Deflate level of archive entries, 0 (stored) to 9 (smallest)
+	CompressionLevel int `yaml:"compression_level"`
+	// ⭐ JOBS-001: Jobs reading and deflating files: a number, or "auto" to
+	// adapt their number to the measured throughput
+	ArchiveJobs string `yaml:"archive_jobs"`
+	// ⭐ MEMORY-001: Memory limit of the archive pipeline, e.g. "512MB"; empty is unlimited
+	MaxMemory string `yaml:"max_memory"`
+
+	// ⭐ QUOTA-001: Size limit of the archives of an archive directory, e.g. "50GB"; empty is unlimited
+	Quota string `yaml:"quota"`
+	// QuotaPolicy is "fail" to refuse an archive over the quota, or "prune" to delete the oldest archives first.
+	QuotaPolicy string `yaml:"quota_policy"`
+
+	// ⭐ KEEP-GOING-001: Skip unreadable files instead of aborting the archive
+	KeepGoing bool `yaml:"keep_going"`
+
+	// ⭐ POWER-001: Defer archiving and verification on battery or under load
+	PowerAware bool `yaml:"power_aware"`
+	// PowerMaxLoadPercent is the 1-minute load average per CPU, in percent,
+	// above which power_aware defers; 0 only checks the battery.
+	PowerMaxLoadPercent int `yaml:"power_max_load_percent"`
+
+	// ⭐ VOLATILE-001: Patterns of files whose changes alone do not make an incremental archive
+	VolatilePatterns []string `yaml:"volatile_patterns"`
+
+	// ⭐ LAST-001: Runs kept per target in the state directory for `bkpdir last`; 0 records none
+	RunHistory int `yaml:"run_history"`
+
+	// ⭐ FULL-AGE-001: Age above which the base full archive of inc is too old,
+	// such as "7d"; empty is no limit
+	MaxFullArchiveAge string `yaml:"max_full_archive_age"`
+	// FullArchiveAgeAction is "warn" to archive and exit with
+	// status_full_archive_too_old, or "fail" to refuse the incremental archive.
+	FullArchiveAgeAction string `yaml:"full_archive_age_action"`
+
+	// ⭐ SUDO-OWNER-001: Give files created through sudo back to the user
+	// named by SUDO_UID and SUDO_GID
+	PreserveInvokingUserOwnership bool `yaml:"preserve_invoking_user_ownership"`
+
+	// ⭐ FOREIGN-001: What `bkpdir gc --foreign` does with files in the archive
+	// directory that bkpdir did not create: "ignore" or "quarantine"
+	ForeignFiles string `yaml:"foreign_files"`
+
+	// ⭐ NESTED-DIR-001: What archive runs do when archive_dir_path or
+	// backup_dir_path is inside the archived directory: "exclude" or "error"
+	NestedArchiveDirs string `yaml:"nested_archive_dirs"`
+
+	// ⭐ DEDUP-001: Record the SHA-256 of every archived file in the manifest
+	ManifestFileHashes bool `yaml:"manifest_file_hashes"`
+
+	// ⭐ FILE-COMPARE-001: How a file is compared with its most recent backup
+	FileCompare string `yaml:"file_compare"`
+
+	// ⭐ META-BACKUP-001: Back up permission, ownership and xattr changes of identical files
+	BackupMetadataChanges bool `yaml:"backup_metadata_changes"`
+
+	// ⭐ MANIFEST-AUDIT-001: Record the files left out of an archive in its manifest
+	ManifestExclusions bool `yaml:"manifest_exclusions"`
+
+	// ⭐ CHANGES-001: Journal the files created, modified and deleted between archives
+	ChangeJournal bool `yaml:"change_journal"`
+
+	// ⭐ AUDIT-001: Record create, prune, restore and config-set actions in a hash-chained audit log
+	AuditLog bool `yaml:"audit_log"`
+
+	// ⭐ COLOR-001: Plain ASCII status markers for logs and limited terminals
+	ASCIIOnly bool `yaml:"ascii_only"`
+
 	// ⭐ CFG-005: Configuration inheritance support - 🔧 Core inheritance functionality
 	// Inherit specifies configuration files to inherit from
 	Inherit []string `yaml:"inherit,omitempty"`
@@ -77,10 +202,42 @@ type Config struct {
 	// Git configuration for repository detection and information extraction
 	Git *GitConfig `yaml:"git,omitempty"`
 
+	// ⭐ CDC-001: Experimental deduplicating chunk repository - 🔧
+	// Repository stores full archives as chunked snapshots when its path is set.
+	Repository *RepositoryConfig `yaml:"repository,omitempty"`
+
+	// ⭐ ENCRYPT-001: Encryption at rest - 🔧
+	// Encryption holds the key source and what is encrypted.
+	Encryption *EncryptionConfig `yaml:"encryption,omitempty"`
+
+	// ⭐ REMOTE-001: Remote storage - 🔧
+	// Remote holds credentials and transfer settings of remotes.
+	Remote *RemoteConfig `yaml:"remote,omitempty"`
+
+	// ⭐ TIER-001: Age-based tiering to cold storage - 🔧
+	// Tiering moves old archives to a cold remote with `bkpdir tier`.
+	Tiering *TieringConfig `yaml:"tiering,omitempty"`
+
+	// ⭐ SHARED-001: Archive directories shared by several users - 🔧
+	// Shared namespaces archives per user and sets the modes of created files.
+	Shared *SharedConfig `yaml:"shared,omitempty"`
+
+	// ⭐ HOOK-001: Database dumps included in full archives - 🔧
+	// Hooks maps built-in database hook names to the database each dumps
+	// before a full archive.
+	Hooks map[string]DatabaseHookConfig `yaml:"hooks,omitempty"`
+
+	// ⭐ PLUGIN-001: External processing stages - 🔧
+	// Plugins process each archived file, in order, before it is compressed.
+	Plugins []PluginConfig `yaml:"plugins,omitempty"`
+
 	// 🔶 REFACTOR-003: Schema separation - File backup specific settings - 🔧
 	// File backup settings
 	BackupDirPath             string `yaml:"backup_dir_path"`
 	UseCurrentDirNameForFiles bool   `yaml:"use_current_dir_name_for_files"`
+	// ⭐ HOME-REL-001: Store backups of files under the home directory by their
+	// home-relative path, independent of the working directory
+	UseHomeRelativePaths bool `yaml:"use_home_relative_paths"`
 
 	// 🔶 REFACTOR-003: Schema separation - Backup application status codes - 🔧
 	// Status codes for directory operations
@@ -92,6 +249,14 @@ type Config struct {
 	StatusPermissionDenied                      int `yaml:"status_permission_denied"`
 	StatusDiskFull                              int `yaml:"status_disk_full"`
 	StatusConfigError                           int `yaml:"status_config_error"`
+	// ⭐ KEEP-GOING-001: Archive created with some files skipped
+	StatusPartialArchive int `yaml:"status_partial_archive"`
+	// ⭐ QUOTA-001: Exit code when a new archive would exceed the quota
+	StatusQuotaExceeded int `yaml:"status_quota_exceeded"`
+	// ⭐ POWER-001: Exit code when power_aware deferred the run
+	StatusDeferred int `yaml:"status_deferred"`
+	// ⭐ FULL-AGE-001: Exit code when the base full archive is older than max_full_archive_age
+	StatusFullArchiveTooOld int `yaml:"status_full_archive_too_old"`
 
 	// Status codes for file operations
 	StatusCreatedBackup                   int `yaml:"status_created_backup"`
@@ -140,10 +305,16 @@ type Config struct {
 	// 🔺 CFG-004: Extended format strings for comprehensive string configuration - 📝
 	// 🔶 REFACTOR-003: Schema separation - Extended backup operation messages - 📝
 	// Archive operation messages
-	FormatNoArchivesFound      string `yaml:"format_no_archives_found"`
-	FormatVerificationFailed   string `yaml:"format_verification_failed"`
-	FormatVerificationSuccess  string `yaml:"format_verification_success"`
-	FormatVerificationWarning  string `yaml:"format_verification_warning"`
+	FormatNoArchivesFound     string `yaml:"format_no_archives_found"`
+	FormatVerificationFailed  string `yaml:"format_verification_failed"`
+	FormatVerificationSuccess string `yaml:"format_verification_success"`
+	FormatVerificationWarning string `yaml:"format_verification_warning"`
+	// ⭐ ARCH-006: Sampled verification summary
+	FormatVerificationSample string `yaml:"format_verification_sample"`
+	// ⭐ SEAL-001: Integrity seal mismatch warning
+	FormatIntegritySealMismatch string `yaml:"format_integrity_seal_mismatch"`
+	// ⭐ VERIFY-QUICK-001: Passed quick structural check
+	FormatVerificationQuick    string `yaml:"format_verification_quick"`
 	FormatConfigurationUpdated string `yaml:"format_configuration_updated"`
 	FormatConfigFilePath       string `yaml:"format_config_file_path"`
 	FormatDryRunFilesHeader    string `yaml:"format_dry_run_files_header"`
@@ -238,12 +409,15 @@ type ConfigValue struct {
 // Default regex patterns
 const (
 	defaultArchivePattern = `(?P<prefix>[^-]*)-(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2})-` +
-		`(?P<hour>\d{2})-(?P<minute>\d{2})(?:=(?P<branch>[^=]+))?(?:=(?P<hash>[^=]+))?(?:=(?P<note>.+))?\.zip`
+		`(?P<hour>\d{2})-(?P<minute>\d{2})(?:_(?P<sequence>\d+))?(?:=(?P<branch>[^=]+))?(?:=(?P<hash>[^=]+))?(?:=(?P<note>.+))?\.zip`
 	defaultBackupPattern = `(?P<filename>[^/]+)-(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2})-` +
-		`(?P<hour>\d{2})-(?P<minute>\d{2})(?:=(?P<note>.+))?`
+		`(?P<hour>\d{2})-(?P<minute>\d{2})(?:_(?P<sequence>\d+))?(?:=(?P<note>.+))?`
 	defaultConfigPattern    = `(?P<name>[^:]+):\s*(?P<value>[^(]+)\s*\(source:\s*(?P<source>[^)]+)\)`
 	defaultTimestampPattern = `(?P<year>\d{4})-(?P<month>\d{2})-(?P<day>\d{2})\s+` +
 		`(?P<hour>\d{2}):(?P<minute>\d{2}):(?P<second>\d{2})`
+
+	// ⭐ ARCH-005: Default layout for the timestamp portion of archive and backup names
+	defaultTimestampFormat = "2006-01-02-15-04"
 )
 
 // 🔺 CFG-001: Default configuration implementation - 📝
@@ -261,6 +435,7 @@ func DefaultConfig() *Config {
 		ArchiveDirPath:     "../.bkpdir",
 		UseCurrentDirName:  true,
 		ExcludePatterns:    []string{".git/", "vendor/"},
+		ExcludeFrom:        []string{},
 		IncludeGitInfo:     false,
 		ShowGitDirtyStatus: true,
 		SkipBrokenSymlinks: false,
@@ -269,12 +444,87 @@ func DefaultConfig() *Config {
 			ChecksumAlgorithm: "sha256",
 		},
 
+		// ⭐ ARCH-005: Local clock and minute precision preserve the original naming
+		TimestampTimezone: "local",
+		TimestampFormat:   defaultTimestampFormat,
+		// ⭐ TRASH-001: Trash defaults
+		TrashDirPath:       "../.bkpdir-trash",
+		TrashRetentionDays: 7,
+		IntegritySeal:      false,
+		EventLog:           EventLogNone,
+		// ⭐ UNICODE-001: Restore names exactly as archived by default
+		RestoreUnicodeNormalization: UnicodeNormalizationPreserve,
+		// ⭐ RESTORE-GUARD-001: Deflate expands at most about 1030 times, so
+		// only crafted archives and files of one repeated byte go beyond
+		RestoreMaxRatio:         1000,
+		RestoreExternalSymlinks: false,
+		// ⭐ TRACE-001: Tracing follows the OTEL_* environment unless configured
+		OTLPEndpoint: "",
+		// ⭐ HEALTHCHECK-001: No pings unless configured
+		HealthcheckURL: "",
+		// ⭐ LIMIT-001: No limits unless configured; exceeded limits warn
+		MaxFileSize:  "",
+		MaxTotalSize: "",
+		MaxFileCount: 0,
+		LimitAction:  LimitActionWarn,
+		// ⭐ BENCH-001: The default level of compress/flate
+		CompressionLevel: 6,
+		// ⭐ JOBS-001: One job, as before concurrent archiving
+		ArchiveJobs: "1",
+		// ⭐ MEMORY-001: No memory limit unless configured
+		MaxMemory: "",
+		// ⭐ QUOTA-001: No quota unless configured; exceeding it fails
+		Quota:       "",
+		QuotaPolicy: QuotaPolicyFail,
+		// ⭐ KEEP-GOING-001: Abort on the first unreadable file by default
+		KeepGoing: false,
+		// ⭐ POWER-001: Runs are not deferred unless power_aware is set
+		PowerAware:          false,
+		PowerMaxLoadPercent: 80,
+		// ⭐ VOLATILE-001: Every change counts unless volatile patterns are configured
+		VolatilePatterns: []string{},
+		// ⭐ LAST-001: Keep the last 20 runs of each target
+		RunHistory: 20,
+		// ⭐ FULL-AGE-001: Full archives never get too old unless a limit is set
+		MaxFullArchiveAge:    "",
+		FullArchiveAgeAction: FullAgeActionWarn,
+		// ⭐ SUDO-OWNER-001: Files created through sudo stay owned by root unless enabled
+		PreserveInvokingUserOwnership: false,
+		// ⭐ FOREIGN-001: Foreign files are only reported unless quarantine is set
+		ForeignFiles: ForeignFilesIgnore,
+		// ⭐ NESTED-DIR-001: Nested output directories are excluded with a warning
+		NestedArchiveDirs: NestedDirsExclude,
+		// ⭐ DEDUP-001: Hashing re-reads each new archive, so it is opt-in
+		ManifestFileHashes: false,
+		// ⭐ FILE-COMPARE-001: Modification times and recorded checksums spare reading backups
+		FileCompare: FileCompareAuto,
+		// ⭐ META-BACKUP-001: Identical files are not backed up again by default
+		BackupMetadataChanges: false,
+		// ⭐ MANIFEST-AUDIT-001: Listing excluded files walks the directory again, so it is opt-in
+		ManifestExclusions: false,
+		// ⭐ CHANGES-001: The change journal is opt-in
+		ChangeJournal: false,
+		// ⭐ AUDIT-001: The audit log is opt-in
+		AuditLog: false,
+		// ⭐ COLOR-001: Emoji and box-drawing markers are shown by default
+		ASCIIOnly: false,
+
 		// 🔶 GIT-005: Git configuration integration - default configuration
 		Git: DefaultGitConfig(),
 
+		// ⭐ CDC-001: Archives are zip files unless a repository path is set
+		Repository: DefaultRepositoryConfig(),
+
+		// ⭐ ENCRYPT-001: Nothing is encrypted by default
+		Encryption: DefaultEncryptionConfig(),
+		Remote:     DefaultRemoteConfig(),
+		Tiering:    DefaultTieringConfig(),
+		Shared:     DefaultSharedConfig(),
+
 		// File backup settings
 		BackupDirPath:             "../.bkpdir",
 		UseCurrentDirNameForFiles: true,
+		UseHomeRelativePaths:      false,
 
 		// Status codes for directory operations
 		StatusCreatedArchive:                        0,
@@ -285,6 +535,10 @@ func DefaultConfig() *Config {
 		StatusPermissionDenied:                      22,
 		StatusDiskFull:                              30,
 		StatusConfigError:                           10,
+		StatusPartialArchive:                        40,
+		StatusQuotaExceeded:                         32,
+		StatusDeferred:                              75,
+		StatusFullArchiveTooOld:                     41,
 
 		// Status codes for file operations
 		StatusCreatedBackup:                   0,
@@ -329,10 +583,15 @@ func DefaultConfig() *Config {
 
 		// 🔺 CFG-004: Extended format strings for comprehensive string configuration - 📝
 		// Archive operation messages
-		FormatNoArchivesFound:      "No archives found in %s\n",
-		FormatVerificationFailed:   "Archive %s verification failed: %v\n",
-		FormatVerificationSuccess:  "Archive %s verified successfully\n",
-		FormatVerificationWarning:  "Warning: Could not store verification status for %s: %v\n",
+		FormatNoArchivesFound:     "No archives found in %s\n",
+		FormatVerificationFailed:  "Archive %s verification failed: %v\n",
+		FormatVerificationSuccess: "Archive %s verified successfully\n",
+		FormatVerificationWarning: "Warning: Could not store verification status for %s: %v\n",
+		FormatVerificationSample: "Archive %s: sampled %d of %d entries; " +
+			"95%% confidence that at most %.1f%% of entries are corrupt\n",
+		FormatIntegritySealMismatch: "Warning: Archive %s does not match its integrity seal; " +
+			"it may have been modified by another tool\n",
+		FormatVerificationQuick:    "Archive %s structure is intact (quick check, data not read)\n",
 		FormatConfigurationUpdated: "Configuration updated: %s = %v\n",
 		FormatConfigFilePath:       "Config file: %s\n",
 		FormatDryRunFilesHeader:    "[Dry Run] Files to include:\n",
@@ -417,7 +676,8 @@ func getConfigSearchPaths() []string {
 	}
 
 	// Default search path
-	return []string{"./.bkpdir.yml", "~/.bkpdir.yml"}
+	// ⭐ XDG-001: The user configuration file in the XDG config directory
+	return []string{"./.bkpdir.yml", userConfigSearchPath(), "~/.bkpdir.yml"}
 }
 
 // 🔺 CFG-001: Path expansion implementation - 🔍
@@ -437,6 +697,86 @@ func expandPath(path string) string {
 	return path
 }
 
+// ⭐ ARCH-005: Timestamp clock resolution - 🔧
+// TEST-REF: TestResolveTimestampLocation
+// ResolveTimestampLocation maps a timestamp_timezone value to a time.Location.
+// An empty value or "local" selects the system clock, "UTC" is case-insensitive,
+// and anything else is looked up as an IANA zone name.
+func ResolveTimestampLocation(tz string) (*time.Location, error) {
+	switch strings.ToLower(strings.TrimSpace(tz)) {
+	case "", "local":
+		return time.Local, nil
+	case "utc":
+		return time.UTC, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timestamp_timezone %q: %w", tz, err)
+	}
+	return loc, nil
+}
+
+// ⭐ ARCH-005: Name timestamp rendering - 🔧
+// TEST-REF: TestFormatNameTimestamp
+// FormatNameTimestamp renders t for use in archive and backup names using the
+// configured timezone and layout. Invalid settings fall back to the defaults;
+// LoadConfig rejects them up front so this only matters for hand-built configs.
+func FormatNameTimestamp(timezone, layout string, t time.Time) string {
+	loc, err := ResolveTimestampLocation(timezone)
+	if err != nil {
+		loc = time.Local
+	}
+	if layout == "" {
+		layout = defaultTimestampFormat
+	}
+	return t.In(loc).Format(layout)
+}
+
+// ⭐ ARCH-005: Timestamp settings validation - 🛡️
+// TEST-REF: TestValidateTimestampSettings
+// ValidateTimestampSettings checks that timestamp_timezone resolves and that
+// timestamp_format produces names the archive and backup filename patterns can
+// parse back into the same date and time.
+func ValidateTimestampSettings(cfg *Config) error {
+	if _, err := ResolveTimestampLocation(cfg.TimestampTimezone); err != nil {
+		return err
+	}
+	if cfg.TimestampFormat == "" {
+		return fmt.Errorf("timestamp_format must not be empty")
+	}
+
+	sample := time.Date(2024, time.December, 31, 23, 59, 0, 0, time.UTC)
+	stamp := sample.Format(cfg.TimestampFormat)
+
+	checks := []struct {
+		key     string
+		pattern string
+		name    string
+	}{
+		{"pattern_archive_filename", cfg.PatternArchiveFilename, "bkpdir-" + stamp + ".zip"},
+		{"pattern_backup_filename", cfg.PatternBackupFilename, "file.txt-" + stamp},
+	}
+	for _, check := range checks {
+		re, err := regexp.Compile(check.pattern)
+		if err != nil {
+			return fmt.Errorf("invalid %s: %w", check.key, err)
+		}
+		match := re.FindStringSubmatch(check.name)
+		if match == nil {
+			return fmt.Errorf("timestamp_format %q produces names that %s cannot parse",
+				cfg.TimestampFormat, check.key)
+		}
+		want := map[string]string{"year": "2024", "month": "12", "day": "31", "hour": "23", "minute": "59"}
+		for i, group := range re.SubexpNames() {
+			if expected, ok := want[group]; ok && match[i] != expected {
+				return fmt.Errorf("timestamp_format %q yields %s=%q under %s, expected %q",
+					cfg.TimestampFormat, group, match[i], check.key, expected)
+			}
+		}
+	}
+	return nil
+}
+
 // 🔺 CFG-001: Configuration loading implementation - 🔍
 // IMMUTABLE-REF: Configuration Discovery
 // TEST-REF: TestGetConfigSearchPath
@@ -449,7 +789,8 @@ func LoadConfig(root string) (*Config, error) {
 	// Try loading with inheritance first (the new default behavior)
 	cfg, err := LoadConfigWithInheritance(root)
 	if err == nil {
-		return cfg, nil
+		// ⭐ ARCH-005: Reject timestamp settings that would produce unparseable names
+		return cfg, ValidateTimestampSettings(cfg)
 	}
 
 	// If inheritance loading fails, fallback to original method for backward compatibility
@@ -469,21 +810,17 @@ func LoadConfig(root string) (*Config, error) {
 		}
 
 		if _, err := os.Stat(expandedPath); err == nil {
-			f, err := os.Open(expandedPath)
+			data, err := os.ReadFile(expandedPath)
 			if err != nil {
 				continue // Skip files we can't open
 			}
-			defer f.Close()
 
 			// 🔶 REFACTOR-003: Schema separation - Hardcoded Config struct unmarshaling - 🔧
 			// Create a temporary config to load into
 			tempCfg := DefaultConfig()
-			d := yaml.NewDecoder(f)
-			if err := d.Decode(tempCfg); err != nil {
-				f.Close()
+			if err := unmarshalConfigYAML(data, tempCfg); err != nil {
 				continue // Skip files with invalid YAML
 			}
-			f.Close()
 
 			// 🔶 REFACTOR-003: Config abstraction - Schema-specific merging logic - 📝
 			// Merge non-zero values from tempCfg into cfg
@@ -492,7 +829,7 @@ func LoadConfig(root string) (*Config, error) {
 		}
 	}
 
-	return cfg, nil
+	return cfg, ValidateTimestampSettings(cfg)
 }
 
 // 🔺 CFG-001: Configuration merging implementation - 🔍
@@ -514,6 +851,145 @@ func mergeConfigs(dst, src *Config) {
 	mergeExtendedTemplates(dst, src)
 	// 🔶 GIT-005: Git configuration merging
 	mergeGitSettings(dst, src)
+	// ⭐ CDC-001: Repository configuration merging
+	mergeRepositorySettings(dst, src)
+	// ⭐ ENCRYPT-001: Encryption configuration merging
+	mergeEncryptionSettings(dst, src)
+	// ⭐ REMOTE-001: Remote storage configuration merging
+	mergeRemoteSettings(dst, src)
+	// ⭐ TIER-001: Tiering configuration merging
+	mergeTieringSettings(dst, src)
+	// ⭐ SHARED-001: Shared archive directory merging
+	mergeSharedSettings(dst, src)
+	// ⭐ HOOK-001: Database hook merging
+	mergeHookSettings(dst, src)
+	// ⭐ PLUGIN-001: A configured plugin list replaces the inherited one
+	if len(src.Plugins) > 0 {
+		dst.Plugins = src.Plugins
+	}
+}
+
+// ⭐ CDC-001: Repository configuration merging - 📝
+// mergeRepositorySettings merges the repository section field by field.
+func mergeRepositorySettings(dst, src *Config) {
+	defaultRepo := DefaultRepositoryConfig()
+	if dst.Repository == nil {
+		dst.Repository = DefaultRepositoryConfig()
+	}
+	if src.Repository == nil {
+		return
+	}
+	if src.Repository.Path != defaultRepo.Path {
+		dst.Repository.Path = src.Repository.Path
+	}
+	if src.Repository.AverageChunkSize != defaultRepo.AverageChunkSize {
+		dst.Repository.AverageChunkSize = src.Repository.AverageChunkSize
+	}
+}
+
+// ⭐ ENCRYPT-001: Encryption configuration merging - 📝
+// mergeEncryptionSettings merges the encryption section field by field.
+func mergeEncryptionSettings(dst, src *Config) {
+	defaultEnc := DefaultEncryptionConfig()
+	if dst.Encryption == nil {
+		dst.Encryption = DefaultEncryptionConfig()
+	}
+	if src.Encryption == nil {
+		return
+	}
+	if src.Encryption.FileBackups != defaultEnc.FileBackups {
+		dst.Encryption.FileBackups = src.Encryption.FileBackups
+	}
+	if src.Encryption.KeyFile != defaultEnc.KeyFile {
+		dst.Encryption.KeyFile = src.Encryption.KeyFile
+	}
+	if src.Encryption.PassphraseEnv != defaultEnc.PassphraseEnv {
+		dst.Encryption.PassphraseEnv = src.Encryption.PassphraseEnv
+	}
+	if src.Encryption.Passphrase != defaultEnc.Passphrase {
+		dst.Encryption.Passphrase = src.Encryption.Passphrase
+	}
+}
+
+// ⭐ REMOTE-001: Remote storage configuration merging - 📝
+// mergeRemoteSettings merges the remote section field by field.
+func mergeRemoteSettings(dst, src *Config) {
+	defaultRemote := DefaultRemoteConfig()
+	if dst.Remote == nil {
+		dst.Remote = DefaultRemoteConfig()
+	}
+	if src.Remote == nil {
+		return
+	}
+	if src.Remote.S3Region != defaultRemote.S3Region {
+		dst.Remote.S3Region = src.Remote.S3Region
+	}
+	if src.Remote.S3Endpoint != defaultRemote.S3Endpoint {
+		dst.Remote.S3Endpoint = src.Remote.S3Endpoint
+	}
+	if src.Remote.S3AccessKeyID != defaultRemote.S3AccessKeyID {
+		dst.Remote.S3AccessKeyID = src.Remote.S3AccessKeyID
+	}
+	if src.Remote.S3SecretAccessKey != defaultRemote.S3SecretAccessKey {
+		dst.Remote.S3SecretAccessKey = src.Remote.S3SecretAccessKey
+	}
+	if src.Remote.UploadPartSize != defaultRemote.UploadPartSize {
+		dst.Remote.UploadPartSize = src.Remote.UploadPartSize
+	}
+	if src.Remote.ListingCacheTTL != defaultRemote.ListingCacheTTL {
+		dst.Remote.ListingCacheTTL = src.Remote.ListingCacheTTL
+	}
+}
+
+// ⭐ TIER-001: Tiering configuration merging - 📝
+// mergeTieringSettings merges the tiering section field by field.
+func mergeTieringSettings(dst, src *Config) {
+	defaultTiering := DefaultTieringConfig()
+	if dst.Tiering == nil {
+		dst.Tiering = DefaultTieringConfig()
+	}
+	if src.Tiering == nil {
+		return
+	}
+	if src.Tiering.ColdRemote != defaultTiering.ColdRemote {
+		dst.Tiering.ColdRemote = src.Tiering.ColdRemote
+	}
+	if src.Tiering.AfterDays != defaultTiering.AfterDays {
+		dst.Tiering.AfterDays = src.Tiering.AfterDays
+	}
+	if src.Tiering.StorageClass != defaultTiering.StorageClass {
+		dst.Tiering.StorageClass = src.Tiering.StorageClass
+	}
+}
+
+// ⭐ SHARED-001: Shared archive directory merging - 📝
+// mergeSharedSettings merges the shared section field by field.
+func mergeSharedSettings(dst, src *Config) {
+	defaultShared := DefaultSharedConfig()
+	if dst.Shared == nil {
+		dst.Shared = DefaultSharedConfig()
+	}
+	if src.Shared == nil {
+		return
+	}
+	if src.Shared.UserNamespace != "" && src.Shared.UserNamespace != defaultShared.UserNamespace {
+		dst.Shared.UserNamespace = src.Shared.UserNamespace
+	}
+	if src.Shared.Umask != defaultShared.Umask {
+		dst.Shared.Umask = src.Shared.Umask
+	}
+}
+
+// ⭐ HOOK-001: Database hook merging - 📝
+// mergeHookSettings merges the hooks section per database; a configured
+// database replaces the inherited settings of that database as a whole.
+func mergeHookSettings(dst, src *Config) {
+	for name, hook := range src.Hooks {
+		if dst.Hooks == nil {
+			dst.Hooks = make(map[string]DatabaseHookConfig)
+		}
+		dst.Hooks[name] = hook
+	}
 }
 
 // 🔺 CFG-001: Basic settings merging implementation - 🔍
@@ -532,6 +1008,9 @@ func mergeBasicSettings(dst, src *Config) {
 	if len(src.ExcludePatterns) > 0 && !equalStringSlices(src.ExcludePatterns, DefaultConfig().ExcludePatterns) {
 		dst.ExcludePatterns = src.ExcludePatterns
 	}
+	if len(src.ExcludeFrom) > 0 {
+		dst.ExcludeFrom = src.ExcludeFrom
+	}
 	if src.IncludeGitInfo != DefaultConfig().IncludeGitInfo {
 		dst.IncludeGitInfo = src.IncludeGitInfo
 	}
@@ -544,6 +1023,138 @@ func mergeBasicSettings(dst, src *Config) {
 	if src.Verification != nil {
 		dst.Verification = src.Verification
 	}
+	mergeTimestampSettings(dst, src)
+	// ⭐ TRASH-001: Trash settings
+	if src.TrashDirPath != DefaultConfig().TrashDirPath {
+		dst.TrashDirPath = src.TrashDirPath
+	}
+	if src.TrashRetentionDays != DefaultConfig().TrashRetentionDays {
+		dst.TrashRetentionDays = src.TrashRetentionDays
+	}
+	// ⭐ SEAL-001: Integrity seal setting
+	if src.IntegritySeal != DefaultConfig().IntegritySeal {
+		dst.IntegritySeal = src.IntegritySeal
+	}
+	// ⭐ EVENT-001: Lifecycle event sink
+	if src.EventLog != DefaultConfig().EventLog {
+		dst.EventLog = src.EventLog
+	}
+	// ⭐ UNICODE-001: Filename normalization on restore
+	if src.RestoreUnicodeNormalization != "" && src.RestoreUnicodeNormalization != DefaultConfig().RestoreUnicodeNormalization {
+		dst.RestoreUnicodeNormalization = src.RestoreUnicodeNormalization
+	}
+	// ⭐ RESTORE-GUARD-001: Restore limits
+	if src.RestoreMaxRatio != DefaultConfig().RestoreMaxRatio {
+		dst.RestoreMaxRatio = src.RestoreMaxRatio
+	}
+	if src.RestoreExternalSymlinks != DefaultConfig().RestoreExternalSymlinks {
+		dst.RestoreExternalSymlinks = src.RestoreExternalSymlinks
+	}
+	// ⭐ TRACE-001: OTLP collector endpoint
+	if src.OTLPEndpoint != DefaultConfig().OTLPEndpoint {
+		dst.OTLPEndpoint = src.OTLPEndpoint
+	}
+	// ⭐ HEALTHCHECK-001: Healthcheck ping URL
+	if src.HealthcheckURL != DefaultConfig().HealthcheckURL {
+		dst.HealthcheckURL = src.HealthcheckURL
+	}
+	// ⭐ LIMIT-001: Resource limits
+	if src.MaxFileSize != DefaultConfig().MaxFileSize {
+		dst.MaxFileSize = src.MaxFileSize
+	}
+	if src.MaxTotalSize != DefaultConfig().MaxTotalSize {
+		dst.MaxTotalSize = src.MaxTotalSize
+	}
+	if src.MaxFileCount != DefaultConfig().MaxFileCount {
+		dst.MaxFileCount = src.MaxFileCount
+	}
+	if src.LimitAction != "" && src.LimitAction != DefaultConfig().LimitAction {
+		dst.LimitAction = src.LimitAction
+	}
+	// ⭐ BENCH-001: Compression level
+	if src.CompressionLevel != DefaultConfig().CompressionLevel {
+		dst.CompressionLevel = src.CompressionLevel
+	}
+	// ⭐ JOBS-001: Archive jobs
+	if src.ArchiveJobs != "" && src.ArchiveJobs != DefaultConfig().ArchiveJobs {
+		dst.ArchiveJobs = src.ArchiveJobs
+	}
+	// ⭐ MEMORY-001: Memory limit
+	if src.MaxMemory != DefaultConfig().MaxMemory {
+		dst.MaxMemory = src.MaxMemory
+	}
+	// ⭐ QUOTA-001: Archive directory quota
+	if src.Quota != DefaultConfig().Quota {
+		dst.Quota = src.Quota
+	}
+	if src.QuotaPolicy != "" && src.QuotaPolicy != DefaultConfig().QuotaPolicy {
+		dst.QuotaPolicy = src.QuotaPolicy
+	}
+	// ⭐ KEEP-GOING-001: Keep-going mode
+	if src.KeepGoing != DefaultConfig().KeepGoing {
+		dst.KeepGoing = src.KeepGoing
+	}
+	// ⭐ POWER-001: Power-aware scheduling
+	if src.PowerAware != DefaultConfig().PowerAware {
+		dst.PowerAware = src.PowerAware
+	}
+	if src.PowerMaxLoadPercent != DefaultConfig().PowerMaxLoadPercent {
+		dst.PowerMaxLoadPercent = src.PowerMaxLoadPercent
+	}
+	// ⭐ VOLATILE-001: Volatile patterns
+	if len(src.VolatilePatterns) > 0 {
+		dst.VolatilePatterns = src.VolatilePatterns
+	}
+	// ⭐ LAST-001: Run history
+	if src.RunHistory != DefaultConfig().RunHistory {
+		dst.RunHistory = src.RunHistory
+	}
+	// ⭐ FULL-AGE-001: Full archive age policy
+	if src.MaxFullArchiveAge != DefaultConfig().MaxFullArchiveAge {
+		dst.MaxFullArchiveAge = src.MaxFullArchiveAge
+	}
+	if src.FullArchiveAgeAction != "" && src.FullArchiveAgeAction != DefaultConfig().FullArchiveAgeAction {
+		dst.FullArchiveAgeAction = src.FullArchiveAgeAction
+	}
+	// ⭐ SUDO-OWNER-001: Ownership of files created through sudo
+	if src.PreserveInvokingUserOwnership != DefaultConfig().PreserveInvokingUserOwnership {
+		dst.PreserveInvokingUserOwnership = src.PreserveInvokingUserOwnership
+	}
+	// ⭐ FOREIGN-001: Foreign file policy
+	if src.ForeignFiles != "" && src.ForeignFiles != DefaultConfig().ForeignFiles {
+		dst.ForeignFiles = src.ForeignFiles
+	}
+	// ⭐ NESTED-DIR-001: Nested output directory policy
+	if src.NestedArchiveDirs != "" && src.NestedArchiveDirs != DefaultConfig().NestedArchiveDirs {
+		dst.NestedArchiveDirs = src.NestedArchiveDirs
+	}
+	if src.ManifestFileHashes != DefaultConfig().ManifestFileHashes {
+		dst.ManifestFileHashes = src.ManifestFileHashes
+	}
+	// ⭐ FILE-COMPARE-001: File comparison mode
+	if src.FileCompare != "" && src.FileCompare != DefaultConfig().FileCompare {
+		dst.FileCompare = src.FileCompare
+	}
+	// ⭐ META-BACKUP-001: Metadata revisions
+	if src.BackupMetadataChanges != DefaultConfig().BackupMetadataChanges {
+		dst.BackupMetadataChanges = src.BackupMetadataChanges
+	}
+	// ⭐ MANIFEST-AUDIT-001: Excluded files in the manifest
+	if src.ManifestExclusions != DefaultConfig().ManifestExclusions {
+		dst.ManifestExclusions = src.ManifestExclusions
+	}
+	// ⭐ CHANGES-001: Change journal
+	if src.ChangeJournal != DefaultConfig().ChangeJournal {
+		dst.ChangeJournal = src.ChangeJournal
+	}
+	// ⭐ AUDIT-001: Operation audit log
+	if src.AuditLog != DefaultConfig().AuditLog {
+		dst.AuditLog = src.AuditLog
+	}
+	// ⭐ COLOR-001: ASCII-only output
+	if src.ASCIIOnly != DefaultConfig().ASCIIOnly {
+		dst.ASCIIOnly = src.ASCIIOnly
+	}
 	// 🔶 GIT-005: Git configuration integration - legacy Git field support
 	// Support legacy Git fields for backward compatibility
 	if src.Git != nil {
@@ -560,6 +1171,18 @@ func mergeBasicSettings(dst, src *Config) {
 	}
 }
 
+// ⭐ ARCH-005: Timestamp clock settings - 🔧
+// mergeTimestampSettings copies the timestamp keys of src that differ from
+// the defaults.
+func mergeTimestampSettings(dst, src *Config) {
+	if src.TimestampTimezone != DefaultConfig().TimestampTimezone {
+		dst.TimestampTimezone = src.TimestampTimezone
+	}
+	if src.TimestampFormat != DefaultConfig().TimestampFormat {
+		dst.TimestampFormat = src.TimestampFormat
+	}
+}
+
 // 🔶 GIT-005: Git configuration merging implementation - 📝
 // mergeGitSettings merges Git configuration settings between configs.
 // It handles both the new Git configuration and legacy fields for backward compatibility.
@@ -606,6 +1229,9 @@ func mergeGitConfigStruct(dst, src, defaultCfg *GitConfig) {
 	if src.WorkingDirectory != defaultCfg.WorkingDirectory {
 		dst.WorkingDirectory = src.WorkingDirectory
 	}
+	if src.Provider != defaultCfg.Provider {
+		dst.Provider = src.Provider
+	}
 	if src.RequireCleanRepo != defaultCfg.RequireCleanRepo {
 		dst.RequireCleanRepo = src.RequireCleanRepo
 	}
@@ -624,6 +1250,9 @@ func mergeGitConfigStruct(dst, src, defaultCfg *GitConfig) {
 	if src.IncludeStatus != defaultCfg.IncludeStatus {
 		dst.IncludeStatus = src.IncludeStatus
 	}
+	if src.IncludeTags != defaultCfg.IncludeTags {
+		dst.IncludeTags = src.IncludeTags
+	}
 	if src.CommandTimeout != defaultCfg.CommandTimeout {
 		dst.CommandTimeout = src.CommandTimeout
 	}
@@ -645,6 +1274,9 @@ func mergeFileBackupSettings(dst, src *Config) {
 	if src.UseCurrentDirNameForFiles != DefaultConfig().UseCurrentDirNameForFiles {
 		dst.UseCurrentDirNameForFiles = src.UseCurrentDirNameForFiles
 	}
+	if src.UseHomeRelativePaths != DefaultConfig().UseHomeRelativePaths {
+		dst.UseHomeRelativePaths = src.UseHomeRelativePaths
+	}
 }
 
 // 🔺 CFG-002: Status code merging implementation - 🔍
@@ -701,6 +1333,22 @@ func mergeDirectoryStatusCodes(dst, src *Config) {
 			&src.StatusConfigError,
 			&dst.StatusConfigError,
 		},
+		"partial_archive": {
+			&src.StatusPartialArchive,
+			&dst.StatusPartialArchive,
+		},
+		"quota_exceeded": {
+			&src.StatusQuotaExceeded,
+			&dst.StatusQuotaExceeded,
+		},
+		"deferred": {
+			&src.StatusDeferred,
+			&dst.StatusDeferred,
+		},
+		"full_archive_too_old": {
+			&src.StatusFullArchiveTooOld,
+			&dst.StatusFullArchiveTooOld,
+		},
 	}
 
 	for _, codes := range statusCodes {
@@ -1184,6 +1832,15 @@ func mergeExtendedFormatStrings(dst, src *Config) {
 	if src.FormatVerificationWarning != defaultCfg.FormatVerificationWarning {
 		dst.FormatVerificationWarning = src.FormatVerificationWarning
 	}
+	if src.FormatVerificationSample != defaultCfg.FormatVerificationSample {
+		dst.FormatVerificationSample = src.FormatVerificationSample
+	}
+	if src.FormatIntegritySealMismatch != defaultCfg.FormatIntegritySealMismatch {
+		dst.FormatIntegritySealMismatch = src.FormatIntegritySealMismatch
+	}
+	if src.FormatVerificationQuick != defaultCfg.FormatVerificationQuick {
+		dst.FormatVerificationQuick = src.FormatVerificationQuick
+	}
 	if src.FormatConfigurationUpdated != defaultCfg.FormatConfigurationUpdated {
 		dst.FormatConfigurationUpdated = src.FormatConfigurationUpdated
 	}
@@ -1293,6 +1950,10 @@ func mergeExtendedTemplates(dst, src *Config) {
 func (c *Config) GetStatusCodes() map[string]int {
 	return map[string]int{
 		"disk_full":                               c.StatusDiskFull,
+		"partial_archive":                         c.StatusPartialArchive,
+		"quota_exceeded":                          c.StatusQuotaExceeded,
+		"deferred":                                c.StatusDeferred,
+		"full_archive_too_old":                    c.StatusFullArchiveTooOld,
 		"permission_denied":                       c.StatusPermissionDenied,
 		"directory_not_found":                     c.StatusDirectoryNotFound,
 		"file_not_found":                          c.StatusFileNotFound,
@@ -1348,29 +2009,48 @@ func LoadConfigWithInheritance(root string) (*Config, error) {
 	pathResolver := newPathResolver(fileOps)
 	chainBuilder := newInheritanceChainBuilder(fileOps)
 
-	// Get the primary configuration file
-	searchPaths := getConfigSearchPaths()
-	var primaryConfigPath string
+	// If no config file found, return default config
+	primaryConfigPath := findPrimaryConfigPath(root)
+	if primaryConfigPath == "" {
+		return DefaultConfig(), nil
+	}
 
-	for _, configPath := range searchPaths {
+	// Load configuration with inheritance
+	return loadConfigRecursive(primaryConfigPath, pathResolver, chainBuilder)
+}
+
+// findPrimaryConfigPath returns the first configuration file of the search
+// path that exists, relative paths being taken from root, or an empty string
+// when there is none.
+func findPrimaryConfigPath(root string) string {
+	for _, configPath := range getConfigSearchPaths() {
 		expandedPath := expandPath(configPath)
 		if !filepath.IsAbs(expandedPath) {
 			expandedPath = filepath.Join(root, expandedPath)
 		}
 
 		if _, err := os.Stat(expandedPath); err == nil {
-			primaryConfigPath = expandedPath
-			break
+			return expandedPath
 		}
 	}
+	return ""
+}
 
-	// If no config file found, return default config
+// ⭐ CFG-SET-FILE-001: Files of the configuration chain - 🔍
+// configChainFiles returns the configuration files LoadConfig reads in root,
+// parents first, so later files take precedence. It is empty when only the
+// defaults apply.
+func configChainFiles(root string) ([]string, error) {
+	primaryConfigPath := findPrimaryConfigPath(root)
 	if primaryConfigPath == "" {
-		return DefaultConfig(), nil
+		return nil, nil
 	}
-
-	// Load configuration with inheritance
-	return loadConfigRecursive(primaryConfigPath, pathResolver, chainBuilder)
+	fileOps := &configFileOperations{}
+	chain, err := newInheritanceChainBuilder(fileOps).buildChain(primaryConfigPath, newPathResolver(fileOps))
+	if err != nil {
+		return nil, err
+	}
+	return chain.files, nil
 }
 
 // ⭐ CFG-005: Recursive configuration loading - 🔍 Inheritance chain processing
@@ -1406,15 +2086,13 @@ func loadConfigRecursive(configPath string, pathResolver pathResolver, chainBuil
 // ⭐ CFG-005: Single file loading - 📝 Individual config file processing
 // loadSingleConfigFile loads a single configuration file.
 func loadSingleConfigFile(configPath string) (*Config, error) {
-	f, err := os.Open(configPath)
+	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file %s: %w", configPath, err)
 	}
-	defer f.Close()
 
 	cfg := DefaultConfig()
-	d := yaml.NewDecoder(f)
-	if err := d.Decode(cfg); err != nil {
+	if err := unmarshalConfigYAML(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to decode config file %s: %w", configPath, err)
 	}
 
@@ -1442,6 +2120,10 @@ func applyMergeStrategies(dst, src *Config) (*Config, error) {
 	// Start with destination values
 	mergeConfigs(result, dst)
 
+	// ⭐ CFG-005: Inherit the keys configToMap does not expose; the keys it
+	// does expose keep their destination values for the strategy operations
+	mergeUnmappedSettings(result, src, dstMap)
+
 	// Apply source values with merge strategies
 	for key, operation := range processed.operations {
 		err := applyMergeOperation(result, key, operation, dstMap[key])
@@ -1453,6 +2135,16 @@ func applyMergeStrategies(dst, src *Config) (*Config, error) {
 	return result, nil
 }
 
+// mergeUnmappedSettings layers the non-default values of src onto result,
+// except for the keys of mapped, which are left to the merge strategies.
+func mergeUnmappedSettings(result, src *Config, mapped map[string]interface{}) {
+	mergeConfigs(result, src)
+	for key, value := range mapped {
+		// Every key of configToMap is known to setConfigField
+		_ = setConfigField(result, key, value)
+	}
+}
+
 // ⭐ CFG-005: Supporting types and interfaces for inheritance - 🔧 Implementation infrastructure
 
 // configFileOperations implements file operations for inheritance system
@@ -1581,7 +2273,7 @@ func (b *defaultInheritanceChainBuilder) loadInheritanceMetadata(configPath stri
 		Inherit []string `yaml:"inherit"`
 	}
 
-	err = yaml.Unmarshal(data, &metadata)
+	err = unmarshalConfigYAML(data, &metadata)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse inheritance metadata: %w", err)
 	}
@@ -1657,6 +2349,7 @@ func configToMap(cfg *Config) map[string]interface{} {
 		"archive_dir_path":     cfg.ArchiveDirPath,
 		"use_current_dir_name": cfg.UseCurrentDirName,
 		"exclude_patterns":     cfg.ExcludePatterns,
+		"exclude_from":         cfg.ExcludeFrom,
 		"include_git_info":     cfg.IncludeGitInfo,
 		"skip_broken_symlinks": cfg.SkipBrokenSymlinks,
 		// Status codes
@@ -1738,6 +2431,10 @@ func setConfigField(cfg *Config, key string, value interface{}) error {
 		if slice, ok := value.([]string); ok {
 			cfg.ExcludePatterns = slice
 		}
+	case "exclude_from":
+		if slice, ok := value.([]string); ok {
+			cfg.ExcludeFrom = slice
+		}
 	case "include_git_info":
 		if b, ok := value.(bool); ok {
 			cfg.IncludeGitInfo = b
@@ -2000,6 +2697,9 @@ func reflectConfigFields(structType reflect.Type, structValue reflect.Value, pre
 			} else {
 				actualType = field.Type.String()
 				actualKind = field.Type.Kind()
+				if field.Type == reflect.TypeOf(config.Secret("")) {
+					actualType = "secret"
+				}
 				actualValue = fieldValue.Interface()
 			}
 
@@ -2126,6 +2826,13 @@ func formatFieldValue(value interface{}, kind reflect.Kind) string {
 	if value == nil {
 		return "<nil>"
 	}
+	// ⭐ SECRET-001: Secrets are redacted in config output, templates and JSON
+	if secret, ok := value.(config.Secret); ok {
+		if secret == "" {
+			return `""`
+		}
+		return secret.Redacted()
+	}
 
 	switch kind {
 	case reflect.Bool:
@@ -2135,6 +2842,10 @@ func formatFieldValue(value interface{}, kind reflect.Kind) string {
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
 		return fmt.Sprintf("%d", value)
 	case reflect.String:
+		// Show unset strings explicitly so they are not mistaken for missing rows
+		if value.(string) == "" {
+			return `""`
+		}
 		return value.(string)
 	case reflect.Slice:
 		// Handle string slices specifically
@@ -2546,6 +3257,8 @@ type GitConfig struct {
 	// Git command configuration
 	Command          string `yaml:"command"`           // Git command path (default: "git")
 	WorkingDirectory string `yaml:"working_directory"` // Working directory for Git operations (default: ".")
+	// 🔶 GIT-009: Git implementation: cli, native or auto (default: "auto")
+	Provider string `yaml:"provider"`
 
 	// Git behavior settings
 	RequireCleanRepo  bool `yaml:"require_clean_repo"` // Fail operations if repository is dirty
@@ -2556,12 +3269,50 @@ type GitConfig struct {
 	IncludeBranch bool `yaml:"include_branch"` // Include branch name in operations
 	IncludeHash   bool `yaml:"include_hash"`   // Include commit hash in operations
 	IncludeStatus bool `yaml:"include_status"` // Include working directory status
+	// 🔶 GIT-007: Name archives by git describe output instead of the commit hash
+	IncludeTags bool `yaml:"include_tags"`
 
 	// Git command timeouts and limits
 	CommandTimeout    string `yaml:"command_timeout"`     // Timeout for Git commands (default: "30s")
 	MaxSubmoduleDepth int    `yaml:"max_submodule_depth"` // Maximum submodule recursion depth
 }
 
+// ⭐ CDC-001: Repository configuration - 📝
+// RepositoryConfig selects the experimental chunk repository backend. Files
+// are split with content-defined chunking and each chunk is stored once
+// across all snapshots.
+type RepositoryConfig struct {
+	Path             string `yaml:"path"`               // Repository directory; empty keeps zip archives
+	AverageChunkSize string `yaml:"average_chunk_size"` // Target chunk size used when the repository is created
+}
+
+// DefaultRepositoryConfig returns the repository settings used when none are
+// configured: no repository, 1MB chunks.
+func DefaultRepositoryConfig() *RepositoryConfig {
+	return &RepositoryConfig{AverageChunkSize: "1MB"}
+}
+
+// ⭐ HOOK-001: Database hook configuration - 📝
+// DatabaseHookConfig describes one database to dump with a built-in hook
+// (postgres, mysql or sqlite). The dump is written before a full archive and
+// stored in the archive under its output name.
+type DatabaseHookConfig struct {
+	DSN     config.Secret `yaml:"dsn"`     // Connection string; the database file for SQLite
+	Output  string        `yaml:"output"`  // Archive entry of the dump
+	Command string        `yaml:"command"` // Dump program; empty uses the standard one
+}
+
+// ⭐ PLUGIN-001: Plugin configuration - 📝
+// PluginConfig runs an external program as a processing stage. The program
+// speaks the JSON protocol of package bkpdir/pkg/plugin on its stdin and
+// stdout and is started once per archive.
+type PluginConfig struct {
+	Name     string   `yaml:"name"`
+	Command  string   `yaml:"command"`
+	Args     []string `yaml:"args,omitempty"`
+	Patterns []string `yaml:"patterns,omitempty"` // Files sent to the plugin; empty sends all
+}
+
 // 🔶 GIT-005: Git configuration defaults - 📝
 // DefaultGitConfig returns a GitConfig with sensible defaults
 func DefaultGitConfig() *GitConfig {
@@ -2571,12 +3322,14 @@ func DefaultGitConfig() *GitConfig {
 		ShowDirtyStatus:   false, // Legacy compatibility
 		Command:           "git",
 		WorkingDirectory:  ".",
+		Provider:          git.ProviderAuto,
 		RequireCleanRepo:  false,
 		AutoDetectRepo:    true,
 		IncludeSubmodules: false,
 		IncludeBranch:     true,
 		IncludeHash:       true,
 		IncludeStatus:     true,
+		IncludeTags:       false,
 		CommandTimeout:    "30s",
 		MaxSubmoduleDepth: 3,
 	}
@@ -0,0 +1,594 @@
+// This file is part of bkpdir
+//
+// Package main provides the configuration field descriptions registry used by
+// template generation, `config KEY --describe` and configuration inspection.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"bkpdir/pkg/git"
+)
+
+// ⭐ CFG-TEMPLATE-002: Configuration field descriptions registry - 🔧
+// configFieldDoc holds human-facing documentation for a single configuration key.
+// Type and default value are derived from reflection; everything else is curated here.
+type configFieldDoc struct {
+	Description string
+	Allowed     []string
+	Example     string
+	EnvVar      string   // Environment variable that overrides the key, if any
+	Related     []string // YAML paths of closely related keys
+}
+
+// ⭐ CFG-TEMPLATE-002: Configuration field descriptions registry - 🔧
+// configFieldDocs is keyed by YAML path (e.g. "verification.verify_on_create").
+// Status codes, format strings, template strings and patterns fall back to
+// generated descriptions in describeConfigField.
+var configFieldDocs = map[string]configFieldDoc{
+	"archive_dir_path": {
+		Description: "Directory where directory archives are written, relative to the current directory unless absolute. An s3:// or file:// URL names a remote archive directory that only 'bkpdir list' reads",
+		Example:     "archive_dir_path: ~/backups/archives",
+		EnvVar:      "BKPDIR_ARCHIVE_DIR",
+		Related:     []string{"use_current_dir_name", "backup_dir_path"},
+	},
+	"use_current_dir_name": {
+		Description: "Store archives in a subdirectory named after the archived directory",
+		Related:     []string{"archive_dir_path"},
+	},
+	"exclude_patterns": {
+		Description: "Doublestar glob patterns excluded from directory archives",
+		Example:     "exclude_patterns:\n  - .git/\n  - node_modules/\n  - \"*.tmp\"",
+		Related:     []string{"skip_broken_symlinks", "exclude_from"},
+	},
+	"exclude_from": {
+		Description: "Files with additional exclusion patterns, one per line; # starts a comment. Relative paths are resolved against the archived directory",
+		Example:     "exclude_from:\n  - .bkpdirignore",
+		Related:     []string{"exclude_patterns"},
+	},
+	"include_git_info": {
+		Description: "Legacy switch that adds Git branch and hash to archive names; prefer git.include_info",
+		EnvVar:      "BKPDIR_INCLUDE_GIT",
+		Related:     []string{"git.include_info", "show_git_dirty_status"},
+	},
+	"show_git_dirty_status": {
+		Description: "Legacy switch that marks archives of dirty working trees; prefer git.show_dirty_status",
+		Related:     []string{"git.show_dirty_status", "include_git_info"},
+	},
+	"skip_broken_symlinks": {
+		Description: "Skip symbolic links whose targets do not exist instead of failing the archive",
+	},
+	"verification": {
+		Description: "Archive verification settings",
+	},
+	"verification.verify_on_create": {
+		Description: "Verify every archive immediately after it is created",
+		Related:     []string{"verification.checksum_algorithm"},
+	},
+	"verification.checksum_algorithm": {
+		Description: "Checksum algorithm used when computing and verifying archive checksums",
+		Allowed:     []string{"sha256", "md5", "sha1"},
+		Related:     []string{"verification.verify_on_create"},
+	},
+	"timestamp_timezone": {
+		Description: "Clock used for the timestamp in archive and backup names",
+		Allowed:     []string{"local", "UTC", "<IANA zone name>"},
+		Example:     "timestamp_timezone: Europe/Berlin",
+		Related:     []string{"timestamp_format"},
+	},
+	"timestamp_format": {
+		Description: "Go time layout for name timestamps; names must still match the filename patterns",
+		Example:     "timestamp_format: 2006-01-02-15-04",
+		Related:     []string{"timestamp_timezone", "pattern_archive_filename", "pattern_backup_filename"},
+	},
+	"trash_dir_path": {
+		Description: "Directory that removed archives are moved to instead of being deleted",
+		Related:     []string{"trash_retention_days"},
+	},
+	"trash_retention_days": {
+		Description: "Days a trashed item is kept before `trash empty --expired` deletes it",
+		Related:     []string{"trash_dir_path"},
+	},
+	"integrity_seal": {
+		Description: "Write an HMAC seal for each new archive using a key kept in the OS keychain; verify warns on mismatch",
+		EnvVar:      "BKPDIR_SEAL_KEY",
+	},
+	"event_log": {
+		Description: "Where archive lifecycle events (created, verified, trashed, failed) are sent; syslog reaches journald on Linux and unified logging on macOS",
+		Allowed:     []string{EventLogNone, EventLogSyslog},
+	},
+	"restore_unicode_normalization": {
+		Description: "Unicode form of restored file names: preserve keeps the archived bytes, nfc matches Linux tools, nfd matches HFS+; the archive manifest records the original bytes of non-NFC names",
+		Allowed:     []string{UnicodeNormalizationPreserve, UnicodeNormalizationNFC, UnicodeNormalizationNFD},
+	},
+	"restore_max_ratio": {
+		Description: "Refuse to restore an archive whose entries expand to more than this many times its size, a decompression bomb; 0 disables the check",
+		Example:     "restore_max_ratio: 5000",
+		Related:     []string{"restore_external_symlinks"},
+	},
+	"restore_external_symlinks": {
+		Description: "Restore symbolic links that point outside the target directory, such as absolute links; by default an archive holding one is refused, since later writes could follow it out of the target",
+		Example:     "restore_external_symlinks: true",
+		Related:     []string{"restore_max_ratio"},
+	},
+	"otlp_endpoint": {
+		Description: "OTLP/HTTP collector URL that receives OpenTelemetry spans for the scan, filter, compress, write and verify stages of each archive run; empty uses OTEL_EXPORTER_OTLP_ENDPOINT, and tracing is off when neither is set",
+		Example:     "otlp_endpoint: http://localhost:4318",
+		EnvVar:      "OTEL_EXPORTER_OTLP_ENDPOINT",
+	},
+	"healthcheck_url": {
+		Description: "Ping URL of a healthchecks.io-style monitor: each full or incremental archive run pings URL/start, then URL on success or URL/fail with the error; a secret that may be env:NAME or secretref:STORE/ITEM. Ping failures only warn",
+		Example:     "healthcheck_url: https://hc-ping.com/your-check-uuid",
+		Related:     []string{"otlp_endpoint", "event_log"},
+	},
+	"max_file_size": {
+		Description: "Largest single file to archive, e.g. 500MB (binary units); larger files are skipped with a warning, or abort the archive with limit_action: fail. Empty means unlimited",
+		Example:     "max_file_size: 500MB",
+		Related:     []string{"max_total_size", "max_file_count", "limit_action"},
+	},
+	"max_total_size": {
+		Description: "Largest total size of the files in one archive; empty means unlimited",
+		Example:     "max_total_size: 20GB",
+		Related:     []string{"max_file_size", "max_file_count", "limit_action"},
+	},
+	"max_file_count": {
+		Description: "Most entries one archive may contain; 0 means unlimited",
+		Example:     "max_file_count: 100000",
+		Related:     []string{"max_file_size", "max_total_size", "limit_action"},
+	},
+	"limit_action": {
+		Description: "What happens when a resource limit is exceeded: warn skips oversized files and continues, fail aborts the archive",
+		Allowed:     []string{LimitActionWarn, LimitActionFail},
+		Related:     []string{"max_file_size", "max_total_size", "max_file_count"},
+	},
+	"keep_going": {
+		Description: "Skip files that cannot be read, list them in the archive manifest and exit with status_partial_archive",
+		Related:     []string{"status_partial_archive"},
+	},
+	"manifest_file_hashes": {
+		Description: "Record the size and SHA-256 of every archived file in the archive manifest, so stats --dedup can confirm identical files across archives; new archives are read back once to hash them",
+		Example:     "manifest_file_hashes: true",
+	},
+	"file_compare": {
+		Description: "How 'bkpdir backup' decides a file is identical to its most recent backup: auto trusts a file older than the backup, then the SHA-256 recorded in .metadata, reading the backup only when the checksums differ; hash compares with the recorded checksum only; bytes reads both files",
+		Example:     "file_compare: bytes",
+		Allowed:     []string{FileCompareAuto, FileCompareHash, FileCompareBytes},
+	},
+	"backup_metadata_changes": {
+		Description: "When a file is identical to its most recent backup but its permissions, owner or extended attributes changed, record a metadata revision instead of reporting it identical: a backup name linking to the backup holding the content, with the new metadata in .metadata, shown as a metadata change by --list",
+		Example:     "backup_metadata_changes: true",
+		Related:     []string{"file_compare"},
+	},
+	"manifest_exclusions": {
+		Description: "Record in the archive manifest every file of the directory the archive leaves out, with the reason (exclude_pattern, max_file_size or broken_symlink) and the pattern or setting that removed it, so audits can show what a backup did not capture",
+		Example:     "manifest_exclusions: true",
+		Related:     []string{"exclude_patterns", "max_file_size", "skip_broken_symlinks"},
+	},
+	"change_journal": {
+		Description: "Append the files created, modified and deleted since the previous archive to an append-only journal in the .metadata directory of the archive directory, so 'bkpdir history PATH' can show when a file changed without opening archives",
+		Example:     "change_journal: true",
+		Related:     []string{"manifest_file_hashes"},
+	},
+	"audit_log": {
+		Description: "Append every create, trash, prune, restore, tier and config-set action to .metadata/audit.jsonl under archive_dir_path, each entry chained to the previous one by SHA-256 so 'bkpdir audit verify' detects edited or removed entries",
+		Example:     "audit_log: true",
+		Related:     []string{"event_log", "change_journal"},
+	},
+	"ascii_only": {
+		Description: "Replace emoji and box-drawing status markers in output with plain ASCII, for logs and terminals without Unicode fonts; colors are controlled separately by --color, NO_COLOR and CLICOLOR_FORCE",
+		Example:     "ascii_only: true",
+	},
+	"status_partial_archive": {
+		Description: "Exit code when an archive was created but keep_going skipped unreadable files",
+		Related:     []string{"keep_going"},
+	},
+	"compression_level": {
+		Description: "Deflate level of archive entries, from 0 (stored without compression, fastest) to 9 (smallest, slowest); bkpdir bench measures the levels on this machine and recommends one",
+		Example:     "compression_level: 3",
+		Related:     []string{"max_total_size"},
+	},
+	"archive_jobs": {
+		Description: "Number of jobs reading and deflating files at the same time while a single writer adds them to the archive in order, or auto to start with one and adjust the number to the measured throughput, up to one less than the number of CPUs (at least 2). Files over 8MB are streamed by the writer. Jobs are not used when plugins are configured",
+		Example:     "archive_jobs: auto",
+		Related:     []string{"compression_level"},
+	},
+	"max_memory": {
+		Description: "Memory limit of the buffers of archive creation, such as 512MB (binary units); empty is unlimited. Concurrent archive_jobs wait for memory before reading a file and fewer jobs run on small limits, down to writing sequentially; files that do not fit are streamed by the writer. bkpdir bench keeps at most half of it as its sample",
+		Example:     "max_memory: 512MB",
+		Related:     []string{"archive_jobs"},
+	},
+	"quota": {
+		Description: "Size limit of the archives in an archive directory, such as 50GB (binary units); a new archive that would exceed it is refused or makes room according to quota_policy. Sidecars and archives in cold storage do not count. Empty is unlimited",
+		Example:     "quota: 50GB",
+		Related:     []string{"quota_policy", "status_quota_exceeded"},
+	},
+	"quota_policy": {
+		Description: "What happens when a new archive would exceed quota: fail refuses it with status_quota_exceeded; prune first deletes the oldest archive chains (a full archive with its incrementals) and their sidecars, bypassing the trash, never the newest chain or the one the new archive belongs to, and fails like fail when that is not enough",
+		Allowed:     []string{QuotaPolicyFail, QuotaPolicyPrune},
+		Related:     []string{"quota"},
+	},
+	"status_quota_exceeded": {
+		Description: "Exit code when a new archive would exceed quota and quota_policy could not make room for it",
+		Related:     []string{"quota"},
+	},
+	"power_aware": {
+		Description: "Defer full and incremental archives and verification while the system runs on battery or its load is above power_max_load_percent, exiting with status_deferred; --ignore-power runs anyway. Detected on Linux and macOS",
+		Example:     "power_aware: true",
+		Related:     []string{"power_max_load_percent", "status_deferred"},
+	},
+	"power_max_load_percent": {
+		Description: "1-minute load average per CPU, in percent, above which power_aware defers a run; 0 only checks the battery",
+		Example:     "power_max_load_percent: 50",
+		Related:     []string{"power_aware"},
+	},
+	"volatile_patterns": {
+		Description: "Doublestar glob patterns, like exclude_patterns, of files such as caches, logs and editor swap files whose changes alone do not make a new incremental archive; when only they changed, inc reports no significant changes. They are still archived with other changes, and --include-volatile archives them alone",
+		Example:     "volatile_patterns:\n  - \"*.log\"\n  - \"*.swp\"\n  - .cache/",
+		Related:     []string{"exclude_patterns"},
+	},
+	"run_history": {
+		Description: "Number of runs of each directory or file whose summary (kind, time, archive, size, duration, outcome) is kept in the state directory for bkpdir last; 0 records none. The state directory is $BKPDIR_STATE_DIR, $XDG_STATE_HOME/bkpdir or ~/.local/state/bkpdir",
+		Example:     "run_history: 50",
+	},
+	"max_full_archive_age": {
+		Description: "Age, such as 7d, 2w or 36h, above which the full archive an incremental archive is based on is too old; inc then acts according to full_archive_age_action, nudging towards a fresh full archive. Empty is no limit",
+		Example:     "max_full_archive_age: 7d",
+		Related:     []string{"full_archive_age_action", "status_full_archive_too_old"},
+	},
+	"full_archive_age_action": {
+		Description: "What inc does when its base full archive is older than max_full_archive_age: warn creates the incremental archive, warns and exits with status_full_archive_too_old; fail creates nothing and exits with status_full_archive_too_old",
+		Allowed:     []string{FullAgeActionWarn, FullAgeActionFail},
+		Related:     []string{"max_full_archive_age"},
+	},
+	"status_full_archive_too_old": {
+		Description: "Exit code when the base full archive of inc is older than max_full_archive_age",
+		Related:     []string{"max_full_archive_age"},
+	},
+	"preserve_invoking_user_ownership": {
+		Description: "When bkpdir runs as root through sudo, give the archives, backups, sidecars, run history and audit log it creates back to the user named by SUDO_UID and SUDO_GID, so later runs without sudo can still use them. Directories created for them are given back up to the first one the user already owns",
+		Example:     "preserve_invoking_user_ownership: true",
+	},
+	"foreign_files": {
+		Description: "What bkpdir gc --foreign does with foreign files, files in the archive directory that bkpdir did not create such as ZIP files whose names pattern_archive_filename cannot parse: ignore only reports them; quarantine moves them to the trash, from where bkpdir trash restore brings them back. Foreign files are never listed, verified, tiered or removed as archives",
+		Example:     "foreign_files: quarantine",
+		Allowed:     []string{ForeignFilesIgnore, ForeignFilesQuarantine},
+		Related:     []string{"pattern_archive_filename", "trash_dir_path"},
+	},
+	"nested_archive_dirs": {
+		Description: "What archive runs do when archive_dir_path or backup_dir_path is inside the directory being archived, where each archive would include the earlier ones: exclude leaves the directory out with a warning; error refuses the run with status_config_error. A setting pointing at the archived directory itself is always refused",
+		Example:     "nested_archive_dirs: error",
+		Allowed:     []string{NestedDirsExclude, NestedDirsError},
+		Related:     []string{"archive_dir_path", "backup_dir_path", "exclude_patterns"},
+	},
+	"status_deferred": {
+		Description: "Exit code when power_aware deferred a run; the default 75 is the conventional code for a temporary failure worth retrying",
+		Related:     []string{"power_aware"},
+	},
+	"inherit": {
+		Description: "Configuration files to inherit from; prefix keys with + to merge, ^ to prepend, ! to replace, = to keep defaults",
+		Example:     "inherit:\n  - ~/.bkpdir.yml",
+	},
+	"backup_dir_path": {
+		Description: "Directory where single-file backups are written",
+		Example:     "backup_dir_path: ~/backups/files",
+		EnvVar:      "BKPDIR_BACKUP_DIR",
+		Related:     []string{"use_current_dir_name_for_files", "archive_dir_path"},
+	},
+	"use_current_dir_name_for_files": {
+		Description: "Mirror the source file's directory structure under backup_dir_path",
+		Related:     []string{"backup_dir_path", "use_home_relative_paths"},
+	},
+	"use_home_relative_paths": {
+		Description: "Store backups of files in the home directory by their home-relative path, without leading dots, whatever the current directory (~/.zshrc backs up to backup_dir_path/zshrc/); other files use use_current_dir_name_for_files",
+		Example:     "use_home_relative_paths: true",
+		Related:     []string{"backup_dir_path", "use_current_dir_name_for_files"},
+	},
+	"repository": {
+		Description: "Experimental deduplicating repository backend; when path is set, full archives are stored as snapshots of content-defined chunks shared by all snapshots",
+	},
+	"repository.path": {
+		Description: "Repository directory, created on first use; empty keeps zip archives in archive_dir_path",
+		Example:     "path: ~/.bkpdir-repo",
+		Related:     []string{"repository.average_chunk_size"},
+	},
+	"repository.average_chunk_size": {
+		Description: "Average chunk size, e.g. 1MB; only used when the repository is created, later snapshots keep its chunking",
+		Example:     "average_chunk_size: 512KB",
+		Related:     []string{"repository.path"},
+	},
+	"encryption": {
+		Description: "Encryption at rest with AES-256-GCM; the key comes from key_file, or is derived from passphrase or the passphrase in passphrase_env",
+	},
+	"encryption.file_backups": {
+		Description: "Encrypt the copies made by 'bkpdir backup FILE'; encrypted backups are compared and restored transparently",
+		Example:     "file_backups: true",
+		Related:     []string{"encryption.key_file", "encryption.passphrase", "encryption.passphrase_env"},
+	},
+	"encryption.key_file": {
+		Description: "File holding a 32-byte key, raw or as 64 hex digits; takes precedence over the passphrase",
+		Example:     "key_file: ~/.config/bkpdir/backup.key",
+		Related:     []string{"encryption.passphrase", "encryption.passphrase_env"},
+	},
+	"encryption.passphrase": {
+		Description: "Secret passphrase that keys are derived from: a literal, env:NAME or secretref:STORE/ITEM; literals are redacted in output and it takes precedence over passphrase_env",
+		Example:     "passphrase: secretref:keychain/bkpdir",
+		Related:     []string{"encryption.key_file", "encryption.passphrase_env"},
+	},
+	"encryption.passphrase_env": {
+		Description: "Environment variable holding the passphrase that keys are derived from with PBKDF2-HMAC-SHA256",
+		Example:     "passphrase_env: BKPDIR_PASSPHRASE",
+		Related:     []string{"encryption.key_file", "encryption.passphrase"},
+	},
+	"remote": {
+		Description: "Credentials and transfer settings of remotes (s3://BUCKET/PREFIX, file://DIR or a directory) used by 'bkpdir upload' and remote archive directories; empty S3 settings fall back to the AWS_* environment variables",
+	},
+	"remote.s3_region": {
+		Description: "Region of S3 buckets; empty uses AWS_REGION or AWS_DEFAULT_REGION, then us-east-1",
+		Example:     "s3_region: eu-central-1",
+		EnvVar:      "AWS_REGION",
+	},
+	"remote.s3_endpoint": {
+		Description: "Base URL of an S3-compatible store such as MinIO; requests then use path-style addressing. Empty uses Amazon S3",
+		Example:     "s3_endpoint: https://minio.example.com:9000",
+	},
+	"remote.s3_access_key_id": {
+		Description: "S3 access key ID; when it and the secret access key are empty, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY are used",
+		EnvVar:      "AWS_ACCESS_KEY_ID",
+		Related:     []string{"remote.s3_secret_access_key"},
+	},
+	"remote.s3_secret_access_key": {
+		Description: "S3 secret access key: a secret that may be env:NAME or secretref:STORE/ITEM and is redacted in output",
+		Example:     "s3_secret_access_key: secretref:keychain/s3-backups",
+		EnvVar:      "AWS_SECRET_ACCESS_KEY",
+		Related:     []string{"remote.s3_access_key_id"},
+	},
+	"remote.upload_part_size": {
+		Description: "Size of the parts uploads are split into (at least 5MB); an interrupted upload resumes after its last complete part",
+		Example:     "upload_part_size: 64MB",
+	},
+	"remote.listing_cache_ttl": {
+		Description: "How long 'bkpdir list' reuses the cached listing of a remote archive_dir_path before listing the remote again; cached manifests are revalidated with conditional reads. 0 lists every time; 'bkpdir list --refresh' forces a new listing",
+		Example:     "listing_cache_ttl: 1h",
+	},
+	"tiering": {
+		Description: "Age-based tiering: 'bkpdir tier' moves archives older than after_days to cold_remote and records them in .metadata/cold-storage.json so list and verify know where they are",
+	},
+	"tiering.cold_remote": {
+		Description: "Remote that old archives are moved to (s3://BUCKET/PREFIX, file://DIR or a directory), with the credentials of the remote section; empty disables tiering",
+		Example:     "cold_remote: s3://backups-cold/laptop",
+		Related:     []string{"tiering.after_days", "tiering.storage_class"},
+	},
+	"tiering.after_days": {
+		Description: "Age in days, from the archive modification time, after which 'bkpdir tier' moves an archive to cold_remote",
+		Example:     "after_days: 180",
+	},
+	"tiering.storage_class": {
+		Description: "S3 storage class of archives moved to an s3:// cold_remote, e.g. STANDARD_IA, GLACIER or DEEP_ARCHIVE; empty uses the bucket default",
+		Example:     "storage_class: GLACIER",
+		Allowed:     []string{"STANDARD_IA", "ONEZONE_IA", "INTELLIGENT_TIERING", "GLACIER_IR", "GLACIER", "DEEP_ARCHIVE"},
+	},
+	"shared": {
+		Description: "Settings for an archive directory shared by several users, such as a team NAS: a per-user namespace for archives and the permissions of created files",
+	},
+	"shared.user_namespace": {
+		Description: "How the archives of each user are kept apart: none stores them side by side, subdir below archive_dir_path/$USER, prefix starts their names with '$USER-'; 'bkpdir list --owner NAME' lists the archives of one user",
+		Example:     "user_namespace: subdir",
+		Allowed:     []string{UserNamespaceNone, UserNamespaceSubdir, UserNamespacePrefix},
+	},
+	"shared.umask": {
+		Description: "Octal umask applied to everything bkpdir creates, e.g. 002 for group-writable or 027 for group-readable archives; new archives, their sidecars and the directories leading to them below archive_dir_path get the modes it allows. Empty keeps the process umask. Unix only",
+		Example:     "umask: \"002\"",
+		Related:     []string{"shared.user_namespace"},
+	},
+	"hooks": {
+		Description: "Built-in database hooks (postgres, mysql, sqlite) with dsn, output and optional command; dsn is a secret that may be env:NAME or secretref:STORE/ITEM and is redacted in output; each configured database is dumped before a full archive and the dump is stored in the archive",
+		Example:     "hooks:\n  postgres:\n    dsn: postgres://app@localhost/app\n    output: db.sql",
+	},
+	"plugins": {
+		Description: "External processing stages run on each archived file in order, each with name, command, optional args and optional patterns selecting the files it sees; plugins answer keep, skip, replace or fail over a JSON protocol on stdin and stdout",
+		Example:     "plugins:\n  - name: scrub-pii\n    command: ~/bin/scrub-pii\n    patterns: [\"**/*.csv\"]",
+	},
+	"git": {
+		Description: "Git integration settings",
+	},
+	"git.enabled": {
+		Description: "Enable Git integration",
+		EnvVar:      "BKPDIR_GIT_ENABLED",
+	},
+	"git.include_info": {
+		Description: "Include Git information in archive names",
+		EnvVar:      "BKPDIR_GIT_INCLUDE_INFO",
+		Related:     []string{"include_git_info", "git.include_branch", "git.include_hash"},
+	},
+	"git.show_dirty_status": {
+		Description: "Mark archives created from a working tree with uncommitted changes",
+		EnvVar:      "BKPDIR_GIT_SHOW_DIRTY_STATUS",
+		Related:     []string{"show_git_dirty_status"},
+	},
+	"git.command": {
+		Description: "Git executable used for repository queries",
+		Example:     "command: /usr/local/bin/git",
+		EnvVar:      "BKPDIR_GIT_COMMAND",
+		Related:     []string{"git.command_timeout"},
+	},
+	"git.provider": {
+		Description: "Git implementation: cli runs the git executable, native (alias gogit) reads .git in-process, auto uses cli when git is on PATH",
+		Allowed:     []string{git.ProviderAuto, git.ProviderCLI, git.ProviderNative, git.ProviderGoGit},
+		Example:     "provider: native",
+		EnvVar:      "BKPDIR_GIT_PROVIDER",
+		Related:     []string{"git.command"},
+	},
+	"git.working_directory": {
+		Description: "Directory in which Git commands are run",
+		EnvVar:      "BKPDIR_GIT_WORKING_DIRECTORY",
+	},
+	"git.require_clean_repo": {
+		Description: "Refuse to archive when the repository has uncommitted changes",
+	},
+	"git.auto_detect_repo": {
+		Description: "Detect Git repositories automatically",
+	},
+	"git.include_submodules": {
+		Description: "Include submodule information",
+		EnvVar:      "BKPDIR_GIT_INCLUDE_SUBMODULES",
+		Related:     []string{"git.max_submodule_depth"},
+	},
+	"git.include_branch": {
+		Description: "Include the branch name in Git information",
+	},
+	"git.include_hash": {
+		Description: "Include the abbreviated commit hash in Git information",
+	},
+	"git.include_status": {
+		Description: "Include working tree status in Git information",
+	},
+	"git.include_tags": {
+		Description: "Name archives by the git describe --tags --dirty output instead of the commit hash when a tag is reachable",
+		Example:     "include_tags: true",
+		Related:     []string{"git.include_info", "git.include_hash"},
+	},
+	"git.command_timeout": {
+		Description: "Maximum duration of a single Git command; 0 disables the timeout",
+		Example:     "command_timeout: 10s",
+	},
+	"git.max_submodule_depth": {
+		Description: "Maximum submodule recursion depth",
+		Related:     []string{"git.include_submodules"},
+	},
+}
+
+// ⭐ CFG-TEMPLATE-002: YAML path resolution - 🔧
+// configYAMLPath converts a Go field path such as "Verification.VerifyOnCreate"
+// into the YAML path "verification.verify_on_create".
+func configYAMLPath(goPath string) string {
+	t := reflect.TypeOf(Config{})
+	var parts []string
+	for _, name := range strings.Split(goPath, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		if t.Kind() != reflect.Struct {
+			return strings.Join(parts, ".")
+		}
+		field, ok := t.FieldByName(name)
+		if !ok {
+			parts = append(parts, strings.ToLower(name))
+			continue
+		}
+		yamlName := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if yamlName == "" {
+			yamlName = strings.ToLower(name)
+		}
+		parts = append(parts, yamlName)
+		t = field.Type
+	}
+	return strings.Join(parts, ".")
+}
+
+// ⭐ CFG-TEMPLATE-002: Field description lookup - 🔧
+// describeConfigField returns documentation for a field, generating a
+// description from the key name for message, status and pattern fields.
+func describeConfigField(field configFieldInfo) configFieldDoc {
+	yamlPath := configYAMLPath(field.Path)
+	doc := configFieldDocs[yamlPath]
+
+	if doc.Description == "" {
+		words := strings.ReplaceAll(field.YAMLName, "_", " ")
+		switch field.Category {
+		case "status_codes":
+			doc.Description = fmt.Sprintf("Exit code when: %s", strings.TrimPrefix(words, "status "))
+		case "format_strings":
+			doc.Description = fmt.Sprintf("Printf-style message for: %s", strings.TrimPrefix(words, "format "))
+		case "template_strings":
+			doc.Description = fmt.Sprintf("Template message with %%{name} placeholders for: %s", strings.TrimPrefix(words, "template "))
+		case "regex_patterns":
+			doc.Description = fmt.Sprintf("Regular expression with named groups for: %s", strings.TrimPrefix(words, "pattern "))
+		default:
+			doc.Description = words
+		}
+	}
+
+	if len(doc.Allowed) == 0 && field.Kind == reflect.Bool {
+		doc.Allowed = []string{"true", "false"}
+	}
+
+	return doc
+}
+
+// ⭐ CFG-DESCRIBE-001: Configuration key lookup - 🔧
+// findConfigField resolves a key given either as a full YAML path
+// ("verification.checksum_algorithm") or as an unambiguous leaf name ("checksum_algorithm").
+func findConfigField(cfg *Config, key string) (configFieldInfo, error) {
+	var matches []configFieldInfo
+	for _, field := range GetAllConfigFields(cfg) {
+		yamlPath := configYAMLPath(field.Path)
+		if yamlPath == key {
+			return field, nil
+		}
+		if field.YAMLName == key {
+			matches = append(matches, field)
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return configFieldInfo{}, fmt.Errorf("unknown configuration key: %s", key)
+	case 1:
+		return matches[0], nil
+	default:
+		var paths []string
+		for _, m := range matches {
+			paths = append(paths, configYAMLPath(m.Path))
+		}
+		return configFieldInfo{}, fmt.Errorf("ambiguous configuration key %s, use one of: %s", key, strings.Join(paths, ", "))
+	}
+}
+
+// ⭐ CFG-DESCRIBE-001: Configuration key description - 🔧
+// describeConfigKey renders the purpose, type, default, current value, environment
+// variable and related keys of a single configuration key.
+func describeConfigKey(cfg *Config, key string) (string, error) {
+	field, err := findConfigField(cfg, key)
+	if err != nil {
+		return "", err
+	}
+	doc := describeConfigField(field)
+
+	defaultValue := ""
+	if def, err := getFieldValueByPath(reflect.ValueOf(*DefaultConfig()), field.Path); err == nil {
+		defaultValue = formatFieldValue(def, field.Kind)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", configYAMLPath(field.Path))
+	fmt.Fprintf(&b, "  Description: %s\n", doc.Description)
+	fmt.Fprintf(&b, "  Type:        %s\n", field.Type)
+	fmt.Fprintf(&b, "  Category:    %s\n", field.Category)
+	fmt.Fprintf(&b, "  Default:     %s\n", strconv.Quote(defaultValue))
+	fmt.Fprintf(&b, "  Current:     %s\n", strconv.Quote(formatFieldValue(field.Value, field.Kind)))
+	if len(doc.Allowed) > 0 {
+		fmt.Fprintf(&b, "  Allowed:     %s\n", strings.Join(doc.Allowed, ", "))
+	}
+	envVar := doc.EnvVar
+	if envVar == "" {
+		envVar = "(none)"
+	}
+	fmt.Fprintf(&b, "  Env var:     %s\n", envVar)
+	if len(doc.Related) > 0 {
+		fmt.Fprintf(&b, "  Related:     %s\n", strings.Join(doc.Related, ", "))
+	}
+	if doc.Example != "" {
+		fmt.Fprintf(&b, "  Example:\n")
+		for _, line := range strings.Split(doc.Example, "\n") {
+			fmt.Fprintf(&b, "    %s\n", line)
+		}
+	}
+	return b.String(), nil
+}
@@ -13,7 +13,7 @@ import (
 	"regexp"
 	"strings"
 
-	yaml "gopkg.in/yaml.v3"
+	"bkpdir/pkg/fileops"
 
 	// 🔶 GIT-005: Import Git package for configuration integration
 	"bkpdir/pkg/git"
@@ -52,7 +52,7 @@ func (d *DefaultConfigLoader) LoadConfig(root string) (*Config, error) {
 			}
 
 			var fileCfg Config
-			if err := yaml.Unmarshal(data, &fileCfg); err != nil {
+			if err := unmarshalConfigYAML(data, &fileCfg); err != nil {
 				continue
 			}
 
@@ -157,7 +157,7 @@ func (f *FileConfigSource) LoadFromFile(path string) (*Config, error) {
 	}
 
 	var cfg Config
-	if err := yaml.Unmarshal(data, &cfg); err != nil {
+	if err := unmarshalConfigYAML(data, &cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
 	}
 
@@ -201,6 +201,9 @@ func (f *FileConfigSource) LoadFromEnvironment() (*Config, error) {
 	if workingDir := os.Getenv("BKPDIR_GIT_WORKING_DIRECTORY"); workingDir != "" {
 		cfg.Git.WorkingDirectory = workingDir
 	}
+	if provider := os.Getenv("BKPDIR_GIT_PROVIDER"); provider != "" {
+		cfg.Git.Provider = provider
+	}
 	if includeSubmodules := os.Getenv("BKPDIR_GIT_INCLUDE_SUBMODULES"); includeSubmodules != "" {
 		cfg.Git.IncludeSubmodules = strings.ToLower(includeSubmodules) == "true"
 	}
@@ -459,7 +462,7 @@ func (f *FileSystemOperations) ReadFile(path string) ([]byte, error) {
 // WriteFile writes configuration file contents.
 // 🔻 REFACTOR-003: Config abstraction - File content writing - 📝
 func (f *FileSystemOperations) WriteFile(path string, data []byte, perm os.FileMode) error {
-	return os.WriteFile(path, data, perm)
+	return fileops.WriteFile(path, data, perm)
 }
 
 // GetFileInfo returns file information for configuration files.
@@ -551,6 +554,7 @@ func (gc *GitConfig) ToGitConfig() *git.Config {
 		// Git command configuration
 		Command:          gc.Command,
 		WorkingDirectory: gc.WorkingDirectory,
+		Provider:         gc.Provider,
 
 		// Git behavior settings
 		RequireCleanRepo:  gc.RequireCleanRepo,
@@ -561,6 +565,7 @@ func (gc *GitConfig) ToGitConfig() *git.Config {
 		IncludeBranch: gc.IncludeBranch,
 		IncludeHash:   gc.IncludeHash,
 		IncludeStatus: gc.IncludeStatus,
+		IncludeTags:   gc.IncludeTags,
 
 		// Git command timeouts and limits
 		CommandTimeout:    gc.CommandTimeout,
@@ -0,0 +1,115 @@
+// This file is part of bkpdir
+//
+// Package main provides environment interpolation of configuration files:
+// `${NAME}` and `${NAME:-default}` in YAML values are replaced with
+// environment variables when a file is loaded, and `$${` stands for a
+// literal `${`.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ⭐ CFG-ENV-001: Interpolated configuration decoding - 🔧
+// unmarshalConfigYAML decodes a configuration file like yaml.Unmarshal after
+// interpolating environment variables into its scalar values. Anchors,
+// aliases and merge keys are resolved by the decoder as usual. An empty
+// document leaves out unchanged.
+func unmarshalConfigYAML(data []byte, out interface{}) error {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return err
+	}
+	if doc.Kind == 0 {
+		return nil
+	}
+	interpolateNode(&doc)
+	return doc.Decode(out)
+}
+
+// interpolateNode expands the scalars below node. Aliases are skipped: the
+// node they refer to is expanded where its anchor is defined, and expanding
+// it twice would expand what an escape produced.
+func interpolateNode(node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if !strings.Contains(node.Value, "${") {
+			return
+		}
+		node.Value = interpolateEnv(node.Value, os.LookupEnv)
+		// Let plain scalars resolve again, so `${JOBS:-4}` can set a number
+		if node.Style == 0 && node.Tag == "!!str" {
+			node.Tag = ""
+		}
+	case yaml.AliasNode:
+	default:
+		for _, child := range node.Content {
+			interpolateNode(child)
+		}
+	}
+}
+
+// ⭐ CFG-ENV-001: Environment interpolation - 🔧
+// interpolateEnv replaces `${NAME}` with the variable NAME, or an empty
+// string when it is unset, and `${NAME:-default}` with default when NAME is
+// unset or empty. `$${` is replaced with a literal `${`. A `$` in any other
+// position, an unterminated `${` or an invalid name is kept as written, so
+// passwords and patterns containing `$` need no escaping.
+func interpolateEnv(value string, lookup func(string) (string, bool)) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(value, "${")
+		if i < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		if i > 0 && value[i-1] == '$' {
+			b.WriteString(value[:i])
+			b.WriteString("{")
+			value = value[i+2:]
+			continue
+		}
+		end := strings.IndexByte(value[i:], '}')
+		if end < 0 {
+			b.WriteString(value)
+			return b.String()
+		}
+		expr := value[i+2 : i+end]
+		name, def, hasDefault := strings.Cut(expr, ":-")
+		if !isEnvName(name) {
+			b.WriteString(value[:i+end+1])
+			value = value[i+end+1:]
+			continue
+		}
+		b.WriteString(value[:i])
+		v, _ := lookup(name)
+		if v == "" && hasDefault {
+			v = def
+		}
+		b.WriteString(v)
+		value = value[i+end+1:]
+	}
+}
+
+// isEnvName reports whether name is a valid environment variable name.
+func isEnvName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		switch {
+		case c == '_', c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z':
+		case c >= '0' && c <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,127 @@
+// This file is part of bkpdir
+
+// Package main provides tests for environment interpolation of configuration
+// files. It verifies the expansion syntax and that anchors and aliases keep
+// working through the inheritance merge pipeline.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ⭐ CFG-ENV-001: Interpolation syntax - 🧪
+func TestInterpolateEnv(t *testing.T) {
+	env := map[string]string{"HOME": "/home/u", "EMPTY": ""}
+	lookup := func(name string) (string, bool) {
+		v, ok := env[name]
+		return v, ok
+	}
+	tests := []struct {
+		in, want string
+	}{
+		{"${HOME}/archives", "/home/u/archives"},
+		{"${UNSET}", ""},
+		{"${UNSET:-/tmp}/x", "/tmp/x"},
+		{"${EMPTY:-fallback}", "fallback"},
+		{"${HOME:-/tmp}", "/home/u"},
+		{"$${HOME}", "${HOME}"},
+		{"a$${HOME}b${HOME}", "a${HOME}b/home/u"},
+		{"pa$$word$", "pa$$word$"},
+		{"^.*\\.log$", "^.*\\.log$"},
+		{"${not valid}", "${not valid}"},
+		{"${HOME", "${HOME"},
+	}
+	for _, tt := range tests {
+		if got := interpolateEnv(tt.in, lookup); got != tt.want {
+			t.Errorf("interpolateEnv(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+// ⭐ CFG-ENV-001: Interpolated config files - 🧪
+func TestLoadSingleConfigFileInterpolation(t *testing.T) {
+	t.Setenv("BKPDIR_TEST_ARCHIVES", "/srv/archives")
+	t.Setenv("BKPDIR_TEST_JOBS", "")
+	path := filepath.Join(t.TempDir(), "config.yml")
+	content := `archive_dir_path: ${BKPDIR_TEST_ARCHIVES}/projects
+archive_jobs: ${BKPDIR_TEST_JOBS:-4}
+compression_level: ${BKPDIR_TEST_LEVEL:-3}
+trash_dir_path: "/trash/$${literal}"
+verification:
+  checksum_algorithm: ${BKPDIR_TEST_ALGORITHM:-sha1}
+`
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := loadSingleConfigFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ArchiveDirPath != "/srv/archives/projects" {
+		t.Errorf("archive_dir_path = %q", cfg.ArchiveDirPath)
+	}
+	if cfg.ArchiveJobs != "4" || cfg.CompressionLevel != 3 {
+		t.Errorf("archive_jobs = %q, compression_level = %d", cfg.ArchiveJobs, cfg.CompressionLevel)
+	}
+	if cfg.TrashDirPath != "/trash/${literal}" {
+		t.Errorf("trash_dir_path = %q", cfg.TrashDirPath)
+	}
+	if cfg.Verification.ChecksumAlgorithm != "sha1" {
+		t.Errorf("checksum_algorithm = %q", cfg.Verification.ChecksumAlgorithm)
+	}
+}
+
+// ⭐ CFG-ENV-001: Anchors and aliases through inheritance - 🧪
+func TestConfigAnchorsSurviveInheritance(t *testing.T) {
+	t.Setenv("BKPDIR_TEST_BUILD", "out")
+	dir := t.TempDir()
+	base := `backup_dir_path: &dir ${BKPDIR_TEST_ROOT:-/base}/backups
+trash_dir_path: *dir
+`
+	// Anchors do not cross files; the child defines its own
+	child := `inherit:
+  - base.yml
+.excludes: &excludes
+  - "*.log"
+  - "${BKPDIR_TEST_BUILD}/**"
+exclude_patterns: *excludes
+.paths: &paths
+  archive_dir_path: /archives
+  include_git_info: false
+<<: *paths
+.verify: &verify
+  verify_on_create: true
+  checksum_algorithm: sha1
+verification:
+  <<: *verify
+  checksum_algorithm: md5
+`
+	if err := os.WriteFile(filepath.Join(dir, "base.yml"), []byte(base), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	childPath := filepath.Join(dir, "child.yml")
+	if err := os.WriteFile(childPath, []byte(child), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	fileOps := &configFileOperations{}
+	cfg, err := loadConfigRecursive(childPath, newPathResolver(fileOps), newInheritanceChainBuilder(fileOps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"*.log", "out/**"}; !reflect.DeepEqual(cfg.ExcludePatterns, want) {
+		t.Errorf("exclude_patterns = %v, want %v", cfg.ExcludePatterns, want)
+	}
+	if cfg.ArchiveDirPath != "/archives" || cfg.IncludeGitInfo {
+		t.Errorf("archive_dir_path = %q, include_git_info = %v", cfg.ArchiveDirPath, cfg.IncludeGitInfo)
+	}
+	if cfg.BackupDirPath != "/base/backups" || cfg.TrashDirPath != "/base/backups" {
+		t.Errorf("backup_dir_path = %q, trash_dir_path = %q", cfg.BackupDirPath, cfg.TrashDirPath)
+	}
+	if !cfg.Verification.VerifyOnCreate || cfg.Verification.ChecksumAlgorithm != "md5" {
+		t.Errorf("verification = %+v", *cfg.Verification)
+	}
+}
@@ -0,0 +1,143 @@
+// This file is part of bkpdir
+//
+// Package main provides JSON Schema export of the configuration so editors
+// with YAML language servers can validate and autocomplete .bkpdir.yml.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// ⭐ CFG-SCHEMA-001: JSON Schema dialect - 🔧
+const configSchemaDialect = "http://json-schema.org/draft-07/schema#"
+
+// ⭐ CFG-SCHEMA-001: JSON Schema generation - 🔧
+// GenerateConfigSchema builds a JSON Schema for .bkpdir.yml from the reflected
+// Config struct. Descriptions and enums come from the configFieldDocs registry
+// and defaults from DefaultConfig.
+func GenerateConfigSchema() map[string]interface{} {
+	defaults := reflect.ValueOf(*DefaultConfig())
+	schema := objectSchema(defaults.Type(), defaults, "")
+	schema["$schema"] = configSchemaDialect
+	schema["title"] = "BkpDir configuration"
+	schema["description"] = "Configuration file for bkpdir (.bkpdir.yml)"
+	return schema
+}
+
+// ⭐ CFG-SCHEMA-001: JSON Schema serialization - 🔧
+// MarshalConfigSchema renders GenerateConfigSchema as indented JSON.
+func MarshalConfigSchema() ([]byte, error) {
+	data, err := json.MarshalIndent(GenerateConfigSchema(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
+
+// objectSchema describes a struct type as a JSON Schema object. Unknown keys are
+// allowed so inheritance merge prefixes (+key, ^key, !key, =key) still validate.
+func objectSchema(t reflect.Type, defaults reflect.Value, goPrefix string) map[string]interface{} {
+	properties := make(map[string]interface{})
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		yamlName := strings.Split(field.Tag.Get("yaml"), ",")[0]
+		if yamlName == "" {
+			yamlName = strings.ToLower(field.Name)
+		}
+		if yamlName == "-" {
+			continue
+		}
+		goPath := field.Name
+		if goPrefix != "" {
+			goPath = goPrefix + "." + field.Name
+		}
+
+		fieldType := field.Type
+		fieldValue := defaults.Field(i)
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+			if fieldValue.IsNil() {
+				fieldValue = reflect.Zero(fieldType)
+			} else {
+				fieldValue = fieldValue.Elem()
+			}
+		}
+
+		var prop map[string]interface{}
+		if fieldType.Kind() == reflect.Struct {
+			prop = objectSchema(fieldType, fieldValue, goPath)
+		} else {
+			prop = valueSchema(fieldType, fieldValue)
+		}
+
+		doc := describeConfigField(configFieldInfo{
+			Name:     field.Name,
+			YAMLName: yamlName,
+			Kind:     fieldType.Kind(),
+			Category: determineFieldCategory(field.Name, ""),
+			Path:     goPath,
+		})
+		if doc.Description != "" {
+			prop["description"] = doc.Description
+		}
+		if enum := schemaEnum(doc.Allowed); fieldType.Kind() == reflect.String && len(enum) > 0 {
+			prop["enum"] = enum
+		}
+
+		properties[yamlName] = prop
+	}
+
+	return map[string]interface{}{
+		"type":       "object",
+		"properties": properties,
+	}
+}
+
+// valueSchema describes a scalar or slice field and records its default.
+func valueSchema(t reflect.Type, defaultValue reflect.Value) map[string]interface{} {
+	prop := map[string]interface{}{}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		prop["type"] = "boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		prop["type"] = "integer"
+	case reflect.Float32, reflect.Float64:
+		prop["type"] = "number"
+	case reflect.String:
+		prop["type"] = "string"
+	case reflect.Slice:
+		prop["type"] = "array"
+		prop["items"] = valueSchema(t.Elem(), reflect.Zero(t.Elem()))
+		delete(prop["items"].(map[string]interface{}), "default")
+	}
+
+	if defaultValue.IsValid() && !(t.Kind() == reflect.Slice && defaultValue.IsNil()) {
+		prop["default"] = defaultValue.Interface()
+	}
+
+	return prop
+}
+
+// schemaEnum keeps only literal allowed values; placeholders such as
+// "<IANA zone name>" mean the value set is open.
+func schemaEnum(allowed []string) []string {
+	for _, v := range allowed {
+		if strings.HasPrefix(v, "<") {
+			return nil
+		}
+	}
+	return allowed
+}
@@ -3,6 +3,7 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -13,6 +14,7 @@ import (
 	"testing"
 	"time"
 
+	"bkpdir/pkg/config"
 	"gopkg.in/yaml.v3"
 )
 
@@ -159,6 +161,7 @@ func TestGetConfigSearchPath(t *testing.T) {
 	// Test basic path retrieval
 
 	// Save original environment
+	t.Setenv("XDG_CONFIG_HOME", "")
 	origEnv := os.Getenv("BKPDIR_CONFIG")
 	defer func() {
 		if origEnv == "" {
@@ -173,7 +176,7 @@ func TestGetConfigSearchPath(t *testing.T) {
 		os.Unsetenv("BKPDIR_CONFIG")
 
 		paths := getConfigSearchPaths()
-		expectedPaths := []string{"./.bkpdir.yml", "~/.bkpdir.yml"}
+		expectedPaths := []string{"./.bkpdir.yml", "~/.config/bkpdir/config.yml", "~/.bkpdir.yml"}
 
 		if len(paths) != len(expectedPaths) {
 			t.Errorf("Expected %d paths, got %d", len(expectedPaths), len(paths))
@@ -224,7 +227,7 @@ func TestGetConfigSearchPath(t *testing.T) {
 		os.Setenv("BKPDIR_CONFIG", "")
 
 		paths := getConfigSearchPaths()
-		expectedPaths := []string{"./.bkpdir.yml", "~/.bkpdir.yml"}
+		expectedPaths := []string{"./.bkpdir.yml", "~/.config/bkpdir/config.yml", "~/.bkpdir.yml"}
 
 		if len(paths) != len(expectedPaths) {
 			t.Errorf("Expected %d paths, got %d", len(expectedPaths), len(paths))
@@ -1104,6 +1107,45 @@ include_git_info: false
 	}
 }
 
+// TestInheritUnmappedSettings tests that keys without a merge strategy are
+// inherited while those with one still follow the strategy
+func TestInheritUnmappedSettings(t *testing.T) {
+	dir := t.TempDir()
+	base := `
+include_git_info: true
+trash_dir_path: /base/trash
+trash_retention_days: 7
+timestamp_timezone: UTC
+`
+	child := `
+inherit:
+  - base.yml
+include_git_info: false
+trash_dir_path: /child/trash
+`
+	if err := os.WriteFile(filepath.Join(dir, "base.yml"), []byte(base), 0644); err != nil {
+		t.Fatal(err)
+	}
+	childPath := filepath.Join(dir, "child.yml")
+	if err := os.WriteFile(childPath, []byte(child), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fileOps := &configFileOperations{}
+	cfg, err := loadConfigRecursive(childPath, newPathResolver(fileOps), newInheritanceChainBuilder(fileOps))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStringEqual(t, "TrashDirPath", cfg.TrashDirPath, "/child/trash")
+	assertStringEqual(t, "TimestampTimezone", cfg.TimestampTimezone, "UTC")
+	if cfg.IncludeGitInfo {
+		t.Error("Expected the child include_git_info: false to override the inherited value")
+	}
+	if cfg.TrashRetentionDays != 7 {
+		t.Errorf("Expected trash_retention_days 7 to be inherited, got %d", cfg.TrashRetentionDays)
+	}
+}
+
 // TestMergeStrategies tests different merge strategies for configuration inheritance
 func TestMergeStrategies(t *testing.T) {
 	// Test merge strategy extraction
@@ -2626,8 +2668,10 @@ func TestDisplayFormatting(t *testing.T) {
 					foundVerificationFields = true
 				} else if strings.HasPrefix(field.Path, "Git.") {
 					foundGitFields = true
-				} else {
-					t.Errorf("Unexpected nested field path format: %s (expected Verification.* or Git.*)", field.Path)
+				} else if !strings.HasPrefix(field.Path, "Repository.") && !strings.HasPrefix(field.Path, "Encryption.") &&
+					!strings.HasPrefix(field.Path, "Remote.") && !strings.HasPrefix(field.Path, "Tiering.") &&
+					!strings.HasPrefix(field.Path, "Shared.") {
+					t.Errorf("Unexpected nested field path format: %s (expected Verification.*, Git.*, Repository.*, Encryption.*, Remote.*, Tiering.* or Shared.*)", field.Path)
 				}
 			}
 		}
@@ -3837,3 +3881,299 @@ func TestGitConfigIntegration(t *testing.T) {
 		}
 	})
 }
+
+// ⭐ ARCH-005: Timestamp clock configuration tests - 🔧
+func TestResolveTimestampLocation(t *testing.T) {
+	tests := []struct {
+		name    string
+		tz      string
+		want    string
+		wantErr bool
+	}{
+		{"empty uses local", "", time.Local.String(), false},
+		{"local keyword", "local", time.Local.String(), false},
+		{"utc is case-insensitive", "utc", "UTC", false},
+		{"named zone", "America/New_York", "America/New_York", false},
+		{"unknown zone", "Mars/Olympus_Mons", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loc, err := ResolveTimestampLocation(tt.tz)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ResolveTimestampLocation(%q) expected error", tt.tz)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveTimestampLocation(%q) error: %v", tt.tz, err)
+			}
+			if loc.String() != tt.want {
+				t.Errorf("ResolveTimestampLocation(%q) = %s, want %s", tt.tz, loc, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatNameTimestamp(t *testing.T) {
+	instant := time.Date(2024, time.March, 10, 23, 30, 0, 0, time.UTC)
+
+	if got := FormatNameTimestamp("UTC", "", instant); got != "2024-03-10-23-30" {
+		t.Errorf("default layout in UTC = %q", got)
+	}
+	if got := FormatNameTimestamp("Asia/Tokyo", defaultTimestampFormat, instant); got != "2024-03-11-08-30" {
+		t.Errorf("Asia/Tokyo timestamp = %q", got)
+	}
+}
+
+func TestValidateTimestampSettings(t *testing.T) {
+	t.Run("defaults are valid", func(t *testing.T) {
+		if err := ValidateTimestampSettings(DefaultConfig()); err != nil {
+			t.Errorf("default config rejected: %v", err)
+		}
+	})
+
+	t.Run("invalid timezone", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.TimestampTimezone = "Nowhere/Land"
+		if err := ValidateTimestampSettings(cfg); err == nil {
+			t.Error("expected error for unknown timezone")
+		}
+	})
+
+	t.Run("format the patterns cannot parse", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.TimestampFormat = "20060102T1504"
+		if err := ValidateTimestampSettings(cfg); err == nil {
+			t.Error("expected error for compact format under default patterns")
+		}
+	})
+
+	t.Run("format with swapped fields", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.TimestampFormat = "2006-02-01-15-04"
+		if err := ValidateTimestampSettings(cfg); err == nil {
+			t.Error("expected error when day and month are swapped")
+		}
+	})
+
+	t.Run("custom format with matching patterns", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.TimestampFormat = "20060102T1504"
+		cfg.PatternArchiveFilename = `(?P<prefix>[^-]*)-(?P<year>\d{4})(?P<month>\d{2})(?P<day>\d{2})T` +
+			`(?P<hour>\d{2})(?P<minute>\d{2})(?:=(?P<note>.+))?\.zip`
+		cfg.PatternBackupFilename = `(?P<filename>[^/]+)-(?P<year>\d{4})(?P<month>\d{2})(?P<day>\d{2})T` +
+			`(?P<hour>\d{2})(?P<minute>\d{2})(?:=(?P<note>.+))?`
+		if err := ValidateTimestampSettings(cfg); err != nil {
+			t.Errorf("matching patterns rejected: %v", err)
+		}
+	})
+
+	t.Run("loaded from config file", func(t *testing.T) {
+		origEnv := os.Getenv("BKPDIR_CONFIG")
+		defer os.Setenv("BKPDIR_CONFIG", origEnv)
+
+		dir := t.TempDir()
+		configPath := filepath.Join(dir, ".bkpdir.yml")
+		if err := os.WriteFile(configPath, []byte("timestamp_timezone: UTC\n"), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		os.Setenv("BKPDIR_CONFIG", configPath)
+
+		cfg, err := LoadConfig(dir)
+		if err != nil {
+			t.Fatalf("LoadConfig error: %v", err)
+		}
+		assertStringEqual(t, "TimestampTimezone", cfg.TimestampTimezone, "UTC")
+
+		if err := os.WriteFile(configPath, []byte("timestamp_format: \"2006/01/02\"\n"), 0644); err != nil {
+			t.Fatalf("Failed to write config: %v", err)
+		}
+		cfg, err = LoadConfig(dir)
+		if err == nil {
+			t.Error("LoadConfig should reject an unparseable timestamp_format")
+		}
+		if cfg == nil {
+			t.Error("LoadConfig should still return the config so callers can read status codes")
+		}
+	})
+
+	t.Run("inherited", func(t *testing.T) {
+		parent := DefaultConfig()
+		parent.TimestampTimezone = "UTC"
+		parent.TimestampFormat = "20060102T1504"
+		child := DefaultConfig()
+		child.TimestampFormat = "2006-01-02T15-04"
+
+		cfg, err := applyMergeStrategies(parent, child)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertStringEqual(t, "TimestampTimezone", cfg.TimestampTimezone, "UTC")
+		assertStringEqual(t, "TimestampFormat", cfg.TimestampFormat, "2006-01-02T15-04")
+	})
+}
+
+// ⭐ CFG-DESCRIBE-001: Configuration key description tests - 🔧
+func TestDescribeConfigKey(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = "/srv/archives"
+
+	t.Run("full description", func(t *testing.T) {
+		out, err := describeConfigKey(cfg, "archive_dir_path")
+		if err != nil {
+			t.Fatalf("describeConfigKey failed: %v", err)
+		}
+		for _, want := range []string{
+			"Type:        string",
+			`Default:     "../.bkpdir"`,
+			`Current:     "/srv/archives"`,
+			"Env var:     BKPDIR_ARCHIVE_DIR",
+			"Related:     use_current_dir_name, backup_dir_path",
+		} {
+			if !strings.Contains(out, want) {
+				t.Errorf("Description missing %q:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("nested leaf name", func(t *testing.T) {
+		out, err := describeConfigKey(cfg, "checksum_algorithm")
+		if err != nil {
+			t.Fatalf("describeConfigKey failed: %v", err)
+		}
+		if !strings.HasPrefix(out, "verification.checksum_algorithm\n") {
+			t.Errorf("Expected full YAML path heading, got:\n%s", out)
+		}
+		if !strings.Contains(out, "Allowed:     sha256, md5, sha1") {
+			t.Errorf("Expected allowed values, got:\n%s", out)
+		}
+	})
+
+	t.Run("generated description", func(t *testing.T) {
+		out, err := describeConfigKey(cfg, "status_config_error")
+		if err != nil {
+			t.Fatalf("describeConfigKey failed: %v", err)
+		}
+		if !strings.Contains(out, "Exit code when: config error") || !strings.Contains(out, "Env var:     (none)") {
+			t.Errorf("Unexpected description:\n%s", out)
+		}
+	})
+
+	t.Run("unknown key", func(t *testing.T) {
+		if _, err := describeConfigKey(cfg, "no_such_key"); err == nil {
+			t.Error("Expected error for unknown key")
+		}
+	})
+}
+
+// ⭐ CFG-SCHEMA-001: JSON Schema export tests - 🔧
+func TestGenerateConfigSchema(t *testing.T) {
+	data, err := MarshalConfigSchema()
+	if err != nil {
+		t.Fatalf("MarshalConfigSchema failed: %v", err)
+	}
+
+	var schema struct {
+		Schema     string `json:"$schema"`
+		Type       string `json:"type"`
+		Properties map[string]struct {
+			Type        string                     `json:"type"`
+			Default     interface{}                `json:"default"`
+			Enum        []string                   `json:"enum"`
+			Description string                     `json:"description"`
+			Properties  map[string]json.RawMessage `json:"properties"`
+		} `json:"properties"`
+	}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("Schema is not valid JSON: %v", err)
+	}
+
+	if schema.Schema != configSchemaDialect || schema.Type != "object" {
+		t.Errorf("Unexpected schema header: %q %q", schema.Schema, schema.Type)
+	}
+
+	archiveDir := schema.Properties["archive_dir_path"]
+	if archiveDir.Type != "string" || archiveDir.Default != "../.bkpdir" || archiveDir.Description == "" {
+		t.Errorf("Unexpected archive_dir_path schema: %+v", archiveDir)
+	}
+	if schema.Properties["status_config_error"].Type != "integer" {
+		t.Error("Status codes should be integers")
+	}
+	if schema.Properties["exclude_patterns"].Type != "array" {
+		t.Error("exclude_patterns should be an array")
+	}
+
+	verification := schema.Properties["verification"]
+	if verification.Type != "object" {
+		t.Fatalf("verification should be an object, got %q", verification.Type)
+	}
+	var checksum struct {
+		Enum []string `json:"enum"`
+	}
+	if err := json.Unmarshal(verification.Properties["checksum_algorithm"], &checksum); err != nil {
+		t.Fatalf("checksum_algorithm schema: %v", err)
+	}
+	if strings.Join(checksum.Enum, ",") != "sha256,md5,sha1" {
+		t.Errorf("Unexpected checksum enum: %v", checksum.Enum)
+	}
+
+	if len(schema.Properties["timestamp_timezone"].Enum) != 0 {
+		t.Error("Open value sets such as timestamp_timezone must not become enums")
+	}
+}
+
+// ⭐ SECRET-001: Secrets redacted in config output - 🧪
+func TestSecretConfigRedaction(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "secret.yml")
+	createTestConfigFileWithData(t, configPath, map[string]interface{}{
+		"encryption": map[string]interface{}{"passphrase": "hunter2"},
+		"hooks": map[string]interface{}{
+			"mysql": map[string]interface{}{"dsn": "mysql://app:s3cret@db/shop"},
+		},
+	})
+	t.Setenv("BKPDIR_CONFIG", configPath)
+
+	cfg, err := LoadConfig(dir)
+	if err != nil {
+		t.Fatalf("LoadConfig error: %v", err)
+	}
+	key, err := loadEncryptionKey(cfg)
+	if err != nil || key == nil || string(key.passphrase) != "hunter2" {
+		t.Fatalf("Expected the configured passphrase to be used, got %v, %v", key, err)
+	}
+
+	var passphrase string
+	for _, v := range GetAllConfigValuesWithSources(cfg, dir) {
+		if strings.Contains(v.ConfigValue.Value, "hunter2") || strings.Contains(v.ConfigValue.Value, "s3cret") {
+			t.Errorf("Secret shown in %s = %s", v.ConfigValue.Name, v.ConfigValue.Value)
+		}
+		if v.ConfigValue.Name == "passphrase" {
+			passphrase = v.ConfigValue.Value
+			if v.FieldInfo.Type != "secret" || v.ConfigValue.Source == "default" {
+				t.Errorf("Expected a configured secret, got type %s from %s", v.FieldInfo.Type, v.ConfigValue.Source)
+			}
+		}
+	}
+	if passphrase != config.SecretRedacted {
+		t.Errorf("Expected the passphrase to be redacted, got %q", passphrase)
+	}
+
+	template, err := generateConfigurationTemplateWithOptions(cfg, TemplateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(template, "hunter2") || strings.Contains(template, "s3cret") {
+		t.Error("Secret shown in the generated template")
+	}
+
+	cfg.Encryption.Passphrase = "env:BKPDIR_TEST_PASSPHRASE"
+	if got := formatFieldValue(cfg.Encryption.Passphrase, reflect.String); got != "env:BKPDIR_TEST_PASSPHRASE" {
+		t.Errorf("Expected references to be shown, got %q", got)
+	}
+	if _, err := loadEncryptionKey(cfg); err == nil || !strings.Contains(err.Error(), "is not set") {
+		t.Errorf("Expected an unset passphrase variable to be reported, got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// This file is part of bkpdir
+//
+// Package main provides validation of values written by `config set`, using
+// the reflected field metadata and the descriptions registry plus custom
+// per-field validators.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/git"
+)
+
+// ⭐ CFG-SET-001: Per-field value validators - 🛡️
+// configValueValidators holds constraints that field metadata cannot express,
+// keyed by YAML path. They run after the type and Allowed checks.
+var configValueValidators = map[string]func(value string) error{
+	"timestamp_timezone": func(value string) error {
+		if _, err := ResolveTimestampLocation(value); err != nil {
+			return errors.Unwrap(err)
+		}
+		return nil
+	},
+	"max_file_size":                 validateByteSizeValue,
+	"max_total_size":                validateByteSizeValue,
+	"repository.average_chunk_size": validateByteSizeValue,
+	"git.command_timeout": func(value string) error {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("must be a duration such as 30s or 2m: %w", err)
+		}
+		if d < 0 {
+			return fmt.Errorf("must not be negative")
+		}
+		return nil
+	},
+	"git.provider": git.ValidateProvider,
+}
+
+// validateByteSizeValue accepts sizes such as 500MB; empty means unlimited.
+func validateByteSizeValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	_, err := ParseByteSize(value)
+	return err
+}
+
+// ⭐ CFG-SET-001: Configuration value validation - 🛡️
+// validateConfigSetValue resolves key to a configuration field and checks value
+// against the field's type, its documented allowed values and any custom
+// validator. It returns the field so the caller can convert and store the value.
+func validateConfigSetValue(key, value string) (configFieldInfo, error) {
+	field, err := findConfigField(DefaultConfig(), key)
+	if err != nil {
+		return configFieldInfo{}, err
+	}
+	path := configYAMLPath(field.Path)
+	if field.IsSlice || field.IsStruct || field.Kind == reflect.Map {
+		return field, fmt.Errorf("%s is a %s and cannot be set with config set; edit the configuration file instead", path, field.Type)
+	}
+
+	switch field.Kind {
+	case reflect.Bool:
+		if value != "true" && value != "false" {
+			return field, fmt.Errorf("%s requires a boolean value (true/false), got: %s", path, value)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return field, fmt.Errorf("%s requires an integer value, got: %s", path, value)
+		}
+		if field.Category == "status_codes" && (n < 0 || n > 255) {
+			return field, fmt.Errorf("%s must be an exit status between 0 and 255, got: %d", path, n)
+		}
+		if n < 0 && field.Category != "status_codes" {
+			return field, fmt.Errorf("%s must not be negative, got: %d", path, n)
+		}
+	}
+
+	if allowed := enumeratedValues(describeConfigField(field).Allowed); len(allowed) > 0 {
+		if !slices.Contains(allowed, value) {
+			return field, fmt.Errorf("%s must be one of %s, got: %s", path, strings.Join(allowed, ", "), value)
+		}
+	}
+
+	if validate, ok := configValueValidators[path]; ok {
+		if err := validate(value); err != nil {
+			return field, fmt.Errorf("invalid %s: %w", path, err)
+		}
+	}
+	return field, nil
+}
+
+// enumeratedValues returns allowed as a closed set, or nil when it contains a
+// placeholder such as "<IANA zone name>" and so is only descriptive.
+func enumeratedValues(allowed []string) []string {
+	for _, a := range allowed {
+		if strings.HasPrefix(a, "<") {
+			return nil
+		}
+	}
+	return allowed
+}
@@ -0,0 +1,51 @@
+// This file is part of bkpdir
+
+// Package main provides tests for config set value validation.
+// It verifies type, allowed-value and per-field checks and nested key storage.
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ⭐ CFG-SET-001: Configuration value validation - 🧪
+func TestValidateConfigSetValue(t *testing.T) {
+	tests := []struct {
+		key     string
+		value   string
+		wantErr string
+	}{
+		{"archive_dir_path", "/backups", ""},
+		{"checksum_algorithm", "sha1", ""},
+		{"checksum_algorithm", "crc32", "must be one of sha256, md5, sha1"},
+		{"timestamp_timezone", "Europe/Berlin", ""},
+		{"timestamp_timezone", "Mars/Base", "unknown time zone"},
+		{"git.command_timeout", "45s", ""},
+		{"command_timeout", "0", ""},
+		{"git.command_timeout", "10 minutes", "must be a duration"},
+		{"git.command_timeout", "-5s", "must not be negative"},
+		{"git.provider", "native", ""},
+		{"provider", "libgit2", "must be one of"},
+		{"max_file_size", "500MB", ""},
+		{"max_file_size", "", ""},
+		{"max_file_size", "5QB", "invalid max_file_size"},
+		{"limit_action", "explode", "must be one of"},
+		{"keep_going", "yes", "requires a boolean"},
+		{"status_config_error", "300", "between 0 and 255"},
+		{"trash_retention_days", "-1", "must not be negative"},
+		{"max_file_count", "ten", "requires an integer"},
+		{"exclude_patterns", "*.tmp", "cannot be set with config set"},
+		{"no_such_key", "1", "unknown configuration key"},
+	}
+
+	for _, tt := range tests {
+		_, err := validateConfigSetValue(tt.key, tt.value)
+		switch {
+		case tt.wantErr == "" && err != nil:
+			t.Errorf("%s=%q: unexpected error %v", tt.key, tt.value, err)
+		case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+			t.Errorf("%s=%q: expected error containing %q, got %v", tt.key, tt.value, tt.wantErr, err)
+		}
+	}
+}
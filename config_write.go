@@ -0,0 +1,216 @@
+// This file is part of bkpdir
+//
+// Package main provides safe updates of .bkpdir.yml by `config set`: the file
+// is locked for the read-modify-write cycle, edited as a YAML node tree so
+// comments and key order survive, and backed up before it is replaced. With
+// --file or --global another file of the configuration chain is written.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"bkpdir/pkg/fileops"
+)
+
+// configLockTimeout bounds how long config set waits for another writer.
+const configLockTimeout = 5 * time.Second
+
+// configBackupSuffix names the copy of the previous configuration file.
+const configBackupSuffix = ".backup"
+
+// ⭐ CFG-WRITE-001: Locked configuration update - 🛡️
+// writeConfigValue sets yamlPath to value in the configuration file at
+// configPath. The file is locked while it is read, edited, backed up, replaced
+// and journaled for undo, so concurrent config set runs cannot lose updates.
+// cfg decides who owns the undo journal and may be nil.
+func writeConfigValue(cfg *Config, configPath, yamlPath string, value interface{}, description string) error {
+	// ⭐ CFG-SET-FILE-001: The user configuration directory may not exist yet
+	if err := fileops.MkdirAll(filepath.Dir(configPath), 0o755); err != nil {
+		return fmt.Errorf("cannot create %s: %v", filepath.Dir(configPath), err)
+	}
+	lock, err := fileops.LockFile(configPath, configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	// ⭐ UNDO-001: Capture the previous file so the change can be undone
+	previous, readErr := os.ReadFile(configPath)
+	previousExisted := readErr == nil
+	if readErr != nil && !os.IsNotExist(readErr) {
+		return fmt.Errorf("cannot read %s: %v", configPath, readErr)
+	}
+
+	updated, err := setConfigYAMLValue(previous, yamlPath, value)
+	if err != nil {
+		return fmt.Errorf("cannot update %s: %v", configPath, err)
+	}
+
+	if _, err := fileops.BackupBeforeOverwrite(configPath, configBackupSuffix); err != nil {
+		return err
+	}
+	// 🔶 FILE-004: Atomic, fsynced write so a crash never leaves a truncated config
+	if err := fileops.AtomicWriteFile(configPath, updated, 0644); err != nil {
+		return fmt.Errorf("cannot write %s: %v", configPath, err)
+	}
+
+	if err := RecordFileChange(cfg, JournalOpConfigSet, description, configPath, previous, previousExisted); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record change for undo: %v\n", err)
+	}
+	return nil
+}
+
+// ⭐ CFG-WRITE-001: Comment-preserving YAML edit - 🔧
+// setConfigYAMLValue returns data with the dotted yamlPath set to value.
+// Existing keys are updated in place, keeping their comments; missing keys
+// and sections are appended. The original indentation is kept.
+func setConfigYAMLValue(data []byte, yamlPath string, value interface{}) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 {
+		// Empty or comment-only file
+		doc = yaml.Node{
+			Kind:        yaml.DocumentNode,
+			HeadComment: doc.HeadComment,
+			Content:     []*yaml.Node{{Kind: yaml.MappingNode, Tag: "!!map"}},
+		}
+	}
+
+	var scalar yaml.Node
+	if err := scalar.Encode(value); err != nil {
+		return nil, err
+	}
+
+	section := doc.Content[0]
+	parts := strings.Split(yamlPath, ".")
+	for i, name := range parts {
+		if section.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("%s is not a mapping", strings.Join(parts[:i], "."))
+		}
+		node := mappingValue(section, name)
+		if node == nil {
+			node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+			section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, node)
+		}
+		if i == len(parts)-1 {
+			node.Kind, node.Tag, node.Value, node.Style = scalar.Kind, scalar.Tag, scalar.Value, scalar.Style
+			node.Content = nil
+			break
+		}
+		if node.Kind == yaml.ScalarNode && node.Tag == "!!null" {
+			// "section:" with nothing under it
+			node.Kind, node.Tag, node.Value = yaml.MappingNode, "!!map", ""
+		}
+		section = node
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(yamlIndent(data))
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	if err := enc.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// mappingValue returns the value node stored under key in a mapping node.
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// yamlIndent returns the indentation of the first nested key in data, or 2
+// when there is none.
+func yamlIndent(data []byte) int {
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimLeft(line, " ")
+		indent := len(line) - len(trimmed)
+		if indent > 0 && trimmed != "" && !strings.HasPrefix(trimmed, "#") && !strings.HasPrefix(trimmed, "-") {
+			return indent
+		}
+	}
+	return 2
+}
+
+// ⭐ CFG-SET-FILE-001: Configuration file written by config set - 🔧
+// configSetTarget returns the configuration file config set writes: file,
+// relative to root, when given; the user configuration file with global;
+// otherwise .bkpdir.yml in root. The user configuration file is
+// ~/.bkpdir.yml while it is not migrated to the config directory.
+func configSetTarget(root, file string, global bool) (string, error) {
+	switch {
+	case file != "" && global:
+		return "", fmt.Errorf("--file and --global cannot be used together")
+	case file != "":
+		path := expandPath(file)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(root, path)
+		}
+		return filepath.Clean(path), nil
+	case global:
+		return unmigrated(expandPath(userConfigSearchPath()), expandPath("~/.bkpdir.yml")), nil
+	}
+	return filepath.Join(root, ".bkpdir.yml"), nil
+}
+
+// ⭐ CFG-SET-FILE-001: Source of an effective value - 🔍
+// configValueSource returns the file of the configuration chain of root that
+// sets yamlPath last, and so decides its value, or "default" when no file
+// sets it. Top-level keys may carry a merge strategy prefix such as "+".
+func configValueSource(root, yamlPath string) (string, error) {
+	files, err := configChainFiles(root)
+	if err != nil {
+		return "", err
+	}
+	parts := strings.Split(yamlPath, ".")
+	for i := len(files) - 1; i >= 0; i-- {
+		data, err := os.ReadFile(files[i])
+		if err != nil {
+			continue
+		}
+		var doc yaml.Node
+		if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+			continue
+		}
+		for _, prefix := range []string{"", "+", "^", "!", "="} {
+			if configNodeAt(doc.Content[0], append([]string{prefix + parts[0]}, parts[1:]...)) != nil {
+				return files[i], nil
+			}
+		}
+	}
+	return "default", nil
+}
+
+// configNodeAt returns the node at the key path below mapping, or nil.
+func configNodeAt(mapping *yaml.Node, path []string) *yaml.Node {
+	node := mapping
+	for _, name := range path {
+		if node.Kind != yaml.MappingNode {
+			return nil
+		}
+		if node = mappingValue(node, name); node == nil {
+			return nil
+		}
+	}
+	return node
+}
@@ -0,0 +1,204 @@
+// This file is part of bkpdir
+
+// Package main provides tests for locked, comment-preserving config writes.
+// It verifies YAML node editing, backups and serialization of concurrent writers.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ CFG-WRITE-001: Comment-preserving YAML edit - 🧪
+func TestSetConfigYAMLValue(t *testing.T) {
+	original := `# Project backup settings
+archive_dir_path: ../archives # relative to the project
+
+# Verification
+verification:
+    # run after each archive
+    verify_on_create: false
+    checksum_algorithm: sha256
+git:
+`
+	data, err := setConfigYAMLValue([]byte(original), "verification.verify_on_create", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = setConfigYAMLValue(data, "git.command_timeout", "45s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = setConfigYAMLValue(data, "status_config_error", 9)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(data)
+
+	for _, want := range []string{
+		"# Project backup settings\n",
+		"archive_dir_path: ../archives # relative to the project\n",
+		"# Verification\n",
+		"    # run after each archive\n    verify_on_create: true\n",
+		"git:\n    command_timeout: 45s\n",
+		"status_config_error: 9\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Expected %q in:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "archive_dir_path") > strings.Index(got, "verification:") {
+		t.Errorf("Expected key order to be preserved:\n%s", got)
+	}
+
+	var cfg Config
+	if err := yaml.Unmarshal(data, &cfg); err != nil || cfg.Git == nil || cfg.Git.CommandTimeout != "45s" || cfg.StatusConfigError != 9 {
+		t.Errorf("Expected the result to load as configuration, got %+v (%v)", cfg.Git, err)
+	}
+
+	empty, err := setConfigYAMLValue(nil, "verification.checksum_algorithm", "md5")
+	if err != nil || string(empty) != "verification:\n  checksum_algorithm: md5\n" {
+		t.Errorf("Unexpected result for a new file: %q (%v)", empty, err)
+	}
+
+	if _, err := setConfigYAMLValue([]byte("archive_dir_path: x\n"), "archive_dir_path.nested", "y"); err == nil {
+		t.Error("Expected an error when a scalar is used as a section")
+	}
+}
+
+// ⭐ CFG-WRITE-001: Locked configuration update - 🧪
+func TestWriteConfigValueConcurrent(t *testing.T) {
+	t.Setenv("BKPDIR_JOURNAL", filepath.Join(t.TempDir(), "journal.json"))
+	configPath := filepath.Join(t.TempDir(), ".bkpdir.yml")
+	if err := os.WriteFile(configPath, []byte("# keep me\narchive_dir_path: a\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	const writers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			key := fmt.Sprintf("section.key_%d", i)
+			errs <- writeConfigValue(nil, configPath, key, i, "config "+key)
+		}(i)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var parsed struct {
+		Section map[string]int `yaml:"section"`
+	}
+	if err := yaml.Unmarshal(data, &parsed); err != nil || len(parsed.Section) != writers {
+		t.Errorf("Expected %d keys after concurrent writes, got %v (%v)", writers, parsed.Section, err)
+	}
+	if !strings.HasPrefix(string(data), "# keep me\n") {
+		t.Errorf("Expected the leading comment to survive, got:\n%s", data)
+	}
+	if _, err := os.Stat(configPath + configBackupSuffix); err != nil {
+		t.Errorf("Expected a backup of the previous version: %v", err)
+	}
+	if _, err := os.Stat(configPath + ".lock"); !os.IsNotExist(err) {
+		t.Errorf("Expected the lock file to be removed, got %v", err)
+	}
+
+	lock, err := fileops.LockFile(configPath, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lock.Unlock()
+	if _, err := fileops.LockFile(configPath, 0); !errors.Is(err, fileops.ErrLocked) {
+		t.Errorf("Expected ErrLocked while the lock is held, got %v", err)
+	}
+}
+
+// ⭐ CFG-SET-FILE-001: Configuration file written by config set - 🧪
+func TestConfigSetTarget(t *testing.T) {
+	root := t.TempDir()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", "")
+
+	tests := []struct {
+		name   string
+		file   string
+		global bool
+		want   string
+	}{
+		{"local", "", false, filepath.Join(root, ".bkpdir.yml")},
+		{"relative file", "../team.yml", false, filepath.Join(filepath.Dir(root), "team.yml")},
+		{"absolute file", "/etc/bkpdir.yml", false, "/etc/bkpdir.yml"},
+		{"global", "", true, filepath.Join(home, ".config", "bkpdir", "config.yml")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := configSetTarget(root, tt.file, tt.global)
+			if err != nil || got != tt.want {
+				t.Errorf("configSetTarget(%q, %v) = %q, %v, want %q", tt.file, tt.global, got, err, tt.want)
+			}
+		})
+	}
+
+	legacy := filepath.Join(home, ".bkpdir.yml")
+	if err := os.WriteFile(legacy, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := configSetTarget(root, "", true); got != legacy {
+		t.Errorf("Expected the unmigrated %s with --global, got %s", legacy, got)
+	}
+	if _, err := configSetTarget(root, "x.yml", true); err == nil {
+		t.Error("Expected --file with --global to fail")
+	}
+}
+
+// ⭐ CFG-SET-FILE-001: Source of an effective value - 🧪
+func TestConfigValueSource(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "project")
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BKPDIR_CONFIG", "./.bkpdir.yml")
+	local := filepath.Join(root, ".bkpdir.yml")
+	team := filepath.Join(dir, "team.yml")
+	files := map[string]string{
+		team:  "include_git_info: false\n+exclude_patterns: ['*.log']\nverification:\n  verify_on_create: true\n",
+		local: "inherit:\n  - ../team.yml\ninclude_git_info: true\n",
+	}
+	for path, content := range files {
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for yamlPath, want := range map[string]string{
+		"include_git_info":              local,
+		"exclude_patterns":              team,
+		"verification.verify_on_create": team,
+		"archive_dir_path":              "default",
+	} {
+		if got, err := configValueSource(root, yamlPath); err != nil || got != want {
+			t.Errorf("configValueSource(%s) = %q, %v, want %q", yamlPath, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// This file is part of bkpdir
+//
+// Package main provides the cross-archive duplicate analysis of
+// `bkpdir stats --dedup`, which finds files stored identically in several
+// archives and estimates the space a deduplicating layout would save.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// defaultDedupGroups is the number of duplicate groups shown by stats --dedup.
+const defaultDedupGroups = 10
+
+// dedupAdviceRatio is the share of savable bytes from which the analysis
+// suggests a different storage layout.
+const dedupAdviceRatio = 0.2
+
+// ⭐ DEDUP-001: Duplicate analysis report - 📝
+// DedupReport summarizes the files stored more than once across archives.
+// Sizes are the compressed sizes of the archive entries, which is the space
+// the copies take on disk.
+type DedupReport struct {
+	Archives       int              `json:"archives"`
+	HashedArchives int              `json:"hashed_archives"` // Archives whose manifest lists file hashes
+	Files          int              `json:"files"`
+	StoredBytes    int64            `json:"stored_bytes"`
+	DuplicateFiles int              `json:"duplicate_files"` // Copies beyond the first of each file
+	SavableBytes   int64            `json:"savable_bytes"`
+	Groups         []DuplicateGroup `json:"groups,omitempty"`
+	Unreadable     []string         `json:"unreadable,omitempty"` // Archives that could not be opened
+	Advice         []string         `json:"advice,omitempty"`
+}
+
+// DuplicateGroup is one file content stored several times. SHA256 is set
+// when every copy has a hash in its manifest; otherwise the copies only share
+// their size and CRC-32.
+type DuplicateGroup struct {
+	Size         int64      `json:"size"`
+	CRC32        string     `json:"crc32"`
+	SHA256       string     `json:"sha256,omitempty"`
+	SavableBytes int64      `json:"savable_bytes"`
+	Copies       []FileCopy `json:"copies"`
+}
+
+// FileCopy locates one copy of a file.
+type FileCopy struct {
+	Archive string `json:"archive"`
+	Path    string `json:"path"`
+}
+
+// entryKey identifies probable duplicates from the zip central directory.
+type entryKey struct {
+	size  uint64
+	crc32 uint32
+}
+
+// storedEntry is one archive entry considered by the analysis.
+type storedEntry struct {
+	FileCopy
+	compressed int64
+	sha256     string
+}
+
+// ⭐ DEDUP-001: Cross-archive duplicate detection - 🔍
+// AnalyzeDuplicates finds archive entries with identical content across the
+// archives, oldest first. Entries are matched by size and CRC-32 from the zip
+// directory and split by SHA-256 where manifests record hashes, so no file
+// data is read. Empty files, directories and internal entries are ignored,
+// and archives that cannot be opened are listed as unreadable. At most top
+// groups, largest savings first, are kept in the report.
+func AnalyzeDuplicates(archives []Archive, top int) *DedupReport {
+	sorted := append([]Archive(nil), archives...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreationTime.Before(sorted[j].CreationTime) })
+
+	report := &DedupReport{}
+	candidates := make(map[entryKey][]storedEntry)
+	for _, a := range sorted {
+		hashes, err := manifestHashes(a.Path)
+		if err != nil {
+			report.Unreadable = append(report.Unreadable, a.Name)
+			continue
+		}
+		r, err := zip.OpenReader(a.Path)
+		if err != nil {
+			report.Unreadable = append(report.Unreadable, a.Name)
+			continue
+		}
+		report.Archives++
+		if hashes != nil {
+			report.HashedArchives++
+		}
+		for _, f := range r.File {
+			if strings.HasSuffix(f.Name, "/") || f.Name == ".checksums" {
+				continue
+			}
+			report.Files++
+			report.StoredBytes += int64(f.CompressedSize64)
+			if f.UncompressedSize64 == 0 {
+				continue
+			}
+			key := entryKey{size: f.UncompressedSize64, crc32: f.CRC32}
+			candidates[key] = append(candidates[key], storedEntry{
+				FileCopy:   FileCopy{Archive: a.Name, Path: f.Name},
+				compressed: int64(f.CompressedSize64),
+				sha256:     hashes[f.Name],
+			})
+		}
+		r.Close()
+	}
+
+	for key, entries := range candidates {
+		for _, group := range splitByHash(entries) {
+			if len(group) < 2 {
+				continue
+			}
+			g := DuplicateGroup{Size: int64(key.size), CRC32: fmt.Sprintf("%08x", key.crc32), SHA256: group[0].sha256}
+			for i, e := range group {
+				g.Copies = append(g.Copies, e.FileCopy)
+				if i > 0 {
+					g.SavableBytes += e.compressed
+				}
+			}
+			report.DuplicateFiles += len(group) - 1
+			report.SavableBytes += g.SavableBytes
+			report.Groups = append(report.Groups, g)
+		}
+	}
+	sort.Slice(report.Groups, func(i, j int) bool {
+		gi, gj := report.Groups[i], report.Groups[j]
+		if gi.SavableBytes != gj.SavableBytes {
+			return gi.SavableBytes > gj.SavableBytes
+		}
+		return gi.Copies[0].Path < gj.Copies[0].Path
+	})
+	if top >= 0 && len(report.Groups) > top {
+		report.Groups = report.Groups[:top]
+	}
+	return report
+}
+
+// splitByHash splits entries sharing size and CRC-32 by their SHA-256 when
+// every entry has one. Otherwise they stay one group of probable duplicates.
+func splitByHash(entries []storedEntry) [][]storedEntry {
+	for _, e := range entries {
+		if e.sha256 == "" {
+			return [][]storedEntry{entries}
+		}
+	}
+	var groups [][]storedEntry
+	index := make(map[string]int)
+	for _, e := range entries {
+		i, ok := index[e.sha256]
+		if !ok {
+			i = len(groups)
+			index[e.sha256] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], e)
+	}
+	return groups
+}
+
+// manifestHashes returns the file hashes recorded in the manifest of an
+// archive, or nil when it has none.
+func manifestHashes(archivePath string) (map[string]string, error) {
+	manifest, err := LoadArchiveManifest(archivePath)
+	if err != nil || manifest == nil || len(manifest.Files) == 0 {
+		return nil, err
+	}
+	hashes := make(map[string]string, len(manifest.Files))
+	for _, f := range manifest.Files {
+		hashes[f.Path] = f.SHA256
+	}
+	return hashes, nil
+}
+
+// ⭐ DEDUP-001: Manifest file hashes - 🔧
+// hashArchiveEntries reads a finished archive back and returns the size and
+// SHA-256 of each file entry, for manifest_file_hashes.
+func hashArchiveEntries(archivePath string) ([]ManifestFile, error) {
+	r, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+
+	var files []ManifestFile
+	for _, f := range r.File {
+		if strings.HasSuffix(f.Name, "/") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		h := sha256.New()
+		n, err := io.Copy(h, rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", f.Name, err)
+		}
+		files = append(files, ManifestFile{Path: f.Name, Size: n, SHA256: hex.EncodeToString(h.Sum(nil))})
+	}
+	return files, nil
+}
+
+// ⭐ DEDUP-001: Storage advice - 📝
+// dedupAdvice suggests storage changes when duplicates take a noticeable
+// share of the archive directory.
+func dedupAdvice(report *DedupReport, stats StatsReport, cfg *Config) []string {
+	var advice []string
+	if report.StoredBytes > 0 && float64(report.SavableBytes) >= dedupAdviceRatio*float64(report.StoredBytes) {
+		share := 100 * report.SavableBytes / report.StoredBytes
+		if !repositoryEnabled(cfg) {
+			advice = append(advice, fmt.Sprintf(
+				"%d%% of the stored data repeats files kept in other archives; set repository.path to store new archives as deduplicated snapshots", share))
+		}
+		if stats.IncrementalArchives == 0 && stats.FullArchives > 1 {
+			advice = append(advice, "Only full archives exist; create incremental archives (bkpdir inc) between full archives to store changed files only")
+		}
+		advice = append(advice, "Remove older full archives whose files are repeated by newer ones to consolidate the archive directory")
+	}
+	if report.DuplicateFiles > 0 && report.HashedArchives < report.Archives {
+		advice = append(advice, fmt.Sprintf(
+			"%d of %d archives have no recorded file hashes, so their matches rely on size and CRC-32; set manifest_file_hashes: true to record SHA-256 hashes for new archives",
+			report.Archives-report.HashedArchives, report.Archives))
+	}
+	return advice
+}
+
+// ⭐ DEDUP-001: Text duplicate report - 📝
+// writeDedupText writes the duplicate summary, the largest groups and the advice.
+func writeDedupText(w io.Writer, report *DedupReport, width int) {
+	fmt.Fprintf(w, "\nDuplicate files across %d archives\n", report.Archives)
+	if len(report.Unreadable) > 0 {
+		fmt.Fprintf(w, "  Skipped unreadable archives: %s\n", strings.Join(report.Unreadable, ", "))
+	}
+	if report.DuplicateFiles == 0 {
+		fmt.Fprintln(w, "  No file is stored in more than one place")
+		return
+	}
+	share := int64(0)
+	if report.StoredBytes > 0 {
+		share = 100 * report.SavableBytes / report.StoredBytes
+	}
+	fmt.Fprintf(w, "  %d duplicate copies of %d files; deduplication would save %s (%d%% of %s)\n",
+		report.DuplicateFiles, report.Files, formatHumanSize(report.SavableBytes), share, formatHumanSize(report.StoredBytes))
+
+	if len(report.Groups) > 0 {
+		fmt.Fprintln(w, "\n  Largest duplicates:")
+		for _, g := range report.Groups {
+			match := "size+crc32 " + g.CRC32
+			if g.SHA256 != "" {
+				match = "sha256 " + g.SHA256[:12]
+			}
+			line := fmt.Sprintf("  %9s  %d copies  %s  (%s)", formatHumanSize(g.SavableBytes), len(g.Copies),
+				filepath.ToSlash(g.Copies[0].Path), match)
+			fmt.Fprintln(w, truncateMiddle(line, width))
+		}
+	}
+	for _, a := range report.Advice {
+		fmt.Fprintf(w, "\nSuggestion: %s\n", a)
+	}
+}
+
+// ⭐ DEDUP-001: Duplicate analysis for stats - 🔧
+// analyzeArchiveDuplicates runs the duplicate analysis over archives and adds
+// the advice for the current configuration.
+func analyzeArchiveDuplicates(archives []archiveSize, stats StatsReport, top int, cfg *Config) *DedupReport {
+	entries := make([]Archive, len(archives))
+	for i, a := range archives {
+		entries[i] = a.Archive
+	}
+	report := AnalyzeDuplicates(entries, top)
+	report.Advice = dedupAdvice(report, stats, cfg)
+	return report
+}
@@ -0,0 +1,91 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the cross-archive duplicate analysis.
+// It verifies matching by size and CRC-32, confirmation by manifest hashes
+// and the storage advice.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testArchive writes an archive holding files and returns it as an Archive.
+func testArchive(t *testing.T, dir, name string, created time.Time, files map[string]string) Archive {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	writeTestZip(t, path, files)
+	return Archive{Name: name, Path: path, CreationTime: created}
+}
+
+// ⭐ DEDUP-001: Duplicate detection across archives - 🧪
+func TestAnalyzeDuplicates(t *testing.T) {
+	dir := t.TempDir()
+	shared := strings.Repeat("shared content ", 100)
+	now := time.Now()
+	older := testArchive(t, dir, "p-1.zip", now.Add(-time.Hour), map[string]string{"lib.txt": shared, "a.txt": "a", "empty": ""})
+	newer := testArchive(t, dir, "p-2.zip", now, map[string]string{"lib.txt": shared, "copy/lib.txt": shared, "b.txt": "b", "empty": ""})
+	broken := filepath.Join(dir, "p-3.zip")
+	os.WriteFile(broken, []byte("not a zip"), 0o644)
+
+	report := AnalyzeDuplicates([]Archive{newer, older, {Name: "p-3.zip", Path: broken}}, 10)
+	if report.Archives != 2 || report.Files != 7 || len(report.Unreadable) != 1 {
+		t.Fatalf("Unexpected report %+v", report)
+	}
+	if report.DuplicateFiles != 2 || len(report.Groups) != 1 {
+		t.Fatalf("Expected one group with two extra copies, got %+v", report)
+	}
+	g := report.Groups[0]
+	if g.SHA256 != "" || g.Copies[0] != (FileCopy{Archive: "p-1.zip", Path: "lib.txt"}) || len(g.Copies) != 3 {
+		t.Errorf("Expected an unconfirmed group starting with the oldest copy, got %+v", g)
+	}
+	if g.SavableBytes <= 0 || g.SavableBytes != report.SavableBytes {
+		t.Errorf("Expected savable bytes, got %d of %d", g.SavableBytes, report.SavableBytes)
+	}
+	if top := AnalyzeDuplicates([]Archive{older, newer}, 0); len(top.Groups) != 0 || top.DuplicateFiles != 2 {
+		t.Errorf("Expected --top 0 to hide groups only, got %+v", top)
+	}
+
+	// Recorded hashes confirm matches and separate CRC-32 collisions
+	for _, a := range []Archive{older, newer} {
+		hashes, err := hashArchiveEntries(a.Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if a.Name == "p-2.zip" {
+			for i := range hashes {
+				if hashes[i].Path == "copy/lib.txt" {
+					hashes[i].SHA256 = strings.Repeat("0", 64)
+				}
+			}
+		}
+		if err := StoreArchiveManifest(a.Path, &ArchiveManifest{Files: hashes}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	report = AnalyzeDuplicates([]Archive{older, newer}, 10)
+	if report.HashedArchives != 2 || report.DuplicateFiles != 1 || len(report.Groups[0].SHA256) != 64 {
+		t.Errorf("Expected one confirmed duplicate, got %+v", report)
+	}
+}
+
+func TestDedupAdvice(t *testing.T) {
+	cfg := DefaultConfig()
+	report := &DedupReport{Archives: 3, HashedArchives: 1, StoredBytes: 1000, SavableBytes: 600, DuplicateFiles: 4}
+	advice := dedupAdvice(report, StatsReport{FullArchives: 3}, cfg)
+	joined := strings.Join(advice, "\n")
+	for _, want := range []string{"60%", "repository.path", "bkpdir inc", "manifest_file_hashes"} {
+		if !strings.Contains(joined, want) {
+			t.Errorf("Expected advice mentioning %q, got %q", want, joined)
+		}
+	}
+
+	cfg.Repository.Path = "/repo"
+	report = &DedupReport{Archives: 2, HashedArchives: 2, StoredBytes: 1000, SavableBytes: 50, DuplicateFiles: 1}
+	if advice := dedupAdvice(report, StatsReport{FullArchives: 2}, cfg); len(advice) != 0 {
+		t.Errorf("Expected no advice for little duplication, got %q", advice)
+	}
+}
@@ -0,0 +1,59 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir doctor`, which checks the archive directory
+// of the current directory for problems and suggests a repair for each.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// ⭐ CHAIN-CHECK-001: Archive directory health check - 🛡️
+// RunDoctor checks the incremental chains and looks for foreign files in
+// the archive directory, and fails when a chain needs attention. Foreign
+// files are only reported, as foreign_files decides what happens to them.
+func RunDoctor(w io.Writer, cfg *Config) error {
+	if isRemoteLocation(cfg.ArchiveDirPath) {
+		return NewArchiveError("doctor is not supported for remote archive directories", cfg.StatusConfigError)
+	}
+	if repositoryEnabled(cfg) {
+		return NewArchiveError("doctor checks archives and is not supported with a repository", cfg.StatusConfigError)
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "Archive directory: %s\n", archiveDir)
+
+	reports, err := CheckArchiveChains(cfg, archiveDir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\nIncremental chains: %d\n", len(reports))
+	broken := writeChainReports(w, reports)
+
+	foreign, err := FindForeignFiles(cfg, archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	fmt.Fprintf(w, "\nForeign files: %d\n", len(foreign))
+	for _, f := range foreign {
+		fmt.Fprintf(w, "- %s (%s)\n", f.Name, formatHumanSize(f.Size))
+	}
+	if len(foreign) > 0 {
+		fmt.Fprintf(w, "  → Apply foreign_files to them: bkpdir gc --foreign\n")
+	}
+
+	if broken > 0 {
+		return NewArchiveError(fmt.Sprintf("%d %s need attention", broken, pluralChains(broken)), 1)
+	}
+	if len(foreign) == 0 {
+		fmt.Fprintln(w, "\nNo problems found")
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+// This file is part of bkpdir
+
+// Package main provides tests for encrypted file backups.
+// It verifies the encrypted stream format round trip and its integrity
+// checks, and that encrypted backups are compared and restored by their
+// plaintext.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ ENCRYPT-001: Encrypted stream round trip and integrity - 🧪
+func TestEncryptStream(t *testing.T) {
+	defer func(n int) { pbkdf2Iterations = n }(pbkdf2Iterations)
+	pbkdf2Iterations = 1000
+
+	plain := bytes.Repeat([]byte("secret data "), 20000) // several chunks
+	keys := map[string]*encryptionKey{
+		"key file":   {raw: bytes.Repeat([]byte{7}, 32)},
+		"passphrase": {passphrase: []byte("correct horse")},
+	}
+	for name, key := range keys {
+		t.Run(name, func(t *testing.T) {
+			for _, content := range [][]byte{nil, []byte("short"), plain} {
+				var sealed, opened bytes.Buffer
+				if err := encryptStream(&sealed, bytes.NewReader(content), key); err != nil {
+					t.Fatal(err)
+				}
+				if len(content) > 0 && bytes.Contains(sealed.Bytes(), content[:5]) {
+					t.Error("Expected the plaintext not to appear in the output")
+				}
+				if err := decryptStream(&opened, bytes.NewReader(sealed.Bytes()), key); err != nil || !bytes.Equal(opened.Bytes(), content) {
+					t.Fatalf("Expected a %d byte round trip, got %d bytes, %v", len(content), opened.Len(), err)
+				}
+			}
+		})
+	}
+
+	var sealed bytes.Buffer
+	if err := encryptStream(&sealed, bytes.NewReader(plain), keys["passphrase"]); err != nil {
+		t.Fatal(err)
+	}
+	data := sealed.Bytes()
+	tampered := append([]byte(nil), data...)
+	tampered[len(tampered)/2] ^= 1
+	cases := map[string]struct {
+		data []byte
+		key  *encryptionKey
+	}{
+		"wrong passphrase": {data, &encryptionKey{passphrase: []byte("wrong")}},
+		"key file key":     {data, keys["key file"]},
+		"no key":           {data, nil},
+		"tampered":         {tampered, keys["passphrase"]},
+		"truncated":        {data[:encryptionHeaderSize+4+encryptionChunkSize+16], keys["passphrase"]},
+		"trailing data":    {append(append([]byte(nil), data...), 0), keys["passphrase"]},
+	}
+	for name, c := range cases {
+		if err := decryptStream(&bytes.Buffer{}, bytes.NewReader(c.data), c.key); err == nil {
+			t.Errorf("%s: expected decryption to fail", name)
+		}
+	}
+}
+
+// ⭐ ENCRYPT-001: Per-backup keys from a key file - 🧪
+func TestKeyFileSubkeys(t *testing.T) {
+	key := &encryptionKey{raw: bytes.Repeat([]byte{7}, 32)}
+	subkey := func() []byte {
+		var sealed bytes.Buffer
+		if err := encryptStream(&sealed, strings.NewReader("same content"), key); err != nil {
+			t.Fatal(err)
+		}
+		header := sealed.Bytes()[:encryptionHeaderSize]
+		if header[len(encryptionMagic)] != kdfKeyFileSalted {
+			t.Fatalf("key derivation = %d, want %d", header[len(encryptionMagic)], kdfKeyFileSalted)
+		}
+		return keyFileSubkey(key.raw, header[len(encryptionMagic)+5:len(encryptionMagic)+21])
+	}
+	first, second := subkey(), subkey()
+	if bytes.Equal(first, second) || bytes.Equal(first, key.raw) {
+		t.Error("Expected every backup to be sealed under its own key")
+	}
+
+	// Backups sealed directly under the key file key stay readable
+	header := make([]byte, encryptionHeaderSize)
+	copy(header, encryptionMagic)
+	aead, err := key.aead(header)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed := aead.Seal(nil, chunkNonce(header, 0), []byte("legacy"), chunkAAD(header, true))
+	legacy := append(append(header, byte(0), byte(0), byte(0), byte(len(sealed))), sealed...)
+	var opened bytes.Buffer
+	if err := decryptStream(&opened, bytes.NewReader(legacy), key); err != nil || opened.String() != "legacy" {
+		t.Errorf("legacy key file backup: %q, %v", opened.String(), err)
+	}
+}
+
+// ⭐ ENCRYPT-001: Encrypted file backups - 🧪
+func TestEncryptedFileBackup(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(source, []byte("dear diary"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	keyFile := filepath.Join(tmpDir, "backup.key")
+	if err := os.WriteFile(keyFile, []byte(strings.Repeat("ab", 32)+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.BackupDirPath = filepath.Join(tmpDir, "backups")
+	cfg.UseCurrentDirNameForFiles = false
+	cfg.Encryption.FileBackups = true
+	cfg.Encryption.PassphraseEnv = "BKPDIR_TEST_UNSET_PASSPHRASE"
+	if err := CreateFileBackup(cfg, source, "", false); err == nil {
+		t.Fatal("Expected encrypted backups without a key to be refused")
+	}
+
+	cfg.Encryption.KeyFile = keyFile
+	if err := CreateFileBackup(cfg, source, "", false); err != nil {
+		t.Fatal(err)
+	}
+	backups, _ := ListFileBackups(cfg.BackupDirPath, "notes.txt")
+	if len(backups) != 1 || !isEncryptedBackup(backups[0].Path) {
+		t.Fatalf("Expected one encrypted backup, got %+v", backups)
+	}
+	if data, _ := os.ReadFile(backups[0].Path); bytes.Contains(data, []byte("diary")) {
+		t.Error("Expected the backup not to contain the plaintext")
+	}
+
+	key, _ := loadEncryptionKey(cfg)
+	if identical, _, err := checkForIdenticalFileBackup(source, cfg.BackupDirPath, "notes.txt", key, FileCompareAuto); err != nil || !identical {
+		t.Errorf("Expected the file to match its encrypted backup, got %v, %v", identical, err)
+	}
+	if identical, _, _ := CheckForIdenticalFileBackup(source, cfg.BackupDirPath, "notes.txt"); identical {
+		t.Error("Expected an encrypted backup not to match without a key")
+	}
+
+	// Restore decrypts; the plan and conflict strategy apply as for archives
+	if err := os.WriteFile(source, []byte("changed"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	opts := RestoreOptions{Config: cfg, Output: &out, Archive: backups[0].Path, Target: source, Conflict: RestoreConflictFail, Preview: true}
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "overwrite  notes.txt (10B)") {
+		t.Errorf("Unexpected preview:\n%s", out.String())
+	}
+	opts.Preview = false
+	if err := RestoreArchive(opts); err == nil {
+		t.Error("Expected the fail strategy to refuse overwriting")
+	}
+	opts.Conflict = RestoreConflictOverwrite
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, source, "dear diary")
+
+	opts.Target = tmpDir
+	if err := RestoreArchive(opts); err == nil {
+		t.Error("Expected a directory target to be refused for a file backup")
+	}
+}
@@ -8,6 +8,7 @@
 package main
 
 import (
+	"bkpdir/pkg/fileops"
 	"bkpdir/pkg/formatter"
 	"context"
 	"fmt"
@@ -326,7 +327,7 @@ type TempFile struct {
 func (tf *TempFile) Cleanup() error {
 	// DECISION-REF: DEC-006
 	// 🔶 REFACTOR-004: Standardized cleanup pattern - 📝
-	return os.Remove(tf.Path)
+	return fileops.Remove(tf.Path)
 }
 
 func (tf *TempFile) String() string {
@@ -342,7 +343,7 @@ type TempDir struct {
 func (td *TempDir) Cleanup() error {
 	// DECISION-REF: DEC-006
 	// 🔶 REFACTOR-004: Standardized cleanup pattern - 📝
-	return os.RemoveAll(td.Path)
+	return fileops.RemoveAll(td.Path)
 }
 
 func (td *TempDir) String() string {
@@ -578,7 +579,7 @@ func AtomicWriteFile(path string, data []byte, rm *ResourceManager) error {
 	tempFile := path + ".tmp"
 	rm.AddTempFile(tempFile)
 
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+	if err := fileops.WriteFile(tempFile, data, 0644); err != nil {
 		return NewArchiveErrorWithCause(
 			"Failed to write temporary file",
 			1,
@@ -586,7 +587,7 @@ func AtomicWriteFile(path string, data []byte, rm *ResourceManager) error {
 		)
 	}
 
-	if err := os.Rename(tempFile, path); err != nil {
+	if err := fileops.Rename(tempFile, path); err != nil {
 		return NewArchiveErrorWithCause(
 			"Failed to finalize file",
 			1,
@@ -614,7 +615,7 @@ func AtomicWriteFileWithContext(ctx context.Context, path string, data []byte, r
 		return err
 	}
 
-	if err := os.WriteFile(tempFile, data, 0644); err != nil {
+	if err := fileops.WriteFile(tempFile, data, 0644); err != nil {
 		return NewArchiveErrorWithCause(
 			"Failed to write temporary file",
 			1,
@@ -627,7 +628,7 @@ func AtomicWriteFileWithContext(ctx context.Context, path string, data []byte, r
 		return err
 	}
 
-	if err := os.Rename(tempFile, path); err != nil {
+	if err := fileops.Rename(tempFile, path); err != nil {
 		return NewArchiveErrorWithCause(
 			"Failed to finalize file",
 			1,
@@ -643,7 +644,7 @@ func AtomicWriteFileWithContext(ctx context.Context, path string, data []byte, r
 // SafeMkdirAllWithInterface creates directories using interface abstractions
 func SafeMkdirAllWithInterface(path string, perm os.FileMode, cfg ErrorConfig) error {
 	// 🔶 REFACTOR-004: Resource consolidation - 🔍
-	if err := os.MkdirAll(path, perm); err != nil {
+	if err := fileops.MkdirAll(path, perm); err != nil {
 		if IsDiskFullError(err) {
 			return NewArchiveError(
 				fmt.Sprintf("Failed to create directory due to insufficient disk space: %s", path),
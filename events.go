@@ -0,0 +1,133 @@
+// This file is part of bkpdir
+//
+// Package main provides structured archive lifecycle events for system logs,
+// so fleet management tools can collect backup status without parsing stdout.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ⭐ EVENT-001: Lifecycle event sinks - 🔧
+const (
+	// EventLogNone disables lifecycle events.
+	EventLogNone = "none"
+	// EventLogSyslog sends events to the local syslog socket, which journald
+	// collects on Linux and unified logging collects on macOS.
+	EventLogSyslog = "syslog"
+)
+
+// ⭐ EVENT-001: Lifecycle event types - 🔧
+const (
+	EventCreated  = "created"
+	EventVerified = "verified"
+	EventTrashed  = "trashed"
+	EventPruned   = "pruned"
+	EventTiered   = "tiered"
+	EventFailed   = "failed"
+)
+
+// ⭐ EVENT-001: Operations that produce lifecycle events - 🔧
+const (
+	OperationCreate = "create"
+	OperationVerify = "verify"
+	OperationTrash  = "trash"
+	OperationPrune  = "prune"
+	OperationTier   = "tier"
+)
+
+// operationEvents maps a successful operation to its event type.
+var operationEvents = map[string]string{
+	OperationCreate: EventCreated,
+	OperationVerify: EventVerified,
+	OperationTrash:  EventTrashed,
+	OperationPrune:  EventPruned,
+	OperationTier:   EventTiered,
+}
+
+// ⭐ EVENT-001: Lifecycle event record - 🔧
+// LifecycleEvent is one archive lifecycle transition.
+type LifecycleEvent struct {
+	Type      string
+	Operation string
+	Archive   string
+	Path      string
+	Detail    string
+}
+
+// String renders the event as logfmt so it stays greppable in plain syslog
+// and parseable by log collectors.
+func (e LifecycleEvent) String() string {
+	fields := []string{"event=" + e.Type}
+	if e.Operation != "" {
+		fields = append(fields, "operation="+e.Operation)
+	}
+	if e.Archive != "" {
+		fields = append(fields, "archive="+strconv.Quote(e.Archive))
+	}
+	if e.Path != "" {
+		fields = append(fields, "path="+strconv.Quote(e.Path))
+	}
+	if e.Detail != "" {
+		fields = append(fields, "detail="+strconv.Quote(e.Detail))
+	}
+	return strings.Join(fields, " ")
+}
+
+// eventLogger is the subset of *syslog.Writer used for lifecycle events.
+type eventLogger interface {
+	Info(m string) error
+	Err(m string) error
+	Close() error
+}
+
+// openEventLogger connects to the system log; replaced in tests.
+var openEventLogger = openSystemLogger
+
+// ⭐ EVENT-001: Lifecycle event emission - 🔧
+// EmitLifecycleEvent sends ev to the configured sink. Failures are reported on
+// stderr and never affect the operation that produced the event.
+func EmitLifecycleEvent(sink string, ev LifecycleEvent) {
+	if sink != EventLogSyslog {
+		return
+	}
+	logger, err := openEventLogger()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not connect to system log: %v\n", err)
+		return
+	}
+	defer logger.Close()
+
+	if ev.Type == EventFailed {
+		err = logger.Err(ev.String())
+	} else {
+		err = logger.Info(ev.String())
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write lifecycle event: %v\n", err)
+	}
+}
+
+// emitArchiveEvent emits the outcome of operation on an archive: its success
+// event, or a failed event carrying err as detail.
+func emitArchiveEvent(sink, operation, archivePath string, err error) {
+	ev := LifecycleEvent{
+		Type:      operationEvents[operation],
+		Operation: operation,
+		Archive:   filepath.Base(archivePath),
+		Path:      archivePath,
+	}
+	if err != nil {
+		ev.Type = EventFailed
+		ev.Detail = err.Error()
+	}
+	EmitLifecycleEvent(sink, ev)
+}
@@ -0,0 +1,18 @@
+//go:build !windows
+
+// This file is part of bkpdir
+//
+// Package main provides the system log connection of lifecycle events on
+// systems with a syslog socket.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import "log/syslog"
+
+// openSystemLogger connects to the local syslog socket.
+func openSystemLogger() (eventLogger, error) {
+	return syslog.New(syslog.LOG_INFO|syslog.LOG_USER, "bkpdir")
+}
@@ -0,0 +1,25 @@
+//go:build windows
+
+// This file is part of bkpdir
+//
+// Package main provides the system log connection of lifecycle events on
+// systems without syslog, where events are dropped.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+// openSystemLogger returns a logger that discards every event.
+func openSystemLogger() (eventLogger, error) {
+	return discardEventLogger{}, nil
+}
+
+// discardEventLogger is the eventLogger of systems without syslog.
+type discardEventLogger struct{}
+
+func (discardEventLogger) Info(string) error { return nil }
+
+func (discardEventLogger) Err(string) error { return nil }
+
+func (discardEventLogger) Close() error { return nil }
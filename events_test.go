@@ -0,0 +1,44 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive lifecycle events.
+// It verifies event formatting, severity and sink selection.
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+type fakeEventLogger struct {
+	info []string
+	errs []string
+}
+
+func (f *fakeEventLogger) Info(m string) error { f.info = append(f.info, m); return nil }
+func (f *fakeEventLogger) Err(m string) error  { f.errs = append(f.errs, m); return nil }
+func (f *fakeEventLogger) Close() error        { return nil }
+
+// ⭐ EVENT-001: Lifecycle event tests - 🔧
+func TestEmitLifecycleEvent(t *testing.T) {
+	fake := &fakeEventLogger{}
+	orig := openEventLogger
+	openEventLogger = func() (eventLogger, error) { return fake, nil }
+	defer func() { openEventLogger = orig }()
+
+	emitArchiveEvent(EventLogSyslog, OperationCreate, "/backups/proj/proj-2024-01-01-10-00.zip", nil)
+	want := `event=created operation=create archive="proj-2024-01-01-10-00.zip" path="/backups/proj/proj-2024-01-01-10-00.zip"`
+	if len(fake.info) != 1 || fake.info[0] != want {
+		t.Errorf("Unexpected info events: %q", fake.info)
+	}
+
+	emitArchiveEvent(EventLogSyslog, OperationVerify, "/backups/a.zip", errors.New("bad crc"))
+	want = `event=failed operation=verify archive="a.zip" path="/backups/a.zip" detail="bad crc"`
+	if len(fake.errs) != 1 || fake.errs[0] != want {
+		t.Errorf("Failures should be logged at error severity, got %q", fake.errs)
+	}
+
+	emitArchiveEvent(EventLogNone, OperationCreate, "/backups/b.zip", nil)
+	if len(fake.info) != 1 || len(fake.errs) != 1 {
+		t.Error("No events expected when event_log is none")
+	}
+}
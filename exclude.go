@@ -8,6 +8,12 @@
 package main
 
 import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
 	"bkpdir/pkg/fileops"
 )
 
@@ -29,3 +35,48 @@ func ShouldExcludeFile(path string, patterns []string) bool {
 	// ⭐ EXTRACT-006: Delegating to extracted package - 🔍
 	return fileops.ShouldExcludeFile(path, patterns)
 }
+
+// ⭐ EXCLUDE-001: External exclusion pattern files - 🔧
+// ReadExcludeFile reads exclusion patterns from path, one per line. Blank lines
+// and lines starting with # are ignored.
+func ReadExcludeFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open exclude file: %w", err)
+	}
+	defer f.Close()
+
+	var patterns []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read exclude file %s: %w", path, err)
+	}
+	return patterns, nil
+}
+
+// ⭐ EXCLUDE-001: Exclusion pattern file merging - 🔧
+// ApplyExcludeFrom appends the patterns read from the config's exclude_from
+// files and from extra (the --exclude-from flags) to cfg.ExcludePatterns.
+// Relative paths are resolved against baseDir.
+func ApplyExcludeFrom(cfg *Config, baseDir string, extra []string) error {
+	sources := append(append([]string{}, cfg.ExcludeFrom...), extra...)
+	for _, source := range sources {
+		path := source
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		patterns, err := ReadExcludeFile(path)
+		if err != nil {
+			return err
+		}
+		cfg.ExcludePatterns = append(cfg.ExcludePatterns[:len(cfg.ExcludePatterns):len(cfg.ExcludePatterns)], patterns...)
+	}
+	return nil
+}
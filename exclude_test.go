@@ -4,7 +4,12 @@
 // It verifies pattern matching and exclusion behavior.
 package main
 
-import "testing"
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
 
 // ⭐ FILE-003: File exclusion pattern validation - 📝
 // TEST-REF: TestShouldExcludeFile
@@ -36,3 +41,34 @@ func TestShouldExcludeFile(t *testing.T) {
 		}
 	}
 }
+
+// ⭐ EXCLUDE-001: Exclusion pattern file tests - 📝
+func TestApplyExcludeFrom(t *testing.T) {
+	dir := t.TempDir()
+	writeFile := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeFile(".bkpdirignore", "# build output\nbuild/\n\n  *.tmp  \n")
+	writeFile("extra.ignore", "node_modules/\n")
+
+	cfg := DefaultConfig()
+	cfg.ExcludeFrom = []string{".bkpdirignore"}
+	if err := ApplyExcludeFrom(cfg, dir, []string{filepath.Join(dir, "extra.ignore")}); err != nil {
+		t.Fatalf("ApplyExcludeFrom failed: %v", err)
+	}
+
+	want := []string{".git/", "vendor/", "build/", "*.tmp", "node_modules/"}
+	if strings.Join(cfg.ExcludePatterns, ",") != strings.Join(want, ",") {
+		t.Errorf("ExcludePatterns = %v, want %v", cfg.ExcludePatterns, want)
+	}
+	if !ShouldExcludeFile("build/out.o", cfg.ExcludePatterns) {
+		t.Error("Expected pattern from exclude file to apply")
+	}
+
+	if err := ApplyExcludeFrom(DefaultConfig(), dir, []string{"missing.ignore"}); err == nil {
+		t.Error("Expected error for missing exclude file")
+	}
+}
@@ -0,0 +1,149 @@
+// This file is part of bkpdir
+//
+// Package main provides the exclusion audit of `--explain-exclusions`: how
+// many files and bytes each exclusion pattern kept out of an archive run,
+// and the largest files it skipped, to find out why something was not
+// backed up.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+
+	"bkpdir/pkg/fileops"
+)
+
+// exclusionAuditTop is the number of largest excluded files an audit keeps.
+const exclusionAuditTop = 10
+
+// ExclusionStats is what one exclusion pattern excluded.
+type ExclusionStats struct {
+	Pattern string
+	Origin  string // Setting or file the pattern comes from
+	Files   int
+	Bytes   int64
+}
+
+// ExcludedFile is a file kept out of an archive and the pattern that
+// excluded it.
+type ExcludedFile struct {
+	Path    string
+	Size    int64
+	Pattern string
+}
+
+// ExclusionAudit is what the exclusion patterns keep out of an archive of a
+// directory.
+type ExclusionAudit struct {
+	Dir      string
+	Patterns []ExclusionStats // In the order the patterns are applied
+	Largest  []ExcludedFile   // Largest excluded files, largest first
+	Files    int
+	Bytes    int64
+}
+
+// ⭐ EXCLUDE-AUDIT-001: Exclusion auditing - 🔍
+// AuditExclusions walks dir like an archive run and counts the files and
+// bytes each exclusion pattern excludes. A file matching several patterns
+// counts for the first one only, as that is the one excluding it. extra
+// lists the --exclude-from files; cfg.ExcludePatterns must not hold their
+// patterns yet.
+func AuditExclusions(ctx context.Context, cfg *Config, dir string, extra []string) (*ExclusionAudit, error) {
+	exclusions, err := explainExclusions(cfg, dir, extra)
+	if err != nil {
+		return nil, err
+	}
+	audit := &ExclusionAudit{Dir: dir, Patterns: make([]ExclusionStats, len(exclusions))}
+	matchers := make([]*fileops.PatternMatcher, len(exclusions))
+	for i, e := range exclusions {
+		audit.Patterns[i] = ExclusionStats{Pattern: e.Pattern, Origin: e.Origin}
+		matchers[i] = fileops.NewPatternMatcher([]string{e.Pattern})
+	}
+
+	opts := archiveScanOptions
+	opts.Info = true
+	err = fileops.ParallelWalk(ctx, dir, opts, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || d.IsDir() {
+			return nil
+		}
+		for i, m := range matchers {
+			if !m.ShouldExclude(rel) {
+				continue
+			}
+			var size int64
+			if info, err := d.Info(); err == nil {
+				size = info.Size()
+			}
+			stats := &audit.Patterns[i]
+			stats.Files++
+			stats.Bytes += size
+			audit.Files++
+			audit.Bytes += size
+			audit.addLargest(ExcludedFile{Path: filepath.ToSlash(rel), Size: size, Pattern: stats.Pattern})
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+	}
+	return audit, nil
+}
+
+// addLargest keeps f if it is among the exclusionAuditTop largest excluded
+// files; ties keep the file found first.
+func (a *ExclusionAudit) addLargest(f ExcludedFile) {
+	if len(a.Largest) == exclusionAuditTop && f.Size <= a.Largest[len(a.Largest)-1].Size {
+		return
+	}
+	i := sort.Search(len(a.Largest), func(i int) bool { return a.Largest[i].Size < f.Size })
+	a.Largest = append(a.Largest, ExcludedFile{})
+	copy(a.Largest[i+1:], a.Largest[i:])
+	a.Largest[i] = f
+	if len(a.Largest) > exclusionAuditTop {
+		a.Largest = a.Largest[:exclusionAuditTop]
+	}
+}
+
+// ⭐ EXCLUDE-AUDIT-001: Exclusion report - 📝
+// Print writes the audit: each pattern with its origin and what it
+// excluded, patterns excluding nothing included so typos stand out, then
+// the largest excluded files.
+func (a *ExclusionAudit) Print(w io.Writer) {
+	fmt.Fprintf(w, "\nExclusions in %s:\n", a.Dir)
+	if len(a.Patterns) == 0 {
+		fmt.Fprintln(w, "  none")
+		return
+	}
+	patternWidth, originWidth := 0, 0
+	for _, p := range a.Patterns {
+		patternWidth, originWidth = max(patternWidth, len(p.Pattern)), max(originWidth, len(p.Origin))
+	}
+	for _, p := range a.Patterns {
+		fmt.Fprintf(w, "  %-*s  %-*s  %d %s, %s\n", patternWidth, p.Pattern, originWidth, p.Origin,
+			p.Files, pluralFiles(p.Files), formatHumanSize(p.Bytes))
+	}
+	fmt.Fprintf(w, "Excluded %d %s (%s)\n", a.Files, pluralFiles(a.Files), formatHumanSize(a.Bytes))
+	if len(a.Largest) == 0 {
+		return
+	}
+	fmt.Fprintln(w, "\nLargest excluded files:")
+	for _, f := range a.Largest {
+		fmt.Fprintf(w, "  %s (%s, %s)\n", f.Path, formatHumanSize(f.Size), f.Pattern)
+	}
+}
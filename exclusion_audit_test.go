@@ -0,0 +1,103 @@
+// This file is part of bkpdir
+
+// Package main provides tests for exclusion auditing.
+// It verifies per-pattern counts, origins and the largest excluded files.
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ EXCLUDE-AUDIT-001: Exclusion audit tests - 🧪
+func TestAuditExclusions(t *testing.T) {
+	dir := t.TempDir()
+	for name, size := range map[string]int{
+		"main.go":              10,
+		"debug.log":            100,
+		"logs/app.log":         300,
+		"node_modules/a/x.js":  50,
+		"node_modules/big.bin": 1000,
+		"build/out.log":        20,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, ".bkpignore"), []byte("# dependencies\nnode_modules/\nbuild/\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ExcludePatterns = []string{"*.log", "*.tmp", ".bkpignore"}
+	audit, err := AuditExclusions(context.Background(), cfg, dir, []string{".bkpignore"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []ExclusionStats{
+		{Pattern: "*.log", Origin: "exclude_patterns", Files: 3, Bytes: 420},
+		{Pattern: "*.tmp", Origin: "exclude_patterns"},
+		{Pattern: ".bkpignore", Origin: "exclude_patterns", Files: 1, Bytes: 36},
+		{Pattern: "node_modules/", Origin: "--exclude-from .bkpignore", Files: 2, Bytes: 1050},
+		{Pattern: "build/", Origin: "--exclude-from .bkpignore"},
+	}
+	if len(audit.Patterns) != len(want) {
+		t.Fatalf("patterns = %+v", audit.Patterns)
+	}
+	for i, w := range want {
+		if audit.Patterns[i] != w {
+			t.Errorf("pattern %d = %+v, want %+v", i, audit.Patterns[i], w)
+		}
+	}
+	if audit.Files != 6 || audit.Bytes != 1506 {
+		t.Errorf("excluded %d files, %d bytes; want 6, 1506", audit.Files, audit.Bytes)
+	}
+	if len(audit.Largest) != 6 || audit.Largest[0].Path != "node_modules/big.bin" ||
+		audit.Largest[0].Pattern != "node_modules/" || audit.Largest[5].Path != "build/out.log" {
+		t.Errorf("largest = %+v", audit.Largest)
+	}
+
+	var out bytes.Buffer
+	audit.Print(&out)
+	for _, line := range []string{
+		"  *.tmp          exclude_patterns           0 files, 0B\n",
+		"Excluded 6 files (",
+		"  node_modules/big.bin (1000B, node_modules/)\n",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("report lacks %q:\n%s", line, out.String())
+		}
+	}
+
+	if _, err := AuditExclusions(context.Background(), cfg, dir, []string{"missing"}); err == nil {
+		t.Error("Expected a missing exclusion file to fail")
+	}
+}
+
+// ⭐ EXCLUDE-AUDIT-001: Largest excluded files tests - 🧪
+func TestExclusionAuditLargest(t *testing.T) {
+	var audit ExclusionAudit
+	for i := 0; i < 3*exclusionAuditTop; i++ {
+		audit.addLargest(ExcludedFile{Path: "f", Size: int64(i % (2 * exclusionAuditTop))})
+	}
+	if len(audit.Largest) != exclusionAuditTop {
+		t.Fatalf("kept %d files, want %d", len(audit.Largest), exclusionAuditTop)
+	}
+	for i := 1; i < len(audit.Largest); i++ {
+		if audit.Largest[i].Size > audit.Largest[i-1].Size {
+			t.Fatalf("not sorted: %+v", audit.Largest)
+		}
+	}
+	if audit.Largest[0].Size != 2*exclusionAuditTop-1 {
+		t.Errorf("largest = %d", audit.Largest[0].Size)
+	}
+}
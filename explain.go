@@ -0,0 +1,336 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir explain create`, which prints the plan of an
+// archive run without executing it: the settings that shape it and where
+// they come from, the archive name and destination, the exclusion rules in
+// effect and the hooks that would run.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+)
+
+// ExplainOptions configures `bkpdir explain create`.
+type ExplainOptions struct {
+	Config      *Config
+	Output      io.Writer
+	Context     context.Context
+	Dir         string   // Directory that would be archived
+	Note        string   // Note added to the archive name
+	Incremental bool     // Explain an incremental archive instead of a full one
+	Base        string   // Full archive an incremental archive is based on; empty for the latest
+	ExcludeFrom []string // Exclusion files given with --exclude-from
+}
+
+// explainCreateSettings are the configuration fields, by Go field path,
+// that affect archive creation.
+var explainCreateSettings = []string{
+	"ArchiveDirPath",
+	"UseCurrentDirName",
+	"Shared.UserNamespace",
+	"Shared.Umask",
+	"ExcludePatterns",
+	"ExcludeFrom",
+	"IncludeGitInfo",
+	"ShowGitDirtyStatus",
+	"SkipBrokenSymlinks",
+	"TimestampTimezone",
+	"TimestampFormat",
+	"MaxFileSize",
+	"MaxTotalSize",
+	"MaxFileCount",
+	"LimitAction",
+	"CompressionLevel",
+	"ArchiveJobs",
+	"MaxMemory",
+	"KeepGoing",
+	"Quota",
+	"QuotaPolicy",
+	"PowerAware",
+	"PowerMaxLoadPercent",
+	"VolatilePatterns",
+	"MaxFullArchiveAge",
+	"FullArchiveAgeAction",
+	"ManifestFileHashes",
+	"ManifestExclusions",
+	"IntegritySeal",
+	"Verification.VerifyOnCreate",
+	"Verification.ChecksumAlgorithm",
+	"Repository.Path",
+	"HealthcheckURL",
+	"OTLPEndpoint",
+	"EventLog",
+	"AuditLog",
+}
+
+// explainExclusion is an exclusion pattern and where it was configured.
+type explainExclusion struct {
+	Pattern string
+	Origin  string
+}
+
+// ⭐ EXPLAIN-001: Archive creation plan - 🔍
+// ExplainCreate prints what `bkpdir full` (or `bkpdir inc` with
+// Incremental) would do in opts.Dir. Nothing is written: the archive
+// directory is not created, no name is reserved and no hook is run.
+func ExplainCreate(opts ExplainOptions) error {
+	cfg := opts.Config
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	w := opts.Output
+
+	exclusions, err := explainExclusions(cfg, opts.Dir, opts.ExcludeFrom)
+	if err != nil {
+		return err
+	}
+	patterns := make([]string, len(exclusions))
+	for i, e := range exclusions {
+		patterns[i] = e.Pattern
+	}
+	// The archive is built with the exclusion files merged into the patterns
+	planCfg := *cfg
+	planCfg.ExcludePatterns = patterns
+	archiveConfig := &ConfigToArchiveConfigAdapter{cfg: &planCfg}
+
+	kind := "a full archive"
+	switch {
+	case repositoryEnabled(cfg) && opts.Incremental:
+		return NewArchiveError("Incremental archives are not supported with a repository; "+
+			"full snapshots only store changed chunks", cfg.StatusConfigError)
+	case repositoryEnabled(cfg):
+		kind = "a repository snapshot"
+	case opts.Incremental:
+		kind = "an incremental archive"
+	}
+
+	// Resolve the archive before printing so a run that would fail prints
+	// only the error
+	var files []string
+	var scan *CachedScan
+	var archive []string // Name, base and destination lines
+	if repositoryEnabled(cfg) {
+		// ⭐ SIZE-CACHE-001: Full scans go through the size estimate cache
+		scan, err = scanArchiveFilesCached(ctx, opts.Dir, patterns)
+		if err != nil {
+			return NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+		}
+		files = scan.Files
+		nameCfg := fullArchiveNameConfig(archiveConfig, opts.Dir, opts.Note)
+		archive = append(archive,
+			"Name:        "+strings.TrimSuffix(GenerateArchiveName(nameCfg), ".zip"),
+			"Destination: repository "+cfg.Repository.Path+explainMissing(cfg.Repository.Path))
+	} else {
+		archiveDir, err := prepareArchiveDirectoryWithInterface(archiveConfig, opts.Dir, true)
+		if err != nil {
+			return err
+		}
+		var nameCfg ArchiveConfig
+		if opts.Incremental {
+			base, err := findBaseFullArchive(archiveDir, opts.Base, cfg.StatusConfigError)
+			if err != nil {
+				return err
+			}
+			files, err = collectModifiedFiles(ctx, opts.Dir, base, patterns)
+			if err != nil {
+				return NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+			}
+			nameCfg = incrementalArchiveNameConfig(opts.Dir, base, archiveConfig, opts.Note)
+			archive = append(archive, "Base:        "+base.Name)
+		} else {
+			scan, err = scanArchiveFilesCached(ctx, opts.Dir, patterns)
+			if err != nil {
+				return NewArchiveErrorWithCause("Failed to scan directory", 1, err)
+			}
+			files = scan.Files
+			nameCfg = fullArchiveNameConfig(archiveConfig, opts.Dir, opts.Note)
+		}
+		archivePath, err := claimArchivePath(archiveDir, nameCfg, false)
+		if err != nil {
+			return NewArchiveErrorWithCause("Failed to choose an archive name", 1, err)
+		}
+		archive = append([]string{"Name:        " + filepath.Base(archivePath)}, archive...)
+		archive = append(archive, "Destination: "+archiveDir+explainMissing(archiveDir))
+	}
+	archive = append(archive, fmt.Sprintf("Files:       %d", len(files)))
+	if scan != nil {
+		archive = append(archive, "Size:        "+formatHumanSize(scan.Estimate(files).Bytes)+" before compression")
+	}
+
+	fmt.Fprintf(w, "Plan for %s of %s\n", kind, opts.Dir)
+	fmt.Fprintln(w, "\nSettings:")
+	explainSettings(w, cfg, opts.Dir)
+	fmt.Fprintln(w, "\nArchive:")
+	for _, line := range archive {
+		fmt.Fprintf(w, "  %s\n", line)
+	}
+
+	fmt.Fprintln(w, "\nExclusions:")
+	if len(exclusions) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	width := 0
+	for _, e := range exclusions {
+		width = max(width, len(e.Pattern))
+	}
+	for _, e := range exclusions {
+		fmt.Fprintf(w, "  %-*s  %s\n", width, e.Pattern, e.Origin)
+	}
+
+	fmt.Fprintln(w, "\nHooks:")
+	hooks := explainHooks(cfg, files, opts.Incremental)
+	if len(hooks) == 0 {
+		fmt.Fprintln(w, "  none")
+	}
+	for _, h := range hooks {
+		fmt.Fprintf(w, "  %s\n", h)
+	}
+
+	command := "bkpdir full"
+	if opts.Incremental {
+		command = "bkpdir inc"
+	}
+	fmt.Fprintf(w, "\nNothing was written; run '%s' to create the archive.\n", command)
+	return nil
+}
+
+// explainExclusions returns the exclusion patterns of an archive run in the
+// order they are applied, with the setting or file each comes from.
+func explainExclusions(cfg *Config, dir string, extra []string) ([]explainExclusion, error) {
+	var exclusions []explainExclusion
+	for _, p := range cfg.ExcludePatterns {
+		exclusions = append(exclusions, explainExclusion{Pattern: p, Origin: "exclude_patterns"})
+	}
+	files := make([]explainExclusion, 0, len(cfg.ExcludeFrom)+len(extra))
+	for _, f := range cfg.ExcludeFrom {
+		files = append(files, explainExclusion{Pattern: f, Origin: "exclude_from"})
+	}
+	for _, f := range extra {
+		files = append(files, explainExclusion{Pattern: f, Origin: "--exclude-from"})
+	}
+	for _, f := range files {
+		path := f.Pattern
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		patterns, err := ReadExcludeFile(path)
+		if err != nil {
+			return nil, NewArchiveErrorWithCause("Failed to load exclude patterns", cfg.StatusConfigError, err)
+		}
+		for _, p := range patterns {
+			exclusions = append(exclusions, explainExclusion{Pattern: p, Origin: f.Origin + " " + f.Pattern})
+		}
+	}
+	// ⭐ NESTED-DIR-001: The archive and backup directories inside dir
+	nested, err := nestedDirExclusions(cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	return append(exclusions, nested...), nil
+}
+
+// explainSettings prints the settings affecting archive creation with their
+// values and sources.
+func explainSettings(w io.Writer, cfg *Config, dir string) {
+	byPath := make(map[string]ConfigValueWithMetadata)
+	for _, v := range GetAllConfigValuesWithSources(cfg, dir) {
+		byPath[v.FieldInfo.Path] = v
+	}
+	type row struct{ key, value, source string }
+	var rows []row
+	keyWidth, valueWidth := 0, 0
+	for _, path := range explainCreateSettings {
+		v, ok := byPath[path]
+		if !ok {
+			continue
+		}
+		r := row{key: configKeyForPath(path), value: v.Value, source: v.Source}
+		keyWidth, valueWidth = max(keyWidth, len(r.key)), max(valueWidth, len(r.value))
+		rows = append(rows, r)
+	}
+	for _, r := range rows {
+		fmt.Fprintf(w, "  %-*s  %-*s  (%s)\n", keyWidth, r.key, valueWidth, r.value, r.source)
+	}
+}
+
+// configKeyForPath returns the dotted YAML key of a Config field path such
+// as Verification.VerifyOnCreate.
+func configKeyForPath(path string) string {
+	t := reflect.TypeOf(Config{})
+	var keys []string
+	for _, name := range strings.Split(path, ".") {
+		for t.Kind() == reflect.Ptr {
+			t = t.Elem()
+		}
+		f, ok := t.FieldByName(name)
+		if !ok {
+			return path
+		}
+		keys = append(keys, strings.Split(f.Tag.Get("yaml"), ",")[0])
+		t = f.Type
+	}
+	return strings.Join(keys, ".")
+}
+
+// explainHooks describes the commands and notifications an archive run
+// would trigger, in the order they happen.
+func explainHooks(cfg *Config, files []string, incremental bool) []string {
+	var hooks []string
+	if cfg.HealthcheckURL != "" {
+		hooks = append(hooks, fmt.Sprintf("healthcheck: ping healthcheck_url (%s) when the run starts, succeeds or fails",
+			cfg.HealthcheckURL))
+	}
+	if cfg.OTLPEndpoint != "" {
+		hooks = append(hooks, fmt.Sprintf("tracing: send spans to %s", cfg.OTLPEndpoint))
+	}
+	// ⭐ HOOK-001: Database dumps only run before full archives
+	if databaseHooksConfigured(cfg) && !incremental {
+		dbHooks, err := configuredDatabaseHooks(cfg, files)
+		if err != nil {
+			hooks = append(hooks, fmt.Sprintf("database hooks: invalid, the run would fail: %v", err))
+		}
+		for _, h := range dbHooks {
+			command := h.hook.Command
+			if command == "" {
+				command = h.plugin.command
+			}
+			hooks = append(hooks, fmt.Sprintf("%s hook: dump with %s into %s", h.plugin.name, command, h.output))
+		}
+	}
+	for _, p := range cfg.Plugins {
+		desc := fmt.Sprintf("plugin %s: %s", p.Name, strings.Join(append([]string{p.Command}, p.Args...), " "))
+		if len(p.Patterns) > 0 {
+			desc += fmt.Sprintf(" (files matching %s)", strings.Join(p.Patterns, ", "))
+		}
+		hooks = append(hooks, desc)
+	}
+	if cfg.Verification != nil && cfg.Verification.VerifyOnCreate {
+		hooks = append(hooks, "verification: check the archive after it is written")
+	}
+	if cfg.IntegritySeal {
+		hooks = append(hooks, "integrity seal: write an HMAC seal next to the archive")
+	}
+	if cfg.EventLog != "" && cfg.EventLog != "none" {
+		hooks = append(hooks, fmt.Sprintf("event log: report the outcome to %s", cfg.EventLog))
+	}
+	return hooks
+}
+
+// explainMissing notes a destination directory that does not exist yet.
+func explainMissing(dir string) string {
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return " (created with the first archive)"
+	}
+	return ""
+}
@@ -0,0 +1,83 @@
+// This file is part of bkpdir
+
+// Package main provides tests for explaining archive runs.
+// It verifies that `explain create` reports the archive name, the exclusion
+// rules with their origins and the hooks, and that it writes nothing.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ EXPLAIN-001: Archive creation plan - 🧪
+func TestExplainCreate(t *testing.T) {
+	archiveDir := filepath.Join(t.TempDir(), "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.ExcludePatterns = []string{"*.log"}
+	cfg.ExcludeFrom = []string{".bkpdirignore"}
+	cfg.HealthcheckURL = "https://hc.example.com/secret-uuid"
+
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"main.go":       "package main",
+		"debug.log":     "noise",
+		"tmp/cache":     "cache",
+		".bkpdirignore": "tmp/\n",
+		"extra.exclude": "*.bak\n",
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	err := ExplainCreate(ExplainOptions{
+		Config:      cfg,
+		Output:      &out,
+		Dir:         dir,
+		Note:        "release",
+		ExcludeFrom: []string{"extra.exclude"},
+	})
+	if err != nil {
+		t.Fatalf("ExplainCreate: %v", err)
+	}
+	got := out.String()
+	for _, want := range []string{
+		"Plan for a full archive of " + dir,
+		"=release.zip",
+		"Destination: " + archiveDir + " (created with the first archive)",
+		"Files:       3",
+		"*.log",
+		"exclude_from .bkpdirignore",
+		"--exclude-from extra.exclude",
+		"archive_dir_path",
+		"healthcheck: ping healthcheck_url (********)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("explain output missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(got, "secret-uuid") {
+		t.Errorf("explain output reveals the healthcheck URL:\n%s", got)
+	}
+	if _, err := os.Stat(archiveDir); !os.IsNotExist(err) {
+		t.Errorf("explain created the archive directory: %v", err)
+	}
+
+	out.Reset()
+	err = ExplainCreate(ExplainOptions{Config: cfg, Output: &out, Dir: dir, Incremental: true})
+	if err == nil {
+		t.Fatal("Expected an error explaining an incremental archive without a full archive")
+	}
+	if out.Len() != 0 {
+		t.Errorf("explain printed a partial plan before failing:\n%s", out.String())
+	}
+}
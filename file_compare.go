@@ -0,0 +1,130 @@
+// This file is part of bkpdir
+//
+// Package main decides whether a file is identical to its most recent backup
+// without reading both files when it can: file backups record their SHA-256
+// in the .metadata directory next to them, and file_compare selects whether
+// the modification time, that checksum or the bytes of the backup decide.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// ⭐ FILE-COMPARE-001: File comparison modes - 🔧
+const (
+	// FileCompareAuto trusts an unchanged modification time, then the
+	// recorded checksum, and reads the backup only when the checksums differ.
+	FileCompareAuto = "auto"
+	// FileCompareHash compares the file with the recorded checksum only.
+	FileCompareHash = "hash"
+	// FileCompareBytes compares the file with the backup byte by byte.
+	FileCompareBytes = "bytes"
+)
+
+// fileCompareRacyWindow is how much older than its backup a file must be
+// for its modification time to show it has not changed since, as a change
+// in the same clock tick as the backup would go unnoticed.
+const fileCompareRacyWindow = 2 * time.Second
+
+// fileCompareMode validates file_compare; empty means auto.
+func fileCompareMode(cfg *Config) (string, error) {
+	switch cfg.FileCompare {
+	case "":
+		return FileCompareAuto, nil
+	case FileCompareAuto, FileCompareHash, FileCompareBytes:
+		return cfg.FileCompare, nil
+	default:
+		return "", NewArchiveError(fmt.Sprintf("Invalid file_compare %q (use auto, hash or bytes)", cfg.FileCompare),
+			cfg.StatusConfigError)
+	}
+}
+
+// ⭐ FILE-COMPARE-001: File comparison with a backup - 🔍
+// sameFileBackup reports whether filePath holds the content of backupPath,
+// an unencrypted backup of the same size, comparing them with mode.
+func sameFileBackup(filePath, backupPath, mode string) (bool, error) {
+	if mode == FileCompareBytes {
+		return compareFiles(filePath, backupPath)
+	}
+	if mode == FileCompareAuto {
+		fileInfo, err := os.Stat(filePath)
+		if err != nil {
+			return false, err
+		}
+		backupInfo, err := os.Stat(backupPath)
+		if err != nil {
+			return false, err
+		}
+		if fileInfo.ModTime().Before(backupInfo.ModTime().Add(-fileCompareRacyWindow)) {
+			return true, nil
+		}
+	}
+
+	recorded, ok := readBackupChecksum(backupPath)
+	if !ok {
+		if mode == FileCompareAuto {
+			return compareFiles(filePath, backupPath)
+		}
+		_, sum, err := hashArchive(backupPath)
+		if err != nil {
+			return false, err
+		}
+		recorded = sum
+	}
+	_, sum, err := hashArchive(filePath)
+	if err != nil {
+		return false, err
+	}
+	if sum == recorded {
+		return true, nil
+	}
+	if mode == FileCompareAuto {
+		// A checksum recorded before the backup was altered must not hide a match
+		return compareFiles(filePath, backupPath)
+	}
+	return false, nil
+}
+
+// readBackupChecksum returns the SHA-256 recorded for a backup by
+// writeBackupChecksum, if any.
+func readBackupChecksum(backupPath string) (string, bool) {
+	f, err := os.Open(backupChecksumPath(backupPath))
+	if err != nil {
+		return "", false
+	}
+	defer f.Close()
+	entries, err := ParseChecksumFile(f)
+	if err != nil || len(entries) != 1 || filepath.Base(entries[0].Name) != filepath.Base(backupPath) {
+		return "", false
+	}
+	return entries[0].Sum, true
+}
+
+// backupChecksumPath returns the checksum sidecar of a backup.
+func backupChecksumPath(backupPath string) string {
+	return filepath.Join(filepath.Dir(backupPath), ".metadata", filepath.Base(backupPath)+stdinChecksumSuffix)
+}
+
+// ⭐ FILE-COMPARE-001: Backup checksum recording - 🔧
+// recordFileBackupChecksum records the SHA-256 of a new unencrypted backup
+// for later comparisons, unless file_compare is bytes. A failure only
+// produces a warning: comparisons then read the backup.
+func recordFileBackupChecksum(cfg *Config, backupPath string) {
+	if mode, err := fileCompareMode(cfg); err != nil || mode == FileCompareBytes {
+		return
+	}
+	_, sum, err := hashArchive(backupPath)
+	if err == nil {
+		err = writeBackupChecksum(backupPath, sum)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record the checksum of %s: %v\n", filepath.Base(backupPath), err)
+	}
+}
@@ -0,0 +1,78 @@
+// This file is part of bkpdir
+
+// Package main provides tests for comparing files with their backups.
+// It verifies which of modification time, recorded checksum and bytes
+// decide in each file_compare mode.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ FILE-COMPARE-001: File comparison modes - 🧪
+func TestSameFileBackup(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "notes.txt")
+	backup := filepath.Join(dir, "backups", "notes.txt-2026-10-16-12-00")
+	write := func(path, content string, modTime time.Time) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	check := func(mode string, want bool) {
+		t.Helper()
+		if got, err := sameFileBackup(file, backup, mode); err != nil || got != want {
+			t.Errorf("%s: identical = %v (%v), want %v", mode, got, err, want)
+		}
+	}
+	now := time.Now()
+
+	// A file older than its backup is trusted in auto mode only
+	write(backup, "v1", now)
+	write(file, "v2", now.Add(-time.Hour))
+	check(FileCompareAuto, true)
+	check(FileCompareBytes, false)
+	check(FileCompareHash, false)
+
+	// Without a recorded checksum auto reads the backup
+	write(file, "v2", now)
+	check(FileCompareAuto, false)
+
+	// A recorded checksum decides, and a mismatch is confirmed from the bytes
+	recordFileBackupChecksum(DefaultConfig(), backup)
+	if _, ok := readBackupChecksum(backup); !ok {
+		t.Fatal("Expected the checksum to be recorded")
+	}
+	write(backup, "v9", now)
+	write(file, "v1", now)
+	check(FileCompareAuto, true)
+	check(FileCompareHash, true)
+	check(FileCompareBytes, false)
+	write(file, "v9", now)
+	check(FileCompareAuto, true)
+	check(FileCompareHash, false)
+
+	cfg := DefaultConfig()
+	cfg.FileCompare = "mtime"
+	if _, err := fileCompareMode(cfg); err == nil || !strings.Contains(err.Error(), "use auto, hash or bytes") {
+		t.Errorf("invalid mode: %v", err)
+	}
+	cfg.FileCompare = FileCompareBytes
+	other := filepath.Join(dir, "backups", "other-2026-10-16-12-00")
+	write(other, "x", now)
+	recordFileBackupChecksum(cfg, other)
+	if _, ok := readBackupChecksum(other); ok {
+		t.Error("Expected no checksum with file_compare: bytes")
+	}
+}
@@ -0,0 +1,214 @@
+// This file is part of bkpdir
+//
+// Package main records permission, ownership and extended attribute changes
+// of files whose content is identical to their most recent backup. With
+// backup_metadata_changes, such a change becomes a metadata revision: a
+// backup name linking to the backup holding the content, with the new
+// metadata in the .metadata directory, so the content is not stored twice.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bkpdir/pkg/fileops"
+)
+
+// fileMetadataSuffix is appended to the backup name for its metadata sidecar.
+const fileMetadataSuffix = ".meta.json"
+
+// ⭐ META-BACKUP-001: File metadata record - 📝
+// FileMetadata is the metadata of a backed up file. UID and GID are -1
+// where ownership is unknown.
+type FileMetadata struct {
+	Mode   string            `json:"mode"` // Octal permission and special bits
+	UID    int               `json:"uid"`
+	GID    int               `json:"gid"`
+	Xattrs map[string][]byte `json:"xattrs,omitempty"`
+	// Content names the backup holding the content of a metadata revision
+	Content string `json:"content,omitempty"`
+}
+
+// readFileMetadata returns the metadata of the file at path.
+func readFileMetadata(path string) (*FileMetadata, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &FileMetadata{Mode: octalMode(info.Mode()), UID: -1, GID: -1}
+	if uid, ok := fileOwnerID(info); ok {
+		m.UID = uid
+	}
+	if gid, ok := fileGroupID(info); ok {
+		m.GID = gid
+	}
+	if m.Xattrs, err = readXattrs(path); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// octalMode formats the permission and special bits of mode like chmod.
+func octalMode(mode os.FileMode) string {
+	bits := uint32(mode.Perm())
+	if mode&os.ModeSetuid != 0 {
+		bits |= 0o4000
+	}
+	if mode&os.ModeSetgid != 0 {
+		bits |= 0o2000
+	}
+	if mode&os.ModeSticky != 0 {
+		bits |= 0o1000
+	}
+	return fmt.Sprintf("%04o", bits)
+}
+
+// fileMetadataPath returns the metadata sidecar of a backup.
+func fileMetadataPath(backupPath string) string {
+	return filepath.Join(filepath.Dir(backupPath), ".metadata", filepath.Base(backupPath)+fileMetadataSuffix)
+}
+
+// loadFileMetadata returns the metadata recorded for a backup, or nil when
+// none was recorded.
+func loadFileMetadata(backupPath string) (*FileMetadata, error) {
+	data, err := os.ReadFile(fileMetadataPath(backupPath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var m FileMetadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", fileMetadataPath(backupPath), err)
+	}
+	return &m, nil
+}
+
+// storeFileMetadata records m for a backup.
+func storeFileMetadata(backupPath string, m *FileMetadata) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := fileMetadataPath(backupPath)
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return fileops.AtomicWriteFile(path, data, 0o644)
+}
+
+// ⭐ META-BACKUP-001: Metadata change detection - 🔍
+// metadataChanges describes how the metadata of filePath differs from that
+// of its identical backup at backupPath. Backups recorded without metadata
+// only have the mode copied from their file to compare with.
+func metadataChanges(filePath, backupPath string) ([]string, *FileMetadata, error) {
+	current, err := readFileMetadata(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	recorded, err := loadFileMetadata(backupPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if recorded == nil {
+		if isEncryptedBackup(backupPath) {
+			return nil, current, nil
+		}
+		info, err := os.Stat(backupPath)
+		if err != nil {
+			return nil, nil, err
+		}
+		recorded = &FileMetadata{Mode: octalMode(info.Mode()), UID: current.UID, GID: current.GID, Xattrs: current.Xattrs}
+	}
+
+	var changes []string
+	if current.Mode != recorded.Mode {
+		changes = append(changes, fmt.Sprintf("mode %s -> %s", recorded.Mode, current.Mode))
+	}
+	if current.UID != recorded.UID || current.GID != recorded.GID {
+		changes = append(changes, fmt.Sprintf("owner %d:%d -> %d:%d", recorded.UID, recorded.GID, current.UID, current.GID))
+	}
+	var xattrs []string
+	for name, value := range current.Xattrs {
+		if old, ok := recorded.Xattrs[name]; !ok || !bytes.Equal(old, value) {
+			xattrs = append(xattrs, name)
+		}
+	}
+	for name := range recorded.Xattrs {
+		if _, ok := current.Xattrs[name]; !ok {
+			xattrs = append(xattrs, name)
+		}
+	}
+	if len(xattrs) > 0 {
+		sort.Strings(xattrs)
+		changes = append(changes, "xattrs "+strings.Join(xattrs, ", "))
+	}
+	return changes, current, nil
+}
+
+// recordBackupMetadata records the metadata of the file a new backup was
+// made from, with backup_metadata_changes. A failure only produces a
+// warning: the next change is then detected from the mode alone.
+func recordBackupMetadata(cfg *Config, filePath, backupPath string) {
+	if !cfg.BackupMetadataChanges {
+		return
+	}
+	m, err := readFileMetadata(filePath)
+	if err == nil {
+		err = storeFileMetadata(backupPath, m)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record the metadata of %s: %v\n", filepath.Base(backupPath), err)
+	}
+}
+
+// ⭐ META-BACKUP-001: Metadata revisions - 🔧
+// createMetadataRevision stores a metadata revision of a file whose content
+// is in contentBackup at backupPath, reserved by claimBackupPath: a
+// symbolic link to the backup holding the content, never to another
+// revision, and the new metadata in its sidecar.
+func createMetadataRevision(opts BackupOptions, backupPath, contentBackup string, current *FileMetadata, changes []string) (err error) {
+	// ⭐ LAST-001: Record the run for `bkpdir last`
+	run := startRunRecord(opts.Config, RunKindBackup, opts.FilePath, false)
+	defer func() { run.finish(backupPath, err) }()
+	defer os.Remove(backupPath + ".tmp")
+
+	content := filepath.Base(contentBackup)
+	if recorded, err := loadFileMetadata(contentBackup); err == nil && recorded != nil && recorded.Content != "" {
+		content = recorded.Content
+	}
+	revision := *current
+	revision.Content = content
+	if err := storeFileMetadata(backupPath, &revision); err != nil {
+		return NewArchiveErrorWithCause("Failed to create backup", opts.Config.StatusDiskFull, err)
+	}
+	if err := replaceWithLink(backupPath, func(tmp string) error { return os.Symlink(content, tmp) }); err != nil {
+		os.Remove(fileMetadataPath(backupPath))
+		return NewArchiveErrorWithCause("Failed to create backup", opts.Config.StatusDiskFull, err)
+	}
+	// ⭐ SUDO-OWNER-001: Backups created through sudo belong to the invoking user
+	applyInvokingUserOwnership(opts.Config, backupPath)
+
+	fmt.Printf("Created metadata revision: %s (%s; content in %s)\n", backupPath, strings.Join(changes, "; "), content)
+	return nil
+}
+
+// metadataRevisionNote returns the note `--list FILE` shows for a backup
+// that is a metadata revision, or "" for other backups.
+func metadataRevisionNote(backupPath string) string {
+	m, err := loadFileMetadata(backupPath)
+	if err != nil || m == nil || m.Content == "" {
+		return ""
+	}
+	return fmt.Sprintf(" [metadata change, mode %s, content in %s]", m.Mode, m.Content)
+}
@@ -0,0 +1,50 @@
+//go:build linux
+
+// This file is part of bkpdir
+//
+// Package main provides the extended attribute lookup of metadata revisions
+// of file backups on Linux.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"strings"
+	"syscall"
+)
+
+// ⭐ META-BACKUP-001: Extended attributes - 🔍
+// readXattrs returns the extended attributes of path by name. File systems
+// without extended attributes have none.
+func readXattrs(path string) (map[string][]byte, error) {
+	size, err := syscall.Listxattr(path, nil)
+	if errors.Is(err, syscall.ENOTSUP) || size == 0 && err == nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, size)
+	if size, err = syscall.Listxattr(path, buf); err != nil {
+		return nil, err
+	}
+	xattrs := make(map[string][]byte)
+	for _, name := range strings.Split(strings.TrimRight(string(buf[:size]), "\x00"), "\x00") {
+		if name == "" {
+			continue
+		}
+		n, err := syscall.Getxattr(path, name, nil)
+		if err != nil {
+			return nil, err
+		}
+		value := make([]byte, n)
+		if n, err = syscall.Getxattr(path, name, value); err != nil {
+			return nil, err
+		}
+		xattrs[name] = value[:n]
+	}
+	return xattrs, nil
+}
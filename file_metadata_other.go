@@ -0,0 +1,16 @@
+//go:build !linux
+
+// This file is part of bkpdir
+//
+// Package main provides the extended attribute lookup of metadata revisions
+// of file backups on systems where it is not supported.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+// readXattrs reports no extended attributes on this system.
+func readXattrs(string) (map[string][]byte, error) {
+	return nil, nil
+}
@@ -0,0 +1,76 @@
+// This file is part of bkpdir
+
+// Package main provides tests for metadata revisions of file backups.
+// It verifies that a permission change of identical content is recorded
+// without storing the content again.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ META-BACKUP-001: Metadata revisions - 🧪
+func TestMetadataRevision(t *testing.T) {
+	tmpDir := t.TempDir()
+	source := filepath.Join(tmpDir, "notes.txt")
+	if err := os.WriteFile(source, []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.BackupDirPath = filepath.Join(tmpDir, "backups")
+	cfg.UseCurrentDirNameForFiles = false
+	cfg.BackupMetadataChanges = true
+
+	if err := CreateFileBackup(cfg, source, "", false); err != nil {
+		t.Fatal(err)
+	}
+	backups, err := ListFileBackups(cfg.BackupDirPath, "notes.txt")
+	if err != nil || len(backups) != 1 {
+		t.Fatalf("backups = %+v (%v)", backups, err)
+	}
+	first := backups[0].Path
+	if changes, _, err := metadataChanges(source, first); err != nil || len(changes) != 0 {
+		t.Fatalf("unchanged file: %v (%v)", changes, err)
+	}
+
+	for i, mode := range []os.FileMode{0o600, 0o640} {
+		if err := os.Chmod(source, mode); err != nil {
+			t.Fatal(err)
+		}
+		if err := CreateFileBackup(cfg, source, "", false); err != nil {
+			t.Fatal(err)
+		}
+		if backups, err = ListFileBackups(cfg.BackupDirPath, "notes.txt"); err != nil || len(backups) != i+2 {
+			t.Fatalf("backups after chmod %o = %+v (%v)", mode, backups, err)
+		}
+	}
+
+	// Revisions link to the backup holding the content, never to each other
+	for _, revision := range backups[:2] {
+		link, err := os.Readlink(revision.Path)
+		if err != nil || link != filepath.Base(first) {
+			t.Errorf("%s links to %q (%v)", revision.Name, link, err)
+		}
+		if data, err := os.ReadFile(revision.Path); err != nil || string(data) != "content" {
+			t.Errorf("%s holds %q (%v)", revision.Name, data, err)
+		}
+	}
+	if note := metadataRevisionNote(backups[0].Path); !strings.Contains(note, "metadata change, mode 0640") {
+		t.Errorf("note = %q", note)
+	}
+	if note := metadataRevisionNote(first); note != "" {
+		t.Errorf("content backup note = %q", note)
+	}
+	if changes, _, err := metadataChanges(source, backups[0].Path); err != nil || len(changes) != 0 {
+		t.Errorf("after the revision: %v (%v)", changes, err)
+	}
+	if err := os.Chmod(source, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if changes, _, err := metadataChanges(source, backups[0].Path); err != nil || len(changes) != 1 || changes[0] != "mode 0640 -> 0644" {
+		t.Errorf("changes = %v (%v)", changes, err)
+	}
+}
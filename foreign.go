@@ -0,0 +1,226 @@
+// This file is part of bkpdir
+//
+// Package main provides the foreign file policy: files in the archive
+// directory that bkpdir did not create are classified as foreign, kept out of
+// listings, verification, tiering and quotas, and reported or quarantined by
+// `bkpdir gc --foreign`.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/formatter"
+)
+
+// ⭐ FOREIGN-001: Policies for foreign files - 🔧
+const (
+	// ForeignFilesIgnore leaves foreign files where they are.
+	ForeignFilesIgnore = "ignore"
+	// ForeignFilesQuarantine moves foreign files to the trash.
+	ForeignFilesQuarantine = "quarantine"
+)
+
+// ForeignFile is a file in the archive directory that bkpdir did not create.
+type ForeignFile struct {
+	Name    string    `json:"name"`
+	Path    string    `json:"path"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+}
+
+// GCOptions holds the options of `bkpdir gc`.
+type GCOptions struct {
+	Output  io.Writer
+	Foreign bool // Apply foreign_files to the foreign files
+	DryRun  bool
+}
+
+// foreignFilesPolicy validates foreign_files; empty means ignore.
+func foreignFilesPolicy(cfg *Config) (string, error) {
+	switch cfg.ForeignFiles {
+	case "":
+		return ForeignFilesIgnore, nil
+	case ForeignFilesIgnore, ForeignFilesQuarantine:
+		return cfg.ForeignFiles, nil
+	default:
+		return "", NewArchiveError(fmt.Sprintf("Invalid foreign_files %q (use ignore or quarantine)",
+			cfg.ForeignFiles), cfg.StatusConfigError)
+	}
+}
+
+// ⭐ FOREIGN-001: Foreign file classification - 🔍
+// isForeignFile reports whether the regular file name in archiveDir was not
+// created by bkpdir. Hidden files, the checksum file and the temporary files
+// of atomic writes are bkpdir's own. A ZIP file is an archive when
+// pattern_archive_filename parses its name, or the name of its base archive
+// for incremental archives, or it has a sidecar in .metadata, so archives
+// named under an earlier pattern still count. Without a usable pattern every
+// ZIP file is an archive.
+func isForeignFile(cfg *Config, archiveDir, name string) bool {
+	return isForeignName(cfg.PatternArchiveFilename, archiveDir, name)
+}
+
+// isForeignName is isForeignFile for the archive name pattern alone.
+func isForeignName(pattern, archiveDir, name string) bool {
+	switch {
+	case strings.HasPrefix(name, "."), name == defaultChecksumFile, strings.Contains(name, ".zip.tmp."):
+		return false
+	case !strings.HasSuffix(name, ".zip"):
+		return true
+	}
+	if _, err := regexp.Compile(pattern); err != nil || pattern == "" {
+		return false
+	}
+	parsed := name
+	if base, _, found := strings.Cut(name, "_update="); found {
+		parsed = base + ".zip"
+	}
+	if len(formatter.ExtractNamedGroups(pattern, parsed)) > 0 {
+		return false
+	}
+	sidecars, _ := filepath.Glob(filepath.Join(archiveDir, ".metadata", globEscape(name)+".*"))
+	return len(sidecars) == 0
+}
+
+// globEscape quotes the metacharacters of filepath.Match in name.
+func globEscape(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// FindForeignFiles returns the foreign files in archiveDir sorted by name.
+// Directories, such as .metadata and user namespaces, are never foreign. A
+// missing directory has none.
+func FindForeignFiles(cfg *Config, archiveDir string) ([]ForeignFile, error) {
+	entries, err := os.ReadDir(archiveDir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var foreign []ForeignFile
+	for _, entry := range entries {
+		if entry.IsDir() || !isForeignFile(cfg, archiveDir, entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue // Removed while listing
+		}
+		foreign = append(foreign, ForeignFile{
+			Name:    entry.Name(),
+			Path:    filepath.Join(archiveDir, entry.Name()),
+			Size:    info.Size(),
+			ModTime: info.ModTime(),
+		})
+	}
+	sort.Slice(foreign, func(i, j int) bool { return foreign[i].Name < foreign[j].Name })
+	return foreign, nil
+}
+
+// filterForeignArchives drops the foreign ZIP files from archives found in a
+// local archive directory. Archives in cold storage are kept: the catalog
+// only lists archives bkpdir moved there.
+func filterForeignArchives(cfg *Config, archives []Archive) []Archive {
+	kept := archives[:0]
+	for _, a := range archives {
+		if a.Location == "" && isForeignFile(cfg, filepath.Dir(a.Path), a.Name) {
+			continue
+		}
+		kept = append(kept, a)
+	}
+	return kept
+}
+
+// ⭐ FOREIGN-001: Foreign file collection - 🔧
+// CollectGarbage applies foreign_files to the foreign files in the archive
+// directory of the current directory: ignore only lists them, quarantine
+// moves them to the trash, from where `bkpdir trash restore` brings them
+// back. Dry runs list what would be moved.
+func CollectGarbage(cfg *Config, opts GCOptions) error {
+	if !opts.Foreign {
+		return NewArchiveError("Nothing to collect (use --foreign)", cfg.StatusConfigError)
+	}
+	policy, err := foreignFilesPolicy(cfg)
+	if err != nil {
+		return err
+	}
+	if isRemoteLocation(cfg.ArchiveDirPath) {
+		return NewArchiveError("gc is not supported for remote archive directories", cfg.StatusConfigError)
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	foreign, err := FindForeignFiles(cfg, archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archive directory", cfg.StatusDirectoryNotFound, err)
+	}
+
+	w := opts.Output
+	if len(foreign) == 0 {
+		fmt.Fprintf(w, "No foreign files in %s\n", archiveDir)
+		return nil
+	}
+	if policy == ForeignFilesIgnore || opts.DryRun {
+		for _, f := range foreign {
+			fmt.Fprintf(w, "%s (%s)\n", f.Name, formatHumanSize(f.Size))
+		}
+		if policy == ForeignFilesIgnore {
+			fmt.Fprintf(w, "%d foreign %s left alone; set foreign_files: quarantine to move them to the trash\n",
+				len(foreign), pluralFiles(len(foreign)))
+		} else {
+			fmt.Fprintf(w, "%d foreign %s would be moved to the trash\n", len(foreign), pluralFiles(len(foreign)))
+		}
+		return nil
+	}
+
+	for i, f := range foreign {
+		item, err := MoveToTrash(cfg, f.Path)
+		if err != nil {
+			return NewArchiveErrorWithCause(
+				fmt.Sprintf("Failed to quarantine %s after moving %d of %d files", f.Name, i, len(foreign)), 1, err)
+		}
+		fmt.Fprintf(w, "Quarantined %s as %s\n", f.Name, item.Name)
+	}
+	fmt.Fprintf(w, "Moved %d foreign %s to the trash; bring one back with 'bkpdir trash restore NAME'\n",
+		len(foreign), pluralFiles(len(foreign)))
+	return nil
+}
+
+// ⭐ FOREIGN-001: Foreign files in listings - 📝
+// printForeignFiles lists the foreign files after the archives with
+// `list --foreign` and otherwise notes on standard error how many were left
+// out.
+func printForeignFiles(foreign []ForeignFile, show bool) {
+	if len(foreign) == 0 {
+		return
+	}
+	if !show {
+		fmt.Fprintf(os.Stderr, "Note: %d foreign %s in the archive directory not listed; show them with --foreign\n",
+			len(foreign), pluralFiles(len(foreign)))
+		return
+	}
+	fmt.Println("\nForeign files (not created by bkpdir):")
+	for _, f := range foreign {
+		fmt.Printf("  %s (%s, %s)\n", f.Name, formatHumanSize(f.Size), f.ModTime.Format("2006-01-02 15:04:05"))
+	}
+}
@@ -0,0 +1,128 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the foreign file policy.
+// It verifies classification, exclusion from archive operations and gc.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ FOREIGN-001: Foreign file classification tests - 🧪
+func TestFindForeignFiles(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := DefaultConfig()
+	for _, name := range []string{
+		"proj-2024-01-01-10-00.zip",
+		"proj-2024-01-01-10-00_update=2024-01-02-10-00.zip",
+		"proj-2024-01-03-10-00=main=abc1234=fix [x].zip",
+		"renamed.zip",
+		"proj-2024-01-04-10-00.zip.tmp.123",
+		defaultChecksumFile,
+		".bkpdir-checksums-1.json",
+		"photos.zip",
+		"notes.txt",
+		".metadata/renamed.zip.json",
+		"alice/proj-2024-01-05-10-00.zip",
+	} {
+		path := filepath.Join(archiveDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	foreign, err := FindForeignFiles(cfg, archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, f := range foreign {
+		names = append(names, f.Name)
+	}
+	if got := strings.Join(names, " "); got != "notes.txt photos.zip" {
+		t.Errorf("foreign files = %q, want notes.txt photos.zip", got)
+	}
+
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if kept := filterForeignArchives(cfg, archives); len(kept) != 4 {
+		t.Errorf("kept %d archives, want 4: %+v", len(kept), kept)
+	}
+
+	cfg.PatternArchiveFilename = "("
+	if foreign, _ := FindForeignFiles(cfg, archiveDir); len(foreign) != 1 || foreign[0].Name != "notes.txt" {
+		t.Errorf("with an invalid pattern every ZIP file is an archive, got %+v", foreign)
+	}
+}
+
+// ⭐ FOREIGN-001: Garbage collection tests - 🧪
+func TestCollectGarbage(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(journalEnvVar, filepath.Join(dir, "journal.json"))
+	archiveDir := filepath.Join(dir, "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.TrashDirPath = filepath.Join(dir, "trash")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	archive := filepath.Join(archiveDir, "proj-2024-01-01-10-00.zip")
+	photos := filepath.Join(archiveDir, "photos.zip")
+	for _, path := range []string{archive, photos} {
+		if err := os.WriteFile(path, []byte("zip"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(opts GCOptions) (string, error) {
+		var out bytes.Buffer
+		opts.Output = &out
+		err := CollectGarbage(cfg, opts)
+		return out.String(), err
+	}
+
+	if _, err := run(GCOptions{}); err == nil {
+		t.Error("gc without --foreign should fail")
+	}
+
+	out, err := run(GCOptions{Foreign: true})
+	if err != nil || !strings.Contains(out, "photos.zip (3B)\n1 foreign file left alone") {
+		t.Errorf("ignore: %q (%v)", out, err)
+	}
+
+	cfg.ForeignFiles = ForeignFilesQuarantine
+	out, err = run(GCOptions{Foreign: true, DryRun: true})
+	if err != nil || !strings.Contains(out, "1 foreign file would be moved to the trash") {
+		t.Errorf("dry run: %q (%v)", out, err)
+	}
+	if _, err := os.Stat(photos); err != nil {
+		t.Errorf("dry run moved %s: %v", photos, err)
+	}
+
+	out, err = run(GCOptions{Foreign: true})
+	if err != nil || !strings.Contains(out, "Quarantined photos.zip as ") {
+		t.Errorf("quarantine: %q (%v)", out, err)
+	}
+	if _, err := os.Stat(photos); !os.IsNotExist(err) {
+		t.Errorf("%s was not quarantined", photos)
+	}
+	if _, err := os.Stat(archive); err != nil {
+		t.Errorf("archive was moved: %v", err)
+	}
+	if items, _ := ListTrash(cfg); len(items) != 1 || items[0].OriginalPath != photos {
+		t.Errorf("trash holds %+v, want photos.zip", items)
+	}
+
+	cfg.ForeignFiles = "delete"
+	if _, err := run(GCOptions{Foreign: true}); err == nil || !strings.Contains(err.Error(), "use ignore or quarantine") {
+		t.Errorf("invalid policy: %v", err)
+	}
+}
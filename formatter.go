@@ -17,6 +17,8 @@ import (
 	"regexp"
 	"strings"
 	"text/template"
+
+	"bkpdir/pkg/formatter"
 )
 
 // 🔶 REFACTOR-002: Component boundary - Internal interfaces for extraction preparation - 📝
@@ -119,9 +121,9 @@ func (oc *OutputCollector) FlushAll() {
 	// 🔶 OUT-001: Delayed output implementation - 📝
 	for _, msg := range oc.messages {
 		if msg.Destination == "stderr" {
-			fmt.Fprint(os.Stderr, msg.Content)
+			fmt.Fprint(os.Stderr, formatter.StyleStderr(msg.Content))
 		} else {
-			fmt.Print(msg.Content)
+			fmt.Print(formatter.StyleStdout(msg.Content))
 		}
 	}
 	oc.messages = make([]OutputMessage, 0)
@@ -133,7 +135,7 @@ func (oc *OutputCollector) FlushStdout() {
 	remaining := make([]OutputMessage, 0)
 	for _, msg := range oc.messages {
 		if msg.Destination == "stdout" {
-			fmt.Print(msg.Content)
+			fmt.Print(formatter.StyleStdout(msg.Content))
 		} else {
 			remaining = append(remaining, msg)
 		}
@@ -147,7 +149,7 @@ func (oc *OutputCollector) FlushStderr() {
 	remaining := make([]OutputMessage, 0)
 	for _, msg := range oc.messages {
 		if msg.Destination == "stderr" {
-			fmt.Fprint(os.Stderr, msg.Content)
+			fmt.Fprint(os.Stderr, formatter.StyleStderr(msg.Content))
 		} else {
 			remaining = append(remaining, msg)
 		}
@@ -262,7 +264,7 @@ func (f *OutputFormatter) PrintCreatedArchive(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -280,7 +282,7 @@ func (f *OutputFormatter) PrintIdenticalArchive(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -298,7 +300,7 @@ func (f *OutputFormatter) PrintListArchive(path, creationTime string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -316,7 +318,7 @@ func (f *OutputFormatter) PrintConfigValue(name, value, source string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "config")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -334,7 +336,7 @@ func (f *OutputFormatter) PrintDryRunArchive(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -352,7 +354,7 @@ func (f *OutputFormatter) PrintError(message string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(errorMessage, "error")
 	} else {
-		fmt.Fprint(os.Stderr, errorMessage)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(errorMessage))
 	}
 }
 
@@ -370,7 +372,7 @@ func (f *OutputFormatter) PrintCreatedBackup(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -388,7 +390,7 @@ func (f *OutputFormatter) PrintIdenticalBackup(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -406,7 +408,7 @@ func (f *OutputFormatter) PrintListBackup(path, creationTime string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -424,7 +426,7 @@ func (f *OutputFormatter) PrintDryRunBackup(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -636,24 +638,8 @@ func (f *OutputFormatter) formatTemplate(templateStr string, data map[string]str
 // Regex-based data extraction - shared functionality
 // ExtractArchiveFilenameData extracts data from archive filename patterns.
 func (f *OutputFormatter) extractPatternData(pattern, text string) map[string]string {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return make(map[string]string)
-	}
-
-	matches := re.FindStringSubmatch(text)
-	if matches == nil {
-		return make(map[string]string)
-	}
-
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		if i > 0 && i < len(matches) && name != "" {
-			result[name] = matches[i]
-		}
-	}
-
-	return result
+	// ⭐ NAME-PATTERN-001: Names from the archive directory are untrusted
+	return formatter.ExtractNamedGroups(pattern, text)
 }
 
 // 🔶 REFACTOR-002: Component boundary - Template Formatter Component (Lines 637-928) - 📝
@@ -701,28 +687,9 @@ func (tf *TemplateFormatter) FormatWithTemplate(input, pattern, tmplStr string)
 // FormatWithPlaceholders formats a string using placeholder-based template formatting.
 // It replaces placeholders in the format string with values from the data map.
 func (tf *TemplateFormatter) FormatWithPlaceholders(format string, data map[string]string) string {
-	result := format
-
-	// Handle %{name} style placeholders
-	for key, value := range data {
-		placeholder := fmt.Sprintf("%%{%s}", key)
-		result = strings.ReplaceAll(result, placeholder, value)
-	}
-
-	// Handle Go text/template style {{.name}} placeholders
-	tmpl, err := template.New("format").Parse(result)
-	if err != nil {
-		// Fall back to simple replacement if template parsing fails
-		return result
-	}
-
-	var buf strings.Builder
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// Fall back to simple replacement if template execution fails
-		return result
-	}
-
-	return buf.String()
+	// ⭐ NAME-PATTERN-001: Values, which may come from file names, are data
+	// and never template source
+	return formatter.FormatPlaceholders(format, data)
 }
 
 // 🔶 REFACTOR-002: Component boundary - Template Method Series (Lines 718-817) - 📝
@@ -834,7 +801,7 @@ func (tf *TemplateFormatter) PrintTemplateCreatedArchive(path string) {
 	filename := getFilenameFromPath(path)
 	data := tf.extractArchiveData(filename)
 	data["path"] = path
-	fmt.Print(tf.TemplateCreatedArchive(data))
+	fmt.Print(formatter.StyleStdout(tf.TemplateCreatedArchive(data)))
 }
 
 // 🔺 CFG-003: Template-based backup creation printing - 📝
@@ -848,7 +815,7 @@ func (tf *TemplateFormatter) PrintTemplateCreatedBackup(path string) {
 	filename := getFilenameFromPath(path)
 	data := tf.extractBackupData(filename)
 	data["path"] = path
-	fmt.Print(tf.TemplateCreatedBackup(data))
+	fmt.Print(formatter.StyleStdout(tf.TemplateCreatedBackup(data)))
 }
 
 // 🔺 CFG-003: Template-based backup listing printing - 📝
@@ -863,7 +830,7 @@ func (tf *TemplateFormatter) PrintTemplateListBackup(path, creationTime string)
 	data := tf.extractBackupData(filename)
 	data["path"] = path
 	data["creation_time"] = creationTime
-	fmt.Print(tf.TemplateListBackup(data))
+	fmt.Print(formatter.StyleStdout(tf.TemplateListBackup(data)))
 }
 
 // 🔺 CFG-003: Template-based error printing - 📝
@@ -877,7 +844,7 @@ func (tf *TemplateFormatter) PrintTemplateError(message, operation string) {
 		"message":   message,
 		"operation": operation,
 	}
-	fmt.Print(tf.TemplateError(data))
+	fmt.Print(formatter.StyleStdout(tf.TemplateError(data)))
 }
 
 // 🔺 CFG-003: Archive data extraction - 📝
@@ -887,24 +854,8 @@ func (tf *TemplateFormatter) PrintTemplateError(message, operation string) {
 // extractArchiveData extracts data from an archive filename using regex patterns.
 // It returns a map of named capture groups from the configured patterns.
 func (tf *TemplateFormatter) extractArchiveData(filename string) map[string]string {
-	re, err := regexp.Compile(tf.config.PatternArchiveFilename)
-	if err != nil {
-		return make(map[string]string)
-	}
-
-	matches := re.FindStringSubmatch(filename)
-	if matches == nil {
-		return make(map[string]string)
-	}
-
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		if i > 0 && i < len(matches) && name != "" {
-			result[name] = matches[i]
-		}
-	}
-
-	return result
+	// ⭐ NAME-PATTERN-001: Names from the archive directory are untrusted
+	return formatter.ExtractNamedGroups(tf.config.PatternArchiveFilename, filename)
 }
 
 // 🔺 CFG-003: Backup data extraction - 📝
@@ -914,24 +865,8 @@ func (tf *TemplateFormatter) extractArchiveData(filename string) map[string]stri
 // extractBackupData extracts data from a backup filename using regex patterns.
 // It returns a map of named capture groups from the configured patterns.
 func (tf *TemplateFormatter) extractBackupData(filename string) map[string]string {
-	re, err := regexp.Compile(tf.config.PatternBackupFilename)
-	if err != nil {
-		return make(map[string]string)
-	}
-
-	matches := re.FindStringSubmatch(filename)
-	if matches == nil {
-		return make(map[string]string)
-	}
-
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		if i > 0 && i < len(matches) && name != "" {
-			result[name] = matches[i]
-		}
-	}
-
-	return result
+	// ⭐ NAME-PATTERN-001: Names from the archive directory are untrusted
+	return formatter.ExtractNamedGroups(tf.config.PatternBackupFilename, filename)
 }
 
 // 🔶 REFACTOR-002: Component boundary - Extended Printf Formatters (Lines 929-1084) - 🔍
@@ -1042,7 +977,7 @@ func (f *OutputFormatter) PrintCreatedArchiveWithStats(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1053,7 +988,7 @@ func (f *OutputFormatter) PrintIncrementalCreatedWithStats(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1193,7 +1128,7 @@ func (f *OutputFormatter) PrintNoArchivesFound(archiveDir string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1204,7 +1139,7 @@ func (f *OutputFormatter) PrintVerificationFailed(archiveName string, err error)
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "error")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1215,7 +1150,7 @@ func (f *OutputFormatter) PrintVerificationSuccess(archiveName string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1226,7 +1161,7 @@ func (f *OutputFormatter) PrintVerificationWarning(archiveName string, err error
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "warning")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1237,7 +1172,7 @@ func (f *OutputFormatter) PrintConfigurationUpdated(key string, value interface{
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "config")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1248,7 +1183,7 @@ func (f *OutputFormatter) PrintConfigFilePath(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "config")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1259,7 +1194,7 @@ func (f *OutputFormatter) PrintDryRunFilesHeader() {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1270,7 +1205,7 @@ func (f *OutputFormatter) PrintDryRunFileEntry(file string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1281,7 +1216,7 @@ func (f *OutputFormatter) PrintNoFilesModified() {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1292,7 +1227,7 @@ func (f *OutputFormatter) PrintIncrementalCreated(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1321,7 +1256,7 @@ func (f *OutputFormatter) PrintNoBackupsFound(filename, backupDir string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1332,7 +1267,7 @@ func (f *OutputFormatter) PrintBackupWouldCreate(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1343,7 +1278,7 @@ func (f *OutputFormatter) PrintBackupIdentical(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1354,7 +1289,7 @@ func (f *OutputFormatter) PrintBackupCreated(path string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1525,7 +1460,7 @@ func (f *OutputFormatter) PrintDiskFullError(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1537,7 +1472,7 @@ func (f *OutputFormatter) PrintPermissionError(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1549,7 +1484,7 @@ func (f *OutputFormatter) PrintDirectoryNotFound(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1561,7 +1496,7 @@ func (f *OutputFormatter) PrintFileNotFound(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1573,7 +1508,7 @@ func (f *OutputFormatter) PrintInvalidDirectory(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1585,7 +1520,7 @@ func (f *OutputFormatter) PrintInvalidFile(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1597,7 +1532,7 @@ func (f *OutputFormatter) PrintFailedWriteTemp(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1609,7 +1544,7 @@ func (f *OutputFormatter) PrintFailedFinalizeFile(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1621,7 +1556,7 @@ func (f *OutputFormatter) PrintFailedCreateDirDisk(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1633,7 +1568,7 @@ func (f *OutputFormatter) PrintFailedCreateDir(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1645,7 +1580,7 @@ func (f *OutputFormatter) PrintFailedAccessDir(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1657,7 +1592,7 @@ func (f *OutputFormatter) PrintFailedAccessFile(err error) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -1669,7 +1604,7 @@ func (f *OutputFormatter) PrintVerificationErrorDetail(errMsg string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "error")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -1681,6 +1616,6 @@ func (f *OutputFormatter) PrintArchiveListWithStatus(output, status string) {
 		// 🔶 OUT-001: Delayed output implementation - 📝
 		f.collector.AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
@@ -428,7 +428,7 @@ func (fa *FormatterAdapter) PrintNoArchivesFound(archiveDir string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -437,7 +437,7 @@ func (fa *FormatterAdapter) PrintVerificationFailed(archiveName string, err erro
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -446,7 +446,7 @@ func (fa *FormatterAdapter) PrintVerificationSuccess(archiveName string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -455,7 +455,7 @@ func (fa *FormatterAdapter) PrintVerificationWarning(archiveName string, err err
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "warning")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -464,7 +464,7 @@ func (fa *FormatterAdapter) PrintConfigurationUpdated(key string, value interfac
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "config")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -473,7 +473,7 @@ func (fa *FormatterAdapter) PrintConfigFilePath(path string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "config")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -482,7 +482,7 @@ func (fa *FormatterAdapter) PrintDryRunFilesHeader() {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -491,7 +491,7 @@ func (fa *FormatterAdapter) PrintDryRunFileEntry(file string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -500,7 +500,7 @@ func (fa *FormatterAdapter) PrintNoFilesModified() {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -509,7 +509,7 @@ func (fa *FormatterAdapter) PrintIncrementalCreated(path string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -518,7 +518,7 @@ func (fa *FormatterAdapter) PrintNoBackupsFound(filename, backupDir string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -527,7 +527,7 @@ func (fa *FormatterAdapter) PrintBackupWouldCreate(path string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "dry-run")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -536,7 +536,7 @@ func (fa *FormatterAdapter) PrintBackupIdentical(path string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -545,7 +545,7 @@ func (fa *FormatterAdapter) PrintBackupCreated(path string) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -561,13 +561,61 @@ func NewOutputFormatterWithCollector(cfg *Config, collector *formatter.OutputCol
 }
 
 // ⭐ EXTRACT-003: FormatterAdapter - 📝 Additional print methods for compatibility
+// ⭐ ARCH-006: Sampled verification summary output - 📝
+// FormatVerificationSample formats the sample size and confidence bound for an archive.
+func (fa *FormatterAdapter) FormatVerificationSample(archiveName string, sampled, total int, bound float64) string {
+	return fmt.Sprintf(fa.config.FormatVerificationSample, archiveName, sampled, total, bound*100)
+}
+
+// PrintVerificationSample prints the sample size and confidence bound for an archive.
+func (fa *FormatterAdapter) PrintVerificationSample(archiveName string, sampled, total int, bound float64) {
+	message := fa.FormatVerificationSample(archiveName, sampled, total, bound)
+	if fa.formatter.IsDelayedMode() {
+		fa.formatter.GetCollector().AddStdout(message, "info")
+	} else {
+		fmt.Print(formatter.StyleStdout(message))
+	}
+}
+
+// ⭐ SEAL-001: Integrity seal mismatch output - 📝
+// FormatIntegritySealMismatch formats the warning for an archive that no longer matches its seal.
+func (fa *FormatterAdapter) FormatIntegritySealMismatch(archiveName string) string {
+	return fmt.Sprintf(fa.config.FormatIntegritySealMismatch, archiveName)
+}
+
+// PrintIntegritySealMismatch prints the seal mismatch warning to stderr.
+func (fa *FormatterAdapter) PrintIntegritySealMismatch(archiveName string) {
+	message := fa.FormatIntegritySealMismatch(archiveName)
+	if fa.formatter.GetCollector() != nil {
+		fa.formatter.GetCollector().AddStderr(message, "warning")
+	} else {
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
+	}
+}
+
+// ⭐ VERIFY-QUICK-001: Quick verification output - 📝
+// FormatVerificationQuick formats the result of an archive passing the quick structural check.
+func (fa *FormatterAdapter) FormatVerificationQuick(archiveName string) string {
+	return fmt.Sprintf(fa.config.FormatVerificationQuick, archiveName)
+}
+
+// PrintVerificationQuick prints the result of an archive passing the quick structural check.
+func (fa *FormatterAdapter) PrintVerificationQuick(archiveName string) {
+	message := fa.FormatVerificationQuick(archiveName)
+	if fa.formatter.IsDelayedMode() {
+		fa.formatter.GetCollector().AddStdout(message, "info")
+	} else {
+		fmt.Print(formatter.StyleStdout(message))
+	}
+}
+
 // PrintVerificationErrorDetail prints verification error details
 func (fa *FormatterAdapter) PrintVerificationErrorDetail(errMsg string) {
 	message := fmt.Sprintf("  - %s\n", errMsg)
 	if fa.formatter.IsDelayedMode() {
 		fa.formatter.GetCollector().AddStdout(message, "error")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
@@ -577,18 +625,28 @@ func (fa *FormatterAdapter) PrintArchiveListWithStatus(output, status string) {
 	if fa.formatter.IsDelayedMode() {
 		fa.formatter.GetCollector().AddStdout(message, "info")
 	} else {
-		fmt.Print(message)
+		fmt.Print(formatter.StyleStdout(message))
 	}
 }
 
 // ⭐ EXTRACT-003: FormatterAdapter - 📝 Extended formatting methods with extraction
 // FormatListArchiveWithExtraction formats archive listing with data extraction
 func (fa *FormatterAdapter) FormatListArchiveWithExtraction(archivePath, creationTime string) string {
+	return fa.FormatListArchiveWithData(archivePath, creationTime, nil)
+}
+
+// 🔶 GIT-007: Archive listing with metadata placeholders - 📝
+// FormatListArchiveWithData formats archive listing with data extracted from the
+// filename plus extra placeholder values such as %{tag} and %{describe}.
+func (fa *FormatterAdapter) FormatListArchiveWithData(archivePath, creationTime string, extra map[string]string) string {
 	// Extract data from archive filename and format with template
 	data := fa.formatter.ExtractArchiveFilenameData(archivePath)
 	if data == nil {
 		data = make(map[string]string)
 	}
+	for key, value := range extra {
+		data[key] = value
+	}
 	data["path"] = archivePath
 	data["creation_time"] = creationTime
 
@@ -627,7 +685,7 @@ func (fa *FormatterAdapter) PrintDiskFullError(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -637,7 +695,7 @@ func (fa *FormatterAdapter) PrintPermissionError(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -647,7 +705,7 @@ func (fa *FormatterAdapter) PrintDirectoryNotFound(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -657,7 +715,7 @@ func (fa *FormatterAdapter) PrintFileNotFound(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -667,7 +725,7 @@ func (fa *FormatterAdapter) PrintInvalidDirectory(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -677,7 +735,7 @@ func (fa *FormatterAdapter) PrintInvalidFile(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -687,7 +745,7 @@ func (fa *FormatterAdapter) PrintFailedWriteTemp(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -697,7 +755,7 @@ func (fa *FormatterAdapter) PrintFailedFinalizeFile(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -707,7 +765,7 @@ func (fa *FormatterAdapter) PrintFailedCreateDirDisk(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -717,7 +775,7 @@ func (fa *FormatterAdapter) PrintFailedCreateDir(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -727,7 +785,7 @@ func (fa *FormatterAdapter) PrintFailedAccessDir(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
@@ -737,7 +795,7 @@ func (fa *FormatterAdapter) PrintFailedAccessFile(err error) {
 	if fa.formatter.GetCollector() != nil {
 		fa.formatter.GetCollector().AddStderr(message, "error")
 	} else {
-		fmt.Fprint(os.Stderr, message)
+		fmt.Fprint(os.Stderr, formatter.StyleStderr(message))
 	}
 }
 
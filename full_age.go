@@ -0,0 +1,107 @@
+// This file is part of bkpdir
+//
+// Package main provides the full archive age policy: with
+// max_full_archive_age set, `bkpdir inc` warns about or refuses a base full
+// archive older than the limit and exits with status_full_archive_too_old,
+// nudging users to take a fresh full archive before chains grow too long.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ⭐ FULL-AGE-001: Policies for an old base full archive - 🔧
+const (
+	// FullAgeActionWarn creates the incremental archive and exits with
+	// status_full_archive_too_old.
+	FullAgeActionWarn = "warn"
+	// FullAgeActionFail refuses to create the incremental archive.
+	FullAgeActionFail = "fail"
+)
+
+// parseAge parses an age such as "7d", "2w" or any time.ParseDuration
+// value such as "36h". Days are 24 hours.
+func parseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, fmt.Errorf("empty age")
+	}
+	units := map[byte]time.Duration{'d': 24 * time.Hour, 'w': 7 * 24 * time.Hour}
+	if unit, ok := units[s[len(s)-1]]; ok && len(s) > 1 {
+		n, err := strconv.ParseFloat(s[:len(s)-1], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid age %q", s)
+		}
+		return time.Duration(n * float64(unit)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("invalid age %q (use e.g. 7d, 2w or 36h)", s)
+	}
+	return d, nil
+}
+
+// fullArchiveAgeLimit parses the age policy of cfg; 0 means no limit.
+func fullArchiveAgeLimit(cfg *Config) (time.Duration, error) {
+	if strings.TrimSpace(cfg.MaxFullArchiveAge) == "" {
+		return 0, nil
+	}
+	limit, err := parseAge(cfg.MaxFullArchiveAge)
+	if err == nil && limit <= 0 {
+		err = fmt.Errorf("max_full_archive_age must be positive")
+	}
+	if err != nil {
+		return 0, NewArchiveErrorWithCause("Invalid max_full_archive_age", cfg.StatusConfigError, err)
+	}
+	switch cfg.FullArchiveAgeAction {
+	case "", FullAgeActionWarn, FullAgeActionFail:
+	default:
+		return 0, NewArchiveError(fmt.Sprintf("Invalid full_archive_age_action %q (use warn or fail)",
+			cfg.FullArchiveAgeAction), cfg.StatusConfigError)
+	}
+	return limit, nil
+}
+
+// ⭐ FULL-AGE-001: Base full archive age check - 🛡️
+// checkFullArchiveAge applies max_full_archive_age to the base of an
+// incremental archive. When base is older, the fail action returns err with
+// status_full_archive_too_old and nothing must be archived; the warn action
+// prints a warning and returns it as stale, the error the run ends with once
+// its archive is created. Dry runs only print the warning.
+func checkFullArchiveAge(cfg *Config, base *Archive, now time.Time, dryRun bool) (stale error, err error) {
+	limit, err := fullArchiveAgeLimit(cfg)
+	if err != nil || limit == 0 {
+		return nil, err
+	}
+	age := now.Sub(base.CreationTime)
+	if age <= limit {
+		return nil, nil
+	}
+	msg := fmt.Sprintf("Full archive %s is %s old, older than max_full_archive_age %s; create a new one with 'bkpdir full'",
+		base.Name, formatArchiveAge(age), cfg.MaxFullArchiveAge)
+	if cfg.FullArchiveAgeAction == FullAgeActionFail && !dryRun {
+		return nil, NewArchiveError(msg, cfg.StatusFullArchiveTooOld)
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	if dryRun {
+		return nil, nil
+	}
+	return NewArchiveError(fmt.Sprintf("Incremental archive based on a full archive %s old", formatArchiveAge(age)),
+		cfg.StatusFullArchiveTooOld), nil
+}
+
+// formatArchiveAge formats an age in days, or hours below two days.
+func formatArchiveAge(d time.Duration) string {
+	if d < 48*time.Hour {
+		return fmt.Sprintf("%d hours", int(d.Hours()))
+	}
+	return fmt.Sprintf("%d days", int(d.Hours()/24))
+}
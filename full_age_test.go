@@ -0,0 +1,92 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the full archive age policy.
+// It verifies age parsing and how inc treats an old base full archive.
+package main
+
+import (
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+// ⭐ FULL-AGE-001: Age parsing - 🧪
+func TestParseAge(t *testing.T) {
+	for in, want := range map[string]time.Duration{
+		"7d":   7 * 24 * time.Hour,
+		"2w":   14 * 24 * time.Hour,
+		"1.5d": 36 * time.Hour,
+		"36h":  36 * time.Hour,
+		" 90m": 90 * time.Minute,
+	} {
+		if got, err := parseAge(in); err != nil || got != want {
+			t.Errorf("parseAge(%q) = %v, %v; want %v", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"", "d", "7x", "sevend"} {
+		if _, err := parseAge(in); err == nil {
+			t.Errorf("parseAge(%q) succeeded", in)
+		}
+	}
+}
+
+// ⭐ FULL-AGE-001: Old base full archive - 🧪
+func TestIncrementalFullArchiveAge(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	full, _ := listArchiveEntries(archiveDir)
+	old := time.Now().Add(-10 * 24 * time.Hour)
+	if err := os.Chtimes(full[0].Path, old, old); err != nil {
+		t.Fatal(err)
+	}
+	cfg.MaxFullArchiveAge = "7d"
+	incrementals := func() int {
+		archives, _ := listArchiveEntries(archiveDir)
+		return len(archives) - 1
+	}
+
+	// fail refuses the incremental archive
+	cfg.FullArchiveAgeAction = FullAgeActionFail
+	os.WriteFile("b.txt", []byte("b"), 0o644)
+	err := CreateIncrementalArchive(cfg, "", false, false)
+	var archiveErr *ArchiveError
+	if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusFullArchiveTooOld {
+		t.Fatalf("fail: err = %v", err)
+	}
+	if n := incrementals(); n != 0 {
+		t.Errorf("fail: %d incremental archives created", n)
+	}
+
+	// Dry runs only warn
+	if err := CreateIncrementalArchive(cfg, "", true, false); err != nil {
+		t.Errorf("dry run: err = %v", err)
+	}
+
+	// warn creates the archive and still exits with the status
+	cfg.FullArchiveAgeAction = FullAgeActionWarn
+	err = CreateIncrementalArchive(cfg, "", false, false)
+	if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusFullArchiveTooOld {
+		t.Fatalf("warn: err = %v", err)
+	}
+	if n := incrementals(); n != 1 {
+		t.Errorf("warn: %d incremental archives created, want 1", n)
+	}
+
+	// A recent enough full archive is accepted
+	cfg.MaxFullArchiveAge = "2w"
+	os.WriteFile("c.txt", []byte("c"), 0o644)
+	if err := CreateIncrementalArchive(cfg, "", false, false); err != nil {
+		t.Errorf("2w: err = %v", err)
+	}
+
+	cfg.MaxFullArchiveAge = "-1d"
+	if err := CreateIncrementalArchive(cfg, "", false, false); !errors.As(err, &archiveErr) ||
+		archiveErr.StatusCode != cfg.StatusConfigError {
+		t.Errorf("invalid age: err = %v", err)
+	}
+}
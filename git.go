@@ -8,7 +8,15 @@
 package main
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bkpdir/pkg/fileops"
 	"bkpdir/pkg/git"
+	"bkpdir/pkg/processing"
 )
 
 // ⭐ EXTRACT-004: Git integration adapter using extracted pkg/git package - 🔧
@@ -25,6 +33,29 @@ type GitError = git.GitError
 // ⭐ EXTRACT-004: Backward compatibility functions using pkg/git - 🔧
 // These functions maintain the original API while delegating to the extracted package
 
+// 🔶 GIT-008: Git information for archive names - 🔍
+// gitNamingInfo looks up branch, hash and clean state of cwd for an archive
+// name using the configured Git command and timeout, and the describe output
+// when git.include_tags is set. Directories outside a
+// repository silently get no Git info; a missing Git binary, a timeout or
+// another failure is reported as a warning and the name omits Git info.
+func gitNamingInfo(cwd string, base *git.Config) (*git.Info, bool) {
+	gitCfg := *base
+	gitCfg.WorkingDirectory = cwd
+	gitCfg.IncludeDirtyStatus = true
+	gitCfg.IncludeSubmodules = false
+
+	info, err := git.InfoWithStatus(&gitCfg)
+	if errors.Is(err, git.ErrNotRepository) {
+		return nil, false
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: Git information omitted from archive name: %v\n", err)
+		return nil, false
+	}
+	return info, true
+}
+
 // 🔺 GIT-001: Git repository detection implementation - 🔍
 // IsGitRepository checks if the given directory is a Git repository.
 // It uses git rev-parse to check if the directory is inside a git work tree.
@@ -87,3 +118,103 @@ func GetGitSubmodules(dir string) []git.SubmoduleInfo {
 func GetGitSubmoduleStatus(dir, path string) string {
 	return git.GetGitSubmoduleStatus(dir, path)
 }
+
+// 🔶 GIT-007: Git tag information - 🔍
+// GetGitTagInfo returns the git describe --tags --dirty output and the nearest tag.
+// Both values are empty if not in a Git repository or if no tag is reachable.
+func GetGitTagInfo(dir string) (describe, tag string) {
+	return git.GetGitTagInfo(dir)
+}
+
+// 🔶 GIT-007: Git metadata recorded alongside archives - 📝
+// GitMetadata holds the repository state captured when an archive was created.
+// It is stored next to the verification status in the archive's .metadata directory
+// so listings can filter on tags without re-parsing archive names.
+type GitMetadata struct {
+	Branch   string `json:"branch"`
+	Hash     string `json:"hash"`
+	IsClean  bool   `json:"is_clean"`
+	Describe string `json:"describe,omitempty"`
+	Tag      string `json:"tag,omitempty"`
+}
+
+// 🔶 GIT-007: Git metadata collection - 🔍
+// CollectGitMetadata gathers branch, hash, status and tag information for dir.
+// It returns nil if dir is not inside a Git repository.
+func CollectGitMetadata(dir string) *GitMetadata {
+	if !IsGitRepository(dir) {
+		return nil
+	}
+	branch, hash, isClean := GetGitInfoWithStatus(dir)
+	describe, tag := GetGitTagInfo(dir)
+	return &GitMetadata{
+		Branch:   branch,
+		Hash:     hash,
+		IsClean:  isClean,
+		Describe: describe,
+		Tag:      tag,
+	}
+}
+
+// gitMetadataPath returns the sidecar path holding Git metadata for an archive.
+func gitMetadataPath(archive *Archive) string {
+	return filepath.Join(filepath.Dir(archive.Path), ".metadata", archive.Name+".git.json")
+}
+
+// 🔶 GIT-007: Git metadata persistence - 🔧
+// StoreGitMetadata writes Git metadata for an archive to its .metadata directory.
+func StoreGitMetadata(archive *Archive, meta *GitMetadata) error {
+	metadataPath := gitMetadataPath(archive)
+	if err := fileops.MkdirAll(filepath.Dir(metadataPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode git metadata: %w", err)
+	}
+	if err := fileops.WriteFile(metadataPath, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write git metadata: %w", err)
+	}
+	return nil
+}
+
+// 🔶 GIT-007: Git metadata loading - 🔧
+// LoadGitMetadata reads Git metadata for an archive.
+// It returns nil without error if no metadata was recorded.
+func LoadGitMetadata(archive *Archive) (*GitMetadata, error) {
+	data, err := os.ReadFile(gitMetadataPath(archive))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read git metadata: %w", err)
+	}
+
+	var meta GitMetadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to decode git metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// 🔶 GIT-007: Archive Git metadata recording - 🔧
+// recordArchiveGitMetadata stages Git metadata for a new archive in txn when Git
+// information is enabled and returns it for the manifest. Failures are ignored
+// because the archive itself is complete.
+func recordArchiveGitMetadata(txn *processing.Transaction, cwd, archivePath string, cfg ArchiveConfigInterface) *GitMetadata {
+	if !cfg.GetIncludeGitInfo() {
+		return nil
+	}
+	meta := CollectGitMetadata(cwd)
+	if meta == nil {
+		return nil
+	}
+	data, err := json.Marshal(meta)
+	if err != nil {
+		return meta
+	}
+	archive := &Archive{Name: filepath.Base(archivePath), Path: archivePath}
+	_ = txn.WriteFile(gitMetadataPath(archive), data, 0o644)
+	return meta
+}
@@ -10,6 +10,8 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"bkpdir/pkg/processing"
 )
 
 // TestGitIntegration tests the Git integration functionality for GIT-001 feature
@@ -469,3 +471,88 @@ func TestGitSubmoduleIntegration(t *testing.T) {
 		}
 	})
 }
+
+// TestGitTagMetadata tests Git metadata persistence and tag filtering for GIT-007
+func TestGitTagMetadata(t *testing.T) {
+	// 🔶 GIT-007: Git tag metadata validation - 🔧
+	// TEST-REF: Feature tracking matrix GIT-007
+	if !isGitAvailable() {
+		t.Skip("Git not available, skipping git tag metadata tests")
+	}
+
+	repoDir := t.TempDir()
+	runGitCommand(t, repoDir, "init")
+	runGitCommand(t, repoDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, repoDir, "config", "user.name", "Test User")
+	if err := os.WriteFile(filepath.Join(repoDir, "file.txt"), []byte("content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitCommand(t, repoDir, "add", "file.txt")
+	runGitCommand(t, repoDir, "commit", "-m", "Initial commit")
+	runGitCommand(t, repoDir, "tag", "v1.4.0")
+
+	meta := CollectGitMetadata(repoDir)
+	if meta == nil {
+		t.Fatal("Expected Git metadata for repository")
+	}
+	if meta.Tag != "v1.4.0" || meta.Describe != "v1.4.0" {
+		t.Errorf("Unexpected tag info: describe=%q tag=%q", meta.Describe, meta.Tag)
+	}
+
+	if CollectGitMetadata(t.TempDir()) != nil {
+		t.Error("Expected nil metadata outside a repository")
+	}
+
+	// With git.include_tags the describe output takes the place of the hash
+	cfg := DefaultConfig()
+	cfg.IncludeGitInfo = true
+	cfg.Git.IncludeTags = true
+	name := GenerateArchiveNameWithInterface(fullArchiveNameConfig(&ConfigToArchiveConfigAdapter{cfg: cfg}, repoDir, ""))
+	if !strings.HasSuffix(name, "=v1.4.0.zip") {
+		t.Errorf("Expected the describe output in the archive name, got %s", name)
+	}
+
+	manifestArchive := filepath.Join(t.TempDir(), "proj-2024-01-01-10-00.zip")
+	commitSidecars(t, manifestArchive, func(txn *processing.Transaction) {
+		recordArchiveManifest(txn, manifestArchive, nil, nil, nil, "", nil, nil, meta)
+	})
+	manifest, err := LoadArchiveManifest(manifestArchive)
+	if err != nil || manifest == nil || manifest.GitDescribe != "v1.4.0" || manifest.GitTag != "v1.4.0" {
+		t.Errorf("Expected describe and tag in the manifest, got %+v, %v", manifest, err)
+	}
+
+	archiveDir := t.TempDir()
+	tagged := filepath.Join(archiveDir, "proj-2024-01-01-10-00.zip")
+	untagged := filepath.Join(archiveDir, "proj-2024-01-02-10-00.zip")
+	for _, p := range []string{tagged, untagged} {
+		if err := os.WriteFile(p, []byte("zip"), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := StoreGitMetadata(&Archive{Name: filepath.Base(tagged), Path: tagged}, meta); err != nil {
+		t.Fatalf("StoreGitMetadata failed: %v", err)
+	}
+
+	archives, err := ListArchives(archiveDir)
+	if err != nil {
+		t.Fatalf("ListArchives failed: %v", err)
+	}
+
+	matched, err := filterArchivesByTag(archives, "v1.*")
+	if err != nil {
+		t.Fatalf("filterArchivesByTag failed: %v", err)
+	}
+	if len(matched) != 1 || matched[0].Path != tagged {
+		t.Errorf("Expected only the tagged archive to match, got %+v", matched)
+	}
+	if matched[0].GitBranch == "" || matched[0].GitHash == "" {
+		t.Error("Expected branch and hash to be loaded from metadata")
+	}
+
+	if matched, _ := filterArchivesByTag(archives, "v2.*"); len(matched) != 0 {
+		t.Errorf("Expected no archives to match v2.*, got %d", len(matched))
+	}
+	if _, err := filterArchivesByTag(archives, "v1.["); err == nil {
+		t.Error("Expected error for malformed pattern")
+	}
+}
@@ -0,0 +1,121 @@
+// This file is part of bkpdir
+//
+// Package main provides healthcheck pings for archive runs. When
+// healthcheck_url is set, each full or incremental archive run pings
+// URL/start when it begins and URL or URL/fail when it ends, in the style of
+// healthchecks.io, so a monitor can alert when scheduled backups fail or
+// stop running.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// ⭐ HEALTHCHECK-001: Ping settings - 🔧
+const (
+	healthcheckTimeout = 10 * time.Second
+	// healthcheckBodyLimit caps the error text sent with a failure ping.
+	healthcheckBodyLimit = 10000
+)
+
+// healthcheckRun is one pinged archive run. A nil run ignores all calls, so
+// runs can be reported unconditionally.
+type healthcheckRun struct {
+	url string
+	rid string // Run ID pairing the start ping with the outcome
+}
+
+// ⭐ HEALTHCHECK-001: Run start - 🔧
+// startHealthcheck pings URL/start and returns the run, or nil when no
+// healthcheck_url is configured or this is a dry run. An unresolvable URL
+// only warns; pings never fail an archive operation.
+func startHealthcheck(cfg *Config, dryRun bool) *healthcheckRun {
+	if cfg.HealthcheckURL == "" || dryRun {
+		return nil
+	}
+	pingURL, err := cfg.HealthcheckURL.Resolve()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not ping healthcheck: %v\n", err)
+		return nil
+	}
+	run := &healthcheckRun{url: strings.TrimSuffix(pingURL, "/"), rid: newRunID()}
+	run.ping("/start", "")
+	return run
+}
+
+// ⭐ HEALTHCHECK-001: Run outcome - 🔧
+// finish pings URL when err is nil and URL/fail with the error otherwise.
+func (h *healthcheckRun) finish(err error) {
+	if h == nil {
+		return
+	}
+	if err != nil {
+		msg := err.Error()
+		if len(msg) > healthcheckBodyLimit {
+			msg = msg[:healthcheckBodyLimit]
+		}
+		h.ping("/fail", msg)
+		return
+	}
+	h.ping("", "")
+}
+
+// ping sends one ping with the run ID; failures only warn.
+func (h *healthcheckRun) ping(suffix, body string) {
+	target, err := url.Parse(h.url + suffix)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not ping healthcheck: invalid healthcheck_url\n")
+		return
+	}
+	query := target.Query()
+	query.Set("rid", h.rid)
+	target.RawQuery = query.Encode()
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthcheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.String(), strings.NewReader(body))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not ping healthcheck: %v\n", err)
+		return
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	req.Header.Set("User-Agent", "bkpdir/"+Version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		// The error quotes the URL, which holds the check's secret
+		fmt.Fprintf(os.Stderr, "Warning: could not ping healthcheck: %v\n", unwrapURLError(err))
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		fmt.Fprintf(os.Stderr, "Warning: healthcheck ping returned %s\n", resp.Status)
+	}
+}
+
+// unwrapURLError drops the request URL from HTTP client errors.
+func unwrapURLError(err error) error {
+	if urlErr, ok := err.(*url.Error); ok {
+		return urlErr.Err
+	}
+	return err
+}
+
+// newRunID returns a random UUID (version 4).
+func newRunID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
@@ -0,0 +1,96 @@
+// This file is part of bkpdir
+
+// Package main provides tests for healthcheck pings of archive runs.
+// It verifies the start, success and failure pings and their run IDs.
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// ⭐ HEALTHCHECK-001: Archive run pings - 🧪
+func TestHealthcheckPings(t *testing.T) {
+	var mu sync.Mutex
+	var paths, rids, bodies []string
+	monitor := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mu.Lock()
+		defer mu.Unlock()
+		paths = append(paths, r.URL.Path)
+		rids = append(rids, r.URL.Query().Get("rid"))
+		bodies = append(bodies, string(body))
+	}))
+	defer monitor.Close()
+	reset := func() {
+		mu.Lock()
+		defer mu.Unlock()
+		paths, rids, bodies = nil, nil, nil
+	}
+
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(sourceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(sourceDir, "a.txt"), []byte("content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Setenv("BKPDIR_TEST_HEALTHCHECK", monitor.URL+"/check-uuid/")
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = filepath.Join(tempDir, "archives")
+	cfg.UseCurrentDirName = false
+	cfg.HealthcheckURL = "env:BKPDIR_TEST_HEALTHCHECK"
+
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatalf("CreateFullArchive failed: %v", err)
+	}
+	if want := []string{"/check-uuid/start", "/check-uuid"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Expected pings %v, got %v", want, paths)
+	}
+	if rids[0] == "" || rids[0] != rids[1] {
+		t.Errorf("Expected one run ID for start and success, got %v", rids)
+	}
+
+	reset()
+	if err := CreateFullArchive(cfg, "", true, false); err != nil {
+		t.Fatalf("Dry run failed: %v", err)
+	}
+	if len(paths) != 0 {
+		t.Errorf("Expected dry runs not to ping, got %v", paths)
+	}
+
+	reset()
+	cfg.ArchiveDirPath = filepath.Join(sourceDir, "a.txt", "archives")
+	if err := CreateFullArchive(cfg, "", false, false); err == nil {
+		t.Fatal("Expected archiving into a file path to fail")
+	}
+	if want := []string{"/check-uuid/start", "/check-uuid/fail"}; !reflect.DeepEqual(paths, want) {
+		t.Fatalf("Expected pings %v, got %v", want, paths)
+	}
+	if bodies[1] == "" {
+		t.Error("Expected the failure ping to carry the error")
+	}
+
+	reset()
+	run := &healthcheckRun{url: monitor.URL + "/check-uuid", rid: newRunID()}
+	run.finish(errors.New(string(make([]byte, healthcheckBodyLimit+10))))
+	if len(bodies) != 1 || len(bodies[0]) != healthcheckBodyLimit {
+		t.Errorf("Expected the failure body to be capped, got %d pings", len(bodies))
+	}
+	var none *healthcheckRun
+	none.finish(nil)
+}
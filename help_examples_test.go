@@ -0,0 +1,108 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the generated help examples.
+// It verifies that examples use the configured archive directory and the
+// most recent archives, and fall back to a name in the configured layout.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ HELP-EXAMPLES-001: Examples from the real configuration - 🧪
+func TestLoadHelpExampleData(t *testing.T) {
+	cwd := filepath.Join(t.TempDir(), "proj")
+	archiveDir := filepath.Join(cwd, "archives")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(cwd, ".bkpdir.yml")
+	config := "archive_dir_path: archives\nuse_current_dir_name: false\ntimestamp_timezone: UTC\n"
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BKPDIR_CONFIG", configPath)
+
+	data := loadHelpExampleData(cwd)
+	if data.ArchiveDir != "archives" || data.Incremental != "" {
+		t.Errorf("data = %+v", data)
+	}
+	if want := "proj-" + time.Now().UTC().Format("2006-01-02") + "-"; !strings.HasPrefix(data.Archive, want) {
+		t.Errorf("fallback archive %q does not start with %q", data.Archive, want)
+	}
+
+	now := time.Now()
+	for i, name := range []string{
+		"proj-2024-03-20-14-30.zip",
+		"proj-2024-03-21-09-00.zip",
+		"proj-2024-03-21-09-00_update=2024-03-22-10-00.zip",
+	} {
+		path := filepath.Join(archiveDir, name)
+		if err := os.WriteFile(path, nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stamp := now.Add(time.Duration(i-3) * time.Hour)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	data = loadHelpExampleData(cwd)
+	if data.Archive != "proj-2024-03-21-09-00.zip" {
+		t.Errorf("Archive = %q, want the most recent full archive", data.Archive)
+	}
+	if data.Incremental != "proj-2024-03-21-09-00_update=2024-03-22-10-00.zip" {
+		t.Errorf("Incremental = %q", data.Incremental)
+	}
+
+	example := helpExamples["bkpdir verify"](data)
+	if !strings.Contains(example, "bkpdir verify proj-2024-03-21-09-00.zip --checksum --progress") {
+		t.Errorf("verify example:\n%s", example)
+	}
+	example = helpExamples["bkpdir restore"](data)
+	if !strings.Contains(example, filepath.Join("archives", "proj-2024-03-21-09-00.zip")+" .") {
+		t.Errorf("restore example:\n%s", example)
+	}
+}
+
+// ⭐ HELP-EXAMPLES-001: The verify example runs on an archive made by create - 🧪
+func TestHelpExampleVerifyRuns(t *testing.T) {
+	cwd := filepath.Join(t.TempDir(), "proj")
+	if err := os.MkdirAll(cwd, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	configPath := filepath.Join(cwd, ".bkpdir.yml")
+	config := "archive_dir_path: " + filepath.Join(filepath.Dir(cwd), "archives") + "\nuse_current_dir_name: false\n"
+	if err := os.WriteFile(configPath, []byte(config), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BKPDIR_CONFIG", configPath)
+	if wd, err := os.Getwd(); err == nil {
+		t.Cleanup(func() { os.Chdir(wd) })
+	}
+	if err := os.Chdir(cwd); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	data := loadHelpExampleData(cwd)
+	example := helpExamples["bkpdir verify"](data)
+	if !strings.Contains(example, "bkpdir verify "+data.Archive+" --checksum --progress") {
+		t.Fatalf("verify example:\n%s", example)
+	}
+	if err := VerifyArchiveEnhanced(VerifyOptions{Config: cfg, Formatter: NewOutputFormatter(cfg),
+		ArchiveName: data.Archive, WithChecksum: true, Progress: true}); err != nil {
+		t.Errorf("bkpdir verify %s --checksum --progress: %v", data.Archive, err)
+	}
+}
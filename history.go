@@ -0,0 +1,233 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir history PATH`, which lists every archive of
+// the current directory that contains a file, with the size and hash of each
+// version, and extracts a chosen version with --restore-version.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// HistoryOptions configures `bkpdir history`.
+type HistoryOptions struct {
+	Config         *Config
+	Output         io.Writer
+	Path           string // File of the current directory
+	Journal        bool   // Show the change journal instead of the archived versions
+	RestoreVersion int    // Version to extract; 0 only lists the versions
+	Target         string // Where the version is extracted; empty for Path, "-" for Output
+	Overwrite      bool   // Replace an existing file at Target
+	DryRun         bool
+}
+
+// ⭐ FILE-HISTORY-001: Archived file version - 📝
+// FileVersion is one archive containing a file. Versions are numbered from 1,
+// oldest first. Hash is "sha256:<hex>" when the manifest or the archive
+// checksums record one, and the CRC-32 of the entry otherwise.
+type FileVersion struct {
+	Number   int
+	Archive  Archive
+	Size     int64
+	Hash     string
+	Modified time.Time
+}
+
+// ⭐ FILE-HISTORY-001: Version search - 🔍
+// FindFileVersions returns a version of rel, a file path relative to the
+// archived directory, for every archive in archiveDir that contains it.
+// Archives that cannot be opened are skipped.
+func FindFileVersions(archiveDir, rel string) ([]FileVersion, error) {
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(archives, func(i, j int) bool {
+		if !archives[i].CreationTime.Equal(archives[j].CreationTime) {
+			return archives[i].CreationTime.Before(archives[j].CreationTime)
+		}
+		return archives[i].Name < archives[j].Name
+	})
+
+	var versions []FileVersion
+	for _, archive := range archives {
+		version, found := findFileVersion(archive, rel)
+		if !found {
+			continue
+		}
+		version.Number = len(versions) + 1
+		versions = append(versions, version)
+	}
+	return versions, nil
+}
+
+// findFileVersion looks up rel in one archive.
+func findFileVersion(archive Archive, rel string) (FileVersion, bool) {
+	r, err := zip.OpenReader(archive.Path)
+	if err != nil {
+		return FileVersion{}, false
+	}
+	defer r.Close()
+
+	name := filepath.ToSlash(rel)
+	var entry, checksums *zip.File
+	for _, f := range r.File {
+		switch f.Name {
+		case name, rel:
+			entry = f
+		case ".checksums":
+			checksums = f
+		}
+	}
+	if entry == nil {
+		return FileVersion{}, false
+	}
+	version := FileVersion{
+		Archive:  archive,
+		Size:     int64(entry.UncompressedSize64),
+		Hash:     fmt.Sprintf("crc32:%08x", entry.CRC32),
+		Modified: entry.Modified,
+	}
+	// ⭐ DEDUP-001: Prefer the SHA-256 of manifest_file_hashes
+	if manifest, err := LoadArchiveManifest(archive.Path); err == nil && manifest != nil {
+		for _, f := range manifest.Files {
+			if f.Path == entry.Name {
+				version.Hash = "sha256:" + f.SHA256
+				return version, true
+			}
+		}
+	}
+	if checksums != nil {
+		if sums, err := readChecksumsFromFile(checksums); err == nil && sums[entry.Name] != "" {
+			version.Hash = "sha256:" + sums[entry.Name]
+		}
+	}
+	return version, true
+}
+
+// shortFileHash abbreviates a version hash for display.
+func shortFileHash(hash string) string {
+	const width = len("sha256:") + 12
+	if len(hash) > width {
+		return hash[:width]
+	}
+	return hash
+}
+
+// ⭐ FILE-HISTORY-001: File history - 🔍
+// ShowFileHistory lists the archived versions of opts.Path, or the journaled
+// changes with opts.Journal, and extracts opts.RestoreVersion when it is set.
+func ShowFileHistory(opts HistoryOptions) error {
+	cfg := opts.Config
+	cwd, err := os.Getwd()
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
+	}
+	rel, err := historyRelPath(cwd, opts.Path)
+	if err != nil {
+		return NewArchiveErrorWithCause("Invalid path", cfg.StatusFileNotFound, err)
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	if opts.Journal {
+		if opts.RestoreVersion != 0 {
+			return NewArchiveError("--restore-version cannot be combined with --journal", cfg.StatusConfigError)
+		}
+		return showJournalHistory(opts, archiveDir, cwd, rel)
+	}
+
+	versions, err := FindFileVersions(archiveDir, rel)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	if opts.RestoreVersion != 0 {
+		return restoreFileVersion(opts, versions, cwd, rel)
+	}
+	if len(versions) == 0 {
+		fmt.Fprintf(opts.Output, "No archive in %s contains %s\n", archiveDir, rel)
+		return nil
+	}
+	fmt.Fprintf(opts.Output, "History of %s (%d versions):\n", rel, len(versions))
+	for _, v := range versions {
+		fmt.Fprintf(opts.Output, "  %3d  %s  %10s  %-19s  %s\n", v.Number,
+			v.Archive.CreationTime.Format("2006-01-02 15:04"), formatHumanSize(v.Size), shortFileHash(v.Hash), v.Archive.Name)
+	}
+	return nil
+}
+
+// ⭐ FILE-HISTORY-001: Version extraction - 🔧
+// restoreFileVersion writes version opts.RestoreVersion of rel to the target.
+// An existing file is only replaced with opts.Overwrite.
+func restoreFileVersion(opts HistoryOptions, versions []FileVersion, cwd, rel string) error {
+	cfg := opts.Config
+	n := opts.RestoreVersion
+	if n < 1 || n > len(versions) {
+		return NewArchiveError(fmt.Sprintf("%s has no version %d (%d archived versions)", rel, n, len(versions)), cfg.StatusFileNotFound)
+	}
+	version := versions[n-1]
+
+	r, err := zip.OpenReader(version.Archive.Path)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to open archive", 1, err)
+	}
+	defer r.Close()
+	var entry *zip.File
+	for _, f := range r.File {
+		if f.Name == filepath.ToSlash(rel) || f.Name == rel {
+			entry = f
+			break
+		}
+	}
+	if entry == nil {
+		return NewArchiveError(fmt.Sprintf("%s is no longer in %s", rel, version.Archive.Name), cfg.StatusFileNotFound)
+	}
+
+	if opts.Target == "-" {
+		rc, err := entry.Open()
+		if err != nil {
+			return NewArchiveErrorWithCause("Failed to read archived file", 1, err)
+		}
+		defer rc.Close()
+		if _, err := io.Copy(opts.Output, rc); err != nil {
+			return NewArchiveErrorWithCause("Failed to read archived file", 1, err)
+		}
+		return nil
+	}
+
+	target := opts.Target
+	if target == "" {
+		target = filepath.Join(cwd, rel)
+	}
+	if info, err := os.Lstat(target); err == nil {
+		if info.IsDir() {
+			return NewArchiveError(fmt.Sprintf("Target is a directory: %s", target), 1)
+		}
+		if !opts.Overwrite {
+			return NewArchiveError(fmt.Sprintf("%s exists; use --overwrite to replace it or --output to write elsewhere", target), 1)
+		}
+	}
+	if opts.DryRun {
+		fmt.Fprintf(opts.Output, "Would restore version %d of %s from %s to %s\n", n, rel, version.Archive.Name, target)
+		return nil
+	}
+	err = restoreEntry(entry, target)
+	// ⭐ AUDIT-001: Record the restore in the audit log
+	recordAudit(cfg, AuditActionRestore, version.Archive.Path, fmt.Sprintf("version %d of %s to %s", n, rel, target), err)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to restore file", 1, err)
+	}
+	fmt.Fprintf(opts.Output, "Restored version %d of %s from %s to %s\n", n, rel, version.Archive.Name, target)
+	return nil
+}
@@ -0,0 +1,99 @@
+// This file is part of bkpdir
+
+// Package main provides tests for file history across archives.
+// It verifies that every archive containing a file is listed with the size
+// and hash of its version, and that a version can be extracted.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ FILE-HISTORY-001: Versions across archives - 🧪
+func TestFileHistoryVersions(t *testing.T) {
+	archiveDir := filepath.Join(t.TempDir(), "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.ManifestFileHashes = true
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(cwd, "notes.txt")
+	seen := map[string]bool{}
+	base := time.Now().Add(-time.Hour)
+	for i, content := range []string{"one", "one two", "one two three"} {
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := CreateFullArchive(cfg, "", false, false); err != nil {
+			t.Fatalf("archive %d: %v", i+1, err)
+		}
+		// Order the archives by creation even within the same second
+		matches, _ := filepath.Glob(filepath.Join(archiveDir, "*.zip"))
+		for _, m := range matches {
+			if !seen[m] {
+				seen[m] = true
+				stamp := base.Add(time.Duration(i) * time.Minute)
+				if err := os.Chtimes(m, stamp, stamp); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+	}
+
+	versions, err := FindFileVersions(archiveDir, "notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("found %d versions, want 3", len(versions))
+	}
+	for i, v := range versions {
+		if v.Number != i+1 || !strings.HasPrefix(v.Hash, "sha256:") {
+			t.Errorf("version %d = %+v", i+1, v)
+		}
+	}
+	if versions[0].Size != 3 || versions[2].Size != int64(len("one two three")) {
+		t.Errorf("sizes %d and %d", versions[0].Size, versions[2].Size)
+	}
+
+	var out bytes.Buffer
+	if err := ShowFileHistory(HistoryOptions{Config: cfg, Output: &out, Path: "notes.txt"}); err != nil {
+		t.Fatalf("ShowFileHistory: %v", err)
+	}
+	if !strings.Contains(out.String(), "(3 versions)") || !strings.Contains(out.String(), versions[1].Archive.Name) {
+		t.Errorf("history:\n%s", out.String())
+	}
+
+	// The file exists, so restoring over it needs --overwrite
+	opts := HistoryOptions{Config: cfg, Output: &out, Path: "notes.txt", RestoreVersion: 1}
+	if err := ShowFileHistory(opts); err == nil {
+		t.Error("restoring over an existing file without --overwrite should fail")
+	}
+	opts.Overwrite = true
+	if err := ShowFileHistory(opts); err != nil {
+		t.Fatalf("restore version 1: %v", err)
+	}
+	if data, _ := os.ReadFile(file); string(data) != "one" {
+		t.Errorf("restored %q, want version 1", data)
+	}
+
+	out.Reset()
+	opts = HistoryOptions{Config: cfg, Output: &out, Path: "notes.txt", RestoreVersion: 2, Target: "-"}
+	if err := ShowFileHistory(opts); err != nil {
+		t.Fatalf("restore version 2 to stdout: %v", err)
+	}
+	if out.String() != "one two" {
+		t.Errorf("version 2 = %q", out.String())
+	}
+
+	opts.RestoreVersion = 4
+	if err := ShowFileHistory(opts); err == nil {
+		t.Error("a version past the last one should fail")
+	}
+}
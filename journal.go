@@ -0,0 +1,258 @@
+// This file is part of bkpdir
+//
+// Package main provides the operation journal used by `bkpdir undo` to revert
+// the most recent destructive operation.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ UNDO-001: Journaled operation kinds - 🔧
+const (
+	// JournalOpConfigSet records a `config KEY VALUE` modification.
+	JournalOpConfigSet = "config_set"
+	// JournalOpTrash records a file moved into the trash area.
+	JournalOpTrash = "trash"
+)
+
+// ⭐ UNDO-001: Journal limits - 🔧
+const (
+	// journalMaxEntries bounds the journal so it never grows without limit.
+	journalMaxEntries = 50
+	// journalEnvVar overrides the journal location.
+	journalEnvVar = "BKPDIR_JOURNAL"
+	// journalName is the journal in the state directory.
+	journalName = "journal.json"
+)
+
+// ErrJournalEmpty is returned by Undo when there is nothing to revert.
+var ErrJournalEmpty = errors.New("no operations to undo")
+
+// ⭐ UNDO-001: Journal entry - 🔧
+// JournalEntry records enough state to revert one operation. For file
+// modifications the previous content is kept verbatim together with a checksum
+// of the content the operation produced, so undo can detect later edits.
+type JournalEntry struct {
+	Time            time.Time `json:"time"`
+	Operation       string    `json:"operation"`
+	Description     string    `json:"description"`
+	Path            string    `json:"path"`
+	PreviousExisted bool      `json:"previous_existed"`
+	PreviousContent []byte    `json:"previous_content,omitempty"`
+	ResultChecksum  string    `json:"result_checksum,omitempty"`
+	TrashPath       string    `json:"trash_path,omitempty"`
+}
+
+// ⭐ UNDO-001: Operation journal - 🔧
+// Journal is a bounded, file-backed list of recent operations, newest last.
+type Journal struct {
+	Path    string         `json:"-"`
+	Entries []JournalEntry `json:"entries"`
+	// ⭐ SUDO-OWNER-001: Config decides who owns the journal after Save
+	Config *Config `json:"-"`
+}
+
+// ⭐ UNDO-001: Journal location - 🔧
+// JournalPath returns the journal file location: $BKPDIR_JOURNAL if set,
+// otherwise journal.json in the state directory. A journal still in the
+// user configuration directory of earlier layouts is used until migrated.
+func JournalPath() (string, error) {
+	if p := os.Getenv(journalEnvVar); p != "" {
+		return p, nil
+	}
+	dir, err := StateDir()
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, journalName)
+	// ⭐ XDG-001: Journals of earlier layouts
+	if configDir, err := os.UserConfigDir(); err == nil && os.Getenv(stateDirEnvVar) == "" {
+		path = unmigrated(path, filepath.Join(configDir, userDirName, journalName))
+	}
+	return path, nil
+}
+
+// ⭐ UNDO-001: Journal loading - 🔧
+// LoadJournal reads the journal at path; a missing file yields an empty journal.
+func LoadJournal(path string) (*Journal, error) {
+	j := &Journal{Path: path}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return j, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read journal: %w", err)
+	}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("failed to parse journal %s: %w", path, err)
+	}
+	return j, nil
+}
+
+// ⭐ UNDO-001: Journal persistence - 🔧
+// Save writes the journal atomically, keeping only the newest entries.
+func (j *Journal) Save() error {
+	if len(j.Entries) > journalMaxEntries {
+		j.Entries = j.Entries[len(j.Entries)-journalMaxEntries:]
+	}
+	data, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode journal: %w", err)
+	}
+	if err := fileops.MkdirAll(filepath.Dir(j.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create journal directory: %w", err)
+	}
+	if err := fileops.AtomicWriteFile(j.Path, data, 0644); err != nil {
+		return err
+	}
+	// ⭐ SUDO-OWNER-001: Keep the journal writable without sudo
+	applyInvokingUserOwnership(j.Config, j.Path)
+	return nil
+}
+
+// Last returns the most recent entry, if any.
+func (j *Journal) Last() (JournalEntry, bool) {
+	if len(j.Entries) == 0 {
+		return JournalEntry{}, false
+	}
+	return j.Entries[len(j.Entries)-1], true
+}
+
+// ⭐ UNDO-001: File modification recording - 🔧
+// RecordFileChange appends an entry for a file that was just rewritten.
+// previous and previousExisted describe the file before the operation; cfg
+// may be nil.
+func RecordFileChange(cfg *Config, operation, description, path string, previous []byte, previousExisted bool) error {
+	journalPath, err := JournalPath()
+	if err != nil {
+		return err
+	}
+	j, err := LoadJournal(journalPath)
+	if err != nil {
+		return err
+	}
+	j.Config = cfg
+
+	current, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read %s for journal: %w", path, err)
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		absPath = path
+	}
+
+	j.Entries = append(j.Entries, JournalEntry{
+		Time:            time.Now(),
+		Operation:       operation,
+		Description:     description,
+		Path:            absPath,
+		PreviousExisted: previousExisted,
+		PreviousContent: previous,
+		ResultChecksum:  contentChecksum(current),
+	})
+	return j.Save()
+}
+
+// ⭐ TRASH-001: Trash move recording - 🔧
+// recordTrashMove appends an entry for a file moved into the trash area.
+func recordTrashMove(cfg *Config, item TrashItem, trashPath string) error {
+	journalPath, err := JournalPath()
+	if err != nil {
+		return err
+	}
+	j, err := LoadJournal(journalPath)
+	if err != nil {
+		return err
+	}
+	j.Config = cfg
+	absTrash, err := filepath.Abs(trashPath)
+	if err != nil {
+		absTrash = trashPath
+	}
+	j.Entries = append(j.Entries, JournalEntry{
+		Time:        item.TrashedAt,
+		Operation:   JournalOpTrash,
+		Description: "trash " + filepath.Base(item.OriginalPath),
+		Path:        item.OriginalPath,
+		TrashPath:   absTrash,
+	})
+	return j.Save()
+}
+
+// ⭐ UNDO-001: Undo implementation - 🔧
+// Undo reverts the most recent journaled operation and removes it from the
+// journal. Unless force is set, it refuses when the file changed after the
+// operation so later manual edits are not silently lost.
+func (j *Journal) Undo(force bool) (JournalEntry, error) {
+	entry, ok := j.Last()
+	if !ok {
+		return JournalEntry{}, ErrJournalEmpty
+	}
+
+	switch entry.Operation {
+	case JournalOpConfigSet:
+		if err := revertFileChange(entry, force); err != nil {
+			return entry, err
+		}
+	case JournalOpTrash:
+		// ⭐ TRASH-001: Undo a move into the trash area
+		if _, err := os.Lstat(entry.TrashPath); err != nil {
+			return entry, fmt.Errorf("%s is no longer in the trash (restored or emptied)", entry.Path)
+		}
+		if err := restoreTrashedPath(entry.TrashPath, entry.Path); err != nil {
+			return entry, err
+		}
+	default:
+		return entry, fmt.Errorf("cannot undo unsupported operation %q", entry.Operation)
+	}
+
+	j.Entries = j.Entries[:len(j.Entries)-1]
+	return entry, j.Save()
+}
+
+// revertFileChange restores or removes the file recorded in entry.
+func revertFileChange(entry JournalEntry, force bool) error {
+	// ⭐ CFG-WRITE-001: Serialize with config set
+	lock, err := fileops.LockFile(entry.Path, configLockTimeout)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	current, err := os.ReadFile(entry.Path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to read %s: %w", entry.Path, err)
+	}
+	if !force && (err != nil || contentChecksum(current) != entry.ResultChecksum) {
+		return fmt.Errorf("%s was modified after %s; use --force to undo anyway", entry.Path, entry.Description)
+	}
+
+	if !entry.PreviousExisted {
+		if err := fileops.Remove(entry.Path); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove %s: %w", entry.Path, err)
+		}
+		return nil
+	}
+	return fileops.AtomicWriteFile(entry.Path, entry.PreviousContent, 0644)
+}
+
+func contentChecksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,103 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the operation journal.
+// It verifies that journaled changes can be undone safely.
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ⭐ UNDO-001: Journal and undo tests - 🔧
+func TestJournalUndo(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(journalEnvVar, filepath.Join(dir, "journal.json"))
+	target := filepath.Join(dir, ".bkpdir.yml")
+
+	write := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(target, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	load := func() *Journal {
+		t.Helper()
+		path, err := JournalPath()
+		if err != nil {
+			t.Fatal(err)
+		}
+		j, err := LoadJournal(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return j
+	}
+
+	// First change creates the file, second modifies it
+	write("a: 1\n")
+	if err := RecordFileChange(nil, JournalOpConfigSet, "config a 1", target, nil, false); err != nil {
+		t.Fatalf("RecordFileChange failed: %v", err)
+	}
+	write("a: 2\n")
+	if err := RecordFileChange(nil, JournalOpConfigSet, "config a 2", target, []byte("a: 1\n"), true); err != nil {
+		t.Fatalf("RecordFileChange failed: %v", err)
+	}
+
+	t.Run("restores previous content", func(t *testing.T) {
+		entry, err := load().Undo(false)
+		if err != nil {
+			t.Fatalf("Undo failed: %v", err)
+		}
+		if entry.Description != "config a 2" {
+			t.Errorf("Undid wrong entry: %s", entry.Description)
+		}
+		if data, _ := os.ReadFile(target); string(data) != "a: 1\n" {
+			t.Errorf("Expected previous content, got %q", data)
+		}
+	})
+
+	t.Run("refuses after manual edits", func(t *testing.T) {
+		write("a: 1\nb: manual\n")
+		if _, err := load().Undo(false); err == nil {
+			t.Fatal("Expected undo to refuse after manual edit")
+		}
+		if data, _ := os.ReadFile(target); string(data) != "a: 1\nb: manual\n" {
+			t.Error("Refused undo must not touch the file")
+		}
+	})
+
+	t.Run("force removes file that did not exist", func(t *testing.T) {
+		if _, err := load().Undo(true); err != nil {
+			t.Fatalf("Forced undo failed: %v", err)
+		}
+		if _, err := os.Stat(target); !os.IsNotExist(err) {
+			t.Error("Expected file created by the first change to be removed")
+		}
+	})
+
+	t.Run("empty journal", func(t *testing.T) {
+		if _, err := load().Undo(false); !errors.Is(err, ErrJournalEmpty) {
+			t.Errorf("Expected ErrJournalEmpty, got %v", err)
+		}
+	})
+}
+
+func TestJournalBounded(t *testing.T) {
+	j := &Journal{Path: filepath.Join(t.TempDir(), "journal.json")}
+	for i := 0; i < journalMaxEntries+5; i++ {
+		j.Entries = append(j.Entries, JournalEntry{Operation: JournalOpConfigSet})
+	}
+	if err := j.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	loaded, err := LoadJournal(j.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(loaded.Entries) != journalMaxEntries {
+		t.Errorf("Expected %d entries, got %d", journalMaxEntries, len(loaded.Entries))
+	}
+}
@@ -0,0 +1,183 @@
+// This file is part of bkpdir
+//
+// Package main provides JUnit XML reports for `bkpdir verify` and
+// `bkpdir selftest`, so CI systems such as Jenkins and GitLab can show
+// backup verification as test results: one test case per verified archive
+// or self-test step.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ JUNIT-001: Report formats - 🔧
+// ReportFormatJUnit writes JUnit XML.
+const ReportFormatJUnit = "junit"
+
+// parseReportSpec parses a --report value of the form FORMAT=FILE. An
+// empty value requests no report.
+func parseReportSpec(cfg *Config, spec string) (format, path string, err error) {
+	if spec == "" {
+		return "", "", nil
+	}
+	format, path, ok := strings.Cut(spec, "=")
+	if !ok || path == "" {
+		return "", "", NewArchiveError(fmt.Sprintf("Invalid --report %q (use junit=FILE)", spec), cfg.StatusConfigError)
+	}
+	if format != ReportFormatJUnit {
+		return "", "", NewArchiveError(fmt.Sprintf("Unknown --report format %q (use junit)", format), cfg.StatusConfigError)
+	}
+	return format, path, nil
+}
+
+// junitTestSuites is the document element of a JUnit report.
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Name     string           `xml:"name,attr"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+// junitTestSuite is the one suite of a report.
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Hostname  string          `xml:"hostname,attr,omitempty"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+// junitTestCase is one verified archive or self-test step.
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *junitProblem `xml:"skipped,omitempty"`
+}
+
+// junitProblem is the failure, error or reason for skipping of a test case.
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Type    string `xml:"type,attr,omitempty"`
+	Detail  string `xml:",chardata"`
+}
+
+// ⭐ JUNIT-001: Test result collection - 🔧
+// junitReport collects the test cases of a command and writes them to
+// path. A nil report ignores all calls, so results can be recorded
+// unconditionally.
+type junitReport struct {
+	path      string
+	suite     string
+	className string
+	started   time.Time
+	cases     []junitTestCase
+}
+
+// newJUnitReport returns a report of the command, written to path, or nil
+// when path is empty.
+func newJUnitReport(command, path string) *junitReport {
+	if path == "" {
+		return nil
+	}
+	return &junitReport{path: path, suite: "bkpdir " + command, className: "bkpdir." + command, started: time.Now()}
+}
+
+// pass records a successful test case.
+func (r *junitReport) pass(name string, d time.Duration) {
+	r.add(junitTestCase{Name: name}, d)
+}
+
+// fail records a test case that found a problem, such as an archive that
+// does not verify; details lists what is wrong.
+func (r *junitReport) fail(name string, d time.Duration, message string, details ...string) {
+	r.add(junitTestCase{Name: name, Failure: &junitProblem{Message: message, Type: "verification",
+		Detail: strings.Join(details, "\n")}}, d)
+}
+
+// errored records a test case that could not be carried out.
+func (r *junitReport) errored(name string, d time.Duration, err error) {
+	r.add(junitTestCase{Name: name, Error: &junitProblem{Message: err.Error(), Type: "error"}}, d)
+}
+
+// skip records a test case that was not run and why.
+func (r *junitReport) skip(name, reason string) {
+	r.add(junitTestCase{Name: name, Skipped: &junitProblem{Message: reason}}, 0)
+}
+
+// add appends c with the duration d.
+func (r *junitReport) add(c junitTestCase, d time.Duration) {
+	if r == nil {
+		return
+	}
+	c.ClassName = r.className
+	c.Time = junitSeconds(d)
+	r.cases = append(r.cases, c)
+}
+
+// empty reports whether no test case was recorded.
+func (r *junitReport) empty() bool {
+	return r != nil && len(r.cases) == 0
+}
+
+// junitSeconds formats d in seconds as JUnit expects.
+func junitSeconds(d time.Duration) string {
+	return fmt.Sprintf("%.3f", d.Seconds())
+}
+
+// ⭐ JUNIT-001: Report writing - 📝
+// write writes the report atomically. A failure only warns, so the outcome
+// of the command, which CI also sees as its exit status, is kept.
+func (r *junitReport) write() {
+	if r == nil {
+		return
+	}
+	suite := junitTestSuite{
+		Name:      r.suite,
+		Tests:     len(r.cases),
+		Time:      junitSeconds(time.Since(r.started)),
+		Timestamp: r.started.UTC().Format("2006-01-02T15:04:05"),
+		Cases:     r.cases,
+	}
+	suite.Hostname, _ = os.Hostname()
+	for _, c := range r.cases {
+		switch {
+		case c.Failure != nil:
+			suite.Failures++
+		case c.Error != nil:
+			suite.Errors++
+		case c.Skipped != nil:
+			suite.Skipped++
+		}
+	}
+	doc := junitTestSuites{Name: r.suite, Tests: suite.Tests, Failures: suite.Failures, Errors: suite.Errors,
+		Skipped: suite.Skipped, Time: suite.Time, Suites: []junitTestSuite{suite}}
+	data, err := xml.MarshalIndent(doc, "", "  ")
+	if err == nil {
+		data = append([]byte(xml.Header), append(data, '\n')...)
+		err = fileops.AtomicWriteFile(r.path, data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write the JUnit report %s: %v\n", r.path, err)
+	}
+}
@@ -0,0 +1,89 @@
+// This file is part of bkpdir
+
+// Package main provides tests for JUnit reports. It verifies the test cases
+// written for verify and selftest.
+package main
+
+import (
+	"encoding/xml"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// readJUnitReport parses the report at path.
+func readJUnitReport(t *testing.T, path string) junitTestSuites {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc junitTestSuites
+	if err := xml.Unmarshal(data, &doc); err != nil || len(doc.Suites) != 1 {
+		t.Fatalf("report %s: %v\n%s", path, err, data)
+	}
+	return doc
+}
+
+// ⭐ JUNIT-001: Verification report - 🧪
+func TestVerifyJUnitReport(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		os.WriteFile(name, []byte(name), 0o644)
+		if err := CreateFullArchive(cfg, "", false, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	archives, _ := ListArchives(archiveDir)
+	if len(archives) != 3 {
+		t.Fatalf("archives = %+v", archives)
+	}
+	report := filepath.Join(t.TempDir(), "verify.xml")
+	opts := VerifyOptions{Config: cfg, Formatter: NewOutputFormatter(cfg), Report: newJUnitReport("verify", report)}
+	if err := VerifyArchiveEnhanced(opts); err != nil {
+		t.Fatal(err)
+	}
+	opts.Report.write()
+	doc := readJUnitReport(t, report)
+	if doc.Tests != 3 || doc.Failures != 0 || doc.Suites[0].Cases[0].ClassName != "bkpdir.verify" {
+		t.Errorf("report = %+v", doc)
+	}
+
+	// The corrupt archive fails and --fail-fast skips the others
+	os.WriteFile(archives[0].Path, []byte("not a zip"), 0o644)
+	opts.FailFast = true
+	opts.Report = newJUnitReport("verify", report)
+	if err := VerifyArchiveEnhanced(opts); err == nil {
+		t.Fatal("a corrupt archive verified")
+	}
+	opts.Report.write()
+	doc = readJUnitReport(t, report)
+	cases := doc.Suites[0].Cases
+	if doc.Tests != 3 || doc.Failures != 1 || doc.Skipped != 2 || cases[0].Name != archives[0].Name ||
+		cases[0].Failure == nil || cases[0].Failure.Detail == "" || cases[1].Skipped == nil {
+		t.Errorf("fail-fast report = %+v", doc)
+	}
+}
+
+// ⭐ JUNIT-001: Self-test report - 🧪
+func TestSelftestJUnitReport(t *testing.T) {
+	cfg := DefaultConfig()
+	report := filepath.Join(t.TempDir(), "selftest.xml")
+	opts := SelftestOptions{Config: cfg, Output: io.Discard, Size: "64KB", Report: "junit=" + report}
+	if err := RunSelftest(opts); err != nil {
+		t.Fatal(err)
+	}
+	doc := readJUnitReport(t, report)
+	if doc.Tests != 5 || doc.Failures+doc.Errors+doc.Skipped != 0 || doc.Suites[0].Cases[0].Name != "generate" {
+		t.Errorf("report = %+v", doc)
+	}
+
+	for _, spec := range []string{"junit", "junit=", "xml=report.xml"} {
+		opts.Report = spec
+		if err := RunSelftest(opts); err == nil {
+			t.Errorf("--report %q accepted", spec)
+		}
+	}
+}
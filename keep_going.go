@@ -0,0 +1,127 @@
+// This file is part of bkpdir
+//
+// Package main provides keep-going archive creation, where files that cannot
+// be read are recorded and skipped instead of aborting the whole archive.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	bkperrors "bkpdir/pkg/errors"
+)
+
+// ⭐ KEEP-GOING-001: Per-file failure record - 📝
+// FileFailure is a file that could not be archived in keep-going mode.
+type FileFailure struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+	// ⭐ MANIFEST-AUDIT-001: Kind of failure, as the summary groups it
+	Category string `json:"category,omitempty"`
+
+	err error // Original error, kept for classification
+}
+
+// newFileFailure records that rel could not be archived because of err.
+func newFileFailure(rel string, err error) FileFailure {
+	return FileFailure{
+		Path:     rel,
+		Error:    err.Error(),
+		Category: bkperrors.NewDefaultErrorClassifier().ClassifyError(err).String(),
+		err:      err,
+	}
+}
+
+// fileSourceError marks an error reading a file that is being archived, as
+// opposed to an error writing the archive itself. Only source errors are
+// skipped in keep-going mode.
+type fileSourceError struct {
+	Err error
+}
+
+func (e *fileSourceError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *fileSourceError) Unwrap() error {
+	return e.Err
+}
+
+// sourceReader wraps read errors of an archived file in fileSourceError.
+type sourceReader struct {
+	r io.Reader
+}
+
+func (s sourceReader) Read(p []byte) (int, error) {
+	n, err := s.r.Read(p)
+	if err != nil && err != io.EOF {
+		err = &fileSourceError{Err: err}
+	}
+	return n, err
+}
+
+// archivedFiles returns files without the paths that failed.
+func archivedFiles(files []string, failures []FileFailure) []string {
+	if len(failures) == 0 {
+		return files
+	}
+	failed := make(map[string]bool, len(failures))
+	for _, f := range failures {
+		failed[f.Path] = true
+	}
+	kept := make([]string, 0, len(files)-len(failures))
+	for _, rel := range files {
+		if !failed[rel] {
+			kept = append(kept, rel)
+		}
+	}
+	return kept
+}
+
+// ⭐ KEEP-GOING-001: Partial archive summary - 🔧
+// partialArchiveError lists the skipped files on stderr, grouped by the kind
+// of failure, and returns the error
+// that makes the command exit with status_partial_archive. It returns nil when
+// nothing was skipped.
+func partialArchiveError(archivePath string, total int, failures []FileFailure, cfg ArchiveConfigInterface) error {
+	if len(failures) == 0 {
+		return nil
+	}
+	fmt.Fprintf(os.Stderr, "Warning: %d of %d files could not be archived:\n", len(failures), total)
+	fmt.Fprint(os.Stderr, failureList(failures).Format(archiveTemplateFormatter(cfg), "  %{category} (%{count}):\n", "    %{context}: %{error}\n"))
+	return NewArchiveError(
+		fmt.Sprintf("Archive %s is incomplete: %d files skipped (listed in its manifest)", archivePath, len(failures)),
+		cfg.GetStatusPartialArchive(),
+	)
+}
+
+// ⭐ KEEP-GOING-001: Skipped files as an error list - 🔧
+// failureList collects failures into a MultiError so they can be grouped and
+// matched with errors.Is.
+func failureList(failures []FileFailure) *bkperrors.MultiError {
+	list := bkperrors.NewMultiError("keep-going")
+	for _, f := range failures {
+		err := f.err
+		if err == nil {
+			// Failures loaded from a manifest only carry the message
+			err = errors.New(f.Error)
+		}
+		list.Add(f.Path, err)
+	}
+	return list
+}
+
+// archiveTemplateFormatter returns a template formatter for cfg, falling back
+// to the defaults when cfg is not backed by a Config.
+func archiveTemplateFormatter(cfg ArchiveConfigInterface) *TemplateFormatter {
+	if adapter, ok := cfg.(*ConfigToArchiveConfigAdapter); ok {
+		return NewTemplateFormatter(adapter.cfg)
+	}
+	return NewTemplateFormatter(DefaultConfig())
+}
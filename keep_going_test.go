@@ -0,0 +1,83 @@
+// This file is part of bkpdir
+
+// Package main provides tests for keep-going archive creation.
+// It verifies that unreadable files are skipped, recorded and reported.
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bkpdir/pkg/processing"
+)
+
+// ⭐ KEEP-GOING-001: Skipping unreadable files - 🛡️
+func TestKeepGoingSkipsUnreadableFiles(t *testing.T) {
+	sourceDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(sourceDir, "good.txt"), []byte("good"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("missing-target", filepath.Join(sourceDir, "broken")); err != nil {
+		t.Fatal(err)
+	}
+	// vanished.txt was collected but removed before it could be archived
+	files := []string{"good.txt", "broken", "vanished.txt"}
+	archivePath := filepath.Join(t.TempDir(), "test.zip")
+
+	cfg := DefaultConfig()
+	adapter := &ConfigToArchiveConfigAdapter{cfg: cfg}
+	if _, err := createZipArchiveWithContextAndConfig(context.Background(), sourceDir, archivePath, files, adapter); err == nil {
+		t.Fatal("Expected the first unreadable file to abort without keep-going")
+	}
+
+	cfg.KeepGoing = true
+	failures, err := createZipArchiveWithContextAndConfig(context.Background(), sourceDir, archivePath, files, adapter)
+	if err != nil {
+		t.Fatalf("Expected keep-going to complete the archive, got %v", err)
+	}
+	var failed []string
+	for _, f := range failures {
+		failed = append(failed, f.Path)
+	}
+	if want := []string{"broken", "vanished.txt"}; !reflect.DeepEqual(failed, want) {
+		t.Errorf("failures = %v, want %v", failed, want)
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reader.Close()
+	if len(reader.File) != 1 || reader.File[0].Name != "good.txt" {
+		t.Errorf("Expected only good.txt in the archive, got %d entries", len(reader.File))
+	}
+
+	if got := archivedFiles(files, failures); !reflect.DeepEqual(got, []string{"good.txt"}) {
+		t.Errorf("archivedFiles = %v", got)
+	}
+
+	commitSidecars(t, archivePath, func(txn *processing.Transaction) {
+		recordArchiveManifest(txn, archivePath, archivedFiles(files, failures), failures, nil, "", nil, nil, nil)
+	})
+	manifest, err := LoadArchiveManifest(archivePath)
+	if err != nil || manifest == nil || len(manifest.FailedFiles) != 2 {
+		t.Fatalf("Expected failed files in the manifest, got %+v (%v)", manifest, err)
+	}
+
+	err = partialArchiveError(archivePath, len(files), failures, adapter)
+	var archiveErr *ArchiveError
+	if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusPartialArchive {
+		t.Errorf("Expected partial archive status %d, got %v", cfg.StatusPartialArchive, err)
+	}
+	if list := failureList(failures); !errors.Is(list, os.ErrNotExist) {
+		t.Errorf("Expected the failure list to match os.ErrNotExist, got %v", list)
+	}
+	if partialArchiveError(archivePath, len(files), nil, adapter) != nil {
+		t.Error("Expected no error when nothing was skipped")
+	}
+}
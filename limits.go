@@ -0,0 +1,168 @@
+// This file is part of bkpdir
+//
+// Package main provides resource limits for archive creation so an
+// unexpectedly large file or file set is reported instead of silently
+// inflating a backup.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ⭐ LIMIT-001: Limit actions - 🔧
+const (
+	// LimitActionWarn skips oversized files and warns about oversized sets.
+	LimitActionWarn = "warn"
+	// LimitActionFail aborts the archive when any limit is exceeded.
+	LimitActionFail = "fail"
+)
+
+// ErrResourceLimit is wrapped by errors returned for exceeded hard limits.
+var ErrResourceLimit = errors.New("resource limit exceeded")
+
+// ResourceLimits holds the parsed limits; zero values mean unlimited.
+type ResourceLimits struct {
+	MaxFileSize  int64
+	MaxFileCount int
+	MaxTotalSize int64
+	Fail         bool
+}
+
+// byteSizeUnits are the binary multipliers accepted by ParseByteSize, matching
+// the units used when sizes are printed.
+var byteSizeUnits = map[string]int64{
+	"":   1,
+	"B":  1,
+	"K":  1 << 10,
+	"KB": 1 << 10,
+	"M":  1 << 20,
+	"MB": 1 << 20,
+	"G":  1 << 30,
+	"GB": 1 << 30,
+	"T":  1 << 40,
+	"TB": 1 << 40,
+}
+
+// ⭐ LIMIT-001: Size parsing - 🔧
+// ParseByteSize parses sizes such as "500MB", "1.5GB" or "4096". Units are
+// binary (1KB = 1024 bytes) and case-insensitive; "KiB"-style suffixes are
+// accepted as well. An empty string parses as 0.
+func ParseByteSize(s string) (int64, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, nil
+	}
+	upper := strings.ReplaceAll(strings.ToUpper(s), "IB", "B")
+	split := len(upper)
+	for split > 0 && (upper[split-1] < '0' || upper[split-1] > '9') {
+		split--
+	}
+	number, unit := strings.TrimSpace(upper[:split]), strings.TrimSpace(upper[split:])
+	multiplier, ok := byteSizeUnits[unit]
+	if !ok {
+		return 0, fmt.Errorf("invalid size %q: unknown unit %q", s, unit)
+	}
+	value, err := strconv.ParseFloat(number, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid size %q", s)
+	}
+	return int64(value * float64(multiplier)), nil
+}
+
+// ⭐ LIMIT-001: Limit configuration - 🔧
+// resourceLimitsFromConfig parses the limit settings of cfg.
+func resourceLimitsFromConfig(cfg *Config) (ResourceLimits, error) {
+	maxFile, err := ParseByteSize(cfg.MaxFileSize)
+	if err != nil {
+		return ResourceLimits{}, fmt.Errorf("max_file_size: %w", err)
+	}
+	maxTotal, err := ParseByteSize(cfg.MaxTotalSize)
+	if err != nil {
+		return ResourceLimits{}, fmt.Errorf("max_total_size: %w", err)
+	}
+	if cfg.MaxFileCount < 0 {
+		return ResourceLimits{}, fmt.Errorf("max_file_count must not be negative")
+	}
+	switch cfg.LimitAction {
+	case LimitActionWarn, LimitActionFail, "":
+	default:
+		return ResourceLimits{}, fmt.Errorf("limit_action must be %s or %s, got: %s", LimitActionWarn, LimitActionFail, cfg.LimitAction)
+	}
+	return ResourceLimits{
+		MaxFileSize:  maxFile,
+		MaxFileCount: cfg.MaxFileCount,
+		MaxTotalSize: maxTotal,
+		Fail:         cfg.LimitAction == LimitActionFail,
+	}, nil
+}
+
+// ⭐ LIMIT-001: Candidate set limits - 🛡️
+// ApplyResourceLimits checks the candidate files (relative to sourceDir)
+// against limits. With soft limits, files over MaxFileSize are dropped with a
+// warning and exceeded set limits only warn; with hard limits the first
+// exceeded limit is returned as an ErrResourceLimit.
+func ApplyResourceLimits(sourceDir string, files []string, limits ResourceLimits) ([]string, error) {
+	if limits.MaxFileSize == 0 && limits.MaxFileCount == 0 && limits.MaxTotalSize == 0 {
+		return files, nil
+	}
+
+	kept := make([]string, 0, len(files))
+	var total int64
+	for _, rel := range files {
+		info, err := os.Stat(filepath.Join(sourceDir, rel))
+		if err != nil || info.IsDir() {
+			// Unreadable entries are left for the archiver to report
+			kept = append(kept, rel)
+			continue
+		}
+		if limits.MaxFileSize > 0 && info.Size() > limits.MaxFileSize {
+			msg := fmt.Sprintf("%s is %s, over max_file_size %s", rel,
+				formatHumanSize(info.Size()), formatHumanSize(limits.MaxFileSize))
+			if limits.Fail {
+				return nil, fmt.Errorf("%w: %s", ErrResourceLimit, msg)
+			}
+			fmt.Fprintf(os.Stderr, "Warning: skipping %s\n", msg)
+			continue
+		}
+		total += info.Size()
+		kept = append(kept, rel)
+	}
+
+	var exceeded []string
+	if limits.MaxFileCount > 0 && len(kept) > limits.MaxFileCount {
+		exceeded = append(exceeded, fmt.Sprintf("%d files, over max_file_count %d", len(kept), limits.MaxFileCount))
+	}
+	if limits.MaxTotalSize > 0 && total > limits.MaxTotalSize {
+		exceeded = append(exceeded, fmt.Sprintf("%s in total, over max_total_size %s",
+			formatHumanSize(total), formatHumanSize(limits.MaxTotalSize)))
+	}
+	for _, msg := range exceeded {
+		if limits.Fail {
+			return nil, fmt.Errorf("%w: %s", ErrResourceLimit, msg)
+		}
+		fmt.Fprintf(os.Stderr, "Warning: archive has %s\n", msg)
+	}
+	return kept, nil
+}
+
+// enforceResourceLimits applies the configured limits during archive creation.
+func enforceResourceLimits(cfg *Config, sourceDir string, files []string) ([]string, error) {
+	limits, err := resourceLimitsFromConfig(cfg)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Invalid resource limits", cfg.StatusConfigError, err)
+	}
+	files, err = ApplyResourceLimits(sourceDir, files, limits)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Resource limit exceeded", cfg.StatusConfigError, err)
+	}
+	return files, nil
+}
@@ -0,0 +1,90 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive resource limits.
+// It verifies size parsing and soft and hard limit handling.
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ⭐ LIMIT-001: Size parsing tests - 🔧
+func TestParseByteSize(t *testing.T) {
+	tests := map[string]int64{
+		"":       0,
+		"4096":   4096,
+		"1KB":    1024,
+		"1.5 kb": 1536,
+		"2MiB":   2 << 20,
+		"1g":     1 << 30,
+		"1TB":    1 << 40,
+	}
+	for in, want := range tests {
+		if got, err := ParseByteSize(in); err != nil || got != want {
+			t.Errorf("ParseByteSize(%q) = %d, %v; want %d", in, got, err, want)
+		}
+	}
+	for _, in := range []string{"MB", "12XB", "-1KB", "1.2.3"} {
+		if _, err := ParseByteSize(in); err == nil {
+			t.Errorf("ParseByteSize(%q) should fail", in)
+		}
+	}
+}
+
+// ⭐ LIMIT-001: Soft and hard limit tests - 🛡️
+func TestApplyResourceLimits(t *testing.T) {
+	dir := t.TempDir()
+	sizes := map[string]int{"small.txt": 10, "medium.txt": 100, "core": 5000}
+	for name, size := range sizes {
+		if err := os.WriteFile(filepath.Join(dir, name), make([]byte, size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files := []string{"small.txt", "medium.txt", "core"}
+
+	t.Run("Unlimited", func(t *testing.T) {
+		got, err := ApplyResourceLimits(dir, files, ResourceLimits{})
+		if err != nil || !reflect.DeepEqual(got, files) {
+			t.Errorf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("SoftFileSize", func(t *testing.T) {
+		got, err := ApplyResourceLimits(dir, files, ResourceLimits{MaxFileSize: 1000})
+		want := []string{"small.txt", "medium.txt"}
+		if err != nil || !reflect.DeepEqual(got, want) {
+			t.Errorf("got %v, %v; want %v", got, err, want)
+		}
+	})
+
+	t.Run("SoftSetLimitsWarnOnly", func(t *testing.T) {
+		got, err := ApplyResourceLimits(dir, files, ResourceLimits{MaxFileCount: 1, MaxTotalSize: 50})
+		if err != nil || len(got) != len(files) {
+			t.Errorf("got %v, %v", got, err)
+		}
+	})
+
+	t.Run("Hard", func(t *testing.T) {
+		for name, limits := range map[string]ResourceLimits{
+			"file size":  {MaxFileSize: 1000, Fail: true},
+			"file count": {MaxFileCount: 2, Fail: true},
+			"total size": {MaxTotalSize: 1000, Fail: true},
+		} {
+			if _, err := ApplyResourceLimits(dir, files, limits); !errors.Is(err, ErrResourceLimit) {
+				t.Errorf("%s: expected ErrResourceLimit, got %v", name, err)
+			}
+		}
+	})
+
+	t.Run("InvalidConfig", func(t *testing.T) {
+		cfg := DefaultConfig()
+		cfg.MaxFileSize = "lots"
+		if _, err := enforceResourceLimits(cfg, dir, files); err == nil {
+			t.Error("Expected error for invalid max_file_size")
+		}
+	})
+}
@@ -0,0 +1,151 @@
+// This file is part of bkpdir
+//
+// Package main lists archives as incremental chains with `list --tree`: each
+// full archive is followed by the incremental archives based on it, with the
+// length and total size of the chain. Chains whose full archive was pruned
+// or deleted are flagged, as their incremental archives cannot be restored
+// on their own.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"bkpdir/pkg/formatter"
+)
+
+// ⭐ LIST-TREE-001: Chains in listing order - 🔍
+// listChains groups archives into chains, the most recent first, with the
+// full archive of each chain before its incremental archives, oldest first.
+func listChains(archives []Archive) []*archiveChain {
+	chains := archiveChains(archives)
+	for _, chain := range chains {
+		sort.SliceStable(chain.Archives, func(i, j int) bool {
+			a, b := chain.Archives[i], chain.Archives[j]
+			if a.IsIncremental != b.IsIncremental {
+				return !a.IsIncremental
+			}
+			return a.CreationTime.Before(b.CreationTime)
+		})
+	}
+	sort.SliceStable(chains, func(i, j int) bool { return chains[i].Created.After(chains[j].Created) })
+	return chains
+}
+
+// paginateChains returns the archives of the window of chains starting at
+// offset with at most limit chains, so a chain is never split across pages.
+// A limit of 0 means no limit.
+func paginateChains(archives []Archive, offset, limit int) []Archive {
+	chains := listChains(archives)
+	if offset >= len(chains) {
+		return nil
+	}
+	chains = chains[offset:]
+	if limit > 0 && limit < len(chains) {
+		chains = chains[:limit]
+	}
+	var page []Archive
+	for _, chain := range chains {
+		page = append(page, chain.Archives...)
+	}
+	return page
+}
+
+// chainBase returns the full archive of a chain, or nil when only its
+// incremental archives are left.
+func chainBase(chain *archiveChain) *Archive {
+	if len(chain.Archives) > 0 && !chain.Archives[0].IsIncremental {
+		return &chain.Archives[0]
+	}
+	return nil
+}
+
+// prunedArchives returns the trash items of the archives moved to the
+// trash from archiveDir, by archive name.
+func prunedArchives(cfg *Config, archiveDir string) map[string]TrashItem {
+	items, err := ListTrash(cfg)
+	if err != nil || isRemoteLocation(archiveDir) {
+		return nil
+	}
+	dir, err := filepath.Abs(archiveDir)
+	if err != nil {
+		return nil
+	}
+	pruned := make(map[string]TrashItem)
+	for _, item := range items {
+		if filepath.Dir(item.OriginalPath) == dir {
+			pruned[filepath.Base(item.OriginalPath)] = item
+		}
+	}
+	return pruned
+}
+
+// ⭐ LIST-TREE-001: Incremental chains - 🔍
+// printArchiveChains prints archives as chains: the full archive with the
+// length and size of its chain, then its incremental archives indented
+// below it. A chain without its full archive gets a line flagging the base
+// as pruned, when it is in the trash, or missing.
+func printArchiveChains(cfg *Config, formatter formatter.OutputFormatterInterface, archiveDir string, archives []Archive, width int) {
+	var pruned map[string]TrashItem
+	broken := 0
+	printLine := func(output, status string) {
+		if formatterAdapter, ok := formatter.(*FormatterAdapter); ok {
+			formatterAdapter.PrintArchiveListWithStatus(output, status)
+		} else {
+			fmt.Printf("%s%s\n", output, status)
+		}
+	}
+
+	for _, chain := range listChains(archives) {
+		summary := fmt.Sprintf(" [chain: %d %s, %s]", len(chain.Archives), pluralArchives(len(chain.Archives)),
+			formatHumanSize(chain.Size))
+		incrementals := chain.Archives
+		if base := chainBase(chain); base != nil {
+			printLine(archiveListLine(formatter, *base, "", summary, width))
+			incrementals = incrementals[1:]
+		} else {
+			broken++
+			if pruned == nil {
+				pruned = prunedArchives(cfg, archiveDir)
+			}
+			flag := " [BASE MISSING]"
+			if item, ok := pruned[chain.Name]; ok {
+				flag = fmt.Sprintf(" [BASE PRUNED %s, in the trash as %s]", item.TrashedAt.Format("2006-01-02"), item.Name)
+			}
+			printLine(chain.Name, flag+summary)
+		}
+		for i, a := range incrementals {
+			prefix := "├── "
+			if i == len(incrementals)-1 {
+				prefix = "└── "
+			}
+			printLine(archiveListLine(formatter, a, prefix, "", width))
+		}
+	}
+	if broken > 0 {
+		fmt.Fprintf(os.Stderr, "Note: %d %s without a full archive; their incremental archives cannot be restored (see bkpdir trash list)\n",
+			broken, pluralChains(broken))
+	}
+}
+
+// pluralArchives returns "archive" or "archives" for n.
+func pluralArchives(n int) string {
+	if n == 1 {
+		return "archive"
+	}
+	return "archives"
+}
+
+// pluralChains returns "chain" or "chains" for n.
+func pluralChains(n int) string {
+	if n == 1 {
+		return "chain"
+	}
+	return "chains"
+}
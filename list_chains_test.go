@@ -0,0 +1,93 @@
+// This file is part of bkpdir
+
+// Package main provides tests for listing archives as incremental chains.
+// It verifies the grouping, the chain summaries and the flags of chains
+// whose full archive is gone.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ LIST-TREE-001: Incremental chains in listings - 🧪
+func TestListArchiveChains(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(journalEnvVar, filepath.Join(dir, "journal.json"))
+	archiveDir := filepath.Join(dir, "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.TrashDirPath = filepath.Join(dir, "trash")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	for i, name := range []string{
+		"proj-2024-03-01-10-00.zip",
+		"proj-2024-03-01-10-00_update=2024-03-02-10-00.zip",
+		"proj-2024-03-01-10-00_update=2024-03-03-10-00.zip",
+		"proj-2024-03-04-10-00.zip",
+		"proj-2024-03-04-10-00_update=2024-03-05-10-00.zip",
+		"proj-2024-03-06-10-00.zip",
+		"proj-2024-03-06-10-00_update=2024-03-07-10-00.zip",
+	} {
+		path := filepath.Join(archiveDir, name)
+		if err := os.WriteFile(path, []byte(strings.Repeat("z", 100)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := start.AddDate(0, 0, i)
+		if err := os.Chtimes(path, modTime, modTime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := MoveToTrash(cfg, filepath.Join(archiveDir, "proj-2024-03-04-10-00.zip")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(archiveDir, "proj-2024-03-06-10-00.zip")); err != nil {
+		t.Fatal(err)
+	}
+
+	list := func(opts ListOptions) string {
+		t.Helper()
+		stdout, err := os.CreateTemp(t.TempDir(), "stdout")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func(orig *os.File) { os.Stdout = orig }(os.Stdout)
+		os.Stdout = stdout
+		opts.Config, opts.Formatter, opts.Tree = cfg, NewOutputFormatter(cfg), true
+		err = ListArchivesWithOptions(opts)
+		os.Stdout.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+		out, _ := os.ReadFile(stdout.Name())
+		return string(out)
+	}
+
+	lines := strings.Split(strings.TrimSpace(list(ListOptions{})), "\n")
+	for i, want := range []string{
+		"proj-2024-03-06-10-00.zip [BASE MISSING] [chain: 1 archive, 100B]",
+		"└── proj-2024-03-06-10-00_update=2024-03-07-10-00.zip",
+		"proj-2024-03-04-10-00.zip [BASE PRUNED ",
+		"└── proj-2024-03-04-10-00_update=2024-03-05-10-00.zip",
+		"proj-2024-03-01-10-00.zip",
+		"├── proj-2024-03-01-10-00_update=2024-03-02-10-00.zip",
+		"└── proj-2024-03-01-10-00_update=2024-03-03-10-00.zip",
+	} {
+		if i >= len(lines) || !strings.HasPrefix(lines[i], want) {
+			t.Fatalf("line %d does not start with %q:\n%s", i+1, want, strings.Join(lines, "\n"))
+		}
+	}
+	if !strings.HasSuffix(lines[4], "[chain: 3 archives, 300B]") {
+		t.Errorf("base line = %q", lines[4])
+	}
+
+	// Pages never split a chain
+	if out := list(ListOptions{Offset: 2, Limit: 1}); strings.Count(out, "\n") != 3 ||
+		!strings.HasPrefix(out, "proj-2024-03-01-10-00.zip") {
+		t.Errorf("third chain:\n%s", out)
+	}
+}
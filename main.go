@@ -11,17 +11,25 @@ package main
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"os/signal"
+	"path"
 	"path/filepath"
+	"reflect"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/spf13/cobra"
-	"gopkg.in/yaml.v3"
 
+	"bkpdir/pkg/cli"
+	bkperrors "bkpdir/pkg/errors"
+	"bkpdir/pkg/fileops"
 	"bkpdir/pkg/formatter"
 )
 
@@ -33,15 +41,145 @@ var version = "dev"
 var date = "unknown"
 var commit = "unknown"
 
-// 🔺 CFG-003: Global variables for command configuration - 📝
-var (
-	createNote   string
-	createDryRun bool
-	createVerify bool
-	listFile     string
-	archiveName  string
-	withChecksum bool
-)
+// 🔺 CFG-003: Command options - 📝
+// ⭐ CLI-FLAGS-001: Each command binds its flags to its own options struct
+// with cli.BindFlags, so flags such as --note no longer share storage between
+// commands or keep values from an earlier invocation.
+
+// rootOptions holds the flags of the root command. --dry-run is persistent;
+// subcommands read it with InheritBool.
+type rootOptions struct {
+	DryRun     bool
+	ShowConfig bool
+	ListFile   string
+	Color      string
+	// ⭐ GUARD-001: Refuse writes outside the archive and backup directories
+	ReadOnly bool
+	// ⭐ TERM-WIDTH-001: Keep long lines intact on terminals
+	NoTruncate bool
+}
+
+// autoDetectOptions holds the flags of auto-detected file and directory
+// operations (`bkpdir PATH [NOTE]`).
+type autoDetectOptions struct {
+	Note   string
+	DryRun bool
+}
+
+// archiveCmdOptions holds the flags of full and inc.
+type archiveCmdOptions struct {
+	Note   string
+	DryRun bool
+	// ⭐ EXCLUDE-001: Extra exclusion pattern files for archive creation
+	ExcludeFrom []string
+	// ⭐ KEEP-GOING-001: Skip unreadable files during archive creation
+	KeepGoing bool
+	// ⭐ ARCH-007: Explicit base for incremental archives
+	Base string
+	// ⭐ POWER-001: Run even when power_aware would defer
+	IgnorePower bool
+	// ⭐ FROM-LIST-001: Archive the paths listed in a file ("-" for stdin)
+	FromList string
+	Null     bool
+	// ⭐ VOLATILE-001: Archive changes of volatile files alone
+	IncludeVolatile bool
+	// ⭐ SPLIT-001: One archive per immediate subdirectory
+	SplitByDir bool
+	// ⭐ EXCLUDE-AUDIT-001: Report what the exclusion patterns kept out
+	ExplainExclusions bool
+}
+
+// backupCmdOptions holds the flags of backup.
+type backupCmdOptions struct {
+	Note   string
+	DryRun bool
+	// ⭐ STDIN-001: backup --stdin flags
+	Stdin bool
+	Name  string
+}
+
+// verifyCmdOptions holds the flags of verify that are not verification
+// options themselves.
+type verifyCmdOptions struct {
+	VerifyOptions
+	// ⭐ ARCH-006: Sample size for verification
+	Sample string
+	// ⭐ VERIFY-DIR-001: Directory audited against an archive
+	AgainstDir string
+	// ⭐ SUMS-001: sha256sum-compatible checksum file
+	ChecksumFile string
+	// ⭐ POWER-001: Run even when power_aware would defer
+	IgnorePower bool
+	// ⭐ JUNIT-001: FORMAT=FILE report of the verified archives
+	Report string
+	// ⭐ CHAIN-CHECK-001: Check incremental chains instead of archive contents
+	Chain bool
+}
+
+// ⭐ SUMS-001: Checksum file written by checksum write
+type checksumWriteOptions struct {
+	File string
+}
+
+// undoCmdOptions holds the flags of undo.
+type undoCmdOptions struct {
+	DryRun bool
+	Force  bool
+}
+
+// trashEmptyOptions holds the flags of trash empty.
+type trashEmptyOptions struct {
+	DryRun  bool
+	Expired bool
+}
+
+// configCmdOptions holds the flags of config.
+type configCmdOptions struct {
+	ShowAll       bool
+	OverridesOnly bool
+	Sources       bool
+	Format        string
+	Filter        string
+	Describe      bool
+	// ⭐ CFG-SET-FILE-001: Configuration file written by config KEY VALUE
+	File   string
+	Global bool
+}
+
+// templateCmdOptions holds the flags of template. Its --dry-run is its own,
+// not the persistent flag of the root command.
+type templateCmdOptions struct {
+	Output  string
+	DryRun  bool
+	Force   bool
+	Minimal bool
+	// ⭐ CFG-TEMPLATE-003: Template to stdout or as a diff
+	Stdout bool
+	Diff   bool
+	// ⭐ CFG-TEMPLATE-INHERIT-001: Base file of a layered configuration
+	Inherit string
+}
+
+// dryRunOptions holds the flags of commands that only take --dry-run.
+type dryRunOptions struct {
+	DryRun bool
+}
+
+// bindDryRun binds the inherited --dry-run flag of cmd.
+func bindDryRun(cmd *cobra.Command) *cli.FlagBinding[dryRunOptions] {
+	return cli.BindFlags(cmd, dryRunOptions{}).
+		InheritBool(func(o *dryRunOptions) *bool { return &o.DryRun }, "dry-run")
+}
+
+// commandOptions returns the options bound for this invocation of cmd.
+func commandOptions[T any](flags *cli.FlagBinding[T], cmd *cobra.Command) T {
+	opts, err := flags.Options(cmd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	return opts
+}
 
 // Short description for the main application
 var shortDesc = `bkpdir is a comprehensive backup and archiving solution for directories and files.`
@@ -66,11 +204,9 @@ var (
 	Version     = appVersion // Public version for external access
 )
 
-var (
-	dryRun     bool
-	note       string
-	showConfig bool
-)
+// ⭐ GUARD-001: Refuse writes outside the archive and backup directories;
+// set from --read-only before a command runs
+var readOnly bool
 
 // ⭐ CLI-015: Path type detection for automatic command routing - 🔍
 // isFile checks if the given path is a regular file
@@ -110,7 +246,7 @@ func validatePath(path string) error {
 
 // ⭐ CLI-015: Automatic command routing based on path type - 🔧
 // handleAutoDetectedCommand routes to appropriate command based on first argument type
-func handleAutoDetectedCommand(args []string) {
+func handleAutoDetectedCommand(args []string, opts autoDetectOptions) {
 	if len(args) == 0 {
 		fmt.Fprintf(os.Stderr, "Error: no path provided\n")
 		os.Exit(1)
@@ -127,10 +263,10 @@ func handleAutoDetectedCommand(args []string) {
 	// Determine operation type based on path type
 	if isFile(path) {
 		// Route to file backup operation
-		handleAutoDetectedFileBackup(args)
+		handleAutoDetectedFileBackup(args, opts)
 	} else if isDirectory(path) {
 		// Route to directory archive operation
-		handleAutoDetectedDirectoryArchive(args)
+		handleAutoDetectedDirectoryArchive(args, opts)
 	} else {
 		// Handle special file types (symlinks, devices, etc.)
 		fmt.Fprintf(os.Stderr, "Error: unsupported file type for path: %s\n", path)
@@ -139,9 +275,28 @@ func handleAutoDetectedCommand(args []string) {
 	}
 }
 
+// ⭐ GUARD-001: Read-only mode activation - 🛡️
+// enforceReadOnlyMode restricts writes to the configured archive and backup
+// directories when --read-only is set. Relative directories are resolved
+// against the current directory, as the commands themselves do.
+func enforceReadOnlyMode(cfg *Config) {
+	if !readOnly {
+		return
+	}
+	roots := []string{cfg.ArchiveDirPath, cfg.BackupDirPath}
+	if cfg.UseHomeRelativePaths {
+		// ⭐ HOME-REL-001: Home-relative backups are written below the home directory
+		roots = append(roots, homeBackupRoot(cfg.BackupDirPath))
+	}
+	if err := fileops.EnableWriteGuard(roots...); err != nil {
+		fmt.Fprintf(os.Stderr, "Error enabling read-only mode: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+}
+
 // ⭐ CLI-015: Auto-detected file backup operation - 📝
 // handleAutoDetectedFileBackup handles file backup when auto-detected
-func handleAutoDetectedFileBackup(args []string) {
+func handleAutoDetectedFileBackup(args []string, opts autoDetectOptions) {
 	ctx := context.Background()
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -154,12 +309,13 @@ func handleAutoDetectedFileBackup(args []string) {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(cfg.StatusConfigError)
 	}
+	enforceReadOnlyMode(cfg)
 
 	formatter := NewOutputFormatter(cfg)
 	filePath := args[0]
 
 	// Extract note from second argument if provided
-	backupNote := note // Use global note flag if set
+	backupNote := opts.Note // Use the --note flag if set
 	if backupNote == "" && len(args) > 1 {
 		backupNote = args[1]
 	}
@@ -171,7 +327,7 @@ func handleAutoDetectedFileBackup(args []string) {
 		Formatter: formatter,
 		FilePath:  filePath,
 		Note:      backupNote,
-		DryRun:    dryRun,
+		DryRun:    opts.DryRun,
 	}); err != nil {
 		exitCode := HandleArchiveError(err, cfg, formatter)
 		os.Exit(exitCode)
@@ -180,7 +336,7 @@ func handleAutoDetectedFileBackup(args []string) {
 
 // ⭐ CLI-015: Auto-detected directory archive operation - 📝
 // handleAutoDetectedDirectoryArchive handles directory archive when auto-detected
-func handleAutoDetectedDirectoryArchive(args []string) {
+func handleAutoDetectedDirectoryArchive(args []string, opts autoDetectOptions) {
 	ctx := context.Background()
 
 	// Change to the specified directory for archiving
@@ -208,17 +364,24 @@ func handleAutoDetectedDirectoryArchive(args []string) {
 		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
 		os.Exit(1) // Use default exit code since cfg might be nil
 	}
+	enforceReadOnlyMode(cfg)
+
+	// ⭐ EXCLUDE-001: Merge patterns from exclude_from
+	if err := ApplyExcludeFrom(cfg, ".", nil); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading exclude patterns: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
 
 	formatter := NewOutputFormatter(cfg)
 
 	// Extract note from second argument if provided
-	archiveNote := note // Use global note flag if set
+	archiveNote := opts.Note // Use the --note flag if set
 	if archiveNote == "" && len(args) > 1 {
 		archiveNote = args[1]
 	}
 
 	// Create full archive using existing functionality
-	if err := CreateFullArchiveWithContext(ctx, cfg, archiveNote, dryRun, false); err != nil {
+	if err := CreateFullArchiveWithContext(ctx, cfg, archiveNote, opts.DryRun, false); err != nil {
 		exitCode := HandleArchiveError(err, cfg, formatter)
 		os.Exit(exitCode)
 	}
@@ -247,13 +410,13 @@ func executeWithAutoDetection(rootCmd *cobra.Command) error {
 
 	// List of known commands that should be handled by Cobra normally
 	knownCommands := []string{
-		"create", "config", "template", "full", "inc", "list", "verify", "backup", "version",
-		"help", "--help", "-h", "--version", "-v",
+		"create", "config", "template", "full", "inc", "list", "verify", "backup", "version", "undo", "trash",
+		"serve", "stats", "checksum", "restore", "mount", "keyring", "upload", "clone", "explain", "annotate", "migrate-names", "migrate-dirs", "docs", "history", "tier", "audit", "bench", "selftest", "last", "gc", "doctor", "help", "--help", "-h", "--version", "-v",
 	}
 
 	// Check for global flags that should be handled normally
 	globalFlags := []string{
-		"--config", "--dry-run", "-d", "--list",
+		"--config", "--dry-run", "-d", "--list", "--read-only",
 	}
 
 	// If first argument is a known command or global flag, execute normally
@@ -278,44 +441,46 @@ func executeWithAutoDetection(rootCmd *cobra.Command) error {
 	// Let handleAutoDetectedCommand handle path validation and provide appropriate errors
 	// We need to manually handle the global flags that might be present
 	var filteredArgs []string
-	var dryRunFlag bool
-	var noteFlag string
+	var opts autoDetectOptions
+	var readOnlyFlag bool
 
 	// Parse arguments to extract global flags
 	i := 0
 	for i < len(args) {
 		arg := args[i]
 		if arg == "--dry-run" || arg == "-d" {
-			dryRunFlag = true
+			opts.DryRun = true
+		} else if arg == "--read-only" {
+			readOnlyFlag = true
 		} else if arg == "--note" || arg == "-n" {
 			if i+1 < len(args) {
-				noteFlag = args[i+1]
+				opts.Note = args[i+1]
 				i++ // Skip the next argument as it's the note value
 			}
 		} else if strings.HasPrefix(arg, "--note=") {
-			noteFlag = strings.TrimPrefix(arg, "--note=")
+			opts.Note = strings.TrimPrefix(arg, "--note=")
 		} else if strings.HasPrefix(arg, "-n=") {
-			noteFlag = strings.TrimPrefix(arg, "-n=")
+			opts.Note = strings.TrimPrefix(arg, "-n=")
 		} else {
 			filteredArgs = append(filteredArgs, arg)
 		}
 		i++
 	}
 
-	// Set global variables for the handlers to use
-	dryRun = dryRunFlag
-	if noteFlag != "" {
-		note = noteFlag
-	}
+	readOnly = readOnly || readOnlyFlag
 
 	// Execute auto-detection with filtered arguments
-	handleAutoDetectedCommand(filteredArgs)
+	handleAutoDetectedCommand(filteredArgs, opts)
 	return nil
 }
 
 func main() {
+	// ⭐ KEYRING-001: secretref:keychain/ALIAS reads the OS credential store
+	registerSecretStores()
+
 	// 🔺 CFG-001: CLI application initialization and command structure - 📝
 	// DECISION-REF: DEC-002
+	var rootFlags *cli.FlagBinding[rootOptions]
 	rootCmd := &cobra.Command{
 		Use:     "bkpdir",
 		Short:   "Directory archiving and file backup CLI for macOS and Linux",
@@ -353,21 +518,22 @@ func main() {
   bkpdir config
   bkpdir --config  # backward compatibility`,
 		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(rootFlags, cmd)
 			// Handle --config flag when no subcommand is provided (backward compatibility)
-			if showConfig {
+			if opts.ShowConfig {
 				handleConfigCommand()
 				return
 			}
 			// Handle --list flag for file backups
 			if cmd.Flags().Changed("list") {
-				handleListFileBackupsCommand(args)
+				handleListFileBackupsCommand(opts.ListFile, args)
 				return
 			}
 
 			// ⭐ CLI-015: Automatic file/directory command detection - 🔧
 			// If positional arguments provided, auto-detect operation type
 			if len(args) > 0 {
-				handleAutoDetectedCommand(args)
+				handleAutoDetectedCommand(args, autoDetectOptions{DryRun: opts.DryRun})
 				return
 			}
 
@@ -382,12 +548,55 @@ func main() {
 	rootCmd.SetVersionTemplate(versionTemplate)
 
 	// Global flags
-	rootCmd.PersistentFlags().BoolVarP(&dryRun, "dry-run", "d", false,
-		"Show what would be done without creating archives")
-	rootCmd.PersistentFlags().BoolVar(&showConfig, "config", false,
-		"Display configuration values and exit (backward compatibility)")
-	rootCmd.PersistentFlags().StringVar(&listFile, "list", "",
-		"List backups for a specific file")
+	rootFlags = cli.BindPersistentFlags(rootCmd, rootOptions{Color: string(formatter.ColorAuto)})
+	rootFlags.
+		Bool(func(o *rootOptions) *bool { return &o.DryRun }, "dry-run", "d",
+			"Show what would be done without creating archives").
+		Bool(func(o *rootOptions) *bool { return &o.ShowConfig }, "config", "",
+			"Display configuration values and exit (backward compatibility)").
+		String(func(o *rootOptions) *string { return &o.ListFile }, "list", "",
+			"List backups for a specific file").
+		// ⭐ COLOR-001: Color policy flag
+		String(func(o *rootOptions) *string { return &o.Color }, "color", "",
+			"Keep ANSI colors in output: auto (terminals, unless NO_COLOR is set), always or never").
+		// ⭐ GUARD-001: Read-only safety flag
+		Bool(func(o *rootOptions) *bool { return &o.ReadOnly }, "read-only", "",
+			"Refuse any write outside the archive and backup directories").
+		// ⭐ TERM-WIDTH-001: Keep long lines intact on terminals
+		Bool(func(o *rootOptions) *bool { return &o.NoTruncate }, "no-truncate", "",
+			"Do not wrap or truncate long names and values to the terminal width")
+	rootCmd.PersistentPreRun = func(cmd *cobra.Command, _ []string) {
+		opts := commandOptions(rootFlags, cmd)
+		readOnly, noTruncate = opts.ReadOnly, opts.NoTruncate
+		mode, err := formatter.ParseColorMode(opts.Color)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --color: %v\n", err)
+			os.Exit(DefaultConfig().StatusConfigError)
+		}
+		cwd, err := os.Getwd()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+			os.Exit(1)
+		}
+		// A configuration error is reported by the command unless --read-only needs it
+		cfg, err := LoadConfig(cwd)
+		configureOutputStyle(mode, cfg != nil && cfg.ASCIIOnly)
+		// ⭐ SHARED-001: Everything the command creates follows shared.umask
+		if err == nil {
+			if err := applySharedUmask(cfg); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(cfg.StatusConfigError)
+			}
+		}
+		if !readOnly {
+			return
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+			os.Exit(cfg.StatusConfigError)
+		}
+		enforceReadOnlyMode(cfg)
+	}
 
 	// Add commands - new specification-compliant commands first
 	rootCmd.AddCommand(createCmd())
@@ -403,6 +612,32 @@ func main() {
 	rootCmd.AddCommand(verifyCmd())
 	rootCmd.AddCommand(backupCmd())
 	rootCmd.AddCommand(versionCmd())
+	rootCmd.AddCommand(undoCmd())
+	rootCmd.AddCommand(trashCmd())
+	rootCmd.AddCommand(serveCmd())
+	rootCmd.AddCommand(statsCmd())
+	rootCmd.AddCommand(checksumCmd())
+	rootCmd.AddCommand(restoreCmd())
+	rootCmd.AddCommand(mountCmd())
+	rootCmd.AddCommand(keyringCmd())
+	rootCmd.AddCommand(uploadCmd())
+	rootCmd.AddCommand(cloneCmd())
+	rootCmd.AddCommand(explainCmd())
+	rootCmd.AddCommand(annotateCmd())
+	rootCmd.AddCommand(migrateNamesCmd())
+	rootCmd.AddCommand(migrateDirsCmd())
+	rootCmd.AddCommand(docsCmd())
+	rootCmd.AddCommand(historyCmd())
+	rootCmd.AddCommand(tierCmd())
+	rootCmd.AddCommand(auditCmd())
+	rootCmd.AddCommand(benchCmd())
+	rootCmd.AddCommand(selftestCmd())
+	rootCmd.AddCommand(lastCmd())
+	rootCmd.AddCommand(gcCmd())
+	rootCmd.AddCommand(doctorCmd())
+
+	// ⭐ HELP-EXAMPLES-001: Examples generated from the current configuration
+	installHelpExamples(rootCmd)
 
 	// ⭐ CLI-015: Custom command execution with auto-detection fallback - 🔧
 	if err := executeWithAutoDetection(rootCmd); err != nil {
@@ -521,25 +756,27 @@ func displayConfigTree(values []ConfigValueWithMetadata, showSources bool) {
 		categories[category] = append(categories[category], value)
 	}
 
+	// ⭐ TERM-WIDTH-001: Long values and chains are fitted to the terminal
+	width := outputWidth()
+
 	// Display each category as a tree branch
 	for category, categoryValues := range categories {
 		fmt.Printf("📁 %s\n", strings.Title(strings.ReplaceAll(category, "_", " ")))
 
 		for i, value := range categoryValues {
-			prefix := "├── "
+			prefix, indent := "├── ", "│       "
 			if i == len(categoryValues)-1 {
-				prefix = "└── "
+				prefix, indent = "└── ", "        "
 			}
 
-			fmt.Printf("%s%s: %s", prefix, value.ConfigValue.Name, value.ConfigValue.Value)
-
+			suffix, chain := "", ""
 			if showSources {
-				fmt.Printf(" (source: %s)", value.ConfigValue.Source)
+				suffix = fmt.Sprintf(" (source: %s)", value.ConfigValue.Source)
 				if len(value.InheritanceChain) > 1 {
-					fmt.Printf(" [chain: %s]", strings.Join(value.InheritanceChain, " → "))
+					chain = fmt.Sprintf("[chain: %s]", strings.Join(value.InheritanceChain, " → "))
 				}
 			}
-			fmt.Println()
+			fmt.Println(fitConfigLine(prefix, value.ConfigValue.Name, value.ConfigValue.Value, suffix, chain, indent, width))
 		}
 		fmt.Println()
 	}
@@ -605,38 +842,36 @@ func displayConfigTable(values []ConfigValueWithMetadata, showSources bool) {
 		expandedPaths[i] = expandedPath
 	}
 
+	// ⭐ TERM-WIDTH-001: Long values and chains are fitted to the terminal
+	width := outputWidth()
+
 	configPathsStr := strings.Join(expandedPaths, ":")
-	fmt.Printf("config: %s (source: default)\n", configPathsStr)
+	fmt.Println(fitConfigLine("", "config", configPathsStr, " (source: default)", "", "", width))
 
 	// Display each configuration value
 	for _, value := range values {
-		fmt.Printf("%s: %s (source: %s)", value.ConfigValue.Name, value.ConfigValue.Value, value.ConfigValue.Source)
+		suffix := fmt.Sprintf(" (source: %s)", value.ConfigValue.Source)
+		chain := ""
 
 		if showSources {
 			if value.IsOverridden {
-				fmt.Printf(" [overridden]")
+				suffix += " [overridden]"
 			}
 			if value.FieldInfo.Category != "basic_settings" {
-				fmt.Printf(" [%s]", value.FieldInfo.Category)
+				suffix += fmt.Sprintf(" [%s]", value.FieldInfo.Category)
 			}
 			if len(value.InheritanceChain) > 1 {
-				fmt.Printf(" [chain: %s]", strings.Join(value.InheritanceChain, " → "))
+				chain = fmt.Sprintf("[chain: %s]", strings.Join(value.InheritanceChain, " → "))
 			}
 		}
-		fmt.Println()
+		fmt.Println(fitConfigLine("", value.ConfigValue.Name, value.ConfigValue.Value, suffix, chain, "    ", width))
 	}
 }
 
-func handleCreateCommand() {
-	// Implementation
-}
-
 // ⭐ CFG-TEMPLATE-001: Template command implementation - 🔧
-func handleTemplateCommand(cmd *cobra.Command, args []string) {
-	// Get flag values
-	outputFile, _ := cmd.Flags().GetString("output")
-	dryRun, _ := cmd.Flags().GetBool("dry-run")
-	force, _ := cmd.Flags().GetBool("force")
+func handleTemplateCommand(opts templateCmdOptions, args []string) {
+	outputFile, dryRun, force := opts.Output, opts.DryRun, opts.Force
+	minimal, toStdout, diff, inherit := opts.Minimal, opts.Stdout, opts.Diff, opts.Inherit
 
 	// Get current working directory
 	cwd, err := os.Getwd()
@@ -658,7 +893,7 @@ func handleTemplateCommand(cmd *cobra.Command, args []string) {
 	targetFile := determineTemplateFileName(outputFile)
 
 	// Check if file exists and handle conflicts
-	if !force && !dryRun {
+	if !force && !dryRun && !toStdout && !diff {
 		if _, err := os.Stat(targetFile); err == nil {
 			fmt.Printf("File %s already exists. Use --force to overwrite or choose a different name.\n", targetFile)
 			os.Exit(1)
@@ -667,12 +902,42 @@ func handleTemplateCommand(cmd *cobra.Command, args []string) {
 
 	// ⭐ CFG-TEMPLATE-001: Template generation - 🔧
 	// Generate template content
-	templateContent, err := generateConfigurationTemplate(cfg)
+	templateContent, err := generateConfigurationTemplateWithOptions(cfg, TemplateOptions{Minimal: minimal})
+	if inherit != "" {
+		// ⭐ CFG-TEMPLATE-INHERIT-001: Child configuration of a base file
+		templateContent, err = generateInheritingTemplate(cfg, targetFile, inherit)
+	}
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error generating template: %v\n", err)
 		os.Exit(1)
 	}
 
+	// ⭐ CFG-TEMPLATE-003: Template to stdout - 🔧
+	if toStdout {
+		fmt.Print(templateContent)
+		return
+	}
+
+	// ⭐ CFG-TEMPLATE-003: Differences from the existing configuration - 🔍
+	if diff {
+		existingFile := outputFile
+		if existingFile == "" {
+			existingFile = ".bkpdir.yml"
+		}
+		existing, err := os.ReadFile(existingFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading %s: %v\n", existingFile, err)
+			os.Exit(1)
+		}
+		changes := unifiedDiff(existingFile, "template", string(existing), templateContent)
+		if changes == "" {
+			fmt.Fprintf(os.Stderr, "%s matches the template\n", existingFile)
+			return
+		}
+		fmt.Print(changes)
+		return
+	}
+
 	if dryRun {
 		fmt.Printf("Would create file: %s\n", targetFile)
 		fmt.Printf("Template content:\n")
@@ -688,11 +953,11 @@ func handleTemplateCommand(cmd *cobra.Command, args []string) {
 		os.Exit(1)
 	}
 
-	fmt.Printf("✅ Configuration template created: %s\n", targetFile)
-	fmt.Printf("📝 Edit the file to customize your configuration options\n")
+	fmt.Print(formatter.StyleStdout(fmt.Sprintf("✅ Configuration template created: %s\n", targetFile)))
+	fmt.Print(formatter.StyleStdout("📝 Edit the file to customize your configuration options\n"))
 }
 
-func handleListCommand() {
+func handleListCommand(opts ListOptions) {
 	// ⭐ ARCH-002: Archive listing command implementation - 📝
 	// 🔺 CFG-003: Archive listing output formatting - 📝
 	// Requirement: List Archives - Display all archives in the archive directory
@@ -714,14 +979,111 @@ func handleListCommand() {
 
 	formatter := NewOutputFormatter(cfg)
 
-	if err := ListArchivesEnhanced(cfg, formatter); err != nil {
+	opts.Config = cfg
+	opts.Formatter = formatter
+	if err := ListArchivesWithOptions(opts); err != nil {
 		exitCode := HandleArchiveError(err, cfg, formatter)
 		os.Exit(exitCode)
 	}
 }
 
-func handleVerifyCommand() {
-	// Implementation
+func handleVerifyCommand(flags verifyCmdOptions, args []string) {
+	// ⭐ ARCH-002: Archive verification command implementation - 🛡️
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	formatter := NewOutputFormatter(cfg)
+
+	// ⭐ POWER-001: Defer on battery or under load unless --ignore-power
+	if err := checkPowerState(cfg, flags.IgnorePower, "verification"); err != nil {
+		os.Exit(HandleArchiveError(err, cfg, formatter))
+	}
+
+	opts := flags.VerifyOptions
+	opts.Config = cfg
+	opts.Formatter = formatter
+	if len(args) > 0 {
+		opts.ArchiveName = args[0]
+	}
+
+	// ⭐ JUNIT-001: Report each verified archive as a test case
+	_, reportPath, err := parseReportSpec(cfg, flags.Report)
+	if err != nil {
+		os.Exit(HandleArchiveError(err, cfg, formatter))
+	}
+	opts.Report = newJUnitReport("verify", reportPath)
+	start := time.Now()
+	// finish records modes without per-archive results as one test case
+	// named name, writes the report and exits on failure.
+	finish := func(name string, err error) {
+		if opts.Report.empty() {
+			if err != nil {
+				opts.Report.fail(name, time.Since(start), err.Error())
+			} else {
+				opts.Report.pass(name, time.Since(start))
+			}
+		}
+		opts.Report.write()
+		if err != nil {
+			os.Exit(HandleArchiveError(err, cfg, formatter))
+		}
+	}
+
+	// ⭐ CHAIN-CHECK-001: Incremental archives whose base is gone or corrupt
+	if flags.Chain {
+		if opts.ArchiveName != "" {
+			formatter.PrintError("--chain checks every chain and takes no archive name")
+			os.Exit(cfg.StatusConfigError)
+		}
+		finish("chains", VerifyArchiveChains(os.Stdout, cfg))
+		return
+	}
+
+	// ⭐ SUMS-001: Check the files listed in an external checksum file
+	if flags.ChecksumFile != "" {
+		finish(flags.ChecksumFile, VerifyArchiveChecksums(os.Stdout, cfg, flags.ChecksumFile))
+		return
+	}
+
+	// ⭐ VERIFY-DIR-001: Audit a directory tree against one archive
+	if flags.AgainstDir != "" {
+		if opts.ArchiveName == "" {
+			formatter.PrintError("--against-dir requires an archive name")
+			os.Exit(cfg.StatusConfigError)
+		}
+		finish(opts.ArchiveName+" against "+flags.AgainstDir, verifyDirectoryAgainstArchive(opts, flags.AgainstDir))
+		return
+	}
+
+	// ⭐ ARCH-006: Parse the sample size before touching any archive
+	if flags.Sample != "" {
+		spec, err := ParseSampleSpec(flags.Sample)
+		if err != nil {
+			formatter.PrintError(err.Error())
+			os.Exit(cfg.StatusConfigError)
+		}
+		opts.Sample = &spec
+	}
+	// ⭐ VERIFY-QUICK-001: Repository snapshots have no ZIP structure to check
+	if opts.Quick && repositoryEnabled(cfg) {
+		formatter.PrintError("--quick checks ZIP archives and is not supported with a repository")
+		os.Exit(cfg.StatusConfigError)
+	}
+	if opts.Progress && (!opts.WithChecksum || opts.Sample != nil) {
+		formatter.PrintError("--progress requires --checksum without --sample")
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	finish("archives", VerifyArchiveEnhanced(opts))
 }
 
 func handleVersionCommand() {
@@ -734,14 +1096,7 @@ func configCmd() *cobra.Command {
 	// 🔺 CFG-006: Enhanced config command interface - 🔧
 	// 🔻 CFG-006: Documentation - 📝 Enhanced help text
 
-	var (
-		showAll       bool
-		showOverrides bool
-		showSources   bool
-		outputFormat  string
-		filterPattern string
-	)
-
+	var flags *cli.FlagBinding[configCmdOptions]
 	cmd := &cobra.Command{
 		Use:   "config [KEY] [VALUE]",
 		Short: "Display or modify configuration values",
@@ -764,6 +1119,10 @@ Display Options:
   --format FORMAT   Choose output format: table (default), tree, json
   --filter PATTERN  Filter fields by name pattern
 
+Set Options:
+  --file PATH       Write to this file of the inheritance chain instead of ./.bkpdir.yml
+  --global          Write to the user configuration file
+
 Examples:
   # Display all configuration values (100+ fields auto-discovered)
   bkpdir config
@@ -787,6 +1146,16 @@ Examples:
   bkpdir config archive_dir_path /custom/archive/path
   bkpdir config include_git_info false
 
+  # Set it in the user configuration file or a shared parent instead
+  bkpdir config --global include_git_info false
+  bkpdir config --file ../team.yml exclude_patterns "*.log"
+
+  # Explain what a key does, its default and environment override
+  bkpdir config archive_dir_path --describe
+
+  # Export a JSON Schema for editor validation and completion
+  bkpdir config schema > bkpdir.schema.json
+
 Troubleshooting:
   # Check why a value isn't being applied
   bkpdir config [field_name] --sources --format tree
@@ -799,13 +1168,17 @@ Troubleshooting:
 
 For detailed documentation, see docs/configuration-inspection-guide.md`,
 		Args: cobra.MaximumNArgs(2),
-		Run: func(_ *cobra.Command, args []string) {
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
 			if len(args) == 0 {
 				// Enhanced configuration display with filtering options
-				handleEnhancedConfigCommand(showAll, showOverrides, showSources, outputFormat, filterPattern)
+				handleEnhancedConfigCommand(opts.ShowAll, opts.OverridesOnly, opts.Sources, opts.Format, opts.Filter)
+			} else if len(args) == 1 && opts.Describe {
+				// ⭐ CFG-DESCRIBE-001: Describe a single configuration key
+				handleConfigDescribeCommand(args[0])
 			} else if len(args) == 2 {
 				// Set configuration value
-				handleConfigSetCommand(args[0], args[1])
+				handleConfigSetCommand(args[0], args[1], opts.File, opts.Global)
 			} else {
 				fmt.Fprintf(os.Stderr, "Error: config set requires both KEY and VALUE\n")
 				fmt.Fprintf(os.Stderr, "Usage: bkpdir config [KEY] [VALUE]\n")
@@ -815,30 +1188,114 @@ For detailed documentation, see docs/configuration-inspection-guide.md`,
 	}
 
 	// 🔺 CFG-006: Command-line options and filtering - 🔧
-	cmd.Flags().BoolVar(&showAll, "all", true, "Show all configuration fields")
-	cmd.Flags().BoolVar(&showOverrides, "overrides-only", false, "Display only non-default values")
-	cmd.Flags().BoolVar(&showSources, "sources", false, "Show detailed source attribution")
-	cmd.Flags().StringVar(&outputFormat, "format", "table", "Output format: table, tree, json")
-	cmd.Flags().StringVar(&filterPattern, "filter", "", "Filter fields by name pattern")
+	flags = cli.BindFlags(cmd, configCmdOptions{ShowAll: true, Format: "table"}).
+		Bool(func(o *configCmdOptions) *bool { return &o.ShowAll }, "all", "", "Show all configuration fields").
+		Bool(func(o *configCmdOptions) *bool { return &o.OverridesOnly }, "overrides-only", "", "Display only non-default values").
+		Bool(func(o *configCmdOptions) *bool { return &o.Sources }, "sources", "", "Show detailed source attribution").
+		String(func(o *configCmdOptions) *string { return &o.Format }, "format", "", "Output format: table, tree, json").
+		String(func(o *configCmdOptions) *string { return &o.Filter }, "filter", "", "Filter fields by name pattern").
+		Bool(func(o *configCmdOptions) *bool { return &o.Describe }, "describe", "",
+			"Describe KEY: purpose, type, default, env var and related keys").
+		// ⭐ CFG-SET-FILE-001: Choose the layer of the configuration chain that is written
+		String(func(o *configCmdOptions) *string { return &o.File }, "file", "",
+			"Write KEY VALUE to this configuration file instead of ./.bkpdir.yml").
+		Bool(func(o *configCmdOptions) *bool { return &o.Global }, "global", "", "Write KEY VALUE to the user configuration file")
+
+	// ⭐ CFG-SCHEMA-001: JSON Schema export subcommand
+	cmd.AddCommand(configSchemaCmd())
 
 	return cmd
 }
 
+// ⭐ CFG-SCHEMA-001: JSON Schema export command - 🔧
+func configSchemaCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "schema",
+		Short: "Print a JSON Schema for .bkpdir.yml",
+		Long: `Print a JSON Schema describing every configuration key, its type, default
+value and known allowed values. Point a YAML language server at the output to
+validate and autocomplete .bkpdir.yml files.`,
+		Example: `  bkpdir config schema > bkpdir.schema.json
+
+  # Reference it from .bkpdir.yml (yaml-language-server)
+  # yaml-language-server: $schema=./bkpdir.schema.json`,
+		Args: cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			handleConfigSchemaCommand()
+		},
+	}
+}
+
 func createCmd() *cobra.Command {
 	// ⭐ ARCH-002: Archive creation command implementation - 🔧
 	// 🔺 CFG-003: Command interface for archive creation - 🔧
+	var flags *cli.FlagBinding[archiveCmdOptions]
 	cmd := &cobra.Command{
 		Use:   "create",
 		Short: "Create a new archive",
-		Run: func(*cobra.Command, []string) {
-			handleCreateCommand()
+		Long: `Create a full archive of the current directory, like full.
+
+With --from-list, archive exactly the listed paths instead of the files found
+by walking the current directory, so tools like find, fd or git ls-files
+decide what is backed up.
+
+The list holds one path per line, or NUL-separated paths with -0; "-" reads
+it from stdin. Paths are relative to the current directory or absolute
+within it. Listed directories are not expanded and exclude_patterns do not
+apply. A missing path fails the run, or is recorded as unreadable with
+--keep-going.
+
+With --explain-exclusions and no list, report what the exclusion patterns
+keep out of an archive of the current directory: the files and bytes each
+pattern excludes, with the setting or file it comes from, and the largest
+excluded files. full and inc take the flag too and print the report after
+the run.`,
+		Example: `  # Archive the current directory with extra exclusion patterns
+  bkpdir create --exclude-from .bkpignore "Before refactor"
+
+  # Archive the files tracked by Git
+  git ls-files | bkpdir create --from-list -
+
+  # Archive files changed in the last day, with names containing newlines
+  find . -type f -mtime -1 -print0 | bkpdir create --from-list - -0 "Today's changes"
+
+  # Find out why a file is not backed up
+  bkpdir create --explain-exclusions --exclude-from .bkpignore`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			// ⭐ EXCLUDE-AUDIT-001: Without a list, audit the exclusions of the current directory
+			if opts.FromList == "" && opts.ExplainExclusions {
+				cwd, err := os.Getwd()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+					os.Exit(1)
+				}
+				runWithConfig(func(cfg *Config) error {
+					audit, err := AuditExclusions(context.Background(), cfg, cwd, opts.ExcludeFrom)
+					if err != nil {
+						return err
+					}
+					audit.Print(os.Stdout)
+					return nil
+				})
+				return
+			}
+			runFullArchiveCommand(opts, args)
 		},
 	}
+	flags = bindArchiveFlags(cmd).
+		String(func(o *archiveCmdOptions) *string { return &o.FromList }, "from-list", "",
+			"Archive the paths listed in FILE, one per line (- for stdin)").
+		Bool(func(o *archiveCmdOptions) *bool { return &o.Null }, "null", "0",
+			"Paths in the list are separated by NUL characters, as from find -print0")
+	cmd.MarkFlagsMutuallyExclusive("from-list", "explain-exclusions")
 	return cmd
 }
 
 func templateCmd() *cobra.Command {
 	// ⭐ CFG-TEMPLATE-001: CLI command implementation - 🔧
+	var flags *cli.FlagBinding[templateCmdOptions]
 	cmd := &cobra.Command{
 		Use:   "template",
 		Short: "Generate configuration template file",
@@ -846,7 +1303,16 @@ func templateCmd() *cobra.Command {
 
 Creates a YAML configuration file with all possible configuration keys, properly 
 commented and organized by category. Values are populated from the current 
-configuration after loading BKPDIR_CONFIG.
+configuration after loading BKPDIR_CONFIG. Each key is preceded by its
+description, type, default value, allowed values and, where useful, an example.
+
+Use --minimal for a short starter template with only the commonly changed keys.
+Use --inherit BASE for a layered configuration instead: it inherits BASE and
+sets only the keys whose current values differ from those inherited from
+BASE, so it keeps the current configuration without copying the rest.
+Use --stdout to print the template instead of writing a file, and --diff to
+show a unified diff from the existing .bkpdir.yml (or --output) to the
+template, e.g. to find the keys added by an upgrade.
 
 File naming:
 - Creates .bkpdir.yml if it doesn't exist
@@ -858,23 +1324,139 @@ File naming:
   bkpdir template --output custom-config.yml
 
   # Preview template without creating file
-  bkpdir template --dry-run`,
+  bkpdir template --dry-run
+
+  # Generate a short starter template
+  bkpdir template --minimal
+
+  # Generate a child of a shared configuration with only the differences
+  bkpdir template --inherit ../team.yml --output .bkpdir.yml --force
+
+  # Print the template for piping
+  bkpdir template --stdout > bkpdir.yml.example
+
+  # Show what the template adds to the existing configuration
+  bkpdir template --diff`,
 		Run: func(cmd *cobra.Command, args []string) {
-			handleTemplateCommand(cmd, args)
+			handleTemplateCommand(commandOptions(flags, cmd), args)
 		},
 	}
 
 	// Add flags
-	cmd.Flags().StringP("output", "o", "", "Custom output filename (default: .bkpdir.yml or .bkpdir.default-YYYY-MM-DD.yml)")
-	cmd.Flags().BoolP("dry-run", "d", false, "Show what would be written without creating the file")
-	cmd.Flags().BoolP("force", "f", false, "Overwrite existing files without confirmation")
+	flags = cli.BindFlags(cmd, templateCmdOptions{}).
+		String(func(o *templateCmdOptions) *string { return &o.Output }, "output", "o",
+			"Custom output filename (default: .bkpdir.yml or .bkpdir.default-YYYY-MM-DD.yml)").
+		Bool(func(o *templateCmdOptions) *bool { return &o.DryRun }, "dry-run", "d",
+			"Show what would be written without creating the file").
+		Bool(func(o *templateCmdOptions) *bool { return &o.Force }, "force", "f", "Overwrite existing files without confirmation").
+		Bool(func(o *templateCmdOptions) *bool { return &o.Minimal }, "minimal", "",
+			"Generate a short starter template with only the common settings").
+		Bool(func(o *templateCmdOptions) *bool { return &o.Stdout }, "stdout", "",
+			"Print the template to stdout instead of writing a file").
+		Bool(func(o *templateCmdOptions) *bool { return &o.Diff }, "diff", "",
+			"Show a unified diff from the existing configuration file to the template").
+		String(func(o *templateCmdOptions) *string { return &o.Inherit }, "inherit", "",
+			"Generate a configuration inheriting this file with only the keys that differ from it")
+	cmd.MarkFlagsMutuallyExclusive("stdout", "diff", "dry-run")
+	cmd.MarkFlagsMutuallyExclusive("minimal", "inherit")
 
 	return cmd
 }
 
+// runFullArchiveCommand creates a full archive for full and create.
+func runFullArchiveCommand(opts archiveCmdOptions, args []string) {
+	ctx := context.Background()
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	// ⭐ EXCLUDE-AUDIT-001: Audit before exclude_from is merged, to keep the origins
+	audit := auditArchiveExclusions(opts, cfg, cwd)
+
+	// ⭐ EXCLUDE-001: Merge patterns from exclude_from and --exclude-from
+	if err := ApplyExcludeFrom(cfg, cwd, opts.ExcludeFrom); err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading exclude patterns: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	// ⭐ KEEP-GOING-001: The flag turns keep-going on for this run
+	if opts.KeepGoing {
+		cfg.KeepGoing = true
+	}
+
+	formatter := NewOutputFormatter(cfg)
+
+	// ⭐ POWER-001: Defer on battery or under load unless --ignore-power
+	if !opts.DryRun {
+		if err := checkPowerState(cfg, opts.IgnorePower, "full archive"); err != nil {
+			os.Exit(HandleArchiveError(err, cfg, formatter))
+		}
+	}
+
+	// Use note from flag if provided, otherwise use positional argument
+	archiveNote := opts.Note
+	if archiveNote == "" && len(args) > 0 {
+		archiveNote = args[0]
+	}
+
+	// ⭐ FROM-LIST-001: Archive only the listed paths
+	if opts.FromList != "" {
+		paths, err := loadFileList(opts.FromList, opts.Null)
+		if err != nil {
+			os.Exit(HandleArchiveError(NewArchiveErrorWithCause("Failed to read file list", cfg.StatusFileNotFound, err), cfg, formatter))
+		}
+		err = CreateFullArchiveFromList(ctx, cfg, archiveNote, paths, opts.DryRun, false)
+		if err != nil {
+			os.Exit(HandleArchiveError(err, cfg, formatter))
+		}
+		return
+	}
+
+	// ⭐ SPLIT-001: Archive each immediate subdirectory separately
+	if opts.SplitByDir {
+		if err := CreateSplitArchives(ctx, cfg, archiveNote, opts.DryRun, os.Stdout); err != nil {
+			os.Exit(HandleArchiveError(err, cfg, formatter))
+		}
+		return
+	}
+
+	err = CreateFullArchiveWithContext(ctx, cfg, archiveNote, opts.DryRun, false)
+	if audit != nil {
+		audit.Print(os.Stdout)
+	}
+	if err != nil {
+		exitCode := HandleArchiveError(err, cfg, formatter)
+		os.Exit(exitCode)
+	}
+}
+
+// ⭐ EXCLUDE-AUDIT-001: Exclusion audit of an archive run - 🔍
+// auditArchiveExclusions audits the exclusions of an archive of cwd with
+// --explain-exclusions and returns nil otherwise. It must run before the
+// patterns of exclude_from and --exclude-from are merged into cfg.
+func auditArchiveExclusions(opts archiveCmdOptions, cfg *Config, cwd string) *ExclusionAudit {
+	if !opts.ExplainExclusions {
+		return nil
+	}
+	audit, err := AuditExclusions(context.Background(), cfg, cwd, opts.ExcludeFrom)
+	if err != nil {
+		os.Exit(HandleArchiveError(err, cfg, NewOutputFormatter(cfg)))
+	}
+	return audit
+}
+
 func fullCmd() *cobra.Command {
 	// ⭐ ARCH-002: Full archive creation command (backward compatibility) - 🔧
 	// 🔺 CFG-003: Backward compatibility command interface - 🔧
+	var flags *cli.FlagBinding[archiveCmdOptions]
 	cmd := &cobra.Command{
 		Use:   "full [NOTE]",
 		Short: "Create a full archive of the current directory",
@@ -891,49 +1473,37 @@ If the directory is identical to the most recent archive, no new archive is crea
   bkpdir full "Before changes"
 
   # Show what would be archived without creating archive
-  bkpdir full -d`,
-		Args: cobra.MaximumNArgs(1),
-		Run: func(_ *cobra.Command, args []string) {
-			ctx := context.Background()
-			cwd, err := os.Getwd()
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
-				os.Exit(1)
-			}
+  bkpdir full -d
 
-			cfg, err := LoadConfig(cwd)
-			if err != nil {
-				fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
-				os.Exit(cfg.StatusConfigError)
-			}
-
-			formatter := NewOutputFormatter(cfg)
-
-			// Use note from flag if provided, otherwise use positional argument
-			archiveNote := note
-			if archiveNote == "" && len(args) > 0 {
-				archiveNote = args[0]
-			}
-
-			if err := CreateFullArchiveWithContext(ctx, cfg, archiveNote, dryRun, false); err != nil {
-				exitCode := HandleArchiveError(err, cfg, formatter)
-				os.Exit(exitCode)
-			}
+  # Archive each project in ~/src separately
+  cd ~/src && bkpdir full --split-by-dir`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			runFullArchiveCommand(commandOptions(flags, cmd), args)
 		},
 	}
-	cmd.Flags().StringVarP(&note, "note", "n", "", "Add a note to the archive name")
+	flags = bindArchiveFlags(cmd)
+	flags.Bool(func(o *archiveCmdOptions) *bool { return &o.SplitByDir }, "split-by-dir", "",
+		"Create one archive per immediate subdirectory, sharing a run ID, and print a combined summary")
+	cmd.MarkFlagsMutuallyExclusive("split-by-dir", "explain-exclusions")
 	return cmd
 }
 
 func incCmd() *cobra.Command {
 	// ⭐ ARCH-003: Incremental archive creation command - 🔧
 	// 🔺 CFG-003: Incremental command interface - 🔧
+	var flags *cli.FlagBinding[archiveCmdOptions]
 	cmd := &cobra.Command{
 		Use:   "inc [NOTE]",
 		Short: "Create an incremental archive of the current directory",
 		Long: `Create an incremental ZIP archive containing only files changed since the last full archive.
 The archive will be stored in the archive directory with a timestamp. If no files have changed,
-no new archive is created.
+no new archive is created. Use --base to diff against a specific full archive instead of the
+latest one; the incremental archive's name records the chosen base.
+
+When every changed file matches volatile_patterns (caches, logs, swap files),
+no archive is created and "No significant changes" is reported; use
+--include-volatile to archive them anyway.
 
 Before creating an archive, the command compares the directory with its most recent archive.
 If the directory is identical to the most recent archive, no new archive is created.`,
@@ -944,9 +1514,13 @@ If the directory is identical to the most recent archive, no new archive is crea
   bkpdir inc "After changes"
 
   # Show what would be archived without creating archive
-  bkpdir inc -d`,
+  bkpdir inc -d
+
+  # Diff against an older full archive instead of the latest one
+  bkpdir inc --base myproject-2024-03-21-15-30.zip`,
 		Args: cobra.MaximumNArgs(1),
-		Run: func(_ *cobra.Command, args []string) {
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
 			ctx := context.Background()
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -960,55 +1534,1114 @@ If the directory is identical to the most recent archive, no new archive is crea
 				os.Exit(cfg.StatusConfigError)
 			}
 
+			// ⭐ EXCLUDE-AUDIT-001: Audit before exclude_from is merged, to keep the origins
+			audit := auditArchiveExclusions(opts, cfg, cwd)
+
+			// ⭐ EXCLUDE-001: Merge patterns from exclude_from and --exclude-from
+			if err := ApplyExcludeFrom(cfg, cwd, opts.ExcludeFrom); err != nil {
+				fmt.Fprintf(os.Stderr, "Error loading exclude patterns: %v\n", err)
+				os.Exit(cfg.StatusConfigError)
+			}
+
+			// ⭐ KEEP-GOING-001: The flag turns keep-going on for this run
+			if opts.KeepGoing {
+				cfg.KeepGoing = true
+			}
+
 			formatter := NewOutputFormatter(cfg)
 
+			// ⭐ POWER-001: Defer on battery or under load unless --ignore-power
+			if !opts.DryRun {
+				if err := checkPowerState(cfg, opts.IgnorePower, "incremental archive"); err != nil {
+					os.Exit(HandleArchiveError(err, cfg, formatter))
+				}
+			}
+
 			// Use note from flag if provided, otherwise use positional argument
-			archiveNote := note
+			archiveNote := opts.Note
 			if archiveNote == "" && len(args) > 0 {
 				archiveNote = args[0]
 			}
 
-			if err := CreateIncrementalArchiveWithContext(ctx, cfg, archiveNote, dryRun, false); err != nil {
+			err = createIncrementalArchive(IncrementalArchiveConfig{
+				Config:  cfg,
+				Note:    archiveNote,
+				DryRun:  opts.DryRun,
+				Context: ctx,
+				Base:    opts.Base,
+				// ⭐ VOLATILE-001: Archive volatile changes on request
+				IncludeVolatile: opts.IncludeVolatile,
+			})
+			if audit != nil {
+				audit.Print(os.Stdout)
+			}
+			if err != nil {
 				exitCode := HandleArchiveError(err, cfg, formatter)
 				os.Exit(exitCode)
 			}
 		},
 	}
-	cmd.Flags().StringVarP(&note, "note", "n", "", "Add a note to the archive name")
+	flags = bindArchiveFlags(cmd)
+	// ⭐ ARCH-007: Diff against a chosen full archive - 🔧
+	flags.String(func(o *archiveCmdOptions) *string { return &o.Base }, "base", "",
+		"Full archive to diff against (default: the latest full archive)")
+	// ⭐ VOLATILE-001: Override volatile_patterns - 🔧
+	flags.Bool(func(o *archiveCmdOptions) *bool { return &o.IncludeVolatile }, "include-volatile", "",
+		"Create the archive even when only files matching volatile_patterns changed")
 	return cmd
 }
 
+// bindArchiveFlags binds the flags full and inc share.
+func bindArchiveFlags(cmd *cobra.Command) *cli.FlagBinding[archiveCmdOptions] {
+	return cli.BindFlags(cmd, archiveCmdOptions{}).
+		InheritBool(func(o *archiveCmdOptions) *bool { return &o.DryRun }, "dry-run").
+		String(func(o *archiveCmdOptions) *string { return &o.Note }, "note", "n", "Add a note to the archive name").
+		StringArray(func(o *archiveCmdOptions) *[]string { return &o.ExcludeFrom }, "exclude-from", "",
+			"Read additional exclusion patterns from FILE (repeatable)").
+		// ⭐ KEEP-GOING-001: Partial archives instead of aborting - 🛡️
+		Bool(func(o *archiveCmdOptions) *bool { return &o.KeepGoing }, "keep-going", "",
+			"Skip unreadable files, list them in the manifest and exit with status_partial_archive").
+		// ⭐ POWER-001: Override power_aware - 🔧
+		Bool(func(o *archiveCmdOptions) *bool { return &o.IgnorePower }, "ignore-power", "",
+			"Run even on battery or under load when power_aware is set").
+		// ⭐ EXCLUDE-AUDIT-001: Report excluded files after the run - 🔍
+		Bool(func(o *archiveCmdOptions) *bool { return &o.ExplainExclusions }, "explain-exclusions", "",
+			"Report the files and bytes each exclusion pattern excluded and the largest excluded files")
+}
+
 func listCmd() *cobra.Command {
 	// ⭐ ARCH-002: Archive listing command - 🔧
 	// 🔺 CFG-003: List command interface - 🔧
+	var flags *cli.FlagBinding[ListOptions]
 	cmd := &cobra.Command{
 		Use:   "list",
 		Short: "List archives",
-		Run: func(*cobra.Command, []string) {
-			handleListCommand()
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(flags, cmd)
+			handleListCommand(opts)
 		},
 	}
+	flags = cli.BindFlags(cmd, ListOptions{Output: OutputText, VerifyBudget: 2 * time.Second}).
+		// 🔶 GIT-007: Filter archives by recorded Git tag - 🔍
+		String(func(o *ListOptions) *string { return &o.TagPattern }, "tag-matches", "",
+			"Only list archives whose Git tag or describe output matches the glob (e.g. 'v1.*')").
+		// ⭐ LIST-001: Paginate large archive directories - 🔍
+		Int(func(o *ListOptions) *int { return &o.Limit }, "limit", "", "Show at most this many archives (0 for all)").
+		Int(func(o *ListOptions) *int { return &o.Offset }, "offset", "", "Skip this many of the most recent archives").
+		// ⭐ REPORT-001: Machine-readable listing - 📝
+		String(func(o *ListOptions) *string { return &o.Output }, "output", "", "Output format: text or json").
+		// ⭐ LIST-VERIFY-001: Opportunistic verification while listing - 🛡️
+		Bool(func(o *ListOptions) *bool { return &o.VerifyInline }, "verify-inline", "",
+			"Check that unverified archives have a readable ZIP central directory").
+		Duration(func(o *ListOptions) *time.Duration { return &o.VerifyBudget }, "verify-budget", "",
+			"Time allowed for --verify-inline checks (0 for no limit)").
+		// ⭐ REMOTE-CACHE-001: Refresh cached remote listings - 🔍
+		Bool(func(o *ListOptions) *bool { return &o.Refresh }, "refresh", "",
+			"List a remote archive directory and revalidate its manifests instead of using the cache").
+		// ⭐ SHARED-001: Archives of one user in a shared archive directory - 🔍
+		String(func(o *ListOptions) *string { return &o.Owner }, "owner", "",
+			"Only list the archives of this user (see shared.user_namespace)").
+		// ⭐ FOREIGN-001: Files in the archive directory bkpdir did not create - 🔍
+		Bool(func(o *ListOptions) *bool { return &o.Foreign }, "foreign", "",
+			"Also list the files in the archive directory that bkpdir did not create").
+		// ⭐ LIST-TREE-001: Incremental chains - 🔍
+		Bool(func(o *ListOptions) *bool { return &o.Tree }, "tree", "",
+			"Group incremental archives under their base full archive, with chain length and size")
 	return cmd
 }
 
-func verifyCmd() *cobra.Command {
-	// Archive verification command
-	// 🔺 CFG-003: Verify command interface - 🛡️
+// ⭐ STATS-001: Storage statistics command - 🔧
+func statsCmd() *cobra.Command {
+	var flags *cli.FlagBinding[StatsOptions]
 	cmd := &cobra.Command{
-		Use:   "verify",
-		Short: "Verify archives",
-		Run: func(*cobra.Command, []string) {
-			handleVerifyCommand()
+		Use:   "stats",
+		Short: "Show archive storage statistics",
+		Long: `Show the number and total size of the archives for the current directory.
+
+With --growth, archives are grouped by ISO week: sparklines show the trend of
+the total size, the bytes added and the number of archives, followed by a
+per-week table with bars. --output json emits the same series for dashboards.
+Only archives still present in the archive directory are counted.
+
+With --dedup, files stored with identical content in several archives are
+reported with the space deduplication would save, followed by suggestions
+such as a deduplicating repository or incremental archives. Matches use the
+size and CRC-32 of the archive entries and, where manifest_file_hashes
+recorded them, SHA-256 hashes.`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(flags, cmd)
+			handleStatsCommand(opts)
 		},
 	}
+	flags = cli.BindFlags(cmd, StatsOptions{Weeks: defaultGrowthWeeks, Output: OutputText, Top: defaultDedupGroups}).
+		Bool(func(o *StatsOptions) *bool { return &o.Growth }, "growth", "", "Show the weekly storage growth trend").
+		Int(func(o *StatsOptions) *int { return &o.Weeks }, "weeks", "", "Number of weeks in the growth trend").
+		String(func(o *StatsOptions) *string { return &o.Output }, "output", "", "Output format: text or json").
+		Bool(func(o *StatsOptions) *bool { return &o.Dedup }, "dedup", "", "Report files duplicated across archives and potential savings").
+		Int(func(o *StatsOptions) *int { return &o.Top }, "top", "", "Number of duplicate groups listed by --dedup")
 	return cmd
 }
 
-func versionCmd() *cobra.Command {
-	// Version display command
-	// 🔺 CFG-003: Version command interface - 📝
-	cmd := &cobra.Command{
-		Use:   "version",
+func handleStatsCommand(opts StatsOptions) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	opts.Config = cfg
+	if err := ShowStats(os.Stdout, opts); err != nil {
+		exitCode := HandleArchiveError(err, cfg, NewOutputFormatter(cfg))
+		os.Exit(exitCode)
+	}
+}
+
+// ⭐ SUMS-001: Checksum file command group - 🔧
+func checksumCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "checksum",
+		Short: "Write and verify sha256sum-compatible checksum files",
+		Long: `Write and verify checksum files in the format of sha256sum.
+
+'bkpdir checksum write' lists the SHA-256 of the archives of the current
+directory in SHA256SUMS in the archive directory, with names relative to it.
+Copy the file along with the archives and check them anywhere with
+'sha256sum -c SHA256SUMS', or with 'bkpdir checksum verify'.`,
+		Example: `  bkpdir checksum write
+  bkpdir checksum write myproject-2024-03-20-14-30 --file /media/usb/SHA256SUMS
+  bkpdir checksum verify /media/usb/SHA256SUMS`,
+	}
+
+	var writeFlags *cli.FlagBinding[checksumWriteOptions]
+	writeCmd := &cobra.Command{
+		Use:   "write [ARCHIVE_NAME...]",
+		Short: "Write the checksums of archives to a checksum file",
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(writeFlags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				return WriteArchiveChecksums(os.Stdout, cfg, args, opts.File)
+			})
+		},
+	}
+	writeFlags = cli.BindFlags(writeCmd, checksumWriteOptions{}).
+		String(func(o *checksumWriteOptions) *string { return &o.File }, "file", "",
+			"Checksum file to write (default SHA256SUMS in the archive directory)")
+	cmd.AddCommand(writeCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify [FILE]",
+		Short: "Verify the files listed in a checksum file",
+		Args:  cobra.MaximumNArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			file := ""
+			if len(args) > 0 {
+				file = args[0]
+			}
+			runWithConfig(func(cfg *Config) error {
+				return VerifyArchiveChecksums(os.Stdout, cfg, file)
+			})
+		},
+	})
+	return cmd
+}
+
+// runWithConfig loads the configuration of the current directory and runs a
+// command with it.
+func runWithConfig(run func(cfg *Config) error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	if err := run(cfg); err != nil {
+		os.Exit(HandleArchiveError(err, cfg, NewOutputFormatter(cfg)))
+	}
+}
+
+// ⭐ RESTORE-002: Restore command - 🔧
+func restoreCmd() *cobra.Command {
+	var flags *cli.FlagBinding[RestoreOptions]
+	cmd := &cobra.Command{
+		Use:   "restore ARCHIVE TARGET",
+		Short: "Restore an archive into a directory",
+		Long: `Extract ARCHIVE, a name in the archive directory, an alias such as @latest
+or a path, into TARGET.
+
+Use --preview to see what a restore would do without changing anything: every
+file that would be created or overwritten is listed, as well as conflicts such
+as read-only files, directories in the way of files and directories that are
+not writable, followed by counts and sizes. Files whose content already
+matches the archive are left unchanged.
+
+--conflict chooses what happens to existing files: "fail" (default) refuses
+to restore if any file would be overwritten or conflicts, "skip" keeps
+existing files and "overwrite" replaces them. Conflicting paths are never
+written. Incremental archives only hold the files changed since their base.
+
+Use --link to "mount" an archive for inspection: file contents are extracted
+once into a content store and TARGET is filled with symbolic links to them
+(--link=hard for hard links, which need the store on the same file system).
+Identical files share one read-only copy in the store, across archives. The
+store defaults to .restore-store in the archive directory; set it with --store.
+
+Archives record the uid and gid of each file and the names of those users and
+groups. When run as root, or with --uid-map or --gid-map, restored files get
+their archived owner: a FROM:TO pair given with --uid-map/--gid-map first
+(repeatable; a file of such pairs, one per line, works too), then the local
+id of the archived user or group name, then the archived id. --preview lists
+every file whose owner would differ from the archived one.`,
+		Example: `  bkpdir restore myproject-2024-03-20-14-30.zip /tmp/restore --preview
+  bkpdir restore ../.bkpdir/myproject/myproject-2024-03-20-14-30.zip . --conflict overwrite
+  bkpdir restore myproject-2024-03-20-14-30.zip /tmp/inspect --link
+  bkpdir restore @latest /tmp/restore --preview
+  sudo bkpdir restore @latest /srv/app --uid-map 1000:501 --gid-map 1000:20 --preview`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Config = cfg
+				opts.Output = os.Stdout
+				opts.Archive, opts.Target = args[0], args[1]
+				return RestoreArchive(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, RestoreOptions{Conflict: RestoreConflictFail}).
+		Bool(func(o *RestoreOptions) *bool { return &o.Preview }, "preview", "",
+			"List the files that would be created, overwritten or conflict, without restoring").
+		String(func(o *RestoreOptions) *string { return &o.Conflict }, "conflict", "",
+			"Strategy for existing files: fail, skip or overwrite").
+		String(func(o *RestoreOptions) *string { return &o.Link }, "link", "",
+			"Link files from a content store instead of copying them: symlink or hard").
+		String(func(o *RestoreOptions) *string { return &o.Store }, "store", "",
+			"Content store directory for --link (default .restore-store in the archive directory)").
+		StringArray(func(o *RestoreOptions) *[]string { return &o.UIDMap }, "uid-map", "",
+			"Give files of archived uid FROM the uid TO (FROM:TO, or a file of pairs; repeatable)").
+		StringArray(func(o *RestoreOptions) *[]string { return &o.GIDMap }, "gid-map", "",
+			"Give files of archived gid FROM the gid TO (FROM:TO, or a file of pairs; repeatable)")
+	cmd.Flags().Lookup("link").NoOptDefVal = RestoreLinkSymlink
+	return cmd
+}
+
+// ⭐ MOUNT-001: Mount command - 🔧
+func mountCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "mount ARCHIVE MOUNTPOINT",
+		Short: "Browse an archive as a read-only file system",
+		Long: `Mount ARCHIVE, a name in the archive directory, an alias such as @latest
+or a path, read-only at MOUNTPOINT using FUSE. Files are decompressed as they
+are read, so large archives can be browsed without extracting them.
+
+The command keeps running while the archive is mounted; press Ctrl-C (or send
+SIGTERM) to unmount it. Mounting needs FUSE on Linux: root mounts directly,
+other users need fusermount3 or fusermount.`,
+		Example: `  bkpdir mount myproject-2024-03-20-14-30.zip /mnt/archive`,
+		Args:    cobra.ExactArgs(2),
+		Run: func(_ *cobra.Command, args []string) {
+			runWithConfig(func(cfg *Config) error {
+				return MountArchive(os.Stdout, cfg, args[0], args[1])
+			})
+		},
+	}
+}
+
+// ⭐ KEYRING-001: Keyring command group - 🔧
+func keyringCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "keyring",
+		Short: "Store credentials in the OS credential store",
+		Long: `Keep credentials in the OS credential store (the login keychain on macOS,
+the Secret Service through secret-tool on Linux) under an alias, and refer to
+them from the configuration as secretref:keychain/ALIAS instead of writing
+them in plain text.
+
+'keyring set' reads the secret from stdin, prompting without echo on a
+terminal.`,
+		Example: `  bkpdir keyring set backup-passphrase
+  printf '%s\n' "$DB_URL" | bkpdir keyring set shop-db
+
+  # .bkpdir.yml
+  encryption:
+    passphrase: secretref:keychain/backup-passphrase`,
+	}
+
+	var setFlags *cli.FlagBinding[dryRunOptions]
+	setCmd := &cobra.Command{
+		Use:   "set ALIAS",
+		Short: "Store a secret under ALIAS, replacing any previous one",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(setFlags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				return KeyringSet(cfg, os.Stdin, os.Stdout, args[0], opts.DryRun)
+			})
+		},
+	}
+	setFlags = bindDryRun(setCmd)
+	cmd.AddCommand(setCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "get ALIAS",
+		Short: "Print the secret stored under ALIAS",
+		Args:  cobra.ExactArgs(1),
+		Run: func(_ *cobra.Command, args []string) {
+			runWithConfig(func(cfg *Config) error {
+				return KeyringGet(cfg, os.Stdout, args[0])
+			})
+		},
+	})
+
+	var deleteFlags *cli.FlagBinding[dryRunOptions]
+	deleteCmd := &cobra.Command{
+		Use:   "delete ALIAS",
+		Short: "Remove the secret stored under ALIAS",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(deleteFlags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				return KeyringDelete(cfg, os.Stdout, args[0], opts.DryRun)
+			})
+		},
+	}
+	deleteFlags = bindDryRun(deleteCmd)
+	cmd.AddCommand(deleteCmd)
+
+	return cmd
+}
+
+// ⭐ REMOTE-001: Upload command - 🔧
+func uploadCmd() *cobra.Command {
+	var flags *cli.FlagBinding[UploadOptions]
+	cmd := &cobra.Command{
+		Use:   "upload [REMOTE [ARCHIVE...]]",
+		Short: "Upload archives to a remote with resumable multipart uploads",
+		Long: `Upload archives to REMOTE: s3://BUCKET/PREFIX (Amazon S3 or an S3-compatible
+store set with remote.s3_endpoint), file://DIR or a directory path such as a
+network mount. Without ARCHIVE every archive in the archive directory is
+uploaded; archives the remote already stores with the same size are skipped.
+
+Archives are uploaded in parts of remote.upload_part_size. A session journal
+in the archive directory records every stored part, so an upload interrupted
+by Ctrl-C, a crash or a network failure continues with --resume after its last
+stored part. --resume without REMOTE continues every interrupted upload.
+--restart discards interrupted uploads and starts them over.`,
+		Example: `  bkpdir upload s3://backups/laptop
+  bkpdir upload /mnt/nas/backups myproject-2024-03-20-14-30.zip
+  bkpdir upload --resume
+  bkpdir upload --restart s3://backups/laptop`,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				opts.Config, opts.Output, opts.Context = cfg, os.Stdout, ctx
+				if len(args) > 0 {
+					opts.Remote, opts.Archives = args[0], args[1:]
+				}
+				return UploadArchives(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, UploadOptions{}).
+		InheritBool(func(o *UploadOptions) *bool { return &o.DryRun }, "dry-run").
+		Bool(func(o *UploadOptions) *bool { return &o.Resume }, "resume", "",
+			"Continue interrupted uploads from their session journal").
+		Bool(func(o *UploadOptions) *bool { return &o.Restart }, "restart", "",
+			"Discard interrupted uploads and start them over")
+	return cmd
+}
+
+// ⭐ TIER-001: Tiering command - 🔧
+func tierCmd() *cobra.Command {
+	var flags *cli.FlagBinding[TierOptions]
+	cmd := &cobra.Command{
+		Use:   "tier",
+		Short: "Move old archives to cold storage",
+		Long: `Move the archives of the current directory that are older than
+tiering.after_days to tiering.cold_remote, for example an S3 bucket with
+tiering.storage_class set to GLACIER.
+
+Each archive is uploaded like 'bkpdir upload', checked on the remote and
+recorded in .metadata/cold-storage.json before the local copy is removed.
+Its sidecars stay in .metadata, so 'bkpdir list' shows it with [COLD] and
+where it lives, and 'bkpdir verify' reports where it is instead of failing.
+An interrupted upload is continued with 'bkpdir upload --resume'; run tier
+again afterwards to finish the move.`,
+		Example: `  bkpdir tier --dry-run
+  bkpdir tier`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				opts.Config, opts.Output, opts.Context = cfg, os.Stdout, ctx
+				return TierArchives(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, TierOptions{}).
+		InheritBool(func(o *TierOptions) *bool { return &o.DryRun }, "dry-run")
+	return cmd
+}
+
+// ⭐ AUDIT-001: Audit command group - 🔧
+func auditCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit",
+		Short: "Review and check the operation audit log",
+		Long: `With audit_log: true, every create, trash, prune (trash empty), restore, tier
+and config-set action is appended to .metadata/audit.jsonl under
+archive_dir_path, with the user, host and outcome. Each entry records the
+SHA-256 of the entry before it, so 'audit verify' detects entries that were
+edited, inserted or removed. Entries removed from the end are only detected
+by comparing with a last hash printed earlier.`,
+		Example: `  bkpdir audit show --action restore
+  bkpdir audit verify`,
+	}
+
+	var showFlags *cli.FlagBinding[AuditOptions]
+	showCmd := &cobra.Command{
+		Use:   "show",
+		Short: "Print the recorded actions, oldest first",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(showFlags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Config, opts.Output = cfg, os.Stdout
+				return ShowAuditLog(opts)
+			})
+		},
+	}
+	showFlags = cli.BindFlags(showCmd, AuditOptions{}).
+		String(func(o *AuditOptions) *string { return &o.Action }, "action", "",
+			"Only show one action: create, trash, prune, restore, tier or config-set").
+		Int(func(o *AuditOptions) *int { return &o.Limit }, "limit", "",
+			"Only show the newest N entries")
+	cmd.AddCommand(showCmd)
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "verify",
+		Short: "Check the hash chain of the audit log",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			runWithConfig(func(cfg *Config) error {
+				return CheckAuditLog(cfg, os.Stdout)
+			})
+		},
+	})
+	return cmd
+}
+
+// ⭐ CLONE-001: Clone command - 🔧
+// ⭐ BENCH-001: Environment performance profile command - 🔧
+func benchCmd() *cobra.Command {
+	var flags *cli.FlagBinding[BenchOptions]
+	cmd := &cobra.Command{
+		Use:   "bench",
+		Short: "Measure disk, compression and checksum speed and recommend settings",
+		Long: `Measure how fast this machine reads the current directory, deflates it at
+compression levels 0 (stored) to 9, and computes the checksums accepted by
+verification.checksum_algorithm, then recommend settings.
+
+Up to --size of the files that would be archived are read once; the first
+32MB of them are kept in memory for the compression and checksum
+measurements, so these do not depend on the disk. Recently read files may
+come from the page cache and read faster than the disk. Nothing is written.
+
+The recommended compression_level is the highest level that keeps up with
+reading the disk, or 0 when the data barely compresses. With several CPUs,
+archive_jobs: auto is recommended.`,
+		Example: `  bkpdir bench
+  bkpdir bench --size 1GB`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				cwd, err := os.Getwd()
+				if err != nil {
+					return err
+				}
+				opts.Config, opts.Output, opts.Dir = cfg, os.Stdout, cwd
+				return RunBench(context.Background(), opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, BenchOptions{}).
+		String(func(o *BenchOptions) *string { return &o.Size }, "size", "",
+			"Data read from the current directory (default "+defaultBenchSize+")")
+	return cmd
+}
+
+func selftestCmd() *cobra.Command {
+	var flags *cli.FlagBinding[SelftestOptions]
+	cmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Back up, verify and restore synthetic data to check this installation",
+		Long: `Create synthetic files in a temporary sandbox, archive them, verify the
+archive, restore it and compare the restored files with the originals,
+stopping at the first step that fails.
+
+The test uses the default configuration with the compression_level,
+archive_jobs and max_memory of the current configuration, so hooks, plugins,
+notifications and the audit log are not involved. Use --archive-dir to write
+the archive to the storage you want to check, e.g. a mounted network share;
+it is created in a temporary directory there and removed afterwards unless
+--keep is given.
+
+--report junit=FILE writes a JUnit XML report with a test case per step;
+steps after a failed one are skipped.`,
+		Example: `  bkpdir selftest
+  bkpdir selftest --archive-dir /mnt/backup --size 64MB
+  bkpdir selftest --report junit=selftest.xml`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Config, opts.Output = cfg, os.Stdout
+				return RunSelftest(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, SelftestOptions{}).
+		String(func(o *SelftestOptions) *string { return &o.ArchiveDir }, "archive-dir", "",
+			"Directory to write the test archive to (default: the sandbox)").
+		String(func(o *SelftestOptions) *string { return &o.Size }, "size", "",
+			"Amount of synthetic data (default "+defaultSelftestSize+")").
+		Bool(func(o *SelftestOptions) *bool { return &o.Keep }, "keep", "",
+			"Keep the sandbox and test archive").
+		String(func(o *SelftestOptions) *string { return &o.Report }, "report", "",
+			"Write a report of the steps: junit=FILE for JUnit XML")
+	return cmd
+}
+
+// ⭐ LAST-001: Last runs command - 🔍
+func lastCmd() *cobra.Command {
+	var flags *cli.FlagBinding[LastOptions]
+	cmd := &cobra.Command{
+		Use:   "last [PATH]",
+		Short: "Show when the last full, incremental and backup runs succeeded",
+		Long: `Show when the last full archive, incremental archive and file backup of PATH
+succeeded, with the archive created, its size and how long the run took, and
+the error of the last run when it failed. PATH is a directory or a backed-up
+file and defaults to the current directory.
+
+Each run is recorded in runs.json in the state directory ($BKPDIR_STATE_DIR,
+$XDG_STATE_HOME/bkpdir or ~/.local/state/bkpdir), which keeps the last
+run_history runs of each target. Dry runs are not recorded. The command exits
+with status_file_not_found when no run of PATH was recorded.`,
+		Example: `  # Did last night's backup run?
+  bkpdir last
+
+  bkpdir last ~/src/project
+  bkpdir last --all --output json`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			if len(args) > 0 {
+				opts.Target = args[0]
+			}
+			runWithConfig(func(cfg *Config) error {
+				return ShowLastRuns(os.Stdout, cfg, opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, LastOptions{Output: OutputText}).
+		Bool(func(o *LastOptions) *bool { return &o.All }, "all", "", "Show every recorded directory and file").
+		String(func(o *LastOptions) *string { return &o.Output }, "output", "", "Output format: text or json")
+	return cmd
+}
+
+func cloneCmd() *cobra.Command {
+	var flags *cli.FlagBinding[CloneOptions]
+	cmd := &cobra.Command{
+		Use:   "clone SRC DST",
+		Short: "Copy archives between storage backends",
+		Long: `Copy the archives stored in SRC, with their .metadata sidecars, to DST. Both
+are s3://BUCKET/PREFIX, file://DIR or a directory path, so archives can be
+replicated from the archive directory to a NAS and on to S3 for a 3-2-1
+backup strategy.
+
+Archives DST already stores with the same size are skipped. Every copied
+object is read back from DST and compared with SRC by SHA-256; a mismatch
+stops the clone. Archives are copied oldest first.`,
+		Example: `  bkpdir clone ../.bkpdir/project /mnt/nas/backups/project
+  bkpdir clone /mnt/nas/backups/project s3://backups/project --older-than 168h
+  bkpdir clone s3://backups/project ./restore-copy --match 'project-2024-03-*'`,
+		Args: cobra.ExactArgs(2),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+				defer stop()
+				opts.Config, opts.Output, opts.Context = cfg, os.Stdout, ctx
+				opts.Source, opts.Dest = args[0], args[1]
+				return CloneArchives(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, CloneOptions{}).
+		InheritBool(func(o *CloneOptions) *bool { return &o.DryRun }, "dry-run").
+		String(func(o *CloneOptions) *string { return &o.Match }, "match", "",
+			"Only clone archives whose name matches the glob").
+		Duration(func(o *CloneOptions) *time.Duration { return &o.OlderThan }, "older-than", "",
+			"Only clone archives older than this (e.g. 168h)").
+		Duration(func(o *CloneOptions) *time.Duration { return &o.NewerThan }, "newer-than", "",
+			"Only clone archives newer than this (e.g. 24h)")
+	return cmd
+}
+
+// ⭐ EXPLAIN-001: Explain command - 🔍
+func explainCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "explain",
+		Short: "Show what an operation would do without running it",
+	}
+
+	var flags *cli.FlagBinding[ExplainOptions]
+	createCmd := &cobra.Command{
+		Use:   "create [NOTE]",
+		Short: "Show the plan of an archive of the current directory",
+		Long: `Print the plan of 'bkpdir full' (or 'bkpdir inc' with --incremental) for the
+current directory without executing anything:
+
+- the settings that affect the archive, with the file or default they come from
+- the archive name, its destination and the number of files it would hold
+- the exclusion patterns in effect and where each is configured
+- the database hooks, plugins and notifications that would run
+
+Nothing is written: the archive directory is not created and no hook runs.`,
+		Example: `  bkpdir explain create
+  bkpdir explain create "before refactor" --exclude-from .bkpignore
+  bkpdir explain create --incremental --base myproject-2024-03-21-15-30.zip`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			cwd, err := os.Getwd()
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+				os.Exit(1)
+			}
+			runWithConfig(func(cfg *Config) error {
+				opts.Config, opts.Output, opts.Dir = cfg, os.Stdout, cwd
+				if opts.Note == "" && len(args) > 0 {
+					opts.Note = args[0]
+				}
+				return ExplainCreate(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(createCmd, ExplainOptions{}).
+		String(func(o *ExplainOptions) *string { return &o.Note }, "note", "n", "Note added to the archive name").
+		Bool(func(o *ExplainOptions) *bool { return &o.Incremental }, "incremental", "i",
+			"Explain an incremental archive instead of a full one").
+		String(func(o *ExplainOptions) *string { return &o.Base }, "base", "",
+			"Full archive the incremental archive is based on (default: the latest full archive)").
+		StringArray(func(o *ExplainOptions) *[]string { return &o.ExcludeFrom }, "exclude-from", "",
+			"Read additional exclusion patterns from FILE (repeatable)")
+	cmd.AddCommand(createCmd)
+	return cmd
+}
+
+// ⭐ ANNOTATE-001: Annotate command - 🔧
+func annotateCmd() *cobra.Command {
+	var flags *cli.FlagBinding[AnnotateOptions]
+	cmd := &cobra.Command{
+		Use:   "annotate ARCHIVE",
+		Short: "Change the note and tags of an existing archive",
+		Long: `Record a note and tags for ARCHIVE, a name in the archive directory or a
+path. They are stored in the archive's .metadata sidecar; the archive itself
+is not changed, so its checksums and integrity seal stay valid. Listings show
+the annotated note instead of the note in the name.
+
+--tag KEY=VALUE sets a tag and may be repeated; --tag KEY= removes it.
+
+With --rename the archive is also renamed so that its name carries the note,
+following the naming convention. Its sidecars are renamed with it, its
+integrity seal is renewed for the new name and its SHA256SUMS entry is
+updated. Full archives that incremental archives are based on cannot be
+renamed.`,
+		Example: `  bkpdir annotate myproject-2024-03-20-14-30.zip --note "before release"
+  bkpdir annotate myproject-2024-03-20-14-30.zip --tag ticket=OPS-12 --tag keep=yes
+  bkpdir annotate myproject-2024-03-20-14-30=wip.zip --note release-1.2 --rename`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Config, opts.Output, opts.Archive = cfg, os.Stdout, args[0]
+				opts.SetNote = cmd.Flags().Changed("note")
+				return AnnotateArchive(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, AnnotateOptions{}).
+		InheritBool(func(o *AnnotateOptions) *bool { return &o.DryRun }, "dry-run").
+		String(func(o *AnnotateOptions) *string { return &o.Note }, "note", "n",
+			"New note for the archive (empty removes it)").
+		StringArray(func(o *AnnotateOptions) *[]string { return &o.Tags }, "tag", "t",
+			"Set a KEY=VALUE tag; KEY= removes it (repeatable)").
+		Bool(func(o *AnnotateOptions) *bool { return &o.Rename }, "rename", "",
+			"Rename the archive so its name carries the note")
+	return cmd
+}
+
+// ⭐ MIGRATE-001: Migrate names command - 🔧
+func migrateNamesCmd() *cobra.Command {
+	var flags *cli.FlagBinding[MigrateNamesOptions]
+	cmd := &cobra.Command{
+		Use:   "migrate-names",
+		Short: "Rename archives after the timestamp layout of names changed",
+		Long: `Rename the archives of the current directory whose names carry a timestamp
+in the --from-template layout so they carry it in the --to-template layout.
+Both are Go time layouts like timestamp_format and default to it, so set the
+one that differs from the configuration: --from-template after changing
+timestamp_format, or --to-template before changing it.
+
+The prefix, sequence suffix, Git fields and note of each name are kept.
+Incremental archives are renamed with their base archive, so they still name
+it. Sidecars in .metadata are renamed with the archives, integrity seals are
+renewed for the new names and SHA256SUMS entries are updated.
+
+Without --apply the renames are only listed. Archives whose names are not in
+the old layout are left alone, and nothing is renamed if two archives would
+get the same name.`,
+		Example: `  bkpdir migrate-names --to-template 2006-01-02T15-04-05
+  bkpdir migrate-names --from-template 2006-01-02-15-04 --apply`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Config, opts.Output = cfg, os.Stdout
+				return MigrateArchiveNames(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, MigrateNamesOptions{}).
+		InheritBool(func(o *MigrateNamesOptions) *bool { return &o.DryRun }, "dry-run").
+		String(func(o *MigrateNamesOptions) *string { return &o.FromLayout }, "from-template", "",
+			"Timestamp layout of the existing names (default: timestamp_format)").
+		String(func(o *MigrateNamesOptions) *string { return &o.ToLayout }, "to-template", "",
+			"Timestamp layout of the new names (default: timestamp_format)").
+		Bool(func(o *MigrateNamesOptions) *bool { return &o.Apply }, "apply", "",
+			"Rename the archives instead of listing the renames")
+	return cmd
+}
+
+// ⭐ XDG-001: Migrate directories command - 🔧
+func migrateDirsCmd() *cobra.Command {
+	var flags *cli.FlagBinding[MigrateDirsOptions]
+	cmd := &cobra.Command{
+		Use:   "migrate-dirs",
+		Short: "Move state and configuration files to the XDG directories",
+		Long: `Move files of earlier layouts to the directories bkpdir now uses:
+
+  state   $XDG_STATE_HOME/bkpdir, ~/Library/Application Support/bkpdir on
+          macOS, else ~/.local/state/bkpdir (run history, undo journal)
+  cache   $XDG_CACHE_HOME/bkpdir, ~/Library/Caches/bkpdir on macOS, else
+          ~/.cache/bkpdir (remote listings)
+  config  $XDG_CONFIG_HOME/bkpdir/config.yml, else ~/.config/bkpdir/config.yml
+
+The undo journal moves out of the user configuration directory, and on macOS
+the state directory moves out of ~/.local/state. Until they are moved, files
+of earlier layouts keep being used where they are. With --config,
+~/.bkpdir.yml moves to the config directory too; update any inherit entries
+naming it.
+
+Without --apply the moves are only listed. Files whose destination exists are
+left alone, as are locations set by BKPDIR_STATE_DIR, BKPDIR_JOURNAL and
+BKPDIR_CONFIG.`,
+		Example: `  bkpdir migrate-dirs
+  bkpdir migrate-dirs --config --apply`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Output = os.Stdout
+				return MigrateDirs(cfg, opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, MigrateDirsOptions{}).
+		InheritBool(func(o *MigrateDirsOptions) *bool { return &o.DryRun }, "dry-run").
+		Bool(func(o *MigrateDirsOptions) *bool { return &o.Config }, "config", "",
+			"Also move ~/.bkpdir.yml to the config directory").
+		Bool(func(o *MigrateDirsOptions) *bool { return &o.Apply }, "apply", "",
+			"Move the files instead of listing the moves")
+	return cmd
+}
+
+// ⭐ FOREIGN-001: Garbage collection command - 🔧
+func gcCmd() *cobra.Command {
+	var flags *cli.FlagBinding[GCOptions]
+	cmd := &cobra.Command{
+		Use:   "gc --foreign",
+		Short: "Deal with files in the archive directory that bkpdir did not create",
+		Long: `Apply foreign_files to the foreign files in the archive directory of the
+current directory: files bkpdir did not create, such as ZIP files whose names
+pattern_archive_filename cannot parse and that have no sidecar in .metadata.
+Foreign files are never listed, verified, tiered or removed as archives.
+
+With foreign_files: ignore (the default) they are only listed. With
+foreign_files: quarantine they are moved to the trash, from where
+'bkpdir trash restore NAME' brings them back; --dry-run lists the moves.`,
+		Example: `  bkpdir gc --foreign
+  bkpdir gc --foreign --dry-run`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Output = os.Stdout
+				return CollectGarbage(cfg, opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, GCOptions{}).
+		InheritBool(func(o *GCOptions) *bool { return &o.DryRun }, "dry-run").
+		Bool(func(o *GCOptions) *bool { return &o.Foreign }, "foreign", "",
+			"Apply foreign_files to the files bkpdir did not create")
+	return cmd
+}
+
+// ⭐ CHAIN-CHECK-001: Archive directory health check - 🛡️
+func doctorCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "doctor",
+		Short: "Check the archive directory for problems and suggest repairs",
+		Long: `Check the archive directory of the current directory for problems that make
+archives unusable or that other commands only warn about, and print a way to
+repair each one:
+
+  - incremental chains whose full archive was pruned, deleted or is corrupt,
+    and corrupt incremental archives, as 'bkpdir verify --chain' reports them
+  - foreign files that bkpdir did not create (see 'bkpdir gc --foreign')
+
+Archives are checked with the structural check of 'verify --quick', so the
+data is not read. The exit status is 1 when a problem needs attention.`,
+		Args: cobra.NoArgs,
+		Run: func(*cobra.Command, []string) {
+			runWithConfig(func(cfg *Config) error {
+				return RunDoctor(os.Stdout, cfg)
+			})
+		},
+	}
+}
+
+// ⭐ FILE-HISTORY-001: File history command - 🔍
+func historyCmd() *cobra.Command {
+	var flags *cli.FlagBinding[HistoryOptions]
+	cmd := &cobra.Command{
+		Use:   "history PATH",
+		Short: "Show the versions of a file across archives",
+		Long: `List every archive of the current directory that contains PATH, oldest first,
+with the size and hash of the file in each. Versions are numbered from 1, and
+--restore-version N extracts version N without restoring the whole archive.
+
+Hashes are SHA-256 when the archive recorded them, through manifest_file_hashes
+or checksums, and the CRC-32 of the zip entry otherwise.
+
+The file is restored to PATH unless --output names another file; "-" writes it
+to standard output. An existing file is only replaced with --overwrite.
+
+With --journal, the changes recorded by change_journal are shown instead:
+when PATH was created, modified or deleted, and the archive that recorded each
+change. No archive is opened.`,
+		Example: `  bkpdir history src/main.go
+  bkpdir history src/main.go --restore-version 2 --output /tmp/main.go
+  bkpdir history src/main.go --journal`,
+		Args: cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(cfg *Config) error {
+				opts.Config, opts.Output, opts.Path = cfg, os.Stdout, args[0]
+				return ShowFileHistory(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, HistoryOptions{}).
+		InheritBool(func(o *HistoryOptions) *bool { return &o.DryRun }, "dry-run").
+		Int(func(o *HistoryOptions) *int { return &o.RestoreVersion }, "restore-version", "",
+			"Extract version N of the file").
+		String(func(o *HistoryOptions) *string { return &o.Target }, "output", "o",
+			"File the version is extracted to, or - for standard output (default: PATH)").
+		Bool(func(o *HistoryOptions) *bool { return &o.Overwrite }, "overwrite", "",
+			"Replace an existing file with the extracted version").
+		Bool(func(o *HistoryOptions) *bool { return &o.Journal }, "journal", "",
+			"Show the changes recorded by change_journal instead of the versions")
+	return cmd
+}
+
+// ⭐ DOCS-001: Reference documentation command - 📝
+func docsCmd() *cobra.Command {
+	var flags *cli.FlagBinding[DocsOptions]
+	cmd := &cobra.Command{
+		Use:   "docs man|markdown|rest",
+		Short: "Generate man pages or reference documentation",
+		Long: `Write a page for every command in the given format to --dir, together with
+bkpdir-config-reference, which lists every configuration key with its type,
+default value and description.
+
+man writes section 1 pages for the commands and a section 5 page for the
+configuration keys; markdown and rest write .md and .rst files.`,
+		Example: `  bkpdir docs man --dir /usr/local/share/man/man1
+  bkpdir docs markdown --dir docs/reference`,
+		Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+		ValidArgs: []string{"man", "markdown", "rest"},
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			runWithConfig(func(*Config) error {
+				opts.Root, opts.Format, opts.Output = cmd.Root(), args[0], os.Stdout
+				return GenerateReferenceDocs(opts)
+			})
+		},
+	}
+	flags = cli.BindFlags(cmd, DocsOptions{Dir: "."}).
+		String(func(o *DocsOptions) *string { return &o.Dir }, "dir", "",
+			"Directory the pages are written to")
+	return cmd
+}
+
+func verifyCmd() *cobra.Command {
+	// Archive verification command
+	// 🔺 CFG-003: Verify command interface - 🛡️
+	var flags *cli.FlagBinding[verifyCmdOptions]
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Verify archives",
+		Long: `Verify the integrity of one archive or of all archives for the current directory.
+
+The archive may be given by an alias: @latest is the most recent archive,
+@last-full the most recent full archive and @last-verified the most recent
+archive whose last verification succeeded. Aliases are accepted wherever an
+archive name is, for example by restore, mount, annotate and upload.
+
+Use --sample to check a random subset of entries in each archive, for example
+--sample 10% or --sample 200. Sampled entries are read completely and, with
+--checksum, compared against stored checksums. The report includes a 95%
+confidence bound on the fraction of corrupt entries; omit --sample for full
+verification.
+
+Use --against-dir DIR ARCHIVE to audit a restored (or the original) tree: every
+file in the archive must exist in DIR with identical content, and DIR must not
+contain other files apart from excluded ones. Extra files are not reported for
+incremental archives, which only hold changed files.
+
+Use --checksum-file FILE to check the files listed in a sha256sum-compatible
+checksum file, such as one written by 'bkpdir checksum write'.
+
+Use --quick for a structural check that reads no data: the ZIP central
+directory, the local header of every entry and, when the archive has one, its
+manifest must be intact. It takes milliseconds per archive, catches truncated
+and overwritten archives, but not corrupt data, and is not recorded as a
+verification. 'bkpdir list --verify-inline' runs the same check.
+
+With --checksum every entry is checked and all corrupt entries are reported.
+Entries are compared against the .checksums entry of the archive or, without
+one, the SHA-256 hashes its manifest records with manifest_file_hashes;
+archives with neither only have the CRC-32 of every entry checked.
+--progress prints "ok" or "FAIL" for each entry as it is checked, with a
+progress bar when stderr is a terminal. --fail-fast stops at the first corrupt
+entry and, when verifying all archives, at the first failed archive.
+
+Archives moved to cold storage by 'bkpdir tier' are verified from their
+metadata: the object on the cold remote must have the recorded size, and the
+checksums and manifest kept in .metadata must be readable. Their content is
+only read with --thaw. Archives in an archival class such as S3 Glacier are
+first retrieved: --thaw requests the retrieval at --thaw-tier and returns; run
+the same command again once it completes to download and verify the archive.
+Retrieval and downloads are charged by the provider and announced with a
+warning.
+
+With power_aware set, verification is deferred on battery power or under high
+load and exits with status_deferred; --ignore-power runs it anyway.
+
+--report junit=FILE writes a JUnit XML report for CI systems such as Jenkins
+and GitLab: each archive is a test case that fails with the problems found,
+and archives left unchecked by --fail-fast are skipped. The report is written
+whether or not verification succeeds.
+
+Use --chain to check the incremental chains instead: an incremental archive
+only holds the files changed since its full archive, so it cannot be restored
+when that archive was pruned, deleted or fails the --quick check. Broken
+chains and corrupt incremental archives are reported with ways to repair
+them; chains whose incremental archives are all annotated with
+--tag chain=unusable are only listed. 'bkpdir doctor' runs the same check.`,
+		Example: `  bkpdir verify myproject-2024-03-20-14-30.zip --checksum --progress
+  bkpdir verify --quick
+  bkpdir verify myproject-2024-03-20-14-30.zip --sample 10%
+  bkpdir verify @last-full --checksum
+  bkpdir verify --checksum --fail-fast
+  bkpdir verify --checksum --report junit=verify.xml
+  bkpdir verify --chain`,
+		Args: cobra.MaximumNArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			handleVerifyCommand(opts, args)
+		},
+	}
+	flags = cli.BindFlags(cmd, verifyCmdOptions{}).
+		Bool(func(o *verifyCmdOptions) *bool { return &o.WithChecksum }, "checksum", "c",
+			"Include checksum verification of archive contents").
+		// ⭐ ARCH-006: Sampled verification flag - 🔍
+		String(func(o *verifyCmdOptions) *string { return &o.Sample }, "sample", "",
+			"Verify a random sample of entries (e.g. 10% or 200)").
+		// ⭐ VERIFY-DIR-001: Compare a restored or original tree with an archive - 🛡️
+		String(func(o *verifyCmdOptions) *string { return &o.AgainstDir }, "against-dir", "",
+			"Compare DIR with the archive and report missing, extra and changed files").
+		// ⭐ SUMS-001: Verify against a sha256sum-compatible checksum file - 🛡️
+		String(func(o *verifyCmdOptions) *string { return &o.ChecksumFile }, "checksum-file", "",
+			"Verify the files listed in a sha256sum checksum file").
+		// ⭐ VERIFY-PROGRESS-001: Per-entry status stream and early stop - 🛡️
+		Bool(func(o *verifyCmdOptions) *bool { return &o.Progress }, "progress", "",
+			"Print the result of each entry as it is checked (with --checksum)").
+		Bool(func(o *verifyCmdOptions) *bool { return &o.FailFast }, "fail-fast", "",
+			"Stop at the first corrupt entry or failed archive").
+		// ⭐ VERIFY-QUICK-001: Structural check in milliseconds - 🛡️
+		Bool(func(o *verifyCmdOptions) *bool { return &o.Quick }, "quick", "q",
+			"Only check the archive structure (headers, central directory, manifest) without reading the data").
+		// ⭐ THAW-001: Full verification of archives in cold storage - 🛡️
+		Bool(func(o *verifyCmdOptions) *bool { return &o.Thaw }, "thaw", "",
+			"Retrieve archives in cold storage and verify their content (retrieval is charged)").
+		String(func(o *verifyCmdOptions) *string { return &o.ThawTier }, "thaw-tier", "",
+			"Retrieval tier of archival storage classes: Expedited, Standard or Bulk (default Standard)").
+		Int(func(o *verifyCmdOptions) *int { return &o.ThawDays }, "thaw-days", "",
+			"Days a retrieved archive stays readable (default 1)").
+		// ⭐ POWER-001: Override power_aware - 🔧
+		Bool(func(o *verifyCmdOptions) *bool { return &o.IgnorePower }, "ignore-power", "",
+			"Run even on battery or under load when power_aware is set").
+		// ⭐ JUNIT-001: Report for CI - 📝
+		String(func(o *verifyCmdOptions) *string { return &o.Report }, "report", "",
+			"Write a report of the verified archives: junit=FILE for JUnit XML").
+		// ⭐ CHAIN-CHECK-001: Broken incremental chains - 🛡️
+		Bool(func(o *verifyCmdOptions) *bool { return &o.Chain }, "chain", "",
+			"Check that every incremental archive has a usable full archive and suggest repairs")
+	for _, other := range []string{"checksum", "sample", "against-dir", "checksum-file", "progress", "thaw"} {
+		cmd.MarkFlagsMutuallyExclusive("quick", other)
+	}
+	for _, other := range []string{"quick", "checksum", "sample", "against-dir", "checksum-file", "thaw"} {
+		cmd.MarkFlagsMutuallyExclusive("chain", other)
+	}
+	return cmd
+}
+
+func versionCmd() *cobra.Command {
+	// Version display command
+	// 🔺 CFG-003: Version command interface - 📝
+	cmd := &cobra.Command{
+		Use:   "version",
 		Short: "Display version information",
 		Run: func(*cobra.Command, []string) {
 			handleVersionCommand()
@@ -1017,6 +2650,84 @@ func versionCmd() *cobra.Command {
 	return cmd
 }
 
+// ⭐ UNDO-001: Undo command - 🔧
+func undoCmd() *cobra.Command {
+	var flags *cli.FlagBinding[undoCmdOptions]
+	cmd := &cobra.Command{
+		Use:   "undo",
+		Short: "Revert the most recent configuration change",
+		Long: `Revert the most recent journaled operation.
+
+Every 'bkpdir config KEY VALUE' records the previous configuration file in a
+journal (bkpdir/journal.json under the user configuration directory, or
+$BKPDIR_JOURNAL). 'bkpdir undo' restores that file and drops the entry, so
+repeated undo walks back through earlier changes.
+
+Undo refuses when the file was edited after the journaled change; use --force
+to discard those later edits. With --dry-run the operation is only reported.`,
+		Example: `  bkpdir config archive_dir_path /backups
+  bkpdir undo`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			handleUndoCommand(commandOptions(flags, cmd))
+		},
+	}
+	flags = cli.BindFlags(cmd, undoCmdOptions{}).
+		InheritBool(func(o *undoCmdOptions) *bool { return &o.DryRun }, "dry-run").
+		Bool(func(o *undoCmdOptions) *bool { return &o.Force }, "force", "",
+			"Undo even if the file changed after the journaled operation")
+	return cmd
+}
+
+// ⭐ TRASH-001: Trash command group - 🔧
+func trashCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "trash",
+		Short: "Inspect and recover removed archives",
+		Long: `Removed archives are moved to the trash directory (trash_dir_path) instead of
+being deleted, and kept for trash_retention_days. Use these subcommands to list,
+restore or permanently delete trashed items.`,
+	}
+
+	cmd.AddCommand(&cobra.Command{
+		Use:   "list",
+		Short: "List trashed items",
+		Args:  cobra.NoArgs,
+		Run: func(_ *cobra.Command, _ []string) {
+			handleTrashListCommand()
+		},
+	})
+
+	var restoreFlags *cli.FlagBinding[dryRunOptions]
+	restoreCmd := &cobra.Command{
+		Use:   "restore NAME",
+		Short: "Move a trashed item back to its original location",
+		Args:  cobra.ExactArgs(1),
+		Run: func(cmd *cobra.Command, args []string) {
+			handleTrashRestoreCommand(args[0], commandOptions(restoreFlags, cmd).DryRun)
+		},
+	}
+	restoreFlags = bindDryRun(restoreCmd)
+	cmd.AddCommand(restoreCmd)
+
+	var emptyFlags *cli.FlagBinding[trashEmptyOptions]
+	emptyCmd := &cobra.Command{
+		Use:   "empty",
+		Short: "Permanently delete trashed items",
+		Args:  cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			handleTrashEmptyCommand(commandOptions(emptyFlags, cmd))
+		},
+	}
+	emptyFlags = cli.BindFlags(emptyCmd, trashEmptyOptions{}).
+		InheritBool(func(o *trashEmptyOptions) *bool { return &o.DryRun }, "dry-run").
+		Bool(func(o *trashEmptyOptions) *bool { return &o.Expired }, "expired", "",
+			"Only delete items past their retention window")
+	cmd.AddCommand(emptyCmd)
+
+	return cmd
+}
+
 // ArchiveOptions holds parameters for archive creation functions
 type ArchiveOptions struct {
 	Context   context.Context
@@ -1046,30 +2757,135 @@ func CreateIncrementalArchiveEnhanced(opts ArchiveOptions) error {
 // ListArchivesEnhanced displays all archives in the archive directory with enhanced formatting
 // and error handling.
 func ListArchivesEnhanced(cfg *Config, formatter formatter.OutputFormatterInterface) error {
+	return ListArchivesWithOptions(ListOptions{Config: cfg, Formatter: formatter})
+}
+
+// ListOptions holds the parameters for listing archives.
+type ListOptions struct {
+	Config    *Config
+	Formatter formatter.OutputFormatterInterface
+	// 🔶 GIT-007: Glob matched against the recorded Git tag and describe output
+	TagPattern string
+	// ⭐ LIST-001: Pagination over the sorted listing; Limit 0 lists everything
+	Limit  int
+	Offset int
+	// ⭐ REPORT-001: "text" (default) or "json" for an ArchiveListReport
+	Output string
+	// ⭐ LIST-VERIFY-001: Check unverified archives within VerifyBudget (0 for no limit)
+	VerifyInline bool
+	VerifyBudget time.Duration
+	// ⭐ REMOTE-CACHE-001: List a remote archive directory again instead of using the cache
+	Refresh bool
+	// ⭐ SHARED-001: Only list the archives of this user
+	Owner string
+	// ⭐ FOREIGN-001: List the files bkpdir did not create in a separate section
+	Foreign bool
+	// ⭐ LIST-TREE-001: Group incremental archives under their base; Limit and
+	// Offset then count chains
+	Tree bool
+}
+
+// ListArchivesWithOptions lists archives using the provided options.
+func ListArchivesWithOptions(opts ListOptions) error {
 	// ⭐ ARCH-002: Enhanced archive listing with formatting - 🔍
 	// 🔺 CFG-003: Template-based archive listing - 🔍
+	cfg := opts.Config
+	formatter := opts.Formatter
 	cwd, err := os.Getwd()
 	if err != nil {
 		return NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
 	}
 
-	archiveDir := cfg.ArchiveDirPath
+	// ⭐ SHARED-001: Another user's archives with --owner
+	owner := opts.Owner
+	if owner == "" {
+		owner = archiveUser()
+	}
+	archiveDir := archiveRootDir(cfg, owner)
 	if cfg.UseCurrentDirName {
 		archiveDir = filepath.Join(archiveDir, filepath.Base(cwd))
 	}
 
-	archives, err := ListArchives(archiveDir)
-	if err != nil {
+	if opts.Limit < 0 || opts.Offset < 0 {
+		return NewArchiveError("--limit and --offset must not be negative", cfg.StatusConfigError)
+	}
+	jsonOutput := opts.Output == OutputJSON
+	if opts.Output != "" && opts.Output != OutputText && !jsonOutput {
+		return NewArchiveError(fmt.Sprintf("Unknown output format %q (use text or json)", opts.Output), cfg.StatusConfigError)
+	}
+	if opts.Tree && jsonOutput {
+		return NewArchiveError("--tree is not supported with --output json (see base_archive)", cfg.StatusConfigError)
+	}
+
+	// No index database exists for archive directories, so the listing always
+	// comes from the directory itself and the catalog of archives moved to
+	// cold storage; sidecars are read only when needed.
+	var archives []Archive
+	var foreign []ForeignFile
+	loadMetadata, loadGitFields := loadArchiveMetadata, loadArchiveGitFields
+	if isRemoteLocation(cfg.ArchiveDirPath) {
+		// ⭐ REMOTE-CACHE-001: Remote archive directories are listed through a local cache
+		if opts.VerifyInline {
+			return NewArchiveError("--verify-inline is not supported for remote archive directories", cfg.StatusConfigError)
+		}
+		listing, err := openRemoteListing(context.Background(), cfg, remoteArchiveLocation(cfg, cwd, owner), opts.Refresh)
+		if err != nil {
+			return err
+		}
+		defer listing.save()
+		archiveDir, archives = listing.backend.URL(), listing.Archives()
+		loadMetadata, loadGitFields = listing.loadMetadata, listing.loadMetadata
+	} else if repositoryEnabled(cfg) {
+		// ⭐ CDC-001: Repository snapshots are listed like archives
+		if opts.VerifyInline {
+			return NewArchiveError("--verify-inline is not supported for repository snapshots", cfg.StatusConfigError)
+		}
+		archiveDir, archives, err = listRepositorySnapshots(cfg)
+		if err != nil {
+			return err
+		}
+	} else if _, statErr := os.Stat(archiveDir); opts.Owner != "" && os.IsNotExist(statErr) {
+		// ⭐ SHARED-001: Listing never creates the archive directory of another user
+	} else if archives, err = listArchiveEntries(archiveDir); err != nil {
 		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	} else {
+		// ⭐ FOREIGN-001: Files bkpdir did not create are not archives
+		archives = filterForeignArchives(cfg, archives)
+		if foreign, err = FindForeignFiles(cfg, archiveDir); err != nil {
+			return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+		}
+		// ⭐ TIER-001: Archives moved to cold storage are listed where they live
+		cold, err := coldArchives(archiveDir, archives)
+		if err != nil {
+			return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+		}
+		archives = append(archives, cold...)
 	}
 
-	if len(archives) == 0 {
+	if opts.Owner != "" {
+		if archives, err = filterArchivesByOwner(cfg, archives, opts.Owner); err != nil {
+			return err
+		}
+	}
+
+	if opts.TagPattern != "" {
+		for i := range archives {
+			loadGitFields(&archives[i])
+		}
+		archives, err = filterArchivesByTag(archives, opts.TagPattern)
+		if err != nil {
+			return NewArchiveErrorWithCause("Invalid --tag-matches pattern", cfg.StatusConfigError, err)
+		}
+	}
+
+	if len(archives) == 0 && !jsonOutput {
 		// Cast to FormatterAdapter to access extended methods
 		if formatterAdapter, ok := formatter.(*FormatterAdapter); ok {
 			formatterAdapter.PrintNoArchivesFound(archiveDir)
 		} else {
 			formatter.PrintError(fmt.Sprintf("No archives found in %s", archiveDir))
 		}
+		printForeignFiles(foreign, opts.Foreign)
 		return nil
 	}
 
@@ -1078,40 +2894,169 @@ func ListArchivesEnhanced(cfg *Config, formatter formatter.OutputFormatterInterf
 		return archives[i].CreationTime.After(archives[j].CreationTime)
 	})
 
-	for _, a := range archives {
-		status := ""
-		if a.VerificationStatus != nil {
-			if a.VerificationStatus.IsVerified {
-				status = " [VERIFIED]"
-			} else {
-				status = " [FAILED]"
-			}
-		} else {
-			status = " [UNVERIFIED]"
+	if opts.Tree {
+		archives = paginateChains(archives, opts.Offset, opts.Limit)
+	} else {
+		archives = paginateArchives(archives, opts.Offset, opts.Limit)
+	}
+	for i := range archives {
+		loadMetadata(&archives[i])
+	}
+	if opts.VerifyInline {
+		if skipped := checkArchiveStructures(archives, opts.VerifyBudget); skipped > 0 {
+			fmt.Fprintf(os.Stderr, "Note: --verify-budget of %v spent; %d archive(s) not checked\n",
+				opts.VerifyBudget, skipped)
+		}
+	}
+
+	if jsonOutput {
+		report := NewArchiveListReport(archiveDir, archives)
+		if opts.Foreign {
+			report.Foreign = foreign
 		}
+		return writeJSONReport(os.Stdout, report)
+	}
 
-		// Use enhanced formatting with extraction if possible
-		creationTime := a.CreationTime.Format("2006-01-02 15:04:05")
+	width := outputWidth()
+	// ⭐ LIST-TREE-001: Incremental archives grouped under their base
+	if opts.Tree {
+		printArchiveChains(cfg, formatter, archiveDir, archives, width)
+		printForeignFiles(foreign, opts.Foreign)
+		return nil
+	}
+	for _, a := range archives {
+		output, status := archiveListLine(formatter, a, "", "", width)
 		if formatterAdapter, ok := formatter.(*FormatterAdapter); ok {
-			output := formatterAdapter.FormatListArchiveWithExtraction(a.Name, creationTime)
-			// Remove trailing newline from output to add status on same line
-			output = strings.TrimSuffix(output, "\n")
 			formatterAdapter.PrintArchiveListWithStatus(output, status)
 		} else {
-			output := formatter.FormatListArchive(a.Name, creationTime)
-			fmt.Printf("%s%s\n", strings.TrimSuffix(output, "\n"), status)
+			fmt.Printf("%s%s\n", output, status)
 		}
 	}
 
+	printForeignFiles(foreign, opts.Foreign)
 	return nil
 }
 
+// archiveListLine returns the line list prints for a and its status. The
+// line starts with prefix and suffix follows the status; the archive name is
+// shortened so the whole line fits width.
+func archiveListLine(formatter formatter.OutputFormatterInterface, a Archive, prefix, suffix string, width int) (string, string) {
+	status := ""
+	if a.VerificationStatus != nil {
+		if a.VerificationStatus.IsVerified {
+			status = " [VERIFIED]"
+		} else {
+			status = " [FAILED]"
+		}
+	} else if a.StructureChecked {
+		// ⭐ LIST-VERIFY-001: Inline check results
+		if a.StructureError == "" {
+			status = " [READABLE]"
+		} else {
+			status = " [FAILED]"
+		}
+	} else {
+		status = " [UNVERIFIED]"
+	}
+	// ⭐ TIER-001: Where archives moved to cold storage live
+	if a.Location != "" {
+		status += " [COLD " + a.Location + "]"
+	}
+	status += suffix
+
+	// Use enhanced formatting with extraction if possible
+	creationTime := a.CreationTime.Format("2006-01-02 15:04:05")
+	formatterAdapter, templated := formatter.(*FormatterAdapter)
+	// ⭐ NAME-PATTERN-001: Names pattern_archive_filename cannot parse are
+	// foreign and listed with the plain format
+	if templated && len(formatterAdapter.ExtractArchiveFilenameData(a.Name)) == 0 {
+		templated = false
+	}
+	formatLine := func(name string) string {
+		if templated {
+			extra := map[string]string{
+				"tag":      a.GitTag,
+				"describe": a.GitDescribe,
+				"tags":     formatArchiveTags(a.Tags),
+			}
+			// ⭐ ANNOTATE-001: An annotated note replaces the note of the name
+			if a.Note != "" {
+				extra["note"] = a.Note
+			}
+			return formatterAdapter.FormatListArchiveWithData(name, creationTime, extra)
+		}
+		return formatter.FormatListArchive(name, creationTime)
+	}
+	// Remove trailing newline from output to add status on same line
+	output := prefix + strings.TrimSuffix(formatLine(a.Name), "\n")
+
+	// ⭐ TERM-WIDTH-001: Shorten the archive name so the line fits the terminal
+	if over := textWidth(output+status) - width; width > 0 && over > 0 {
+		nameWidth := textWidth(a.Name) - over
+		if nameWidth < minTruncatedWidth {
+			nameWidth = minTruncatedWidth
+		}
+		output = prefix + strings.TrimSuffix(formatLine(truncateMiddle(a.Name, nameWidth)), "\n")
+	}
+	return output, status
+}
+
+// ⭐ LIST-001: Listing pagination - 🔍
+// paginateArchives returns the window of archives starting at offset with at
+// most limit entries. A limit of 0 means no limit.
+func paginateArchives(archives []Archive, offset, limit int) []Archive {
+	if offset >= len(archives) {
+		return nil
+	}
+	archives = archives[offset:]
+	if limit > 0 && limit < len(archives) {
+		archives = archives[:limit]
+	}
+	return archives
+}
+
+// 🔶 GIT-007: Tag-based archive filtering - 🔍
+// filterArchivesByTag keeps archives whose recorded Git tag or describe output
+// matches the glob pattern. Archives without Git metadata never match.
+func filterArchivesByTag(archives []Archive, pattern string) ([]Archive, error) {
+	if _, err := path.Match(pattern, ""); err != nil {
+		return nil, err
+	}
+
+	var matched []Archive
+	for _, a := range archives {
+		for _, candidate := range []string{a.GitTag, a.GitDescribe} {
+			if candidate == "" {
+				continue
+			}
+			if ok, _ := path.Match(pattern, candidate); ok {
+				matched = append(matched, a)
+				break
+			}
+		}
+	}
+	return matched, nil
+}
+
 // VerifyOptions holds parameters for archive verification functions
 type VerifyOptions struct {
 	Config       *Config
 	Formatter    formatter.OutputFormatterInterface
 	ArchiveName  string
 	WithChecksum bool
+	// ⭐ ARCH-006: Optional sampling; nil verifies every entry
+	Sample *SampleSpec
+	// ⭐ VERIFY-PROGRESS-001: Stream entry results and stop at the first failure
+	Progress bool
+	FailFast bool
+	// ⭐ VERIFY-QUICK-001: Check the archive structure without reading the data
+	Quick bool
+	// ⭐ THAW-001: Retrieve archives in cold storage for a full verification
+	Thaw     bool
+	ThawTier string // Expedited, Standard or Bulk
+	ThawDays int    // Days the retrieved copy stays readable
+	// ⭐ JUNIT-001: Test cases of the verified archives; nil records nothing
+	Report *junitReport
 }
 
 // VerifyArchiveEnhanced verifies the integrity of an archive with optional checksum verification.
@@ -1119,12 +3064,21 @@ type VerifyOptions struct {
 func VerifyArchiveEnhanced(opts VerifyOptions) error {
 	// Archive verification implementation
 	// 🔺 CFG-003: Verification output formatting - 🔍
+	// ⭐ CDC-001: Snapshots are verified by checking their chunks
+	if repositoryEnabled(opts.Config) {
+		return verifyRepositorySnapshots(opts)
+	}
+
 	archiveDir, err := getArchiveDirectory(opts.Config)
 	if err != nil {
 		return err
 	}
 
 	if opts.ArchiveName != "" {
+		// ⭐ ALIAS-001: Verify the archive an alias stands for
+		if opts.ArchiveName, err = resolveArchiveAlias(opts.Config, archiveDir, opts.ArchiveName); err != nil {
+			return err
+		}
 		return verifySingleArchive(opts, archiveDir)
 	}
 	return verifyAllArchives(opts, archiveDir)
@@ -1134,17 +3088,56 @@ func VerifyArchiveEnhanced(opts VerifyOptions) error {
 func getArchiveDirectory(cfg *Config) (string, error) {
 	// 🔺 CFG-001: Archive directory resolution - 🔍
 	// DECISION-REF: DEC-002
+	// ⭐ REMOTE-CACHE-001: Remote archive directories are only listed
+	if isRemoteLocation(cfg.ArchiveDirPath) {
+		return "", remoteArchiveDirError(cfg.ArchiveDirPath, cfg.StatusConfigError)
+	}
 	cwd, err := os.Getwd()
 	if err != nil {
 		return "", NewArchiveErrorWithCause("Failed to get current directory",
 			cfg.StatusDirectoryNotFound, err)
 	}
-
-	archiveDir := cfg.ArchiveDirPath
+	// ⭐ SHARED-001: Archives of the current user with the subdir namespace
+	archiveDir := archiveRootDir(cfg, archiveUser())
 	if cfg.UseCurrentDirName {
 		archiveDir = filepath.Join(archiveDir, filepath.Base(cwd))
 	}
-	return archiveDir, nil
+	return archiveDir, nil
+}
+
+// ⭐ VERIFY-DIR-001: Restore-correctness audit - 🛡️
+// verifyDirectoryAgainstArchive compares dir with the named archive and
+// reports every missing, extra or changed file.
+func verifyDirectoryAgainstArchive(opts VerifyOptions, dir string) error {
+	archiveDir, err := getArchiveDirectory(opts.Config)
+	if err != nil {
+		return err
+	}
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return NewArchiveError(fmt.Sprintf("Not a directory: %s", dir), opts.Config.StatusDirectoryNotFound)
+	}
+	if opts.ArchiveName, err = resolveArchiveAlias(opts.Config, archiveDir, opts.ArchiveName); err != nil {
+		return err
+	}
+
+	archivePath := filepath.Join(archiveDir, opts.ArchiveName)
+	result, err := CompareArchiveToDir(archivePath, dir, opts.Config.ExcludePatterns)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to compare directory with archive", 1, err)
+	}
+
+	formatter := NewOutputFormatter(opts.Config)
+	if result.Matches() {
+		formatter.PrintVerificationSuccess(opts.ArchiveName)
+		return nil
+	}
+	formatter.PrintVerificationFailed(opts.ArchiveName,
+		fmt.Errorf("%s does not match: %d missing, %d extra, %d changed",
+			dir, len(result.Missing), len(result.Extra), len(result.Mismatched)))
+	for _, detail := range result.Details() {
+		formatter.PrintVerificationErrorDetail(detail)
+	}
+	return NewArchiveError("Directory does not match archive", 1)
 }
 
 // verifySingleArchive verifies a specific archive
@@ -1155,8 +3148,20 @@ func verifySingleArchive(opts VerifyOptions, archiveDir string) error {
 		Name: opts.ArchiveName,
 		Path: archivePath,
 	}
+	start := time.Now()
+	// ⭐ THAW-001: Archives moved to cold storage are verified where they live
+	if _, err := os.Stat(archivePath); os.IsNotExist(err) {
+		if catalog, err := LoadColdCatalog(archiveDir); err == nil {
+			if c := catalog.Find(opts.ArchiveName); c != nil {
+				err := verifyColdArchive(context.Background(), os.Stdout, opts, archiveDir, *c)
+				recordVerification(opts.Report, c.Name, time.Since(start), nil, err)
+				return err
+			}
+		}
+	}
 
-	status, err := performVerification(archive.Path, opts.WithChecksum)
+	status, err := verifyArchiveWithOptions(archive.Path, opts)
+	recordVerification(opts.Report, opts.ArchiveName, time.Since(start), status, err)
 	if err != nil {
 		return err
 	}
@@ -1164,6 +3169,21 @@ func verifySingleArchive(opts VerifyOptions, archiveDir string) error {
 	return handleVerificationResult(archive, status, opts.ArchiveName)
 }
 
+// ⭐ JUNIT-001: Verification test cases - 📝
+// recordVerification records the verification of an archive in report: a
+// failure with the problems found when it does not verify or cannot be
+// read, a pass otherwise.
+func recordVerification(report *junitReport, name string, d time.Duration, status *VerificationStatus, err error) {
+	switch {
+	case err != nil:
+		report.fail(name, d, err.Error())
+	case status != nil && !status.IsVerified:
+		report.fail(name, d, "verification failed", status.Errors...)
+	default:
+		report.pass(name, d)
+	}
+}
+
 // verifyAllArchives verifies all archives in the directory
 func verifyAllArchives(opts VerifyOptions, archiveDir string) error {
 	// All archives verification
@@ -1171,10 +3191,22 @@ func verifyAllArchives(opts VerifyOptions, archiveDir string) error {
 	if err != nil {
 		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
 	}
-
-	allPassed := true
-	for _, archive := range archives {
-		status, err := performVerification(archive.Path, opts.WithChecksum)
+	// ⭐ FOREIGN-001: Foreign ZIP files are not verified
+	archives = filterForeignArchives(opts.Config, archives)
+
+	// ⭐ ERR-AGG-001: Per-archive failures collected for errors.Is/As on the result
+	failures := bkperrors.NewMultiError("verify")
+	for i, archive := range archives {
+		// ⭐ JUNIT-001: Archives left unchecked by --fail-fast are skipped
+		if opts.FailFast && failures.Len() > 0 {
+			for _, rest := range archives[i:] {
+				opts.Report.skip(rest.Name, "not verified: --fail-fast")
+			}
+			break
+		}
+		start := time.Now()
+		status, err := verifyArchiveWithOptions(archive.Path, opts)
+		recordVerification(opts.Report, archive.Name, time.Since(start), status, err)
 		if err != nil {
 			// Cast to FormatterAdapter to access extended methods
 			if formatterAdapter, ok := opts.Formatter.(*FormatterAdapter); ok {
@@ -1182,17 +3214,34 @@ func verifyAllArchives(opts VerifyOptions, archiveDir string) error {
 			} else {
 				opts.Formatter.PrintError(fmt.Sprintf("Verification failed for %s: %v", archive.Name, err))
 			}
-			allPassed = false
+			failures.Add(archive.Name, err)
 			continue
 		}
 
-		if err := handleVerificationResult(&archive, status, archive.Name); err != nil {
-			allPassed = false
-		}
+		// ⭐ VERIFY-PROGRESS-001: The remaining archives are left unchecked after a failure
+		failures.Add(archive.Name, handleVerificationResult(&archive, status, archive.Name))
 	}
 
-	if !allPassed {
-		return NewArchiveError("Some archives failed verification", 1)
+	// ⭐ THAW-001: Archives moved to cold storage are verified where they live
+	cold, err := coldCatalogEntries(archiveDir, archives)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	for _, c := range cold {
+		if opts.FailFast && failures.Len() > 0 {
+			opts.Report.skip(c.Name, "not verified: --fail-fast")
+			continue
+		}
+		start := time.Now()
+		err := verifyColdArchive(context.Background(), os.Stdout, opts, archiveDir, c)
+		recordVerification(opts.Report, c.Name, time.Since(start), nil, err)
+		if err != nil {
+			opts.Formatter.PrintError(fmt.Sprintf("Verification failed for %s: %v", c.Name, err))
+			failures.Add(c.Name, err)
+		}
+	}
+	if failures.Len() > 0 {
+		return NewArchiveErrorWithCause("Some archives failed verification", 1, failures)
 	}
 	return nil
 }
@@ -1215,6 +3264,28 @@ func performVerification(archivePath string, withChecksum bool) (*VerificationSt
 	return status, nil
 }
 
+// ⭐ ARCH-006: Verification dispatch between full and sampled modes - 🔍
+// verifyArchiveWithOptions verifies every entry unless a sample is requested.
+func verifyArchiveWithOptions(archivePath string, opts VerifyOptions) (*VerificationStatus, error) {
+	// ⭐ VERIFY-QUICK-001: Headers only
+	if opts.Quick {
+		return VerifyArchiveQuick(archivePath)
+	}
+	// ⭐ VERIFY-PROGRESS-001: Full checksum verification checks every entry
+	if opts.Sample == nil && opts.WithChecksum {
+		return verifyChecksumsWithOptions(archivePath, opts)
+	}
+	if opts.Sample == nil {
+		return performVerification(archivePath, opts.WithChecksum)
+	}
+
+	status, err := VerifyArchiveSampled(archivePath, opts.WithChecksum, *opts.Sample, nil)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Sampled archive verification failed", 1, err)
+	}
+	return status, nil
+}
+
 // handleVerificationResult handles the result of verification
 func handleVerificationResult(archive *Archive, status *VerificationStatus, name string) error {
 	// Get config and formatter for proper output formatting
@@ -1222,12 +3293,40 @@ func handleVerificationResult(archive *Archive, status *VerificationStatus, name
 	cfg, _ := LoadConfig(cwd)
 	formatter := NewOutputFormatter(cfg)
 
-	// Store verification status
-	if err := StoreVerificationStatus(archive, status); err != nil {
-		// Don't fail if we can't store status, just warn
-		formatter.PrintVerificationWarning(name, err)
+	// ⭐ VERIFY-QUICK-001: Quick checks neither count as verifications nor read
+	// the whole archive for its seal
+	if !status.Quick {
+		// Store verification status
+		if err := StoreVerificationStatus(archive, status); err != nil {
+			// Don't fail if we can't store status, just warn
+			formatter.PrintVerificationWarning(name, err)
+		}
+
+		// ⭐ SEAL-001: Warn when the archive no longer matches its integrity seal
+		if state, err := CheckArchiveSeal(archive.Path); err != nil {
+			formatter.PrintVerificationWarning(name, err)
+		} else if state == SealMismatch {
+			formatter.PrintIntegritySealMismatch(name)
+		}
+	}
+
+	// ⭐ ARCH-006: Report sample coverage alongside the result
+	if status.SampledEntries > 0 {
+		bound := SampleConfidenceBound(status.SampledEntries, status.TotalEntries)
+		formatter.PrintVerificationSample(name, status.SampledEntries, status.TotalEntries, bound)
+	}
+
+	// ⭐ EVENT-001: Report the verification outcome to the system log
+	var verifyErr error
+	if !status.IsVerified {
+		verifyErr = fmt.Errorf("verification failed: %s", strings.Join(status.Errors, "; "))
 	}
+	emitArchiveEvent(cfg.EventLog, OperationVerify, archive.Path, verifyErr)
 
+	if status.IsVerified && status.Quick {
+		formatter.PrintVerificationQuick(name)
+		return nil
+	}
 	if status.IsVerified {
 		formatter.PrintVerificationSuccess(name)
 		return nil
@@ -1240,7 +3339,7 @@ func handleVerificationResult(archive *Archive, status *VerificationStatus, name
 	return NewArchiveError("Archive verification failed", 1)
 }
 
-func handleListFileBackupsCommand(args []string) {
+func handleListFileBackupsCommand(listFile string, args []string) {
 	// ⭐ FILE-002: File backup listing command implementation - 📝
 	// 🔺 CFG-003: File backup listing output formatting - 📝
 	var filePath string
@@ -1276,6 +3375,7 @@ func handleListFileBackupsCommand(args []string) {
 func backupCmd() *cobra.Command {
 	// ⭐ FILE-002: File backup command implementation - 🔧
 	// 🔺 CFG-003: Backup command interface - 🔧
+	var flags *cli.FlagBinding[backupCmdOptions]
 	cmd := &cobra.Command{
 		Use:   "backup [FILE_PATH] [NOTE]",
 		Short: "Create a backup of a single file",
@@ -1291,9 +3391,24 @@ If the file is identical to the most recent backup, no new backup is created.`,
   bkpdir backup myfile.txt -n "Before changes"
 
   # Show what would be backed up without creating backup
-  bkpdir backup -d myfile.txt`,
-		Args: cobra.MinimumNArgs(1),
-		Run: func(_ *cobra.Command, args []string) {
+  bkpdir backup -d myfile.txt
+
+  # Back up command output as report.txt
+  some-command | bkpdir backup --stdin --name report.txt "nightly report"`,
+		Args: func(cmd *cobra.Command, args []string) error {
+			// ⭐ STDIN-001: With --stdin the only argument is the note
+			if commandOptions(flags, cmd).Stdin {
+				return cobra.MaximumNArgs(1)(cmd, args)
+			}
+			return cobra.MinimumNArgs(1)(cmd, args)
+		},
+		Run: func(cmd *cobra.Command, args []string) {
+			opts := commandOptions(flags, cmd)
+			if opts.Stdin {
+				handleStdinBackupCommand(opts, args)
+				return
+			}
+
 			ctx := context.Background()
 			cwd, err := os.Getwd()
 			if err != nil {
@@ -1312,7 +3427,7 @@ If the file is identical to the most recent backup, no new backup is created.`,
 			filePath := args[0]
 
 			// Use note from flag if provided, otherwise use positional argument
-			backupNote := note
+			backupNote := opts.Note
 			if backupNote == "" && len(args) > 1 {
 				backupNote = args[1]
 			}
@@ -1323,18 +3438,67 @@ If the file is identical to the most recent backup, no new backup is created.`,
 				Formatter: formatter,
 				FilePath:  filePath,
 				Note:      backupNote,
-				DryRun:    dryRun,
+				DryRun:    opts.DryRun,
 			}); err != nil {
 				exitCode := HandleArchiveError(err, cfg, formatter)
 				os.Exit(exitCode)
 			}
 		},
 	}
-	cmd.Flags().StringVarP(&note, "note", "n", "", "Add a note to the backup name")
+	flags = cli.BindFlags(cmd, backupCmdOptions{}).
+		InheritBool(func(o *backupCmdOptions) *bool { return &o.DryRun }, "dry-run").
+		String(func(o *backupCmdOptions) *string { return &o.Note }, "note", "n", "Add a note to the backup name").
+		Bool(func(o *backupCmdOptions) *bool { return &o.Stdin }, "stdin", "", "Back up content piped to standard input").
+		String(func(o *backupCmdOptions) *string { return &o.Name }, "name", "", "File name to store --stdin backups under")
 	return cmd
 }
 
-func handleConfigSetCommand(key, value string) {
+// ⭐ STDIN-001: backup --stdin command handler - 🔧
+func handleStdinBackupCommand(opts backupCmdOptions, args []string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+	formatter := NewOutputFormatter(cfg)
+
+	backupNote := opts.Note
+	if backupNote == "" && len(args) > 0 {
+		backupNote = args[0]
+	}
+	if info, err := os.Stdin.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 && !opts.DryRun {
+		os.Exit(HandleArchiveError(NewArchiveError("--stdin expects piped input, not a terminal", cfg.StatusConfigError), cfg, formatter))
+	}
+
+	result, err := CreateStdinBackup(StdinBackupOptions{
+		Context:   context.Background(),
+		Config:    cfg,
+		Formatter: formatter,
+		Input:     os.Stdin,
+		Name:      opts.Name,
+		Note:      backupNote,
+		DryRun:    opts.DryRun,
+	})
+	if err != nil {
+		os.Exit(HandleArchiveError(err, cfg, formatter))
+	}
+	switch {
+	case opts.DryRun:
+	case result.Identical:
+		formatter.PrintIdenticalBackup(result.Path)
+		os.Exit(cfg.StatusFileIsIdenticalToExistingBackup)
+	default:
+		formatter.PrintBackupCreated(result.Path)
+		fmt.Printf("SHA-256: %s\n", result.SHA256)
+	}
+}
+
+func handleConfigSetCommand(key, value, file string, global bool) {
 	// 🔺 CFG-001: Configuration modification command - 🔍
 	// 🔺 CFG-002: Configuration value setting - 🔍
 	// DECISION-REF: DEC-002
@@ -1351,51 +3515,235 @@ func handleConfigSetCommand(key, value string) {
 	}
 
 	formatter := NewOutputFormatter(cfg)
-	configPath := filepath.Join(cwd, ".bkpdir.yml")
-	configData := loadExistingConfigData(configPath)
+	// ⭐ CFG-SET-FILE-001: --file and --global pick another file of the chain
+	configPath, err := configSetTarget(cwd, file, global)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
 	convertedValue := convertConfigValue(key, value)
-	updateConfigData(configData, key, convertedValue)
-	saveConfigData(configPath, configData)
+	// ⭐ CFG-WRITE-001: Leaf names are written under their section, e.g. verification.checksum_algorithm
+	yamlPath := key
+	if field, err := findConfigField(DefaultConfig(), key); err == nil {
+		yamlPath = configYAMLPath(field.Path)
+	}
+
+	description := fmt.Sprintf("config %s %s", key, value)
+	err = writeConfigValue(cfg, configPath, yamlPath, convertedValue, description)
+	// ⭐ AUDIT-001: Record the change in the audit log, hiding secret values
+	recordAudit(cfg, AuditActionConfigSet, configPath, yamlPath+" = "+auditConfigValue(key, value), err)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
 
 	formatter.PrintConfigurationUpdated(key, convertedValue)
 	formatter.PrintConfigFilePath(configPath)
+	printEffectiveConfigValue(cwd, key, yamlPath, configPath)
 }
 
-func loadExistingConfigData(configPath string) map[string]interface{} {
-	// 🔺 CFG-001: Configuration file loading - 📝
-	// DECISION-REF: DEC-002
-	var configData map[string]interface{}
+// ⭐ CFG-SET-FILE-001: Effective value after config set - 🔍
+// printEffectiveConfigValue shows the value key now has in root and the file
+// it comes from, noting when the written file does not decide it.
+func printEffectiveConfigValue(root, key, yamlPath, configPath string) {
+	cfg, err := LoadConfig(root)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not load the updated configuration: %v\n", err)
+		return
+	}
+	field, err := findConfigField(cfg, key)
+	if err != nil {
+		return
+	}
+	source, err := configValueSource(root, yamlPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not resolve the configuration chain: %v\n", err)
+		return
+	}
+	fmt.Printf("Effective value: %s = %s (from %s)\n", yamlPath, formatFieldValue(field.Value, field.Kind), source)
+	if source == configPath {
+		return
+	}
+	if files, _ := configChainFiles(root); !slices.Contains(files, configPath) {
+		fmt.Fprintf(os.Stderr, "Note: %s is not in the configuration chain of %s; add it to inherit to use it here\n",
+			configPath, root)
+	} else {
+		fmt.Fprintf(os.Stderr, "Note: %s overrides %s\n", source, configPath)
+	}
+}
+
+// ⭐ CFG-DESCRIBE-001: Configuration key description command - 🔧
+func handleConfigDescribeCommand(key string) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	description, err := describeConfigKey(cfg, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+	fmt.Print(description)
+}
+
+// ⭐ CFG-SCHEMA-001: JSON Schema export - 🔧
+func handleConfigSchemaCommand() {
+	data, err := MarshalConfigSchema()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error generating schema: %v\n", err)
+		os.Exit(1)
+	}
+	os.Stdout.Write(data)
+}
+
+// ⭐ TRASH-001: Trash command configuration loading - 🔧
+func loadTrashConfig() *Config {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error getting current directory: %v\n", err)
+		os.Exit(1)
+	}
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+	return cfg
+}
+
+// ⭐ TRASH-001: Trash listing command - 🔧
+func handleTrashListCommand() {
+	cfg := loadTrashConfig()
+	items, err := ListTrash(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(items) == 0 {
+		fmt.Printf("Trash is empty: %s\n", cfg.TrashDirPath)
+		return
+	}
+	now := time.Now()
+	for _, item := range items {
+		status := "expires " + item.ExpiresAt.Format("2006-01-02 15:04")
+		if item.Expired(now) {
+			status = "expired"
+		}
+		fmt.Printf("%s  %s  (trashed %s, %s)\n", item.Name, item.OriginalPath,
+			item.TrashedAt.Format("2006-01-02 15:04"), status)
+	}
+}
+
+// ⭐ TRASH-001: Trash restore command - 🔧
+func handleTrashRestoreCommand(name string, dryRun bool) {
+	cfg := loadTrashConfig()
+	if dryRun {
+		fmt.Printf("Would restore %s from %s\n", name, cfg.TrashDirPath)
+		return
+	}
+	item, err := RestoreFromTrash(cfg, name)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Restored %s\n", item.OriginalPath)
+}
 
-	if data, err := os.ReadFile(configPath); err == nil {
-		if err := yaml.Unmarshal(data, &configData); err != nil {
-			fmt.Fprintf(os.Stderr, "Error parsing existing config file: %v\n", err)
+// ⭐ TRASH-001: Trash purge command - 🔧
+func handleTrashEmptyCommand(opts trashEmptyOptions) {
+	cfg := loadTrashConfig()
+	if opts.DryRun {
+		items, err := ListTrash(cfg)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
 			os.Exit(1)
 		}
-	} else {
-		configData = make(map[string]interface{})
+		now := time.Now()
+		for _, item := range items {
+			if !opts.Expired || item.Expired(now) {
+				fmt.Printf("Would delete %s\n", item.Name)
+			}
+		}
+		return
+	}
+	removed, err := EmptyTrash(cfg, opts.Expired, time.Now())
+	for _, item := range removed {
+		fmt.Printf("Deleted %s\n", item.Name)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// ⭐ UNDO-001: Undo command implementation - 🔧
+func handleUndoCommand(opts undoCmdOptions) {
+	journalPath, err := JournalPath()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	journal, err := LoadJournal(journalPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	// ⭐ SUDO-OWNER-001: Keep the journal writable without sudo
+	if cwd, err := os.Getwd(); err == nil {
+		if cfg, err := LoadConfig(cwd); err == nil {
+			journal.Config = cfg
+		}
+	}
+
+	if opts.DryRun {
+		if entry, ok := journal.Last(); ok {
+			fmt.Printf("Would undo: %s (%s, %s)\n", entry.Description, entry.Path, entry.Time.Format("2006-01-02 15:04:05"))
+		} else {
+			fmt.Println(ErrJournalEmpty.Error())
+		}
+		return
 	}
 
-	return configData
+	entry, err := journal.Undo(opts.Force)
+	if errors.Is(err, ErrJournalEmpty) {
+		fmt.Println(err.Error())
+		return
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Undid: %s (%s)\n", entry.Description, entry.Path)
 }
 
 func convertConfigValue(key, value string) interface{} {
 	// 🔺 CFG-002: Configuration value type conversion - 🔧
-	switch key {
-	case "use_current_dir_name", "use_current_dir_name_for_files", "include_git_info", "verify_on_create":
+	// ⭐ CFG-SET-001: Values are validated against field metadata before conversion
+	field, err := validateConfigSetValue(key, value)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		if field.Path == "" {
+			fmt.Fprintf(os.Stderr, "Run 'bkpdir config' to list the valid keys\n")
+		}
+		os.Exit(1)
+		return nil
+	}
+
+	switch field.Kind {
+	case reflect.Bool:
 		return convertBooleanValue(key, value)
-	case "status_config_error", "status_created_archive", "status_created_backup",
-		"status_disk_full", "status_permission_denied":
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		return convertIntegerValue(key, value)
-	case "archive_dir_path", "backup_dir_path", "checksum_algorithm":
-		return value
 	default:
-		fmt.Fprintf(os.Stderr, "Error: unknown configuration key: %s\n", key)
-		fmt.Fprintf(os.Stderr, "Valid keys: archive_dir_path, backup_dir_path, use_current_dir_name, "+
-			"use_current_dir_name_for_files, include_git_info, verify_on_create, checksum_algorithm, "+
-			"status_config_error, status_created_archive, status_created_backup, status_disk_full, "+
-			"status_permission_denied\n")
-		os.Exit(1)
-		return nil
+		return value
 	}
 }
 
@@ -1422,38 +3770,6 @@ func convertIntegerValue(key, value string) int {
 	return intVal
 }
 
-func updateConfigData(configData map[string]interface{}, key string, convertedValue interface{}) {
-	// 🔺 CFG-001: Configuration data updating - 🔍
-	if key == "verify_on_create" || key == "checksum_algorithm" {
-		if configData["verification"] == nil {
-			configData["verification"] = make(map[string]interface{})
-		}
-		verificationMap := configData["verification"].(map[string]interface{})
-		if key == "verify_on_create" {
-			verificationMap["verify_on_create"] = convertedValue
-		} else {
-			verificationMap["checksum_algorithm"] = convertedValue
-		}
-	} else {
-		configData[key] = convertedValue
-	}
-}
-
-func saveConfigData(configPath string, configData map[string]interface{}) {
-	// 🔺 CFG-001: Configuration data persistence - 📝
-	// DECISION-REF: DEC-002, DEC-008
-	yamlData, err := yaml.Marshal(configData)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error marshaling config data: %v\n", err)
-		os.Exit(1)
-	}
-
-	if err := os.WriteFile(configPath, yamlData, 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing config file: %v\n", err)
-		os.Exit(1)
-	}
-}
-
 // 🔶 REFACTOR-005: Structure optimization - Standardized command configuration - 📝
 // CommandConfig holds configuration for CLI command execution
 type CommandConfig struct {
@@ -1608,11 +3924,46 @@ func determineTemplateFileName(customOutput string) string {
 	return dateBasedFile
 }
 
+// ⭐ CFG-TEMPLATE-002: Template generation options - 🔧
+// TemplateOptions controls the shape of the generated configuration template.
+type TemplateOptions struct {
+	Minimal bool // Emit a short starter template with the most commonly changed keys
+}
+
+// ⭐ CFG-TEMPLATE-002: Starter template keys - 🔧
+// minimalTemplateKeys lists the YAML paths included in the --minimal template.
+var minimalTemplateKeys = []string{
+	"archive_dir_path",
+	"backup_dir_path",
+	"use_current_dir_name",
+	"exclude_patterns",
+	"include_git_info",
+	"verification.verify_on_create",
+	"verification.checksum_algorithm",
+}
+
 // ⭐ CFG-TEMPLATE-001: Template generation - 🔧
-// generateConfigurationTemplate creates a comprehensive YAML template with all Config fields
+// generateConfigurationTemplate creates a comprehensive configuration template with all available options
 func generateConfigurationTemplate(cfg *Config) (string, error) {
+	return generateConfigurationTemplateWithOptions(cfg, TemplateOptions{})
+}
+
+// ⭐ CFG-TEMPLATE-002: Documented template generation - 🔧
+// generateConfigurationTemplateWithOptions renders either the full documented
+// template or the minimal starter template.
+func generateConfigurationTemplateWithOptions(cfg *Config, opts TemplateOptions) (string, error) {
 	var template strings.Builder
 
+	// ⭐ CFG-TEMPLATE-001: Configuration reflection - 🔧
+	// Use existing CFG-006 field discovery system
+	allFields := GetAllConfigFields(cfg)
+	defaults := defaultFieldValues()
+
+	if opts.Minimal {
+		writeMinimalTemplate(&template, allFields, defaults)
+		return template.String(), nil
+	}
+
 	// Add header
 	template.WriteString("# BkpDir Configuration Template\n")
 	template.WriteString("# Generated on: " + time.Now().Format("2006-01-02 15:04:05") + "\n")
@@ -1620,14 +3971,11 @@ func generateConfigurationTemplate(cfg *Config) (string, error) {
 	template.WriteString("# This template includes all available configuration options organized by category.\n")
 	template.WriteString("# Uncomment and modify the values you want to customize.\n")
 	template.WriteString("# Values shown are the current effective configuration after loading BKPDIR_CONFIG.\n")
+	template.WriteString("# Each option lists its description, type, default and allowed values.\n")
 	template.WriteString("#\n")
 	template.WriteString("# For more information, see: docs/configuration.md\n")
 	template.WriteString("\n")
 
-	// ⭐ CFG-TEMPLATE-001: Configuration reflection - 🔧
-	// Use existing CFG-006 field discovery system
-	allFields := GetAllConfigFields(cfg)
-
 	// Group fields by category
 	categories := make(map[string][]configFieldInfo)
 	for _, field := range allFields {
@@ -1651,6 +3999,11 @@ func generateConfigurationTemplate(cfg *Config) (string, error) {
 			continue
 		}
 
+		// Keep nested fields together under their parent key
+		sort.SliceStable(fields, func(i, j int) bool {
+			return configYAMLPath(fields[i].Path) < configYAMLPath(fields[j].Path)
+		})
+
 		// Category header
 		categoryTitle := strings.Title(strings.ReplaceAll(categoryName, "_", " "))
 		template.WriteString(fmt.Sprintf("# %s Configuration\n", categoryTitle))
@@ -1661,16 +4014,46 @@ func generateConfigurationTemplate(cfg *Config) (string, error) {
 		template.WriteString("\n")
 
 		// Generate fields for this category
+		currentParent := ""
 		for _, field := range fields {
-			generateFieldTemplate(&template, field, cfg)
+			currentParent = generateFieldTemplate(&template, field, defaults, currentParent)
 		}
-
-		template.WriteString("\n")
 	}
 
 	return template.String(), nil
 }
 
+// ⭐ CFG-TEMPLATE-002: Default value lookup - 🔧
+// defaultFieldValues returns the formatted default value of every field keyed by Go field path.
+func defaultFieldValues() map[string]string {
+	defaults := make(map[string]string)
+	for _, field := range GetAllConfigFields(DefaultConfig()) {
+		defaults[field.Path] = formatFieldValue(field.Value, field.Kind)
+	}
+	return defaults
+}
+
+// ⭐ CFG-TEMPLATE-002: Starter template generation - 🔧
+// writeMinimalTemplate renders the short starter template used by `template --minimal`.
+func writeMinimalTemplate(template *strings.Builder, fields []configFieldInfo, defaults map[string]string) {
+	template.WriteString("# BkpDir starter configuration\n")
+	template.WriteString("# Uncomment and modify the values you want to customize.\n")
+	template.WriteString("# Run 'bkpdir template' without --minimal for every available option.\n")
+	template.WriteString("\n")
+
+	byPath := make(map[string]configFieldInfo)
+	for _, field := range fields {
+		byPath[configYAMLPath(field.Path)] = field
+	}
+
+	currentParent := ""
+	for _, key := range minimalTemplateKeys {
+		if field, ok := byPath[key]; ok {
+			currentParent = generateFieldTemplate(template, field, defaults, currentParent)
+		}
+	}
+}
+
 // ⭐ CFG-TEMPLATE-001: Template generation - 🔧
 // addCategoryDescription adds helpful descriptions for each configuration category
 func addCategoryDescription(template *strings.Builder, categoryName string) {
@@ -1692,68 +4075,63 @@ func addCategoryDescription(template *strings.Builder, categoryName string) {
 }
 
 // ⭐ CFG-TEMPLATE-001: Template generation - 🔧
-// generateFieldTemplate creates a commented YAML entry for a single configuration field
-func generateFieldTemplate(template *strings.Builder, field configFieldInfo, cfg *Config) {
+// ⭐ CFG-TEMPLATE-002: Inline field documentation - 🔧
+// generateFieldTemplate creates a documented, commented YAML entry for a single
+// configuration field. Nested fields (like git.enabled) are written under a
+// single parent key; the parent last written is returned so callers can track it.
+func generateFieldTemplate(template *strings.Builder, field configFieldInfo, defaults map[string]string, currentParent string) string {
 	// Get current value
 	currentValue := formatFieldValue(field.Value, field.Kind)
+	doc := describeConfigField(field)
+
+	yamlPath := configYAMLPath(field.Path)
+	parent := ""
+	indent := ""
+	if idx := strings.LastIndex(yamlPath, "."); idx >= 0 {
+		parent = yamlPath[:idx]
+		indent = "  "
+	}
 
-	// Format the field with proper indentation
-	yamlKey := field.YAMLName
-
-	// Handle nested fields (like git.enabled)
-	if strings.Contains(field.Path, ".") {
-		// For nested fields, we need special handling
-		parts := strings.Split(field.Path, ".")
-		if len(parts) == 2 {
-			parentKey := parts[0]
-			childKey := field.YAMLName
+	if parent != "" && parent != currentParent {
+		template.WriteString(fmt.Sprintf("# %s:\n", parent))
+	}
 
-			// Check if this is the first field of a nested struct
-			if field.Name == getFirstFieldOfStruct(field.Path) {
-				template.WriteString(fmt.Sprintf("# %s:\n", parentKey))
-			}
-			template.WriteString(fmt.Sprintf("#   %s: %s\n", childKey, currentValue))
-			return
+	// Documentation lines use "##" so they stay comments once a value line is uncommented
+	template.WriteString(fmt.Sprintf("##%s %s\n", indent, doc.Description))
+	meta := fmt.Sprintf("type: %s", field.Type)
+	if def, ok := defaults[field.Path]; ok {
+		if field.Kind == reflect.String {
+			def = strconv.Quote(def)
 		}
+		meta += fmt.Sprintf(" | default: %s", def)
 	}
-
-	// Regular field
-	template.WriteString(fmt.Sprintf("# %s: %s\n", yamlKey, currentValue))
-}
-
-// ⭐ CFG-TEMPLATE-001: Template generation - 🔧
-// getFirstFieldOfStruct determines if this is the first field of a nested struct (for proper formatting)
-func getFirstFieldOfStruct(fieldPath string) string {
-	parts := strings.Split(fieldPath, ".")
-	if len(parts) != 2 {
-		return ""
-	}
-
-	parent := parts[0]
-	switch parent {
-	case "verification":
-		return "VerifyOnCreate"
-	case "git":
-		return "Enabled"
-	default:
-		return ""
+	if len(doc.Allowed) > 0 {
+		meta += fmt.Sprintf(" | allowed: %s", strings.Join(doc.Allowed, ", "))
+	}
+	if doc.EnvVar != "" {
+		meta += fmt.Sprintf(" | env: %s", doc.EnvVar)
+	}
+	template.WriteString(fmt.Sprintf("##%s %s\n", indent, meta))
+	if doc.Example != "" {
+		template.WriteString(fmt.Sprintf("##%s example:\n", indent))
+		for _, line := range strings.Split(doc.Example, "\n") {
+			template.WriteString(fmt.Sprintf("##%s   %s\n", indent, line))
+		}
 	}
+	template.WriteString(fmt.Sprintf("# %s%s: %s\n\n", indent, field.YAMLName, currentValue))
+
+	return parent
 }
 
 // ⭐ CFG-TEMPLATE-001: File management - 🔧
 // writeTemplateToFile safely writes the template content to the specified file
 func writeTemplateToFile(filename, content string) error {
-	// Create backup of existing file if it exists
-	if _, err := os.Stat(filename); err == nil {
-		backupName := filename + ".backup"
-		if err := copyFile(filename, backupName); err != nil {
-			return fmt.Errorf("failed to create backup: %v", err)
-		}
+	// 🔶 FILE-004: Back up and replace through pkg/fileops atomic helpers
+	if _, err := fileops.BackupBeforeOverwrite(filename, ".backup"); err != nil {
+		return fmt.Errorf("failed to create backup: %v", err)
 	}
 
-	// Write template content
-	err := os.WriteFile(filename, []byte(content), 0644)
-	if err != nil {
+	if err := fileops.AtomicWriteFile(filename, []byte(content), 0644); err != nil {
 		return fmt.Errorf("failed to write template file: %v", err)
 	}
 
@@ -0,0 +1,130 @@
+// This file is part of bkpdir
+//
+// Package main provides the archive manifest, a metadata sidecar recording
+// facts about archive entries that matter when restoring on another system.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/processing"
+)
+
+// ⭐ MANIFEST-001: Manifest sidecar suffix - 🔧
+const manifestSuffix = ".manifest.json"
+
+// ⭐ MANIFEST-001: Archive manifest record - 📝
+// ArchiveManifest is stored as .metadata/<archive>.manifest.json next to the
+// archive. It is only written when it has something to record.
+type ArchiveManifest struct {
+	// CaseCollisions lists groups of entry paths that differ only by case.
+	CaseCollisions [][]string `json:"case_collisions,omitempty"`
+	// ⭐ UNICODE-001: Entries whose names are not NFC, with their original bytes
+	UnicodeNames []NameNormalization `json:"unicode_names,omitempty"`
+	// ⭐ KEEP-GOING-001: Files that could not be read and are missing from the archive
+	FailedFiles []FileFailure `json:"failed_files,omitempty"`
+	// ⭐ MANIFEST-AUDIT-001: Files left out of the archive and why, with manifest_exclusions
+	ExcludedFiles []ManifestExclusion `json:"excluded_files,omitempty"`
+	// ⭐ DEDUP-001: Size and SHA-256 of each archived file, with manifest_file_hashes
+	Files []ManifestFile `json:"files,omitempty"`
+	// ⭐ SPLIT-001: Run shared by the archives of one --split-by-dir run
+	RunID string `json:"run_id,omitempty"`
+	// ⭐ RESTORE-001: Names of the owners recorded in the entries, for restores on other machines
+	Owners *ManifestOwners `json:"owners,omitempty"`
+	// 🔶 GIT-007: git describe --tags --dirty output and nearest tag of the archived tree
+	GitDescribe string `json:"git_describe,omitempty"`
+	GitTag      string `json:"git_tag,omitempty"`
+}
+
+// ManifestFile is the size and SHA-256 of one archived file.
+type ManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// IsEmpty reports whether the manifest records nothing.
+func (m *ArchiveManifest) IsEmpty() bool {
+	return len(m.CaseCollisions) == 0 && len(m.UnicodeNames) == 0 && len(m.FailedFiles) == 0 && len(m.Files) == 0 &&
+		len(m.ExcludedFiles) == 0 && m.RunID == "" && m.Owners == nil && m.GitDescribe == "" && m.GitTag == ""
+}
+
+// manifestPath returns the manifest location for an archive.
+func manifestPath(archivePath string) string {
+	return filepath.Join(filepath.Dir(archivePath), ".metadata", filepath.Base(archivePath)+manifestSuffix)
+}
+
+// ⭐ MANIFEST-001: Manifest construction - 🔧
+// BuildArchiveManifest collects the manifest facts for the archived files.
+func BuildArchiveManifest(files []string) *ArchiveManifest {
+	return &ArchiveManifest{
+		CaseCollisions: DetectCaseCollisions(files),
+		UnicodeNames:   CollectNameNormalizations(files),
+	}
+}
+
+// ⭐ MANIFEST-001: Manifest persistence - 🔧
+// StoreArchiveManifest writes the manifest for an archive to its .metadata directory.
+func StoreArchiveManifest(archivePath string, manifest *ArchiveManifest) error {
+	path := manifestPath(archivePath)
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode manifest: %w", err)
+	}
+	return fileops.AtomicWriteFile(path, data, 0o644)
+}
+
+// ⭐ MANIFEST-001: Manifest loading - 🔧
+// LoadArchiveManifest reads the manifest for an archive.
+// It returns nil without error if no manifest was recorded.
+func LoadArchiveManifest(archivePath string) (*ArchiveManifest, error) {
+	data, err := os.ReadFile(manifestPath(archivePath))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+	var manifest ArchiveManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to decode manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// ⭐ MANIFEST-001: Archive manifest recording - 🔧
+// recordArchiveManifest stages the manifest for a new archive in txn. A
+// failure only produces a warning because the archive itself is complete.
+func recordArchiveManifest(txn *processing.Transaction, archivePath string, files []string, failures []FileFailure, hashes []ManifestFile, runID string,
+	excluded []ManifestExclusion, owners *ManifestOwners, gitMeta *GitMetadata) {
+	manifest := BuildArchiveManifest(files)
+	manifest.Owners = owners
+	if gitMeta != nil {
+		manifest.GitDescribe, manifest.GitTag = gitMeta.Describe, gitMeta.Tag
+	}
+	manifest.FailedFiles = failures
+	manifest.ExcludedFiles = excluded
+	manifest.Files = hashes
+	manifest.RunID = runID
+	if manifest.IsEmpty() {
+		return
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err == nil {
+		err = txn.WriteFile(manifestPath(archivePath), data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record manifest for %s: %v\n", filepath.Base(archivePath), err)
+	}
+}
@@ -0,0 +1,141 @@
+// This file is part of bkpdir
+//
+// Package main records the files left out of an archive in its manifest with
+// manifest_exclusions, so an audit can show what a backup did not capture and
+// which rule removed each file.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ MANIFEST-AUDIT-001: Reasons for files left out of an archive - 📝
+const (
+	// ExclusionReasonPattern is a file matching an exclusion pattern.
+	ExclusionReasonPattern = "exclude_pattern"
+	// ExclusionReasonMaxFileSize is a file over max_file_size.
+	ExclusionReasonMaxFileSize = "max_file_size"
+	// ExclusionReasonBrokenSymlink is a broken link skipped with skip_broken_symlinks.
+	ExclusionReasonBrokenSymlink = "broken_symlink"
+)
+
+// ManifestExclusion is a file of the archived directory that the archive
+// does not hold, with the reason and the rule that removed it.
+type ManifestExclusion struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+	Rule   string `json:"rule"` // The exclusion pattern or setting
+}
+
+// ⭐ MANIFEST-AUDIT-001: Excluded file collection - 🔍
+// collectManifestExclusions returns the files of cwd an archive run leaves
+// out, sorted by path, when manifest_exclusions is set. patterns are the
+// exclusion patterns of the run, nil when it archives a file list;
+// candidates are the files found before the resource limits dropped any,
+// and files those being archived.
+func collectManifestExclusions(ctx context.Context, cfg *Config, cwd string, patterns, candidates, files []string) ([]ManifestExclusion, error) {
+	if !cfg.ManifestExclusions {
+		return nil, nil
+	}
+	var excluded []ManifestExclusion
+	if patterns != nil {
+		var err error
+		if excluded, err = excludedByPattern(ctx, cwd, patterns); err != nil {
+			return nil, err
+		}
+	}
+
+	kept := make(map[string]bool, len(files))
+	for _, rel := range files {
+		kept[rel] = true
+	}
+	for _, rel := range candidates {
+		if kept[rel] {
+			continue
+		}
+		var size int64
+		if info, err := os.Stat(filepath.Join(cwd, rel)); err == nil {
+			size = info.Size()
+		}
+		excluded = append(excluded, ManifestExclusion{Path: filepath.ToSlash(rel), Size: size,
+			Reason: ExclusionReasonMaxFileSize, Rule: "max_file_size " + cfg.MaxFileSize})
+	}
+	if cfg.SkipBrokenSymlinks {
+		for _, rel := range files {
+			if isBrokenSymlink(filepath.Join(cwd, rel)) {
+				excluded = append(excluded, ManifestExclusion{Path: filepath.ToSlash(rel),
+					Reason: ExclusionReasonBrokenSymlink, Rule: "skip_broken_symlinks"})
+			}
+		}
+	}
+
+	sort.SliceStable(excluded, func(i, j int) bool { return excluded[i].Path < excluded[j].Path })
+	return excluded, nil
+}
+
+// excludedByPattern walks dir like an archive run and returns the files
+// patterns exclude, each with the first pattern matching it.
+func excludedByPattern(ctx context.Context, dir string, patterns []string) ([]ManifestExclusion, error) {
+	matchers := make([]*fileops.PatternMatcher, len(patterns))
+	for i, p := range patterns {
+		matchers[i] = fileops.NewPatternMatcher([]string{p})
+	}
+	var excluded []ManifestExclusion
+	opts := archiveScanOptions
+	opts.Info = true
+	err := fileops.ParallelWalk(ctx, dir, opts, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == "." || d.IsDir() {
+			return nil
+		}
+		for i, m := range matchers {
+			if !m.ShouldExclude(rel) {
+				continue
+			}
+			var size int64
+			if info, err := d.Info(); err == nil {
+				size = info.Size()
+			}
+			excluded = append(excluded, ManifestExclusion{Path: filepath.ToSlash(rel), Size: size,
+				Reason: ExclusionReasonPattern, Rule: patterns[i]})
+			break
+		}
+		return nil
+	})
+	return excluded, err
+}
+
+// isBrokenSymlink reports whether path is a symbolic link whose target does
+// not exist, as the archiver decides it.
+func isBrokenSymlink(path string) bool {
+	info, err := os.Lstat(path)
+	if err != nil || info.Mode()&os.ModeSymlink == 0 {
+		return false
+	}
+	target, err := os.Readlink(path)
+	if err != nil {
+		return true
+	}
+	if !filepath.IsAbs(target) {
+		target = filepath.Join(filepath.Dir(path), target)
+	}
+	_, err = os.Stat(target)
+	return os.IsNotExist(err)
+}
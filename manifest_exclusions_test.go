@@ -0,0 +1,69 @@
+// This file is part of bkpdir
+
+// Package main provides tests for excluded files in archive manifests.
+// It verifies each left-out file is recorded with its reason and rule.
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+)
+
+// ⭐ MANIFEST-AUDIT-001: Excluded files recorded in the manifest - 🧪
+func TestManifestExclusions(t *testing.T) {
+	cfg := uploadTestConfig(t, "../archives")
+	cfg.ExcludePatterns = []string{"*.log", "tmp/"}
+	cfg.MaxFileSize = "10B"
+	cfg.SkipBrokenSymlinks = true
+	cfg.ManifestExclusions = true
+	for name, data := range map[string]string{
+		"main.go":   "package x",
+		"debug.log": "log",
+		"big.bin":   "0123456789abcdef",
+	} {
+		if err := os.WriteFile(name, []byte(data), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("missing", "dangling"); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := createFullArchive(context.Background(), cfg, "", nil, false, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := LoadArchiveManifest(archive)
+	if err != nil || manifest == nil {
+		t.Fatalf("manifest: %v, %v", manifest, err)
+	}
+	want := []ManifestExclusion{
+		{Path: "big.bin", Size: 16, Reason: ExclusionReasonMaxFileSize, Rule: "max_file_size 10B"},
+		{Path: "dangling", Reason: ExclusionReasonBrokenSymlink, Rule: "skip_broken_symlinks"},
+		{Path: "debug.log", Size: 3, Reason: ExclusionReasonPattern, Rule: "*.log"},
+	}
+	if len(manifest.ExcludedFiles) != len(want) {
+		t.Fatalf("excluded = %+v", manifest.ExcludedFiles)
+	}
+	for i, w := range want {
+		if manifest.ExcludedFiles[i] != w {
+			t.Errorf("excluded %d = %+v, want %+v", i, manifest.ExcludedFiles[i], w)
+		}
+	}
+
+	// Off by default
+	cfg.ManifestExclusions = false
+	if excluded, err := collectManifestExclusions(context.Background(), cfg, ".", cfg.ExcludePatterns, nil, nil); err != nil || excluded != nil {
+		t.Errorf("disabled: %+v (%v)", excluded, err)
+	}
+}
+
+// ⭐ MANIFEST-AUDIT-001: Failures carry their category - 🧪
+func TestNewFileFailureCategory(t *testing.T) {
+	failure := newFileFailure("secret.txt", &os.PathError{Op: "open", Path: "secret.txt", Err: os.ErrPermission})
+	if failure.Category != "permission" || failure.Path != "secret.txt" || !errors.Is(failure.err, os.ErrPermission) {
+		t.Errorf("failure = %+v", failure)
+	}
+}
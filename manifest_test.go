@@ -0,0 +1,51 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the archive manifest sidecar.
+// It verifies recording, loading and skipping empty manifests.
+package main
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"bkpdir/pkg/processing"
+)
+
+// ⭐ MANIFEST-001: Manifest persistence tests - 🔧
+func TestArchiveManifest(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "proj-2024-01-01-10-00.zip")
+
+	commitSidecars(t, archive, func(txn *processing.Transaction) {
+		recordArchiveManifest(txn, archive, []string{"a.txt", "b.txt"}, nil, nil, "", nil, nil, nil)
+	})
+	if m, err := LoadArchiveManifest(archive); err != nil || m != nil {
+		t.Fatalf("Expected no manifest without collisions, got %v (%v)", m, err)
+	}
+
+	commitSidecars(t, archive, func(txn *processing.Transaction) {
+		recordArchiveManifest(txn, archive, []string{"Notes.txt", "notes.txt", "b.txt"}, nil, nil, "", nil, nil, nil)
+	})
+	m, err := LoadArchiveManifest(archive)
+	if err != nil || m == nil {
+		t.Fatalf("Expected manifest, got %v (%v)", m, err)
+	}
+	want := [][]string{{"Notes.txt", "notes.txt"}}
+	if !reflect.DeepEqual(m.CaseCollisions, want) {
+		t.Errorf("CaseCollisions = %v, want %v", m.CaseCollisions, want)
+	}
+}
+
+// commitSidecars stages the sidecars recorded by record for archivePath and
+// commits them.
+func commitSidecars(t *testing.T, archivePath string, record func(txn *processing.Transaction)) {
+	t.Helper()
+	txn, err := processing.NewTransaction(filepath.Dir(archivePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record(txn)
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+}
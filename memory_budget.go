@@ -0,0 +1,123 @@
+// This file is part of bkpdir
+//
+// Package main provides the memory budget of archive creation: with
+// `max_memory`, the buffers of concurrent jobs and of the files they prepare
+// stay within a limit, and fewer jobs run instead of exceeding it.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"sync"
+)
+
+// jobMemory is the working memory of a job or of the writer: the deflate
+// state and read buffers.
+const jobMemory = 1 << 20
+
+// ⭐ MEMORY-001: Memory budget - 🛡️
+// memoryBudget hands out bytes of max_memory. A nil budget is unlimited.
+type memoryBudget struct {
+	mu     sync.Mutex
+	cond   *sync.Cond
+	limit  int64
+	used   int64
+	closed bool
+}
+
+// newMemoryBudget returns the budget of max_memory, keeping the memory of
+// the writer aside; nil when limit is 0.
+func newMemoryBudget(limit int64) *memoryBudget {
+	if limit == 0 {
+		return nil
+	}
+	b := &memoryBudget{limit: max(limit-jobMemory, jobMemory)}
+	b.cond = sync.NewCond(&b.mu)
+	return b
+}
+
+// jobs returns how many of jobs the budget allows: each needs room for its
+// working memory and at least as much for the file it prepares.
+func (b *memoryBudget) jobs(jobs int) int {
+	if b == nil {
+		return jobs
+	}
+	return min(jobs, max(1, int(b.limit/(2*jobMemory))))
+}
+
+// maxEntrySize is the largest file a job prepares; larger ones are streamed
+// by the writer.
+func (b *memoryBudget) maxEntrySize() int64 {
+	if b == nil {
+		return parallelEntryLimit
+	}
+	return min(parallelEntryLimit, b.limit-jobMemory)
+}
+
+// entryMemory is the memory a job needs to prepare path: its working memory
+// and the size of the file, which bounds the deflated data. Files the writer
+// streams need none.
+func (b *memoryBudget) entryMemory(path string) int64 {
+	if b == nil {
+		return 0
+	}
+	info, err := os.Lstat(path)
+	if err != nil || !info.Mode().IsRegular() || info.Size() > b.maxEntrySize() {
+		return 0
+	}
+	return jobMemory + info.Size()
+}
+
+// reserve waits until n bytes fit in the budget and takes them. It returns
+// false once the budget is closed.
+func (b *memoryBudget) reserve(n int64) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for !b.closed && b.used > 0 && b.used+n > b.limit {
+		b.cond.Wait()
+	}
+	if b.closed {
+		return false
+	}
+	b.used += n
+	return true
+}
+
+// release returns n reserved bytes.
+func (b *memoryBudget) release(n int64) {
+	if b == nil || n == 0 {
+		return
+	}
+	b.mu.Lock()
+	b.used -= n
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
+
+// shrink returns the part of reserved bytes beyond keep, once a job knows
+// how much of its reservation the prepared entry holds, and returns what is
+// still reserved.
+func (b *memoryBudget) shrink(reserved, keep int64) int64 {
+	if keep >= reserved {
+		return reserved
+	}
+	b.release(reserved - keep)
+	return keep
+}
+
+// close wakes and refuses all waiting reservations.
+func (b *memoryBudget) close() {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	b.closed = true
+	b.mu.Unlock()
+	b.cond.Broadcast()
+}
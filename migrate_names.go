@@ -0,0 +1,174 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir migrate-names`, which renames existing
+// archives after the timestamp layout of archive names changed, so old and
+// new archives sort and parse alike.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/formatter"
+)
+
+// MigrateNamesOptions configures `bkpdir migrate-names`.
+type MigrateNamesOptions struct {
+	Config     *Config
+	Output     io.Writer
+	FromLayout string // Timestamp layout of the existing names; empty for timestamp_format
+	ToLayout   string // Timestamp layout of the new names; empty for timestamp_format
+	Apply      bool   // Rename the archives instead of only listing the renames
+	DryRun     bool
+}
+
+// nameMigration is the rename of one archive.
+type nameMigration struct {
+	From, To string
+}
+
+// ⭐ MIGRATE-001: Archive name migration - 🔧
+// MigrateArchiveNames renames the archives of the current directory whose
+// names carry a timestamp in FromLayout so they carry it in ToLayout. The
+// rest of each name is kept, and incremental archives are renamed with
+// their base so they still name it. Sidecars, seals and SHA256SUMS follow
+// the archives. Without Apply the renames are only listed.
+func MigrateArchiveNames(opts MigrateNamesOptions) error {
+	cfg := opts.Config
+	from, to := opts.FromLayout, opts.ToLayout
+	if from == "" {
+		from = cfg.TimestampFormat
+	}
+	if to == "" {
+		to = cfg.TimestampFormat
+	}
+	if from == to {
+		return NewArchiveError(fmt.Sprintf("Archive names already use %q; set --from-template or --to-template", to), cfg.StatusConfigError)
+	}
+	// The new names must stay parseable by the archive filename pattern
+	check := *cfg
+	check.TimestampFormat = to
+	if err := ValidateTimestampSettings(&check); err != nil {
+		return NewArchiveErrorWithCause("Invalid --to-template", cfg.StatusConfigError, err)
+	}
+	loc, err := ResolveTimestampLocation(cfg.TimestampTimezone)
+	if err != nil {
+		return NewArchiveErrorWithCause("Invalid timestamp_timezone", cfg.StatusConfigError, err)
+	}
+
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	sort.Slice(archives, func(i, j int) bool { return archives[i].Name < archives[j].Name })
+
+	var migrations []nameMigration
+	skipped := 0
+	existing := make(map[string]bool, len(archives))
+	for _, a := range archives {
+		existing[a.Name] = true
+	}
+	targets := make(map[string]string)
+	for _, a := range archives {
+		newName, ok := migrateArchiveName(a.Name, from, to, loc)
+		if !ok {
+			skipped++
+			continue
+		}
+		if other, taken := targets[newName]; taken {
+			return NewArchiveError(fmt.Sprintf("%s and %s would both be renamed to %s", other, a.Name, newName), 1)
+		}
+		if existing[newName] {
+			return NewArchiveError(fmt.Sprintf("Cannot rename %s to %s: the archive exists", a.Name, newName), 1)
+		}
+		targets[newName] = a.Name
+		migrations = append(migrations, nameMigration{From: a.Name, To: newName})
+	}
+
+	w := opts.Output
+	if len(migrations) == 0 {
+		fmt.Fprintf(w, "No archives in %s use the timestamp layout %q\n", archiveDir, from)
+		return nil
+	}
+	if !opts.Apply || opts.DryRun {
+		for _, m := range migrations {
+			fmt.Fprintf(w, "%s -> %s\n", m.From, m.To)
+		}
+		fmt.Fprintf(w, "%d archives would be renamed", len(migrations))
+		if skipped > 0 {
+			fmt.Fprintf(w, " (%d left alone: not in the layout %q)", skipped, from)
+		}
+		fmt.Fprintln(w, "; run with --apply to rename them")
+		return nil
+	}
+
+	for i, m := range migrations {
+		if err := renameArchive(filepath.Join(archiveDir, m.From), filepath.Join(archiveDir, m.To)); err != nil {
+			return NewArchiveErrorWithCause(
+				fmt.Sprintf("Failed to rename %s after renaming %d of %d archives", m.From, i, len(migrations)), 1, err)
+		}
+		fmt.Fprintf(w, "Renamed %s to %s\n", m.From, m.To)
+	}
+	fmt.Fprintf(w, "Renamed %d archives\n", len(migrations))
+	return nil
+}
+
+// migrateArchiveName returns name with its timestamps reformatted from one
+// layout to another. Incremental names also carry the name of their base
+// archive, whose timestamp is reformatted the same way. It reports false
+// when a timestamp is not in the from layout.
+func migrateArchiveName(name, from, to string, loc *time.Location) (string, bool) {
+	// ⭐ NAME-PATTERN-001: Every position of a name is tried as a timestamp
+	if len(name) > formatter.MaxPatternInputLength {
+		return "", false
+	}
+	stem := strings.TrimSuffix(name, ".zip")
+	base, update, incremental := strings.Cut(stem, "_update=")
+	newBase, ok := migrateNameTimestamp(base, from, to, loc, false)
+	if !ok {
+		return "", false
+	}
+	if !incremental {
+		return newBase + ".zip", true
+	}
+	newUpdate, ok := migrateNameTimestamp(update, from, to, loc, true)
+	if !ok {
+		return "", false
+	}
+	return newBase + "_update=" + newUpdate + ".zip", true
+}
+
+// migrateNameTimestamp reformats the timestamp in a name stem. The timestamp
+// starts the stem or follows a dash of the prefix, and ends the stem or
+// precedes the sequence suffix or the first "=" field. With atStart the
+// timestamp must start the stem, as in the update part of incremental names.
+func migrateNameTimestamp(stem, from, to string, loc *time.Location, atStart bool) (string, bool) {
+	for start := 0; start < len(stem); start++ {
+		if start > 0 && (atStart || stem[start-1] != '-') {
+			continue
+		}
+		for end := start + 1; end <= len(stem); end++ {
+			if end < len(stem) && stem[end] != '_' && stem[end] != '=' {
+				continue
+			}
+			t, err := time.ParseInLocation(from, stem[start:end], loc)
+			if err != nil || t.Format(from) != stem[start:end] {
+				continue
+			}
+			return stem[:start] + t.Format(to) + stem[end:], true
+		}
+	}
+	return "", false
+}
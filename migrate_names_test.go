@@ -0,0 +1,109 @@
+// This file is part of bkpdir
+
+// Package main provides tests for migrating archive names.
+// It verifies that timestamps are reformatted with the rest of the name
+// kept, that incremental archives keep naming their base, and that
+// colliding names stop the migration.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMigrateArchiveName(t *testing.T) {
+	const from, to = "20060102T1504", "2006-01-02-15-04"
+	tests := []struct {
+		name string
+		want string
+		ok   bool
+	}{
+		{"project-20240320T1430.zip", "project-2024-03-20-14-30.zip", true},
+		{"my-project-20240320T1430_2=main=abc123=note.zip", "my-project-2024-03-20-14-30_2=main=abc123=note.zip", true},
+		{"20240320T1430=note.zip", "2024-03-20-14-30=note.zip", true},
+		{"project-20240320T1430=wip_update=20240321T0915=fix.zip",
+			"project-2024-03-20-14-30=wip_update=2024-03-21-09-15=fix.zip", true},
+		{"project-2024-03-20-14-30.zip", "", false},
+		{"notes.zip", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := migrateArchiveName(tt.name, from, to, time.UTC)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("migrateArchiveName(%s) = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+// ⭐ MIGRATE-001: Archive name migration - 🧪
+func TestMigrateArchiveNames(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	names := []string{
+		"project-20240320T1430=wip.zip",
+		"project-20240320T1430=wip_update=20240321T0915.zip",
+		"project-2024-03-22-08-00.zip",
+	}
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(archiveDir, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := filepath.Join(archiveDir, ".metadata", names[0]+".json")
+	if err := os.MkdirAll(filepath.Dir(status), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(status, []byte(`{"is_verified":true}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	opts := MigrateNamesOptions{Config: cfg, Output: &out, FromLayout: "20060102T1504"}
+	if err := MigrateArchiveNames(opts); err != nil {
+		t.Fatalf("MigrateArchiveNames: %v", err)
+	}
+	if !strings.Contains(out.String(), "2 archives would be renamed") || !pathExists(filepath.Join(archiveDir, names[0])) {
+		t.Fatalf("Expected only a listing without --apply:\n%s", out.String())
+	}
+
+	out.Reset()
+	opts.Apply = true
+	if err := MigrateArchiveNames(opts); err != nil {
+		t.Fatalf("MigrateArchiveNames --apply: %v", err)
+	}
+	archives, err := ListArchives(archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]Archive{}
+	for _, a := range archives {
+		got[a.Name] = a
+	}
+	full, ok := got["project-2024-03-20-14-30=wip.zip"]
+	if !ok || full.VerificationStatus == nil || !full.VerificationStatus.IsVerified {
+		t.Errorf("Full archive or its verification status not migrated: %v", got)
+	}
+	inc, ok := got["project-2024-03-20-14-30=wip_update=2024-03-21-09-15.zip"]
+	if !ok || inc.BaseArchive != full.Name {
+		t.Errorf("Incremental archive does not name its migrated base: %+v", inc)
+	}
+	if _, ok := got[names[2]]; !ok {
+		t.Errorf("Archive already in the new layout was renamed: %v", got)
+	}
+
+	// A name that is taken stops the migration before anything is renamed
+	for _, name := range []string{"project-20240401T1000.zip", "project-2024-04-01-10-00.zip", "project-20240402T1000.zip"} {
+		if err := os.WriteFile(filepath.Join(archiveDir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := MigrateArchiveNames(opts); err == nil || !strings.Contains(err.Error(), "exists") {
+		t.Errorf("Expected a collision error, got %v", err)
+	}
+	if !pathExists(filepath.Join(archiveDir, "project-20240402T1000.zip")) {
+		t.Error("Archives were renamed despite the collision")
+	}
+}
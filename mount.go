@@ -0,0 +1,310 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir mount`, which serves an archive as a
+// read-only FUSE file system. Entries are decompressed on demand as they
+// are read, and the file system is unmounted on SIGINT or SIGTERM.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"bkpdir/pkg/fuse"
+)
+
+// archiveNode is a file or directory of a mounted archive.
+type archiveNode struct {
+	attr     fuse.Attr
+	file     *zip.File // nil for directories
+	children map[string]*archiveNode
+	names    []string // sorted child names
+}
+
+// entryStream is an open decompressor of an entry and its position.
+type entryStream struct {
+	rc  io.ReadCloser
+	pos int64
+}
+
+// ⭐ MOUNT-001: Archive file system - 🔧
+// archiveFS serves the entries of a zip archive. Stored entries are read
+// directly from the archive file; compressed entries keep a decompressor
+// per open file, so sequential reads stream and seeking backwards restarts
+// the entry.
+type archiveFS struct {
+	file    *os.File
+	nodes   []*archiveNode // indexed by inode number - 1
+	mu      sync.Mutex
+	streams map[uint64]*entryStream
+}
+
+// openArchiveFS builds the directory tree of the archive at path. Entry names
+// that are absolute or contain ".." are refused.
+func openArchiveFS(path string) (*archiveFS, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r, err := zip.NewReader(f, info.Size())
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	afs := &archiveFS{file: f, streams: make(map[uint64]*entryStream)}
+	root := afs.newNode(os.ModeDir|0o555, info.ModTime())
+	for _, zf := range r.File {
+		if zf.Name == ".checksums" {
+			continue
+		}
+		rel, err := restoreEntryPath(zf.Name)
+		if err != nil {
+			f.Close()
+			return nil, err
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		dir := root
+		for _, part := range parts[:len(parts)-1] {
+			dir = afs.child(dir, part, os.ModeDir|0o555, zf.Modified)
+		}
+		name := parts[len(parts)-1]
+		if zf.FileInfo().IsDir() {
+			node := afs.child(dir, name, os.ModeDir|0o555, zf.Modified)
+			node.attr.Mtime = zf.Modified
+			continue
+		}
+		if _, exists := dir.children[name]; exists {
+			continue
+		}
+		node := afs.child(dir, name, zf.Mode()&(os.ModeSymlink|os.ModePerm)&^0o222, zf.Modified)
+		node.file = zf
+		node.attr.Size = zf.UncompressedSize64
+	}
+	for _, node := range afs.nodes {
+		sort.Strings(node.names)
+	}
+	return afs, nil
+}
+
+// newNode adds a node with the next inode number.
+func (a *archiveFS) newNode(mode os.FileMode, mtime time.Time) *archiveNode {
+	node := &archiveNode{attr: fuse.Attr{Ino: uint64(len(a.nodes) + 1), Mode: mode, Mtime: mtime}}
+	if mode.IsDir() {
+		node.children = make(map[string]*archiveNode)
+	}
+	a.nodes = append(a.nodes, node)
+	return node
+}
+
+// child returns the child name of dir, creating it with mode if missing.
+func (a *archiveFS) child(dir *archiveNode, name string, mode os.FileMode, mtime time.Time) *archiveNode {
+	if node, ok := dir.children[name]; ok {
+		return node
+	}
+	if mode&os.ModePerm == 0 && !mode.IsDir() {
+		mode |= 0o444
+	}
+	node := a.newNode(mode, mtime)
+	dir.children[name] = node
+	dir.names = append(dir.names, name)
+	return node
+}
+
+// node returns the node with inode number ino.
+func (a *archiveFS) node(ino uint64) (*archiveNode, error) {
+	if ino == 0 || ino > uint64(len(a.nodes)) {
+		return nil, fs.ErrNotExist
+	}
+	return a.nodes[ino-1], nil
+}
+
+// Attr implements fuse.FS.
+func (a *archiveFS) Attr(ino uint64) (fuse.Attr, error) {
+	node, err := a.node(ino)
+	if err != nil {
+		return fuse.Attr{}, err
+	}
+	return node.attr, nil
+}
+
+// Lookup implements fuse.FS.
+func (a *archiveFS) Lookup(parent uint64, name string) (fuse.Attr, error) {
+	dir, err := a.node(parent)
+	if err != nil {
+		return fuse.Attr{}, err
+	}
+	node, ok := dir.children[name]
+	if !ok {
+		return fuse.Attr{}, fs.ErrNotExist
+	}
+	return node.attr, nil
+}
+
+// ReadDir implements fuse.FS.
+func (a *archiveFS) ReadDir(ino uint64) ([]fuse.Dirent, error) {
+	dir, err := a.node(ino)
+	if err != nil {
+		return nil, err
+	}
+	if dir.children == nil {
+		return nil, syscall.ENOTDIR
+	}
+	entries := make([]fuse.Dirent, 0, len(dir.names))
+	for _, name := range dir.names {
+		child := dir.children[name]
+		entries = append(entries, fuse.Dirent{Name: name, Ino: child.attr.Ino, Mode: child.attr.Mode})
+	}
+	return entries, nil
+}
+
+// Readlink implements fuse.FS.
+func (a *archiveFS) Readlink(ino uint64) (string, error) {
+	node, err := a.node(ino)
+	if err != nil {
+		return "", err
+	}
+	if node.file == nil || node.attr.Mode&os.ModeSymlink == 0 {
+		return "", syscall.EINVAL
+	}
+	rc, err := node.file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(rc)
+	return string(target), err
+}
+
+// ReadAt implements fuse.FS.
+func (a *archiveFS) ReadAt(ino uint64, p []byte, off int64) (int, error) {
+	node, err := a.node(ino)
+	if err != nil {
+		return 0, err
+	}
+	if node.file == nil {
+		return 0, syscall.EISDIR
+	}
+	if off >= int64(node.attr.Size) {
+		return 0, io.EOF
+	}
+	if node.file.Method == zip.Store {
+		start, err := node.file.DataOffset()
+		if err != nil {
+			return 0, err
+		}
+		if rest := int64(node.attr.Size) - off; int64(len(p)) > rest {
+			p = p[:rest]
+		}
+		return a.file.ReadAt(p, start+off)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	s := a.streams[ino]
+	if s == nil || off < s.pos {
+		if s != nil {
+			s.rc.Close()
+		}
+		rc, err := node.file.Open()
+		if err != nil {
+			return 0, err
+		}
+		s = &entryStream{rc: rc}
+		a.streams[ino] = s
+	}
+	if off > s.pos {
+		skipped, err := io.CopyN(io.Discard, s.rc, off-s.pos)
+		s.pos += skipped
+		if err != nil {
+			return 0, err
+		}
+	}
+	n, err := io.ReadFull(s.rc, p)
+	s.pos += int64(n)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	return n, err
+}
+
+// Release implements fuse.FS.
+func (a *archiveFS) Release(ino uint64) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if s := a.streams[ino]; s != nil {
+		s.rc.Close()
+		delete(a.streams, ino)
+	}
+}
+
+// Close closes the open entries and the archive.
+func (a *archiveFS) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for ino, s := range a.streams {
+		s.rc.Close()
+		delete(a.streams, ino)
+	}
+	return a.file.Close()
+}
+
+// ⭐ MOUNT-001: Mount command - 🔧
+// MountArchive mounts the named archive read-only at mountpoint and serves
+// it until SIGINT or SIGTERM, or until it is unmounted from outside.
+func MountArchive(w io.Writer, cfg *Config, name, mountpoint string) error {
+	archivePath, err := resolveRestoreArchive(cfg, name)
+	if err != nil {
+		return err
+	}
+	afs, err := openArchiveFS(archivePath)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to open archive", 1, err)
+	}
+	defer afs.Close()
+
+	conn, err := fuse.Mount(mountpoint, "bkpdir:"+filepath.Base(archivePath))
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Failed to mount %s", mountpoint), 1, err)
+	}
+	defer conn.Close()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+	served := make(chan error, 1)
+	go func() { served <- conn.Serve(afs) }()
+	fmt.Fprintf(w, "Mounted %s at %s; press Ctrl-C to unmount\n", filepath.Base(archivePath), mountpoint)
+
+	select {
+	case err = <-served:
+	case <-signals:
+		if err := conn.Unmount(); err != nil {
+			return NewArchiveErrorWithCause("Failed to unmount", 1, err)
+		}
+		err = <-served
+	}
+	if err != nil {
+		return NewArchiveErrorWithCause("FUSE server failed", 1, err)
+	}
+	fmt.Fprintf(w, "Unmounted %s\n", mountpoint)
+	return nil
+}
@@ -0,0 +1,60 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the archive file system of bkpdir mount.
+// It verifies the directory tree built from archive entries and that
+// compressed entries stream forwards and restart when read backwards.
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ MOUNT-001: Archive file system - 🧪
+func TestArchiveFS(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.zip")
+	big := strings.Repeat("0123456789", 1000)
+	writeTestZip(t, path, map[string]string{"a.txt": "alpha", "dir/sub/big.txt": big, ".checksums": "{}"})
+	afs, err := openArchiveFS(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer afs.Close()
+
+	entries, err := afs.ReadDir(1)
+	if err != nil || len(entries) != 2 || entries[0].Name != "a.txt" || entries[1].Name != "dir" || !entries[1].Mode.IsDir() {
+		t.Fatalf("Unexpected root listing %+v, %v", entries, err)
+	}
+	dir, _ := afs.Lookup(1, "dir")
+	sub, _ := afs.Lookup(dir.Ino, "sub")
+	file, err := afs.Lookup(sub.Ino, "big.txt")
+	if err != nil || file.Size != uint64(len(big)) || file.Mode.Perm()&0o222 != 0 {
+		t.Fatalf("Unexpected attributes %+v, %v", file, err)
+	}
+	if _, err := afs.Lookup(1, "missing"); !os.IsNotExist(err) {
+		t.Errorf("Expected a missing name to not exist, got %v", err)
+	}
+
+	buf := make([]byte, 10)
+	for _, off := range []int64{0, 5000, 20, 9995} {
+		n, err := afs.ReadAt(file.Ino, buf, off)
+		if want := big[off:min(off+10, int64(len(big)))]; string(buf[:n]) != want || (err != nil && err != io.EOF) {
+			t.Errorf("ReadAt(%d): expected %q, got %q, %v", off, want, buf[:n], err)
+		}
+	}
+	if _, err := afs.ReadAt(file.Ino, buf, int64(len(big))); err != io.EOF {
+		t.Errorf("Expected EOF past the end, got %v", err)
+	}
+	afs.Release(file.Ino)
+	if len(afs.streams) != 0 {
+		t.Error("Expected release to close the entry stream")
+	}
+
+	writeTestZip(t, path, map[string]string{"../escape": "x"})
+	if _, err := openArchiveFS(path); err == nil {
+		t.Error("Expected unsafe entry names to be refused")
+	}
+}
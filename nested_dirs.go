@@ -0,0 +1,118 @@
+// This file is part of bkpdir
+//
+// Package main keeps the archive and backup directories out of the archives
+// of a directory containing them, so runs do not archive earlier archives
+// over and over: they are excluded with a warning, or the run is refused
+// with nested_archive_dirs: error.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ⭐ NESTED-DIR-001: Policies for output directories inside the archived directory - 🛡️
+const (
+	// NestedDirsExclude excludes the nested directories with a warning.
+	NestedDirsExclude = "exclude"
+	// NestedDirsError refuses to archive a directory containing them.
+	NestedDirsError = "error"
+)
+
+// nestedDirsPolicy validates nested_archive_dirs; empty means exclude.
+func nestedDirsPolicy(cfg *Config) (string, error) {
+	switch cfg.NestedArchiveDirs {
+	case "":
+		return NestedDirsExclude, nil
+	case NestedDirsExclude, NestedDirsError:
+		return cfg.NestedArchiveDirs, nil
+	default:
+		return "", NewArchiveError(fmt.Sprintf("Invalid nested_archive_dirs %q (use exclude or error)",
+			cfg.NestedArchiveDirs), cfg.StatusConfigError)
+	}
+}
+
+// ⭐ NESTED-DIR-001: Nested output directory detection - 🔍
+// nestedDirExclusions returns an exclusion for archive_dir_path and
+// backup_dir_path when they are inside dir, the directory being archived,
+// with the setting as origin. It fails when one of them is dir itself, or
+// with nested_archive_dirs: error.
+func nestedDirExclusions(cfg *Config, dir string) ([]explainExclusion, error) {
+	policy, err := nestedDirsPolicy(cfg)
+	if err != nil {
+		return nil, err
+	}
+	root := resolvedDir(dir)
+	var exclusions []explainExclusion
+	for _, setting := range []struct{ name, path string }{
+		{"archive_dir_path", cfg.ArchiveDirPath},
+		{"backup_dir_path", cfg.BackupDirPath},
+	} {
+		if setting.path == "" || isRemoteLocation(setting.path) {
+			continue
+		}
+		path := expandPath(setting.path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(dir, path)
+		}
+		rel, err := filepath.Rel(root, resolvedDir(path))
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+		if rel == "." {
+			return nil, NewArchiveError(fmt.Sprintf("%s %s is the directory being archived; "+
+				"point it outside %s", setting.name, setting.path, dir), cfg.StatusConfigError)
+		}
+		if policy == NestedDirsError {
+			return nil, NewArchiveError(fmt.Sprintf("%s %s is inside %s, so archives would include it; "+
+				"point it outside or set nested_archive_dirs: exclude", setting.name, setting.path, dir),
+				cfg.StatusConfigError)
+		}
+		exclusions = append(exclusions, explainExclusion{
+			Pattern: globEscape(filepath.ToSlash(rel)) + "/**",
+			Origin:  setting.name,
+		})
+	}
+	return exclusions, nil
+}
+
+// resolvedDir returns the absolute path of dir with symbolic links resolved
+// as far as it exists, so links to the archived directory are recognized.
+func resolvedDir(dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return filepath.Clean(dir)
+	}
+	var missing []string
+	for path := abs; ; path = filepath.Dir(path) {
+		if resolved, err := filepath.EvalSymlinks(path); err == nil {
+			return filepath.Join(append([]string{resolved}, missing...)...)
+		}
+		if filepath.Dir(path) == path {
+			return abs
+		}
+		missing = append([]string{filepath.Base(path)}, missing...)
+	}
+}
+
+// excludeNestedDirs returns patterns extended with the exclusions of
+// nestedDirExclusions for dir, warning on stderr about each one.
+func excludeNestedDirs(cfg *Config, dir string, patterns []string) ([]string, error) {
+	exclusions, err := nestedDirExclusions(cfg, dir)
+	if err != nil || len(exclusions) == 0 {
+		return patterns, err
+	}
+	patterns = append([]string{}, patterns...)
+	for _, e := range exclusions {
+		fmt.Fprintf(os.Stderr, "Warning: %s is inside the directory being archived; excluding %s\n",
+			e.Origin, strings.TrimSuffix(e.Pattern, "/**"))
+		patterns = append(patterns, e.Pattern)
+	}
+	return patterns, nil
+}
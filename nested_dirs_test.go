@@ -0,0 +1,106 @@
+// This file is part of bkpdir
+
+// Package main provides tests for nested output directory handling.
+// It verifies that archives never include the archive or backup directory.
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ NESTED-DIR-001: Nested output directory detection tests - 🧪
+func TestNestedDirExclusions(t *testing.T) {
+	dir := t.TempDir()
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = "backups/archives"
+	cfg.BackupDirPath = filepath.Join(dir, "[files]")
+
+	exclusions, err := nestedDirExclusions(cfg, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []explainExclusion{
+		{Pattern: "backups/archives/**", Origin: "archive_dir_path"},
+		{Pattern: `\[files]/**`, Origin: "backup_dir_path"},
+	}
+	if len(exclusions) != len(want) || exclusions[0] != want[0] || exclusions[1] != want[1] {
+		t.Errorf("exclusions = %+v, want %+v", exclusions, want)
+	}
+	if !ShouldExcludeFile("[files]/notes.txt", []string{exclusions[1].Pattern}) ||
+		ShouldExcludeFile("src/backups/archives/a.zip", []string{exclusions[0].Pattern}) {
+		t.Error("exclusions must match the nested directories only")
+	}
+
+	// Directories outside, remote or reached through a link to dir
+	link := filepath.Join(t.TempDir(), "link")
+	if err := os.Symlink(dir, link); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ArchiveDirPath = "../archives"
+	cfg.BackupDirPath = "s3://bucket/backups"
+	if exclusions, err := nestedDirExclusions(cfg, dir); err != nil || len(exclusions) != 0 {
+		t.Errorf("outside: %+v (%v)", exclusions, err)
+	}
+	cfg.ArchiveDirPath = filepath.Join(link, "out")
+	if exclusions, err := nestedDirExclusions(cfg, dir); err != nil || len(exclusions) != 1 {
+		t.Errorf("through a link: %+v (%v)", exclusions, err)
+	}
+
+	cfg.NestedArchiveDirs = NestedDirsError
+	if _, err := nestedDirExclusions(cfg, dir); err == nil || !strings.Contains(err.Error(), "archive_dir_path") {
+		t.Errorf("error policy: %v", err)
+	}
+	cfg.NestedArchiveDirs = NestedDirsExclude
+	cfg.ArchiveDirPath = "."
+	if _, err := nestedDirExclusions(cfg, dir); err == nil || !strings.Contains(err.Error(), "is the directory being archived") {
+		t.Errorf("archived directory itself: %v", err)
+	}
+	cfg.NestedArchiveDirs = "ignore"
+	if _, err := nestedDirExclusions(cfg, dir); err == nil || !strings.Contains(err.Error(), "use exclude or error") {
+		t.Errorf("invalid policy: %v", err)
+	}
+}
+
+// ⭐ NESTED-DIR-001: Archives leave out a nested archive directory - 🧪
+func TestFullArchiveExcludesNestedArchiveDir(t *testing.T) {
+	cfg := uploadTestConfig(t, ".bkpdir")
+	cfg.BackupDirPath = "../backups"
+	if err := os.WriteFile("main.go", []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var archives []string
+	for i := 0; i < 2; i++ {
+		archive, err := createFullArchive(context.Background(), cfg, "", nil, false, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archives = append(archives, archive)
+		if err := os.WriteFile("main.go", []byte("package main\n\n// changed\n"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if filepath.Dir(archives[1]) != ".bkpdir" {
+		t.Fatalf("archive written to %s", archives[1])
+	}
+	r, err := zip.OpenReader(archives[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, f := range r.File {
+		if strings.HasPrefix(f.Name, ".bkpdir") {
+			t.Errorf("second archive holds %s", f.Name)
+		}
+	}
+
+	cfg.NestedArchiveDirs = NestedDirsError
+	if _, err := createFullArchive(context.Background(), cfg, "", nil, false, false); err == nil {
+		t.Error("Expected nested_archive_dirs: error to refuse the run")
+	}
+}
@@ -0,0 +1,144 @@
+// This file is part of bkpdir
+
+// Package main provides tests for scanning with the parallel directory
+// walker. It verifies the walk order, skipping and cancellation.
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+
+	"bkpdir/pkg/fileops"
+)
+
+// makeWalkTree creates a tree of nested directories and files below dir.
+func makeWalkTree(t *testing.T, dir string) {
+	t.Helper()
+	for i := 0; i < 6; i++ {
+		for j := 0; j < 4; j++ {
+			sub := filepath.Join(dir, fmt.Sprintf("d%d", i), fmt.Sprintf("e%d", j))
+			if err := os.MkdirAll(sub, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			for k := 0; k < 3; k++ {
+				os.WriteFile(filepath.Join(sub, fmt.Sprintf("f%d", k)), nil, 0o644)
+			}
+		}
+		os.WriteFile(filepath.Join(dir, fmt.Sprintf("d%d", i), "top"), nil, 0o644)
+	}
+	os.Symlink("d0", filepath.Join(dir, "link"))
+}
+
+// ⭐ WALK-001: Walk order - 🧪
+func TestParallelWalkMatchesWalkDir(t *testing.T) {
+	dir := t.TempDir()
+	makeWalkTree(t, dir)
+	var want []string
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		want = append(want, path)
+		return err
+	})
+
+	for _, opts := range []fileops.ParallelWalkOptions{
+		{Ordered: true},
+		{Ordered: true, Parallelism: 1, Info: true},
+		{Parallelism: 3},
+	} {
+		var got []string
+		err := fileops.ParallelWalk(context.Background(), dir, opts, func(path string, d fs.DirEntry, err error) error {
+			if d.Name() == "link" && d.Type() != fs.ModeSymlink {
+				t.Errorf("%+v: link has type %v", opts, d.Type())
+			}
+			got = append(got, path)
+			return err
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !opts.Ordered {
+			sort.Strings(got)
+			want := append([]string(nil), want...)
+			sort.Strings(want)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("%+v: visited %d entries, want %d", opts, len(got), len(want))
+			}
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%+v: order differs from filepath.WalkDir:\n%v\nwant\n%v", opts, got, want)
+		}
+	}
+}
+
+// ⭐ WALK-001: Skipping and early cancellation - 🧪
+func TestParallelWalkSkipAndStop(t *testing.T) {
+	dir := t.TempDir()
+	makeWalkTree(t, dir)
+
+	for _, ordered := range []bool{true, false} {
+		opts := fileops.ParallelWalkOptions{Ordered: ordered}
+		visited := 0
+		err := fileops.ParallelWalk(context.Background(), dir, opts, func(path string, d fs.DirEntry, err error) error {
+			if path != dir && filepath.Dir(path) != dir && d.IsDir() && d.Name() != "e0" {
+				return filepath.SkipDir
+			}
+			visited++
+			return err
+		})
+		// The root, link, 6 directories with their top files, and each e0 with its 3 files
+		if err != nil || visited != 1+6+6+6*4+1 {
+			t.Errorf("ordered %v: SkipDir visited %d entries (%v)", ordered, visited, err)
+		}
+
+		stop := errors.New("stop")
+		visited = 0
+		err = fileops.ParallelWalk(context.Background(), dir, opts, func(path string, d fs.DirEntry, err error) error {
+			if visited++; visited == 10 {
+				return stop
+			}
+			return err
+		})
+		if err != stop || visited != 10 {
+			t.Errorf("ordered %v: stop = %v after %d entries", ordered, err, visited)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		visited = 0
+		err = fileops.ParallelWalk(ctx, dir, opts, func(path string, d fs.DirEntry, err error) error {
+			if visited++; visited == 5 {
+				cancel()
+			}
+			return err
+		})
+		if !errors.Is(err, context.Canceled) || visited != 5 {
+			t.Errorf("ordered %v: cancel = %v after %d entries", ordered, err, visited)
+		}
+	}
+}
+
+// ⭐ WALK-001: Archive scanning order - 🧪
+func TestCollectFilesToArchiveOrder(t *testing.T) {
+	dir := t.TempDir()
+	makeWalkTree(t, dir)
+	var want []string
+	filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if rel, _ := filepath.Rel(dir, path); !info.IsDir() && rel != "link" {
+			want = append(want, rel)
+		}
+		return err
+	})
+	got, err := collectFilesToArchiveWithInterface(context.Background(), dir, []string{"link"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("files = %v\nwant %v", got, want)
+	}
+}
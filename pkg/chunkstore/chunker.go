@@ -0,0 +1,146 @@
+// ⭐ CDC-001: Content-defined chunking - Gear hash boundaries that survive insertions - 🔧
+package chunkstore
+
+import (
+	"fmt"
+	"io"
+	"math/bits"
+)
+
+// Smallest and largest average chunk sizes accepted by NewParams.
+const (
+	MinAverageSize = 256
+	MaxAverageSize = 64 << 20
+)
+
+// Params are the chunk size bounds of a repository. They are fixed when the
+// repository is created, since different parameters cut the same data into
+// different chunks and defeat deduplication.
+type Params struct {
+	Min int `json:"min"`
+	Avg int `json:"avg"`
+	Max int `json:"max"`
+}
+
+// NewParams returns the parameters for an average chunk size, rounded down to
+// a power of two. Chunks are at least a quarter and at most eight times the
+// average.
+func NewParams(avg int64) (Params, error) {
+	if avg < MinAverageSize || avg > MaxAverageSize {
+		return Params{}, fmt.Errorf("average chunk size must be between %d and %d bytes, got %d", MinAverageSize, MaxAverageSize, avg)
+	}
+	size := 1 << (bits.Len64(uint64(avg)) - 1)
+	return Params{Min: size / 4, Avg: size, Max: size * 8}, nil
+}
+
+// validate checks parameters read from a repository.
+func (p Params) validate() error {
+	if p.Avg < MinAverageSize || p.Avg > MaxAverageSize || p.Avg&(p.Avg-1) != 0 || p.Min <= 0 || p.Min > p.Avg || p.Max < p.Avg {
+		return fmt.Errorf("invalid chunker parameters %+v", p)
+	}
+	return nil
+}
+
+// gear maps each byte to a pseudo-random value. The table is generated with
+// splitmix64 from a fixed seed so that every build cuts identical data at
+// identical positions.
+var gear = func() [256]uint64 {
+	var table [256]uint64
+	state := uint64(0x62_6b_70_64_69_72) // "bkpdir"
+	for i := range table {
+		state += 0x9e3779b97f4a7c15
+		z := state
+		z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+		z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+		table[i] = z ^ (z >> 31)
+	}
+	return table
+}()
+
+// ⭐ CDC-001: Chunk boundary detection - 🔍
+// boundary returns the length of the chunk at the start of data, which holds
+// at most p.Max bytes unless it is the end of the stream. Like FastCDC it uses
+// a stricter mask before the average size and a looser one after it, which
+// keeps chunk sizes close to the average. The masks test the high bits of the
+// rolling hash, which depend on the last 64 bytes.
+func boundary(data []byte, p Params) int {
+	n := len(data)
+	if n <= p.Min {
+		return n
+	}
+	if n > p.Max {
+		n = p.Max
+	}
+	avgBits := bits.Len(uint(p.Avg)) - 1
+	strict := ^uint64(0) << (64 - avgBits - 1)
+	loose := ^uint64(0) << (64 - avgBits + 1)
+
+	normal := p.Avg
+	if normal > n {
+		normal = n
+	}
+	var fp uint64
+	i := p.Min
+	for ; i < normal; i++ {
+		fp = fp<<1 + gear[data[i]]
+		if fp&strict == 0 {
+			return i + 1
+		}
+	}
+	for ; i < n; i++ {
+		fp = fp<<1 + gear[data[i]]
+		if fp&loose == 0 {
+			return i + 1
+		}
+	}
+	return n
+}
+
+// Chunker splits a stream into content-defined chunks.
+type Chunker struct {
+	r          io.Reader
+	p          Params
+	buf        []byte
+	start, end int
+	eof        bool
+}
+
+// NewChunker returns a chunker reading from r.
+func NewChunker(r io.Reader, p Params) *Chunker {
+	return &Chunker{r: r, p: p, buf: make([]byte, 2*p.Max)}
+}
+
+// ⭐ CDC-001: Stream chunking - 🔧
+// Next returns the next chunk, or io.EOF after the last one. The returned
+// slice is only valid until the following call.
+func (c *Chunker) Next() ([]byte, error) {
+	if err := c.fill(); err != nil {
+		return nil, err
+	}
+	if c.start == c.end {
+		return nil, io.EOF
+	}
+	n := boundary(c.buf[c.start:c.end], c.p)
+	chunk := c.buf[c.start : c.start+n]
+	c.start += n
+	return chunk, nil
+}
+
+// fill reads until at least p.Max bytes are buffered or the stream ends.
+func (c *Chunker) fill() error {
+	if c.eof || c.end-c.start >= c.p.Max {
+		return nil
+	}
+	c.end = copy(c.buf, c.buf[c.start:c.end])
+	c.start = 0
+	for c.end < len(c.buf) && !c.eof {
+		n, err := c.r.Read(c.buf[c.end:])
+		c.end += n
+		if err == io.EOF {
+			c.eof = true
+		} else if err != nil {
+			return err
+		}
+	}
+	return nil
+}
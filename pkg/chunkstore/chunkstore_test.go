@@ -0,0 +1,158 @@
+// ⭐ CDC-001: Chunk repository tests - Chunking stability, deduplication and verification - 🧪
+package chunkstore
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// chunkAll splits data and returns the chunks.
+func chunkAll(t *testing.T, data []byte, p Params) [][]byte {
+	t.Helper()
+	var chunks [][]byte
+	c := NewChunker(bytes.NewReader(data), p)
+	for {
+		chunk, err := c.Next()
+		if err == io.EOF {
+			return chunks
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		chunks = append(chunks, append([]byte(nil), chunk...))
+	}
+}
+
+func TestNewParams(t *testing.T) {
+	p, err := NewParams(3000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p != (Params{Min: 512, Avg: 2048, Max: 16384}) {
+		t.Errorf("NewParams(3000) = %+v", p)
+	}
+	for _, size := range []int64{0, 100, MaxAverageSize + 1} {
+		if _, err := NewParams(size); err == nil {
+			t.Errorf("Expected an error for average size %d", size)
+		}
+	}
+}
+
+func TestChunker(t *testing.T) {
+	p, _ := NewParams(1024)
+	data := make([]byte, 256*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	chunks := chunkAll(t, data, p)
+	if !bytes.Equal(bytes.Join(chunks, nil), data) {
+		t.Fatal("Chunks do not reassemble the input")
+	}
+	for i, c := range chunks {
+		if len(c) > p.Max || (len(c) < p.Min && i != len(chunks)-1) {
+			t.Errorf("Chunk %d has %d bytes, outside [%d, %d]", i, len(c), p.Min, p.Max)
+		}
+	}
+	if avg := len(data) / len(chunks); avg < p.Avg/2 || avg > p.Avg*2 {
+		t.Errorf("Average chunk size %d is far from %d", avg, p.Avg)
+	}
+
+	// Inserting bytes near the start only changes the chunks around it
+	edited := append(append(append([]byte(nil), data[:1000]...), "inserted"...), data[1000:]...)
+	known := make(map[string]bool)
+	for _, c := range chunks {
+		known[string(c)] = true
+	}
+	var changed int
+	for _, c := range chunkAll(t, edited, p) {
+		if !known[string(c)] {
+			changed++
+		}
+	}
+	if changed > 2 {
+		t.Errorf("Expected the insertion to change at most 2 chunks, got %d of %d", changed, len(chunks))
+	}
+}
+
+func TestRepository(t *testing.T) {
+	src := t.TempDir()
+	content := make([]byte, 64*1024)
+	rand.New(rand.NewSource(2)).Read(content)
+	os.WriteFile(filepath.Join(src, "a.bin"), content, 0o644)
+	os.WriteFile(filepath.Join(src, "b.bin"), content, 0o644)
+	os.WriteFile(filepath.Join(src, "empty"), nil, 0o644)
+	os.Symlink("a.bin", filepath.Join(src, "link"))
+	files := []string{"a.bin", "b.bin", "empty", "link"}
+
+	p, _ := NewParams(1024)
+	dir := filepath.Join(t.TempDir(), "repo")
+	if _, err := Open(dir); !errors.Is(err, ErrNoRepository) {
+		t.Fatalf("Expected ErrNoRepository, got %v", err)
+	}
+	if _, err := Init(dir, p); err != nil {
+		t.Fatal(err)
+	}
+	repo, err := Open(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repo.Params() != p {
+		t.Errorf("Expected params %+v, got %+v", p, repo.Params())
+	}
+
+	first, err := repo.Backup("first", src, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.Stats.Files != 4 || first.Stats.Bytes != 2*int64(len(content)) {
+		t.Errorf("Unexpected stats %+v", first.Stats)
+	}
+	if first.Stats.NewChunks*2 != first.Stats.Chunks {
+		t.Errorf("Expected the identical files to share chunks, got %+v", first.Stats)
+	}
+	if _, err := repo.Backup("first", src, files); err == nil {
+		t.Error("Expected an existing snapshot name to be refused")
+	}
+
+	second, err := repo.Backup("second", src, files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Stats.NewChunks != 0 || second.Stats.NewBytes != 0 {
+		t.Errorf("Expected an unchanged tree to add no chunks, got %+v", second.Stats)
+	}
+
+	snaps, err := repo.Snapshots()
+	if err != nil || len(snaps) != 2 || snaps[0].Name != "first" {
+		t.Fatalf("Snapshots() = %v, %v", snaps, err)
+	}
+	var buf bytes.Buffer
+	if err := repo.WriteFile(&buf, snaps[1].Files[1]); err != nil || !bytes.Equal(buf.Bytes(), content) {
+		t.Errorf("Expected b.bin to round-trip, got %d bytes, %v", buf.Len(), err)
+	}
+	if link := snaps[1].Files[3]; link.Link != "a.bin" || len(link.Chunks) != 0 {
+		t.Errorf("Expected link to be stored as a link, got %+v", link)
+	}
+
+	if problems := repo.Verify(first, true); len(problems) != 0 {
+		t.Errorf("Expected a clean repository, got %v", problems)
+	}
+
+	// Corrupt one chunk and remove another
+	corrupt := repo.chunkPath(first.Files[0].Chunks[0])
+	os.Chmod(corrupt, 0o644)
+	os.WriteFile(corrupt, []byte("garbage"), 0o644)
+	os.Remove(repo.chunkPath(first.Files[0].Chunks[1]))
+
+	// Shared chunks are reported once
+	if problems := repo.Verify(first, false); len(problems) != 1 {
+		t.Errorf("Expected the missing chunk reported, got %v", problems)
+	}
+	if problems := repo.Verify(first, true); len(problems) != 2 {
+		t.Errorf("Expected the corrupt and missing chunks reported, got %v", problems)
+	}
+}
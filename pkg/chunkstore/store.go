@@ -0,0 +1,379 @@
+// ⭐ CDC-001: Deduplicating chunk repository - Chunks stored once across snapshots - 🔧
+package chunkstore
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// repositoryVersion is the layout version written to config.json.
+const repositoryVersion = 1
+
+// ErrNoRepository is returned by Open for a directory that holds no repository.
+var ErrNoRepository = errors.New("not a chunk repository")
+
+// Repository is a directory of chunks and the snapshots that reference them:
+//
+//	config.json            layout version and chunker parameters
+//	chunks/ab/abcd...      zlib-compressed chunk named by the SHA-256 of its data
+//	snapshots/NAME.json    file list of one snapshot
+//
+// Chunks are written before the snapshot that references them, so an
+// interrupted backup leaves at most unreferenced chunks behind.
+type Repository struct {
+	dir    string
+	params Params
+}
+
+// repositoryConfig is the content of config.json.
+type repositoryConfig struct {
+	Version int    `json:"version"`
+	Chunker Params `json:"chunker"`
+}
+
+// Snapshot lists the files of one backup and the chunks holding their data.
+type Snapshot struct {
+	Name    string        `json:"name"`
+	Created time.Time     `json:"created"`
+	Source  string        `json:"source"`
+	Files   []FileEntry   `json:"files"`
+	Stats   SnapshotStats `json:"stats"`
+}
+
+// FileEntry is one file of a snapshot. Path uses forward slashes and is
+// relative to the snapshot source.
+type FileEntry struct {
+	Path    string      `json:"path"`
+	Mode    os.FileMode `json:"mode"`
+	ModTime time.Time   `json:"mtime"`
+	Size    int64       `json:"size"`
+	Link    string      `json:"link,omitempty"`
+	Chunks  []string    `json:"chunks,omitempty"`
+}
+
+// SnapshotStats summarizes a snapshot. NewChunks and NewBytes count the chunks
+// the snapshot added to the repository and their stored (compressed) size.
+type SnapshotStats struct {
+	Files     int   `json:"files"`
+	Bytes     int64 `json:"bytes"`
+	Chunks    int   `json:"chunks"`
+	NewChunks int   `json:"new_chunks"`
+	NewBytes  int64 `json:"new_bytes"`
+}
+
+// ⭐ CDC-001: Repository creation - 🔧
+// Init creates a repository in dir, which must not already hold one.
+func Init(dir string, p Params) (*Repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.validate(); err != nil {
+		return nil, err
+	}
+	configPath := filepath.Join(dir, "config.json")
+	if _, err := os.Stat(configPath); err == nil {
+		return nil, fmt.Errorf("repository already exists in %s", dir)
+	}
+	for _, sub := range []string{"chunks", "snapshots"} {
+		if err := fileops.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, err
+		}
+	}
+	data, err := json.MarshalIndent(repositoryConfig{Version: repositoryVersion, Chunker: p}, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := fileops.AtomicWriteFile(configPath, data, 0o644); err != nil {
+		return nil, err
+	}
+	return &Repository{dir: dir, params: p}, nil
+}
+
+// Open opens the repository in dir. It returns an error wrapping
+// ErrNoRepository when dir has no config.json.
+func Open(dir string) (*Repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "config.json"))
+	if os.IsNotExist(err) {
+		return nil, fmt.Errorf("%s: %w", dir, ErrNoRepository)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var cfg repositoryConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("%s: invalid config.json: %w", dir, err)
+	}
+	if cfg.Version != repositoryVersion {
+		return nil, fmt.Errorf("%s: unsupported repository version %d", dir, cfg.Version)
+	}
+	if err := cfg.Chunker.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", dir, err)
+	}
+	return &Repository{dir: dir, params: cfg.Chunker}, nil
+}
+
+// Dir returns the absolute repository directory.
+func (r *Repository) Dir() string {
+	return r.dir
+}
+
+// Params returns the chunker parameters of the repository.
+func (r *Repository) Params() Params {
+	return r.params
+}
+
+// chunkPath returns the file holding the chunk with the given id.
+func (r *Repository) chunkPath(id string) string {
+	return filepath.Join(r.dir, "chunks", id[:2], id)
+}
+
+// SnapshotPath returns the file holding the named snapshot.
+func (r *Repository) SnapshotPath(name string) string {
+	return filepath.Join(r.dir, "snapshots", name+".json")
+}
+
+// ⭐ CDC-001: Chunk storage - 🔧
+// PutChunk stores data unless a chunk with the same content exists. It returns
+// the chunk id and the number of bytes written, which is zero for a chunk
+// that was already stored.
+func (r *Repository) PutChunk(data []byte) (string, int64, error) {
+	sum := sha256.Sum256(data)
+	id := hex.EncodeToString(sum[:])
+	path := r.chunkPath(id)
+	if _, err := os.Stat(path); err == nil {
+		return id, 0, nil
+	}
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	if _, err := zw.Write(data); err != nil {
+		return "", 0, err
+	}
+	if err := zw.Close(); err != nil {
+		return "", 0, err
+	}
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", 0, err
+	}
+	if err := fileops.AtomicWriteFile(path, buf.Bytes(), 0o444); err != nil {
+		return "", 0, fmt.Errorf("cannot store chunk %s: %w", id, err)
+	}
+	return id, int64(buf.Len()), nil
+}
+
+// ReadChunk returns the data of a chunk after checking it against its id.
+func (r *Repository) ReadChunk(id string) ([]byte, error) {
+	f, err := os.Open(r.chunkPath(id))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	zr, err := zlib.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %s: %w", id, err)
+	}
+	data, err := io.ReadAll(zr)
+	if err != nil {
+		return nil, fmt.Errorf("chunk %s: %w", id, err)
+	}
+	if sum := sha256.Sum256(data); hex.EncodeToString(sum[:]) != id {
+		return nil, fmt.Errorf("chunk %s: content does not match its id", id)
+	}
+	return data, nil
+}
+
+// ⭐ CDC-001: Snapshot creation - 🔧
+// Backup chunks the files, given relative to root, and records them as the
+// snapshot name. Symbolic links are stored as links. The snapshot file is
+// written last, once all its chunks are stored.
+func (r *Repository) Backup(name, root string, files []string) (*Snapshot, error) {
+	if err := validSnapshotName(name); err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(r.SnapshotPath(name)); err == nil {
+		return nil, fmt.Errorf("snapshot %s already exists", name)
+	}
+
+	snap := &Snapshot{Name: name, Created: time.Now(), Source: root}
+	for _, rel := range files {
+		entry, err := r.backupFile(root, rel, &snap.Stats)
+		if err != nil {
+			return nil, err
+		}
+		snap.Files = append(snap.Files, entry)
+		snap.Stats.Files++
+		snap.Stats.Bytes += entry.Size
+	}
+
+	data, err := json.MarshalIndent(snap, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := fileops.AtomicWriteFile(r.SnapshotPath(name), data, 0o644); err != nil {
+		return nil, fmt.Errorf("cannot write snapshot %s: %w", name, err)
+	}
+	return snap, nil
+}
+
+// backupFile stores one file and returns its entry.
+func (r *Repository) backupFile(root, rel string, stats *SnapshotStats) (FileEntry, error) {
+	path := filepath.Join(root, rel)
+	info, err := os.Lstat(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	entry := FileEntry{Path: filepath.ToSlash(rel), Mode: info.Mode(), ModTime: info.ModTime()}
+	if info.Mode()&os.ModeSymlink != 0 {
+		entry.Link, err = os.Readlink(path)
+		return entry, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return FileEntry{}, err
+	}
+	defer f.Close()
+	chunker := NewChunker(f, r.params)
+	for {
+		chunk, err := chunker.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return FileEntry{}, fmt.Errorf("cannot read %s: %w", rel, err)
+		}
+		id, written, err := r.PutChunk(chunk)
+		if err != nil {
+			return FileEntry{}, err
+		}
+		entry.Chunks = append(entry.Chunks, id)
+		entry.Size += int64(len(chunk))
+		stats.Chunks++
+		if written > 0 {
+			stats.NewChunks++
+			stats.NewBytes += written
+		}
+	}
+	return entry, nil
+}
+
+// validSnapshotName rejects names that are not a single file name.
+func validSnapshotName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return fmt.Errorf("invalid snapshot name %q", name)
+	}
+	return nil
+}
+
+// LoadSnapshot reads the named snapshot.
+func (r *Repository) LoadSnapshot(name string) (*Snapshot, error) {
+	if err := validSnapshotName(name); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(r.SnapshotPath(name))
+	if err != nil {
+		return nil, err
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("snapshot %s: %w", name, err)
+	}
+	return &snap, nil
+}
+
+// Snapshots returns all snapshots, oldest first.
+func (r *Repository) Snapshots() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(filepath.Join(r.dir, "snapshots"))
+	if err != nil {
+		return nil, err
+	}
+	var snaps []*Snapshot
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok || e.IsDir() {
+			continue
+		}
+		snap, err := r.LoadSnapshot(name)
+		if err != nil {
+			return nil, err
+		}
+		snaps = append(snaps, snap)
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Created.Before(snaps[j].Created) })
+	return snaps, nil
+}
+
+// ⭐ CDC-001: Snapshot verification - 🛡️
+// Verify checks that every chunk of the snapshot is stored. With readData
+// set each chunk is also decompressed and checked against its id, and file
+// sizes are compared with the data of their chunks. All problems are returned.
+func (r *Repository) Verify(snap *Snapshot, readData bool) []error {
+	var problems []error
+	checked := make(map[string]int64)
+	for _, f := range snap.Files {
+		var size int64
+		for _, id := range f.Chunks {
+			n, seen := checked[id]
+			if !seen {
+				n = -1
+				if readData {
+					if data, err := r.ReadChunk(id); err != nil {
+						problems = append(problems, fmt.Errorf("%s: %w", f.Path, err))
+					} else {
+						n = int64(len(data))
+					}
+				} else if _, err := os.Stat(r.chunkPath(id)); err != nil {
+					problems = append(problems, fmt.Errorf("%s: chunk %s is missing", f.Path, id))
+				}
+				checked[id] = n
+			}
+			size += n
+		}
+		if readData && size != f.Size && !hasUnreadable(f.Chunks, checked) {
+			problems = append(problems, fmt.Errorf("%s: chunks hold %d bytes, expected %d", f.Path, size, f.Size))
+		}
+	}
+	return problems
+}
+
+// hasUnreadable reports whether any of the chunks could not be read.
+func hasUnreadable(ids []string, checked map[string]int64) bool {
+	for _, id := range ids {
+		if checked[id] < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteFile writes the content of a snapshot file to w.
+func (r *Repository) WriteFile(w io.Writer, f FileEntry) error {
+	for _, id := range f.Chunks {
+		data, err := r.ReadChunk(id)
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
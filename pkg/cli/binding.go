@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// ⭐ CLI-FLAGS-001: Per-command flag binding - 🔧
+
+// FlagBinding ties the flags of one command to the fields of an options
+// struct owned by that command. Commands that declare flags with the same
+// name, such as --note, keep their values apart instead of writing to a
+// shared package variable.
+type FlagBinding[T any] struct {
+	flags     *pflag.FlagSet
+	defaults  T
+	opts      *T
+	inherited []func(cmd *cobra.Command, opts *T) error
+}
+
+// BindFlags returns a binding that registers local flags of cmd, starting
+// from the values in defaults.
+func BindFlags[T any](cmd *cobra.Command, defaults T) *FlagBinding[T] {
+	return newFlagBinding(cmd.Flags(), defaults)
+}
+
+// BindPersistentFlags returns a binding that registers persistent flags of
+// cmd, which its subcommands inherit.
+func BindPersistentFlags[T any](cmd *cobra.Command, defaults T) *FlagBinding[T] {
+	return newFlagBinding(cmd.PersistentFlags(), defaults)
+}
+
+func newFlagBinding[T any](flags *pflag.FlagSet, defaults T) *FlagBinding[T] {
+	opts := defaults
+	return &FlagBinding[T]{flags: flags, defaults: defaults, opts: &opts}
+}
+
+// Bool registers a boolean flag stored in the field selected by field.
+func (b *FlagBinding[T]) Bool(field func(*T) *bool, name, shorthand, usage string) *FlagBinding[T] {
+	p := field(b.opts)
+	b.flags.BoolVarP(p, name, shorthand, *p, usage)
+	return b
+}
+
+// String registers a string flag stored in the field selected by field.
+func (b *FlagBinding[T]) String(field func(*T) *string, name, shorthand, usage string) *FlagBinding[T] {
+	p := field(b.opts)
+	b.flags.StringVarP(p, name, shorthand, *p, usage)
+	return b
+}
+
+// StringArray registers a repeatable string flag stored in the field
+// selected by field.
+func (b *FlagBinding[T]) StringArray(field func(*T) *[]string, name, shorthand, usage string) *FlagBinding[T] {
+	b.flags.VarP(&stringArrayValue{value: field(b.opts)}, name, shorthand, usage)
+	return b
+}
+
+// stringArrayValue is pflag's stringArray with a resettable first-use
+// state: pflag's own value keeps appending to the values of a previous run
+// once it has been set, whatever Flag.Changed says.
+type stringArrayValue struct {
+	value   *[]string
+	changed bool
+}
+
+func (s *stringArrayValue) Set(val string) error {
+	if !s.changed {
+		*s.value = []string{val}
+		s.changed = true
+	} else {
+		*s.value = append(*s.value, val)
+	}
+	return nil
+}
+
+func (s *stringArrayValue) Append(val string) error {
+	*s.value = append(*s.value, val)
+	return nil
+}
+
+func (s *stringArrayValue) Replace(val []string) error {
+	*s.value = append([]string(nil), val...)
+	return nil
+}
+
+func (s *stringArrayValue) GetSlice() []string {
+	return append([]string{}, *s.value...)
+}
+
+func (s *stringArrayValue) Type() string { return "stringArray" }
+
+// String renders the values like pflag does, so GetStringArray reads them.
+func (s *stringArrayValue) String() string {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	_ = w.Write(*s.value)
+	w.Flush()
+	return "[" + strings.TrimSuffix(buf.String(), "\n") + "]"
+}
+
+func (s *stringArrayValue) reset() { s.changed = false }
+
+// Int registers an integer flag stored in the field selected by field.
+func (b *FlagBinding[T]) Int(field func(*T) *int, name, shorthand, usage string) *FlagBinding[T] {
+	p := field(b.opts)
+	b.flags.IntVarP(p, name, shorthand, *p, usage)
+	return b
+}
+
+// Duration registers a duration flag stored in the field selected by field.
+func (b *FlagBinding[T]) Duration(field func(*T) *time.Duration, name, shorthand, usage string) *FlagBinding[T] {
+	p := field(b.opts)
+	b.flags.DurationVarP(p, name, shorthand, *p, usage)
+	return b
+}
+
+// InheritBool copies the boolean flag name, declared as a persistent flag
+// by a parent command, into the field selected by field when Options is
+// called. It lets a subcommand read --dry-run without a package variable.
+func (b *FlagBinding[T]) InheritBool(field func(*T) *bool, name string) *FlagBinding[T] {
+	b.inherited = append(b.inherited, func(cmd *cobra.Command, opts *T) error {
+		if cmd.Flags().Lookup(name) == nil {
+			return nil
+		}
+		v, err := cmd.Flags().GetBool(name)
+		if err != nil {
+			return fmt.Errorf("flag --%s: %w", name, err)
+		}
+		*field(opts) = v
+		return nil
+	})
+	return b
+}
+
+// Options returns a copy of the options parsed for the current invocation
+// of cmd, including the inherited flags. Changing the copy does not affect
+// the binding.
+func (b *FlagBinding[T]) Options(cmd *cobra.Command) (T, error) {
+	opts := *b.opts
+	for _, inherit := range b.inherited {
+		if err := inherit(cmd, &opts); err != nil {
+			return opts, err
+		}
+	}
+	return opts, nil
+}
+
+// Reset restores the defaults and clears the changed state of the bound
+// flags, so the command can be executed again without keeping the values
+// of its previous run.
+func (b *FlagBinding[T]) Reset() {
+	*b.opts = b.defaults
+	b.flags.VisitAll(func(f *pflag.Flag) {
+		f.Changed = false
+		if v, ok := f.Value.(*stringArrayValue); ok {
+			v.reset()
+		}
+	})
+}
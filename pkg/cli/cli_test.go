@@ -196,6 +196,80 @@ func TestFlagManager(t *testing.T) {
 	}
 }
 
+// ⭐ CLI-FLAGS-001: Per-command flag binding - 🧪
+func TestFlagBinding(t *testing.T) {
+	type rootOpts struct{ DryRun bool }
+	type noteOpts struct {
+		Note    string
+		DryRun  bool
+		Limit   int
+		Timeout time.Duration
+		Files   []string
+	}
+
+	root := &cobra.Command{Use: "root"}
+	BindPersistentFlags(root, rootOpts{}).
+		Bool(func(o *rootOpts) *bool { return &o.DryRun }, "dry-run", "d", "Dry run")
+
+	ran := map[string]noteOpts{}
+	bindings := map[string]*FlagBinding[noteOpts]{}
+	for _, name := range []string{"full", "backup"} {
+		name := name
+		cmd := &cobra.Command{Use: name, Run: func(cmd *cobra.Command, _ []string) {
+			opts, err := bindings[name].Options(cmd)
+			if err != nil {
+				t.Fatal(err)
+			}
+			ran[name] = opts
+		}}
+		bindings[name] = BindFlags(cmd, noteOpts{Limit: 10, Files: []string{"default"}}).
+			InheritBool(func(o *noteOpts) *bool { return &o.DryRun }, "dry-run").
+			String(func(o *noteOpts) *string { return &o.Note }, "note", "n", "Note").
+			Int(func(o *noteOpts) *int { return &o.Limit }, "limit", "", "Limit").
+			Duration(func(o *noteOpts) *time.Duration { return &o.Timeout }, "timeout", "", "Timeout").
+			StringArray(func(o *noteOpts) *[]string { return &o.Files }, "file", "", "File")
+		root.AddCommand(cmd)
+	}
+
+	root.SetArgs([]string{"full", "-d", "-n", "first", "--limit", "3", "--timeout", "2s", "--file", "a", "--file", "b"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	got := ran["full"]
+	if !got.DryRun || got.Note != "first" || got.Limit != 3 || got.Timeout != 2*time.Second || len(got.Files) != 2 {
+		t.Errorf("Unexpected options for full: %+v", got)
+	}
+
+	root.SetArgs([]string{"backup"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ran["backup"]; got.Note != "" || got.Limit != 10 {
+		t.Errorf("backup shares the flags of full: %+v", got)
+	}
+
+	bindings["full"].Reset()
+	if opts, _ := bindings["full"].Options(root); opts.Note != "" || opts.Limit != 10 || len(opts.Files) != 1 || opts.Files[0] != "default" {
+		t.Errorf("Reset did not restore the defaults: %+v", opts)
+	}
+	full, _, _ := root.Find([]string{"full"})
+	if full.Flags().Changed("note") {
+		t.Error("Reset did not clear the changed state of --note")
+	}
+
+	// A repeatable flag starts over instead of appending to the last run
+	root.SetArgs([]string{"full", "--file", "c"})
+	if err := root.Execute(); err != nil {
+		t.Fatal(err)
+	}
+	if got := ran["full"].Files; len(got) != 1 || got[0] != "c" {
+		t.Errorf("--file after Reset = %v, want [c]", got)
+	}
+	if files, err := full.Flags().GetStringArray("file"); err != nil || len(files) != 1 || files[0] != "c" {
+		t.Errorf("GetStringArray = %v, %v", files, err)
+	}
+}
+
 func TestCommandBuilder(t *testing.T) {
 	fm := NewFlagManager()
 	cb := NewCommandBuilder(fm)
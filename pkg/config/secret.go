@@ -0,0 +1,125 @@
+// Package config provides secret configuration values.
+//
+// This file contains the Secret type used for credentials in configuration
+// files. Secrets are redacted wherever configuration is displayed and are
+// resolved from literals, environment variables or secret store references.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+)
+
+// Prefixes of secret references.
+const (
+	SecretEnvPrefix = "env:"       // env:NAME reads environment variable NAME
+	SecretRefPrefix = "secretref:" // secretref:STORE/ITEM asks a registered secret store
+)
+
+// SecretRedacted replaces literal secret values in output.
+const SecretRedacted = "********"
+
+// ⭐ SECRET-001: Secret configuration values - 📝
+// Secret is a configuration string holding a credential. It is either the
+// literal value or a reference: "env:NAME" or "secretref:STORE/ITEM".
+// Formatting a Secret or encoding it as JSON shows references as written
+// and literal values as SecretRedacted; use Resolve to obtain the value.
+type Secret string
+
+// IsReference reports whether s refers to a value kept elsewhere.
+func (s Secret) IsReference() bool {
+	return strings.HasPrefix(string(s), SecretEnvPrefix) || strings.HasPrefix(string(s), SecretRefPrefix)
+}
+
+// Redacted returns s with a literal value replaced by SecretRedacted.
+// Empty secrets and references are returned unchanged.
+func (s Secret) Redacted() string {
+	if s == "" || s.IsReference() {
+		return string(s)
+	}
+	return SecretRedacted
+}
+
+// String implements fmt.Stringer with the redacted form.
+func (s Secret) String() string {
+	return s.Redacted()
+}
+
+// GoString implements fmt.GoStringer with the redacted form.
+func (s Secret) GoString() string {
+	return fmt.Sprintf("config.Secret(%q)", s.Redacted())
+}
+
+// MarshalJSON encodes the redacted form.
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.Redacted())
+}
+
+// ⭐ SECRET-001: Secret resolution - 🔧
+// Resolve returns the value of s. Literal values are returned as they are,
+// "env:NAME" requires NAME to be set and "secretref:STORE/ITEM" is looked up
+// with the resolver registered for STORE.
+func (s Secret) Resolve() (string, error) {
+	value := string(s)
+	switch {
+	case strings.HasPrefix(value, SecretEnvPrefix):
+		name := strings.TrimPrefix(value, SecretEnvPrefix)
+		if name == "" {
+			return "", fmt.Errorf("secret reference %q names no environment variable", value)
+		}
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+	case strings.HasPrefix(value, SecretRefPrefix):
+		store, item, ok := strings.Cut(strings.TrimPrefix(value, SecretRefPrefix), "/")
+		if !ok || store == "" || item == "" {
+			return "", fmt.Errorf("secret reference %q must look like secretref:STORE/ITEM", value)
+		}
+		resolver := lookupSecretResolver(store)
+		if resolver == nil {
+			return "", fmt.Errorf("secret reference %q: no secret store %q is available", value, store)
+		}
+		v, err := resolver(item)
+		if err != nil {
+			return "", fmt.Errorf("secret reference %q: %w", value, err)
+		}
+		return v, nil
+	}
+	return value, nil
+}
+
+// SecretResolver returns the secret stored under item.
+type SecretResolver func(item string) (string, error)
+
+var (
+	secretResolversMu sync.RWMutex
+	secretResolvers   = make(map[string]SecretResolver)
+)
+
+// ⭐ SECRET-001: Secret store registration - 🔧
+// RegisterSecretResolver makes store available to "secretref:STORE/ITEM"
+// references. Registering a nil resolver removes the store.
+func RegisterSecretResolver(store string, resolver SecretResolver) {
+	secretResolversMu.Lock()
+	defer secretResolversMu.Unlock()
+	if resolver == nil {
+		delete(secretResolvers, store)
+		return
+	}
+	secretResolvers[store] = resolver
+}
+
+// lookupSecretResolver returns the resolver registered for store, if any.
+func lookupSecretResolver(store string) SecretResolver {
+	secretResolversMu.RLock()
+	defer secretResolversMu.RUnlock()
+	return secretResolvers[store]
+}
@@ -0,0 +1,84 @@
+// Package config provides schema-agnostic configuration management for CLI applications.
+//
+// This test file verifies redaction and resolution of secret values.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// ⭐ SECRET-001: Secret redaction - 🧪
+func TestSecretRedaction(t *testing.T) {
+	tests := []struct {
+		secret Secret
+		want   string
+	}{
+		{"", ""},
+		{"hunter2", SecretRedacted},
+		{"env:DB_PASSWORD", "env:DB_PASSWORD"},
+		{"secretref:keychain/bkpdir", "secretref:keychain/bkpdir"},
+	}
+	for _, tt := range tests {
+		if got := fmt.Sprintf("%v|%s", tt.secret, tt.secret); got != tt.want+"|"+tt.want {
+			t.Errorf("Formatting %q gave %q", string(tt.secret), got)
+		}
+		data, err := json.Marshal(struct{ S Secret }{tt.secret})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if want, _ := json.Marshal(struct{ S string }{tt.want}); string(data) != string(want) {
+			t.Errorf("JSON of %q is %s, want %s", string(tt.secret), data, want)
+		}
+	}
+
+	nested := fmt.Sprintf("%v %+v %#v", map[string]Secret{"a": "hunter2"}, struct{ DSN Secret }{"hunter2"}, Secret("hunter2"))
+	if strings.Contains(nested, "hunter2") {
+		t.Errorf("Nested secret leaked: %s", nested)
+	}
+}
+
+// ⭐ SECRET-001: Secret resolution - 🧪
+func TestSecretResolve(t *testing.T) {
+	t.Setenv("BKPDIR_TEST_SECRET", "from-env")
+	RegisterSecretResolver("test", func(item string) (string, error) {
+		if item == "missing" {
+			return "", errors.New("not found")
+		}
+		return "stored-" + item, nil
+	})
+	defer RegisterSecretResolver("test", nil)
+
+	tests := []struct {
+		secret  Secret
+		want    string
+		wantErr string
+	}{
+		{"literal", "literal", ""},
+		{"env:BKPDIR_TEST_SECRET", "from-env", ""},
+		{"env:BKPDIR_TEST_SECRET_UNSET", "", "is not set"},
+		{"env:", "", "names no environment variable"},
+		{"secretref:test/db", "stored-db", ""},
+		{"secretref:test/missing", "", "not found"},
+		{"secretref:other/db", "", `no secret store "other"`},
+		{"secretref:test", "", "secretref:STORE/ITEM"},
+	}
+	for _, tt := range tests {
+		got, err := tt.secret.Resolve()
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Resolve(%q) error = %v, want %q", string(tt.secret), err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("Resolve(%q) = %q, %v; want %q", string(tt.secret), got, err, tt.want)
+		}
+	}
+}
@@ -277,3 +277,56 @@ func (m *mockErrorFormatter) PrintPermissionError(err error) {
 func (m *mockErrorFormatter) PrintDirectoryNotFound(err error) {
 	m.lastMessage = "Directory not found: " + err.Error()
 }
+
+// placeholderFormatter expands %{name} placeholders for the grouped display tests
+type placeholderFormatter struct{}
+
+func (placeholderFormatter) FormatWithPlaceholders(format string, data map[string]string) string {
+	for key, value := range data {
+		format = strings.ReplaceAll(format, "%{"+key+"}", value)
+	}
+	return format
+}
+
+// ⭐ ERR-AGG-001: Error aggregation testing - 🧪 MultiError membership and display
+func TestMultiError(t *testing.T) {
+	list := NewMultiError("verify")
+	if list.ErrorOrNil() != nil {
+		t.Error("Expected nil for an empty list")
+	}
+
+	list.Add("a.zip", os.ErrNotExist)
+	list.Add("skipped.zip", nil)
+	list.Add("b.zip", NewApplicationError("checksum mismatch", 1))
+	list.Add("c.zip", os.ErrPermission)
+	if list.Len() != 3 {
+		t.Fatalf("Expected 3 errors, got %d", list.Len())
+	}
+
+	err := list.ErrorOrNil()
+	if !errors.Is(err, os.ErrNotExist) || !errors.Is(err, os.ErrPermission) {
+		t.Error("Expected errors.Is to match members")
+	}
+	var appErr *ApplicationError
+	if !errors.As(err, &appErr) || appErr.GetStatusCode() != 1 {
+		t.Error("Expected errors.As to find the ApplicationError member")
+	}
+	if errors.Is(err, context.Canceled) {
+		t.Error("Unexpected match for an error that was not added")
+	}
+
+	want := "verify: 3 errors: a.zip: file does not exist; b.zip: checksum mismatch; c.zip: permission denied"
+	if got := list.Error(); got != want {
+		t.Errorf("Error() = %q, want %q", got, want)
+	}
+
+	wantGrouped := "filesystem (1):\n  a.zip: file does not exist\n" +
+		"permission (1):\n  c.zip: permission denied\n" +
+		"other (1):\n  b.zip: checksum mismatch\n"
+	if got := list.Format(placeholderFormatter{}, "", ""); got != wantGrouped {
+		t.Errorf("Format() = %q, want %q", got, wantGrouped)
+	}
+	if got := list.Format(placeholderFormatter{}, "", "[%{category}] %{context}\n"); !strings.Contains(got, "[permission] c.zip\n") {
+		t.Errorf("Expected custom entry template to be used, got %q", got)
+	}
+}
@@ -157,6 +157,26 @@ const (
 	ErrorCategoryValidation
 )
 
+// String returns a display name for the category, used when grouping errors.
+func (c ErrorCategory) String() string {
+	switch c {
+	case ErrorCategoryFilesystem:
+		return "filesystem"
+	case ErrorCategoryPermission:
+		return "permission"
+	case ErrorCategoryDiskSpace:
+		return "disk space"
+	case ErrorCategoryNetwork:
+		return "network"
+	case ErrorCategoryConfiguration:
+		return "configuration"
+	case ErrorCategoryValidation:
+		return "validation"
+	default:
+		return "other"
+	}
+}
+
 // ErrorSeverity represents the severity level of errors
 type ErrorSeverity int
 
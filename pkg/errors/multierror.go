@@ -0,0 +1,164 @@
+// Error aggregation for operations that continue past individual failures,
+// such as archiving many files or verifying many archives. A MultiError keeps
+// each failure with the file or archive it belongs to and can render them
+// grouped by category through placeholder templates.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package errors
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ⭐ ERR-AGG-001: Error aggregation - 🔧 Default grouped display templates
+const (
+	// DefaultGroupTemplate introduces each category; placeholders: %{category}, %{count}
+	DefaultGroupTemplate = "%{category} (%{count}):\n"
+	// DefaultEntryTemplate renders one member; placeholders: %{context}, %{error}, %{category}
+	DefaultEntryTemplate = "  %{context}: %{error}\n"
+)
+
+// PlaceholderFormatter expands %{name} placeholders, as implemented by the
+// template formatters of pkg/formatter and the BkpDir application.
+type PlaceholderFormatter interface {
+	FormatWithPlaceholders(format string, data map[string]string) string
+}
+
+// ⭐ ERR-AGG-001: Error aggregation - 📝 Member error with context
+// ContextError is a member of a MultiError: an error and the file, archive or
+// other item it occurred for.
+type ContextError struct {
+	Context string
+	Err     error
+}
+
+func (e *ContextError) Error() string {
+	if e.Context == "" {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("%s: %v", e.Context, e.Err)
+}
+
+// Unwrap returns the member's error.
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// ⭐ ERR-AGG-001: Error aggregation - 🔧 Error list type
+// MultiError collects errors from an operation that keeps going after
+// failures. errors.Is and errors.As match against every member.
+type MultiError struct {
+	Operation  string          // Operation the errors belong to, used in Error()
+	Errors     []*ContextError // Members in the order they were added
+	Classifier ErrorClassifier // Groups members for display; nil uses DefaultErrorClassifier
+}
+
+// NewMultiError creates an empty error list for operation.
+func NewMultiError(operation string) *MultiError {
+	return &MultiError{Operation: operation}
+}
+
+// Add records err for context. Nil errors are ignored.
+func (m *MultiError) Add(context string, err error) {
+	if err == nil {
+		return
+	}
+	m.Errors = append(m.Errors, &ContextError{Context: context, Err: err})
+}
+
+// Len returns the number of collected errors.
+func (m *MultiError) Len() int {
+	return len(m.Errors)
+}
+
+// ErrorOrNil returns m if it holds errors and nil otherwise, so a MultiError
+// can be returned directly as an error.
+func (m *MultiError) ErrorOrNil() error {
+	if m == nil || len(m.Errors) == 0 {
+		return nil
+	}
+	return m
+}
+
+// Error summarizes the members on one line.
+func (m *MultiError) Error() string {
+	parts := make([]string, len(m.Errors))
+	for i, e := range m.Errors {
+		parts[i] = e.Error()
+	}
+	noun := "errors"
+	if len(m.Errors) == 1 {
+		noun = "error"
+	}
+	summary := fmt.Sprintf("%d %s: %s", len(m.Errors), noun, strings.Join(parts, "; "))
+	if m.Operation != "" {
+		return m.Operation + ": " + summary
+	}
+	return summary
+}
+
+// Unwrap exposes the members to errors.Is and errors.As.
+func (m *MultiError) Unwrap() []error {
+	errs := make([]error, len(m.Errors))
+	for i, e := range m.Errors {
+		errs[i] = e
+	}
+	return errs
+}
+
+// ⭐ ERR-AGG-001: Error aggregation - 📝 Grouped display
+// Format renders the members grouped by category. Each group starts with
+// groupTemplate and lists its members with entryTemplate; empty templates use
+// DefaultGroupTemplate and DefaultEntryTemplate. Groups are ordered by
+// category with unclassified errors last; members keep the order they were
+// added in.
+func (m *MultiError) Format(f PlaceholderFormatter, groupTemplate, entryTemplate string) string {
+	if groupTemplate == "" {
+		groupTemplate = DefaultGroupTemplate
+	}
+	if entryTemplate == "" {
+		entryTemplate = DefaultEntryTemplate
+	}
+	classifier := m.Classifier
+	if classifier == nil {
+		classifier = NewDefaultErrorClassifier()
+	}
+
+	groups := make(map[ErrorCategory][]*ContextError)
+	for _, e := range m.Errors {
+		category := classifier.ClassifyError(e.Err)
+		groups[category] = append(groups[category], e)
+	}
+	categories := make([]ErrorCategory, 0, len(groups))
+	for category := range groups {
+		categories = append(categories, category)
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		// Unclassified errors go last
+		if (categories[i] == ErrorCategoryUnknown) != (categories[j] == ErrorCategoryUnknown) {
+			return categories[j] == ErrorCategoryUnknown
+		}
+		return categories[i] < categories[j]
+	})
+
+	var b strings.Builder
+	for _, category := range categories {
+		members := groups[category]
+		b.WriteString(f.FormatWithPlaceholders(groupTemplate, map[string]string{
+			"category": category.String(),
+			"count":    strconv.Itoa(len(members)),
+		}))
+		for _, e := range members {
+			b.WriteString(f.FormatWithPlaceholders(entryTemplate, map[string]string{
+				"context":  e.Context,
+				"error":    e.Err.Error(),
+				"category": category.String(),
+			}))
+		}
+	}
+	return b.String()
+}
@@ -4,14 +4,35 @@
 package fileops
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 )
 
 // ⭐ EXTRACT-006: Atomic file operations extracted - 🔧
 
+// 🔶 FILE-004: Durability policy for atomic writes - 🔧
+// SyncPolicy controls which fsync calls an atomic write performs before it
+// reports success.
+type SyncPolicy int
+
+const (
+	// SyncNone relies on the operating system to flush data eventually.
+	SyncNone SyncPolicy = iota
+	// SyncFile flushes the temporary file before it is renamed into place.
+	SyncFile
+	// SyncFileAndDir also flushes the parent directory so the rename itself
+	// survives a crash.
+	SyncFileAndDir
+)
+
+// DefaultSyncPolicy is the policy used by AtomicWriteFile and CopyFileAtomic.
+const DefaultSyncPolicy = SyncFileAndDir
+
 // AtomicWriter provides atomic file writing capabilities
 type AtomicWriter struct {
 	targetPath  string
@@ -19,6 +40,7 @@ type AtomicWriter struct {
 	tempFile    *os.File
 	isCommitted bool
 	isClosed    bool
+	syncPolicy  SyncPolicy
 }
 
 // AtomicOp defines the interface for atomic file operations
@@ -39,6 +61,11 @@ func NewAtomicWriter(targetPath string) (*AtomicWriter, error) {
 		return nil, fmt.Errorf("invalid target path: %v", err)
 	}
 
+	// ⭐ GUARD-001: Honor read-only mode
+	if err := CheckWrite(targetPath); err != nil {
+		return nil, err
+	}
+
 	// Create temporary file in the same directory as target
 	dir := filepath.Dir(targetPath)
 	base := filepath.Base(targetPath)
@@ -62,6 +89,16 @@ func NewAtomicWriter(targetPath string) (*AtomicWriter, error) {
 	}, nil
 }
 
+// SetSyncPolicy sets the fsync policy applied by Commit.
+func (aw *AtomicWriter) SetSyncPolicy(policy SyncPolicy) {
+	aw.syncPolicy = policy
+}
+
+// TempPath returns the path of the temporary file backing the writer.
+func (aw *AtomicWriter) TempPath() string {
+	return aw.tempPath
+}
+
 // Write writes data to the temporary file
 func (aw *AtomicWriter) Write(data []byte) (int, error) {
 	// ⭐ EXTRACT-006: Atomic write operation - 🔧
@@ -91,8 +128,14 @@ func (aw *AtomicWriter) Commit() error {
 		return fmt.Errorf("writer is closed but not properly cleaned up")
 	}
 
-	// Close the temporary file first
+	// Close the temporary file first, flushing it if the policy requires
 	if aw.tempFile != nil {
+		if aw.syncPolicy >= SyncFile {
+			if err := aw.tempFile.Sync(); err != nil {
+				aw.cleanup()
+				return fmt.Errorf("cannot sync temporary file: %v", err)
+			}
+		}
 		if err := aw.tempFile.Close(); err != nil {
 			aw.cleanup()
 			return fmt.Errorf("cannot close temporary file: %v", err)
@@ -106,6 +149,12 @@ func (aw *AtomicWriter) Commit() error {
 		return fmt.Errorf("cannot commit file: %v", err)
 	}
 
+	if aw.syncPolicy >= SyncFileAndDir {
+		if err := SyncDir(filepath.Dir(aw.targetPath)); err != nil {
+			return fmt.Errorf("cannot sync directory: %v", err)
+		}
+	}
+
 	aw.isCommitted = true
 	aw.isClosed = true
 	return nil
@@ -207,15 +256,22 @@ func AtomicCopy(src, dst string) error {
 	return nil
 }
 
-// AtomicWriteFile writes data to a file atomically
+// AtomicWriteFile writes data to a file atomically using DefaultSyncPolicy
 func AtomicWriteFile(filename string, data []byte, perm os.FileMode) error {
 	// ⭐ EXTRACT-006: Atomic file write operation - 🔧
+	return AtomicWriteFileWithPolicy(filename, data, perm, DefaultSyncPolicy)
+}
 
+// 🔶 FILE-004: Atomic write with explicit durability policy - 🔧
+// AtomicWriteFileWithPolicy writes data to a file atomically, applying the
+// given fsync policy before reporting success.
+func AtomicWriteFileWithPolicy(filename string, data []byte, perm os.FileMode, policy SyncPolicy) error {
 	writer, err := NewAtomicWriter(filename)
 	if err != nil {
 		return err
 	}
 	defer writer.Close()
+	writer.SetSyncPolicy(policy)
 
 	if _, err := writer.Write(data); err != nil {
 		return err
@@ -234,3 +290,163 @@ func AtomicWriteString(filename, data string, perm os.FileMode) error {
 	// ⭐ EXTRACT-006: Atomic string write to file - 🔧
 	return AtomicWriteFile(filename, []byte(data), perm)
 }
+
+// 🔶 FILE-004: Directory fsync - 🔧
+// SyncDir flushes a directory so that renames and creations inside it are
+// durable. Platforms that cannot open directories for syncing are ignored.
+func SyncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+// 🔶 FILE-004: Finalize an already written temporary file - 🔧
+// CommitFile renames a completed temporary file onto target and applies the
+// fsync policy. It is intended for callers that stream output to their own
+// temporary file, such as archive writers.
+func CommitFile(tempPath, target string, policy SyncPolicy) error {
+	// ⭐ GUARD-001: Honor read-only mode
+	if err := CheckWrite(target); err != nil {
+		return err
+	}
+	if policy >= SyncFile {
+		f, err := os.OpenFile(tempPath, os.O_RDWR, 0)
+		if err != nil {
+			return fmt.Errorf("cannot open temporary file: %v", err)
+		}
+		syncErr := f.Sync()
+		f.Close()
+		if syncErr != nil {
+			return fmt.Errorf("cannot sync temporary file: %v", syncErr)
+		}
+	}
+
+	if err := os.Rename(tempPath, target); err != nil {
+		return fmt.Errorf("cannot commit file: %v", err)
+	}
+
+	if policy >= SyncFileAndDir {
+		if err := SyncDir(filepath.Dir(target)); err != nil {
+			return fmt.Errorf("cannot sync directory: %v", err)
+		}
+	}
+	return nil
+}
+
+// ⭐ ARCH-008: Collision-free name reservation - 🛡️
+// ReservePath claims target for a writer that will produce it through
+// tempPath and finalize it with CommitFile. It creates tempPath exclusively
+// and reports false, leaving nothing behind, when either file already exists.
+// Because tempPath only disappears once target exists, two processes can
+// never both reserve the same target.
+func ReservePath(target, tempPath string) (bool, error) {
+	if err := CheckWrite(tempPath); err != nil {
+		return false, err
+	}
+	if _, err := os.Lstat(target); err == nil {
+		return false, nil
+	}
+	f, err := os.OpenFile(tempPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("cannot reserve %s: %v", target, err)
+	}
+	f.Close()
+	// target may have been committed between the first check and the create
+	if _, err := os.Lstat(target); err == nil {
+		os.Remove(tempPath)
+		return false, nil
+	}
+	return true, nil
+}
+
+// 🔶 FILE-004: Atomic copy with checksum validation - 🔧
+// CopyFileAtomic copies src to dst through a temporary file, re-reads the
+// temporary copy and compares its SHA-256 with the source before renaming it
+// into place. It returns the hex-encoded checksum of the copied content.
+func CopyFileAtomic(src, dst string) (string, error) {
+	srcFile, err := os.Open(src)
+	if err != nil {
+		return "", fmt.Errorf("cannot open source file: %v", err)
+	}
+	defer srcFile.Close()
+
+	srcInfo, err := srcFile.Stat()
+	if err != nil {
+		return "", fmt.Errorf("cannot get source file info: %v", err)
+	}
+
+	writer, err := NewAtomicWriter(dst)
+	if err != nil {
+		return "", fmt.Errorf("cannot create atomic writer: %v", err)
+	}
+	defer writer.Close()
+	writer.SetSyncPolicy(DefaultSyncPolicy)
+
+	srcHash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(writer, srcHash), srcFile); err != nil {
+		return "", fmt.Errorf("copy failed: %v", err)
+	}
+	if err := writer.tempFile.Sync(); err != nil {
+		return "", fmt.Errorf("cannot sync temporary file: %v", err)
+	}
+
+	expected := hex.EncodeToString(srcHash.Sum(nil))
+	actual, err := fileChecksum(writer.tempPath)
+	if err != nil {
+		return "", fmt.Errorf("cannot checksum copy: %v", err)
+	}
+	if actual != expected {
+		return "", fmt.Errorf("checksum mismatch copying %s: source %s, copy %s", src, expected, actual)
+	}
+
+	if err := os.Chmod(writer.tempPath, srcInfo.Mode().Perm()); err != nil {
+		return "", fmt.Errorf("cannot set permissions: %v", err)
+	}
+
+	if err := writer.Commit(); err != nil {
+		return "", fmt.Errorf("cannot commit copy: %v", err)
+	}
+	return expected, nil
+}
+
+// 🔶 FILE-004: Backup before overwrite - 🔧
+// BackupBeforeOverwrite copies an existing file to path+suffix using
+// CopyFileAtomic so a later overwrite of path can be undone. It returns the
+// backup path, or an empty string if path does not exist yet.
+func BackupBeforeOverwrite(path, suffix string) (string, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+
+	backupPath := path + suffix
+	if _, err := CopyFileAtomic(path, backupPath); err != nil {
+		return "", fmt.Errorf("cannot back up %s: %v", path, err)
+	}
+	return backupPath, nil
+}
+
+// fileChecksum returns the hex-encoded SHA-256 of a file's contents.
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -34,8 +34,16 @@
 //   - AtomicWriter - Atomic file writing with temporary files
 //   - AtomicCopy() - Atomic file copying
 //   - AtomicWriteFile() - Atomic file creation
+//   - AtomicWriteFileWithPolicy(), CommitFile(), SyncDir() - fsync policy control
+//   - CopyFileAtomic() - Atomic copy with SHA-256 validation
+//   - BackupBeforeOverwrite() - Keep a copy of a file before replacing it
 //   - Automatic cleanup on errors or rollback
 //
+// Write Guard: Read-only mode enforcement
+//   - EnableWriteGuard()/DisableWriteGuard() - Restrict writes to allowed directories
+//   - CheckWrite() - Refuse paths outside them with ErrWriteGuarded
+//   - MkdirAll(), Create(), CreateTemp(), WriteFile(), Rename(), Remove(), RemoveAll(), Chmod() - Guarded os wrappers
+//
 // Traversal: Safe directory walking with exclusions
 //   - Walk() - Basic directory traversal
 //   - WalkWithOptions() - Configurable traversal with exclusions
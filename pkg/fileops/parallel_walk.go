@@ -0,0 +1,245 @@
+// Package fileops provides file operations and utilities for CLI applications.
+//
+// This file contains the parallel directory walker, which reads several
+// directories at the same time so large trees are scanned faster than with
+// filepath.WalkDir.
+package fileops
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sync"
+)
+
+// ⭐ WALK-001: Parallel directory walker - 🔧
+
+// ParallelWalkOptions configures ParallelWalk.
+type ParallelWalkOptions struct {
+	// Parallelism is the number of directories read at the same time; 0 or
+	// less uses twice the number of CPUs, as reading is mostly waiting.
+	Parallelism int
+	// Ordered calls the visitor in the lexical order of filepath.WalkDir.
+	// Otherwise the entries of a directory are visited as soon as it is read,
+	// which needs less memory on very wide trees.
+	Ordered bool
+	// Info reads the FileInfo of every entry in the reading goroutines, so
+	// DirEntry.Info costs nothing in the visitor. Use it when the visitor
+	// needs sizes or modification times.
+	Info bool
+}
+
+// dirListing is a directory to read and, once done is closed, its entries.
+type dirListing struct {
+	path    string
+	entry   fs.DirEntry
+	entries []fs.DirEntry
+	err     error
+	done    chan struct{}
+}
+
+// parallelWalker holds the state of one ParallelWalk.
+type parallelWalker struct {
+	ctx     context.Context
+	opts    ParallelWalkOptions
+	fn      fs.WalkDirFunc
+	results chan *dirListing // Read listings, only when not ordered
+	stop    chan struct{}
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	pending []*dirListing // Directories to read, the last one first
+	closed  bool
+}
+
+// ParallelWalk walks the file tree rooted at root like filepath.WalkDir,
+// calling fn for each file and directory including root, while reading up
+// to opts.Parallelism directories at the same time. fn is never called
+// concurrently and may return filepath.SkipDir or filepath.SkipAll as with
+// WalkDir; any other error stops the walk and is returned. A directory that
+// cannot be read is reported by a second call of fn with the error. The walk
+// stops with ctx.Err() when ctx is cancelled. Symbolic links are not
+// followed.
+func ParallelWalk(ctx context.Context, root string, opts ParallelWalkOptions, fn fs.WalkDirFunc) error {
+	if opts.Parallelism <= 0 {
+		opts.Parallelism = 2 * runtime.GOMAXPROCS(0)
+	}
+	info, err := os.Lstat(root)
+	if err != nil {
+		err = fn(root, nil, err)
+	} else {
+		err = fn(root, fs.FileInfoToDirEntry(info), nil)
+		if err == nil && info.IsDir() {
+			err = walkTree(ctx, root, fs.FileInfoToDirEntry(info), opts, fn)
+		}
+	}
+	if err == filepath.SkipDir || err == filepath.SkipAll {
+		return nil
+	}
+	return err
+}
+
+// walkTree starts the reading goroutines and walks below root.
+func walkTree(ctx context.Context, root string, entry fs.DirEntry, opts ParallelWalkOptions, fn fs.WalkDirFunc) error {
+	w := &parallelWalker{ctx: ctx, opts: opts, fn: fn, stop: make(chan struct{})}
+	w.cond = sync.NewCond(&w.mu)
+	if !opts.Ordered {
+		w.results = make(chan *dirListing, opts.Parallelism)
+	}
+	for i := 0; i < opts.Parallelism; i++ {
+		w.wg.Add(1)
+		go w.read()
+	}
+	defer w.shutdown()
+
+	first := w.schedule(root, entry)
+	if opts.Ordered {
+		return w.walkOrdered(first)
+	}
+	return w.walkUnordered()
+}
+
+// schedule queues dir to be read next.
+func (w *parallelWalker) schedule(path string, entry fs.DirEntry) *dirListing {
+	l := &dirListing{path: path, entry: entry, done: make(chan struct{})}
+	w.mu.Lock()
+	w.pending = append(w.pending, l)
+	w.mu.Unlock()
+	w.cond.Signal()
+	return l
+}
+
+// shutdown stops the reading goroutines and waits for them.
+func (w *parallelWalker) shutdown() {
+	close(w.stop)
+	w.mu.Lock()
+	w.closed = true
+	w.pending = nil
+	w.mu.Unlock()
+	w.cond.Broadcast()
+	w.wg.Wait()
+}
+
+// read is a reading goroutine: it reads queued directories until shutdown.
+// The most recently queued directory is read first, which is the one an
+// ordered walk needs next and keeps an unordered walk depth first.
+func (w *parallelWalker) read() {
+	defer w.wg.Done()
+	for {
+		w.mu.Lock()
+		for len(w.pending) == 0 && !w.closed {
+			w.cond.Wait()
+		}
+		if w.closed {
+			w.mu.Unlock()
+			return
+		}
+		l := w.pending[len(w.pending)-1]
+		w.pending = w.pending[:len(w.pending)-1]
+		w.mu.Unlock()
+
+		l.entries, l.err = os.ReadDir(l.path)
+		if w.opts.Info {
+			for i, e := range l.entries {
+				if info, err := e.Info(); err == nil {
+					l.entries[i] = fs.FileInfoToDirEntry(info)
+				}
+			}
+		}
+		close(l.done)
+		if w.results != nil {
+			select {
+			case w.results <- l:
+			case <-w.stop:
+				return
+			}
+		}
+	}
+}
+
+// wait returns once l is read or the walk is cancelled.
+func (w *parallelWalker) wait(l *dirListing) error {
+	select {
+	case <-l.done:
+		return nil
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	}
+}
+
+// visitListing reports a read error of l and calls fn for its entries,
+// passing the directories fn accepts to descend with their index in l.
+func (w *parallelWalker) visitListing(l *dirListing, descend func(i int, path string, entry fs.DirEntry) error) error {
+	if l.err != nil {
+		if err := w.fn(l.path, l.entry, l.err); err != nil {
+			if err == filepath.SkipDir {
+				return nil
+			}
+			return err
+		}
+	}
+	for i, entry := range l.entries {
+		if err := w.ctx.Err(); err != nil {
+			return err
+		}
+		path := filepath.Join(l.path, entry.Name())
+		err := w.fn(path, entry, nil)
+		if err == nil && entry.IsDir() {
+			err = descend(i, path, entry)
+		}
+		if err == filepath.SkipDir {
+			if entry.IsDir() {
+				continue
+			}
+			// Like WalkDir, SkipDir on a file skips the rest of its directory
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// walkOrdered walks the directory of l depth first in lexical order. The
+// subdirectories of each directory are queued before any is visited, so
+// they are read while the visitor works through their elder siblings.
+func (w *parallelWalker) walkOrdered(l *dirListing) error {
+	if err := w.wait(l); err != nil {
+		return err
+	}
+	subdirs := make([]*dirListing, len(l.entries))
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		if entry := l.entries[i]; entry.IsDir() {
+			subdirs[i] = w.schedule(filepath.Join(l.path, entry.Name()), entry)
+		}
+	}
+	return w.visitListing(l, func(i int, _ string, _ fs.DirEntry) error {
+		return w.walkOrdered(subdirs[i])
+	})
+}
+
+// walkUnordered visits directories in the order they are read, queueing
+// the subdirectories the visitor accepts.
+func (w *parallelWalker) walkUnordered() error {
+	for outstanding := 1; outstanding > 0; outstanding-- {
+		var l *dirListing
+		select {
+		case l = <-w.results:
+		case <-w.ctx.Done():
+			return w.ctx.Err()
+		}
+		err := w.visitListing(l, func(_ int, path string, entry fs.DirEntry) error {
+			w.schedule(path, entry)
+			outstanding++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -64,21 +64,36 @@ func (oc *OutputCollector) GetMessages() []OutputMessage {
 func (oc *OutputCollector) FlushAll() {
 	for _, msg := range oc.messages {
 		if msg.Destination == "stderr" {
-			fmt.Fprint(os.Stderr, msg.Content)
+			fmt.Fprint(os.Stderr, StyleStderr(msg.Content))
 		} else {
-			fmt.Print(msg.Content)
+			fmt.Print(StyleStdout(msg.Content))
 		}
 	}
 	oc.messages = make([]OutputMessage, 0)
 }
 
+// ⭐ SINK-001: OutputCollector component - 📝 Flush through routes
+// FlushRoutes writes all collected messages to the sinks of their type and
+// clears the collector. It stops at the first failed write, keeping the
+// messages not yet written.
+func (oc *OutputCollector) FlushRoutes(routes *Routes) error {
+	for i, msg := range oc.messages {
+		if err := routes.Write(Level(msg.Type), msg.Content); err != nil {
+			oc.messages = oc.messages[i:]
+			return err
+		}
+	}
+	oc.messages = make([]OutputMessage, 0)
+	return nil
+}
+
 // ⭐ EXTRACT-003: OutputCollector component - 📝 Flush stdout only
 // FlushStdout displays only stdout messages and removes them from the collector
 func (oc *OutputCollector) FlushStdout() {
 	remaining := make([]OutputMessage, 0)
 	for _, msg := range oc.messages {
 		if msg.Destination == "stdout" {
-			fmt.Print(msg.Content)
+			fmt.Print(StyleStdout(msg.Content))
 		} else {
 			remaining = append(remaining, msg)
 		}
@@ -92,7 +107,7 @@ func (oc *OutputCollector) FlushStderr() {
 	remaining := make([]OutputMessage, 0)
 	for _, msg := range oc.messages {
 		if msg.Destination == "stderr" {
-			fmt.Fprint(os.Stderr, msg.Content)
+			fmt.Fprint(os.Stderr, StyleStderr(msg.Content))
 		} else {
 			remaining = append(remaining, msg)
 		}
@@ -20,6 +20,8 @@ type DefaultOutputFormatter struct {
 	templateFormatter TemplateFormatter
 	patternExtractor  PatternExtractor
 	collector         *OutputCollector
+	// ⭐ SINK-001: Sinks of the message levels; nil uses DefaultRoutes
+	routes *Routes
 }
 
 // ⭐ EXTRACT-003: OutputFormatter implementation - 🔧 Constructor
@@ -62,6 +64,39 @@ func (f *DefaultOutputFormatter) SetCollector(collector *OutputCollector) {
 	f.collector = collector
 }
 
+// ⭐ SINK-001: Output routing - 🔧 Set routes
+// SetRoutes sets the sinks messages are written to by level; nil restores
+// DefaultRoutes. The formatter does not close them.
+func (f *DefaultOutputFormatter) SetRoutes(routes *Routes) {
+	f.routes = routes
+}
+
+// ⭐ SINK-001: Output routing - 🔍 Get routes
+// GetRoutes returns the routes messages are written to.
+func (f *DefaultOutputFormatter) GetRoutes() *Routes {
+	if f.routes == nil {
+		f.routes = DefaultRoutes()
+	}
+	return f.routes
+}
+
+// ⭐ SINK-001: Output routing - 📝 Print by level
+// PrintMessage writes a formatted message to the sink of level, or collects
+// it in delayed mode. Warnings and errors are collected for stderr.
+func (f *DefaultOutputFormatter) PrintMessage(level Level, message string) {
+	if f.IsDelayedMode() {
+		if level == LevelWarning || level == LevelError {
+			f.collector.AddStderr(message, string(level))
+		} else {
+			f.collector.AddStdout(message, string(level))
+		}
+		return
+	}
+	if err := f.GetRoutes().Write(level, message); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not write output: %v\n", err)
+	}
+}
+
 // ⭐ EXTRACT-003: OutputFormatter implementation - 🔧 Printf-style formatting operations
 
 // FormatCreatedArchive formats a created archive message using printf-style formatting
@@ -159,101 +194,61 @@ func (f *DefaultOutputFormatter) FormatDryRunBackup(path string) string {
 // PrintCreatedArchive prints a created archive message
 func (f *DefaultOutputFormatter) PrintCreatedArchive(path string) {
 	message := f.FormatCreatedArchive(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintIdenticalArchive prints an identical archive message
 func (f *DefaultOutputFormatter) PrintIdenticalArchive(path string) {
 	message := f.FormatIdenticalArchive(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintListArchive prints a list archive message
 func (f *DefaultOutputFormatter) PrintListArchive(path, creationTime string) {
 	message := f.FormatListArchive(path, creationTime)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintConfigValue prints a configuration value message
 func (f *DefaultOutputFormatter) PrintConfigValue(name, value, source string) {
 	message := f.FormatConfigValue(name, value, source)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "config")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelConfig, message)
 }
 
 // PrintError prints an error message
 func (f *DefaultOutputFormatter) PrintError(message string) {
 	formattedMessage := f.FormatError(message)
-	if f.IsDelayedMode() {
-		f.collector.AddStderr(formattedMessage, "error")
-	} else {
-		fmt.Fprint(os.Stderr, formattedMessage)
-	}
+	f.PrintMessage(LevelError, formattedMessage)
 }
 
 // PrintDryRunArchive prints a dry-run archive message
 func (f *DefaultOutputFormatter) PrintDryRunArchive(path string) {
 	message := f.FormatDryRunArchive(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintCreatedBackup prints a created backup message
 func (f *DefaultOutputFormatter) PrintCreatedBackup(path string) {
 	message := f.FormatCreatedBackup(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintIdenticalBackup prints an identical backup message
 func (f *DefaultOutputFormatter) PrintIdenticalBackup(path string) {
 	message := f.FormatIdenticalBackup(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintListBackup prints a list backup message
 func (f *DefaultOutputFormatter) PrintListBackup(path, creationTime string) {
 	message := f.FormatListBackup(path, creationTime)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintDryRunBackup prints a dry-run backup message
 func (f *DefaultOutputFormatter) PrintDryRunBackup(path string) {
 	message := f.FormatDryRunBackup(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // ⭐ EXTRACT-003: OutputFormatter implementation - 🔧 Delegate template operations to TemplateFormatter
@@ -505,21 +500,13 @@ func (f *DefaultOutputFormatter) TemplateIncrementalCreatedWithStats(path string
 // PrintCreatedArchiveWithStats prints a created archive message with detailed file statistics
 func (f *DefaultOutputFormatter) PrintCreatedArchiveWithStats(path string) {
 	message := f.FormatCreatedArchiveWithStats(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // PrintIncrementalCreatedWithStats prints an incremental created message with detailed file statistics
 func (f *DefaultOutputFormatter) PrintIncrementalCreatedWithStats(path string) {
 	message := f.FormatIncrementalCreatedWithStats(path)
-	if f.IsDelayedMode() {
-		f.collector.AddStdout(message, "info")
-	} else {
-		fmt.Print(message)
-	}
+	f.PrintMessage(LevelInfo, message)
 }
 
 // ⭐ OUT-002: Enhanced output with file statistics - Helper methods
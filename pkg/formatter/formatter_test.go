@@ -47,6 +47,14 @@ func (mcp *MockConfigProvider) GetErrorFormat(errorType string) string {
 	return mcp.errorFormats[errorType]
 }
 
+func (mcp *MockConfigProvider) GetDetailedFormatString(formatType string) string {
+	return mcp.formatStrings[formatType]
+}
+
+func (mcp *MockConfigProvider) GetDetailedTemplateString(templateType string) string {
+	return mcp.templateStrings[templateType]
+}
+
 // ⭐ EXTRACT-003: OutputCollector tests - 🧪 Delayed output functionality
 func TestOutputCollector(t *testing.T) {
 	collector := NewOutputCollector()
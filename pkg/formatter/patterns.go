@@ -10,8 +10,58 @@ import (
 	"path/filepath"
 	"regexp"
 	"strings"
+	"sync"
 )
 
+// ⭐ NAME-PATTERN-001: Untrusted file names - 🛡️
+// MaxPatternInputLength is the length in bytes above which text is not
+// matched against patterns. File systems limit names to 255 bytes; longer
+// names can only come from elsewhere, such as remote listings.
+const MaxPatternInputLength = 1024
+
+// patternCache holds compiled patterns by source, so listing a large
+// directory compiles each pattern once.
+var patternCache sync.Map
+
+// compilePattern returns the compiled pattern, compiling it on first use.
+func compilePattern(pattern string) (*regexp.Regexp, error) {
+	if re, ok := patternCache.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	patternCache.Store(pattern, re)
+	return re, nil
+}
+
+// ⭐ NAME-PATTERN-001: Named group extraction - 🔍
+// ExtractNamedGroups returns the named groups of pattern matched in text.
+// Text may be any file name, so it never fails: the result is empty when
+// the pattern is invalid, text is longer than MaxPatternInputLength or does
+// not match, and callers treat such names as foreign.
+func ExtractNamedGroups(pattern, text string) map[string]string {
+	result := make(map[string]string)
+	if len(text) > MaxPatternInputLength {
+		return result
+	}
+	re, err := compilePattern(pattern)
+	if err != nil {
+		return result
+	}
+	matches := re.FindStringSubmatch(text)
+	if matches == nil {
+		return result
+	}
+	for i, name := range re.SubexpNames() {
+		if i > 0 && i < len(matches) && name != "" {
+			result[name] = matches[i]
+		}
+	}
+	return result
+}
+
 // ⭐ EXTRACT-003: PatternExtractor component - 🔧 Generic pattern extraction implementation
 // DefaultPatternExtractor provides default pattern extraction functionality
 type DefaultPatternExtractor struct {
@@ -51,24 +101,7 @@ func (pe *DefaultPatternExtractor) ExtractBackupFilenameData(filename string) ma
 // ⭐ EXTRACT-003: PatternExtractor component - 🔍 Generic pattern data extraction
 // ExtractPatternData extracts named groups from text using a regex pattern
 func (pe *DefaultPatternExtractor) ExtractPatternData(pattern, text string) map[string]string {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return make(map[string]string)
-	}
-
-	matches := re.FindStringSubmatch(text)
-	if matches == nil {
-		return make(map[string]string)
-	}
-
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		if i > 0 && i < len(matches) && name != "" {
-			result[name] = matches[i]
-		}
-	}
-
-	return result
+	return ExtractNamedGroups(pattern, text)
 }
 
 // ⭐ EXTRACT-003: PatternExtractor component - 🔍 Additional extraction utilities
@@ -126,22 +159,5 @@ func (spe *SimplePatternExtractor) ExtractBackupFilenameData(filename string) ma
 // ⭐ EXTRACT-003: PatternExtractor component - 🔍 Simple pattern extraction
 // ExtractPatternData extracts named groups from text using a regex pattern
 func (spe *SimplePatternExtractor) ExtractPatternData(pattern, text string) map[string]string {
-	re, err := regexp.Compile(pattern)
-	if err != nil {
-		return make(map[string]string)
-	}
-
-	matches := re.FindStringSubmatch(text)
-	if matches == nil {
-		return make(map[string]string)
-	}
-
-	result := make(map[string]string)
-	for i, name := range re.SubexpNames() {
-		if i > 0 && i < len(matches) && name != "" {
-			result[name] = matches[i]
-		}
-	}
-
-	return result
+	return ExtractNamedGroups(pattern, text)
 }
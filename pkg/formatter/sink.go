@@ -0,0 +1,268 @@
+// Output sinks and per-level routing for the formatter package.
+// A sink is where formatted messages are written: stdout, stderr, a file or
+// several of them at once. Routes select the sink of each message level, so
+// that warnings and errors can go to stderr while results go to stdout and
+// piped JSON output stays clean.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package formatter
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+)
+
+// ⭐ SINK-001: Message levels - 🔧
+// Level is the kind of a message; it matches OutputMessage.Type.
+type Level string
+
+const (
+	// LevelInfo is a result such as a created archive or a listing line.
+	LevelInfo Level = "info"
+	// LevelConfig is a configuration value.
+	LevelConfig Level = "config"
+	// LevelWarning is a warning that does not stop the operation.
+	LevelWarning Level = "warning"
+	// LevelError is an error.
+	LevelError Level = "error"
+)
+
+// Levels lists the message levels in increasing severity.
+var Levels = []Level{LevelInfo, LevelConfig, LevelWarning, LevelError}
+
+// ⭐ SINK-001: Output sinks - 🔧
+// Sink is a destination of formatted messages. Close releases files; the
+// standard streams are never closed.
+type Sink interface {
+	io.Writer
+	io.Closer
+	// String returns the specification the sink was opened from.
+	String() string
+}
+
+// streamSink writes to stdout or stderr with their style policy. The
+// stream is looked up on every write, so redirecting os.Stdout in tests
+// and delayed output keep working.
+type streamSink struct {
+	stderr bool
+}
+
+// StdoutSink returns the sink writing to stdout with the stdout style.
+func StdoutSink() Sink { return streamSink{} }
+
+// StderrSink returns the sink writing to stderr with the stderr style.
+func StderrSink() Sink { return streamSink{stderr: true} }
+
+func (s streamSink) Write(p []byte) (int, error) {
+	var err error
+	if s.stderr {
+		_, err = fmt.Fprint(os.Stderr, StyleStderr(string(p)))
+	} else {
+		_, err = fmt.Fprint(os.Stdout, StyleStdout(string(p)))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s streamSink) Close() error { return nil }
+
+func (s streamSink) String() string {
+	if s.stderr {
+		return "stderr"
+	}
+	return "stdout"
+}
+
+// fileSink appends to a file. Files are not terminals, so colors are
+// stripped.
+type fileSink struct {
+	path string
+	file *os.File
+}
+
+// FileSink opens path for appending, creating it if needed.
+func FileSink(path string) (Sink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open output file: %w", err)
+	}
+	return &fileSink{path: path, file: file}, nil
+}
+
+func (s *fileSink) Write(p []byte) (int, error) {
+	if _, err := io.WriteString(s.file, StripANSI(string(p))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (s *fileSink) Close() error { return s.file.Close() }
+
+func (s *fileSink) String() string { return "file:" + s.path }
+
+// multiSink writes every message to each of its sinks.
+type multiSink struct {
+	sinks []Sink
+}
+
+// MultiSink returns a sink writing to all of sinks, stopping at the first
+// failed write.
+func MultiSink(sinks ...Sink) Sink {
+	return &multiSink{sinks: sinks}
+}
+
+func (s *multiSink) Write(p []byte) (int, error) {
+	for _, sink := range s.sinks {
+		if _, err := sink.Write(p); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (s *multiSink) Close() error {
+	var first error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+func (s *multiSink) String() string {
+	names := make([]string, len(s.sinks))
+	for i, sink := range s.sinks {
+		names[i] = sink.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// ⭐ SINK-001: Sink specifications - 🔧
+// OpenSink opens the sink named by spec: "stdout", "stderr", "file:PATH",
+// or several of these separated by commas for a multi-writer, such as
+// "stdout,file:/var/log/bkpdir.log".
+func OpenSink(spec string) (Sink, error) {
+	var sinks []Sink
+	fail := func(err error) (Sink, error) {
+		MultiSink(sinks...).Close()
+		return nil, err
+	}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		switch {
+		case part == "stdout":
+			sinks = append(sinks, StdoutSink())
+		case part == "stderr":
+			sinks = append(sinks, StderrSink())
+		case strings.HasPrefix(part, "file:") && len(part) > len("file:"):
+			sink, err := FileSink(strings.TrimPrefix(part, "file:"))
+			if err != nil {
+				return fail(err)
+			}
+			sinks = append(sinks, sink)
+		default:
+			return fail(fmt.Errorf("invalid output sink %q (use stdout, stderr or file:PATH)", part))
+		}
+	}
+	if len(sinks) == 1 {
+		return sinks[0], nil
+	}
+	return MultiSink(sinks...), nil
+}
+
+// ⭐ SINK-001: Per-level routing - 🔧
+// Routes selects the sink of each message level.
+type Routes struct {
+	sinks map[Level]Sink
+}
+
+// DefaultRoutes returns the routing formatters use unless configured:
+// results and configuration values go to stdout, warnings and errors to
+// stderr.
+func DefaultRoutes() *Routes {
+	return &Routes{sinks: map[Level]Sink{
+		LevelInfo:    StdoutSink(),
+		LevelConfig:  StdoutSink(),
+		LevelWarning: StderrSink(),
+		LevelError:   StderrSink(),
+	}}
+}
+
+// ParseRoutes returns the default routes with the levels in specs routed
+// to the sinks they name, e.g. {"info": "stdout,file:run.log"}. Levels
+// other than those in Levels are refused. On error nothing stays open.
+func ParseRoutes(specs map[string]string) (*Routes, error) {
+	routes := DefaultRoutes()
+	levels := make([]string, 0, len(specs))
+	for level := range specs {
+		levels = append(levels, level)
+	}
+	sort.Strings(levels)
+	for _, name := range levels {
+		level := Level(strings.ToLower(strings.TrimSpace(name)))
+		if !validLevel(level) {
+			routes.Close()
+			return nil, fmt.Errorf("invalid output level %q (use info, config, warning or error)", name)
+		}
+		sink, err := OpenSink(specs[name])
+		if err != nil {
+			routes.Close()
+			return nil, fmt.Errorf("output route for %s: %w", level, err)
+		}
+		routes.Route(level, sink)
+	}
+	return routes, nil
+}
+
+// validLevel reports whether level is one of Levels.
+func validLevel(level Level) bool {
+	for _, l := range Levels {
+		if l == level {
+			return true
+		}
+	}
+	return false
+}
+
+// Route sends messages of level to sink.
+func (r *Routes) Route(level Level, sink Sink) {
+	r.sinks[level] = sink
+}
+
+// Sink returns the sink of level. Unknown levels go where info goes.
+func (r *Routes) Sink(level Level) Sink {
+	if sink, ok := r.sinks[level]; ok {
+		return sink
+	}
+	return r.sinks[LevelInfo]
+}
+
+// Write writes message to the sink of level.
+func (r *Routes) Write(level Level, message string) error {
+	_, err := io.WriteString(r.Sink(level), message)
+	return err
+}
+
+// Close closes every sink once, also when several levels share it.
+func (r *Routes) Close() error {
+	var first error
+	closed := make(map[Sink]bool)
+	for _, level := range Levels {
+		sink := r.sinks[level]
+		if sink == nil || closed[sink] {
+			continue
+		}
+		closed[sink] = true
+		if err := sink.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
@@ -0,0 +1,135 @@
+// Tests for the output sinks and per-level routing of the formatter package.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package formatter
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// captureStreams redirects stdout and stderr to files while run runs and
+// returns what was written to each.
+func captureStreams(t *testing.T, run func()) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	stdout, err := os.Create(filepath.Join(dir, "stdout"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	stderr, err := os.Create(filepath.Join(dir, "stderr"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	origOut, origErr := os.Stdout, os.Stderr
+	os.Stdout, os.Stderr = stdout, stderr
+	run()
+	os.Stdout, os.Stderr = origOut, origErr
+	stdout.Close()
+	stderr.Close()
+	out, _ := os.ReadFile(stdout.Name())
+	errOut, _ := os.ReadFile(stderr.Name())
+	return string(out), string(errOut)
+}
+
+// ⭐ SINK-001: Sink specifications - 🧪
+func TestOpenSink(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "run.log")
+	for spec, want := range map[string]string{
+		"stdout":                    "stdout",
+		" stderr ":                  "stderr",
+		"file:" + log:               "file:" + log,
+		"stdout, file:" + log:       "stdout,file:" + log,
+		"stderr,stdout,file:" + log: "stderr,stdout,file:" + log,
+	} {
+		sink, err := OpenSink(spec)
+		if err != nil {
+			t.Errorf("OpenSink(%q): %v", spec, err)
+			continue
+		}
+		if sink.String() != want {
+			t.Errorf("OpenSink(%q) = %s, want %s", spec, sink, want)
+		}
+		sink.Close()
+	}
+	for _, spec := range []string{"", "syslog", "file:", "stdout,"} {
+		if _, err := OpenSink(spec); err == nil {
+			t.Errorf("OpenSink(%q) should fail", spec)
+		}
+	}
+}
+
+// ⭐ SINK-001: Per-level routing - 🧪
+func TestRoutes(t *testing.T) {
+	log := filepath.Join(t.TempDir(), "run.log")
+	formatter := NewDefaultOutputFormatter(NewMockConfigProvider())
+
+	// By default results go to stdout, warnings and errors to stderr
+	out, errOut := captureStreams(t, func() {
+		formatter.PrintCreatedArchive("a.zip")
+		formatter.PrintMessage(LevelWarning, "careful\n")
+		formatter.PrintError("broken")
+	})
+	if out != "Created archive: a.zip\n" || errOut != "careful\nError: broken\n" {
+		t.Errorf("default routes: stdout %q, stderr %q", out, errOut)
+	}
+
+	routes, err := ParseRoutes(map[string]string{"info": "stdout,file:" + log, "Error": "file:" + log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	formatter.SetRoutes(routes)
+	out, errOut = captureStreams(t, func() {
+		formatter.PrintCreatedArchive("\x1b[32mb.zip\x1b[0m")
+		formatter.PrintMessage(LevelWarning, "careful\n")
+		formatter.PrintError("broken")
+	})
+	if err := routes.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(log)
+	if !strings.Contains(out, "b.zip") || errOut != "careful\n" ||
+		string(data) != "Created archive: b.zip\nError: broken\n" {
+		t.Errorf("configured routes: stdout %q, stderr %q, file %q", out, errOut, data)
+	}
+
+	if _, err := ParseRoutes(map[string]string{"debug": "stderr"}); err == nil || !strings.Contains(err.Error(), "invalid output level") {
+		t.Errorf("unknown level: %v", err)
+	}
+	if _, err := ParseRoutes(map[string]string{"info": "file:" + filepath.Join(log, "x")}); err == nil {
+		t.Error("unopenable file should fail")
+	}
+}
+
+// ⭐ SINK-001: Delayed output through routes - 🧪
+func TestFlushRoutes(t *testing.T) {
+	collector := NewOutputCollector()
+	formatter := NewDefaultOutputFormatterWithCollector(NewMockConfigProvider(), collector)
+	formatter.PrintCreatedArchive("a.zip")
+	formatter.PrintMessage(LevelWarning, "careful\n")
+	if messages := collector.GetMessages(); len(messages) != 2 || messages[1].Destination != "stderr" {
+		t.Fatalf("collected %+v", messages)
+	}
+
+	log := filepath.Join(t.TempDir(), "warnings.log")
+	routes, err := ParseRoutes(map[string]string{"warning": "file:" + log})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer routes.Close()
+	out, errOut := captureStreams(t, func() {
+		if err := collector.FlushRoutes(routes); err != nil {
+			t.Error(err)
+		}
+	})
+	data, _ := os.ReadFile(log)
+	if out != "Created archive: a.zip\n" || errOut != "" || string(data) != "careful\n" {
+		t.Errorf("flushed: stdout %q, stderr %q, file %q", out, errOut, data)
+	}
+	if len(collector.GetMessages()) != 0 {
+		t.Error("Expected the collector to be empty")
+	}
+}
@@ -0,0 +1,149 @@
+// Output style policy for the formatter package.
+// Decides whether output keeps ANSI color sequences, following --color,
+// NO_COLOR and CLICOLOR_FORCE, and whether emoji and box-drawing markers are
+// replaced with plain ASCII for logs and limited terminals.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package formatter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ⭐ COLOR-001: Color modes - 🔧
+// ColorMode selects when output keeps ANSI color sequences.
+type ColorMode string
+
+const (
+	// ColorAuto keeps colors on terminals unless NO_COLOR is set.
+	ColorAuto ColorMode = "auto"
+	// ColorAlways keeps colors, even when output is piped.
+	ColorAlways ColorMode = "always"
+	// ColorNever strips colors.
+	ColorNever ColorMode = "never"
+)
+
+// ParseColorMode returns the mode named by s; empty means auto.
+func ParseColorMode(s string) (ColorMode, error) {
+	switch mode := ColorMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case "":
+		return ColorAuto, nil
+	case ColorAuto, ColorAlways, ColorNever:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid color mode %q (use auto, always or never)", s)
+	}
+}
+
+// ⭐ COLOR-001: Output style policy - 🔧
+// StylePolicy is applied to text before it is written to a stream.
+type StylePolicy struct {
+	Color     bool // Keep ANSI color sequences
+	ASCIIOnly bool // Replace emoji and box-drawing markers with ASCII
+}
+
+// NewStylePolicy resolves the policy of a stream. With ColorAuto, NO_COLOR
+// (any value) and CLICOLOR=0 disable colors, CLICOLOR_FORCE (other than 0)
+// enables them, and otherwise colors are kept only on terminals. getenv is
+// usually os.Getenv.
+func NewStylePolicy(mode ColorMode, asciiOnly, terminal bool, getenv func(string) string) StylePolicy {
+	policy := StylePolicy{ASCIIOnly: asciiOnly}
+	switch mode {
+	case ColorAlways:
+		policy.Color = true
+	case ColorNever:
+		policy.Color = false
+	default:
+		switch {
+		case getenv("NO_COLOR") != "":
+			policy.Color = false
+		case getenv("CLICOLOR_FORCE") != "" && getenv("CLICOLOR_FORCE") != "0":
+			policy.Color = true
+		case getenv("CLICOLOR") == "0":
+			policy.Color = false
+		default:
+			policy.Color = terminal
+		}
+	}
+	return policy
+}
+
+// Apply returns s styled by the policy.
+func (p StylePolicy) Apply(s string) string {
+	if !p.Color {
+		s = StripANSI(s)
+	}
+	if p.ASCIIOnly {
+		s = ASCIIMarkers(s)
+	}
+	return s
+}
+
+// ansiSGR matches ANSI select graphic rendition sequences such as \033[36m.
+var ansiSGR = regexp.MustCompile("\x1b\\[[0-9;]*m")
+
+// StripANSI removes ANSI color sequences from s.
+func StripANSI(s string) string {
+	if !strings.Contains(s, "\x1b[") {
+		return s
+	}
+	return ansiSGR.ReplaceAllString(s, "")
+}
+
+// asciiReplacer maps the emoji and drawing characters used in output to
+// plain text. Other non-ASCII text, such as file names, is kept.
+var asciiReplacer = strings.NewReplacer(
+	"\uFE0F", "", // emoji presentation selector
+	"✅", "[OK]",
+	"❌", "[FAIL]",
+	"⚠", "[WARN]",
+	"📁", "[+]",
+	"📝", "[i]",
+	"🔍", "[?]",
+	"✓", "ok",
+	"✗", "x",
+	"├── ", "|-- ",
+	"└── ", "`-- ",
+	"│", "|",
+	"→", "->",
+	"…", "...",
+	"▁", "_",
+	"▂", ".",
+	"▃", "-",
+	"▄", "=",
+	"▅", "+",
+	"▆", "*",
+	"▇", "%",
+	"█", "#",
+)
+
+// ASCIIMarkers replaces emoji and box-drawing markers in s with ASCII.
+func ASCIIMarkers(s string) string {
+	return asciiReplacer.Replace(s)
+}
+
+// Output policies of stdout and stderr; colors are kept until configured.
+var (
+	stdoutStyle = StylePolicy{Color: true}
+	stderrStyle = StylePolicy{Color: true}
+)
+
+// ⭐ COLOR-001: Process-wide output style - 🔧
+// SetOutputStyle sets the policies applied to stdout and stderr output. It
+// is called once at startup, before output is written.
+func SetOutputStyle(stdout, stderr StylePolicy) {
+	stdoutStyle, stderrStyle = stdout, stderr
+}
+
+// StyleStdout applies the stdout policy to s.
+func StyleStdout(s string) string {
+	return stdoutStyle.Apply(s)
+}
+
+// StyleStderr applies the stderr policy to s.
+func StyleStderr(s string) string {
+	return stderrStyle.Apply(s)
+}
@@ -0,0 +1,72 @@
+// Tests for the output style policy of the formatter package.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+package formatter
+
+import "testing"
+
+// ⭐ COLOR-001: Color mode parsing - 🧪
+func TestParseColorMode(t *testing.T) {
+	for in, want := range map[string]ColorMode{
+		"":        ColorAuto,
+		"auto":    ColorAuto,
+		"Always":  ColorAlways,
+		" never ": ColorNever,
+	} {
+		got, err := ParseColorMode(in)
+		if err != nil || got != want {
+			t.Errorf("ParseColorMode(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+	if _, err := ParseColorMode("sometimes"); err == nil {
+		t.Error("ParseColorMode(sometimes) should fail")
+	}
+}
+
+// ⭐ COLOR-001: Environment and terminal resolution - 🧪
+func TestNewStylePolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     ColorMode
+		terminal bool
+		env      map[string]string
+		want     bool
+	}{
+		{"auto terminal", ColorAuto, true, nil, true},
+		{"auto pipe", ColorAuto, false, nil, false},
+		{"NO_COLOR", ColorAuto, true, map[string]string{"NO_COLOR": "1"}, false},
+		{"NO_COLOR beats force", ColorAuto, true, map[string]string{"NO_COLOR": "1", "CLICOLOR_FORCE": "1"}, false},
+		{"CLICOLOR_FORCE pipe", ColorAuto, false, map[string]string{"CLICOLOR_FORCE": "1"}, true},
+		{"CLICOLOR_FORCE zero", ColorAuto, false, map[string]string{"CLICOLOR_FORCE": "0"}, false},
+		{"CLICOLOR zero", ColorAuto, true, map[string]string{"CLICOLOR": "0"}, false},
+		{"always ignores NO_COLOR", ColorAlways, false, map[string]string{"NO_COLOR": "1"}, true},
+		{"never ignores force", ColorNever, true, map[string]string{"CLICOLOR_FORCE": "1"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			getenv := func(k string) string { return tt.env[k] }
+			if got := NewStylePolicy(tt.mode, false, tt.terminal, getenv).Color; got != tt.want {
+				t.Errorf("Color = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// ⭐ COLOR-001: Color stripping and ASCII markers - 🧪
+func TestStylePolicyApply(t *testing.T) {
+	in := "\033[32m✅ Created\033[0m ├── café.txt\n"
+
+	if got := (StylePolicy{Color: true}).Apply(in); got != in {
+		t.Errorf("colored policy changed the text: %q", got)
+	}
+	if got, want := (StylePolicy{}).Apply(in), "✅ Created ├── café.txt\n"; got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	if got, want := (StylePolicy{ASCIIOnly: true}).Apply(in), "[OK] Created |-- café.txt\n"; got != want {
+		t.Errorf("Apply() = %q, want %q", got, want)
+	}
+	if got, want := ASCIIMarkers("⚠️ skipped"), "[WARN] skipped"; got != want {
+		t.Errorf("ASCIIMarkers() = %q, want %q", got, want)
+	}
+}
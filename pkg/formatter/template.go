@@ -10,10 +10,52 @@ import (
 	"fmt"
 	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
 	"text/template"
 )
 
+// placeholderPattern matches a %{name} placeholder.
+var placeholderPattern = regexp.MustCompile(`%\{([^{}]*)\}`)
+
+// ⭐ NAME-PATTERN-001: Placeholder formatting - 🛡️
+// FormatPlaceholders replaces the %{name} placeholders of format and
+// executes its Go text/template {{.name}} actions with the values of data.
+// Values, which may come from file names, are always inserted as data and
+// never parsed as template source. Placeholders without a value are left in
+// place. If format is not a valid template only the %{name} placeholders
+// are replaced.
+func FormatPlaceholders(format string, data map[string]string) string {
+	result := placeholderPattern.ReplaceAllStringFunc(format, func(placeholder string) string {
+		if value, ok := data[placeholder[2:len(placeholder)-1]]; ok {
+			return value
+		}
+		return placeholder
+	})
+
+	// %{name} becomes an action that inserts the value of name
+	source := placeholderPattern.ReplaceAllStringFunc(format, func(placeholder string) string {
+		key := placeholder[2 : len(placeholder)-1]
+		if _, ok := data[key]; ok {
+			return "{{index . " + strconv.Quote(key) + "}}"
+		}
+		return placeholder
+	})
+	tmpl, err := template.New("format").Parse(source)
+	if err != nil {
+		// Fall back to simple replacement if template parsing fails
+		return result
+	}
+
+	var buf strings.Builder
+	if err := tmpl.Execute(&buf, data); err != nil {
+		// Fall back to simple replacement if template execution fails
+		return result
+	}
+
+	return buf.String()
+}
+
 // ⭐ EXTRACT-003: TemplateFormatter component - 🔧 Template formatting implementation
 // DefaultTemplateFormatter provides template-based formatting functionality
 type DefaultTemplateFormatter struct {
@@ -57,28 +99,7 @@ func (tf *DefaultTemplateFormatter) FormatWithTemplate(input, pattern, tmplStr s
 // ⭐ EXTRACT-003: TemplateFormatter component - 🔧 Placeholder-based formatting
 // FormatWithPlaceholders formats a string using placeholder-based template formatting
 func (tf *DefaultTemplateFormatter) FormatWithPlaceholders(format string, data map[string]string) string {
-	result := format
-
-	// Handle %{name} style placeholders
-	for key, value := range data {
-		placeholder := fmt.Sprintf("%%{%s}", key)
-		result = strings.ReplaceAll(result, placeholder, value)
-	}
-
-	// Handle Go text/template style {{.name}} placeholders
-	tmpl, err := template.New("format").Parse(result)
-	if err != nil {
-		// Fall back to simple replacement if template parsing fails
-		return result
-	}
-
-	var buf strings.Builder
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// Fall back to simple replacement if template execution fails
-		return result
-	}
-
-	return buf.String()
+	return FormatPlaceholders(format, data)
 }
 
 // ⭐ EXTRACT-003: TemplateFormatter component - 🔧 Archive template operations
@@ -220,28 +241,7 @@ func (stf *SimpleTemplateFormatter) FormatWithTemplate(input, pattern, tmplStr s
 // ⭐ EXTRACT-003: TemplateFormatter component - 🔧 Simple placeholder formatting
 // FormatWithPlaceholders formats a string using placeholder-based template formatting
 func (stf *SimpleTemplateFormatter) FormatWithPlaceholders(format string, data map[string]string) string {
-	result := format
-
-	// Handle %{name} style placeholders
-	for key, value := range data {
-		placeholder := fmt.Sprintf("%%{%s}", key)
-		result = strings.ReplaceAll(result, placeholder, value)
-	}
-
-	// Handle Go text/template style {{.name}} placeholders
-	tmpl, err := template.New("format").Parse(result)
-	if err != nil {
-		// Fall back to simple replacement if template parsing fails
-		return result
-	}
-
-	var buf strings.Builder
-	if err := tmpl.Execute(&buf, data); err != nil {
-		// Fall back to simple replacement if template execution fails
-		return result
-	}
-
-	return buf.String()
+	return FormatPlaceholders(format, data)
 }
 
 // ⭐ EXTRACT-003: TemplateFormatter component - 🔧 Simple template operations with defaults
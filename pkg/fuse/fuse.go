@@ -0,0 +1,57 @@
+// ⭐ MOUNT-001: Read-only FUSE file systems - Types shared by all platforms - 🔧
+package fuse
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"syscall"
+	"time"
+)
+
+// RootID is the inode number of the root directory.
+const RootID = 1
+
+// ErrUnsupported is returned by Mount where FUSE is not available.
+var ErrUnsupported = errors.New("FUSE mounts are not supported on this platform")
+
+// Attr describes a file of a mounted file system.
+type Attr struct {
+	Ino   uint64
+	Size  uint64
+	Mode  os.FileMode
+	Mtime time.Time
+}
+
+// Dirent is one entry of a directory listing.
+type Dirent struct {
+	Name string
+	Ino  uint64
+	Mode os.FileMode
+}
+
+// FS is a read-only file system served over FUSE. Inodes are numbered by
+// the file system, with RootID for the root directory. Errors wrapping
+// fs.ErrNotExist are reported as ENOENT, a syscall.Errno as itself and
+// anything else as EIO.
+type FS interface {
+	Attr(ino uint64) (Attr, error)
+	Lookup(parent uint64, name string) (Attr, error)
+	ReadDir(ino uint64) ([]Dirent, error)
+	ReadAt(ino uint64, p []byte, off int64) (int, error)
+	Readlink(ino uint64) (string, error)
+	// Release is called when the kernel closes a file opened for reading.
+	Release(ino uint64)
+}
+
+// errno maps an FS error to the error number returned to the kernel.
+func errno(err error) syscall.Errno {
+	var e syscall.Errno
+	switch {
+	case errors.As(err, &e):
+		return e
+	case errors.Is(err, fs.ErrNotExist):
+		return syscall.ENOENT
+	}
+	return syscall.EIO
+}
@@ -0,0 +1,126 @@
+// ⭐ MOUNT-001: FUSE mounts on Linux - mount(2) as root, fusermount otherwise - 🔧
+package fuse
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// Conn is a mounted FUSE file system.
+type Conn struct {
+	dir        string
+	dev        *os.File
+	fusermount string // empty when mounted with mount(2)
+}
+
+// Mount mounts a read-only FUSE file system named fsname at dir. Root
+// mounts with mount(2); other users need fusermount3 or fusermount.
+func Mount(dir, fsname string) (*Conn, error) {
+	if info, err := os.Stat(dir); err != nil {
+		return nil, err
+	} else if !info.IsDir() {
+		return nil, fmt.Errorf("mount point %s is not a directory", dir)
+	}
+	if os.Geteuid() == 0 {
+		return mountDirect(dir, fsname)
+	}
+	return mountFusermount(dir, fsname)
+}
+
+// mountDirect mounts with mount(2), passing an open /dev/fuse.
+func mountDirect(dir, fsname string) (*Conn, error) {
+	dev, err := os.OpenFile("/dev/fuse", os.O_RDWR, 0)
+	if err != nil {
+		return nil, fmt.Errorf("FUSE is not available: %w", err)
+	}
+	data := fmt.Sprintf("fd=%d,rootmode=40000,user_id=0,group_id=0,allow_other", dev.Fd())
+	flags := uintptr(syscall.MS_RDONLY | syscall.MS_NOSUID | syscall.MS_NODEV)
+	if err := syscall.Mount(fsname, dir, "fuse.bkpdir", flags, data); err != nil {
+		dev.Close()
+		return nil, fmt.Errorf("mount %s: %w", dir, err)
+	}
+	return &Conn{dir: dir, dev: dev}, nil
+}
+
+// mountFusermount runs the setuid fusermount helper, which mounts and sends
+// the /dev/fuse descriptor back over a socket.
+func mountFusermount(dir, fsname string) (*Conn, error) {
+	helper, err := findFusermount()
+	if err != nil {
+		return nil, err
+	}
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM, 0)
+	if err != nil {
+		return nil, err
+	}
+	local := os.NewFile(uintptr(fds[0]), "fusermount")
+	remote := os.NewFile(uintptr(fds[1]), "fusermount")
+	defer local.Close()
+	defer remote.Close()
+
+	cmd := exec.Command(helper, "-o", "ro,nosuid,nodev,fsname="+fsname+",subtype=bkpdir", "--", dir)
+	cmd.ExtraFiles = []*os.File{remote}
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s: %w", helper, err)
+	}
+
+	buf := make([]byte, 1)
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := syscall.Recvmsg(int(local.Fd()), buf, oob, 0)
+	if err != nil {
+		return nil, fmt.Errorf("receiving FUSE descriptor: %w", err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		return nil, errors.New("fusermount did not send a FUSE descriptor")
+	}
+	rights, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(rights) == 0 {
+		return nil, errors.New("fusermount did not send a FUSE descriptor")
+	}
+	return &Conn{dir: dir, dev: os.NewFile(uintptr(rights[0]), "/dev/fuse"), fusermount: helper}, nil
+}
+
+// findFusermount returns the path of fusermount3 or fusermount.
+func findFusermount() (string, error) {
+	for _, name := range []string{"fusermount3", "fusermount"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", errors.New("FUSE is not available: fusermount3 or fusermount not found")
+}
+
+// Serve answers requests for fsys until the file system is unmounted.
+func (c *Conn) Serve(fsys FS) error {
+	return serve(c.dev, fsys)
+}
+
+// Unmount unmounts the file system; Serve returns once it is gone. A busy
+// mount is detached and disappears when it is no longer used.
+func (c *Conn) Unmount() error {
+	if c.fusermount != "" {
+		if out, err := exec.Command(c.fusermount, "-u", "-z", c.dir).CombinedOutput(); err != nil {
+			return fmt.Errorf("unmount %s: %s", c.dir, out)
+		}
+		return nil
+	}
+	err := syscall.Unmount(c.dir, 0)
+	if errors.Is(err, syscall.EBUSY) {
+		err = syscall.Unmount(c.dir, syscall.MNT_DETACH)
+	}
+	if err != nil {
+		return fmt.Errorf("unmount %s: %w", c.dir, err)
+	}
+	return nil
+}
+
+// Close releases the FUSE device.
+func (c *Conn) Close() error {
+	return c.dev.Close()
+}
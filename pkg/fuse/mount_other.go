@@ -0,0 +1,27 @@
+//go:build !linux
+
+// ⭐ MOUNT-001: FUSE mounts on other platforms - Not supported - 🔧
+package fuse
+
+// Conn is a mounted FUSE file system.
+type Conn struct{}
+
+// Mount reports that FUSE mounts are not supported.
+func Mount(dir, fsname string) (*Conn, error) {
+	return nil, ErrUnsupported
+}
+
+// Serve reports that FUSE mounts are not supported.
+func (c *Conn) Serve(fsys FS) error {
+	return ErrUnsupported
+}
+
+// Unmount reports that FUSE mounts are not supported.
+func (c *Conn) Unmount() error {
+	return ErrUnsupported
+}
+
+// Close does nothing.
+func (c *Conn) Close() error {
+	return nil
+}
@@ -0,0 +1,277 @@
+// ⭐ MOUNT-001: FUSE kernel protocol - Read-only subset of protocol 7.x - 🔧
+package fuse
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// Opcodes of the FUSE kernel protocol that the server answers.
+const (
+	opLookup      = 1
+	opForget      = 2
+	opGetattr     = 3
+	opReadlink    = 5
+	opOpen        = 14
+	opRead        = 15
+	opStatfs      = 17
+	opRelease     = 18
+	opFlush       = 25
+	opInit        = 26
+	opOpendir     = 27
+	opReaddir     = 28
+	opReleasedir  = 29
+	opAccess      = 34
+	opInterrupt   = 36
+	opDestroy     = 38
+	opBatchForget = 42
+)
+
+const (
+	protocolMajor = 7
+	protocolMinor = 31
+	inHeaderSize  = 40
+	outHeaderSize = 16
+	maxWrite      = 128 * 1024
+	// The kernel requires room for a full write request.
+	readBufferSize = maxWrite + 4096
+	// openKeepCache tells the kernel that file contents never change.
+	openKeepCache = 1 << 1
+	// Archives do not change while mounted.
+	cacheTimeout = time.Minute
+)
+
+// The kernel encodes requests in host byte order.
+var order = binary.NativeEndian
+
+// server answers kernel requests for one file system.
+type server struct {
+	fs       FS
+	uid, gid uint32
+}
+
+// serve answers requests read from dev until the file system is unmounted.
+// Each read returns one request and each write sends one reply.
+func serve(dev io.ReadWriter, fsys FS) error {
+	s := &server{fs: fsys, uid: uint32(os.Getuid()), gid: uint32(os.Getgid())}
+	buf := make([]byte, readBufferSize)
+	for {
+		n, err := dev.Read(buf)
+		switch {
+		case errors.Is(err, syscall.EINTR), errors.Is(err, syscall.EAGAIN), errors.Is(err, syscall.ENOENT):
+			continue
+		case errors.Is(err, syscall.ENODEV), err == io.EOF:
+			return nil
+		case err != nil:
+			return err
+		case n == 0:
+			return nil
+		case n < inHeaderSize:
+			return fmt.Errorf("short FUSE request of %d bytes", n)
+		}
+
+		opcode := order.Uint32(buf[4:])
+		unique := order.Uint64(buf[8:])
+		nodeid := order.Uint64(buf[16:])
+		reply, e, answer := s.handle(opcode, nodeid, buf[inHeaderSize:n])
+		if !answer {
+			continue
+		}
+		out := make([]byte, outHeaderSize, outHeaderSize+len(reply))
+		if e == 0 {
+			out = append(out, reply...)
+		}
+		order.PutUint32(out[0:], uint32(len(out)))
+		order.PutUint32(out[4:], uint32(-int32(e)))
+		order.PutUint64(out[8:], unique)
+		// ENOENT means the request was interrupted and needs no reply
+		if _, err := dev.Write(out); err != nil && !errors.Is(err, syscall.ENOENT) {
+			return err
+		}
+		if opcode == opDestroy {
+			return nil
+		}
+	}
+}
+
+// handle answers one request. It returns the reply body, an error number
+// and whether the kernel expects a reply.
+func (s *server) handle(opcode uint32, nodeid uint64, body []byte) ([]byte, syscall.Errno, bool) {
+	switch opcode {
+	case opInit:
+		if len(body) < 8 || order.Uint32(body) < protocolMajor {
+			return nil, syscall.EPROTO, true
+		}
+		out := make([]byte, 64)
+		order.PutUint32(out[0:], protocolMajor)
+		order.PutUint32(out[4:], protocolMinor)
+		if len(body) >= 12 {
+			copy(out[8:12], body[8:12]) // max_readahead
+		}
+		order.PutUint32(out[20:], maxWrite)
+		order.PutUint32(out[24:], 1) // time_gran
+		return out, 0, true
+
+	case opForget, opBatchForget, opInterrupt:
+		return nil, 0, false
+
+	case opLookup:
+		name := cString(body)
+		attr, err := s.fs.Lookup(nodeid, name)
+		if err != nil {
+			return nil, errno(err), true
+		}
+		out := make([]byte, 40, 128)
+		order.PutUint64(out[0:], attr.Ino)
+		order.PutUint64(out[16:], uint64(cacheTimeout/time.Second))
+		order.PutUint64(out[24:], uint64(cacheTimeout/time.Second))
+		return s.appendAttr(out, attr), 0, true
+
+	case opGetattr:
+		attr, err := s.fs.Attr(nodeid)
+		if err != nil {
+			return nil, errno(err), true
+		}
+		out := make([]byte, 16, 104)
+		order.PutUint64(out[0:], uint64(cacheTimeout/time.Second))
+		return s.appendAttr(out, attr), 0, true
+
+	case opReadlink:
+		target, err := s.fs.Readlink(nodeid)
+		if err != nil {
+			return nil, errno(err), true
+		}
+		return []byte(target), 0, true
+
+	case opOpen, opOpendir:
+		if len(body) >= 4 && order.Uint32(body)&syscall.O_ACCMODE != syscall.O_RDONLY {
+			return nil, syscall.EROFS, true
+		}
+		out := make([]byte, 16)
+		if opcode == opOpen {
+			order.PutUint32(out[8:], openKeepCache)
+		}
+		return out, 0, true
+
+	case opRead:
+		if len(body) < 24 {
+			return nil, syscall.EINVAL, true
+		}
+		off := order.Uint64(body[8:])
+		size := order.Uint32(body[16:])
+		data := make([]byte, size)
+		n, err := s.fs.ReadAt(nodeid, data, int64(off))
+		if err != nil && err != io.EOF {
+			return nil, errno(err), true
+		}
+		return data[:n], 0, true
+
+	case opReaddir:
+		if len(body) < 24 {
+			return nil, syscall.EINVAL, true
+		}
+		entries, err := s.fs.ReadDir(nodeid)
+		if err != nil {
+			return nil, errno(err), true
+		}
+		return encodeDirents(entries, order.Uint64(body[8:]), int(order.Uint32(body[16:]))), 0, true
+
+	case opStatfs:
+		out := make([]byte, 80)
+		order.PutUint32(out[40:], 4096) // bsize
+		order.PutUint32(out[44:], 255)  // namelen
+		order.PutUint32(out[48:], 4096) // frsize
+		return out, 0, true
+
+	case opAccess:
+		if len(body) >= 4 && order.Uint32(body)&2 != 0 { // W_OK
+			return nil, syscall.EROFS, true
+		}
+		return nil, 0, true
+
+	case opRelease:
+		s.fs.Release(nodeid)
+		return nil, 0, true
+
+	case opFlush, opReleasedir, opDestroy:
+		return nil, 0, true
+	}
+	return nil, syscall.ENOSYS, true
+}
+
+// appendAttr appends the fuse_attr encoding of attr to out.
+func (s *server) appendAttr(out []byte, attr Attr) []byte {
+	b := make([]byte, 88)
+	mtime := attr.Mtime.Unix()
+	nsec := uint32(attr.Mtime.Nanosecond())
+	nlink := uint32(1)
+	if attr.Mode.IsDir() {
+		nlink = 2
+	}
+	order.PutUint64(b[0:], attr.Ino)
+	order.PutUint64(b[8:], attr.Size)
+	order.PutUint64(b[16:], (attr.Size+511)/512)
+	for i := 24; i < 48; i += 8 { // atime, mtime, ctime
+		order.PutUint64(b[i:], uint64(mtime))
+	}
+	for i := 48; i < 60; i += 4 {
+		order.PutUint32(b[i:], nsec)
+	}
+	order.PutUint32(b[60:], unixMode(attr.Mode))
+	order.PutUint32(b[64:], nlink)
+	order.PutUint32(b[68:], s.uid)
+	order.PutUint32(b[72:], s.gid)
+	order.PutUint32(b[80:], 4096) // blksize
+	return append(out, b...)
+}
+
+// unixMode converts a file mode to its st_mode bits.
+func unixMode(mode os.FileMode) uint32 {
+	bits := uint32(mode.Perm())
+	switch {
+	case mode.IsDir():
+		bits |= syscall.S_IFDIR
+	case mode&os.ModeSymlink != 0:
+		bits |= syscall.S_IFLNK
+	default:
+		bits |= syscall.S_IFREG
+	}
+	return bits
+}
+
+// encodeDirents encodes the entries from index offset as fuse_dirent
+// records that fit in size bytes. Each record's offset is the index of the
+// entry after it.
+func encodeDirents(entries []Dirent, offset uint64, size int) []byte {
+	var out []byte
+	for i := offset; i < uint64(len(entries)); i++ {
+		e := entries[i]
+		recLen := (24 + len(e.Name) + 7) &^ 7
+		if len(out)+recLen > size {
+			break
+		}
+		rec := make([]byte, recLen)
+		order.PutUint64(rec[0:], e.Ino)
+		order.PutUint64(rec[8:], i+1)
+		order.PutUint32(rec[16:], uint32(len(e.Name)))
+		order.PutUint32(rec[20:], unixMode(e.Mode)>>12) // DT_* type
+		copy(rec[24:], e.Name)
+		out = append(out, rec...)
+	}
+	return out
+}
+
+// cString returns the NUL-terminated string at the start of b.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
@@ -0,0 +1,150 @@
+// ⭐ MOUNT-001: FUSE protocol tests - Requests over a packet socket pair - 🧪
+package fuse
+
+import (
+	"io/fs"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// memFS is a root directory holding hello.txt.
+type memFS struct{ released []uint64 }
+
+func (m *memFS) Attr(ino uint64) (Attr, error) {
+	switch ino {
+	case RootID:
+		return Attr{Ino: RootID, Mode: os.ModeDir | 0o555}, nil
+	case 2:
+		return Attr{Ino: 2, Size: 5, Mode: 0o444, Mtime: time.Unix(1700000000, 0)}, nil
+	}
+	return Attr{}, fs.ErrNotExist
+}
+
+func (m *memFS) Lookup(parent uint64, name string) (Attr, error) {
+	if parent == RootID && name == "hello.txt" {
+		return m.Attr(2)
+	}
+	return Attr{}, fs.ErrNotExist
+}
+
+func (m *memFS) ReadDir(ino uint64) ([]Dirent, error) {
+	return []Dirent{{Name: "hello.txt", Ino: 2, Mode: 0o444}}, nil
+}
+
+func (m *memFS) ReadAt(ino uint64, p []byte, off int64) (int, error) {
+	return copy(p, "hello"[off:]), nil
+}
+
+func (m *memFS) Readlink(ino uint64) (string, error) { return "", syscall.EINVAL }
+
+func (m *memFS) Release(ino uint64) { m.released = append(m.released, ino) }
+
+// kernel plays the kernel side of a connection.
+type kernel struct {
+	t      *testing.T
+	dev    *os.File
+	unique uint64
+}
+
+// startServer serves fsys over a packet socket pair, which keeps message
+// boundaries like /dev/fuse.
+func startServer(t *testing.T, fsys FS) (*kernel, chan error) {
+	t.Helper()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET, 0)
+	if err != nil {
+		t.Skipf("packet sockets unavailable: %v", err)
+	}
+	dev := os.NewFile(uintptr(fds[1]), "dev")
+	done := make(chan error, 1)
+	go func() { done <- serve(dev, fsys) }()
+	k := &kernel{t: t, dev: os.NewFile(uintptr(fds[0]), "kernel")}
+	t.Cleanup(func() { k.dev.Close(); dev.Close() })
+	return k, done
+}
+
+// call sends a request and returns the error number and reply body.
+func (k *kernel) call(opcode uint32, nodeid uint64, body []byte) (int32, []byte) {
+	k.t.Helper()
+	k.unique++
+	req := make([]byte, inHeaderSize, inHeaderSize+len(body))
+	req = append(req, body...)
+	order.PutUint32(req[0:], uint32(len(req)))
+	order.PutUint32(req[4:], opcode)
+	order.PutUint64(req[8:], k.unique)
+	order.PutUint64(req[16:], nodeid)
+	if _, err := k.dev.Write(req); err != nil {
+		k.t.Fatal(err)
+	}
+	out := make([]byte, readBufferSize)
+	n, err := k.dev.Read(out)
+	if err != nil {
+		k.t.Fatal(err)
+	}
+	if order.Uint64(out[8:]) != k.unique || int(order.Uint32(out[0:])) != n {
+		k.t.Fatalf("Malformed reply header %x", out[:outHeaderSize])
+	}
+	return int32(order.Uint32(out[4:])), out[outHeaderSize:n]
+}
+
+func TestServe(t *testing.T) {
+	fsys := &memFS{}
+	k, done := startServer(t, fsys)
+
+	initReq := make([]byte, 16)
+	order.PutUint32(initReq[0:], 7)
+	order.PutUint32(initReq[4:], 38)
+	if e, out := k.call(opInit, 0, initReq); e != 0 || len(out) != 64 || order.Uint32(out[0:]) != protocolMajor {
+		t.Fatalf("Unexpected INIT reply %d %x", e, out)
+	}
+
+	e, out := k.call(opLookup, RootID, []byte("hello.txt\x00"))
+	if e != 0 || len(out) != 128 || order.Uint64(out[0:]) != 2 || order.Uint64(out[40+8:]) != 5 {
+		t.Errorf("Unexpected LOOKUP reply %d %x", e, out)
+	}
+	if mode := order.Uint32(out[40+60:]); mode != syscall.S_IFREG|0o444 {
+		t.Errorf("Expected a read-only regular file, got mode %o", mode)
+	}
+	if e, _ := k.call(opLookup, RootID, []byte("missing\x00")); e != -int32(syscall.ENOENT) {
+		t.Errorf("Expected ENOENT, got %d", e)
+	}
+
+	if e, out := k.call(opGetattr, RootID, make([]byte, 16)); e != 0 || order.Uint32(out[16+60:]) != syscall.S_IFDIR|0o555 {
+		t.Errorf("Unexpected GETATTR reply %d %x", e, out)
+	}
+
+	if e, _ := k.call(opOpen, 2, []byte{byte(syscall.O_WRONLY), 0, 0, 0, 0, 0, 0, 0}); e != -int32(syscall.EROFS) {
+		t.Errorf("Expected writable opens to fail with EROFS, got %d", e)
+	}
+	read := make([]byte, 40)
+	order.PutUint64(read[8:], 1)
+	order.PutUint32(read[16:], 100)
+	if e, out := k.call(opRead, 2, read); e != 0 || string(out) != "ello" {
+		t.Errorf("Unexpected READ reply %d %q", e, out)
+	}
+	k.call(opRelease, 2, make([]byte, 24))
+	if len(fsys.released) != 1 || fsys.released[0] != 2 {
+		t.Errorf("Expected inode 2 to be released, got %v", fsys.released)
+	}
+
+	readdir := make([]byte, 40)
+	order.PutUint32(readdir[16:], 4096)
+	e, out = k.call(opReaddir, RootID, readdir)
+	if e != 0 || len(out) != 40 || order.Uint64(out[8:]) != 1 || string(out[24:33]) != "hello.txt" {
+		t.Errorf("Unexpected READDIR reply %d %x", e, out)
+	}
+	order.PutUint64(readdir[8:], 1)
+	if e, out := k.call(opReaddir, RootID, readdir); e != 0 || len(out) != 0 {
+		t.Errorf("Expected the listing to end, got %d %x", e, out)
+	}
+
+	if e, _ := k.call(99, RootID, nil); e != -int32(syscall.ENOSYS) {
+		t.Errorf("Expected ENOSYS for unknown requests, got %d", e)
+	}
+
+	k.call(opDestroy, 0, nil)
+	if err := <-done; err != nil {
+		t.Errorf("Expected serve to end after DESTROY, got %v", err)
+	}
+}
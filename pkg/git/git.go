@@ -11,9 +11,14 @@
 package git
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
+	"os"
 	"os/exec"
 	"strings"
+	"time"
 )
 
 // ⭐ EXTRACT-004: Git operation context and configuration - 🔧
@@ -28,6 +33,8 @@ type Config struct {
 	// Git command configuration
 	Command          string // Git command path (default: "git")
 	WorkingDirectory string // Working directory for Git operations (default: ".")
+	// 🔶 GIT-009: Implementation used for Git operations
+	Provider string // "cli", "native" or "auto" (default: "auto"; empty means "cli")
 
 	// Git behavior settings
 	RequireCleanRepo  bool // Fail operations if repository is dirty (default: false)
@@ -38,6 +45,7 @@ type Config struct {
 	IncludeBranch bool // Include branch name in operations (default: true)
 	IncludeHash   bool // Include commit hash in operations (default: true)
 	IncludeStatus bool // Include working directory status (default: true)
+	IncludeTags   bool // Include describe output and nearest tag (default: false)
 
 	// Git command timeouts and limits
 	CommandTimeout    string // Timeout for Git commands (default: "30s")
@@ -57,6 +65,7 @@ func DefaultConfig() *Config {
 		ShowDirtyStatus:   false,
 		Command:           "git",
 		WorkingDirectory:  ".",
+		Provider:          ProviderAuto,
 		RequireCleanRepo:  false,
 		AutoDetectRepo:    true,
 		IncludeSubmodules: false,
@@ -71,18 +80,48 @@ func DefaultConfig() *Config {
 	}
 }
 
+// 🔶 GIT-008: Classified Git failures - 🛡️
+// Errors returned by Repository methods wrap one of these, so callers can
+// tell expected conditions from real failures with errors.Is.
+var (
+	// ErrNotRepository means the directory is not inside a Git work tree.
+	ErrNotRepository = errors.New("not a git repository")
+	// ErrTimeout means a Git command ran longer than CommandTimeout.
+	ErrTimeout = errors.New("git command timed out")
+	// ErrGitNotFound means the Git executable could not be found.
+	ErrGitNotFound = errors.New("git executable not found")
+)
+
+// defaultCommandTimeout applies when CommandTimeout is empty or invalid.
+const defaultCommandTimeout = 30 * time.Second
+
 // ⭐ EXTRACT-004: Git error handling structure - 🔧
 // GitError represents an error that occurred during Git operations.
 // It includes the operation that failed and the underlying error.
 type GitError struct {
 	Operation string
 	Err       error
+	// 🔶 GIT-008: Trimmed standard error of the failed command, if any
+	Stderr string
 }
 
 func (e *GitError) Error() string {
+	if e.Stderr != "" {
+		return fmt.Sprintf("git %s failed: %v: %s", e.Operation, e.Err, e.Stderr)
+	}
 	return fmt.Sprintf("git %s failed: %v", e.Operation, e.Err)
 }
 
+// Unwrap returns the underlying error, including the classification sentinels.
+func (e *GitError) Unwrap() error {
+	return e.Err
+}
+
+// notRepositoryError returns the error for an operation outside a work tree.
+func notRepositoryError(operation string) error {
+	return &GitError{Operation: operation, Err: ErrNotRepository}
+}
+
 // ⭐ EXTRACT-004: Git information structure - 🔧
 // Info represents Git repository information
 type Info struct {
@@ -92,6 +131,9 @@ type Info struct {
 	IsRepo      bool
 	IsSubmodule bool
 	Submodules  []SubmoduleInfo
+	// 🔶 GIT-007: Tag information, empty when HEAD has no reachable tag
+	Describe string // Output of git describe --tags --dirty
+	Tag      string // Nearest reachable tag
 }
 
 // 🔶 GIT-004: Git submodule information structure - 🔧
@@ -126,6 +168,11 @@ type Repository interface {
 	GetSubmodules() ([]SubmoduleInfo, error)
 	// GetSubmoduleStatus returns the status of a specific submodule
 	GetSubmoduleStatus(path string) (string, error)
+	// 🔶 GIT-007: Git tag interface methods - 🔍
+	// GetDescribe returns the git describe --tags --dirty output for HEAD
+	GetDescribe() (string, error)
+	// GetNearestTag returns the nearest tag reachable from HEAD
+	GetNearestTag() (string, error)
 }
 
 // ⭐ EXTRACT-004: Git repository implementation - 🔧
@@ -136,51 +183,108 @@ type Repo struct {
 
 // NewRepository creates a new Git repository instance with default configuration
 func NewRepository() Repository {
-	return &Repo{config: DefaultConfig()}
+	return newProviderRepository(DefaultConfig())
 }
 
-// NewRepositoryWithConfig creates a new Git repository instance with custom configuration
+// NewRepositoryWithConfig creates a new Git repository instance with custom configuration.
+// 🔶 GIT-009: config.Provider selects the command-line or pure-Go implementation
 func NewRepositoryWithConfig(config *Config) Repository {
-	return &Repo{config: config}
+	return newProviderRepository(config)
+}
+
+// gitCommand returns the configured Git executable.
+func (r *Repo) gitCommand() string {
+	// 🔶 GIT-005: Use new Command field with legacy GitCommand fallback
+	if r.config.Command != "" {
+		return r.config.Command
+	}
+	if r.config.GitCommand != "" {
+		return r.config.GitCommand // Legacy fallback
+	}
+	return "git" // Ultimate fallback
 }
 
 // ⭐ EXTRACT-004: Generalized Git command execution framework - 🔧
 // 🔶 GIT-005: Enhanced Git command execution with new configuration - 📝
 // executeGitCommand runs a Git command with the configured parameters
 func (r *Repo) executeGitCommand(args ...string) (string, error) {
-	// 🔶 GIT-005: Use new Command field with legacy GitCommand fallback
-	gitCmd := r.config.Command
-	if gitCmd == "" {
-		gitCmd = r.config.GitCommand // Legacy fallback
-	}
-	if gitCmd == "" {
-		gitCmd = "git" // Ultimate fallback
+	gitCmd := r.gitCommand()
+
+	// 🔶 GIT-008: Bound every command and never wait for credentials
+	ctx := context.Background()
+	if timeout := r.commandTimeout(); timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
 	}
 
-	cmd := exec.Command(gitCmd, args...)
+	cmd := exec.CommandContext(ctx, gitCmd, args...)
 	cmd.Dir = r.config.WorkingDirectory
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	// Children of git (ssh, credential helpers) may hold the pipes open
+	// after git itself is killed, so bound the wait for them as well
+	cmd.WaitDelay = time.Second
 	out, err := cmd.Output()
 	if err != nil {
-		return "", &GitError{
-			Operation: strings.Join(args, " "),
-			Err:       err,
-		}
+		return "", classifyCommandError(ctx, strings.Join(args, " "), err, stderr.String())
 	}
 	return strings.TrimSpace(string(out)), nil
 }
 
+// commandTimeout parses CommandTimeout. A zero or negative duration disables
+// the timeout; empty or invalid values use the default.
+func (r *Repo) commandTimeout() time.Duration {
+	d, err := time.ParseDuration(r.config.CommandTimeout)
+	if err != nil {
+		return defaultCommandTimeout
+	}
+	return d
+}
+
+// 🔶 GIT-008: Git failure classification - 🛡️
+// classifyCommandError wraps a failed command's error with the matching
+// sentinel: ErrTimeout, ErrGitNotFound or ErrNotRepository.
+func classifyCommandError(ctx context.Context, operation string, err error, stderr string) error {
+	stderr = strings.TrimSpace(stderr)
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		err = fmt.Errorf("%w: %v", ErrTimeout, err)
+	case errors.Is(err, exec.ErrNotFound):
+		err = fmt.Errorf("%w: %v", ErrGitNotFound, err)
+	case strings.Contains(strings.ToLower(stderr), "not a git repository"):
+		err = fmt.Errorf("%w: %v", ErrNotRepository, err)
+	}
+	return &GitError{Operation: operation, Err: err, Stderr: stderr}
+}
+
+// 🔶 GIT-008: Repository check with classified errors - 🔍
+// CheckRepository returns nil inside a work tree, an error wrapping
+// ErrNotRepository outside one, and ErrTimeout or ErrGitNotFound when Git
+// itself could not answer.
+func (r *Repo) CheckRepository() error {
+	out, err := r.executeGitCommand("rev-parse", "--is-inside-work-tree")
+	if err != nil {
+		return err
+	}
+	if out != "true" {
+		return notRepositoryError("rev-parse --is-inside-work-tree")
+	}
+	return nil
+}
+
 // ⭐ EXTRACT-004: Git repository detection implementation - 🔍
 // IsRepository checks if the configured directory is a Git repository
 func (r *Repo) IsRepository() bool {
-	out, err := r.executeGitCommand("rev-parse", "--is-inside-work-tree")
-	return err == nil && out == "true"
+	return r.CheckRepository() == nil
 }
 
 // ⭐ EXTRACT-004: Git branch extraction implementation - 🔍
 // GetBranch returns the current Git branch name
 func (r *Repo) GetBranch() (string, error) {
 	if !r.IsRepository() {
-		return "", &GitError{Operation: "branch detection", Err: fmt.Errorf("not a git repository")}
+		return "", notRepositoryError("branch detection")
 	}
 	return r.executeGitCommand("rev-parse", "--abbrev-ref", "HEAD")
 }
@@ -189,7 +293,7 @@ func (r *Repo) GetBranch() (string, error) {
 // GetShortHash returns the short commit hash of the current HEAD
 func (r *Repo) GetShortHash() (string, error) {
 	if !r.IsRepository() {
-		return "", &GitError{Operation: "hash extraction", Err: fmt.Errorf("not a git repository")}
+		return "", notRepositoryError("hash extraction")
 	}
 	return r.executeGitCommand("rev-parse", "--short", "HEAD")
 }
@@ -198,7 +302,7 @@ func (r *Repo) GetShortHash() (string, error) {
 // IsWorkingDirectoryClean checks if the Git working directory is clean
 func (r *Repo) IsWorkingDirectoryClean() (bool, error) {
 	if !r.IsRepository() {
-		return false, &GitError{Operation: "status check", Err: fmt.Errorf("not a git repository")}
+		return false, notRepositoryError("status check")
 	}
 
 	out, err := r.executeGitCommand("status", "--porcelain")
@@ -248,6 +352,12 @@ func (r *Repo) GetInfoWithStatus() (*Info, error) {
 		}
 	}
 
+	// 🔶 GIT-007: Tag information is best effort; untagged repositories are common
+	if r.config.IncludeTags {
+		info.Describe, _ = r.GetDescribe()
+		info.Tag, _ = r.GetNearestTag()
+	}
+
 	// 🔶 GIT-004: Add submodule information to Git info - 🔧
 	// 🔶 GIT-005: Use IncludeSubmodules configuration option
 	if r.config.IncludeSubmodules {
@@ -265,6 +375,26 @@ func (r *Repo) GetInfoWithStatus() (*Info, error) {
 	return info, nil
 }
 
+// 🔶 GIT-007: Git describe extraction implementation - 🔍
+// GetDescribe returns the output of git describe --tags --dirty for HEAD.
+// It returns an error when no tag is reachable from HEAD.
+func (r *Repo) GetDescribe() (string, error) {
+	if !r.IsRepository() {
+		return "", notRepositoryError("describe")
+	}
+	return r.executeGitCommand("describe", "--tags", "--dirty")
+}
+
+// 🔶 GIT-007: Nearest tag extraction implementation - 🔍
+// GetNearestTag returns the nearest tag reachable from HEAD.
+// It returns an error when the repository has no reachable tags.
+func (r *Repo) GetNearestTag() (string, error) {
+	if !r.IsRepository() {
+		return "", notRepositoryError("tag detection")
+	}
+	return r.executeGitCommand("describe", "--tags", "--abbrev=0")
+}
+
 // 🔶 GIT-004: Git submodule detection implementation - 🔍
 // IsSubmodule checks if the current directory is a Git submodule
 func (r *Repo) IsSubmodule() (bool, error) {
@@ -288,7 +418,7 @@ func (r *Repo) IsSubmodule() (bool, error) {
 // GetSubmodules returns information about all submodules in the repository
 func (r *Repo) GetSubmodules() ([]SubmoduleInfo, error) {
 	if !r.IsRepository() {
-		return nil, &GitError{Operation: "submodule listing", Err: fmt.Errorf("not a git repository")}
+		return nil, notRepositoryError("submodule listing")
 	}
 
 	// Get submodule information using git submodule status
@@ -321,7 +451,7 @@ func (r *Repo) GetSubmodules() ([]SubmoduleInfo, error) {
 // GetSubmoduleStatus returns the status of a specific submodule
 func (r *Repo) GetSubmoduleStatus(path string) (string, error) {
 	if !r.IsRepository() {
-		return "", &GitError{Operation: "submodule status", Err: fmt.Errorf("not a git repository")}
+		return "", notRepositoryError("submodule status")
 	}
 
 	out, err := r.executeGitCommand("submodule", "status", path)
@@ -421,6 +551,21 @@ func IsGitRepository(dir string) bool {
 	return repo.IsRepository()
 }
 
+// 🔶 GIT-008: Git information with classified errors - 🔍
+// InfoWithStatus returns Git information for config.WorkingDirectory. Unlike
+// the convenience functions below, failures are returned so callers can
+// distinguish ErrNotRepository from ErrTimeout and ErrGitNotFound.
+func InfoWithStatus(config *Config) (*Info, error) {
+	if err := ValidateProvider(config.Provider); err != nil {
+		return &Info{}, &GitError{Operation: "provider selection", Err: err}
+	}
+	repo := newProviderRepository(config)
+	if err := repo.CheckRepository(); err != nil {
+		return &Info{}, err
+	}
+	return repo.GetInfoWithStatus()
+}
+
 // GetGitBranch returns the current Git branch name for the given directory
 func GetGitBranch(dir string) string {
 	config := &Config{WorkingDirectory: dir, GitCommand: "git"}
@@ -480,6 +625,24 @@ func GetGitInfoWithStatus(dir string) (branch, hash string, isClean bool) {
 	return info.Branch, info.Hash, info.IsClean
 }
 
+// 🔶 GIT-007: Convenience function for Git tag information - 🔧
+
+// GetGitTagInfo returns the describe output and nearest tag for the given directory.
+// Both values are empty when the directory is not a repository or has no tags.
+func GetGitTagInfo(dir string) (describe, tag string) {
+	config := &Config{WorkingDirectory: dir, GitCommand: "git"}
+	repo := &Repo{config: config}
+	describe, err := repo.GetDescribe()
+	if err != nil {
+		return "", ""
+	}
+	tag, err = repo.GetNearestTag()
+	if err != nil {
+		return describe, ""
+	}
+	return describe, tag
+}
+
 // 🔶 GIT-004: Convenience functions for Git submodule operations - 🔧
 
 // IsGitSubmodule checks if the given directory is a Git submodule
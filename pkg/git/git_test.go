@@ -2,6 +2,7 @@
 package git
 
 import (
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -9,6 +10,7 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 )
 
 // TestGitIntegration tests the Git integration functionality
@@ -497,6 +499,77 @@ func TestGitError(t *testing.T) {
 	})
 }
 
+// 🔶 GIT-008: Git failure classification testing - 🧪
+// TestGitErrorClassification tests the typed errors and command timeout
+func TestGitErrorClassification(t *testing.T) {
+	tmpDir := t.TempDir()
+
+	t.Run("NotRepository", func(t *testing.T) {
+		if !isGitAvailable() {
+			t.Skip("Git not available")
+		}
+		repo := &Repo{config: &Config{WorkingDirectory: tmpDir, Command: "git"}}
+		if err := repo.CheckRepository(); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("Expected ErrNotRepository, got %v", err)
+		}
+		if _, err := repo.GetBranch(); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("Expected ErrNotRepository from GetBranch, got %v", err)
+		}
+		if _, err := InfoWithStatus(&Config{WorkingDirectory: tmpDir, Command: "git"}); !errors.Is(err, ErrNotRepository) {
+			t.Errorf("Expected ErrNotRepository from InfoWithStatus, got %v", err)
+		}
+	})
+
+	t.Run("BinaryMissing", func(t *testing.T) {
+		repo := &Repo{config: &Config{WorkingDirectory: tmpDir, Command: "bkpdir-no-such-git"}}
+		err := repo.CheckRepository()
+		if !errors.Is(err, ErrGitNotFound) {
+			t.Errorf("Expected ErrGitNotFound, got %v", err)
+		}
+		var gitErr *GitError
+		if !errors.As(err, &gitErr) {
+			t.Errorf("Expected GitError, got %T", err)
+		}
+	})
+
+	// A fake git that records its prompt setting and then hangs
+	fakeGit := filepath.Join(tmpDir, "fake-git")
+	script := "#!/bin/sh\necho \"$GIT_TERMINAL_PROMPT\" > \"$0.env\"\nsleep 5\n"
+	if err := os.WriteFile(fakeGit, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Timeout", func(t *testing.T) {
+		repo := &Repo{config: &Config{WorkingDirectory: tmpDir, Command: fakeGit, CommandTimeout: "100ms"}}
+		start := time.Now()
+		err := repo.CheckRepository()
+		if !errors.Is(err, ErrTimeout) {
+			t.Errorf("Expected ErrTimeout, got %v", err)
+		}
+		if elapsed := time.Since(start); elapsed > 3*time.Second {
+			t.Errorf("Timeout not enforced, took %v", elapsed)
+		}
+		env, err := os.ReadFile(fakeGit + ".env")
+		if err != nil || strings.TrimSpace(string(env)) != "0" {
+			t.Errorf("Expected GIT_TERMINAL_PROMPT=0, got %q (%v)", env, err)
+		}
+	})
+
+	t.Run("TimeoutParsing", func(t *testing.T) {
+		for value, want := range map[string]time.Duration{
+			"":      defaultCommandTimeout,
+			"bogus": defaultCommandTimeout,
+			"5s":    5 * time.Second,
+			"0":     0,
+		} {
+			repo := &Repo{config: &Config{CommandTimeout: value}}
+			if got := repo.commandTimeout(); got != want {
+				t.Errorf("commandTimeout(%q) = %v, want %v", value, got, want)
+			}
+		}
+	})
+}
+
 // Helper functions for testing
 
 func isGitAvailable() bool {
@@ -722,3 +795,61 @@ func TestGitSubmodules(t *testing.T) {
 		}
 	})
 }
+
+// TestGitTags tests describe and nearest tag extraction
+func TestGitTags(t *testing.T) {
+	// 🔶 GIT-007: Git tag extraction validation - 🧪
+	if !isGitAvailable() {
+		t.Skip("Git not available, skipping git tag tests")
+	}
+
+	gitDir := t.TempDir()
+	runGitCommand(t, gitDir, "init")
+	runGitCommand(t, gitDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, gitDir, "config", "user.name", "Test User")
+
+	testFile := filepath.Join(gitDir, "test.txt")
+	if err := os.WriteFile(testFile, []byte("test content"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	runGitCommand(t, gitDir, "add", "test.txt")
+	runGitCommand(t, gitDir, "commit", "-m", "Initial commit")
+
+	repo := NewRepositoryWithConfig(&Config{WorkingDirectory: gitDir, GitCommand: "git", IncludeTags: true})
+
+	t.Run("Untagged", func(t *testing.T) {
+		if _, err := repo.GetNearestTag(); err == nil {
+			t.Error("Expected error for repository without tags")
+		}
+		describe, tag := GetGitTagInfo(gitDir)
+		if describe != "" || tag != "" {
+			t.Errorf("Expected empty tag info, got describe=%q tag=%q", describe, tag)
+		}
+	})
+
+	runGitCommand(t, gitDir, "tag", "v1.2.0")
+
+	t.Run("Tagged", func(t *testing.T) {
+		info, err := repo.GetInfoWithStatus()
+		if err != nil {
+			t.Fatalf("GetInfoWithStatus failed: %v", err)
+		}
+		if info.Tag != "v1.2.0" || info.Describe != "v1.2.0" {
+			t.Errorf("Expected tag v1.2.0, got describe=%q tag=%q", info.Describe, info.Tag)
+		}
+	})
+
+	if err := os.WriteFile(testFile, []byte("changed"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("DirtyAfterTag", func(t *testing.T) {
+		describe, tag := GetGitTagInfo(gitDir)
+		if describe != "v1.2.0-dirty" {
+			t.Errorf("Expected describe v1.2.0-dirty, got %q", describe)
+		}
+		if tag != "v1.2.0" {
+			t.Errorf("Expected tag v1.2.0, got %q", tag)
+		}
+	})
+}
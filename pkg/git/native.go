@@ -0,0 +1,523 @@
+// This file is part of bkpdir
+//
+// Package git provides the native Git provider, a pure-Go reader of the
+// repository state in the .git directory for systems without the git
+// executable. It does not use a Git library: it parses HEAD, refs and the
+// index itself and supports only the index formats described at readIndex.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// 🔶 GIT-009: Git provider selection - 🔧
+const (
+	// ProviderCLI runs the git executable for every operation.
+	ProviderCLI = "cli"
+	// ProviderNative reads the .git directory in-process without the git executable.
+	ProviderNative = "native"
+	// ProviderGoGit is accepted as another name for ProviderNative.
+	ProviderGoGit = "gogit"
+	// ProviderAuto uses the git executable when it is on PATH and ProviderNative otherwise.
+	ProviderAuto = "auto"
+)
+
+// ErrUnsupported is wrapped by errors for operations the pure-Go provider
+// cannot answer, such as tag description and submodule status.
+var ErrUnsupported = errors.New("not supported by the native provider")
+
+// shortHashLength matches the default abbreviation of git rev-parse --short.
+const shortHashLength = 7
+
+// ValidateProvider returns an error for unknown provider names. An empty
+// provider is accepted and means ProviderCLI.
+func ValidateProvider(provider string) error {
+	switch provider {
+	case "", ProviderCLI, ProviderNative, ProviderGoGit, ProviderAuto:
+		return nil
+	}
+	return fmt.Errorf("invalid git provider %q: must be %s, %s (or %s) or %s", provider, ProviderCLI, ProviderNative, ProviderGoGit, ProviderAuto)
+}
+
+// checkedRepository is a Repository that can report why it is not usable.
+type checkedRepository interface {
+	Repository
+	CheckRepository() error
+}
+
+// 🔶 GIT-009: Provider resolution - 🔧
+// newProviderRepository returns the Repository implementation selected by
+// config.Provider.
+func newProviderRepository(config *Config) checkedRepository {
+	switch config.Provider {
+	case ProviderNative, ProviderGoGit:
+		return &NativeRepo{config: config}
+	case ProviderAuto:
+		repo := &Repo{config: config}
+		if _, err := exec.LookPath(repo.gitCommand()); err != nil {
+			return &NativeRepo{config: config}
+		}
+		return repo
+	}
+	return &Repo{config: config}
+}
+
+// 🔶 GIT-009: Pure-Go repository implementation - 🔧
+// NativeRepo implements the Repository interface by reading HEAD, refs and
+// the index from the .git directory. Dirty detection compares tracked files
+// with the index; changes that are only staged and untracked files are not
+// detected, since that needs the object database and ignore rules.
+type NativeRepo struct {
+	config *Config
+}
+
+// NewNativeRepository creates a pure-Go repository reader for config.WorkingDirectory.
+func NewNativeRepository(config *Config) Repository {
+	return &NativeRepo{config: config}
+}
+
+// nativeLayout locates the parts of a repository used by NativeRepo.
+type nativeLayout struct {
+	workTree  string // top of the working tree
+	gitDir    string // .git directory, or the per-worktree directory
+	commonDir string // directory holding shared refs and objects
+}
+
+// locate finds the repository containing the working directory by walking up
+// to the nearest .git directory or gitdir file.
+func (r *NativeRepo) locate() (*nativeLayout, error) {
+	dir := r.config.WorkingDirectory
+	if dir == "" {
+		dir = "."
+	}
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, &GitError{Operation: "repository detection", Err: err}
+	}
+
+	for {
+		candidate := filepath.Join(dir, ".git")
+		if info, err := os.Stat(candidate); err == nil {
+			gitDir := candidate
+			if !info.IsDir() {
+				if gitDir, err = readGitFile(candidate); err != nil {
+					return nil, &GitError{Operation: "repository detection", Err: err}
+				}
+			}
+			if _, err := os.Stat(filepath.Join(gitDir, "HEAD")); err != nil {
+				return nil, notRepositoryError("repository detection")
+			}
+			layout := &nativeLayout{workTree: dir, gitDir: gitDir, commonDir: gitDir}
+			if common, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+				layout.commonDir = resolveGitPath(gitDir, strings.TrimSpace(string(common)))
+			}
+			return layout, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return nil, notRepositoryError("repository detection")
+		}
+		dir = parent
+	}
+}
+
+// readGitFile resolves a "gitdir: <path>" file used by worktrees and submodules.
+func readGitFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	line := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(line, "gitdir:") {
+		return "", fmt.Errorf("invalid gitdir file %s", path)
+	}
+	return resolveGitPath(filepath.Dir(path), strings.TrimSpace(strings.TrimPrefix(line, "gitdir:"))), nil
+}
+
+func resolveGitPath(base, path string) string {
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path)
+	}
+	return filepath.Join(base, path)
+}
+
+// CheckRepository returns nil inside a work tree and an error wrapping
+// ErrNotRepository outside one.
+func (r *NativeRepo) CheckRepository() error {
+	_, err := r.locate()
+	return err
+}
+
+// IsRepository checks if the configured directory is a Git repository
+func (r *NativeRepo) IsRepository() bool {
+	return r.CheckRepository() == nil
+}
+
+// usesSHA256 reports whether the repository was created with
+// extensions.objectFormat = sha256.
+func (l *nativeLayout) usesSHA256() bool {
+	data, err := os.ReadFile(filepath.Join(l.commonDir, "config"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if ok && strings.EqualFold(strings.TrimSpace(key), "objectformat") {
+			return strings.EqualFold(strings.TrimSpace(value), "sha256")
+		}
+	}
+	return false
+}
+
+// readHead returns the symbolic ref HEAD points to, or "" and the commit
+// hash when HEAD is detached.
+func (l *nativeLayout) readHead() (ref, hash string, err error) {
+	data, err := os.ReadFile(filepath.Join(l.gitDir, "HEAD"))
+	if err != nil {
+		return "", "", err
+	}
+	head := strings.TrimSpace(string(data))
+	if strings.HasPrefix(head, "ref:") {
+		return strings.TrimSpace(strings.TrimPrefix(head, "ref:")), "", nil
+	}
+	return "", head, nil
+}
+
+// resolveRef returns the commit hash of a ref from its loose file or packed-refs.
+func (l *nativeLayout) resolveRef(ref string) (string, error) {
+	for depth := 0; depth < 5; depth++ {
+		data, err := os.ReadFile(filepath.Join(l.refDir(ref), filepath.FromSlash(ref)))
+		if err != nil {
+			return l.resolvePackedRef(ref)
+		}
+		value := strings.TrimSpace(string(data))
+		if !strings.HasPrefix(value, "ref:") {
+			return value, nil
+		}
+		ref = strings.TrimSpace(strings.TrimPrefix(value, "ref:"))
+	}
+	return "", fmt.Errorf("symbolic ref loop at %s", ref)
+}
+
+// refDir returns where a ref lives; only worktree-private refs stay in gitDir.
+func (l *nativeLayout) refDir(ref string) string {
+	if strings.HasPrefix(ref, "refs/") && !strings.HasPrefix(ref, "refs/bisect/") &&
+		!strings.HasPrefix(ref, "refs/worktree/") {
+		return l.commonDir
+	}
+	return l.gitDir
+}
+
+func (l *nativeLayout) resolvePackedRef(ref string) (string, error) {
+	f, err := os.Open(filepath.Join(l.commonDir, "packed-refs"))
+	if err != nil {
+		return "", fmt.Errorf("ref %s not found", ref)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[1] == ref {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("ref %s not found", ref)
+}
+
+// GetBranch returns the current branch name, or "HEAD" when detached,
+// matching git rev-parse --abbrev-ref HEAD.
+func (r *NativeRepo) GetBranch() (string, error) {
+	layout, err := r.locate()
+	if err != nil {
+		return "", &GitError{Operation: "branch detection", Err: errors.Unwrap(err)}
+	}
+	ref, _, err := layout.readHead()
+	if err != nil {
+		return "", &GitError{Operation: "branch detection", Err: err}
+	}
+	if ref == "" {
+		return "HEAD", nil
+	}
+	return strings.TrimPrefix(ref, "refs/heads/"), nil
+}
+
+// GetShortHash returns the abbreviated commit hash of HEAD.
+func (r *NativeRepo) GetShortHash() (string, error) {
+	layout, err := r.locate()
+	if err != nil {
+		return "", &GitError{Operation: "hash extraction", Err: errors.Unwrap(err)}
+	}
+	ref, hash, err := layout.readHead()
+	if err == nil && ref != "" {
+		hash, err = layout.resolveRef(ref)
+	}
+	if err != nil {
+		return "", &GitError{Operation: "hash extraction", Err: err}
+	}
+	if len(hash) < shortHashLength {
+		return "", &GitError{Operation: "hash extraction", Err: fmt.Errorf("invalid commit hash %q", hash)}
+	}
+	return hash[:shortHashLength], nil
+}
+
+// IsWorkingDirectoryClean compares every tracked file with its index entry.
+// Files whose size and modification time match the index are trusted like
+// git does; others are hashed as blobs.
+func (r *NativeRepo) IsWorkingDirectoryClean() (bool, error) {
+	layout, err := r.locate()
+	if err != nil {
+		return false, &GitError{Operation: "status check", Err: errors.Unwrap(err)}
+	}
+	newHash, hashSize := sha1.New, sha1.Size
+	if layout.usesSHA256() {
+		newHash, hashSize = sha256.New, sha256.Size
+	}
+	entries, err := readIndex(filepath.Join(layout.gitDir, "index"), hashSize)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, &GitError{Operation: "status check", Err: err}
+	}
+
+	for _, entry := range entries {
+		changed, err := entry.changedIn(layout.workTree, newHash)
+		if err != nil {
+			return false, &GitError{Operation: "status check", Err: err}
+		}
+		if changed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// GetInfo returns complete Git repository information
+func (r *NativeRepo) GetInfo() (*Info, error) {
+	info := &Info{IsRepo: r.IsRepository()}
+	if !info.IsRepo {
+		return info, nil
+	}
+
+	var err error
+	if info.Branch, err = r.GetBranch(); err != nil {
+		return info, err
+	}
+	if info.Hash, err = r.GetShortHash(); err != nil {
+		return info, err
+	}
+	return info, nil
+}
+
+// GetInfoWithStatus returns Git information including working directory
+// status. Tags and submodules are not available from this provider and are
+// left empty.
+func (r *NativeRepo) GetInfoWithStatus() (*Info, error) {
+	info, err := r.GetInfo()
+	if err != nil || !info.IsRepo {
+		return info, err
+	}
+	if r.config.ShowDirtyStatus || r.config.IncludeDirtyStatus {
+		if info.IsClean, err = r.IsWorkingDirectoryClean(); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
+// IsSubmodule reports whether the work tree's .git is a file pointing into a
+// parent repository's modules directory.
+func (r *NativeRepo) IsSubmodule() (bool, error) {
+	layout, err := r.locate()
+	if err != nil {
+		return false, nil
+	}
+	return strings.Contains(filepath.ToSlash(layout.gitDir), "/modules/"), nil
+}
+
+// GetSubmodules is not supported by the pure-Go provider.
+func (r *NativeRepo) GetSubmodules() ([]SubmoduleInfo, error) {
+	return nil, &GitError{Operation: "submodule listing", Err: ErrUnsupported}
+}
+
+// GetSubmoduleStatus is not supported by the pure-Go provider.
+func (r *NativeRepo) GetSubmoduleStatus(path string) (string, error) {
+	return "", &GitError{Operation: "submodule status", Err: ErrUnsupported}
+}
+
+// GetDescribe is not supported by the pure-Go provider.
+func (r *NativeRepo) GetDescribe() (string, error) {
+	return "", &GitError{Operation: "describe", Err: ErrUnsupported}
+}
+
+// GetNearestTag is not supported by the pure-Go provider.
+func (r *NativeRepo) GetNearestTag() (string, error) {
+	return "", &GitError{Operation: "tag detection", Err: ErrUnsupported}
+}
+
+// 🔶 GIT-009: Index reader for dirty detection - 🔍
+// indexEntry holds the fields of a stage-0 index entry needed to detect changes.
+type indexEntry struct {
+	path      string
+	mode      uint32
+	size      uint32
+	mtimeSec  uint32
+	mtimeNsec uint32
+	oid       []byte
+	stage     int
+}
+
+// Index entry mode types (the upper bits of mode).
+const (
+	indexModeSymlink = 0o120000
+	indexModeGitlink = 0o160000
+	indexModeTypes   = 0o170000
+)
+
+// readIndex parses version 2 and 3 index files whose object IDs are
+// hashSize bytes long. Version 4, whose paths are prefix-compressed, is not
+// supported. Of the extensions, the optional ones (TREE, REUC, UNTR, FSMN,
+// EOIE, IEOT, …) are skipped and the sparse-index "sdir" extension is
+// accepted because its directory entries are skip-worktree entries, which
+// are ignored. A split index ("link") and other required extensions are not
+// supported, since the entries they hold are outside this file.
+func readIndex(path string, hashSize int) ([]indexEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < 12 || string(data[:4]) != "DIRC" {
+		return nil, fmt.Errorf("invalid index file %s", path)
+	}
+	version := binary.BigEndian.Uint32(data[4:8])
+	if version != 2 && version != 3 {
+		return nil, fmt.Errorf("%w: index version %d", ErrUnsupported, version)
+	}
+	count := int(binary.BigEndian.Uint32(data[8:12]))
+
+	entries := make([]indexEntry, 0, count)
+	pos := 12
+	for i := 0; i < count; i++ {
+		fixed := 40 + hashSize + 2
+		if pos+fixed > len(data) {
+			return nil, fmt.Errorf("truncated index file %s", path)
+		}
+		e := data[pos:]
+		entry := indexEntry{
+			mtimeSec:  binary.BigEndian.Uint32(e[8:12]),
+			mtimeNsec: binary.BigEndian.Uint32(e[12:16]),
+			mode:      binary.BigEndian.Uint32(e[24:28]),
+			size:      binary.BigEndian.Uint32(e[36:40]),
+			oid:       e[40 : 40+hashSize],
+		}
+		flags := binary.BigEndian.Uint16(e[40+hashSize:])
+		entry.stage = int(flags>>12) & 3
+		nameStart := fixed
+		skipWorktree := false
+		if flags&0x4000 != 0 {
+			if version < 3 {
+				return nil, fmt.Errorf("invalid index file %s", path)
+			}
+			skipWorktree = binary.BigEndian.Uint16(e[fixed:])&0x4000 != 0
+			nameStart += 2
+		}
+		nameLen := bytes.IndexByte(e[nameStart:], 0)
+		if nameLen < 0 {
+			return nil, fmt.Errorf("truncated index file %s", path)
+		}
+		entry.path = string(e[nameStart : nameStart+nameLen])
+		// Entries are NUL padded to a multiple of eight bytes
+		pos += (nameStart + nameLen + 8) &^ 7
+		if !skipWorktree {
+			entries = append(entries, entry)
+		}
+	}
+	if err := checkIndexExtensions(data[min(pos, len(data)):], hashSize); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// checkIndexExtensions walks the extensions that follow the index entries
+// and rejects the required ones readIndex does not understand. Signatures
+// starting with an upper-case letter are optional.
+func checkIndexExtensions(data []byte, hashSize int) error {
+	for len(data) >= 8+hashSize {
+		signature := string(data[:4])
+		size := int(binary.BigEndian.Uint32(data[4:8]))
+		if signature[0] < 'A' || signature[0] > 'Z' {
+			if signature != "sdir" {
+				return fmt.Errorf("%w: index extension %q", ErrUnsupported, signature)
+			}
+		}
+		if size > len(data)-8 {
+			return fmt.Errorf("truncated index extension %q", signature)
+		}
+		data = data[8+size:]
+	}
+	return nil
+}
+
+// changedIn reports whether the entry differs from the file in workTree.
+func (e *indexEntry) changedIn(workTree string, newHash func() hash.Hash) (bool, error) {
+	if e.stage != 0 {
+		return true, nil // unmerged path
+	}
+	if e.mode&indexModeTypes == indexModeGitlink {
+		return false, nil // submodules are checked in their own repository
+	}
+
+	path := filepath.Join(workTree, filepath.FromSlash(e.path))
+	info, err := os.Lstat(path)
+	if os.IsNotExist(err) {
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	isLink := e.mode&indexModeTypes == indexModeSymlink
+	if isLink != (info.Mode()&os.ModeSymlink != 0) || (!isLink && !info.Mode().IsRegular()) {
+		return true, nil
+	}
+	if !isLink && (info.Mode().Perm()&0o100 != 0) != (e.mode&0o100 != 0) {
+		return true, nil
+	}
+	if uint32(info.Size()) != e.size {
+		return true, nil
+	}
+	mtime := info.ModTime()
+	if uint32(mtime.Unix()) == e.mtimeSec && uint32(mtime.Nanosecond()) == e.mtimeNsec {
+		return false, nil
+	}
+
+	var content []byte
+	if isLink {
+		target, err := os.Readlink(path)
+		if err != nil {
+			return false, err
+		}
+		content = []byte(target)
+	} else if content, err = os.ReadFile(path); err != nil {
+		return false, err
+	}
+	h := newHash()
+	fmt.Fprintf(h, "blob %d\x00", len(content))
+	h.Write(content)
+	return !bytes.Equal(h.Sum(nil), e.oid), nil
+}
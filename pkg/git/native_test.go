@@ -0,0 +1,163 @@
+// 🔶 GIT-009: Pure-Go Git provider tests, checked against command-line Git - 🧪
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestNativeRepoMatchesCLI(t *testing.T) {
+	if !isGitAvailable() {
+		t.Skip("Git not available")
+	}
+
+	repoDir := t.TempDir()
+	runGitCommand(t, repoDir, "init", "-b", "main")
+	runGitCommand(t, repoDir, "config", "user.email", "test@example.com")
+	runGitCommand(t, repoDir, "config", "user.name", "Test User")
+	if err := os.MkdirAll(filepath.Join(repoDir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"} {
+		if err := os.WriteFile(filepath.Join(repoDir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	runGitCommand(t, repoDir, "add", ".")
+	runGitCommand(t, repoDir, "commit", "-m", "initial")
+
+	subDir := filepath.Join(repoDir, "sub")
+	cli := &Repo{config: &Config{WorkingDirectory: subDir, Command: "git", IncludeDirtyStatus: true}}
+	native := &NativeRepo{config: &Config{WorkingDirectory: subDir, IncludeDirtyStatus: true}}
+
+	compare := func(t *testing.T) {
+		t.Helper()
+		want, err := cli.GetInfoWithStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := native.GetInfoWithStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Branch != want.Branch || got.Hash != want.Hash || got.IsClean != want.IsClean {
+			t.Errorf("native info %+v, cli info %+v", got, want)
+		}
+	}
+
+	t.Run("Clean", compare)
+
+	t.Run("PackedRefs", func(t *testing.T) {
+		runGitCommand(t, repoDir, "pack-refs", "--all")
+		compare(t)
+	})
+
+	t.Run("ModifiedSameSize", func(t *testing.T) {
+		path := filepath.Join(repoDir, "a.txt")
+		if err := os.WriteFile(path, []byte("ALPHA"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		// A different mtime forces the content comparison
+		later := time.Now().Add(time.Minute)
+		if err := os.Chtimes(path, later, later); err != nil {
+			t.Fatal(err)
+		}
+		compare(t)
+		if clean, _ := native.IsWorkingDirectoryClean(); clean {
+			t.Error("Expected modified work tree to be dirty")
+		}
+		runGitCommand(t, repoDir, "checkout", "--", "a.txt")
+	})
+
+	t.Run("Deleted", func(t *testing.T) {
+		if err := os.Remove(filepath.Join(subDir, "b.txt")); err != nil {
+			t.Fatal(err)
+		}
+		compare(t)
+		runGitCommand(t, repoDir, "checkout", "--", "sub/b.txt")
+	})
+
+	t.Run("TouchedButUnchanged", func(t *testing.T) {
+		later := time.Now().Add(2 * time.Minute)
+		if err := os.Chtimes(filepath.Join(repoDir, "a.txt"), later, later); err != nil {
+			t.Fatal(err)
+		}
+		if clean, err := native.IsWorkingDirectoryClean(); err != nil || !clean {
+			t.Errorf("Expected clean work tree, got %v (%v)", clean, err)
+		}
+	})
+
+	t.Run("DetachedHead", func(t *testing.T) {
+		runGitCommand(t, repoDir, "checkout", "--detach")
+		compare(t)
+		runGitCommand(t, repoDir, "checkout", "main")
+	})
+
+	t.Run("Unsupported", func(t *testing.T) {
+		if _, err := native.GetDescribe(); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Expected ErrUnsupported, got %v", err)
+		}
+	})
+
+	t.Run("IndexFormats", func(t *testing.T) {
+		// The cache tree (TREE) extension is optional and skipped
+		runGitCommand(t, repoDir, "update-index", "--index-version", "3")
+		compare(t)
+		// Entries of a split index live in a shared index file
+		runGitCommand(t, repoDir, "update-index", "--split-index")
+		if _, err := native.IsWorkingDirectoryClean(); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Expected ErrUnsupported for a split index, got %v", err)
+		}
+		runGitCommand(t, repoDir, "update-index", "--no-split-index")
+		runGitCommand(t, repoDir, "update-index", "--index-version", "4")
+		if _, err := native.IsWorkingDirectoryClean(); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("Expected ErrUnsupported for index version 4, got %v", err)
+		}
+		runGitCommand(t, repoDir, "update-index", "--index-version", "2")
+		compare(t)
+	})
+}
+
+func TestNativeRepoNotRepository(t *testing.T) {
+	native := &NativeRepo{config: &Config{WorkingDirectory: t.TempDir()}}
+	if _, err := native.GetBranch(); !errors.Is(err, ErrNotRepository) {
+		t.Errorf("Expected ErrNotRepository, got %v", err)
+	}
+	info, err := native.GetInfo()
+	if err != nil || info.IsRepo {
+		t.Errorf("Expected empty info, got %+v (%v)", info, err)
+	}
+}
+
+func TestProviderSelection(t *testing.T) {
+	tests := []struct {
+		provider string
+		command  string
+		native   bool
+	}{
+		{"", "bkpdir-no-such-git", false},
+		{ProviderCLI, "bkpdir-no-such-git", false},
+		{ProviderNative, "git", true},
+		{ProviderGoGit, "git", true},
+		{ProviderAuto, "bkpdir-no-such-git", true},
+	}
+	for _, tt := range tests {
+		repo := NewRepositoryWithConfig(&Config{Provider: tt.provider, Command: tt.command})
+		if _, isNative := repo.(*NativeRepo); isNative != tt.native {
+			t.Errorf("provider %q with command %q: got %T", tt.provider, tt.command, repo)
+		}
+	}
+
+	if err := ValidateProvider(ProviderGoGit); err != nil {
+		t.Errorf("Expected gogit to be accepted as an alias of native: %v", err)
+	}
+	if err := ValidateProvider("libgit2"); err == nil {
+		t.Error("Expected error for unknown provider")
+	}
+	if _, err := InfoWithStatus(&Config{Provider: "libgit2"}); err == nil {
+		t.Error("Expected InfoWithStatus to reject unknown provider")
+	}
+}
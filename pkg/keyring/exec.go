@@ -0,0 +1,43 @@
+//go:build linux || darwin
+
+// ⭐ KEYRING-001: Credential store helpers - Running the store's command line tool - 🔧
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// runTool runs a credential store tool with stdin as its input and returns
+// its output and exit status. A missing tool is reported as ErrUnsupported.
+func runTool(stdin string, name string, args ...string) (string, int, error) {
+	path, err := exec.LookPath(name)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: %s not found", ErrUnsupported, name)
+	}
+	cmd := exec.Command(path, args...)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return stdout.String(), exitErr.ExitCode(), toolError(name, stderr.String(), err)
+	}
+	if err != nil {
+		return "", 0, err
+	}
+	return stdout.String(), 0, nil
+}
+
+// toolError describes a failed tool run with its error output.
+func toolError(name, stderr string, err error) error {
+	if msg := strings.TrimSpace(stderr); msg != "" {
+		return fmt.Errorf("%s: %s", name, msg)
+	}
+	return fmt.Errorf("%s: %w", name, err)
+}
@@ -0,0 +1,130 @@
+// ⭐ KEYRING-001: OS credential store access - Secrets stored by alias - 🔧
+//
+// Package keyring stores and retrieves secrets in the credential store of
+// the operating system: the login keychain on macOS (through security(1))
+// and the Secret Service on Linux (through secret-tool(1)). Secrets are
+// kept under a service name and an alias, so configuration files can refer
+// to them instead of holding them in plain text.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Service is the service name bkpdir stores its secrets under.
+const Service = "bkpdir"
+
+var (
+	// ErrNotFound is returned when no secret is stored under an alias.
+	ErrNotFound = errors.New("secret not found in keyring")
+	// ErrUnsupported is returned when no credential store is available.
+	ErrUnsupported = errors.New("no OS credential store is available on this system")
+)
+
+// Provider is a credential store.
+type Provider interface {
+	Get(service, alias string) (string, error)
+	Set(service, alias, secret string) error
+	Delete(service, alias string) error
+}
+
+var (
+	providerMu sync.RWMutex
+	provider   Provider = osProvider{}
+)
+
+// SetProvider replaces the credential store used by Get, Set and Delete and
+// returns the previous one.
+func SetProvider(p Provider) Provider {
+	providerMu.Lock()
+	defer providerMu.Unlock()
+	old := provider
+	provider = p
+	return old
+}
+
+// current returns the credential store in use.
+func current() Provider {
+	providerMu.RLock()
+	defer providerMu.RUnlock()
+	return provider
+}
+
+// checkAlias refuses aliases the command line tools cannot store.
+func checkAlias(alias string) error {
+	if alias == "" || strings.ContainsAny(alias, "\x00\n\r") {
+		return fmt.Errorf("invalid keyring alias %q", alias)
+	}
+	return nil
+}
+
+// Get returns the secret stored under alias.
+func Get(alias string) (string, error) {
+	if err := checkAlias(alias); err != nil {
+		return "", err
+	}
+	return current().Get(Service, alias)
+}
+
+// Set stores secret under alias, replacing any previous secret.
+func Set(alias, secret string) error {
+	if err := checkAlias(alias); err != nil {
+		return err
+	}
+	if strings.ContainsAny(secret, "\x00\n") {
+		return errors.New("secrets must be a single line")
+	}
+	return current().Set(Service, alias, secret)
+}
+
+// Delete removes the secret stored under alias.
+func Delete(alias string) error {
+	if err := checkAlias(alias); err != nil {
+		return err
+	}
+	return current().Delete(Service, alias)
+}
+
+// MemoryProvider is an in-memory credential store for tests.
+type MemoryProvider struct {
+	mu      sync.Mutex
+	secrets map[string]string
+}
+
+// NewMemoryProvider returns an empty in-memory credential store.
+func NewMemoryProvider() *MemoryProvider {
+	return &MemoryProvider{secrets: make(map[string]string)}
+}
+
+// Get implements Provider.
+func (m *MemoryProvider) Get(service, alias string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	secret, ok := m.secrets[service+"\x00"+alias]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return secret, nil
+}
+
+// Set implements Provider.
+func (m *MemoryProvider) Set(service, alias, secret string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.secrets[service+"\x00"+alias] = secret
+	return nil
+}
+
+// Delete implements Provider.
+func (m *MemoryProvider) Delete(service, alias string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.secrets[service+"\x00"+alias]; !ok {
+		return ErrNotFound
+	}
+	delete(m.secrets, service+"\x00"+alias)
+	return nil
+}
@@ -0,0 +1,50 @@
+// ⭐ KEYRING-001: macOS keychain store - security(1) - 🔧
+package keyring
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// securityItemNotFound is the exit status of security(1) when no item
+// matches (errSecItemNotFound).
+const securityItemNotFound = 44
+
+// osProvider stores generic passwords in the login keychain with security.
+type osProvider struct{}
+
+// Get implements Provider.
+func (osProvider) Get(service, alias string) (string, error) {
+	out, code, err := runTool("", "security", "find-generic-password", "-s", service, "-a", alias, "-w")
+	if code == securityItemNotFound {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Set implements Provider. The command is sent to `security -i` with the
+// secret hex encoded, so it does not appear in the process list.
+func (osProvider) Set(service, alias, secret string) error {
+	command := fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		securityQuote(service), securityQuote(alias), hex.EncodeToString([]byte(secret)))
+	_, _, err := runTool(command, "security", "-i")
+	return err
+}
+
+// Delete implements Provider.
+func (osProvider) Delete(service, alias string) error {
+	_, code, err := runTool("", "security", "delete-generic-password", "-s", service, "-a", alias)
+	if code == securityItemNotFound {
+		return ErrNotFound
+	}
+	return err
+}
+
+// securityQuote quotes an argument for the interactive mode of security.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
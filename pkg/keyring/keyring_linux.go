@@ -0,0 +1,40 @@
+// ⭐ KEYRING-001: Secret Service store - secret-tool(1) from libsecret - 🔧
+package keyring
+
+import (
+	"strings"
+)
+
+// osProvider stores secrets in the Secret Service with secret-tool. Items
+// carry the attributes service and account.
+type osProvider struct{}
+
+// Get implements Provider. secret-tool exits with status 1 and no output
+// when nothing matches.
+func (osProvider) Get(service, alias string) (string, error) {
+	out, code, err := runTool("", "secret-tool", "lookup", "service", service, "account", alias)
+	if code == 1 && out == "" {
+		return "", ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(out, "\n"), nil
+}
+
+// Set implements Provider. The secret is passed on stdin so it does not
+// appear in the process list.
+func (osProvider) Set(service, alias, secret string) error {
+	_, _, err := runTool(secret, "secret-tool", "store", "--label="+service+": "+alias, "service", service, "account", alias)
+	return err
+}
+
+// Delete implements Provider. secret-tool clear succeeds when nothing
+// matches, so the secret is looked up first.
+func (p osProvider) Delete(service, alias string) error {
+	if _, err := p.Get(service, alias); err != nil {
+		return err
+	}
+	_, _, err := runTool("", "secret-tool", "clear", "service", service, "account", alias)
+	return err
+}
@@ -0,0 +1,51 @@
+// ⭐ KEYRING-001: Secret Service tests - A stand-in secret-tool - 🧪
+package keyring
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// fakeSecretTool keeps secrets as files named SERVICE-ALIAS.
+const fakeSecretTool = `#!/bin/sh
+case "$1" in
+lookup) [ -f "$STORE/$3-$5" ] || exit 1; cat "$STORE/$3-$5" ;;
+store) cat > "$STORE/$4-$6" ;;
+clear) rm -f "$STORE/$3-$5" ;;
+*) echo "unknown command $1" >&2; exit 2 ;;
+esac
+`
+
+func TestSecretTool(t *testing.T) {
+	bin, store := t.TempDir(), t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "secret-tool"), []byte(fakeSecretTool), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+	t.Setenv("STORE", store)
+	p := osProvider{}
+
+	if _, err := p.Get(Service, "sftp"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := p.Set(Service, "sftp", "pa ss"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := p.Get(Service, "sftp"); err != nil || got != "pa ss" {
+		t.Errorf("Expected the stored secret, got %q, %v", got, err)
+	}
+	if err := p.Delete(Service, "sftp"); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Delete(Service, "sftp"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	t.Setenv("PATH", t.TempDir())
+	if _, err := p.Get(Service, "sftp"); !errors.Is(err, ErrUnsupported) || !strings.Contains(err.Error(), "secret-tool") {
+		t.Errorf("Expected a missing secret-tool to be unsupported, got %v", err)
+	}
+}
@@ -0,0 +1,22 @@
+//go:build !linux && !darwin
+
+// ⭐ KEYRING-001: Credential store on other platforms - Not supported - 🔧
+package keyring
+
+// osProvider reports that no credential store is available.
+type osProvider struct{}
+
+// Get implements Provider.
+func (osProvider) Get(service, alias string) (string, error) {
+	return "", ErrUnsupported
+}
+
+// Set implements Provider.
+func (osProvider) Set(service, alias, secret string) error {
+	return ErrUnsupported
+}
+
+// Delete implements Provider.
+func (osProvider) Delete(service, alias string) error {
+	return ErrUnsupported
+}
@@ -0,0 +1,40 @@
+// ⭐ KEYRING-001: Keyring API tests - Aliases through a provider - 🧪
+package keyring
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestKeyring(t *testing.T) {
+	defer SetProvider(SetProvider(NewMemoryProvider()))
+
+	if _, err := Get("s3"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Expected ErrNotFound, got %v", err)
+	}
+	if err := Set("s3", "AKIA123"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Set("s3", "AKIA456"); err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Get("s3"); err != nil || got != "AKIA456" {
+		t.Errorf("Expected the replaced secret, got %q, %v", got, err)
+	}
+	if err := Delete("s3"); err != nil {
+		t.Fatal(err)
+	}
+	if err := Delete("s3"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected ErrNotFound deleting twice, got %v", err)
+	}
+
+	if _, err := Get(""); err == nil {
+		t.Error("Expected an empty alias to be refused")
+	}
+	if err := Set("bad\nalias", "x"); err == nil {
+		t.Error("Expected an alias with a newline to be refused")
+	}
+	if err := Set("multi", "a\nb"); err == nil {
+		t.Error("Expected a multi-line secret to be refused")
+	}
+}
@@ -0,0 +1,103 @@
+// ⭐ PLUGIN-001: Pipeline stage plugin client - Runs one plugin process per archive - 🔧
+package plugin
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+)
+
+// maxMessageSize bounds one JSON line, which holds replaced file content.
+const maxMessageSize = 256 << 20
+
+// Client talks to one running plugin process.
+type Client struct {
+	name   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	reader *bufio.Reader
+	enc    *json.Encoder
+}
+
+// Start runs command with args in dir. The plugin's stderr is passed
+// through; cancelling ctx kills it.
+func Start(ctx context.Context, name, command string, args []string, dir string) (*Client, error) {
+	cmd := exec.CommandContext(ctx, command, args...)
+	cmd.Dir = dir
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	return &Client{
+		name:   name,
+		cmd:    cmd,
+		stdin:  stdin,
+		reader: bufio.NewReaderSize(stdout, 64*1024),
+		enc:    json.NewEncoder(stdin),
+	}, nil
+}
+
+// Name returns the configured plugin name.
+func (c *Client) Name() string {
+	return c.name
+}
+
+// Begin announces the archive and waits for the plugin to accept it.
+func (c *Client) Begin(archive, root string) error {
+	resp, err := c.Process(Request{Type: TypeBegin, Protocol: ProtocolVersion, Archive: archive, Root: root})
+	if err != nil {
+		return err
+	}
+	if resp.Action != ActionKeep {
+		return fmt.Errorf("plugin %s refused the archive: %s", c.name, resp.Reason)
+	}
+	return nil
+}
+
+// Process sends a request and returns the plugin's answer.
+func (c *Client) Process(req Request) (Response, error) {
+	if err := c.enc.Encode(req); err != nil {
+		return Response{}, fmt.Errorf("plugin %s: %w", c.name, err)
+	}
+	line, err := c.reader.ReadBytes('\n')
+	if err == io.EOF && len(line) == 0 {
+		return Response{}, fmt.Errorf("plugin %s exited without answering", c.name)
+	}
+	if err != nil && err != io.EOF {
+		return Response{}, fmt.Errorf("plugin %s: %w", c.name, err)
+	}
+	if len(line) > maxMessageSize {
+		return Response{}, fmt.Errorf("plugin %s: answer exceeds %d bytes", c.name, maxMessageSize)
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return Response{}, fmt.Errorf("plugin %s: invalid answer: %w", c.name, err)
+	}
+	if err := resp.validate(); err != nil {
+		return Response{}, fmt.Errorf("plugin %s: %w", c.name, err)
+	}
+	return resp, nil
+}
+
+// Close sends the end message and waits for the plugin to exit. A plugin
+// exiting with an error fails the archive.
+func (c *Client) Close() error {
+	c.enc.Encode(Request{Type: TypeEnd})
+	c.stdin.Close()
+	if err := c.cmd.Wait(); err != nil {
+		return fmt.Errorf("plugin %s: %w", c.name, err)
+	}
+	return nil
+}
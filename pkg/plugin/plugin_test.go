@@ -0,0 +1,90 @@
+// ⭐ PLUGIN-001: Plugin protocol tests - Client and Serve over a real subprocess - 🧪
+package plugin
+
+import (
+	"context"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestHelperPlugin is run as the plugin process by the other tests.
+func TestHelperPlugin(t *testing.T) {
+	if os.Getenv("BKPDIR_HELPER_PLUGIN") != "1" {
+		t.Skip("helper process")
+	}
+	err := Serve(os.Stdin, os.Stdout, func(req Request) Response {
+		switch {
+		case req.Type == TypeBegin:
+			return Response{Action: ActionKeep}
+		case strings.HasSuffix(req.Path, ".secret"):
+			data, _ := os.ReadFile(req.ContentPath)
+			return Response{Action: ActionReplace, Data: []byte(strings.ToUpper(string(data)))}
+		case strings.HasSuffix(req.Path, ".tmp"):
+			return Response{Action: ActionSkip, Reason: "temporary"}
+		case req.Path == "bad":
+			return Response{Action: "explode"}
+		}
+		return Response{Action: ActionKeep}
+	})
+	if err != nil {
+		os.Exit(2)
+	}
+	os.Exit(0)
+}
+
+// startHelper starts the helper plugin.
+func startHelper(t *testing.T) *Client {
+	t.Helper()
+	t.Setenv("BKPDIR_HELPER_PLUGIN", "1")
+	c, err := Start(context.Background(), "helper", os.Args[0], []string{"-test.run=^TestHelperPlugin$"}, t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestClient(t *testing.T) {
+	c := startHelper(t)
+	if err := c.Begin("archive.zip", "/src"); err != nil {
+		t.Fatal(err)
+	}
+
+	content := t.TempDir() + "/notes.secret"
+	os.WriteFile(content, []byte("abc"), 0o644)
+	tests := []struct {
+		path   string
+		action string
+		data   string
+	}{
+		{"main.go", ActionKeep, ""},
+		{"notes.secret", ActionReplace, "ABC"},
+		{"cache.tmp", ActionSkip, ""},
+	}
+	for _, tt := range tests {
+		resp, err := c.Process(Request{Type: TypeFile, Path: tt.path, ContentPath: content})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Action != tt.action || string(resp.Data) != tt.data {
+			t.Errorf("%s: expected %s %q, got %+v", tt.path, tt.action, tt.data, resp)
+		}
+	}
+	if _, err := c.Process(Request{Type: TypeFile, Path: "bad"}); err == nil {
+		t.Error("Expected an unknown action to be refused")
+	}
+	if err := c.Close(); err != nil {
+		t.Errorf("Expected the plugin to exit cleanly, got %v", err)
+	}
+}
+
+func TestClientProtocolMismatch(t *testing.T) {
+	c := startHelper(t)
+	resp, err := c.Process(Request{Type: TypeBegin, Protocol: ProtocolVersion + 1})
+	if err != nil || resp.Action != ActionFail {
+		t.Errorf("Expected the plugin to refuse the protocol, got %+v, %v", resp, err)
+	}
+	if err := c.Close(); err == nil {
+		t.Error("Expected the plugin to exit with an error")
+	}
+}
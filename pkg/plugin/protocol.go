@@ -0,0 +1,91 @@
+// ⭐ PLUGIN-001: Pipeline stage plugin protocol - JSON messages over a subprocess's stdio - 🔧
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// ProtocolVersion is sent in the begin message. Plugins should refuse
+// versions they do not know.
+const ProtocolVersion = 1
+
+// Message types sent to a plugin.
+const (
+	TypeBegin = "begin" // Start of an archive; answered with ActionKeep
+	TypeFile  = "file"  // One file to process
+	TypeEnd   = "end"   // End of the archive; no answer, stdin is closed after it
+)
+
+// Actions a plugin answers with.
+const (
+	ActionKeep    = "keep"    // Archive the file unchanged
+	ActionSkip    = "skip"    // Leave the file out of the archive
+	ActionReplace = "replace" // Archive Data instead of the file content
+	ActionFail    = "fail"    // Abort the archive
+)
+
+// Request is one message to a plugin. Path is the archive entry name with
+// forward slashes; ContentPath is the file holding the current content, which
+// is a temporary file when an earlier plugin replaced it.
+type Request struct {
+	Type        string `json:"type"`
+	Protocol    int    `json:"protocol,omitempty"`
+	Archive     string `json:"archive,omitempty"`
+	Root        string `json:"root,omitempty"`
+	Path        string `json:"path,omitempty"`
+	ContentPath string `json:"content_path,omitempty"`
+	Size        int64  `json:"size,omitempty"`
+	Mode        uint32 `json:"mode,omitempty"`
+}
+
+// Response is a plugin's answer to a begin or file message. Data is base64
+// encoded in JSON.
+type Response struct {
+	Action string `json:"action"`
+	Reason string `json:"reason,omitempty"`
+	Data   []byte `json:"data,omitempty"`
+}
+
+// validate checks that the response is a known action.
+func (r Response) validate() error {
+	switch r.Action {
+	case ActionKeep, ActionSkip, ActionReplace, ActionFail:
+		return nil
+	case "":
+		return fmt.Errorf("response has no action")
+	}
+	return fmt.Errorf("unknown action %q", r.Action)
+}
+
+// ⭐ PLUGIN-001: Plugin side of the protocol - 🔧
+// Serve reads requests from r and writes the answers of handle to w, one
+// JSON object per line, until the end message or the end of input. Plugins
+// written in Go call it with os.Stdin and os.Stdout; anything they want to
+// log goes to stderr.
+func Serve(r io.Reader, w io.Writer, handle func(Request) Response) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxMessageSize)
+	enc := json.NewEncoder(w)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			return fmt.Errorf("invalid request: %w", err)
+		}
+		switch req.Type {
+		case TypeEnd:
+			return nil
+		case TypeBegin:
+			if req.Protocol != ProtocolVersion {
+				enc.Encode(Response{Action: ActionFail, Reason: fmt.Sprintf("unsupported protocol %d", req.Protocol)})
+				return fmt.Errorf("unsupported protocol %d", req.Protocol)
+			}
+		}
+		if err := enc.Encode(handle(req)); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
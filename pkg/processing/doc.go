@@ -5,6 +5,7 @@
 //   - Timestamp-based naming conventions with metadata integration
 //   - Data integrity verification with pluggable algorithms
 //   - Processing pipelines with context support and atomic operations
+//   - Multi-file transactions that commit related outputs all or none
 //   - Concurrent processing with worker pools and resource management
 //
 // The package is designed to be used independently or in combination with other
@@ -24,6 +25,13 @@
 //	verifier := processing.NewSHA256Verifier()
 //	checksum, err := verifier.Calculate(dataReader)
 //
+//	// Stream a large input with progress updates and cancellation
+//	streaming := verifier.(processing.StreamingVerifierInterface)
+//	checksum, err = streaming.CalculateStream(ctx, dataReader, processing.StreamOptions{
+//		TotalBytes: size,
+//		Progress:   func(p processing.VerificationProgress) { render(p.Fraction()) },
+//	})
+//
 //	// Create a processing pipeline
 //	pipeline := processing.NewPipeline()
 //	pipeline.AddStage(processing.CollectionStage{})
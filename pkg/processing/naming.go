@@ -4,6 +4,7 @@ package processing
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -26,6 +27,7 @@ type NamingTemplate struct {
 	// Git information (extracted from archive.go patterns)
 	GitBranch          string `json:"git_branch,omitempty"`
 	GitHash            string `json:"git_hash,omitempty"`
+	GitDescribe        string `json:"git_describe,omitempty"` // 🔶 GIT-007: Replaces the hash when set
 	GitIsClean         bool   `json:"git_is_clean"`
 	ShowGitDirtyStatus bool   `json:"show_git_dirty_status"`
 
@@ -36,6 +38,9 @@ type NamingTemplate struct {
 	TimestampFormat string `json:"timestamp_format"`
 	IsIncremental   bool   `json:"is_incremental"`
 	BaseName        string `json:"base_name,omitempty"`
+
+	// Sequence distinguishes names sharing a timestamp; 0 adds no suffix
+	Sequence int `json:"sequence,omitempty"`
 }
 
 // NameComponents represents parsed components from a filename
@@ -45,6 +50,7 @@ type NameComponents struct {
 	Note      string            `json:"note,omitempty"`
 	GitBranch string            `json:"git_branch,omitempty"`
 	GitHash   string            `json:"git_hash,omitempty"`
+	Sequence  int               `json:"sequence,omitempty"`
 	Metadata  map[string]string `json:"metadata,omitempty"`
 	Extension string            `json:"extension,omitempty"`
 }
@@ -74,21 +80,23 @@ func NewNamingProvider() *NamingProvider {
 
 // initializePatterns sets up regex patterns for name validation and parsing
 func (np *NamingProvider) initializePatterns() {
-	// Archive pattern: {prefix}-{timestamp}-{git_branch}-{git_hash}-{note}.zip
+	// Every timestamp may be followed by a sequence suffix, e.g. 2024-01-01T120000_2
+
+	// Archive pattern: {prefix}-{timestamp}[_{sequence}]-{git_branch}-{git_hash}-{note}.zip
 	// Example: test-2024-01-01T120000-main-abc123-backup.zip
 	np.patterns["archive"] = regexp.MustCompile(
-		`^(?P<prefix>[^-]+)-(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{6})-(?P<git_branch>[^-]+)-(?P<git_hash>[a-f0-9]+)-(?P<note>[^-.]+)\.(?P<extension>zip)$`,
+		`^(?P<prefix>[^-]+)-(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{6})(?:_(?P<sequence>\d+))?-(?P<git_branch>[^-]+)-(?P<git_hash>[a-f0-9]+)-(?P<note>[^-.]+)\.(?P<extension>zip)$`,
 	)
 
-	// Backup pattern: {filename}-{timestamp}[={note}]
+	// Backup pattern: {filename}-{timestamp}[_{sequence}][={note}]
 	// Example: document.txt-2024-03-20-14-30=before-changes
 	np.patterns["backup"] = regexp.MustCompile(
-		`^(?P<filename>.+)-(?P<timestamp>\d{4}-\d{2}-\d{2}-\d{2}-\d{2})(?:=(?P<note>.+))?$`,
+		`^(?P<filename>.+)-(?P<timestamp>\d{4}-\d{2}-\d{2}-\d{2}-\d{2})(?:_(?P<sequence>\d+))?(?:=(?P<note>.+))?$`,
 	)
 
 	// Incremental archive pattern: {prefix}-{timestamp}-{git_info}-inc-{base}-{note}.zip
 	np.patterns["incremental"] = regexp.MustCompile(
-		`^(?P<prefix>[^-]+)-(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{6})(?:-(?P<git_branch>[^-]+)-(?P<git_hash>[a-f0-9]+)(?P<git_dirty>-dirty)?)?-inc-(?P<base>[^-]+)(?:-(?P<note>[^-.]+))?\\.(?P<extension>zip)$`,
+		`^(?P<prefix>[^-]+)-(?P<timestamp>\d{4}-\d{2}-\d{2}T\d{6})(?:_(?P<sequence>\d+))?(?:-(?P<git_branch>[^-]+)-(?P<git_hash>[a-f0-9]+)(?P<git_dirty>-dirty)?)?-inc-(?P<base>[^-]+)(?:-(?P<note>[^-.]+))?\\.(?P<extension>zip)$`,
 	)
 }
 
@@ -115,7 +123,10 @@ func (np *NamingProvider) GenerateName(template *NamingTemplate) (string, error)
 		parts = append(parts, template.Prefix)
 	}
 
-	// Add timestamp
+	// Add timestamp with the sequence suffix of repeated names
+	if template.Sequence > 0 {
+		timestamp += "_" + strconv.Itoa(template.Sequence)
+	}
 	parts = append(parts, timestamp)
 
 	// Add Git information if available
@@ -124,6 +135,10 @@ func (np *NamingProvider) GenerateName(template *NamingTemplate) (string, error)
 		if template.ShowGitDirtyStatus && !template.GitIsClean {
 			gitInfo += "-dirty"
 		}
+		// git describe output carries its own -dirty marker
+		if template.GitDescribe != "" {
+			gitInfo = template.GitBranch + "-" + template.GitDescribe
+		}
 		parts = append(parts, gitInfo)
 	}
 
@@ -221,6 +236,10 @@ func (np *NamingProvider) ParseName(name string, pattern string) (*NameComponent
 			result.GitBranch = value
 		case "git_hash":
 			result.GitHash = value
+		case "sequence":
+			if value != "" {
+				result.Sequence, _ = strconv.Atoi(value)
+			}
 		case "extension":
 			result.Extension = value
 		default:
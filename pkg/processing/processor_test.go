@@ -4,6 +4,8 @@ package processing
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -34,6 +36,13 @@ func TestNamingProvider(t *testing.T) {
 		t.Errorf("Expected %s, got %s", expected, name)
 	}
 
+	// 🔶 GIT-007: Describe output replaces the hash
+	described := *template
+	described.GitDescribe = "v1.4.0"
+	if name, _ := np.GenerateName(&described); name != "test-2024-01-01T120000-main-v1.4.0-backup" {
+		t.Errorf("Expected the describe output in the name, got %s", name)
+	}
+
 	// Test name parsing
 	fullName := name + ".zip"
 	t.Logf("Generated full name: %s", fullName) // Debug output
@@ -50,6 +59,18 @@ func TestNamingProvider(t *testing.T) {
 		t.Errorf("Expected git branch 'main', got '%s'", components.GitBranch)
 	}
 
+	// ⭐ ARCH-008: Sequence suffixes survive a round trip
+	template.Sequence = 3
+	name, _ = np.GenerateName(template)
+	components, err = np.ParseName(name+".zip", "archive")
+	if err != nil || components.Sequence != 3 || components.GitBranch != "main" {
+		t.Errorf("Expected sequence 3 in %s, got %+v (%v)", name, components, err)
+	}
+	components, err = np.ParseName("notes.txt-2024-03-20-14-30_2=draft", "backup")
+	if err != nil || components.Sequence != 2 || components.Note != "draft" {
+		t.Errorf("Expected sequence 2 in backup name, got %+v (%v)", components, err)
+	}
+
 	// Test supported formats
 	formats := np.GetSupportedFormats()
 	if len(formats) == 0 {
@@ -89,6 +110,49 @@ func TestVerificationProvider(t *testing.T) {
 	}
 }
 
+// Test streaming verification with progress and cancellation
+func TestVerificationStream(t *testing.T) {
+	verifier := NewSHA256Verifier().(StreamingVerifierInterface)
+	data := strings.Repeat("x", 10*1024)
+
+	expected, err := verifier.Calculate(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("Failed to calculate checksum: %v", err)
+	}
+
+	var updates []VerificationProgress
+	checksum, err := verifier.CalculateStream(context.Background(), strings.NewReader(data), StreamOptions{
+		TotalBytes:       int64(len(data)),
+		ProgressInterval: 4096,
+		Progress:         func(p VerificationProgress) { updates = append(updates, p) },
+	})
+	if err != nil {
+		t.Fatalf("Failed to calculate streaming checksum: %v", err)
+	}
+	if checksum != expected {
+		t.Errorf("Streaming checksum %s differs from %s", checksum, expected)
+	}
+	if len(updates) < 2 {
+		t.Fatalf("Expected intermediate and final progress updates, got %d", len(updates))
+	}
+	last := updates[len(updates)-1]
+	if !last.Done || last.BytesProcessed != int64(len(data)) || last.Fraction() != 1 {
+		t.Errorf("Unexpected final progress: %+v", last)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := verifier.VerifyStream(ctx, strings.NewReader(data), expected, StreamOptions{}); err == nil {
+		t.Error("Expected error for cancelled context")
+	}
+
+	vm := NewVerificationManager()
+	result, err := vm.VerifyWithAlgorithm(strings.NewReader(data), expected, "sha256")
+	if err != nil || !result.IsValid || result.BytesVerified != int64(len(data)) {
+		t.Errorf("Unexpected manager result: %+v (%v)", result, err)
+	}
+}
+
 // Test verification manager functionality
 func TestVerificationManager(t *testing.T) {
 	vm := NewVerificationManager()
@@ -313,6 +377,73 @@ func TestUtilityFunctions(t *testing.T) {
 	}
 }
 
+// ⭐ TXN-001: Test multi-file transactions - 🧪
+func TestTransaction(t *testing.T) {
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "proj.zip")
+	sidecar := filepath.Join(dir, ".metadata", "proj.zip.manifest.json")
+	if err := os.WriteFile(archive, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// A failing target rolls back the archive already moved into place
+	txn, err := NewTransaction(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.WriteFile(archive, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.WriteFile(filepath.Join(archive, "not-a-dir"), []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.Commit(); err == nil {
+		t.Fatal("Expected commit to fail for a target below a file")
+	}
+	if data, _ := os.ReadFile(archive); string(data) != "old" {
+		t.Errorf("Expected the previous archive after rollback, got %q", data)
+	}
+
+	// A successful commit updates every target
+	txn, err = NewTransaction(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer txn.Rollback()
+	staged, err := txn.StagePath(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(staged, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := txn.WriteFile(sidecar, []byte("{}"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := txn.StagePath(archive); err == nil {
+		t.Error("Expected an error when staging a target twice")
+	}
+	if err := txn.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(archive); string(data) != "new" {
+		t.Errorf("Expected the new archive, got %q", data)
+	}
+	if _, err := os.Stat(sidecar); err != nil {
+		t.Errorf("Expected the sidecar to be committed: %v", err)
+	}
+	if err := txn.Commit(); err == nil {
+		t.Error("Expected a second commit to fail")
+	}
+
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".bkpdir-txn-") {
+			t.Errorf("Expected staging directory %s to be removed", e.Name())
+		}
+	}
+}
+
 // Test helper types and functions
 
 // TestStage implements PipelineStage for testing
@@ -0,0 +1,208 @@
+// ⭐ TXN-001: Multi-file transactions - Archive and sidecars committed together - 🔧
+package processing
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bkpdir/pkg/fileops"
+)
+
+// stagingDirPattern names the staging directory created next to the targets.
+const stagingDirPattern = ".bkpdir-txn-*"
+
+// Transaction stages several output files in a temporary directory and moves
+// them to their targets together. If any rename fails, the files already
+// moved are taken back and replaced targets are restored, so either all
+// targets are updated or none is.
+//
+// The staging directory is created inside the directory passed to
+// NewTransaction so every rename stays on one filesystem; targets must be on
+// that filesystem too. A Transaction is not safe for concurrent use.
+type Transaction struct {
+	stageDir string
+	policy   fileops.SyncPolicy
+	files    []stagedFile
+	done     bool
+}
+
+// stagedFile pairs a target with the staged content that will replace it.
+type stagedFile struct {
+	target string
+	staged string
+}
+
+// committedFile records a target moved into place and the previous version
+// it replaced, if any.
+type committedFile struct {
+	target   string
+	previous string
+}
+
+// ⭐ TXN-001: Transaction creation - 🔧
+// NewTransaction creates a transaction whose staging directory lives in dir.
+func NewTransaction(dir string) (*Transaction, error) {
+	if err := fileops.CheckWrite(dir); err != nil {
+		return nil, err
+	}
+	stageDir, err := os.MkdirTemp(dir, stagingDirPattern)
+	if err != nil {
+		return nil, NewProcessingError("TXN_CREATE", "NewTransaction", fmt.Sprintf("cannot create staging directory: %v", err))
+	}
+	return &Transaction{stageDir: stageDir, policy: fileops.DefaultSyncPolicy}, nil
+}
+
+// SetSyncPolicy selects the fsync calls made by Commit.
+func (t *Transaction) SetSyncPolicy(policy fileops.SyncPolicy) {
+	t.policy = policy
+}
+
+// ⭐ TXN-001: File staging - 🔧
+// StagePath registers target and returns the path the caller writes its new
+// content to. Each target can be staged once.
+func (t *Transaction) StagePath(target string) (string, error) {
+	if t.done {
+		return "", NewProcessingError("TXN_CLOSED", "StagePath", "transaction already finished")
+	}
+	target = filepath.Clean(target)
+	for _, f := range t.files {
+		if f.target == target {
+			return "", NewProcessingError("TXN_DUPLICATE", "StagePath", "target staged twice: "+target)
+		}
+	}
+	staged := filepath.Join(t.stageDir, fmt.Sprintf("%d-%s", len(t.files), filepath.Base(target)))
+	t.files = append(t.files, stagedFile{target: target, staged: staged})
+	return staged, nil
+}
+
+// WriteFile stages data as the new content of target.
+func (t *Transaction) WriteFile(target string, data []byte, perm os.FileMode) error {
+	staged, err := t.StagePath(target)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(staged, data, perm); err != nil {
+		return NewProcessingError("TXN_STAGE", "WriteFile", fmt.Sprintf("cannot stage %s: %v", target, err))
+	}
+	return nil
+}
+
+// Targets returns the staged targets in staging order.
+func (t *Transaction) Targets() []string {
+	targets := make([]string, len(t.files))
+	for i, f := range t.files {
+		targets[i] = f.target
+	}
+	return targets
+}
+
+// ⭐ TXN-001: All-or-nothing commit - 🛡️
+// Commit moves every staged file onto its target in staging order, creating
+// missing parent directories. On failure the targets are rolled back to their
+// previous state before the error is returned. The staging directory is
+// removed either way.
+func (t *Transaction) Commit() error {
+	if t.done {
+		return NewProcessingError("TXN_CLOSED", "Commit", "transaction already finished")
+	}
+	defer t.Rollback()
+
+	for _, f := range t.files {
+		if err := fileops.CheckWrite(f.target); err != nil {
+			return err
+		}
+		if _, err := os.Stat(f.staged); err != nil {
+			return NewProcessingError("TXN_MISSING", "Commit", fmt.Sprintf("nothing staged for %s", f.target))
+		}
+		if t.policy >= fileops.SyncFile {
+			if err := syncFile(f.staged); err != nil {
+				return NewProcessingError("TXN_SYNC", "Commit", fmt.Sprintf("cannot sync %s: %v", f.target, err))
+			}
+		}
+	}
+
+	var committed []committedFile
+	for i, f := range t.files {
+		c, err := t.moveIntoPlace(i, f)
+		if err != nil {
+			undoCommit(committed)
+			return NewProcessingError("TXN_COMMIT", "Commit", fmt.Sprintf("cannot commit %s: %v", f.target, err))
+		}
+		committed = append(committed, c)
+	}
+
+	if t.policy >= fileops.SyncFileAndDir {
+		synced := make(map[string]bool)
+		for _, f := range t.files {
+			dir := filepath.Dir(f.target)
+			if synced[dir] {
+				continue
+			}
+			synced[dir] = true
+			if err := fileops.SyncDir(dir); err != nil {
+				return NewProcessingError("TXN_SYNC", "Commit", fmt.Sprintf("cannot sync directory %s: %v", dir, err))
+			}
+		}
+	}
+	return nil
+}
+
+// moveIntoPlace renames the i-th staged file onto its target, first moving
+// an existing target into the staging directory so it can be restored.
+func (t *Transaction) moveIntoPlace(i int, f stagedFile) (committedFile, error) {
+	c := committedFile{target: f.target}
+	if err := fileops.MkdirAll(filepath.Dir(f.target), 0o755); err != nil {
+		return c, err
+	}
+	if _, err := os.Lstat(f.target); err == nil {
+		previous := filepath.Join(t.stageDir, fmt.Sprintf("%d-previous", i))
+		if err := os.Rename(f.target, previous); err != nil {
+			return c, err
+		}
+		c.previous = previous
+	}
+	if err := os.Rename(f.staged, f.target); err != nil {
+		if c.previous != "" {
+			os.Rename(c.previous, f.target)
+		}
+		return c, err
+	}
+	return c, nil
+}
+
+// undoCommit reverses moveIntoPlace for committed files, newest first.
+func undoCommit(committed []committedFile) {
+	for i := len(committed) - 1; i >= 0; i-- {
+		c := committed[i]
+		if c.previous != "" {
+			os.Rename(c.previous, c.target)
+		} else {
+			os.Remove(c.target)
+		}
+	}
+}
+
+// ⭐ TXN-001: Transaction rollback - 🔧
+// Rollback discards all staged files. It does nothing after Commit or a
+// previous Rollback, so it can be deferred right after NewTransaction.
+func (t *Transaction) Rollback() error {
+	if t.done {
+		return nil
+	}
+	t.done = true
+	if err := os.RemoveAll(t.stageDir); err != nil {
+		return NewProcessingError("TXN_ROLLBACK", "Rollback", fmt.Sprintf("cannot remove staging directory: %v", err))
+	}
+	return nil
+}
+
+// syncFile flushes a staged file to disk.
+func syncFile(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return f.Sync()
+}
@@ -2,6 +2,7 @@
 package processing
 
 import (
+	"context"
 	"crypto/md5"
 	"crypto/sha256"
 	"crypto/sha512"
@@ -21,6 +22,55 @@ type VerificationProviderInterface interface {
 	GetDisplayName() string
 }
 
+// ⭐ EXTRACT-011: Streaming verifier API - 🔧
+// StreamingVerifierInterface extends verification providers with progress
+// reporting and cancellation for long checksum computations
+type StreamingVerifierInterface interface {
+	VerificationProviderInterface
+	CalculateStream(ctx context.Context, data io.Reader, opts StreamOptions) (string, error)
+	VerifyStream(ctx context.Context, data io.Reader, expected string, opts StreamOptions) (bool, error)
+}
+
+// ⭐ EXTRACT-011: Bytes-processed updates - 📝
+// VerificationProgress reports how far a streaming checksum computation has got
+type VerificationProgress struct {
+	Algorithm      string        `json:"algorithm"`
+	BytesProcessed int64         `json:"bytes_processed"`
+	TotalBytes     int64         `json:"total_bytes,omitempty"`
+	Elapsed        time.Duration `json:"elapsed"`
+	Done           bool          `json:"done"`
+}
+
+// Fraction returns the completed fraction in [0, 1], or -1 when the total is unknown
+func (vp VerificationProgress) Fraction() float64 {
+	if vp.TotalBytes <= 0 {
+		return -1
+	}
+	if vp.BytesProcessed >= vp.TotalBytes {
+		return 1
+	}
+	return float64(vp.BytesProcessed) / float64(vp.TotalBytes)
+}
+
+// VerificationProgressCallback receives progress updates from a streaming computation
+type VerificationProgressCallback func(VerificationProgress)
+
+// DefaultProgressInterval is the number of bytes hashed between progress updates
+const DefaultProgressInterval int64 = 1 << 20
+
+// streamChunkSize is the read size used by streaming computations
+const streamChunkSize = 64 * 1024
+
+// StreamOptions configures a streaming checksum computation
+type StreamOptions struct {
+	// TotalBytes is the expected input size, 0 if unknown
+	TotalBytes int64
+	// Progress is called every ProgressInterval bytes and once on completion
+	Progress VerificationProgressCallback
+	// ProgressInterval defaults to DefaultProgressInterval
+	ProgressInterval int64
+}
+
 // VerificationResult represents the result of a verification operation
 type VerificationResult struct {
 	Algorithm     string        `json:"algorithm"`
@@ -79,31 +129,74 @@ func NewMD5Verifier() VerificationProviderInterface {
 
 // Calculate computes the checksum for the provided data
 func (bvp *BaseVerificationProvider) Calculate(data io.Reader) (string, error) {
+	return bvp.CalculateStream(context.Background(), data, StreamOptions{})
+}
+
+// ⭐ EXTRACT-011: Streaming checksum computation - 🔧
+// CalculateStream computes the checksum for the provided data, reporting
+// progress through opts.Progress and stopping when ctx is cancelled
+func (bvp *BaseVerificationProvider) CalculateStream(ctx context.Context, data io.Reader, opts StreamOptions) (string, error) {
 	if data == nil {
 		return "", NewProcessingError("INVALID_INPUT", "Calculate", "data reader cannot be nil")
 	}
 
+	interval := opts.ProgressInterval
+	if interval <= 0 {
+		interval = DefaultProgressInterval
+	}
+
 	hasher := bvp.hasher()
+	progress := VerificationProgress{Algorithm: bvp.algorithm, TotalBytes: opts.TotalBytes}
+	start := time.Now()
+	nextReport := interval
+	buf := make([]byte, streamChunkSize)
 
-	// Copy data to hasher
-	_, err := io.Copy(hasher, data)
-	if err != nil {
-		return "", NewProcessingError("CALCULATION_FAILED", "Calculate", fmt.Sprintf("failed to read data: %v", err))
+	for {
+		if err := ctx.Err(); err != nil {
+			return "", NewProcessingError("CALCULATION_CANCELLED", "Calculate", fmt.Sprintf("checksum calculation cancelled: %v", err))
+		}
+
+		n, err := data.Read(buf)
+		if n > 0 {
+			hasher.Write(buf[:n])
+			progress.BytesProcessed += int64(n)
+			if opts.Progress != nil && progress.BytesProcessed >= nextReport {
+				progress.Elapsed = time.Since(start)
+				opts.Progress(progress)
+				nextReport = progress.BytesProcessed + interval
+			}
+		}
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", NewProcessingError("CALCULATION_FAILED", "Calculate", fmt.Sprintf("failed to read data: %v", err))
+		}
 	}
 
-	// Get checksum
-	checksum := hex.EncodeToString(hasher.Sum(nil))
+	if opts.Progress != nil {
+		progress.Elapsed = time.Since(start)
+		progress.Done = true
+		opts.Progress(progress)
+	}
 
-	return checksum, nil
+	// Get checksum
+	return hex.EncodeToString(hasher.Sum(nil)), nil
 }
 
 // Verify compares the checksum of data against an expected value
 func (bvp *BaseVerificationProvider) Verify(data io.Reader, expected string) (bool, error) {
+	return bvp.VerifyStream(context.Background(), data, expected, StreamOptions{})
+}
+
+// VerifyStream compares the checksum of data against an expected value with
+// progress reporting and cancellation
+func (bvp *BaseVerificationProvider) VerifyStream(ctx context.Context, data io.Reader, expected string, opts StreamOptions) (bool, error) {
 	if expected == "" {
 		return false, NewProcessingError("INVALID_EXPECTED", "Verify", "expected checksum cannot be empty")
 	}
 
-	calculated, err := bvp.Calculate(data)
+	calculated, err := bvp.CalculateStream(ctx, data, opts)
 	if err != nil {
 		return false, err
 	}
@@ -172,6 +265,14 @@ func (vm *VerificationManager) GetSupportedAlgorithms() []string {
 
 // VerifyWithAlgorithm verifies data using the specified algorithm
 func (vm *VerificationManager) VerifyWithAlgorithm(data io.Reader, expected, algorithm string) (*VerificationResult, error) {
+	return vm.VerifyWithAlgorithmStream(context.Background(), data, expected, algorithm, StreamOptions{})
+}
+
+// ⭐ EXTRACT-011: Streaming verification by algorithm - 🔧
+// VerifyWithAlgorithmStream verifies data using the specified algorithm with
+// progress reporting and cancellation. Providers that do not implement
+// StreamingVerifierInterface are run without progress updates.
+func (vm *VerificationManager) VerifyWithAlgorithmStream(ctx context.Context, data io.Reader, expected, algorithm string, opts StreamOptions) (*VerificationResult, error) {
 	start := time.Now()
 
 	provider, err := vm.GetProvider(algorithm)
@@ -179,15 +280,31 @@ func (vm *VerificationManager) VerifyWithAlgorithm(data io.Reader, expected, alg
 		return nil, err
 	}
 
+	// Track bytes read so the result reports how much data was verified
+	var bytesVerified int64
+	userProgress := opts.Progress
+	opts.Progress = func(p VerificationProgress) {
+		bytesVerified = p.BytesProcessed
+		if userProgress != nil {
+			userProgress(p)
+		}
+	}
+
 	// Calculate checksum
-	checksum, err := provider.Calculate(data)
+	var checksum string
+	if streaming, ok := provider.(StreamingVerifierInterface); ok {
+		checksum, err = streaming.CalculateStream(ctx, data, opts)
+	} else {
+		checksum, err = provider.Calculate(data)
+	}
 	if err != nil {
 		return &VerificationResult{
-			Algorithm:  algorithm,
-			IsValid:    false,
-			VerifiedAt: time.Now(),
-			Duration:   time.Since(start),
-			Errors:     []string{err.Error()},
+			Algorithm:     algorithm,
+			IsValid:       false,
+			VerifiedAt:    time.Now(),
+			Duration:      time.Since(start),
+			BytesVerified: bytesVerified,
+			Errors:        []string{err.Error()},
 		}, err
 	}
 
@@ -195,12 +312,13 @@ func (vm *VerificationManager) VerifyWithAlgorithm(data io.Reader, expected, alg
 	isValid := checksum == expected
 
 	return &VerificationResult{
-		Algorithm:  algorithm,
-		Checksum:   checksum,
-		Expected:   expected,
-		IsValid:    isValid,
-		VerifiedAt: time.Now(),
-		Duration:   time.Since(start),
+		Algorithm:     algorithm,
+		Checksum:      checksum,
+		Expected:      expected,
+		IsValid:       isValid,
+		VerifiedAt:    time.Now(),
+		Duration:      time.Since(start),
+		BytesVerified: bytesVerified,
 	}, nil
 }
 
@@ -0,0 +1,257 @@
+// ⭐ REMOTE-001: Directory backend - Network mounts and local directories - 🔧
+package remote
+
+import (
+	"context"
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+)
+
+// dirUploads holds the parts of unfinished uploads inside the directory.
+const dirUploads = ".uploads"
+
+// DirBackend stores objects as files below a directory. Parts of unfinished
+// uploads are kept in .uploads/ID, so they survive an interrupted run.
+type DirBackend struct {
+	dir string
+}
+
+// NewDirBackend returns the backend of an existing directory.
+func NewDirBackend(dir string) (*DirBackend, error) {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("remote %s is not a directory", dir)
+	}
+	return &DirBackend{dir: abs}, nil
+}
+
+// URL implements Backend.
+func (d *DirBackend) URL() string {
+	return "file://" + filepath.ToSlash(d.dir)
+}
+
+// path returns the file of an object.
+func (d *DirBackend) path(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	return filepath.Join(d.dir, filepath.FromSlash(name)), nil
+}
+
+// List implements Backend.
+func (d *DirBackend) List(ctx context.Context) ([]Object, error) {
+	var objects []Object
+	err := filepath.WalkDir(d.dir, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if p == filepath.Join(d.dir, dirUploads) {
+			return filepath.SkipDir
+		}
+		if !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return err
+		}
+		rel, _ := filepath.Rel(d.dir, p)
+		objects = append(objects, Object{Name: filepath.ToSlash(rel), Size: info.Size(), Modified: info.ModTime()})
+		return nil
+	})
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, err
+}
+
+// Stat implements Backend.
+func (d *DirBackend) Stat(ctx context.Context, name string) (Object, error) {
+	p, err := d.path(name)
+	if err != nil {
+		return Object{}, err
+	}
+	info, err := os.Stat(p)
+	if errors.Is(err, fs.ErrNotExist) || err == nil && !info.Mode().IsRegular() {
+		return Object{}, ErrNotExist
+	}
+	if err != nil {
+		return Object{}, err
+	}
+	return Object{Name: name, Size: info.Size(), Modified: info.ModTime()}, nil
+}
+
+// Open implements Backend.
+func (d *DirBackend) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	p, err := d.path(name)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, ErrNotExist
+	}
+	return f, err
+}
+
+// OpenIfChanged implements Backend. Files have no ETag, so they are
+// unchanged while their size and modification time are.
+func (d *DirBackend) OpenIfChanged(ctx context.Context, name string, cached Object) (io.ReadCloser, Object, error) {
+	obj, err := d.Stat(ctx, name)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	if obj.Size == cached.Size && obj.Modified.Equal(cached.Modified) {
+		return nil, obj, ErrNotModified
+	}
+	r, err := d.Open(ctx, name)
+	return r, obj, err
+}
+
+// uploadDir returns the part directory of an upload.
+func (d *DirBackend) uploadDir(uploadID string) (string, error) {
+	if _, err := hex.DecodeString(uploadID); err != nil || len(uploadID) != 32 {
+		return "", fmt.Errorf("invalid upload ID %q", uploadID)
+	}
+	dir := filepath.Join(d.dir, dirUploads, uploadID)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		return "", ErrUploadNotFound
+	}
+	return dir, nil
+}
+
+// CreateUpload implements Backend.
+func (d *DirBackend) CreateUpload(ctx context.Context, name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	var id [16]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return "", err
+	}
+	uploadID := hex.EncodeToString(id[:])
+	if err := os.MkdirAll(filepath.Join(d.dir, dirUploads, uploadID), 0o755); err != nil {
+		return "", err
+	}
+	return uploadID, nil
+}
+
+// UploadPart implements Backend. Parts are written to a temporary file and
+// renamed, so a stored part is always complete.
+func (d *DirBackend) UploadPart(ctx context.Context, name, uploadID string, number int, data []byte) (Part, error) {
+	dir, err := d.uploadDir(uploadID)
+	if err != nil {
+		return Part{}, err
+	}
+	partPath := filepath.Join(dir, strconv.Itoa(number))
+	if err := writeFileSync(partPath+".tmp", data); err != nil {
+		return Part{}, err
+	}
+	if err := os.Rename(partPath+".tmp", partPath); err != nil {
+		return Part{}, err
+	}
+	sum := md5.Sum(data)
+	return Part{Number: number, Size: int64(len(data)), ETag: hex.EncodeToString(sum[:])}, nil
+}
+
+// CompleteUpload implements Backend.
+func (d *DirBackend) CompleteUpload(ctx context.Context, name, uploadID string, parts []Part) error {
+	dir, err := d.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	dest, err := d.path(name)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	for i, part := range parts {
+		if part.Number != i+1 {
+			tmp.Close()
+			return fmt.Errorf("part %d is missing", i+1)
+		}
+		if err := appendPart(tmp, filepath.Join(dir, strconv.Itoa(part.Number)), part); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// appendPart copies a stored part to w after checking it against part.
+func appendPart(w io.Writer, partPath string, part Part) error {
+	f, err := os.Open(partPath)
+	if err != nil {
+		return fmt.Errorf("part %d: %w", part.Number, err)
+	}
+	defer f.Close()
+	h := md5.New()
+	n, err := io.Copy(io.MultiWriter(w, h), f)
+	if err != nil {
+		return err
+	}
+	if n != part.Size || hex.EncodeToString(h.Sum(nil)) != part.ETag {
+		return fmt.Errorf("part %d does not match its checksum", part.Number)
+	}
+	return nil
+}
+
+// AbortUpload implements Backend.
+func (d *DirBackend) AbortUpload(ctx context.Context, name, uploadID string) error {
+	dir, err := d.uploadDir(uploadID)
+	if err != nil {
+		return err
+	}
+	return os.RemoveAll(dir)
+}
+
+// writeFileSync writes data to a new file and flushes it to disk.
+func writeFileSync(name string, data []byte) error {
+	f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,153 @@
+// ⭐ REMOTE-001: Directory backend tests - Uploads into a local directory - 🧪
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var fakeTime = time.Date(2024, 3, 20, 14, 30, 0, 0, time.UTC)
+
+// failingReader fails reads at or beyond an offset.
+type failingReader struct {
+	r      io.ReaderAt
+	failAt int64
+}
+
+func (f failingReader) ReadAt(p []byte, off int64) (int, error) {
+	if off >= f.failAt {
+		return 0, errors.New("disk error")
+	}
+	return f.r.ReadAt(p, off)
+}
+
+func TestDirBackend(t *testing.T) {
+	dir := t.TempDir()
+	b, err := Open("file://"+filepath.ToSlash(dir), Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	data := bytes.Repeat([]byte("abcdefgh"), 1500000) // 12 MB
+	session := NewSession(b.URL(), "proj/full.zip", "full.zip", int64(len(data)), fakeTime, 0)
+	save := func(*Session) error { return nil }
+	err = Upload(ctx, b, failingReader{bytes.NewReader(data), 2 * MinPartSize}, session, save, nil)
+	if err == nil || len(session.Parts) != 2 {
+		t.Fatalf("Expected the third part to fail, got %v with %d parts", err, len(session.Parts))
+	}
+	if _, err := b.Stat(ctx, "proj/full.zip"); !errors.Is(err, ErrNotExist) {
+		t.Fatalf("Expected no object before the upload completes, got %v", err)
+	}
+
+	var progress []int64
+	if err := Upload(ctx, b, bytes.NewReader(data), session, save, func(n int64) { progress = append(progress, n) }); err != nil {
+		t.Fatal(err)
+	}
+	if len(progress) != 1 || progress[0] != int64(len(data)) {
+		t.Errorf("Expected progress for the last part only, got %v", progress)
+	}
+	got, err := os.ReadFile(filepath.Join(dir, "proj", "full.zip"))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("Uploaded file differs: %v", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(dir, dirUploads)); len(entries) != 0 {
+		t.Errorf("Expected the parts to be removed, got %d uploads", len(entries))
+	}
+
+	empty := NewSession(b.URL(), "empty.zip", "empty.zip", 0, fakeTime, 0)
+	if err := Upload(ctx, b, bytes.NewReader(nil), empty, save, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	objects, err := b.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Name != "empty.zip" || objects[1].Name != "proj/full.zip" {
+		t.Errorf("Unexpected listing %+v", objects)
+	}
+
+	if _, _, err := b.OpenIfChanged(ctx, "empty.zip", objects[0]); !errors.Is(err, ErrNotModified) {
+		t.Errorf("Expected an unchanged file to be reported, got %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "empty.zip"), []byte("now full"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if rc, obj, err := b.OpenIfChanged(ctx, "empty.zip", objects[0]); err != nil || obj.Size != 8 {
+		t.Errorf("Expected the changed file, got %+v, %v", obj, err)
+	} else {
+		rc.Close()
+	}
+
+	id, _ := b.CreateUpload(ctx, "x.zip")
+	part, _ := b.UploadPart(ctx, "x.zip", id, 1, []byte("abc"))
+	part.ETag = "0000"
+	if err := b.CompleteUpload(ctx, "x.zip", id, []Part{part}); err == nil {
+		t.Error("Expected a part with a wrong checksum to be refused")
+	}
+	if err := b.AbortUpload(ctx, "x.zip", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.UploadPart(ctx, "x.zip", id, 1, nil); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Expected ErrUploadNotFound after abort, got %v", err)
+	}
+
+	for _, name := range []string{"", "../x", "/abs", "a/../b", `a\b`} {
+		if _, err := b.Open(ctx, name); err == nil || errors.Is(err, ErrNotExist) {
+			t.Errorf("Expected name %q to be refused, got %v", name, err)
+		}
+	}
+	if _, err := Open("ftp://host/dir", Options{}); err == nil {
+		t.Error("Expected an unsupported scheme to be refused")
+	}
+}
+
+func TestNewSession(t *testing.T) {
+	if s := NewSession("r", "n", "s", 10, fakeTime, 1); s.PartSize != MinPartSize {
+		t.Errorf("Expected the minimum part size, got %d", s.PartSize)
+	}
+	huge := int64(MaxParts) * DefaultPartSize * 2
+	if s := NewSession("r", "n", "s", huge, fakeTime, DefaultPartSize); s.PartSize*MaxParts < huge {
+		t.Errorf("Part size %d needs more than %d parts", s.PartSize, MaxParts)
+	}
+}
+
+func TestUploadDigest(t *testing.T) {
+	b, err := NewDirBackend(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	save := func(*Session) error { return nil }
+	data := bytes.Repeat([]byte("0123456789abcdef"), 700000) // 11.2 MB: three parts
+	sum := sha256.Sum256(data)
+
+	s := NewSession(b.URL(), "ok.zip", "ok.zip", int64(len(data)), fakeTime, 0)
+	s.SHA256 = hex.EncodeToString(sum[:])
+	if err := Upload(ctx, b, failingReader{bytes.NewReader(data), MinPartSize}, s, save, nil); err == nil {
+		t.Fatal("Expected the second part to fail")
+	}
+	if err := Upload(ctx, b, bytes.NewReader(data), s, save, nil); err != nil {
+		t.Fatalf("Expected the resumed upload to hash the stored part and complete, got %v", err)
+	}
+
+	corrupt := append([]byte(nil), data...)
+	corrupt[len(corrupt)-1] ^= 1
+	s = NewSession(b.URL(), "bad.zip", "bad.zip", int64(len(data)), fakeTime, 0)
+	s.SHA256 = hex.EncodeToString(sum[:])
+	if err := Upload(ctx, b, bytes.NewReader(corrupt), s, save, nil); !errors.Is(err, ErrDigestMismatch) {
+		t.Fatalf("Expected ErrDigestMismatch, got %v", err)
+	}
+	if _, err := b.Stat(ctx, "bad.zip"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Expected the mismatching upload to stay uncompleted, got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+// ⭐ REMOTE-001: Remote storage backends - Archives stored off the machine - 🔧
+//
+// Package remote stores archive files in remote storage: S3 and compatible
+// object stores, and directories such as network mounts. Backends are named
+// by URL (s3://bucket/prefix, file:///mnt/nas/backups or a plain path) and
+// share one interface, so uploads, listings and copies work the same on
+// each. Uploads are multipart: each part is stored separately and the
+// object appears only when the upload completes, which lets an interrupted
+// upload resume from its last stored part.
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNotExist is returned for objects that are not stored.
+	ErrNotExist = errors.New("object does not exist")
+	// ErrUploadNotFound is returned when the backend no longer knows an upload.
+	ErrUploadNotFound = errors.New("upload does not exist")
+	// ErrNotModified is returned by OpenIfChanged for unchanged objects.
+	ErrNotModified = errors.New("object not modified")
+)
+
+// Object describes a stored object. Names are relative to the backend URL
+// and use forward slashes.
+type Object struct {
+	Name     string
+	Size     int64
+	Modified time.Time
+	ETag     string
+}
+
+// Part is a stored part of a multipart upload.
+type Part struct {
+	Number int    `json:"number"`
+	Size   int64  `json:"size"`
+	ETag   string `json:"etag"`
+}
+
+// Backend stores objects below one location.
+type Backend interface {
+	// URL returns the location of the backend.
+	URL() string
+	// List returns the stored objects sorted by name.
+	List(ctx context.Context) ([]Object, error)
+	// Stat describes one object.
+	Stat(ctx context.Context, name string) (Object, error)
+	// Open reads one object.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// OpenIfChanged reads one object unless it still matches the ETag and
+	// modification time of cached, in which case it returns ErrNotModified.
+	// It also returns the current description of the object.
+	OpenIfChanged(ctx context.Context, name string, cached Object) (io.ReadCloser, Object, error)
+
+	// CreateUpload starts a multipart upload of name and returns its ID.
+	CreateUpload(ctx context.Context, name string) (string, error)
+	// UploadPart stores part number (from 1) of an upload.
+	UploadPart(ctx context.Context, name, uploadID string, number int, data []byte) (Part, error)
+	// CompleteUpload assembles the parts, in order, into the object.
+	CompleteUpload(ctx context.Context, name, uploadID string, parts []Part) error
+	// AbortUpload discards an upload and its parts.
+	AbortUpload(ctx context.Context, name, uploadID string) error
+}
+
+// ⭐ THAW-001: Archival storage classes - 🔧
+// ThawState describes whether an object in an archival storage class can be
+// read. Objects that are not archived can always be read.
+type ThawState struct {
+	StorageClass string    // Storage class of the object; empty for the default class
+	Archived     bool      // The class must be restored before the object is read
+	Ongoing      bool      // A restore was requested and has not completed
+	Restored     bool      // A restored copy can be read until Expiry
+	Expiry       time.Time // End of the restored copy
+}
+
+// Readable reports whether the object can be read now.
+func (t ThawState) Readable() bool {
+	return !t.Archived || t.Restored
+}
+
+// Thawer is implemented by backends whose objects may sit in an archival
+// storage class, such as S3 Glacier, and need a restore before they are read.
+// Backends without such classes do not implement it.
+type Thawer interface {
+	// ThawState reports whether name can be read.
+	ThawState(ctx context.Context, name string) (ThawState, error)
+	// Thaw requests a readable copy of name for days, at the retrieval tier
+	// (Expedited, Standard or Bulk). A restore already in progress is not an
+	// error.
+	Thaw(ctx context.Context, name string, days int, tier string) error
+}
+
+// Options configures backends.
+type Options struct {
+	S3 S3Options
+}
+
+// ⭐ REMOTE-001: Backend selection - 🔍
+// Open returns the backend for rawURL: s3://BUCKET/PREFIX, file://DIR or a
+// directory path.
+func Open(rawURL string, opts Options) (Backend, error) {
+	if !strings.Contains(rawURL, "://") {
+		return NewDirBackend(rawURL)
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid remote %q: %w", rawURL, err)
+	}
+	switch u.Scheme {
+	case "file":
+		if u.Host != "" && u.Host != "localhost" {
+			return nil, fmt.Errorf("remote %q: file URLs must name a local directory", rawURL)
+		}
+		return NewDirBackend(filepath.FromSlash(u.Path))
+	case "s3":
+		if u.Host == "" {
+			return nil, fmt.Errorf("remote %q names no bucket", rawURL)
+		}
+		return NewS3Backend(u.Host, strings.Trim(u.Path, "/"), opts.S3)
+	}
+	return nil, fmt.Errorf("remote %q: unsupported scheme %q (use s3:// or a directory)", rawURL, u.Scheme)
+}
+
+// checkName refuses object names that would leave the backend location.
+func checkName(name string) error {
+	clean := path.Clean(name)
+	if name == "" || clean != name || clean == "." || path.IsAbs(clean) ||
+		clean == ".." || strings.HasPrefix(clean, "../") || strings.Contains(name, "\\") {
+		return fmt.Errorf("invalid object name %q", name)
+	}
+	return nil
+}
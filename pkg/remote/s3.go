@@ -0,0 +1,512 @@
+// ⭐ REMOTE-001: S3 backend - Amazon S3 and compatible stores over signed HTTP - 🔧
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3Options configures S3 backends. Empty credentials and region fall back
+// to AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and
+// AWS_REGION (or AWS_DEFAULT_REGION, then us-east-1).
+type S3Options struct {
+	Region          string
+	Endpoint        string // Base URL of an S3-compatible store; requests use path-style addressing
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	StorageClass    string // Storage class of uploaded objects, e.g. GLACIER; empty for the bucket default
+	Client          *http.Client
+}
+
+// S3Backend stores objects in a bucket below a key prefix.
+type S3Backend struct {
+	bucket string
+	prefix string // Key prefix without trailing slash; empty for the bucket root
+	opts   S3Options
+	base   *url.URL // Bucket URL
+	now    func() time.Time
+}
+
+// NewS3Backend returns the backend of bucket below prefix.
+func NewS3Backend(bucket, prefix string, opts S3Options) (*S3Backend, error) {
+	if opts.Region == "" {
+		opts.Region = firstEnv("AWS_REGION", "AWS_DEFAULT_REGION")
+		if opts.Region == "" {
+			opts.Region = "us-east-1"
+		}
+	}
+	if opts.AccessKeyID == "" && opts.SecretAccessKey == "" {
+		opts.AccessKeyID = os.Getenv("AWS_ACCESS_KEY_ID")
+		opts.SecretAccessKey = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		if opts.SessionToken == "" {
+			opts.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+		}
+	}
+	if opts.AccessKeyID == "" || opts.SecretAccessKey == "" {
+		return nil, errors.New("S3 credentials are not set (configure them or set AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY)")
+	}
+	if opts.Client == nil {
+		opts.Client = http.DefaultClient
+	}
+
+	var base *url.URL
+	var err error
+	switch {
+	case opts.Endpoint != "":
+		base, err = url.Parse(strings.TrimSuffix(opts.Endpoint, "/") + "/" + bucket)
+	case strings.Contains(bucket, "."):
+		// Dotted bucket names do not match the wildcard certificate
+		base, err = url.Parse(fmt.Sprintf("https://s3.%s.amazonaws.com/%s", opts.Region, bucket))
+	default:
+		base, err = url.Parse(fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, opts.Region))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid S3 endpoint: %w", err)
+	}
+	return &S3Backend{bucket: bucket, prefix: prefix, opts: opts, base: base, now: time.Now}, nil
+}
+
+// firstEnv returns the first non-empty environment variable of names.
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// URL implements Backend.
+func (s *S3Backend) URL() string {
+	if s.prefix == "" {
+		return "s3://" + s.bucket
+	}
+	return "s3://" + s.bucket + "/" + s.prefix
+}
+
+// key returns the S3 key of an object.
+func (s *S3Backend) key(name string) (string, error) {
+	if err := checkName(name); err != nil {
+		return "", err
+	}
+	if s.prefix == "" {
+		return name, nil
+	}
+	return s.prefix + "/" + name, nil
+}
+
+// s3Error is the XML error document of S3.
+type s3Error struct {
+	Code    string `xml:"Code"`
+	Message string `xml:"Message"`
+}
+
+// do signs and sends a request. key is empty for bucket requests. Non-2xx
+// answers are returned as errors; NoSuchKey and NoSuchUpload become
+// ErrNotExist and ErrUploadNotFound.
+func (s *S3Backend) do(ctx context.Context, method, key string, query url.Values, body []byte, header http.Header) (*http.Response, error) {
+	u := *s.base
+	if key != "" {
+		u.Path = strings.TrimSuffix(u.Path, "/") + "/" + key
+	}
+	if u.Path == "" {
+		u.Path = "/"
+	}
+	u.RawPath = s3Escape(u.Path, false)
+	u.RawQuery = canonicalQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	s.sign(req, body)
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode/100 == 2 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	var e s3Error
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	_ = xml.Unmarshal(data, &e)
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		return nil, ErrNotModified
+	case e.Code == "NoSuchUpload":
+		return nil, ErrUploadNotFound
+	case e.Code == "NoSuchKey" || resp.StatusCode == http.StatusNotFound && method == http.MethodHead:
+		return nil, ErrNotExist
+	case e.Code != "":
+		return nil, fmt.Errorf("S3 %s: %s", e.Code, e.Message)
+	}
+	return nil, fmt.Errorf("S3 returned %s", resp.Status)
+}
+
+// ⭐ REMOTE-001: AWS Signature Version 4 - 🛡️
+// sign adds the SigV4 authorization of req, signing every header it carries.
+func (s *S3Backend) sign(req *http.Request, body []byte) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payload := sha256.Sum256(body)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payload[:]))
+	if s.opts.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.opts.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "authorization" || lower == "user-agent" {
+			continue
+		}
+		trimmed := make([]string, len(values))
+		for i, v := range values {
+			trimmed[i] = strings.Join(strings.Fields(v), " ")
+		}
+		headers[lower] = strings.Join(trimmed, ",")
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payload[:]),
+	}, "\n")
+	scope := date + "/" + s.opts.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.opts.SecretAccessKey), date)
+	key = hmacSHA256(key, s.opts.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.opts.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape URI-encodes s as SigV4 requires: everything but unreserved
+// characters, and slashes unless encodeSlash is set.
+func s3Escape(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9',
+			c == '-', c == '_', c == '.', c == '~', c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// canonicalQuery encodes query sorted by name, as SigV4 signs it.
+func canonicalQuery(query url.Values) string {
+	var pairs []string
+	for name, values := range query {
+		for _, v := range values {
+			pairs = append(pairs, s3Escape(name, true)+"="+s3Escape(v, true))
+		}
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, "&")
+}
+
+// List implements Backend with ListObjectsV2.
+func (s *S3Backend) List(ctx context.Context) ([]Object, error) {
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.prefix + "/"
+	}
+	var objects []Object
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		resp, err := s.do(ctx, http.MethodGet, "", query, nil, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result struct {
+			Contents []struct {
+				Key          string    `xml:"Key"`
+				Size         int64     `xml:"Size"`
+				LastModified time.Time `xml:"LastModified"`
+				ETag         string    `xml:"ETag"`
+			} `xml:"Contents"`
+			IsTruncated           bool   `xml:"IsTruncated"`
+			NextContinuationToken string `xml:"NextContinuationToken"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("reading S3 listing: %w", err)
+		}
+		for _, c := range result.Contents {
+			name := strings.TrimPrefix(c.Key, prefix)
+			if name == "" || strings.HasSuffix(name, "/") {
+				continue
+			}
+			objects = append(objects, Object{Name: name, Size: c.Size, Modified: c.LastModified, ETag: strings.Trim(c.ETag, `"`)})
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			break
+		}
+		token = result.NextContinuationToken
+	}
+	sort.Slice(objects, func(i, j int) bool { return objects[i].Name < objects[j].Name })
+	return objects, nil
+}
+
+// Stat implements Backend.
+func (s *S3Backend) Stat(ctx context.Context, name string) (Object, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return Object{}, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return Object{}, err
+	}
+	resp.Body.Close()
+	return s3Object(name, resp), nil
+}
+
+// ⭐ THAW-001: Glacier restores - 🔧
+// archivedClasses are the storage classes whose objects must be restored
+// before they can be read.
+var archivedClasses = map[string]bool{"GLACIER": true, "DEEP_ARCHIVE": true}
+
+// ThawState implements Thawer from the x-amz-storage-class and x-amz-restore
+// headers of a HEAD request.
+func (s *S3Backend) ThawState(ctx context.Context, name string) (ThawState, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return ThawState{}, err
+	}
+	resp, err := s.do(ctx, http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return ThawState{}, err
+	}
+	resp.Body.Close()
+	state := ThawState{StorageClass: resp.Header.Get("X-Amz-Storage-Class")}
+	state.Archived = archivedClasses[state.StorageClass]
+	// ongoing-request="false", expiry-date="Fri, 21 Dec 2012 00:00:00 GMT"
+	if restore := resp.Header.Get("X-Amz-Restore"); restore != "" {
+		state.Ongoing = strings.Contains(restore, `ongoing-request="true"`)
+		state.Restored = strings.Contains(restore, `ongoing-request="false"`)
+		if _, expiry, found := strings.Cut(restore, `expiry-date="`); found {
+			state.Expiry, _ = http.ParseTime(strings.TrimSuffix(strings.TrimSpace(expiry), `"`))
+		}
+	}
+	return state, nil
+}
+
+// Thaw implements Thawer with a RestoreObject request.
+func (s *S3Backend) Thaw(ctx context.Context, name string, days int, tier string) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	body := []byte(fmt.Sprintf("<RestoreRequest><Days>%d</Days><GlacierJobParameters><Tier>%s</Tier></GlacierJobParameters></RestoreRequest>", days, tier))
+	sum := md5.Sum(body)
+	header := http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])}}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"restore": {""}}, body, header)
+	if err != nil {
+		if strings.Contains(err.Error(), "RestoreAlreadyInProgress") {
+			return nil
+		}
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// s3Object describes an object from the headers of a HEAD or GET response.
+func s3Object(name string, resp *http.Response) Object {
+	modified, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return Object{Name: name, Size: resp.ContentLength, Modified: modified, ETag: strings.Trim(resp.Header.Get("ETag"), `"`)}
+}
+
+// Open implements Backend.
+func (s *S3Backend) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// OpenIfChanged implements Backend with a conditional GET: If-None-Match
+// with the cached ETag, or If-Modified-Since when there is none.
+func (s *S3Backend) OpenIfChanged(ctx context.Context, name string, cached Object) (io.ReadCloser, Object, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return nil, Object{}, err
+	}
+	header := http.Header{}
+	if cached.ETag != "" {
+		header.Set("If-None-Match", `"`+cached.ETag+`"`)
+	} else if !cached.Modified.IsZero() {
+		header.Set("If-Modified-Since", cached.Modified.UTC().Format(http.TimeFormat))
+	}
+	resp, err := s.do(ctx, http.MethodGet, key, nil, nil, header)
+	if errors.Is(err, ErrNotModified) {
+		return nil, cached, err
+	}
+	if err != nil {
+		return nil, Object{}, err
+	}
+	return resp.Body, s3Object(name, resp), nil
+}
+
+// CreateUpload implements Backend.
+func (s *S3Backend) CreateUpload(ctx context.Context, name string) (string, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return "", err
+	}
+	var header http.Header
+	if s.opts.StorageClass != "" {
+		header = http.Header{"X-Amz-Storage-Class": {s.opts.StorageClass}}
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploads": {""}}, nil, header)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var result struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || result.UploadID == "" {
+		return "", fmt.Errorf("S3 returned no upload ID")
+	}
+	return result.UploadID, nil
+}
+
+// UploadPart implements Backend. Content-MD5 lets S3 reject parts that were
+// corrupted in transit.
+func (s *S3Backend) UploadPart(ctx context.Context, name, uploadID string, number int, data []byte) (Part, error) {
+	key, err := s.key(name)
+	if err != nil {
+		return Part{}, err
+	}
+	sum := md5.Sum(data)
+	header := http.Header{"Content-Md5": {base64.StdEncoding.EncodeToString(sum[:])}}
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {uploadID}}
+	resp, err := s.do(ctx, http.MethodPut, key, query, data, header)
+	if err != nil {
+		return Part{}, err
+	}
+	resp.Body.Close()
+	etag := strings.Trim(resp.Header.Get("ETag"), `"`)
+	if etag == "" {
+		return Part{}, errors.New("S3 returned no ETag for the part")
+	}
+	return Part{Number: number, Size: int64(len(data)), ETag: etag}, nil
+}
+
+// CompleteUpload implements Backend. S3 can report a failed completion in
+// the body of a 200 answer, so the body is checked for an error.
+func (s *S3Backend) CompleteUpload(ctx context.Context, name, uploadID string, parts []Part) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	type completedPart struct {
+		PartNumber int    `xml:"PartNumber"`
+		ETag       string `xml:"ETag"`
+	}
+	var request struct {
+		XMLName xml.Name        `xml:"CompleteMultipartUpload"`
+		Parts   []completedPart `xml:"Part"`
+	}
+	for _, p := range parts {
+		request.Parts = append(request.Parts, completedPart{PartNumber: p.Number, ETag: `"` + p.ETag + `"`})
+	}
+	body, err := xml.Marshal(request)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodPost, key, url.Values{"uploadId": {uploadID}}, body, http.Header{"Content-Type": {"application/xml"}})
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return err
+	}
+	var e s3Error
+	if xml.Unmarshal(data, &e) == nil && e.Code != "" {
+		if e.Code == "NoSuchUpload" {
+			return ErrUploadNotFound
+		}
+		return fmt.Errorf("S3 %s: %s", e.Code, e.Message)
+	}
+	return nil
+}
+
+// AbortUpload implements Backend.
+func (s *S3Backend) AbortUpload(ctx context.Context, name, uploadID string) error {
+	key, err := s.key(name)
+	if err != nil {
+		return err
+	}
+	resp, err := s.do(ctx, http.MethodDelete, key, url.Values{"uploadId": {uploadID}}, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
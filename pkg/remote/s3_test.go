@@ -0,0 +1,361 @@
+// ⭐ REMOTE-001: S3 backend tests - An in-memory S3 endpoint - 🧪
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 serves one bucket with path-style addressing.
+type fakeS3 struct {
+	t              *testing.T
+	mu             sync.Mutex
+	objects        map[string][]byte
+	uploads        map[string]map[int][]byte
+	nextID         int
+	failPart       int               // Part number answered with an error once
+	puts           int               // Number of stored parts
+	class          string            // Storage class of the last started upload
+	archived       map[string]string // Storage class of archived objects
+	restores       map[string]string // x-amz-restore header of archived objects
+	restoreRequest string            // Body of the last restore request
+}
+
+func newFakeS3(t *testing.T) (*fakeS3, *httptest.Server) {
+	f := &fakeS3{t: t, objects: make(map[string][]byte), uploads: make(map[string]map[int][]byte),
+		archived: make(map[string]string), restores: make(map[string]string)}
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func (f *fakeS3) fail(w http.ResponseWriter, status int, code string) {
+	w.WriteHeader(status)
+	fmt.Fprintf(w, "<Error><Code>%s</Code><Message>%s</Message></Error>", code, code)
+}
+
+// fakeETag returns the ETag of an object stored in one piece.
+func fakeETag(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+		f.fail(w, http.StatusForbidden, "AccessDenied")
+		return
+	}
+	body, _ := io.ReadAll(r.Body)
+	key := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, "/bucket"), "/")
+	q := r.URL.Query()
+	id := q.Get("uploadId")
+
+	switch {
+	case r.Method == http.MethodGet && key == "":
+		var keys []string
+		for k := range f.objects {
+			if strings.HasPrefix(k, q.Get("prefix")) {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		start := 0
+		if token := q.Get("continuation-token"); token != "" {
+			start, _ = strconv.Atoi(token)
+		}
+		end := min(start+1, len(keys))
+		fmt.Fprint(w, "<ListBucketResult>")
+		for _, k := range keys[start:end] {
+			fmt.Fprintf(w, "<Contents><Key>%s</Key><Size>%d</Size><LastModified>2024-03-20T14:30:00.000Z</LastModified><ETag>&quot;%s&quot;</ETag></Contents>", k, len(f.objects[k]), fakeETag(f.objects[k]))
+		}
+		if end < len(keys) {
+			fmt.Fprintf(w, "<IsTruncated>true</IsTruncated><NextContinuationToken>%d</NextContinuationToken>", end)
+		}
+		fmt.Fprint(w, "</ListBucketResult>")
+	case r.Method == http.MethodPost && q.Has("restore"):
+		if f.restores[key] == `ongoing-request="true"` {
+			f.fail(w, http.StatusConflict, "RestoreAlreadyInProgress")
+			return
+		}
+		f.restoreRequest = string(body)
+		f.restores[key] = `ongoing-request="true"`
+		w.WriteHeader(http.StatusAccepted)
+	case r.Method == http.MethodHead || r.Method == http.MethodGet:
+		data, ok := f.objects[key]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		if class := f.archived[key]; class != "" {
+			w.Header().Set("X-Amz-Storage-Class", class)
+			if restore := f.restores[key]; restore != "" {
+				w.Header().Set("X-Amz-Restore", restore)
+			}
+			if r.Method == http.MethodGet && !strings.Contains(f.restores[key], `"false"`) {
+				f.fail(w, http.StatusForbidden, "InvalidObjectState")
+				return
+			}
+		}
+		etag := `"` + fakeETag(data) + `"`
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", etag)
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("Last-Modified", "Wed, 20 Mar 2024 14:30:00 GMT")
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		f.nextID++
+		id := fmt.Sprintf("upload-%d", f.nextID)
+		f.uploads[id] = make(map[int][]byte)
+		f.class = r.Header.Get("X-Amz-Storage-Class")
+		fmt.Fprintf(w, "<InitiateMultipartUploadResult><UploadId>%s</UploadId></InitiateMultipartUploadResult>", id)
+	case r.Method == http.MethodPut:
+		parts, ok := f.uploads[id]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(q.Get("partNumber"))
+		if number == f.failPart {
+			f.failPart = 0
+			f.fail(w, http.StatusInternalServerError, "InternalError")
+			return
+		}
+		sum := md5.Sum(body)
+		if r.Header.Get("Content-Md5") != base64.StdEncoding.EncodeToString(sum[:]) {
+			f.fail(w, http.StatusBadRequest, "BadDigest")
+			return
+		}
+		parts[number] = body
+		f.puts++
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:])+`"`)
+	case r.Method == http.MethodPost && id != "":
+		parts, ok := f.uploads[id]
+		if !ok {
+			f.fail(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		var req struct {
+			Parts []struct {
+				PartNumber int
+				ETag       string
+			} `xml:"Part"`
+		}
+		if err := xml.Unmarshal(body, &req); err != nil {
+			f.fail(w, http.StatusBadRequest, "MalformedXML")
+			return
+		}
+		var data []byte
+		for _, p := range req.Parts {
+			sum := md5.Sum(parts[p.PartNumber])
+			if p.ETag != `"`+hex.EncodeToString(sum[:])+`"` {
+				fmt.Fprint(w, "<Error><Code>InvalidPart</Code><Message>bad etag</Message></Error>")
+				return
+			}
+			data = append(data, parts[p.PartNumber]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, id)
+		fmt.Fprint(w, "<CompleteMultipartUploadResult/>")
+	case r.Method == http.MethodDelete && id != "":
+		delete(f.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.fail(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+func TestS3Backend(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	b, err := Open("s3://bucket/backups", Options{S3: S3Options{Endpoint: srv.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.URL() != "s3://bucket/backups" {
+		t.Errorf("Unexpected URL %s", b.URL())
+	}
+	ctx := context.Background()
+
+	data := bytes.Repeat([]byte("0123456789"), 1200000) // 12 MB: parts of 5, 5 and 2 MB
+	session := NewSession(b.URL(), "proj/a b.zip", "a b.zip", int64(len(data)), fakeTime, 0)
+	var saved []int
+	save := func(s *Session) error { saved = append(saved, len(s.Parts)); return nil }
+
+	fake.failPart = 2
+	if err := Upload(ctx, b, bytes.NewReader(data), session, save, nil); err == nil || !strings.Contains(err.Error(), "InternalError") {
+		t.Fatalf("Expected the second part to fail, got %v", err)
+	}
+	if len(session.Parts) != 1 || session.Uploaded() != MinPartSize {
+		t.Fatalf("Expected one stored part, got %+v", session.Parts)
+	}
+	if err := Upload(ctx, b, bytes.NewReader(data), session, save, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fake.puts != 3 {
+		t.Errorf("Expected the resumed upload to send only the missing parts, got %d puts", fake.puts)
+	}
+	if fmt.Sprint(saved) != "[0 1 2 3]" {
+		t.Errorf("Expected the session to be saved after each part, got %v", saved)
+	}
+	if !bytes.Equal(fake.objects["backups/proj/a b.zip"], data) {
+		t.Fatal("Uploaded object differs")
+	}
+
+	fake.objects["backups/other.zip"] = []byte("x")
+	fake.objects["elsewhere.zip"] = []byte("y")
+	objects, err := b.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(objects) != 2 || objects[0].Name != "other.zip" || objects[1].Name != "proj/a b.zip" || objects[1].Size != int64(len(data)) {
+		t.Errorf("Unexpected listing %+v", objects)
+	}
+
+	if obj, err := b.Stat(ctx, "other.zip"); err != nil || obj.Size != 1 || obj.Modified.IsZero() {
+		t.Errorf("Unexpected stat %+v, %v", obj, err)
+	}
+	if _, err := b.Stat(ctx, "missing.zip"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Expected ErrNotExist from Stat, got %v", err)
+	}
+	if _, err := b.Open(ctx, "missing.zip"); !errors.Is(err, ErrNotExist) {
+		t.Errorf("Expected ErrNotExist from Open, got %v", err)
+	}
+	rc, err := b.Open(ctx, "other.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "x" {
+		t.Errorf("Unexpected content %q", got)
+	}
+
+	cached := objects[0]
+	if _, obj, err := b.OpenIfChanged(ctx, "other.zip", cached); !errors.Is(err, ErrNotModified) || obj.ETag != cached.ETag {
+		t.Errorf("Expected an unchanged object to be reported, got %+v, %v", obj, err)
+	}
+	fake.objects["backups/other.zip"] = []byte("changed")
+	rc, obj, err := b.OpenIfChanged(ctx, "other.zip", cached)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ = io.ReadAll(rc)
+	rc.Close()
+	if string(got) != "changed" || obj.ETag == cached.ETag {
+		t.Errorf("Expected the changed object, got %q with %+v", got, obj)
+	}
+
+	id, err := b.CreateUpload(ctx, "aborted.zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := b.AbortUpload(ctx, "aborted.zip", id); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.UploadPart(ctx, "aborted.zip", id, 1, []byte("x")); !errors.Is(err, ErrUploadNotFound) {
+		t.Errorf("Expected ErrUploadNotFound after abort, got %v", err)
+	}
+	if _, err := b.Stat(ctx, "../escape.zip"); err == nil {
+		t.Error("Expected names leaving the prefix to be refused")
+	}
+	if fake.class != "" {
+		t.Errorf("Expected no storage class by default, got %q", fake.class)
+	}
+
+	cold, err := Open("s3://bucket/cold", Options{S3: S3Options{Endpoint: srv.URL, AccessKeyID: "AKID", SecretAccessKey: "secret", StorageClass: "GLACIER"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cold.CreateUpload(ctx, "old.zip"); err != nil {
+		t.Fatal(err)
+	}
+	if fake.class != "GLACIER" {
+		t.Errorf("Expected the upload to request GLACIER, got %q", fake.class)
+	}
+}
+
+func TestS3Credentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := Open("s3://bucket", Options{}); err == nil || !strings.Contains(err.Error(), "credentials") {
+		t.Errorf("Expected missing credentials to be reported, got %v", err)
+	}
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKID")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_REGION", "eu-west-1")
+	b, err := NewS3Backend("my.bucket", "", S3Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b.base.String() != "https://s3.eu-west-1.amazonaws.com/my.bucket" {
+		t.Errorf("Expected path-style addressing for a dotted bucket, got %s", b.base)
+	}
+	if b, _ := NewS3Backend("bucket", "", S3Options{}); b.base.String() != "https://bucket.s3.eu-west-1.amazonaws.com" {
+		t.Errorf("Expected virtual-host addressing, got %s", b.base)
+	}
+}
+
+func TestS3Thaw(t *testing.T) {
+	fake, srv := newFakeS3(t)
+	b, err := NewS3Backend("bucket", "cold", S3Options{Endpoint: srv.URL, AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	fake.objects["cold/warm.zip"] = []byte("warm")
+	fake.objects["cold/old.zip"] = []byte("old")
+	fake.archived["cold/old.zip"] = "GLACIER"
+
+	if state, err := b.ThawState(ctx, "warm.zip"); err != nil || !state.Readable() || state.Archived {
+		t.Errorf("Expected a readable object, got %+v, %v", state, err)
+	}
+	state, err := b.ThawState(ctx, "old.zip")
+	if err != nil || state.Readable() || state.StorageClass != "GLACIER" {
+		t.Fatalf("Expected an archived object, got %+v, %v", state, err)
+	}
+	if _, err := b.Open(ctx, "old.zip"); err == nil || !strings.Contains(err.Error(), "InvalidObjectState") {
+		t.Errorf("Expected reading an archived object to fail, got %v", err)
+	}
+
+	if err := b.Thaw(ctx, "old.zip", 2, "Bulk"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(fake.restoreRequest, "<Days>2</Days>") || !strings.Contains(fake.restoreRequest, "<Tier>Bulk</Tier>") {
+		t.Errorf("Unexpected restore request %s", fake.restoreRequest)
+	}
+	if err := b.Thaw(ctx, "old.zip", 2, "Bulk"); err != nil {
+		t.Errorf("Expected a restore in progress to be accepted, got %v", err)
+	}
+	if state, _ := b.ThawState(ctx, "old.zip"); !state.Ongoing || state.Readable() {
+		t.Errorf("Expected an ongoing restore, got %+v", state)
+	}
+
+	fake.restores["cold/old.zip"] = `ongoing-request="false", expiry-date="Fri, 22 Mar 2024 00:00:00 GMT"`
+	state, err = b.ThawState(ctx, "old.zip")
+	if err != nil || !state.Readable() || state.Expiry.Day() != 22 {
+		t.Fatalf("Expected a restored copy, got %+v, %v", state, err)
+	}
+	if _, err := b.Open(ctx, "old.zip"); err != nil {
+		t.Errorf("Expected the restored copy to be readable, got %v", err)
+	}
+}
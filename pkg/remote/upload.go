@@ -0,0 +1,135 @@
+// ⭐ REMOTE-001: Resumable uploads - Multipart uploads driven by a session - 🔧
+package remote
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"time"
+)
+
+// ErrDigestMismatch is returned by Upload when the uploaded bytes do not
+// have the SHA-256 the session expects.
+var ErrDigestMismatch = errors.New("content does not match its expected SHA-256")
+
+// Part sizes of multipart uploads. S3 requires at least 5 MiB for every part
+// but the last and allows at most 10,000 parts.
+const (
+	DefaultPartSize = 16 << 20
+	MinPartSize     = 5 << 20
+	MaxParts        = 10000
+)
+
+// Session is the state of one upload. It is saved after every stored part,
+// so an interrupted upload continues from the saved session.
+type Session struct {
+	Remote   string    `json:"remote"`    // Backend URL
+	Name     string    `json:"name"`      // Object name
+	Source   string    `json:"source"`    // Local file
+	Size     int64     `json:"size"`      // Size of the local file
+	ModTime  time.Time `json:"mod_time"`  // Modification time of the local file
+	PartSize int64     `json:"part_size"` // Size of every part but the last
+	UploadID string    `json:"upload_id"` // Backend upload ID; empty until created
+	Parts    []Part    `json:"parts"`     // Stored parts, in order
+	Started  time.Time `json:"started"`
+	// ⭐ TRANSIT-VERIFY-001: Expected SHA-256 of the whole object; empty skips the check
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// NewSession returns the session of a new upload of a local file with the
+// given size. partSize is raised when the file needs more than MaxParts.
+func NewSession(remote, name, source string, size int64, modTime time.Time, partSize int64) *Session {
+	if partSize < MinPartSize {
+		partSize = MinPartSize
+	}
+	if need := (size + MaxParts - 1) / MaxParts; partSize < need {
+		partSize = need
+	}
+	return &Session{
+		Remote: remote, Name: name, Source: source, Size: size, ModTime: modTime,
+		PartSize: partSize, Started: time.Now(),
+	}
+}
+
+// Uploaded returns the number of bytes stored so far.
+func (s *Session) Uploaded() int64 {
+	var n int64
+	for _, p := range s.Parts {
+		n += p.Size
+	}
+	return n
+}
+
+// ⭐ REMOTE-001: Upload loop - 🔧
+// Upload stores the parts of src that the session does not hold yet and
+// completes the upload. save is called whenever the session changes; an
+// upload interrupted by an error or cancellation resumes by calling Upload
+// again with the saved session. progress, if not nil, receives the number
+// of bytes stored so far after each part.
+//
+// When the session names a SHA-256, the bytes are hashed as they are sent,
+// after re-reading the parts stored by earlier runs, and an upload whose
+// content does not match is left uncompleted with ErrDigestMismatch.
+func Upload(ctx context.Context, b Backend, src io.ReaderAt, s *Session, save func(*Session) error, progress func(int64)) error {
+	if s.UploadID == "" {
+		id, err := b.CreateUpload(ctx, s.Name)
+		if err != nil {
+			return fmt.Errorf("starting upload of %s: %w", s.Name, err)
+		}
+		s.UploadID, s.Parts = id, nil
+		if err := save(s); err != nil {
+			return err
+		}
+	}
+
+	offset := s.Uploaded()
+	buf := make([]byte, s.PartSize)
+	var digest hash.Hash
+	if s.SHA256 != "" {
+		digest = sha256.New()
+		if _, err := io.Copy(digest, io.NewSectionReader(src, 0, offset)); err != nil {
+			return fmt.Errorf("reading %s: %w", s.Source, err)
+		}
+	}
+	for offset < s.Size || len(s.Parts) == 0 {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		n := int(min(s.PartSize, s.Size-offset))
+		if _, err := src.ReadAt(buf[:n], offset); err != nil && !(errors.Is(err, io.EOF) && n == 0) {
+			return fmt.Errorf("reading %s: %w", s.Source, err)
+		}
+		if digest != nil {
+			digest.Write(buf[:n])
+		}
+		part, err := b.UploadPart(ctx, s.Name, s.UploadID, len(s.Parts)+1, buf[:n])
+		if err != nil {
+			return fmt.Errorf("uploading part %d of %s: %w", len(s.Parts)+1, s.Name, err)
+		}
+		s.Parts = append(s.Parts, part)
+		if err := save(s); err != nil {
+			return err
+		}
+		offset += int64(n)
+		if progress != nil {
+			progress(offset)
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if digest != nil {
+		if sum := hex.EncodeToString(digest.Sum(nil)); sum != s.SHA256 {
+			return fmt.Errorf("%w: %s has SHA-256 %s, want %s", ErrDigestMismatch, s.Source, sum, s.SHA256)
+		}
+	}
+	if err := b.CompleteUpload(ctx, s.Name, s.UploadID, s.Parts); err != nil {
+		return fmt.Errorf("completing upload of %s: %w", s.Name, err)
+	}
+	return nil
+}
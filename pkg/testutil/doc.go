@@ -15,6 +15,8 @@
 //   - CLI Testing: Helpers for testing command-line interfaces and cobra commands
 //   - Test Assertions: Common assertion functions for various data types
 //   - Test Fixtures: Reusable test data and setup patterns
+//   - Integration Testing: Isolated bkpdir environments with their own HOME and
+//     BKPDIR_CONFIG, git repository builders and an in-process command runner
 //
 // # Usage Examples
 //
@@ -47,6 +49,12 @@
 //	testutil.AssertStringEqual(t, "field name", got, want)
 //	testutil.AssertSliceEqual(t, "slice field", gotSlice, wantSlice)
 //
+// Integration Testing:
+//
+//	env := testutil.NewEnvironment(t)
+//	env.GitRepo().Commit("Initial commit", map[string]string{"main.go": "package main\n"})
+//	result := env.Runner(testutil.CobraMain(newRootCmd)).Run(t, "full", "note")
+//
 // # Design Principles
 //
 // The testutil package follows these design principles:
@@ -0,0 +1,110 @@
+// ⭐ HARNESS-001: Integration test harness - 🧪
+package testutil
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// TestEnvironment tests the isolation of an integration test environment
+func TestEnvironment(t *testing.T) {
+	env := NewEnvironment(t)
+
+	if got := os.Getenv("HOME"); got != env.Home {
+		t.Errorf("HOME = %q, want %q", got, env.Home)
+	}
+	if got := os.Getenv("BKPDIR_CONFIG"); got != env.ConfigPath {
+		t.Errorf("BKPDIR_CONFIG = %q, want %q", got, env.ConfigPath)
+	}
+	if got := os.Getenv("XDG_STATE_HOME"); !strings.HasPrefix(got, env.Root) {
+		t.Errorf("XDG_STATE_HOME = %q is outside the environment", got)
+	}
+	AssertFileContent(t, env.ConfigPath,
+		fmt.Sprintf("archive_dir_path: %s\nbackup_dir_path: %s\n", env.ArchiveDir, env.BackupDir), "config")
+
+	env.WriteFiles(map[string]string{"src/main.go": "package main\n"})
+	AssertFileExists(t, env.Path("src/main.go"), "work file")
+}
+
+// TestGitRepo tests building a repository with commits, branches and tags
+func TestGitRepo(t *testing.T) {
+	env := NewEnvironment(t)
+	repo := env.GitRepo()
+
+	first := repo.Commit("Initial commit", map[string]string{"README.md": "hello\n"})
+	AssertStringEqual(t, "branch", repo.CurrentBranch(), "main")
+	repo.Tag("v1.0.0")
+
+	repo.Branch("feature/x")
+	second := repo.Commit("Add feature", map[string]string{"feature.txt": "x\n"})
+	if first == second || len(second) != 40 {
+		t.Errorf("commits %q and %q", first, second)
+	}
+	AssertStringEqual(t, "branch", repo.CurrentBranch(), "feature/x")
+	AssertStringEqual(t, "log", repo.Git("log", "--format=%s %an"), "Add feature Test User\nInitial commit Test User")
+
+	repo.Checkout("v1.0.0")
+	AssertStringEqual(t, "detached branch", repo.CurrentBranch(), "")
+	AssertStringEqual(t, "head", repo.Head(), first)
+	AssertFileNotExists(t, filepath.Join(repo.Dir, "feature.txt"), "feature file")
+}
+
+// TestRunner tests running commands in-process
+func TestRunner(t *testing.T) {
+	env := NewEnvironment(t)
+	env.WriteFiles(map[string]string{"input.txt": "from file"})
+
+	// exit stands in for os.Exit in the application under test
+	exit := Exit
+	newRoot := func() *cobra.Command {
+		root := &cobra.Command{Use: "app", SilenceUsage: true}
+		root.AddCommand(&cobra.Command{
+			Use: "cat FILE",
+			RunE: func(cmd *cobra.Command, args []string) error {
+				data, err := os.ReadFile(args[0])
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(data))
+				stdin, _ := io.ReadAll(os.Stdin)
+				fmt.Fprintf(os.Stderr, "stdin: %s", stdin)
+				return nil
+			},
+		}, &cobra.Command{
+			Use: "quit",
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Println("quitting")
+				exit(3)
+				t.Error("Exit returned")
+			},
+		})
+		return root
+	}
+	runner := env.Runner(CobraMain(newRoot))
+	runner.Stdin = "typed"
+
+	result := runner.MustRun(t, "cat", "input.txt")
+	if result.Stdout != "from file" || result.Stderr != "stdin: typed" {
+		t.Errorf("cat: %s", result)
+	}
+
+	result = runner.Run(t, "quit")
+	if result.ExitCode != 3 || result.Stdout != "quitting\n" {
+		t.Errorf("quit: %s", result)
+	}
+
+	result = runner.Run(t, "cat", "missing.txt")
+	if result.ExitCode != 1 || !strings.Contains(result.Stderr, "missing.txt") {
+		t.Errorf("cat missing file: %s", result)
+	}
+
+	if wd, _ := os.Getwd(); wd == env.WorkDir {
+		t.Error("working directory was not restored")
+	}
+}
@@ -0,0 +1,176 @@
+// This file is part of bkpdir
+//
+// Package main provides the plugin pipeline. Configured plugins are external
+// programs that see each archived file before it is compressed and may keep,
+// skip or replace it, or abort the archive, for example to scrub personal
+// data or scan for malware.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/plugin"
+)
+
+// pluginPipelineKey stores the running pipeline in the archive context.
+type pluginPipelineKey struct{}
+
+// pluginStage is one running plugin with the files it is sent.
+type pluginStage struct {
+	client  *plugin.Client
+	matcher *fileops.PatternMatcher // nil sends every file
+}
+
+// ⭐ PLUGIN-001: Plugin pipeline - 🔧
+// pluginPipeline runs the configured plugins on the regular files of one
+// archive. Content replaced by a plugin is written to a file in tempDir so the
+// next plugin can read it.
+type pluginPipeline struct {
+	stages  []pluginStage
+	tempDir string
+}
+
+// ⭐ PLUGIN-001: Plugin startup - 🔧
+// startPluginPipeline starts the plugins for an archive of root and sends
+// them the begin message. It returns nil when no plugins are configured.
+func startPluginPipeline(ctx context.Context, plugins []PluginConfig, root, archive string) (p *pluginPipeline, err error) {
+	if len(plugins) == 0 {
+		return nil, nil
+	}
+	p = &pluginPipeline{}
+	defer func() {
+		if err != nil {
+			p.Close()
+			p = nil
+		}
+	}()
+	for i, pc := range plugins {
+		if pc.Name == "" || pc.Command == "" {
+			return p, fmt.Errorf("plugins[%d] needs a name and a command", i)
+		}
+		client, err := plugin.Start(ctx, pc.Name, expandPath(pc.Command), pc.Args, root)
+		if err != nil {
+			return p, err
+		}
+		stage := pluginStage{client: client}
+		if len(pc.Patterns) > 0 {
+			stage.matcher = fileops.NewPatternMatcher(pc.Patterns)
+		}
+		p.stages = append(p.stages, stage)
+		if err := client.Begin(archive, root); err != nil {
+			return p, err
+		}
+	}
+	return p, nil
+}
+
+// withPluginPipeline returns ctx carrying p, or ctx itself when p is nil.
+func withPluginPipeline(ctx context.Context, p *pluginPipeline) context.Context {
+	if p == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, pluginPipelineKey{}, p)
+}
+
+// pluginPipelineFrom returns the pipeline carried by ctx, if any.
+func pluginPipelineFrom(ctx context.Context) *pluginPipeline {
+	if ctx == nil {
+		return nil
+	}
+	p, _ := ctx.Value(pluginPipelineKey{}).(*pluginPipeline)
+	return p
+}
+
+// ⭐ PLUGIN-001: Per-file plugin processing - 🔧
+// addFile runs the plugins on a regular file and archives the result. It
+// reports false, leaving the file to the normal path, when the file is not a
+// regular file or every plugin kept it unchanged. A skipped file is left out
+// with a note; a failing plugin aborts the archive.
+func (p *pluginPipeline) addFile(sourceDir, rel string, zipw *zip.Writer) (bool, error) {
+	abs := filepath.Join(sourceDir, rel)
+	info, err := os.Lstat(abs)
+	if err != nil || !info.Mode().IsRegular() {
+		return false, nil
+	}
+
+	entry := filepath.ToSlash(rel)
+	contentPath, size := abs, info.Size()
+	var replaced []byte
+	isReplaced := false
+	for _, stage := range p.stages {
+		if stage.matcher != nil && !stage.matcher.ShouldExclude(entry) {
+			continue
+		}
+		resp, err := stage.client.Process(plugin.Request{
+			Type:        plugin.TypeFile,
+			Path:        entry,
+			ContentPath: contentPath,
+			Size:        size,
+			Mode:        uint32(info.Mode().Perm()),
+		})
+		if err != nil {
+			return false, err
+		}
+		switch resp.Action {
+		case plugin.ActionSkip:
+			fmt.Fprintf(os.Stderr, "Plugin %s skipped %s: %s\n", stage.client.Name(), entry, resp.Reason)
+			return true, nil
+		case plugin.ActionFail:
+			return false, fmt.Errorf("plugin %s rejected %s: %s", stage.client.Name(), entry, resp.Reason)
+		case plugin.ActionReplace:
+			if contentPath, err = p.stageContent(resp.Data); err != nil {
+				return false, err
+			}
+			replaced, size, isReplaced = resp.Data, int64(len(resp.Data)), true
+		}
+	}
+	if !isReplaced {
+		return false, nil
+	}
+	return true, writeZipEntry(zipw, info, rel, bytes.NewReader(replaced))
+}
+
+// stageContent writes replaced content to the temporary file read by the
+// next plugin. Plugins run one file at a time, so one file is reused.
+func (p *pluginPipeline) stageContent(data []byte) (string, error) {
+	if p.tempDir == "" {
+		dir, err := os.MkdirTemp("", "bkpdir-plugins-")
+		if err != nil {
+			return "", err
+		}
+		p.tempDir = dir
+	}
+	path := filepath.Join(p.tempDir, "content")
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Close ends every plugin and removes replaced content. It returns the first
+// plugin error. A nil pipeline is a no-op.
+func (p *pluginPipeline) Close() error {
+	if p == nil {
+		return nil
+	}
+	var first error
+	for _, stage := range p.stages {
+		if err := stage.client.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	if p.tempDir != "" {
+		os.RemoveAll(p.tempDir)
+	}
+	return first
+}
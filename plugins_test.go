@@ -0,0 +1,102 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the plugin pipeline.
+// It verifies that plugins replace and skip archived files, that patterns
+// select the files a plugin sees, and that a failing plugin stops the archive.
+package main
+
+import (
+	"archive/zip"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// scrubPlugin answers the plugin protocol from a shell script: it replaces
+// secret.txt, skips cache.tmp and fails on virus.bin.
+const scrubPlugin = `while read -r line; do
+  case "$line" in
+    *'"type":"end"'*) exit 0 ;;
+    *secret.txt*) echo '{"action":"replace","data":"c2NydWJiZWQ="}' ;;
+    *cache.tmp*) echo '{"action":"skip","reason":"temporary file"}' ;;
+    *virus.bin*) echo '{"action":"fail","reason":"EICAR test signature"}' ;;
+    *) echo '{"action":"keep"}' ;;
+  esac
+done`
+
+// archiveContents returns the entries of the only archive below dir.
+func archiveContents(t *testing.T, dir string) map[string]string {
+	t.Helper()
+	archives, _ := filepath.Glob(filepath.Join(dir, "*", "*.zip"))
+	if len(archives) != 1 {
+		t.Fatalf("Expected one archive, got %v", archives)
+	}
+	r, err := zip.OpenReader(archives[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	contents := make(map[string]string)
+	for _, f := range r.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, _ := io.ReadAll(rc)
+		rc.Close()
+		contents[f.Name] = string(data)
+	}
+	return contents
+}
+
+// ⭐ PLUGIN-001: Plugins processing archived files - 🧪
+func TestPluginPipeline(t *testing.T) {
+	sourceDir := t.TempDir()
+	for name, content := range map[string]string{"a.txt": "alpha", "secret.txt": "ssn 123", "cache.tmp": "x", "sub/secret.txt": "ssn 456"} {
+		path := filepath.Join(sourceDir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Earlier tests may leave the working directory removed
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = filepath.Join(t.TempDir(), "archives")
+	cfg.Plugins = []PluginConfig{{Name: "scrub", Command: writeHookScript(t, scrubPlugin), Patterns: []string{"*.tmp", "sub/**"}}}
+	if err := CreateFullArchive(cfg, "", false, true); err != nil {
+		t.Fatalf("CreateFullArchive failed: %v", err)
+	}
+	want := map[string]string{"a.txt": "alpha", "secret.txt": "ssn 123", "sub/secret.txt": "scrubbed"}
+	if got := archiveContents(t, cfg.ArchiveDirPath); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected archive contents %v, got %v", want, got)
+	}
+
+	// A failing plugin stops the archive
+	if err := os.WriteFile(filepath.Join(sourceDir, "virus.bin"), []byte("X5O"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	cfg.ArchiveDirPath = filepath.Join(t.TempDir(), "failed")
+	cfg.Plugins[0].Patterns = nil
+	err := CreateFullArchive(cfg, "", false, false)
+	if err == nil || !strings.Contains(err.Error(), "EICAR") {
+		t.Errorf("Expected the plugin to reject virus.bin, got %v", err)
+	}
+	if entries, _ := filepath.Glob(filepath.Join(cfg.ArchiveDirPath, "*", "*")); len(entries) != 0 {
+		t.Errorf("Expected no archive after a plugin failure, got %v", entries)
+	}
+
+	cfg.Plugins = []PluginConfig{{Name: "missing", Command: filepath.Join(t.TempDir(), "missing")}}
+	if err := CreateFullArchive(cfg, "", false, false); err == nil {
+		t.Error("Expected a missing plugin command to fail")
+	}
+}
@@ -0,0 +1,77 @@
+// This file is part of bkpdir
+//
+// Package main provides power-aware scheduling: with `power_aware` set, full
+// and incremental archives and verification are deferred while a laptop runs
+// on battery or the system is busy, so scheduled runs do not drain it.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// powerState is what power_aware looks at before heavy work.
+type powerState struct {
+	OnBattery bool
+	// Load is the 1-minute load average divided by the number of CPUs;
+	// negative when it is unknown.
+	Load float64
+}
+
+// readPowerState reads the power state of this system; tests replace it.
+var readPowerState = platformPowerState
+
+// ⭐ POWER-001: Deferring heavy work - 🔧
+// checkPowerState returns an error with status_deferred when cfg is power
+// aware and the system runs on battery or its load is above
+// power_max_load_percent. ignore is --ignore-power. Conditions that cannot be
+// detected never defer.
+func checkPowerState(cfg *Config, ignore bool, action string) error {
+	if !cfg.PowerAware || ignore {
+		return nil
+	}
+	state := readPowerState()
+	var reason string
+	switch {
+	case state.OnBattery:
+		reason = "running on battery power"
+	case cfg.PowerMaxLoadPercent > 0 && state.Load*100 > float64(cfg.PowerMaxLoadPercent):
+		reason = fmt.Sprintf("system load is %.0f%% of the CPUs (power_max_load_percent: %d)",
+			state.Load*100, cfg.PowerMaxLoadPercent)
+	default:
+		return nil
+	}
+	return NewArchiveError(fmt.Sprintf("Deferred %s: %s; run again later or use --ignore-power", action, reason),
+		cfg.StatusDeferred)
+}
+
+// loadPerCPU divides a load average by the number of CPUs.
+func loadPerCPU(load float64) float64 {
+	return load / float64(runtime.NumCPU())
+}
+
+// parseLoadAverage returns the first number of /proc/loadavg or of
+// `sysctl -n vm.loadavg` ("{ 1.52 1.71 1.80 }").
+func parseLoadAverage(text string) (float64, bool) {
+	for _, field := range strings.Fields(text) {
+		if field == "{" {
+			continue
+		}
+		load, err := strconv.ParseFloat(field, 64)
+		return load, err == nil
+	}
+	return 0, false
+}
+
+// parsePmsetBattery reports whether `pmset -g batt` says the system draws
+// from its battery.
+func parsePmsetBattery(text string) bool {
+	first, _, _ := strings.Cut(text, "\n")
+	return strings.Contains(first, "'Battery Power'")
+}
@@ -0,0 +1,29 @@
+//go:build darwin
+
+// This file is part of bkpdir
+//
+// Package main provides the battery and load detection of power_aware on
+// macOS, from `pmset -g batt` and `sysctl -n vm.loadavg`.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import "os/exec"
+
+// ⭐ POWER-001: macOS power state - 🔍
+// platformPowerState reports battery power when pmset says the system draws
+// from its battery.
+func platformPowerState() powerState {
+	state := powerState{Load: -1}
+	if out, err := exec.Command("pmset", "-g", "batt").Output(); err == nil {
+		state.OnBattery = parsePmsetBattery(string(out))
+	}
+	if out, err := exec.Command("sysctl", "-n", "vm.loadavg").Output(); err == nil {
+		if load, ok := parseLoadAverage(string(out)); ok {
+			state.Load = loadPerCPU(load)
+		}
+	}
+	return state
+}
@@ -0,0 +1,45 @@
+//go:build linux
+
+// This file is part of bkpdir
+//
+// Package main provides the battery and load detection of power_aware on
+// Linux, from /sys/class/power_supply and /proc/loadavg.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ⭐ POWER-001: Linux power state - 🔍
+// platformPowerState reports battery power when a battery is discharging.
+func platformPowerState() powerState {
+	state := powerState{Load: -1}
+	supplies, _ := filepath.Glob("/sys/class/power_supply/*")
+	for _, supply := range supplies {
+		if readSysValue(filepath.Join(supply, "type")) == "Battery" &&
+			readSysValue(filepath.Join(supply, "status")) == "Discharging" {
+			state.OnBattery = true
+		}
+	}
+	if data, err := os.ReadFile("/proc/loadavg"); err == nil {
+		if load, ok := parseLoadAverage(string(data)); ok {
+			state.Load = loadPerCPU(load)
+		}
+	}
+	return state
+}
+
+// readSysValue returns the trimmed content of a sysfs attribute.
+func readSysValue(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
@@ -0,0 +1,17 @@
+//go:build !linux && !darwin
+
+// This file is part of bkpdir
+//
+// Package main provides the power state of systems where power_aware cannot
+// detect battery power or load.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+// platformPowerState reports AC power and an unknown load, so nothing is
+// deferred.
+func platformPowerState() powerState {
+	return powerState{Load: -1}
+}
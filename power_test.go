@@ -0,0 +1,69 @@
+// This file is part of bkpdir
+
+// Package main provides tests for power-aware scheduling.
+// It verifies the battery and load parsing and when runs are deferred.
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+// ⭐ POWER-001: Deferring on battery and under load - 🧪
+func TestCheckPowerState(t *testing.T) {
+	state := powerState{Load: -1}
+	orig := readPowerState
+	readPowerState = func() powerState { return state }
+	defer func() { readPowerState = orig }()
+
+	cfg := DefaultConfig()
+	state.OnBattery = true
+	if err := checkPowerState(cfg, false, "full archive"); err != nil {
+		t.Errorf("deferred without power_aware: %v", err)
+	}
+
+	cfg.PowerAware = true
+	err := checkPowerState(cfg, false, "full archive")
+	var archiveErr *ArchiveError
+	if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusDeferred {
+		t.Fatalf("on battery: %v", err)
+	}
+	if err := checkPowerState(cfg, true, "full archive"); err != nil {
+		t.Errorf("--ignore-power: %v", err)
+	}
+
+	state = powerState{Load: 0.95}
+	if err := checkPowerState(cfg, false, "verification"); err == nil {
+		t.Error("a load above power_max_load_percent should defer")
+	}
+	cfg.PowerMaxLoadPercent = 0
+	if err := checkPowerState(cfg, false, "verification"); err != nil {
+		t.Errorf("load checked with power_max_load_percent 0: %v", err)
+	}
+	state = powerState{Load: -1}
+	cfg.PowerMaxLoadPercent = 80
+	if err := checkPowerState(cfg, false, "verification"); err != nil {
+		t.Errorf("unknown load deferred: %v", err)
+	}
+}
+
+// ⭐ POWER-001: Platform output parsing - 🧪
+func TestPowerStateParsing(t *testing.T) {
+	for text, want := range map[string]float64{
+		"0.52 0.58 0.59 1/389 12345\n": 0.52,
+		"{ 1.52 1.71 1.80 }\n":         1.52,
+	} {
+		if got, ok := parseLoadAverage(text); !ok || got != want {
+			t.Errorf("parseLoadAverage(%q) = %v, %v", text, got, ok)
+		}
+	}
+	if _, ok := parseLoadAverage(""); ok {
+		t.Error("empty load average parsed")
+	}
+
+	battery := "Now drawing from 'Battery Power'\n -InternalBattery-0 (id=1234)\t85%; discharging; 4:10 remaining present: true\n"
+	ac := "Now drawing from 'AC Power'\n -InternalBattery-0 (id=1234)\t100%; charged; 0:00 remaining present: true\n"
+	if !parsePmsetBattery(battery) || parsePmsetBattery(ac) {
+		t.Error("pmset power source misread")
+	}
+}
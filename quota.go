@@ -0,0 +1,178 @@
+// This file is part of bkpdir
+//
+// Package main provides archive directory quotas: a new archive that would
+// bring the archives of a directory over `quota` is refused, or room is made
+// for it by moving the oldest archive chains to the trash.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ⭐ QUOTA-001: Quota policies - 🔧
+const (
+	// QuotaPolicyFail refuses an archive that would exceed the quota.
+	QuotaPolicyFail = "fail"
+	// QuotaPolicyPrune moves the oldest archive chains to the trash until
+	// the new archive fits.
+	QuotaPolicyPrune = "prune"
+)
+
+// archiveChain is a full archive with the incremental archives based on it,
+// named after the full archive even when only incrementals are left.
+type archiveChain struct {
+	Name     string
+	Archives []Archive
+	Created  time.Time
+	Size     int64
+}
+
+// quotaLimit parses the quota settings of cfg; 0 means no quota.
+func quotaLimit(cfg ArchiveConfigInterface) (int64, error) {
+	limit, err := ParseByteSize(cfg.GetQuota())
+	if err != nil {
+		return 0, NewArchiveErrorWithCause("Invalid quota", cfg.GetStatusConfigError(), err)
+	}
+	switch cfg.GetQuotaPolicy() {
+	case "", QuotaPolicyFail, QuotaPolicyPrune:
+	default:
+		return 0, NewArchiveError(fmt.Sprintf("Invalid quota_policy %q (use fail or prune)", cfg.GetQuotaPolicy()), cfg.GetStatusConfigError())
+	}
+	return limit, nil
+}
+
+// archiveChains groups archives into chains, oldest first by the creation
+// time of their first archive.
+func archiveChains(archives []Archive) []*archiveChain {
+	byName := make(map[string]*archiveChain)
+	var chains []*archiveChain
+	for _, a := range archives {
+		name := a.Name
+		if base, _, found := strings.Cut(a.Name, "_update="); found {
+			name = base + ".zip"
+		}
+		chain := byName[name]
+		if chain == nil {
+			chain = &archiveChain{Name: name, Created: a.CreationTime}
+			byName[name] = chain
+			chains = append(chains, chain)
+		}
+		chain.Archives = append(chain.Archives, a)
+		if a.CreationTime.Before(chain.Created) {
+			chain.Created = a.CreationTime
+		}
+		if info, err := os.Stat(a.Path); err == nil {
+			chain.Size += info.Size()
+		}
+	}
+	sort.Slice(chains, func(i, j int) bool { return chains[i].Created.Before(chains[j].Created) })
+	return chains
+}
+
+// ⭐ QUOTA-001: Quota enforcement - 🛡️
+// enforceArchiveQuota checks that an archive of size bytes, about to be
+// committed as archivePath, keeps the archives of its directory within the
+// quota. With the prune policy the oldest chains are moved to the trash
+// first, never the newest one nor the chain archivePath belongs to; nothing
+// is moved unless that makes the archive fit. Pruning needs the trash
+// settings of a full configuration; other configurations fail as with the
+// fail policy.
+func enforceArchiveQuota(cfg ArchiveConfigInterface, archivePath string, size int64) error {
+	limit, err := quotaLimit(cfg)
+	if err != nil || limit == 0 {
+		return err
+	}
+	archiveDir := filepath.Dir(archivePath)
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	var existing []Archive
+	var used int64
+	for _, a := range archives {
+		// ⭐ FOREIGN-001: Foreign files neither count nor move
+		if a.Path == archivePath || isForeignName(cfg.GetPatternArchiveFilename(), archiveDir, a.Name) {
+			continue
+		}
+		if info, err := os.Stat(a.Path); err == nil {
+			used += info.Size()
+			existing = append(existing, a)
+		}
+	}
+	if used+size <= limit {
+		return nil
+	}
+
+	exceeded := NewArchiveError(fmt.Sprintf("%s (%s) would bring %s to %s, over its quota of %s",
+		filepath.Base(archivePath), formatHumanSize(size), archiveDir, formatHumanSize(used+size), formatHumanSize(limit)),
+		cfg.GetStatusQuotaExceeded())
+	adapter, ok := cfg.(*ConfigToArchiveConfigAdapter)
+	if cfg.GetQuotaPolicy() != QuotaPolicyPrune || !ok {
+		return exceeded
+	}
+
+	own := filepath.Base(archivePath)
+	if base, _, found := strings.Cut(own, "_update="); found {
+		own = base + ".zip"
+	}
+	chains := archiveChains(existing)
+	var prune []*archiveChain
+	for i, chain := range chains {
+		if used+size <= limit {
+			break
+		}
+		if i == len(chains)-1 || chain.Name == own {
+			continue
+		}
+		prune = append(prune, chain)
+		used -= chain.Size
+	}
+	if used+size > limit {
+		return exceeded
+	}
+	for _, chain := range prune {
+		// Incrementals go first, so a chain is never left without its base
+		sort.Slice(chain.Archives, func(i, j int) bool { return chain.Archives[i].IsIncremental && !chain.Archives[j].IsIncremental })
+		for _, a := range chain.Archives {
+			if err := trashPrunedArchive(adapter.cfg, a.Path, "quota "+formatHumanSize(limit)); err != nil {
+				return NewArchiveErrorWithCause(fmt.Sprintf("Cannot make room for %s", filepath.Base(archivePath)), cfg.GetStatusQuotaExceeded(), err)
+			}
+			fmt.Fprintf(os.Stderr, "Quota: moved %s to the trash to stay within %s\n", a.Name, formatHumanSize(limit))
+		}
+	}
+	return nil
+}
+
+// ⭐ TRASH-001: Pruning through the trash - 🛡️
+// trashPrunedArchive moves a pruned archive and its sidecars in .metadata to
+// the trash, sidecars first so `bkpdir undo` brings the archive back first,
+// and records the prune with reason in the audit log and as a pruned event.
+func trashPrunedArchive(cfg *Config, archivePath, reason string) error {
+	sidecars, _ := filepath.Glob(filepath.Join(filepath.Dir(archivePath), ".metadata", globEscape(filepath.Base(archivePath))+".*"))
+	for _, sidecar := range sidecars {
+		if _, err := MoveToTrash(cfg, sidecar); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+	item, err := MoveToTrash(cfg, archivePath)
+	detail := reason
+	if err == nil {
+		detail += ", moved to the trash as " + item.Name
+	}
+	// ⭐ EVENT-001: Report the prune to the system log
+	emitArchiveEvent(cfg.EventLog, OperationPrune, archivePath, err)
+	// ⭐ AUDIT-001: Record the prune in the audit log
+	recordAudit(cfg, AuditActionPrune, archivePath, detail, err)
+	return err
+}
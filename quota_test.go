@@ -0,0 +1,136 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive directory quotas.
+// It verifies that archives over the quota are refused, and that the prune
+// policy moves the oldest archive chains and their sidecars to the trash to
+// make room, recording each one in the audit log and as an event.
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ QUOTA-001: Quota policies - 🧪
+func TestArchiveQuota(t *testing.T) {
+	dir := t.TempDir()
+	journal := filepath.Join(dir, "journal.json")
+	t.Setenv(journalEnvVar, journal)
+	fake := &fakeEventLogger{}
+	orig := openEventLogger
+	openEventLogger = func() (eventLogger, error) { return fake, nil }
+	defer func() { openEventLogger = orig }()
+	archiveDir := filepath.Join(dir, "archives")
+	if err := os.MkdirAll(archiveDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.Quota = "350B"
+	cfg.ArchiveDirPath = archiveDir
+	cfg.TrashDirPath = filepath.Join(dir, "trash")
+	cfg.AuditLog = true
+	cfg.EventLog = EventLogSyslog
+
+	base := time.Now().Add(-time.Hour)
+	for i, name := range []string{
+		"proj-2024-01-01-10-00.zip",
+		"proj-2024-01-01-10-00_update=2024-01-02-10-00.zip",
+		"proj-2024-02-01-10-00.zip",
+		"proj-2024-03-01-10-00.zip",
+	} {
+		path := filepath.Join(archiveDir, name)
+		if err := os.WriteFile(path, make([]byte, 100), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		stamp := base.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sidecar := filepath.Join(archiveDir, ".metadata", "proj-2024-01-01-10-00.zip.git.json")
+	if err := os.MkdirAll(filepath.Dir(sidecar), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(sidecar, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	next := filepath.Join(archiveDir, "proj-2024-04-01-10-00.zip")
+
+	err := enforceArchiveQuota(&ConfigToArchiveConfigAdapter{cfg: cfg}, next, 100)
+	var archiveErr *ArchiveError
+	if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusQuotaExceeded {
+		t.Fatalf("fail policy: %v", err)
+	}
+
+	// Only the newest chain and the new archive would remain: still too much
+	cfg.QuotaPolicy = QuotaPolicyPrune
+	if err := enforceArchiveQuota(&ConfigToArchiveConfigAdapter{cfg: cfg}, next, 300); err == nil {
+		t.Fatal("a quota that pruning cannot meet should fail")
+	}
+	if archives, _ := listArchiveEntries(archiveDir); len(archives) != 4 {
+		t.Fatalf("archives were deleted although the archive could not fit: %d left", len(archives))
+	}
+
+	if err := enforceArchiveQuota(&ConfigToArchiveConfigAdapter{cfg: cfg}, next, 100); err != nil {
+		t.Fatalf("prune policy: %v", err)
+	}
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, a := range archives {
+		names = append(names, a.Name)
+	}
+	if got := strings.Join(names, " "); got != "proj-2024-02-01-10-00.zip proj-2024-03-01-10-00.zip" {
+		t.Errorf("kept %s, want the two newest full archives", got)
+	}
+	if _, err := os.Stat(sidecar); !os.IsNotExist(err) {
+		t.Errorf("sidecar of a pruned archive was kept: %v", err)
+	}
+
+	items, err := ListTrash(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 3 {
+		t.Errorf("trash holds %d items, want both archives of the chain and its sidecar", len(items))
+	}
+	entries, err := LoadAuditLog(auditLogPath(cfg))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pruned int
+	for _, e := range entries {
+		if e.Action == AuditActionPrune {
+			pruned++
+		}
+	}
+	if pruned != 2 {
+		t.Errorf("audit log has %d prune entries, want 2", pruned)
+	}
+	var events int
+	for _, line := range fake.info {
+		if strings.HasPrefix(line, "event=pruned operation=prune ") {
+			events++
+		}
+	}
+	if events != 2 {
+		t.Errorf("got %d pruned events, want 2: %q", events, fake.info)
+	}
+
+	j, err := LoadJournal(journal)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := j.Undo(false); err != nil {
+		t.Fatalf("undo of the last pruned archive: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "proj-2024-01-01-10-00.zip")); err != nil {
+		t.Errorf("undo did not restore the pruned archive: %v", err)
+	}
+}
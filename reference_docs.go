@@ -0,0 +1,264 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir docs`, which writes man pages, Markdown or
+// reStructuredText reference pages for every command, together with a page
+// listing every configuration key with its type, default and description.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+	"github.com/cpuguy83/go-md2man/v2/md2man"
+	"github.com/spf13/cobra"
+	"github.com/spf13/cobra/doc"
+)
+
+// DocsOptions configures `bkpdir docs`.
+type DocsOptions struct {
+	Root   *cobra.Command // Command tree to document
+	Format string         // man, markdown or rest
+	Dir    string         // Directory the pages are written to
+	Output io.Writer      // Receives the summary line
+}
+
+// configReferenceName is the base name of the configuration key page.
+const configReferenceName = "bkpdir-config-reference"
+
+// configReferenceCategories orders the categories of configuration keys and
+// names their sections.
+var configReferenceCategories = []struct {
+	Category string
+	Title    string
+}{
+	{"basic_settings", "Basic settings"},
+	{"archive_settings", "Archive settings"},
+	{"backup_settings", "Backup settings"},
+	{"verification", "Verification"},
+	{"inheritance", "Inheritance"},
+	{"status_codes", "Exit status codes"},
+	{"format_strings", "Format strings"},
+	{"template_strings", "Template strings"},
+	{"regex_patterns", "Regular expression patterns"},
+}
+
+// configReferenceEntry documents one configuration key.
+type configReferenceEntry struct {
+	Key      string // YAML path
+	Type     string
+	Default  string // Default value as shown by bkpdir config
+	Category string
+	Doc      configFieldDoc
+	Bool     bool
+}
+
+// ⭐ DOCS-001: Reference documentation generation - 📝
+// GenerateReferenceDocs writes a page per command of opts.Root in the
+// requested format and the configuration key reference to opts.Dir.
+func GenerateReferenceDocs(opts DocsOptions) error {
+	// ⭐ GUARD-001: Checked by the write guard before any page is written
+	if err := fileops.MkdirAll(opts.Dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", opts.Dir, err)
+	}
+	// Pages must not change with the day they are generated on
+	opts.Root.DisableAutoGenTag = true
+
+	entries := configReference()
+	var (
+		name string
+		page []byte
+		err  error
+	)
+	switch opts.Format {
+	case "man":
+		header := &doc.GenManHeader{Title: "BKPDIR", Section: "1", Source: "bkpdir " + Version, Manual: "BkpDir Manual"}
+		if err = doc.GenManTree(opts.Root, header, opts.Dir); err == nil {
+			name, page = configReferenceName+".5", configReferenceMan(entries)
+		}
+	case "markdown":
+		if err = doc.GenMarkdownTree(opts.Root, opts.Dir); err == nil {
+			name, page = configReferenceName+".md", configReferenceMarkdown(entries)
+		}
+	case "rest":
+		if err = doc.GenReSTTree(opts.Root, opts.Dir); err == nil {
+			name, page = configReferenceName+".rst", configReferenceReST(entries)
+		}
+	default:
+		return fmt.Errorf("unknown documentation format %q (use man, markdown or rest)", opts.Format)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to generate %s pages: %w", opts.Format, err)
+	}
+	if err := fileops.WriteFile(filepath.Join(opts.Dir, name), page, 0o644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	if opts.Output != nil {
+		fmt.Fprintf(opts.Output, "Wrote %s pages for %d commands and %d configuration keys to %s\n",
+			opts.Format, countDocumentedCommands(opts.Root), len(entries), opts.Dir)
+	}
+	return nil
+}
+
+// countDocumentedCommands returns the number of commands the doc generators
+// write a page for.
+func countDocumentedCommands(cmd *cobra.Command) int {
+	n := 1
+	for _, c := range cmd.Commands() {
+		if c.IsAvailableCommand() && !c.IsAdditionalHelpTopicCommand() {
+			n += countDocumentedCommands(c)
+		}
+	}
+	return n
+}
+
+// configReference returns every configuration key with its default and
+// description, ordered by category and key.
+func configReference() []configReferenceEntry {
+	rank := make(map[string]int, len(configReferenceCategories))
+	for i, c := range configReferenceCategories {
+		rank[c.Category] = i
+	}
+
+	var entries []configReferenceEntry
+	for _, field := range GetAllConfigFields(DefaultConfig()) {
+		def := "(none)"
+		if field.Value != nil {
+			def = formatFieldValue(field.Value, field.Kind)
+		}
+		// Format strings end with newlines; show them escaped on one line
+		if strings.ContainsAny(def, "\n\t\r") {
+			def = strconv.Quote(def)
+		}
+		entries = append(entries, configReferenceEntry{
+			Key:      configYAMLPath(field.Path),
+			Type:     field.Type,
+			Default:  def,
+			Category: field.Category,
+			Doc:      describeConfigField(field),
+			Bool:     field.Kind == reflect.Bool,
+		})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		ri, iok := rank[entries[i].Category]
+		rj, jok := rank[entries[j].Category]
+		if !iok {
+			ri = len(rank)
+		}
+		if !jok {
+			rj = len(rank)
+		}
+		if ri != rj {
+			return ri < rj
+		}
+		if entries[i].Category != entries[j].Category {
+			return entries[i].Category < entries[j].Category
+		}
+		return entries[i].Key < entries[j].Key
+	})
+	return entries
+}
+
+// configCategoryTitle returns the section title of a category.
+func configCategoryTitle(category string) string {
+	for _, c := range configReferenceCategories {
+		if c.Category == category {
+			return c.Title
+		}
+	}
+	return strings.ReplaceAll(category, "_", " ")
+}
+
+// configReferenceDescription returns the description of an entry followed by
+// its allowed values, environment variable and related keys.
+func configReferenceDescription(e configReferenceEntry) string {
+	parts := []string{strings.TrimSuffix(e.Doc.Description, ".") + "."}
+	if len(e.Doc.Allowed) > 0 && !e.Bool {
+		parts = append(parts, "Allowed: "+strings.Join(e.Doc.Allowed, ", ")+".")
+	}
+	if e.Doc.EnvVar != "" {
+		parts = append(parts, "Environment: "+e.Doc.EnvVar+".")
+	}
+	if len(e.Doc.Related) > 0 {
+		parts = append(parts, "See also: "+strings.Join(e.Doc.Related, ", ")+".")
+	}
+	return strings.Join(parts, " ")
+}
+
+// forEachConfigCategory calls section for each category with its entries.
+func forEachConfigCategory(entries []configReferenceEntry, section func(title string, entries []configReferenceEntry)) {
+	for start := 0; start < len(entries); {
+		end := start
+		for end < len(entries) && entries[end].Category == entries[start].Category {
+			end++
+		}
+		section(configCategoryTitle(entries[start].Category), entries[start:end])
+		start = end
+	}
+}
+
+// configReferenceIntro opens every configuration reference page.
+const configReferenceIntro = "Keys of .bkpdir.yml and the files named by BKPDIR_CONFIG. " +
+	"Nested keys are written with dots; run 'bkpdir config KEY --describe' for the current value of a key."
+
+// configReferenceMarkdown renders the configuration reference as Markdown
+// tables.
+func configReferenceMarkdown(entries []configReferenceEntry) []byte {
+	cell := strings.NewReplacer("|", `\|`, "\n", " ")
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# bkpdir configuration reference\n\n%s\n", configReferenceIntro)
+	forEachConfigCategory(entries, func(title string, entries []configReferenceEntry) {
+		fmt.Fprintf(&b, "\n## %s\n\n| Key | Type | Default | Description |\n| --- | --- | --- | --- |\n", title)
+		for _, e := range entries {
+			fmt.Fprintf(&b, "| `%s` | %s | `%s` | %s |\n",
+				e.Key, cell.Replace(e.Type), cell.Replace(e.Default), cell.Replace(configReferenceDescription(e)))
+		}
+	})
+	return b.Bytes()
+}
+
+// configReferenceReST renders the configuration reference as
+// reStructuredText list tables.
+func configReferenceReST(entries []configReferenceEntry) []byte {
+	title := "bkpdir configuration reference"
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "%s\n%s\n\n%s\n", title, strings.Repeat("=", len(title)), configReferenceIntro)
+	forEachConfigCategory(entries, func(title string, entries []configReferenceEntry) {
+		fmt.Fprintf(&b, "\n%s\n%s\n\n", title, strings.Repeat("-", len(title)))
+		b.WriteString(".. list-table::\n   :header-rows: 1\n   :widths: 25 10 20 45\n\n")
+		b.WriteString("   * - Key\n     - Type\n     - Default\n     - Description\n")
+		for _, e := range entries {
+			fmt.Fprintf(&b, "   * - ``%s``\n     - %s\n     - ``%s``\n     - %s\n",
+				e.Key, e.Type, e.Default, configReferenceDescription(e))
+		}
+	})
+	return b.Bytes()
+}
+
+// configReferenceMan renders the configuration reference as a section 5 man
+// page, one paragraph per key.
+func configReferenceMan(entries []configReferenceEntry) []byte {
+	var b bytes.Buffer
+	// The title block of cobra's man pages, which md2man turns into .TH
+	fmt.Fprintf(&b, "%% %q \"5\" %q %q \"BkpDir Manual\"\n# NAME\n\n%s - configuration keys of bkpdir\n\n# DESCRIPTION\n\n%s\n",
+		strings.ToUpper(configReferenceName), time.Now().Format("Jan 2006"), "bkpdir "+Version, configReferenceName, configReferenceIntro)
+	forEachConfigCategory(entries, func(title string, entries []configReferenceEntry) {
+		fmt.Fprintf(&b, "\n# %s\n", strings.ToUpper(title))
+		for _, e := range entries {
+			fmt.Fprintf(&b, "\n**%s** (%s, default `%s`)\n\n%s\n", e.Key, e.Type, e.Default, configReferenceDescription(e))
+		}
+	})
+	b.WriteString("\n# SEE ALSO\n\n**bkpdir(1)**, **bkpdir-config(1)**\n")
+	return md2man.Render(b.Bytes())
+}
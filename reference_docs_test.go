@@ -0,0 +1,73 @@
+// This file is part of bkpdir
+
+// Package main provides tests for reference documentation generation.
+// It verifies that every format writes the command pages and the
+// configuration key reference built from the description registry.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/spf13/cobra"
+)
+
+// ⭐ DOCS-001: Reference documentation generation - 🧪
+func TestGenerateReferenceDocs(t *testing.T) {
+	root := &cobra.Command{Use: "bkpdir", Short: "Directory archiving"}
+	root.AddCommand(verifyCmd(), docsCmd())
+
+	tests := []struct {
+		format  string
+		pages   []string
+		content []string
+	}{
+		{"markdown", []string{"bkpdir.md", "bkpdir_verify.md", "bkpdir-config-reference.md"},
+			[]string{"## Exit status codes", "| `archive_dir_path` | string | `../.bkpdir` |", "Environment: BKPDIR_ARCHIVE_DIR."}},
+		{"rest", []string{"bkpdir.rst", "bkpdir_verify.rst", "bkpdir-config-reference.rst"},
+			[]string{".. list-table::", "   * - ``verification.checksum_algorithm``"}},
+		{"man", []string{"bkpdir.1", "bkpdir-verify.1", "bkpdir-config-reference.5"},
+			[]string{`.TH "BKPDIR-CONFIG-REFERENCE" "5"`, `\fBarchive_dir_path\fP`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "ref")
+			err := GenerateReferenceDocs(DocsOptions{Root: root, Format: tt.format, Dir: dir})
+			if err != nil {
+				t.Fatalf("GenerateReferenceDocs: %v", err)
+			}
+			for _, page := range tt.pages {
+				if _, err := os.Stat(filepath.Join(dir, page)); err != nil {
+					t.Errorf("page %s not written: %v", page, err)
+				}
+			}
+			data, err := os.ReadFile(filepath.Join(dir, tt.pages[len(tt.pages)-1]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.content {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("configuration reference lacks %q", want)
+				}
+			}
+		})
+	}
+
+	if err := GenerateReferenceDocs(DocsOptions{Root: root, Format: "pdf", Dir: t.TempDir()}); err == nil {
+		t.Error("unknown format should fail")
+	}
+}
+
+// ⭐ DOCS-001: Escaped defaults - 🧪
+func TestConfigReferenceDefaults(t *testing.T) {
+	for _, e := range configReference() {
+		if strings.ContainsAny(e.Default, "\n\r\t") {
+			t.Errorf("default of %s spans lines: %q", e.Key, e.Default)
+		}
+		if e.Key == "format_created_archive" && e.Default != `"Created archive: %s\n"` {
+			t.Errorf("format_created_archive default = %s", e.Default)
+		}
+	}
+}
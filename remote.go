@@ -0,0 +1,80 @@
+// This file is part of bkpdir
+//
+// Package main provides the remote storage settings shared by the commands
+// that copy archives off the machine. Remotes are named by URL: s3://BUCKET
+// with an optional key prefix, file://DIR or a directory path.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+
+	"bkpdir/pkg/config"
+	"bkpdir/pkg/remote"
+)
+
+// ⭐ REMOTE-001: Remote storage configuration - 📝
+// RemoteConfig holds the credentials and transfer settings of remotes.
+// Empty S3 settings fall back to the standard AWS_* environment variables.
+type RemoteConfig struct {
+	S3Region          string        `yaml:"s3_region"`            // Region of the bucket
+	S3Endpoint        string        `yaml:"s3_endpoint"`          // Base URL of an S3-compatible store
+	S3AccessKeyID     string        `yaml:"s3_access_key_id"`     // Access key ID
+	S3SecretAccessKey config.Secret `yaml:"s3_secret_access_key"` // Secret access key, or an env: or secretref: reference
+	UploadPartSize    string        `yaml:"upload_part_size"`     // Size of upload parts, e.g. 16MB
+	// ⭐ REMOTE-CACHE-001: How long a cached listing of a remote archive directory is used
+	ListingCacheTTL string `yaml:"listing_cache_ttl"`
+}
+
+// DefaultRemoteConfig returns the remote settings used when none are
+// configured: credentials from the environment, 16MB upload parts and
+// remote listings cached for five minutes.
+func DefaultRemoteConfig() *RemoteConfig {
+	return &RemoteConfig{UploadPartSize: "16MB", ListingCacheTTL: "5m"}
+}
+
+// remoteSettings returns the remote section of cfg or the defaults.
+func remoteSettings(cfg *Config) *RemoteConfig {
+	if cfg.Remote == nil {
+		return DefaultRemoteConfig()
+	}
+	return cfg.Remote
+}
+
+// ⭐ REMOTE-001: Remote selection - 🔍
+// openRemote returns the backend of a remote URL with the configured
+// credentials.
+func openRemote(cfg *Config, url string) (remote.Backend, error) {
+	settings := remoteSettings(cfg)
+	secret, err := settings.S3SecretAccessKey.Resolve()
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Invalid remote.s3_secret_access_key", cfg.StatusConfigError, err)
+	}
+	backend, err := remote.Open(url, remote.Options{S3: remote.S3Options{
+		Region:          settings.S3Region,
+		Endpoint:        settings.S3Endpoint,
+		AccessKeyID:     settings.S3AccessKeyID,
+		SecretAccessKey: secret,
+		// ⭐ TIER-001: Archives moved to the cold remote use its storage class
+		StorageClass: coldStorageClass(cfg, url),
+	}})
+	if err != nil {
+		return nil, NewArchiveErrorWithCause(fmt.Sprintf("Cannot use remote %s", url), cfg.StatusConfigError, err)
+	}
+	return backend, nil
+}
+
+// uploadPartSize returns the configured upload part size.
+func uploadPartSize(cfg *Config) (int64, error) {
+	size, err := ParseByteSize(remoteSettings(cfg).UploadPartSize)
+	if err != nil {
+		return 0, NewArchiveErrorWithCause("Invalid remote.upload_part_size", cfg.StatusConfigError, err)
+	}
+	if size == 0 {
+		size = remote.DefaultPartSize
+	}
+	return size, nil
+}
@@ -0,0 +1,279 @@
+// This file is part of bkpdir
+//
+// Package main provides listings of archive directories kept on a remote.
+// Remote listings and the manifests in .metadata/ are cached under the user
+// cache directory: listings are reused for remote.listing_cache_ttl, and
+// cached manifests are revalidated with conditional reads, so repeated
+// `bkpdir list` runs transfer only what changed.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/remote"
+)
+
+// remoteManifestLimit caps the size of a manifest read from a remote.
+const remoteManifestLimit = 1 << 20
+
+// isRemoteLocation reports whether an archive directory names a remote
+// (s3://BUCKET/PREFIX or file://DIR) rather than a local path.
+func isRemoteLocation(dir string) bool {
+	return strings.Contains(dir, "://")
+}
+
+// remoteArchiveDirError refuses a remote archive directory in commands that
+// read or write archives locally.
+func remoteArchiveDirError(location string, status int) error {
+	return NewArchiveError(fmt.Sprintf(
+		"archive_dir_path %s is a remote; only 'bkpdir list' reads remote archive directories "+
+			"(create archives locally and copy them with 'bkpdir upload')", location), status)
+}
+
+// remoteArchiveLocation returns the remote archive directory of the archives
+// of owner, with the name of the current directory appended when
+// use_current_dir_name is set.
+func remoteArchiveLocation(cfg *Config, cwd, owner string) string {
+	location := strings.TrimSuffix(archiveRootDir(cfg, owner), "/")
+	if cfg.UseCurrentDirName {
+		location += "/" + filepath.Base(cwd)
+	}
+	return location
+}
+
+// cachedManifest is a manifest read from a remote with the object
+// description it was read at.
+type cachedManifest struct {
+	Object remote.Object `json:"object"`
+	Data   []byte        `json:"data"`
+}
+
+// remoteListingCache is the cached state of one remote archive directory.
+type remoteListingCache struct {
+	URL       string                    `json:"url"`
+	Fetched   time.Time                 `json:"fetched"`
+	Objects   []remote.Object           `json:"objects"`
+	Manifests map[string]cachedManifest `json:"manifests,omitempty"`
+}
+
+// ⭐ REMOTE-CACHE-001: Remote listing - 🔧
+// remoteListing lists a remote archive directory through its cache.
+type remoteListing struct {
+	ctx       context.Context
+	backend   remote.Backend
+	cachePath string
+	cache     remoteListingCache
+	refresh   bool
+	objects   map[string]remote.Object
+	changed   bool
+}
+
+// remoteListingTTL returns how long a cached listing is used.
+func remoteListingTTL(cfg *Config) (time.Duration, error) {
+	ttl := remoteSettings(cfg).ListingCacheTTL
+	if ttl == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(ttl)
+	if err != nil || d < 0 {
+		return 0, NewArchiveError(fmt.Sprintf("Invalid remote.listing_cache_ttl %q (use a duration such as 5m)", ttl), cfg.StatusConfigError)
+	}
+	return d, nil
+}
+
+// remoteListingCachePath returns the cache file of a remote archive directory.
+func remoteListingCachePath(url string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(url))
+	return filepath.Join(dir, "remote-listings", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// ⭐ REMOTE-CACHE-001: Cached listing - 🔍
+// openRemoteListing returns the listing of a remote archive directory. The
+// cached listing is used while it is younger than the TTL unless refresh is
+// set; otherwise the remote is listed again.
+func openRemoteListing(ctx context.Context, cfg *Config, location string, refresh bool) (*remoteListing, error) {
+	ttl, err := remoteListingTTL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	backend, err := openRemote(cfg, location)
+	if err != nil {
+		return nil, err
+	}
+	l := &remoteListing{ctx: ctx, backend: backend, refresh: refresh}
+	if l.cachePath, err = remoteListingCachePath(backend.URL()); err != nil {
+		return nil, NewArchiveErrorWithCause("Cannot use the remote listing cache", 1, err)
+	}
+	if data, err := os.ReadFile(l.cachePath); err == nil {
+		if json.Unmarshal(data, &l.cache) != nil || l.cache.URL != backend.URL() {
+			l.cache = remoteListingCache{}
+		}
+	}
+	l.cache.URL = backend.URL()
+
+	if refresh || l.cache.Fetched.IsZero() || time.Since(l.cache.Fetched) >= ttl {
+		objects, err := backend.List(ctx)
+		if err != nil {
+			return nil, NewArchiveErrorWithCause(fmt.Sprintf("Failed to list %s", backend.URL()), 1, err)
+		}
+		l.cache.Objects, l.cache.Fetched, l.changed = objects, time.Now(), true
+	}
+	l.objects = make(map[string]remote.Object, len(l.cache.Objects))
+	for _, obj := range l.cache.Objects {
+		l.objects[obj.Name] = obj
+	}
+	return l, nil
+}
+
+// Archives returns the archives of the listing, without their metadata.
+func (l *remoteListing) Archives() []Archive {
+	var archives []Archive
+	for _, obj := range l.cache.Objects {
+		if strings.Contains(obj.Name, "/") || !strings.HasSuffix(obj.Name, ".zip") {
+			continue
+		}
+		archive := Archive{
+			Name:          obj.Name,
+			Path:          l.backend.URL() + "/" + obj.Name,
+			IsIncremental: strings.Contains(obj.Name, "_update="),
+			CreationTime:  obj.Modified,
+		}
+		if base, _, found := strings.Cut(obj.Name, "_update="); found {
+			archive.BaseArchive = base + ".zip"
+		}
+		archives = append(archives, archive)
+	}
+	return archives
+}
+
+// loadMetadata fills in the verification status and Git metadata of a
+// remote archive from its manifests, like loadArchiveMetadata does for
+// local archives.
+func (l *remoteListing) loadMetadata(archive *Archive) {
+	if data := l.manifest(".metadata/" + archive.Name + ".json"); data != nil {
+		var status VerificationStatus
+		if json.Unmarshal(data, &status) == nil {
+			archive.VerificationStatus = &status
+		}
+	}
+	if data := l.manifest(".metadata/" + archive.Name + ".git.json"); data != nil {
+		var meta GitMetadata
+		if json.Unmarshal(data, &meta) == nil {
+			archive.GitBranch = meta.Branch
+			archive.GitHash = meta.Hash
+			archive.GitDescribe = meta.Describe
+			archive.GitTag = meta.Tag
+		}
+	}
+	if data := l.manifest(".metadata/" + archive.Name + annotationSuffix); data != nil {
+		var annotation ArchiveAnnotation
+		if json.Unmarshal(data, &annotation) == nil {
+			applyArchiveAnnotation(archive, &annotation)
+		}
+	}
+}
+
+// ⭐ REMOTE-CACHE-001: Manifest validation - 🔍
+// manifest returns the content of a manifest, or nil when it does not exist
+// or cannot be read. A cached manifest is used without a request while the
+// listing shows the same ETag and modification time; otherwise, and always
+// with refresh, it is revalidated with a conditional read.
+func (l *remoteListing) manifest(name string) []byte {
+	listed, inListing := l.objects[name]
+	cached, inCache := l.cache.Manifests[name]
+	if !inListing {
+		return nil
+	}
+	if inCache && !l.refresh && sameObject(cached.Object, listed) {
+		return cached.Data
+	}
+
+	var rc io.ReadCloser
+	var obj remote.Object
+	var err error
+	if inCache {
+		rc, obj, err = l.backend.OpenIfChanged(l.ctx, name, cached.Object)
+		if errors.Is(err, remote.ErrNotModified) {
+			return cached.Data
+		}
+	} else {
+		obj = listed
+		rc, err = l.backend.Open(l.ctx, name)
+	}
+	if errors.Is(err, remote.ErrNotExist) {
+		delete(l.cache.Manifests, name)
+		l.changed = true
+		return nil
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot read %s: %v\n", name, err)
+		return nil
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(io.LimitReader(rc, remoteManifestLimit))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot read %s: %v\n", name, err)
+		return nil
+	}
+	l.storeManifest(name, cachedManifest{Object: obj, Data: data})
+	return data
+}
+
+// sameObject reports whether two descriptions are of the same content.
+func sameObject(a, b remote.Object) bool {
+	if a.ETag != "" || b.ETag != "" {
+		return a.ETag == b.ETag
+	}
+	return a.Size == b.Size && a.Modified.Equal(b.Modified)
+}
+
+// storeManifest records a manifest in the cache.
+func (l *remoteListing) storeManifest(name string, m cachedManifest) {
+	if l.cache.Manifests == nil {
+		l.cache.Manifests = make(map[string]cachedManifest)
+	}
+	l.cache.Manifests[name] = m
+	l.changed = true
+}
+
+// save writes the cache if it changed, dropping manifests of objects that
+// are no longer listed. Failures only warn; the next run lists again. In
+// read-only mode the cache is left as it is.
+func (l *remoteListing) save() {
+	if !l.changed || readOnly {
+		return
+	}
+	for name := range l.cache.Manifests {
+		if _, ok := l.objects[name]; !ok {
+			delete(l.cache.Manifests, name)
+		}
+	}
+	data, err := json.Marshal(&l.cache)
+	if err == nil {
+		if err = fileops.MkdirAll(filepath.Dir(l.cachePath), 0o755); err == nil {
+			err = fileops.AtomicWriteFile(l.cachePath, data, 0o600)
+		}
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: cannot save the remote listing cache: %v\n", err)
+	}
+}
@@ -0,0 +1,90 @@
+// This file is part of bkpdir
+
+// Package main provides tests for listing remote archive directories.
+// It verifies that listings and manifests are served from the local cache
+// within the TTL and fetched again with a refresh.
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ⭐ REMOTE-CACHE-001: Cached remote listings - 🧪
+func TestRemoteListingCache(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	remoteDir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		path := filepath.Join(remoteDir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("a.zip", "zip")
+	write(".metadata/a.zip.json", `{"is_verified": true}`)
+	write(".metadata/a.zip.git.json", `{"branch": "main", "tag": "v1.0"}`)
+
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = "file://" + filepath.ToSlash(remoteDir)
+	cfg.UseCurrentDirName = false
+	ctx := context.Background()
+	list := func(refresh bool) []Archive {
+		t.Helper()
+		listing, err := openRemoteListing(ctx, cfg, remoteArchiveLocation(cfg, "/work/project", "alice"), refresh)
+		if err != nil {
+			t.Fatal(err)
+		}
+		archives := listing.Archives()
+		for i := range archives {
+			listing.loadMetadata(&archives[i])
+		}
+		listing.save()
+		return archives
+	}
+
+	archives := list(false)
+	if len(archives) != 1 || archives[0].Name != "a.zip" {
+		t.Fatalf("archives = %+v, want a.zip", archives)
+	}
+	if a := archives[0]; a.VerificationStatus == nil || !a.VerificationStatus.IsVerified || a.GitBranch != "main" || a.GitTag != "v1.0" {
+		t.Errorf("manifests were not loaded: %+v", a)
+	}
+
+	write("b.zip", "zip")
+	if err := os.Remove(filepath.Join(remoteDir, ".metadata", "a.zip.git.json")); err != nil {
+		t.Fatal(err)
+	}
+	archives = list(false)
+	if len(archives) != 1 || archives[0].GitTag != "v1.0" {
+		t.Errorf("listing within the TTL was not served from the cache: %+v", archives)
+	}
+
+	archives = list(true)
+	if len(archives) != 2 || archives[1].Name != "b.zip" {
+		t.Fatalf("refresh did not list the remote again: %+v", archives)
+	}
+	if archives[0].GitTag != "" || archives[0].VerificationStatus == nil {
+		t.Errorf("refresh did not revalidate the manifests: %+v", archives[0])
+	}
+
+	cfg.Remote = &RemoteConfig{ListingCacheTTL: "0"}
+	write("c.zip", "zip")
+	if archives = list(false); len(archives) != 3 {
+		t.Errorf("a TTL of 0 did not list the remote: %d archives", len(archives))
+	}
+	cfg.Remote.ListingCacheTTL = "soon"
+	if _, err := openRemoteListing(ctx, cfg, cfg.ArchiveDirPath, false); err == nil {
+		t.Error("an invalid listing_cache_ttl was accepted")
+	}
+
+	if _, err := getArchiveDirectory(cfg); err == nil {
+		t.Error("a remote archive directory was accepted for local use")
+	}
+}
@@ -0,0 +1,129 @@
+// This file is part of bkpdir
+//
+// Package main provides the JSON report types shared by `--output json` and
+// the HTTP API of `bkpdir serve`, so both produce the same documents.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// ⭐ REPORT-001: Output formats - 🔧
+const (
+	OutputText = "text"
+	OutputJSON = "json"
+)
+
+// ⭐ REPORT-001: Archive verification states - 🔧
+const (
+	ArchiveStatusVerified   = "verified"
+	ArchiveStatusFailed     = "failed"
+	ArchiveStatusUnverified = "unverified"
+	// ⭐ LIST-VERIFY-001: Unverified, but the ZIP central directory was readable
+	ArchiveStatusReadable = "readable"
+)
+
+// ⭐ REPORT-001: Archive report - 📝
+// ArchiveReport is the JSON form of one archive.
+type ArchiveReport struct {
+	Name         string              `json:"name"`
+	Path         string              `json:"path"`
+	CreatedAt    time.Time           `json:"created_at"`
+	Incremental  bool                `json:"incremental"`
+	BaseArchive  string              `json:"base_archive,omitempty"`
+	Status       string              `json:"status"`
+	Verification *VerificationStatus `json:"verification,omitempty"`
+	StructureErr string              `json:"structure_error,omitempty"`
+	GitBranch    string              `json:"git_branch,omitempty"`
+	GitHash      string              `json:"git_hash,omitempty"`
+	GitTag       string              `json:"git_tag,omitempty"`
+	GitDescribe  string              `json:"git_describe,omitempty"`
+	Note         string              `json:"note,omitempty"`
+	Tags         map[string]string   `json:"tags,omitempty"`
+	Location     string              `json:"location,omitempty"`
+}
+
+// ArchiveListReport is the JSON form of an archive listing.
+type ArchiveListReport struct {
+	ArchiveDir string          `json:"archive_dir"`
+	Archives   []ArchiveReport `json:"archives"`
+	// ⭐ FOREIGN-001: Files bkpdir did not create, with list --foreign
+	Foreign []ForeignFile `json:"foreign,omitempty"`
+}
+
+// CreateReport describes the outcome of an archive creation request.
+// Archive is nil when nothing was created because nothing changed.
+type CreateReport struct {
+	Type    string         `json:"type"`
+	Created bool           `json:"created"`
+	Archive *ArchiveReport `json:"archive,omitempty"`
+}
+
+// ErrorReport is the JSON body returned for failed requests.
+type ErrorReport struct {
+	Error string `json:"error"`
+}
+
+// archiveStatus returns the verification state of an archive.
+func archiveStatus(a Archive) string {
+	switch {
+	case a.VerificationStatus == nil && a.StructureChecked && a.StructureError == "":
+		return ArchiveStatusReadable
+	case a.VerificationStatus == nil && a.StructureChecked:
+		return ArchiveStatusFailed
+	case a.VerificationStatus == nil:
+		return ArchiveStatusUnverified
+	case a.VerificationStatus.IsVerified:
+		return ArchiveStatusVerified
+	default:
+		return ArchiveStatusFailed
+	}
+}
+
+// ⭐ REPORT-001: Report construction - 🔧
+// NewArchiveReport converts an archive with loaded metadata to its report.
+func NewArchiveReport(a Archive) ArchiveReport {
+	return ArchiveReport{
+		Name:         a.Name,
+		Path:         a.Path,
+		CreatedAt:    a.CreationTime,
+		Incremental:  a.IsIncremental,
+		BaseArchive:  a.BaseArchive,
+		Status:       archiveStatus(a),
+		Verification: a.VerificationStatus,
+		StructureErr: a.StructureError,
+		GitBranch:    a.GitBranch,
+		GitHash:      a.GitHash,
+		GitTag:       a.GitTag,
+		GitDescribe:  a.GitDescribe,
+		Note:         a.Note,
+		Tags:         a.Tags,
+		Location:     a.Location,
+	}
+}
+
+// NewArchiveListReport builds the listing report for archives in archiveDir.
+func NewArchiveListReport(archiveDir string, archives []Archive) ArchiveListReport {
+	report := ArchiveListReport{ArchiveDir: archiveDir, Archives: []ArchiveReport{}}
+	for _, a := range archives {
+		report.Archives = append(report.Archives, NewArchiveReport(a))
+	}
+	return report
+}
+
+// writeJSONReport writes v as indented JSON followed by a newline.
+func writeJSONReport(w io.Writer, v interface{}) error {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode report: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	return err
+}
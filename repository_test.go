@@ -0,0 +1,77 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the experimental repository backend.
+// It verifies that full archives become snapshots that list and verify
+// like archives, and that incremental archives are refused.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ⭐ CDC-001: Repository snapshots through the archive commands - 🧪
+func TestRepositorySnapshots(t *testing.T) {
+	sourceDir := t.TempDir()
+	repoDir := filepath.Join(t.TempDir(), "repo")
+	for name, content := range map[string]string{"a.txt": "alpha", "sub/b.txt": "beta"} {
+		path := filepath.Join(sourceDir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Earlier tests may leave the working directory removed
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = filepath.Join(t.TempDir(), "archives")
+	cfg.Repository.Path = repoDir
+	cfg.Repository.AverageChunkSize = "4KB"
+
+	for i := 0; i < 2; i++ {
+		if err := CreateFullArchive(cfg, "snap", false, false); err != nil {
+			t.Fatalf("CreateFullArchive failed: %v", err)
+		}
+	}
+	if _, err := os.Stat(cfg.ArchiveDirPath); !os.IsNotExist(err) {
+		t.Errorf("Expected no archive directory in repository mode, got %v", err)
+	}
+
+	dir, archives, err := listRepositorySnapshots(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archives) != 2 || archives[0].Name == archives[1].Name {
+		t.Fatalf("Expected two distinct snapshots, got %+v", archives)
+	}
+	if abs, _ := filepath.Abs(repoDir); dir != abs {
+		t.Errorf("Expected repository directory %s, got %s", abs, dir)
+	}
+
+	opts := VerifyOptions{Config: cfg, Formatter: NewOutputFormatter(cfg), WithChecksum: true}
+	if err := VerifyArchiveEnhanced(opts); err != nil {
+		t.Errorf("Expected snapshots to verify, got %v", err)
+	}
+	archive := archives[0]
+	if status, err := LoadVerificationStatus(&archive); err != nil || status == nil || !status.IsVerified {
+		t.Errorf("Expected a stored verification status, got %+v, %v", status, err)
+	}
+
+	opts.ArchiveName = "missing"
+	if err := VerifyArchiveEnhanced(opts); err == nil {
+		t.Error("Expected an unknown snapshot to fail")
+	}
+
+	if err := CreateIncrementalArchive(cfg, "", false, false); err == nil {
+		t.Error("Expected incremental archives to be refused")
+	}
+}
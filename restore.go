@@ -0,0 +1,526 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir restore`, which extracts an archive into a
+// target directory. Every entry is first classified against the target as
+// created, overwritten, unchanged or conflicting; --preview prints that plan
+// and --conflict decides what happens to existing files.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bkpdir/pkg/fileops"
+)
+
+// Conflict strategies for existing files in the restore target.
+const (
+	RestoreConflictFail      = "fail"      // Refuse to restore if any file would change
+	RestoreConflictSkip      = "skip"      // Keep existing files and conflicting paths
+	RestoreConflictOverwrite = "overwrite" // Replace existing files
+)
+
+// Restore actions of a planned entry.
+const (
+	RestoreCreate    = "create"
+	RestoreOverwrite = "overwrite"
+	RestoreUnchanged = "unchanged"
+	RestoreConflict  = "conflict"
+)
+
+// RestoreOptions holds the parameters of a restore.
+type RestoreOptions struct {
+	Config   *Config
+	Output   io.Writer
+	Archive  string // Archive name in the archive directory, or a path
+	Target   string
+	Conflict string
+	Preview  bool
+	Link     string // RestoreLinkSymlink or RestoreLinkHard to link files from Store
+	Store    string // Content store of linked restores; empty for the default
+	// ⭐ RESTORE-001: FROM:TO id pairs, or files of them, mapping archived owners
+	UIDMap []string
+	GIDMap []string
+}
+
+// RestorePlanEntry is what restoring one file entry would do. Path is the
+// destination relative to the target; Reason explains a conflict.
+type RestorePlanEntry struct {
+	Path   string
+	Action string
+	Size   int64
+	Reason string
+	file   *zip.File
+}
+
+// RestorePlan lists the file entries of an archive with their actions.
+// Directory entries are only kept when they conflict.
+type RestorePlan struct {
+	Entries []RestorePlanEntry
+	dirs    []string
+}
+
+// Count returns the number and total size of the entries with action.
+func (p *RestorePlan) Count(action string) (int, int64) {
+	var n int
+	var size int64
+	for _, e := range p.Entries {
+		if e.Action == action {
+			n++
+			size += e.Size
+		}
+	}
+	return n, size
+}
+
+// ⭐ RESTORE-002: Restore planning - 🔍
+// PlanRestore classifies every entry of r against target. Entry names are
+// mapped with the restore_unicode_normalization mode. Names that are
+// absolute or leave the target are an error. With linked set, an existing
+// symbolic link to a file holding the entry content is unchanged, so a
+// linked restore can be repeated.
+func PlanRestore(r *zip.Reader, target, normalization string, linked bool) (*RestorePlan, error) {
+	plan := &RestorePlan{}
+	for _, f := range r.File {
+		if f.Name == ".checksums" {
+			continue
+		}
+		rel, err := restoreEntryPath(RestoreEntryName(f.Name, normalization))
+		if err != nil {
+			return nil, err
+		}
+		if f.FileInfo().IsDir() {
+			if reason := restoreDirConflict(target, rel); reason != "" {
+				plan.Entries = append(plan.Entries, RestorePlanEntry{Path: rel, Action: RestoreConflict, Reason: reason})
+				continue
+			}
+			plan.dirs = append(plan.dirs, rel)
+			continue
+		}
+		entry := RestorePlanEntry{Path: rel, Size: int64(f.UncompressedSize64), file: f}
+		entry.Action, entry.Reason = classifyRestoreEntry(target, rel, f, linked)
+		plan.Entries = append(plan.Entries, entry)
+	}
+	return plan, nil
+}
+
+// restoreEntryPath converts an entry name into a clean relative path.
+func restoreEntryPath(name string) (string, error) {
+	slashed := strings.TrimSuffix(strings.ReplaceAll(name, "\\", "/"), "/")
+	if slashed == "" || strings.HasPrefix(slashed, "/") || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", fmt.Errorf("unsafe entry name %q", name)
+	}
+	for _, part := range strings.Split(slashed, "/") {
+		if part == ".." {
+			return "", fmt.Errorf("unsafe entry name %q", name)
+		}
+	}
+	return filepath.Clean(filepath.FromSlash(slashed)), nil
+}
+
+// restoreParentConflict reports a parent of rel below target that exists
+// but is not a directory. Symbolic links are refused as well so a restore
+// never writes outside the target through a link.
+func restoreParentConflict(target, rel string) string {
+	var parents []string
+	for dir := filepath.Dir(rel); dir != "."; dir = filepath.Dir(dir) {
+		parents = append(parents, dir)
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		info, err := os.Lstat(filepath.Join(target, parents[i]))
+		if err != nil {
+			return ""
+		}
+		if !info.IsDir() {
+			return fmt.Sprintf("%s is not a directory", filepath.ToSlash(parents[i]))
+		}
+	}
+	return ""
+}
+
+// restoreDirConflict reports why the directory rel cannot be created.
+func restoreDirConflict(target, rel string) string {
+	if reason := restoreParentConflict(target, rel); reason != "" {
+		return reason
+	}
+	if info, err := os.Lstat(filepath.Join(target, rel)); err == nil && !info.IsDir() {
+		return "a file exists where the archive has a directory"
+	}
+	return ""
+}
+
+// classifyRestoreEntry decides what restoring the file entry f to rel does.
+func classifyRestoreEntry(target, rel string, f *zip.File, linked bool) (string, string) {
+	if reason := restoreParentConflict(target, rel); reason != "" {
+		return RestoreConflict, reason
+	}
+	dest := filepath.Join(target, rel)
+	info, err := os.Lstat(dest)
+	if os.IsNotExist(err) {
+		if dir := existingAncestor(filepath.Dir(dest)); dir != "" && !isWritable(dir) {
+			return RestoreConflict, fmt.Sprintf("directory %s is not writable", dir)
+		}
+		return RestoreCreate, ""
+	}
+	if err != nil {
+		return RestoreConflict, err.Error()
+	}
+	if info.IsDir() {
+		return RestoreConflict, "a directory exists where the archive has a file"
+	}
+	if sameRestoreContent(dest, info, f, linked) {
+		return RestoreUnchanged, ""
+	}
+	switch {
+	case !isWritable(filepath.Dir(dest)):
+		return RestoreConflict, fmt.Sprintf("directory %s is not writable", filepath.Dir(dest))
+	case info.Mode().IsRegular() && !isWritable(dest):
+		return RestoreConflict, "existing file is read-only"
+	}
+	return RestoreOverwrite, ""
+}
+
+// existingAncestor returns dir or its nearest existing parent.
+func existingAncestor(dir string) string {
+	for {
+		if _, err := os.Lstat(dir); err == nil {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// sameRestoreContent reports whether the existing dest already holds the
+// entry: the same link target, or a regular file of the same size and CRC-32.
+// With linked set, a symbolic link is followed when the entry is a file.
+func sameRestoreContent(dest string, info os.FileInfo, f *zip.File, linked bool) bool {
+	entryIsLink := f.Mode()&os.ModeSymlink != 0
+	if linked && !entryIsLink && info.Mode()&os.ModeSymlink != 0 {
+		followed, err := os.Stat(dest)
+		if err != nil {
+			return false
+		}
+		info = followed
+	}
+	if entryIsLink != (info.Mode()&os.ModeSymlink != 0) {
+		return false
+	}
+	if entryIsLink {
+		link, err := os.Readlink(dest)
+		return err == nil && int64(len(link)) == int64(f.UncompressedSize64) && crc32.ChecksumIEEE([]byte(link)) == f.CRC32
+	}
+	if !info.Mode().IsRegular() || info.Size() != int64(f.UncompressedSize64) {
+		return false
+	}
+	file, err := os.Open(dest)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	h := crc32.NewIEEE()
+	if _, err := io.Copy(h, file); err != nil {
+		return false
+	}
+	return h.Sum32() == f.CRC32
+}
+
+// ⭐ RESTORE-002: Restore preview - 📝
+// writeRestorePreview lists the entries that would be created, overwritten
+// or conflict, followed by counts and sizes per action.
+func writeRestorePreview(w io.Writer, plan *RestorePlan, archive, target string) {
+	width := outputWidth()
+	for _, action := range []string{RestoreConflict, RestoreOverwrite, RestoreCreate} {
+		for _, e := range plan.Entries {
+			if e.Action != action {
+				continue
+			}
+			line := fmt.Sprintf("%-10s %s (%s)", action, filepath.ToSlash(e.Path), formatHumanSize(e.Size))
+			if e.Reason != "" {
+				line = fmt.Sprintf("%-10s %s: %s", action, filepath.ToSlash(e.Path), e.Reason)
+			}
+			fmt.Fprintln(w, truncateMiddle(line, width))
+		}
+	}
+	fmt.Fprintf(w, "\nRestoring %s into %s would:\n", archive, target)
+	for _, action := range []string{RestoreCreate, RestoreOverwrite, RestoreUnchanged, RestoreConflict} {
+		n, size := plan.Count(action)
+		fmt.Fprintf(w, "  %-10s %5d %-5s %s\n", action, n, pluralFiles(n), formatHumanSize(size))
+	}
+}
+
+// pluralFiles returns "file" or "files" for n.
+func pluralFiles(n int) string {
+	if n == 1 {
+		return "file"
+	}
+	return "files"
+}
+
+// ⭐ RESTORE-002: Restore command - 🔧
+// RestoreArchive plans the restore and either prints the preview or extracts
+// the archive according to the conflict strategy. With the fail strategy
+// nothing is written when any file would be overwritten or conflicts.
+func RestoreArchive(opts RestoreOptions) (err error) {
+	cfg := opts.Config
+	switch opts.Conflict {
+	case RestoreConflictFail, RestoreConflictSkip, RestoreConflictOverwrite:
+	default:
+		return NewArchiveError(fmt.Sprintf("Invalid --conflict %q (use fail, skip or overwrite)", opts.Conflict), cfg.StatusConfigError)
+	}
+	archivePath, err := resolveRestoreArchive(cfg, opts.Archive)
+	if err != nil {
+		return err
+	}
+	target, err := filepath.Abs(opts.Target)
+	if err != nil {
+		return NewArchiveErrorWithCause("Invalid target directory", cfg.StatusDirectoryNotFound, err)
+	}
+	// ⭐ AUDIT-001: Record the restore in the audit log; previews change nothing
+	defer func() {
+		if !opts.Preview {
+			recordAudit(cfg, AuditActionRestore, archivePath, "to "+target, err)
+		}
+	}()
+
+	r, err := zip.OpenReader(archivePath)
+	if errors.Is(err, zip.ErrFormat) {
+		// ⭐ ENCRYPT-001: Anything that is not an archive is a file backup
+		return restoreFileBackup(opts, archivePath, target)
+	}
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to open archive", 1, err)
+	}
+	defer r.Close()
+	if info, err := os.Stat(target); err == nil && !info.IsDir() {
+		return NewArchiveError(fmt.Sprintf("Target is not a directory: %s", target), cfg.StatusDirectoryNotFound)
+	}
+	linked := opts.Link != ""
+	if linked {
+		if opts.Store, err = restoreStoreDir(cfg, opts); err != nil {
+			return err
+		}
+	} else if opts.Store != "" {
+		return NewArchiveError("--store requires --link", cfg.StatusConfigError)
+	}
+	plan, err := PlanRestore(&r.Reader, target, cfg.RestoreUnicodeNormalization, linked)
+	if err != nil {
+		return NewArchiveErrorWithCause("Refusing to restore archive", 1, err)
+	}
+	// ⭐ RESTORE-GUARD-001: Refuse links out of the target and decompression bombs
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to open archive", 1, err)
+	}
+	if err := restoreGuardFromConfig(cfg).Check(&r.Reader, info.Size(), cfg.RestoreUnicodeNormalization); err != nil {
+		return NewArchiveErrorWithCause("Refusing to restore archive", 1, err)
+	}
+
+	// ⭐ RESTORE-001: Owners are restored by root, or when a mapping is given
+	var owners *ownerMapping
+	if len(opts.UIDMap) > 0 || len(opts.GIDMap) > 0 || effectiveUID() == 0 {
+		manifest, err := LoadArchiveManifest(archivePath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: owner names are unknown: %v\n", err)
+		}
+		var names *ManifestOwners
+		if manifest != nil {
+			names = manifest.Owners
+		}
+		if owners, err = newOwnerMapping(opts.UIDMap, opts.GIDMap, names); err != nil {
+			return NewArchiveErrorWithCause("Invalid owner mapping", cfg.StatusConfigError, err)
+		}
+	}
+
+	if opts.Preview {
+		writeRestorePreview(opts.Output, plan, filepath.Base(archivePath), target)
+		if owners != nil {
+			writeOwnerChanges(opts.Output, ownerChanges(plan, owners, opts.Conflict))
+		}
+		return nil
+	}
+
+	if err := checkRestoreConflicts(plan, opts.Conflict); err != nil {
+		return err
+	}
+
+	if err := fileops.MkdirAll(target, 0o755); err != nil {
+		return NewArchiveErrorWithCause("Failed to create target directory", cfg.StatusDirectoryNotFound, err)
+	}
+	// ⭐ CASE-001: Colliding names would overwrite each other
+	var paths []string
+	for _, e := range plan.Entries {
+		paths = append(paths, e.Path)
+	}
+	if _, err := CheckRestoreCaseCollisions(target, paths, false); err != nil {
+		return NewArchiveErrorWithCause("Refusing to restore archive", 1, err)
+	}
+
+	write := restoreEntry
+	if linked {
+		store := &restoreStore{dir: opts.Store, hard: opts.Link == RestoreLinkHard}
+		write = store.link
+		defer func() {
+			if err == nil {
+				fmt.Fprintf(opts.Output, "Content store %s: %d new files (%s)\n", store.dir, store.added, formatHumanSize(store.addedBytes))
+			}
+		}()
+	}
+	var chown *ownerWriter
+	if owners != nil {
+		chown = &ownerWriter{mapping: owners}
+		write = chown.wrap(write)
+	}
+	restored, skipped, err := extractRestorePlan(plan, target, opts.Conflict, write)
+	if chown != nil {
+		chown.warn(os.Stderr)
+	}
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to restore archive", 1, err)
+	}
+	unchanged, _ := plan.Count(RestoreUnchanged)
+	verb := "Restored"
+	if linked {
+		verb = "Linked"
+	}
+	fmt.Fprintf(opts.Output, "%s %d files to %s (%d unchanged, %d skipped)\n", verb, restored, target, unchanged, skipped)
+	return nil
+}
+
+// checkRestoreConflicts refuses a plan with conflicts unless they are
+// skipped, and with overwrites under the fail strategy.
+func checkRestoreConflicts(plan *RestorePlan, conflict string) error {
+	overwrites, _ := plan.Count(RestoreOverwrite)
+	conflicts, _ := plan.Count(RestoreConflict)
+	if conflict != RestoreConflictSkip && conflicts > 0 {
+		return NewArchiveError(fmt.Sprintf("%d paths conflict with the target; see --preview, or use --conflict skip to leave them", conflicts), 1)
+	}
+	if conflict == RestoreConflictFail && overwrites > 0 {
+		return NewArchiveError(fmt.Sprintf("%d existing files would be overwritten; see --preview and choose --conflict skip or overwrite", overwrites), 1)
+	}
+	return nil
+}
+
+// resolveRestoreArchive returns the path of an archive given by name or alias
+// in the archive directory or by path.
+func resolveRestoreArchive(cfg *Config, name string) (string, error) {
+	if strings.ContainsRune(name, os.PathSeparator) {
+		if _, err := os.Stat(name); err != nil {
+			return "", NewArchiveErrorWithCause(fmt.Sprintf("Archive not found: %s", name), cfg.StatusFileNotFound, err)
+		}
+		return name, nil
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return "", err
+	}
+	// ⭐ ALIAS-001: Names such as @latest stand for an archive
+	if name, err = resolveArchiveAlias(cfg, archiveDir, name); err != nil {
+		return "", err
+	}
+	path := filepath.Join(archiveDir, name)
+	if _, err := os.Stat(path); err != nil {
+		return "", NewArchiveErrorWithCause(fmt.Sprintf("Archive not found: %s", name), cfg.StatusFileNotFound, err)
+	}
+	return path, nil
+}
+
+// extractRestorePlan creates the directories and writes the created and, with
+// the overwrite strategy, overwritten entries with write. It returns the
+// number of files written and skipped.
+func extractRestorePlan(plan *RestorePlan, target, conflict string, write func(*zip.File, string) error) (int, int, error) {
+	for _, rel := range plan.dirs {
+		if err := fileops.MkdirAll(filepath.Join(target, rel), 0o755); err != nil {
+			return 0, 0, err
+		}
+	}
+	var restored, skipped int
+	for _, e := range plan.Entries {
+		switch {
+		case e.Action == RestoreUnchanged:
+			continue
+		case e.Action == RestoreConflict, e.Action == RestoreOverwrite && conflict != RestoreConflictOverwrite:
+			skipped++
+			continue
+		}
+		if err := write(e.file, filepath.Join(target, e.Path)); err != nil {
+			return restored, skipped, fmt.Errorf("%s: %w", filepath.ToSlash(e.Path), err)
+		}
+		restored++
+	}
+	return restored, skipped, nil
+}
+
+// restoreEntry writes one file entry to dest through a temporary file in the
+// same directory, so an existing file is replaced in one step and a symbolic
+// link at dest is replaced rather than followed.
+func restoreEntry(f *zip.File, dest string) error {
+	if err := fileops.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return err
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if f.Mode()&os.ModeSymlink != 0 {
+		link, err := io.ReadAll(rc)
+		if err != nil {
+			return err
+		}
+		return replaceWithLink(dest, func(tmp string) error { return os.Symlink(string(link), tmp) })
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), ".bkpdir-restore-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	mode := f.Mode().Perm()
+	if mode == 0 {
+		mode = 0o644
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	if err := os.Chtimes(tmp.Name(), f.Modified, f.Modified); err != nil && !errors.Is(err, os.ErrPermission) {
+		return err
+	}
+	return os.Rename(tmp.Name(), dest)
+}
+
+// replaceWithLink creates a link with create at a temporary name next to
+// dest and renames it over dest.
+func replaceWithLink(dest string, create func(tmp string) error) error {
+	tmp := dest + ".bkpdir-restore"
+	os.Remove(tmp)
+	if err := create(tmp); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, dest); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,206 @@
+// This file is part of bkpdir
+//
+// Package main checks archives before `bkpdir restore` writes anything, so a
+// crafted archive cannot write outside the target through symbolic links
+// (zip-slip) or fill the disk as a decompression bomb. Absolute and `..`
+// entry names are refused while planning the restore.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+const (
+	// restoreMaxLinkSize bounds the target of a symbolic link entry.
+	restoreMaxLinkSize = 4096
+	// restoreMaxLinkHops bounds the links followed to resolve one link entry.
+	restoreMaxLinkHops = 40
+)
+
+// RestoreGuard holds the limits a restore enforces on an archive.
+type RestoreGuard struct {
+	MaxRatio      int  // Largest expansion of the archive size; 0 disables the check
+	ExternalLinks bool // Allow symbolic links that point outside the target
+}
+
+// restoreGuardFromConfig returns the restore limits of cfg.
+func restoreGuardFromConfig(cfg *Config) RestoreGuard {
+	return RestoreGuard{MaxRatio: cfg.RestoreMaxRatio, ExternalLinks: cfg.RestoreExternalSymlinks}
+}
+
+// ⭐ RESTORE-GUARD-001: Archive checks before a restore - 🛡️
+// Check refuses an archive of size bytes whose entries overlap, which
+// expands more than MaxRatio times, holds entries below one of its symbolic
+// links or, unless ExternalLinks is set, symbolic links resolving outside the
+// target. Entry names are mapped with the restore_unicode_normalization
+// mode and must already be safe, as PlanRestore requires.
+func (g RestoreGuard) Check(r *zip.Reader, size int64, normalization string) error {
+	if err := checkOverlappingEntries(r); err != nil {
+		return err
+	}
+	if err := g.checkExpansion(r, size); err != nil {
+		return err
+	}
+
+	links := make(map[string]string)
+	var names []string
+	for _, f := range r.File {
+		if f.Name == ".checksums" {
+			continue
+		}
+		rel, err := restoreEntryPath(RestoreEntryName(f.Name, normalization))
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		names = append(names, rel)
+		if f.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+		target, err := readLinkEntry(f)
+		if err != nil {
+			return fmt.Errorf("symbolic link %s: %w", rel, err)
+		}
+		links[rel] = target
+	}
+
+	for _, rel := range names {
+		for dir := path.Dir(rel); dir != "."; dir = path.Dir(dir) {
+			if _, ok := links[dir]; ok {
+				return fmt.Errorf("entry %s is inside the symbolic link %s", rel, dir)
+			}
+		}
+	}
+	if !g.ExternalLinks {
+		for _, rel := range names {
+			if target, ok := links[rel]; ok && linkEscapes(links, rel) {
+				return fmt.Errorf("symbolic link %s -> %s points outside the target; "+
+					"set restore_external_symlinks: true to restore it", rel, target)
+			}
+		}
+	}
+	return nil
+}
+
+// checkOverlappingEntries refuses entries sharing compressed data, the
+// layout of zip bombs expanding one block of data many times.
+func checkOverlappingEntries(r *zip.Reader) error {
+	type span struct {
+		name       string
+		start, end int64
+	}
+	var spans []span
+	for _, f := range r.File {
+		if f.CompressedSize64 == 0 {
+			continue
+		}
+		start, err := f.DataOffset()
+		if err != nil {
+			return fmt.Errorf("entry %s: %w", f.Name, err)
+		}
+		if f.CompressedSize64 > math.MaxInt64-uint64(start) {
+			return fmt.Errorf("entry %s has an invalid size", f.Name)
+		}
+		spans = append(spans, span{f.Name, start, start + int64(f.CompressedSize64)})
+	}
+	sort.Slice(spans, func(i, j int) bool { return spans[i].start < spans[j].start })
+	for i := 1; i < len(spans); i++ {
+		if spans[i].start < spans[i-1].end {
+			return fmt.Errorf("entries %s and %s overlap", spans[i-1].name, spans[i].name)
+		}
+	}
+	return nil
+}
+
+// checkExpansion refuses an archive of size bytes whose entries expand to
+// more than MaxRatio times its size.
+func (g RestoreGuard) checkExpansion(r *zip.Reader, size int64) error {
+	if g.MaxRatio <= 0 || size <= 0 {
+		return nil
+	}
+	limit := uint64(math.MaxUint64)
+	if uint64(size) <= math.MaxUint64/uint64(g.MaxRatio) {
+		limit = uint64(size) * uint64(g.MaxRatio)
+	}
+	var total uint64
+	for _, f := range r.File {
+		if f.UncompressedSize64 > limit-total {
+			return fmt.Errorf("archive of %s expands to more than %d times its size; "+
+				"raise restore_max_ratio if it is trusted", formatHumanSize(size), g.MaxRatio)
+		}
+		total += f.UncompressedSize64
+	}
+	return nil
+}
+
+// readLinkEntry returns the target stored in the symbolic link entry f.
+func readLinkEntry(f *zip.File) (string, error) {
+	if f.UncompressedSize64 > restoreMaxLinkSize {
+		return "", fmt.Errorf("target longer than %d bytes", restoreMaxLinkSize)
+	}
+	rc, err := f.Open()
+	if err != nil {
+		return "", err
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(io.LimitReader(rc, restoreMaxLinkSize+1))
+	if err != nil {
+		return "", err
+	}
+	return string(target), nil
+}
+
+// linkEscapes reports whether the symbolic link entry rel resolves outside
+// the target, following the other link entries of the archive as the file
+// system would. Absolute targets, and chains of links too long to follow,
+// count as outside.
+func linkEscapes(links map[string]string, rel string) bool {
+	if absoluteLinkTarget(links[rel]) {
+		return true
+	}
+	var resolved []string
+	pending := append(strings.Split(path.Dir(rel), "/"), strings.Split(links[rel], "/")...)
+	for hops := 0; len(pending) > 0; {
+		part := pending[0]
+		pending = pending[1:]
+		switch part {
+		case "", ".":
+			continue
+		case "..":
+			if len(resolved) == 0 {
+				return true
+			}
+			resolved = resolved[:len(resolved)-1]
+			continue
+		}
+		resolved = append(resolved, part)
+		target, ok := links[strings.Join(resolved, "/")]
+		if !ok {
+			continue
+		}
+		if hops++; hops > restoreMaxLinkHops || absoluteLinkTarget(target) {
+			return true
+		}
+		resolved = resolved[:len(resolved)-1]
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+	return false
+}
+
+// absoluteLinkTarget reports whether a link target is absolute on any system.
+func absoluteLinkTarget(target string) bool {
+	return path.IsAbs(target) || filepath.IsAbs(target) || filepath.VolumeName(target) != "" ||
+		strings.HasPrefix(target, "\\")
+}
@@ -0,0 +1,140 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the checks run before a restore.
+// It verifies that crafted archives are refused before anything is written.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// guardTestEntry is an entry of a crafted archive; link entries hold the
+// link target as content.
+type guardTestEntry struct {
+	name, content string
+	link          bool
+}
+
+// writeGuardTestZip writes the entries to a new archive in order.
+func writeGuardTestZip(t *testing.T, entries ...guardTestEntry) string {
+	t.Helper()
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, e := range entries {
+		hdr := &zip.FileHeader{Name: e.name, Method: zip.Deflate}
+		hdr.SetMode(0o644)
+		if e.link {
+			hdr.SetMode(os.ModeSymlink | 0o777)
+		}
+		w, err := zw.CreateHeader(hdr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(e.content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "crafted.zip")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// checkGuard runs guard on the archive at path.
+func checkGuard(t *testing.T, guard RestoreGuard, path string) error {
+	t.Helper()
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return guard.Check(&r.Reader, info.Size(), "")
+}
+
+// ⭐ RESTORE-GUARD-001: Symbolic links out of the target - 🧪
+func TestRestoreGuardLinks(t *testing.T) {
+	guard := RestoreGuard{}
+	for _, tc := range []struct {
+		name    string
+		entries []guardTestEntry
+		want    string
+	}{
+		{"inside", []guardTestEntry{{"a/link", "../b", true}, {"b", "x", false}}, ""},
+		{"absolute", []guardTestEntry{{"etc", "/etc", true}}, "points outside the target"},
+		{"parent", []guardTestEntry{{"a/up", "../../x", true}}, "points outside the target"},
+		{"write through", []guardTestEntry{{"dir", "sub", true}, {"dir/file", "x", false}}, "inside the symbolic link dir"},
+		{"chain", []guardTestEntry{{"p/q/c", "../../r", true}, {"p/q/a", "c/../../..", true}}, "p/q/a -> c/../../.. points outside"},
+		{"loop", []guardTestEntry{{"a", "b", true}, {"b", "a", true}}, "points outside the target"},
+	} {
+		err := checkGuard(t, guard, writeGuardTestZip(t, tc.entries...))
+		if tc.want == "" && err != nil || tc.want != "" && (err == nil || !strings.Contains(err.Error(), tc.want)) {
+			t.Errorf("%s: error %v, want %q", tc.name, err, tc.want)
+		}
+	}
+
+	guard.ExternalLinks = true
+	if err := checkGuard(t, guard, writeGuardTestZip(t, guardTestEntry{"etc", "/etc", true})); err != nil {
+		t.Errorf("restore_external_symlinks: %v", err)
+	}
+}
+
+// ⭐ RESTORE-GUARD-001: Decompression bombs - 🧪
+func TestRestoreGuardBombs(t *testing.T) {
+	zeros := guardTestEntry{name: "zeros", content: strings.Repeat("\x00", 1<<20)}
+	path := writeGuardTestZip(t, zeros)
+	if err := checkGuard(t, RestoreGuard{MaxRatio: 100}, path); err == nil || !strings.Contains(err.Error(), "restore_max_ratio") {
+		t.Errorf("ratio: %v", err)
+	}
+	if err := checkGuard(t, RestoreGuard{MaxRatio: 1000}, path); err != nil {
+		t.Errorf("within the ratio: %v", err)
+	}
+
+	// Point the second entry at the data of the first
+	path = writeGuardTestZip(t, guardTestEntry{name: "a", content: "same"}, guardTestEntry{name: "b", content: "same"})
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	central := bytes.LastIndex(data, []byte("PK\x01\x02"))
+	binary.LittleEndian.PutUint32(data[central+42:], 0)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkGuard(t, RestoreGuard{}, path); err == nil || !strings.Contains(err.Error(), "overlap") {
+		t.Errorf("overlapping entries: %v", err)
+	}
+}
+
+// ⭐ RESTORE-GUARD-001: Refused restores write nothing - 🧪
+func TestRestoreArchiveRefusesZipSlip(t *testing.T) {
+	archive := writeGuardTestZip(t,
+		guardTestEntry{"a.txt", "a", false},
+		guardTestEntry{"out", "..", true},
+		guardTestEntry{"out/escaped.txt", "x", false})
+	target := filepath.Join(t.TempDir(), "target")
+	opts := RestoreOptions{Config: DefaultConfig(), Output: &bytes.Buffer{}, Archive: archive, Target: target,
+		Conflict: RestoreConflictOverwrite}
+	if err := RestoreArchive(opts); err == nil || !strings.Contains(err.Error(), "Refusing to restore archive") {
+		t.Fatalf("restore: %v", err)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Error("Expected nothing to be written")
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(target), "escaped.txt")); !os.IsNotExist(err) {
+		t.Error("Expected no file outside the target")
+	}
+}
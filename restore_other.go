@@ -0,0 +1,19 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+// This file is part of bkpdir
+//
+// Package main provides the write permission check of restore previews on
+// systems without access(2); the owner write bit is used instead.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import "os"
+
+// isWritable reports whether path has its owner write bit set.
+func isWritable(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode().Perm()&0o200 != 0
+}
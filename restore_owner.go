@@ -0,0 +1,340 @@
+// This file is part of bkpdir
+//
+// Package main records file owners in archives and maps them on restore.
+// Archive entries carry the numeric uid and gid in the Info-ZIP "ux" extra
+// field and the manifest records the user and group names of those ids, so
+// a restore on another machine can map owners by --uid-map/--gid-map pairs
+// or by name.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ownerExtraID is the header ID of the Info-ZIP Unix "ux" extra field.
+const ownerExtraID = 0x7875
+
+// ⭐ RESTORE-001: Archived owners - 🔧
+// ownerExtraField returns the "ux" extra field holding the uid and gid of
+// info, or nil where file owners are unknown.
+func ownerExtraField(info os.FileInfo) []byte {
+	uid, ok := fileOwnerID(info)
+	if !ok {
+		return nil
+	}
+	gid, ok := fileGroupID(info)
+	if !ok {
+		return nil
+	}
+	extra := make([]byte, 15)
+	binary.LittleEndian.PutUint16(extra[0:], ownerExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 11)
+	extra[4] = 1 // Version
+	extra[5] = 4
+	binary.LittleEndian.PutUint32(extra[6:], uint32(uid))
+	extra[10] = 4
+	binary.LittleEndian.PutUint32(extra[11:], uint32(gid))
+	return extra
+}
+
+// entryOwner returns the uid and gid recorded in the extra fields of f.
+func entryOwner(f *zip.File) (int, int, bool) {
+	extra := f.Extra
+	for len(extra) >= 4 {
+		id := binary.LittleEndian.Uint16(extra[0:])
+		size := int(binary.LittleEndian.Uint16(extra[2:]))
+		if len(extra) < 4+size {
+			return 0, 0, false
+		}
+		data := extra[4 : 4+size]
+		extra = extra[4+size:]
+		if id != ownerExtraID || len(data) < 2 || data[0] != 1 {
+			continue
+		}
+		uid, rest, ok := readOwnerID(data[1:])
+		if !ok {
+			return 0, 0, false
+		}
+		gid, _, ok := readOwnerID(rest)
+		return uid, gid, ok
+	}
+	return 0, 0, false
+}
+
+// readOwnerID reads one size-prefixed little-endian id of a "ux" field.
+func readOwnerID(data []byte) (int, []byte, bool) {
+	if len(data) < 1 || len(data) < 1+int(data[0]) || data[0] == 0 || data[0] > 8 {
+		return 0, nil, false
+	}
+	var id uint64
+	for i := int(data[0]); i >= 1; i-- {
+		id = id<<8 | uint64(data[i])
+	}
+	return int(id), data[1+int(data[0]):], true
+}
+
+// ⭐ RESTORE-001: Owner names - 📝
+// ManifestOwners maps the ids found in an archive to user and group names.
+type ManifestOwners struct {
+	Users  map[int]string `json:"users,omitempty"`
+	Groups map[int]string `json:"groups,omitempty"`
+}
+
+// archiveOwnerNames looks up the names of the owners recorded in the archive
+// at path. Ids without a name are left out; nil means there are none.
+func archiveOwnerNames(path string) *ManifestOwners {
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		return nil
+	}
+	defer r.Close()
+	owners := &ManifestOwners{Users: map[int]string{}, Groups: map[int]string{}}
+	seenUsers, seenGroups := map[int]bool{}, map[int]bool{}
+	for _, f := range r.File {
+		uid, gid, ok := entryOwner(f)
+		if !ok {
+			continue
+		}
+		if !seenUsers[uid] {
+			seenUsers[uid] = true
+			if u, err := user.LookupId(strconv.Itoa(uid)); err == nil {
+				owners.Users[uid] = u.Username
+			}
+		}
+		if !seenGroups[gid] {
+			seenGroups[gid] = true
+			if g, err := user.LookupGroupId(strconv.Itoa(gid)); err == nil {
+				owners.Groups[gid] = g.Name
+			}
+		}
+	}
+	if len(owners.Users) == 0 && len(owners.Groups) == 0 {
+		return nil
+	}
+	return owners
+}
+
+// ⭐ RESTORE-001: Ownership mapping - 🔧
+// ownerMapping decides the owner of restored files: an explicit --uid-map or
+// --gid-map pair first, then the local id of the archived user or group
+// name, then the archived id.
+type ownerMapping struct {
+	uids, gids map[int]int
+	names      *ManifestOwners
+	// lookupUser and lookupGroup return the local id of a name; replaced in tests
+	lookupUser, lookupGroup func(name string) (int, bool)
+}
+
+// newOwnerMapping parses the --uid-map and --gid-map values. Each is a
+// FROM:TO pair of ids or a file of such pairs, one per line with #
+// comments.
+func newOwnerMapping(uidMaps, gidMaps []string, names *ManifestOwners) (*ownerMapping, error) {
+	m := &ownerMapping{names: names, lookupUser: lookupUserID, lookupGroup: lookupGroupID}
+	var err error
+	if m.uids, err = parseOwnerMaps(uidMaps); err != nil {
+		return nil, fmt.Errorf("invalid --uid-map: %w", err)
+	}
+	if m.gids, err = parseOwnerMaps(gidMaps); err != nil {
+		return nil, fmt.Errorf("invalid --gid-map: %w", err)
+	}
+	if names == nil {
+		m.names = &ManifestOwners{}
+	}
+	return m, nil
+}
+
+// parseOwnerMaps reads FROM:TO pairs given directly or in files.
+func parseOwnerMaps(values []string) (map[int]int, error) {
+	pairs := map[int]int{}
+	for _, value := range values {
+		if from, to, err := parseOwnerPair(value); err == nil {
+			pairs[from] = to
+			continue
+		}
+		file, err := os.Open(value)
+		if err != nil {
+			return nil, fmt.Errorf("%q is neither a FROM:TO pair of ids nor a readable file", value)
+		}
+		err = readOwnerPairs(file, pairs)
+		file.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", value, err)
+		}
+	}
+	return pairs, nil
+}
+
+// readOwnerPairs adds the FROM:TO lines of r to pairs.
+func readOwnerPairs(r io.Reader, pairs map[int]int) error {
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		from, to, err := parseOwnerPair(line)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+		pairs[from] = to
+	}
+	return scanner.Err()
+}
+
+// parseOwnerPair parses "FROM:TO" with non-negative ids.
+func parseOwnerPair(s string) (int, int, error) {
+	fromText, toText, found := strings.Cut(strings.TrimSpace(s), ":")
+	from, err := strconv.Atoi(fromText)
+	if err != nil || !found || from < 0 {
+		return 0, 0, fmt.Errorf("%q is not a FROM:TO pair of ids", s)
+	}
+	to, err := strconv.Atoi(toText)
+	if err != nil || to < 0 {
+		return 0, 0, fmt.Errorf("%q is not a FROM:TO pair of ids", s)
+	}
+	return from, to, nil
+}
+
+func lookupUserID(name string) (int, bool) {
+	u, err := user.Lookup(name)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(u.Uid)
+	return id, err == nil
+}
+
+func lookupGroupID(name string) (int, bool) {
+	g, err := user.LookupGroup(name)
+	if err != nil {
+		return 0, false
+	}
+	id, err := strconv.Atoi(g.Gid)
+	return id, err == nil
+}
+
+// resolveOwnerID returns the local id of an archived id and how it was found:
+// "map", the archived name, or "" when the archived id is kept.
+func resolveOwnerID(id int, pairs map[int]int, names map[int]string, lookup func(string) (int, bool)) (int, string) {
+	if to, ok := pairs[id]; ok {
+		return to, "map"
+	}
+	if name, ok := names[id]; ok {
+		if local, ok := lookup(name); ok {
+			return local, name
+		}
+	}
+	return id, ""
+}
+
+// OwnerChange is the owner a restored file gets instead of its archived one.
+type OwnerChange struct {
+	Path                string
+	UID, GID            int // Archived owner
+	NewUID, NewGID      int
+	UserFrom, GroupFrom string
+}
+
+// Resolve maps the archived owner of f, reporting whether f records one.
+func (m *ownerMapping) Resolve(f *zip.File) (OwnerChange, bool) {
+	uid, gid, ok := entryOwner(f)
+	if !ok {
+		return OwnerChange{}, false
+	}
+	c := OwnerChange{UID: uid, GID: gid}
+	c.NewUID, c.UserFrom = resolveOwnerID(uid, m.uids, m.names.Users, m.lookupUser)
+	c.NewGID, c.GroupFrom = resolveOwnerID(gid, m.gids, m.names.Groups, m.lookupGroup)
+	return c, true
+}
+
+// ownerChanges lists the entries of plan that would be written and whose
+// owner differs from the archived one.
+func ownerChanges(plan *RestorePlan, m *ownerMapping, conflict string) []OwnerChange {
+	var changes []OwnerChange
+	for _, e := range plan.Entries {
+		if !restoreWrites(e.Action, conflict) {
+			continue
+		}
+		if c, ok := m.Resolve(e.file); ok && (c.NewUID != c.UID || c.NewGID != c.GID) {
+			c.Path = e.Path
+			changes = append(changes, c)
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool { return changes[i].Path < changes[j].Path })
+	return changes
+}
+
+// restoreWrites reports whether an entry with action is written under the
+// conflict strategy.
+func restoreWrites(action, conflict string) bool {
+	return action == RestoreCreate || action == RestoreOverwrite && conflict == RestoreConflictOverwrite
+}
+
+// ⭐ RESTORE-001: Ownership preview - 📝
+// writeOwnerChanges lists the owner changes of a preview.
+func writeOwnerChanges(w io.Writer, changes []OwnerChange) {
+	if len(changes) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\nOwners mapped for %d %s:\n", len(changes), pluralFiles(len(changes)))
+	for _, c := range changes {
+		fmt.Fprintf(w, "  %s: %s → %s\n", filepath.ToSlash(c.Path),
+			fmt.Sprintf("%d:%d", c.UID, c.GID), fmt.Sprintf("%s:%s", ownerSource(c.NewUID, c.UserFrom), ownerSource(c.NewGID, c.GroupFrom)))
+	}
+}
+
+// ownerSource renders a mapped id with the name it was resolved by.
+func ownerSource(id int, from string) string {
+	if from == "" || from == "map" {
+		return strconv.Itoa(id)
+	}
+	return fmt.Sprintf("%d (%s)", id, from)
+}
+
+// ⭐ RESTORE-001: Owner restoration - 🔧
+// ownerWriter wraps the entry writer of a restore so every written file gets
+// its mapped owner. Failures are counted and reported once, since only root
+// may give files away.
+type ownerWriter struct {
+	mapping *ownerMapping
+	failed  int
+	first   error
+}
+
+func (o *ownerWriter) wrap(write func(*zip.File, string) error) func(*zip.File, string) error {
+	return func(f *zip.File, dest string) error {
+		if err := write(f, dest); err != nil {
+			return err
+		}
+		if c, ok := o.mapping.Resolve(f); ok {
+			if err := os.Lchown(dest, c.NewUID, c.NewGID); err != nil {
+				if o.failed == 0 {
+					o.first = err
+				}
+				o.failed++
+			}
+		}
+		return nil
+	}
+}
+
+// warn reports the files whose owner could not be set.
+func (o *ownerWriter) warn(w io.Writer) {
+	if o.failed > 0 {
+		fmt.Fprintf(w, "Warning: could not set the owner of %d %s: %v\n", o.failed, pluralFiles(o.failed), o.first)
+	}
+}
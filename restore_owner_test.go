@@ -0,0 +1,130 @@
+// This file is part of bkpdir
+
+// Package main provides tests for ownership mapping on restore.
+// It verifies that archived owners are mapped by --uid-map/--gid-map pairs
+// and by name, previewed, and set on the restored files.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"bkpdir/pkg/processing"
+)
+
+// writeOwnedTestZip writes an archive whose entries are owned by the given
+// uid and gid, as recorded in the "ux" extra field.
+func writeOwnedTestZip(t *testing.T, path string, files map[string]string, uid, gid int) {
+	t.Helper()
+	extra := make([]byte, 15)
+	binary.LittleEndian.PutUint16(extra[0:], ownerExtraID)
+	binary.LittleEndian.PutUint16(extra[2:], 11)
+	extra[4], extra[5], extra[10] = 1, 4, 4
+	binary.LittleEndian.PutUint32(extra[6:], uint32(uid))
+	binary.LittleEndian.PutUint32(extra[11:], uint32(gid))
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zipw := zip.NewWriter(f)
+	for name, content := range files {
+		w, err := zipw.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Extra: extra})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zipw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ⭐ RESTORE-001: Ownership mapping - 🧪
+func TestRestoreOwnershipMapping(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file owners are not recorded on Windows")
+	}
+	current, err := user.Current()
+	if err != nil {
+		t.Skip("current user unknown")
+	}
+	uid, gid := os.Getuid(), os.Getgid()
+
+	archive := filepath.Join(t.TempDir(), "test.zip")
+	writeOwnedTestZip(t, archive, map[string]string{"a.txt": "a", "dir/b.txt": "b"}, 4242, 4343)
+	// The archived uid belongs to a user of the same name here
+	commitSidecars(t, archive, func(txn *processing.Transaction) {
+		recordArchiveManifest(txn, archive, nil, nil, nil, "", nil,
+			&ManifestOwners{Users: map[int]string{4242: current.Username}}, nil)
+	})
+
+	mapFile := filepath.Join(t.TempDir(), "gids")
+	if err := os.WriteFile(mapFile, []byte(fmt.Sprintf("# build host groups\n4343:%d\n", gid)), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	target := t.TempDir()
+	var out bytes.Buffer
+	opts := RestoreOptions{Config: DefaultConfig(), Output: &out, Archive: archive, Target: target,
+		Conflict: RestoreConflictFail, Preview: true, GIDMap: []string{mapFile}}
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	want := fmt.Sprintf("dir/b.txt: 4242:4343 → %d (%s):%d", uid, current.Username, gid)
+	if !strings.Contains(out.String(), "Owners mapped for 2 files:") || !strings.Contains(out.String(), want) {
+		t.Errorf("Expected preview to contain %q, got:\n%s", want, out.String())
+	}
+
+	opts.Preview = false
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Lstat(filepath.Join(target, "dir", "b.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if owner, _ := fileOwnerID(info); owner != uid {
+		t.Errorf("owner = %d, want %d", owner, uid)
+	}
+	if group, _ := fileGroupID(info); group != gid {
+		t.Errorf("group = %d, want %d", group, gid)
+	}
+
+	opts.UIDMap = []string{"1000-501"}
+	if err := RestoreArchive(opts); err == nil || !strings.Contains(err.Error(), "Invalid owner mapping") {
+		t.Errorf("Expected an invalid pair to be refused, got %v", err)
+	}
+}
+
+// ⭐ RESTORE-001: Owner resolution order - 🧪
+func TestResolveOwnerID(t *testing.T) {
+	lookup := func(name string) (int, bool) { return map[string]int{"alice": 501}[name], name == "alice" }
+	names := map[int]string{1000: "alice", 1001: "bob"}
+	for _, tc := range []struct {
+		id, want int
+		from     string
+	}{
+		{1000, 600, "map"},
+		{1001, 1001, ""},
+		{1002, 1002, ""},
+	} {
+		got, from := resolveOwnerID(tc.id, map[int]int{1000: 600}, names, lookup)
+		if got != tc.want || from != tc.from {
+			t.Errorf("resolveOwnerID(%d) = %d, %q; want %d, %q", tc.id, got, from, tc.want, tc.from)
+		}
+	}
+	if got, from := resolveOwnerID(1000, nil, names, lookup); got != 501 || from != "alice" {
+		t.Errorf("name resolution = %d, %q", got, from)
+	}
+}
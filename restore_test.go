@@ -0,0 +1,214 @@
+// This file is part of bkpdir
+
+// Package main provides tests for restoring archives.
+// It verifies that the preview classifies files as created, overwritten,
+// unchanged or conflicting, and that the conflict strategies decide what is
+// written.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// restoreTestSetup writes an archive and a target that already holds some
+// of its files.
+func restoreTestSetup(t *testing.T) (string, string) {
+	t.Helper()
+	archive := filepath.Join(t.TempDir(), "test.zip")
+	writeTestZip(t, archive, map[string]string{
+		"new.txt":       "new",
+		"same.txt":      "same",
+		"changed.txt":   "archived",
+		"blocked/a.txt": "a",
+		".checksums":    "{}",
+	})
+	target := t.TempDir()
+	for name, content := range map[string]string{"same.txt": "same", "changed.txt": "local", "blocked": "a file"} {
+		if err := os.WriteFile(filepath.Join(target, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return archive, target
+}
+
+// ⭐ RESTORE-002: Restore planning - 🧪
+func TestPlanRestore(t *testing.T) {
+	archive, target := restoreTestSetup(t)
+	r, err := zip.OpenReader(archive)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+
+	plan, err := PlanRestore(&r.Reader, target, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := make(map[string]string)
+	for _, e := range plan.Entries {
+		got[filepath.ToSlash(e.Path)] = e.Action
+	}
+	want := map[string]string{
+		"new.txt":       RestoreCreate,
+		"same.txt":      RestoreUnchanged,
+		"changed.txt":   RestoreOverwrite,
+		"blocked/a.txt": RestoreConflict,
+	}
+	for path, action := range want {
+		if got[path] != action {
+			t.Errorf("%s: expected %s, got %q", path, action, got[path])
+		}
+	}
+	if n, size := plan.Count(RestoreOverwrite); n != 1 || size != int64(len("archived")) {
+		t.Errorf("Expected one overwrite of 8 bytes, got %d (%d)", n, size)
+	}
+
+	if os.Geteuid() != 0 {
+		if err := os.Chmod(filepath.Join(target, "changed.txt"), 0o444); err != nil {
+			t.Fatal(err)
+		}
+		plan, _ = PlanRestore(&r.Reader, target, "", false)
+		for _, e := range plan.Entries {
+			if e.Path == "changed.txt" && (e.Action != RestoreConflict || e.Reason != "existing file is read-only") {
+				t.Errorf("Expected a read-only conflict, got %+v", e)
+			}
+		}
+	}
+}
+
+// ⭐ RESTORE-002: Unsafe entry names - 🧪
+func TestRestoreEntryPath(t *testing.T) {
+	for _, name := range []string{"../x", "a/../../x", "/etc/passwd", "", "a\\..\\..\\x"} {
+		if _, err := restoreEntryPath(name); err == nil {
+			t.Errorf("Expected %q to be refused", name)
+		}
+	}
+	if got, err := restoreEntryPath("dir/sub/"); err != nil || got != filepath.Join("dir", "sub") {
+		t.Errorf("Expected dir/sub, got %q, %v", got, err)
+	}
+}
+
+// ⭐ RESTORE-002: Preview report and conflict strategies - 🧪
+func TestRestoreArchive(t *testing.T) {
+	archive, target := restoreTestSetup(t)
+	cfg := DefaultConfig()
+	opts := RestoreOptions{Config: cfg, Archive: archive, Target: target, Conflict: RestoreConflictFail}
+
+	var out bytes.Buffer
+	opts.Output, opts.Preview = &out, true
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"conflict   blocked/a.txt: blocked is not a directory", "overwrite  changed.txt (8B)", "create     new.txt (3B)", "unchanged      1 file"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("Expected preview to contain %q, got:\n%s", want, out.String())
+		}
+	}
+	if _, err := os.Stat(filepath.Join(target, "new.txt")); !os.IsNotExist(err) {
+		t.Error("Expected the preview not to write files")
+	}
+
+	opts.Preview = false
+	if err := RestoreArchive(opts); err == nil {
+		t.Error("Expected the fail strategy to refuse conflicts")
+	}
+	opts.Conflict = "merge"
+	if err := RestoreArchive(opts); err == nil {
+		t.Error("Expected an unknown strategy to be refused")
+	}
+
+	out.Reset()
+	opts.Conflict = RestoreConflictSkip
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(target, "new.txt"), "new")
+	assertFileContent(t, filepath.Join(target, "changed.txt"), "local")
+	if !strings.Contains(out.String(), "Restored 1 files") {
+		t.Errorf("Unexpected summary %q", out.String())
+	}
+
+	if err := os.Remove(filepath.Join(target, "blocked")); err != nil {
+		t.Fatal(err)
+	}
+	opts.Conflict = RestoreConflictOverwrite
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	assertFileContent(t, filepath.Join(target, "changed.txt"), "archived")
+	assertFileContent(t, filepath.Join(target, "blocked", "a.txt"), "a")
+	if _, err := os.Stat(filepath.Join(target, ".checksums")); !os.IsNotExist(err) {
+		t.Error("Expected .checksums not to be restored")
+	}
+}
+
+// assertFileContent fails the test unless path holds want.
+func assertFileContent(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != want {
+		t.Errorf("Expected %s to hold %q, got %q, %v", path, want, data, err)
+	}
+}
+
+// ⭐ RESTORE-LINK-001: Linked restores share store objects - 🧪
+func TestRestoreArchiveLinked(t *testing.T) {
+	archive := filepath.Join(t.TempDir(), "test.zip")
+	writeTestZip(t, archive, map[string]string{"a.txt": "same", "dir/b.txt": "same", "c.txt": "other"})
+	store := filepath.Join(t.TempDir(), "store")
+	cfg := DefaultConfig()
+
+	var out bytes.Buffer
+	target := t.TempDir()
+	opts := RestoreOptions{Config: cfg, Output: &out, Archive: archive, Target: target, Conflict: RestoreConflictFail, Link: RestoreLinkSymlink, Store: store}
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Linked 3 files") || !strings.Contains(out.String(), "2 new files (9B)") {
+		t.Errorf("Unexpected summary %q", out.String())
+	}
+	a, _ := os.Readlink(filepath.Join(target, "a.txt"))
+	b, _ := os.Readlink(filepath.Join(target, "dir", "b.txt"))
+	if a == "" || a != b || !strings.HasPrefix(a, store) {
+		t.Errorf("Expected identical files to link to one store object, got %q and %q", a, b)
+	}
+	assertFileContent(t, filepath.Join(target, "dir", "b.txt"), "same")
+
+	// Repeating the restore leaves the links unchanged
+	out.Reset()
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatalf("Expected a repeated linked restore to succeed, got %v", err)
+	}
+	if !strings.Contains(out.String(), "Linked 0 files") || !strings.Contains(out.String(), "3 unchanged") {
+		t.Errorf("Unexpected summary %q", out.String())
+	}
+
+	// Hard links reuse the stored objects
+	out.Reset()
+	opts.Link, opts.Target = RestoreLinkHard, t.TempDir()
+	if err := RestoreArchive(opts); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "0 new files") {
+		t.Errorf("Expected no new store objects, got %q", out.String())
+	}
+	info, err := os.Lstat(filepath.Join(opts.Target, "c.txt"))
+	object, _ := os.Stat(filepath.Join(target, "c.txt"))
+	if err != nil || object == nil || !os.SameFile(info, object) || info.Mode().Perm()&0o222 != 0 {
+		t.Errorf("Expected a read-only hard link to the store object, got %v, %v", info, err)
+	}
+
+	opts.Link = "copy"
+	if err := RestoreArchive(opts); err == nil {
+		t.Error("Expected an unknown link type to be refused")
+	}
+	opts.Link = ""
+	if err := RestoreArchive(opts); err == nil {
+		t.Error("Expected --store without --link to be refused")
+	}
+}
@@ -0,0 +1,22 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+// This file is part of bkpdir
+//
+// Package main provides the write permission check of restore previews on
+// Unix systems.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import "syscall"
+
+// accessWrite is the W_OK mode of access(2).
+const accessWrite = 0x2
+
+// ⭐ RESTORE-002: Permission conflicts - 🔍
+// isWritable reports whether the current user may write path.
+func isWritable(path string) bool {
+	return syscall.Access(path, accessWrite) == nil
+}
@@ -0,0 +1,313 @@
+// This file is part of bkpdir
+//
+// Package main provides the run history: a summary of the last runs of each
+// archived directory and backed-up file, kept in the state directory, read
+// by `bkpdir last` to answer "did last night's backup run?".
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/formatter"
+)
+
+// ⭐ LAST-001: Run kinds - 🔧
+const (
+	RunKindFull        = "full"
+	RunKindIncremental = "incremental"
+	RunKindBackup      = "backup"
+)
+
+// runKinds orders the kinds reported by `bkpdir last`.
+var runKinds = []string{RunKindFull, RunKindIncremental, RunKindBackup}
+
+// ⭐ LAST-001: Run history location - 🔧
+// runHistoryName is the run history in the state directory.
+const runHistoryName = "runs.json"
+
+// ⭐ LAST-001: Run summary - 📝
+// RunSummary is one recorded run. Archive is empty when the run created
+// nothing, because nothing changed or it failed before naming the archive.
+type RunSummary struct {
+	Kind     string    `json:"kind"`
+	Started  time.Time `json:"started"`
+	Duration float64   `json:"duration_seconds"`
+	Archive  string    `json:"archive,omitempty"`
+	Size     int64     `json:"size,omitempty"`
+	Outcome  string    `json:"outcome"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// Succeeded reports whether the run completed.
+func (r RunSummary) Succeeded() bool {
+	return r.Outcome == AuditOutcomeOK
+}
+
+// RunHistory holds the recorded runs of each target, an absolute directory
+// or file path, oldest first.
+type RunHistory struct {
+	Path    string                  `json:"-"`
+	Targets map[string][]RunSummary `json:"targets"`
+}
+
+// LoadRunHistory reads the run history at path; a missing file yields an
+// empty history.
+func LoadRunHistory(path string) (*RunHistory, error) {
+	h := &RunHistory{Path: path, Targets: map[string][]RunSummary{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return h, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read run history: %w", err)
+	}
+	if err := json.Unmarshal(data, h); err != nil {
+		return nil, fmt.Errorf("failed to parse run history %s: %w", path, err)
+	}
+	if h.Targets == nil {
+		h.Targets = map[string][]RunSummary{}
+	}
+	return h, nil
+}
+
+// Add appends run to the runs of target, keeping the newest limit.
+func (h *RunHistory) Add(target string, run RunSummary, limit int) {
+	runs := append(h.Targets[target], run)
+	if len(runs) > limit {
+		runs = runs[len(runs)-limit:]
+	}
+	h.Targets[target] = runs
+}
+
+// Save writes the history atomically.
+func (h *RunHistory) Save() error {
+	data, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode run history: %w", err)
+	}
+	if err := fileops.MkdirAll(filepath.Dir(h.Path), 0755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	return fileops.AtomicWriteFile(h.Path, data, 0644)
+}
+
+// runRecord is a run being recorded. A nil record ignores all calls, so runs
+// can be recorded unconditionally.
+type runRecord struct {
+	cfg    *Config
+	target string
+	run    RunSummary
+}
+
+// ⭐ LAST-001: Run recording - 🔧
+// startRunRecord starts timing a run of kind on target, or returns nil for
+// dry runs and when run_history is 0.
+func startRunRecord(cfg *Config, kind, target string, dryRun bool) *runRecord {
+	if cfg == nil || cfg.RunHistory <= 0 || dryRun {
+		return nil
+	}
+	if abs, err := filepath.Abs(target); err == nil {
+		target = abs
+	}
+	return &runRecord{cfg: cfg, target: target, run: RunSummary{Kind: kind, Started: time.Now()}}
+}
+
+// finish records the outcome of the run and the archive it created, if
+// any. A failure only warns: the run itself has already happened.
+func (r *runRecord) finish(archivePath string, err error) {
+	if r == nil {
+		return
+	}
+	run := r.run
+	run.Duration = time.Since(run.Started).Seconds()
+	run.Started = run.Started.UTC()
+	run.Outcome = AuditOutcomeOK
+	if err != nil {
+		run.Outcome = AuditOutcomeFailed
+		run.Error = err.Error()
+	} else if info, statErr := os.Stat(archivePath); archivePath != "" && statErr == nil {
+		run.Archive, _ = filepath.Abs(archivePath)
+		run.Size = info.Size()
+	}
+	if err := appendRunSummary(r.target, run, r.cfg.RunHistory); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record the run summary: %v\n", err)
+	} else if dir, err := StateDir(); err == nil {
+		// ⭐ SUDO-OWNER-001: Keep the history writable without sudo
+		applyInvokingUserOwnership(r.cfg, filepath.Join(dir, runHistoryName))
+	}
+}
+
+// appendRunSummary adds run to the history in the state directory.
+func appendRunSummary(target string, run RunSummary, limit int) error {
+	dir, err := StateDir()
+	if err != nil {
+		return err
+	}
+	h, err := LoadRunHistory(filepath.Join(dir, runHistoryName))
+	if err != nil {
+		return err
+	}
+	h.Add(target, run, limit)
+	return h.Save()
+}
+
+// ⭐ LAST-001: Last runs report - 📝
+// LastRunsReport is the JSON form of `bkpdir last` for one target: the last
+// successful run of each kind, and the last run when it failed.
+type LastRunsReport struct {
+	Target     string                `json:"target"`
+	Successful map[string]RunSummary `json:"last_successful"`
+	LastFailed *RunSummary           `json:"last_failed,omitempty"`
+}
+
+// lastRuns summarizes the runs of target, oldest first.
+func lastRuns(target string, runs []RunSummary) LastRunsReport {
+	report := LastRunsReport{Target: target, Successful: map[string]RunSummary{}}
+	for i, run := range runs {
+		if run.Succeeded() {
+			report.Successful[run.Kind] = run
+		} else if i == len(runs)-1 {
+			failed := run
+			report.LastFailed = &failed
+		}
+	}
+	return report
+}
+
+// LastOptions holds the parameters of the last command.
+type LastOptions struct {
+	Target string // Directory or file; empty is the current directory
+	All    bool   // Report every recorded target
+	Output string // "text" (default) or "json"
+	Now    time.Time
+}
+
+// ⭐ LAST-001: Last runs command - 🔍
+// ShowLastRuns writes when the last full, incremental and backup runs of
+// the target succeeded, with their archive, size and duration, to w. The
+// error has the status of cfg.StatusFileNotFound when nothing was recorded.
+func ShowLastRuns(w io.Writer, cfg *Config, opts LastOptions) error {
+	if opts.Output != "" && opts.Output != OutputText && opts.Output != OutputJSON {
+		return NewArchiveError(fmt.Sprintf("Unknown output format %q (use text or json)", opts.Output), cfg.StatusConfigError)
+	}
+	if opts.All && opts.Target != "" {
+		return NewArchiveError("--all does not take a PATH", cfg.StatusConfigError)
+	}
+	dir, err := StateDir()
+	if err != nil {
+		return NewArchiveErrorWithCause("Cannot read the run history", 1, err)
+	}
+	h, err := LoadRunHistory(filepath.Join(dir, runHistoryName))
+	if err != nil {
+		return NewArchiveErrorWithCause("Cannot read the run history", 1, err)
+	}
+
+	var targets []string
+	if opts.All {
+		for target := range h.Targets {
+			targets = append(targets, target)
+		}
+		sort.Strings(targets)
+	} else {
+		target := opts.Target
+		if target == "" {
+			target = "."
+		}
+		if abs, err := filepath.Abs(target); err == nil {
+			target = abs
+		}
+		if len(h.Targets[target]) > 0 {
+			targets = []string{target}
+		}
+	}
+	if len(targets) == 0 {
+		what := "any target"
+		if !opts.All {
+			what = opts.Target
+			if what == "" {
+				what = "this directory"
+			}
+		}
+		return NewArchiveError(fmt.Sprintf("No runs recorded for %s", what), cfg.StatusFileNotFound)
+	}
+
+	reports := make([]LastRunsReport, 0, len(targets))
+	for _, target := range targets {
+		reports = append(reports, lastRuns(target, h.Targets[target]))
+	}
+	if opts.Output == OutputJSON {
+		if opts.All {
+			return writeJSONReport(w, reports)
+		}
+		return writeJSONReport(w, reports[0])
+	}
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	for i, report := range reports {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		writeLastRunsText(w, report, now)
+	}
+	return nil
+}
+
+// writeLastRunsText writes one target of the last command.
+func writeLastRunsText(w io.Writer, report LastRunsReport, now time.Time) {
+	fmt.Fprintln(w, report.Target)
+	for _, kind := range runKinds {
+		run, ok := report.Successful[kind]
+		if !ok {
+			continue
+		}
+		what := "no changes"
+		if run.Archive != "" {
+			what = fmt.Sprintf("%s (%s)", filepath.Base(run.Archive), formatHumanSize(run.Size))
+		}
+		fmt.Fprint(w, formatter.StyleStdout(fmt.Sprintf("  ✅ %-11s %s, %s ago, took %s: %s\n", kind,
+			run.Started.Local().Format("2006-01-02 15:04"), formatRunAge(now.Sub(run.Started)),
+			formatRunDuration(run.Duration), what)))
+	}
+	if run := report.LastFailed; run != nil {
+		fmt.Fprint(w, formatter.StyleStdout(fmt.Sprintf("  ❌ %-11s %s, %s ago: %s\n", run.Kind,
+			run.Started.Local().Format("2006-01-02 15:04"), formatRunAge(now.Sub(run.Started)),
+			strings.TrimSpace(run.Error))))
+	}
+}
+
+// formatRunAge formats how long ago a run started, to the largest unit.
+func formatRunAge(d time.Duration) string {
+	switch {
+	case d < time.Minute:
+		return "moments"
+	case d < time.Hour:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	case d < 48*time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	default:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	}
+}
+
+// formatRunDuration formats a duration in seconds for display.
+func formatRunDuration(seconds float64) string {
+	d := time.Duration(seconds * float64(time.Second))
+	if d < time.Second {
+		return d.Round(time.Millisecond).String()
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,125 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the run history and `bkpdir last`.
+// It verifies recording, the per-target limit and the reported runs.
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestMain keeps the runs recorded by the tests of this package out of the
+// user's state directory.
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "bkpdir-state-")
+	if err != nil {
+		panic(err)
+	}
+	os.Setenv(stateDirEnvVar, dir)
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// ⭐ LAST-001: Run recording - 🧪
+func TestRunRecordedForArchives(t *testing.T) {
+	state := t.TempDir()
+	t.Setenv(stateDirEnvVar, state)
+	cfg := uploadTestConfig(t, t.TempDir())
+	cwd, _ := os.Getwd()
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+
+	if err := CreateFullArchiveWithContext(context.Background(), cfg, "", true, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFullArchiveWithContext(context.Background(), cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateIncrementalArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	cfg.RunHistory = 2
+	if err := CreateIncrementalArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	h, err := LoadRunHistory(filepath.Join(state, runHistoryName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The dry run is not recorded, and only the last two runs are kept
+	runs := h.Targets[cwd]
+	if len(runs) != 2 || runs[0].Kind != RunKindIncremental || runs[1].Kind != RunKindIncremental {
+		t.Fatalf("runs = %+v", runs)
+	}
+	// Nothing changed since the full archive
+	if runs[1].Archive != "" || !runs[1].Succeeded() {
+		t.Errorf("incremental run = %+v", runs[1])
+	}
+
+	cfg.RunHistory = 20
+	os.WriteFile("b.txt", []byte("b"), 0o644)
+	if err := CreateFullArchiveWithContext(context.Background(), cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	h, _ = LoadRunHistory(filepath.Join(state, runHistoryName))
+	last := h.Targets[cwd][len(h.Targets[cwd])-1]
+	info, err := os.Stat(last.Archive)
+	if err != nil || last.Kind != RunKindFull || last.Size != info.Size() || !filepath.IsAbs(last.Archive) {
+		t.Errorf("full run = %+v (%v)", last, err)
+	}
+}
+
+// ⭐ LAST-001: Last runs report - 🧪
+func TestShowLastRuns(t *testing.T) {
+	t.Setenv(stateDirEnvVar, t.TempDir())
+	cfg := DefaultConfig()
+	now := time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)
+	target := filepath.Join(t.TempDir(), "project")
+	for _, run := range []RunSummary{
+		{Kind: RunKindFull, Started: now.Add(-50 * time.Hour), Duration: 12.4, Archive: "/a/project-full.zip", Size: 2048, Outcome: AuditOutcomeOK},
+		{Kind: RunKindIncremental, Started: now.Add(-26 * time.Hour), Duration: 0.25, Archive: "/a/project-inc.zip", Size: 10, Outcome: AuditOutcomeOK},
+		{Kind: RunKindIncremental, Started: now.Add(-2 * time.Hour), Outcome: AuditOutcomeFailed, Error: "disk full"},
+	} {
+		if err := appendRunSummary(target, run, cfg.RunHistory); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := ShowLastRuns(&out, cfg, LastOptions{Target: target, Now: now}); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"full        ", "2d ago, took 12s: project-full.zip (2.0KB)",
+		"26h ago, took 250ms: project-inc.zip", "❌ incremental", "2h ago: disk full"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output lacks %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := ShowLastRuns(&out, cfg, LastOptions{All: true, Output: OutputJSON}); err != nil {
+		t.Fatal(err)
+	}
+	var reports []LastRunsReport
+	if err := json.Unmarshal(out.Bytes(), &reports); err != nil {
+		t.Fatal(err)
+	}
+	if len(reports) != 1 || reports[0].Successful[RunKindIncremental].Size != 10 || reports[0].LastFailed == nil {
+		t.Errorf("reports = %+v", reports)
+	}
+
+	err := ShowLastRuns(&out, cfg, LastOptions{Target: t.TempDir()})
+	var archiveErr *ArchiveError
+	if !errors.As(err, &archiveErr) || archiveErr.StatusCode != cfg.StatusFileNotFound {
+		t.Errorf("unrecorded target: err = %v", err)
+	}
+}
@@ -0,0 +1,228 @@
+// This file is part of bkpdir
+//
+// Package main provides HMAC integrity seals for archives. The seal key lives
+// in the OS keychain so archives modified by other tools can be detected
+// without full signing infrastructure.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/keyring"
+	"bkpdir/pkg/processing"
+)
+
+// ⭐ SEAL-001: Seal key location - 🔧
+const (
+	// sealKeyAccount is the keyring alias of the seal key.
+	sealKeyAccount = "integrity-seal"
+	// sealKeyEnvVar supplies a hex-encoded key directly, for CI and hosts without a keychain.
+	sealKeyEnvVar = "BKPDIR_SEAL_KEY"
+	sealAlgorithm = "hmac-sha256"
+	sealSuffix    = ".seal.json"
+)
+
+// ⭐ SEAL-001: Seal check results - 🔧
+// SealState describes the outcome of checking an archive against its seal.
+type SealState int
+
+const (
+	// SealMissing means the archive was never sealed.
+	SealMissing SealState = iota
+	// SealValid means the archive matches its seal.
+	SealValid
+	// SealMismatch means the archive or seal changed after sealing.
+	SealMismatch
+)
+
+var errSealKeyNotFound = errors.New("integrity seal key not found in keychain")
+
+// ⭐ SEAL-001: Archive seal record - 🔧
+// ArchiveSeal is stored as .metadata/<archive>.seal.json next to the archive.
+type ArchiveSeal struct {
+	Algorithm string    `json:"algorithm"`
+	Archive   string    `json:"archive"`
+	Size      int64     `json:"size"`
+	SHA256    string    `json:"sha256"`
+	SealedAt  time.Time `json:"sealed_at"`
+	HMAC      string    `json:"hmac"`
+}
+
+// message is the byte string authenticated by the HMAC.
+func (s ArchiveSeal) message() []byte {
+	return []byte(fmt.Sprintf("%s\n%s\n%d\n%s\n%s",
+		s.Algorithm, s.Archive, s.Size, s.SHA256, s.SealedAt.UTC().Format(time.RFC3339Nano)))
+}
+
+// ⭐ SEAL-001: Seal key resolution - 🔧
+// loadSealKey returns the seal key from $BKPDIR_SEAL_KEY or the OS keychain.
+// When create is set and the keychain holds no key, a new random key is stored.
+func loadSealKey(create bool) ([]byte, error) {
+	if env := os.Getenv(sealKeyEnvVar); env != "" {
+		key, err := hex.DecodeString(env)
+		if err != nil || len(key) < 16 {
+			return nil, fmt.Errorf("%s must be at least 16 hex-encoded bytes", sealKeyEnvVar)
+		}
+		return key, nil
+	}
+
+	// ⭐ KEYRING-001: The seal key is the keyring secret integrity-seal
+	encoded, err := keyring.Get(sealKeyAccount)
+	if errors.Is(err, keyring.ErrUnsupported) {
+		return nil, fmt.Errorf("%v; set %s", err, sealKeyEnvVar)
+	}
+	if errors.Is(err, keyring.ErrNotFound) && create {
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			return nil, fmt.Errorf("failed to generate seal key: %w", err)
+		}
+		encoded = hex.EncodeToString(key)
+		if err := keyring.Set(sealKeyAccount, encoded); err != nil {
+			return nil, fmt.Errorf("failed to store seal key in keychain: %w", err)
+		}
+	} else if errors.Is(err, keyring.ErrNotFound) {
+		return nil, errSealKeyNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return hex.DecodeString(strings.TrimSpace(encoded))
+}
+
+// sealPath returns the seal location for an archive.
+func sealPath(archivePath string) string {
+	return filepath.Join(filepath.Dir(archivePath), ".metadata", filepath.Base(archivePath)+sealSuffix)
+}
+
+// computeSealHMAC authenticates the seal fields with key.
+func computeSealHMAC(key []byte, seal ArchiveSeal) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(seal.message())
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// hashArchive returns the size and SHA-256 of the archive file.
+func hashArchive(archivePath string) (int64, string, error) {
+	f, err := os.Open(archivePath)
+	if err != nil {
+		return 0, "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return 0, "", err
+	}
+	return size, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ⭐ SEAL-001: Archive sealing - 🔧
+// SealArchive writes an HMAC seal over the archive's name, size and SHA-256.
+func SealArchive(archivePath string) error {
+	data, err := encodeArchiveSeal(filepath.Base(archivePath), archivePath)
+	if err != nil {
+		return err
+	}
+	path := sealPath(archivePath)
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create metadata directory: %w", err)
+	}
+	return fileops.AtomicWriteFile(path, data, 0o644)
+}
+
+// encodeArchiveSeal returns the seal for an archive named name whose content
+// is at contentPath, which differs from the final path while it is staged.
+func encodeArchiveSeal(name, contentPath string) ([]byte, error) {
+	key, err := loadSealKey(true)
+	if err != nil {
+		return nil, err
+	}
+	size, sum, err := hashArchive(contentPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash archive: %w", err)
+	}
+
+	seal := ArchiveSeal{
+		Algorithm: sealAlgorithm,
+		Archive:   name,
+		Size:      size,
+		SHA256:    sum,
+		SealedAt:  time.Now().UTC(),
+	}
+	seal.HMAC = computeSealHMAC(key, seal)
+
+	data, err := json.MarshalIndent(seal, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode seal: %w", err)
+	}
+	return data, nil
+}
+
+// ⭐ SEAL-001: Archive seal verification - 🔧
+// CheckArchiveSeal compares an archive against its seal. A mismatch means
+// either the archive bytes or the seal itself were changed after sealing.
+func CheckArchiveSeal(archivePath string) (SealState, error) {
+	data, err := os.ReadFile(sealPath(archivePath))
+	if os.IsNotExist(err) {
+		return SealMissing, nil
+	}
+	if err != nil {
+		return SealMissing, fmt.Errorf("failed to read seal: %w", err)
+	}
+	var seal ArchiveSeal
+	if err := json.Unmarshal(data, &seal); err != nil {
+		return SealMismatch, nil
+	}
+	if seal.Algorithm != sealAlgorithm {
+		return SealMismatch, nil
+	}
+
+	key, err := loadSealKey(false)
+	if err != nil {
+		return SealMissing, err
+	}
+	if !hmac.Equal([]byte(computeSealHMAC(key, seal)), []byte(seal.HMAC)) {
+		return SealMismatch, nil
+	}
+
+	size, sum, err := hashArchive(archivePath)
+	if err != nil {
+		return SealMissing, fmt.Errorf("failed to hash archive: %w", err)
+	}
+	if seal.Archive != filepath.Base(archivePath) || size != seal.Size || sum != seal.SHA256 {
+		return SealMismatch, nil
+	}
+	return SealValid, nil
+}
+
+// ⭐ SEAL-001: Post-creation sealing hook - 🔧
+// sealCreatedArchive stages the seal of a new archive, staged at stagedPath,
+// in txn when integrity_seal is enabled. A missing keychain only produces a
+// warning; the archive itself is complete.
+func sealCreatedArchive(txn *processing.Transaction, archivePath, stagedPath string, cfg ArchiveConfigInterface) {
+	if !cfg.GetIntegritySeal() {
+		return
+	}
+	data, err := encodeArchiveSeal(filepath.Base(archivePath), stagedPath)
+	if err == nil {
+		err = txn.WriteFile(sealPath(archivePath), data, 0o644)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not seal %s: %v\n", filepath.Base(archivePath), err)
+	}
+}
@@ -0,0 +1,56 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive integrity seals.
+// It verifies sealing, tamper detection and seal-less archives.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ SEAL-001: Integrity seal tests - 🔧
+func TestArchiveSeal(t *testing.T) {
+	t.Setenv(sealKeyEnvVar, strings.Repeat("ab", 32))
+	dir := t.TempDir()
+	archive := filepath.Join(dir, "proj-2024-01-01-10-00.zip")
+	if err := os.WriteFile(archive, []byte("archive bytes"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if state, err := CheckArchiveSeal(archive); err != nil || state != SealMissing {
+		t.Fatalf("Expected SealMissing before sealing, got %v (%v)", state, err)
+	}
+
+	if err := SealArchive(archive); err != nil {
+		t.Fatalf("SealArchive failed: %v", err)
+	}
+	if state, err := CheckArchiveSeal(archive); err != nil || state != SealValid {
+		t.Fatalf("Expected SealValid, got %v (%v)", state, err)
+	}
+
+	t.Run("wrong key", func(t *testing.T) {
+		t.Setenv(sealKeyEnvVar, strings.Repeat("cd", 32))
+		if state, _ := CheckArchiveSeal(archive); state != SealMismatch {
+			t.Errorf("Expected SealMismatch with a different key, got %v", state)
+		}
+	})
+
+	t.Run("modified archive", func(t *testing.T) {
+		if err := os.WriteFile(archive, []byte("archive bytes, rewritten"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if state, _ := CheckArchiveSeal(archive); state != SealMismatch {
+			t.Errorf("Expected SealMismatch after modification, got %v", state)
+		}
+	})
+
+	t.Run("invalid key", func(t *testing.T) {
+		t.Setenv(sealKeyEnvVar, "not-hex")
+		if err := SealArchive(archive); err == nil {
+			t.Error("Expected error for malformed key")
+		}
+	})
+}
@@ -0,0 +1,121 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir keyring`, which keeps credentials in the OS
+// credential store, and connects the store to configuration secrets so that
+// `secretref:keychain/ALIAS` resolves to the secret stored under ALIAS.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"bkpdir/pkg/config"
+	"bkpdir/pkg/keyring"
+)
+
+// keychainStore is the secret store name of the OS credential store in
+// secretref references.
+const keychainStore = "keychain"
+
+// ⭐ KEYRING-001: Keyring secret store - 🔧
+// registerSecretStores makes the OS credential store available to
+// secretref:keychain/ALIAS configuration values.
+func registerSecretStores() {
+	config.RegisterSecretResolver(keychainStore, keyring.Get)
+}
+
+// keyringError wraps a credential store failure with its alias.
+func keyringError(cfg *Config, alias string, err error) error {
+	if errors.Is(err, keyring.ErrNotFound) {
+		return NewArchiveError(fmt.Sprintf("No secret stored under %q", alias), cfg.StatusConfigError)
+	}
+	return NewArchiveErrorWithCause(fmt.Sprintf("Keyring access for %q failed", alias), cfg.StatusConfigError, err)
+}
+
+// ⭐ KEYRING-001: Storing secrets - 🔧
+// KeyringSet stores the first line of in under alias. When in is a
+// terminal the secret is prompted for without echo.
+func KeyringSet(cfg *Config, in io.Reader, w io.Writer, alias string, dryRun bool) error {
+	secret, err := readSecret(in, w, fmt.Sprintf("Secret for %s: ", alias))
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to read the secret", cfg.StatusConfigError, err)
+	}
+	if secret == "" {
+		return NewArchiveError("Refusing to store an empty secret", cfg.StatusConfigError)
+	}
+	if dryRun {
+		fmt.Fprintf(w, "Would store a secret under %q\n", alias)
+		return nil
+	}
+	if err := keyring.Set(alias, secret); err != nil {
+		return keyringError(cfg, alias, err)
+	}
+	fmt.Fprintf(w, "Stored secret %q; refer to it as %s%s/%s\n", alias, config.SecretRefPrefix, keychainStore, alias)
+	return nil
+}
+
+// ⭐ KEYRING-001: Reading secrets - 🔍
+// KeyringGet prints the secret stored under alias.
+func KeyringGet(cfg *Config, w io.Writer, alias string) error {
+	secret, err := keyring.Get(alias)
+	if err != nil {
+		return keyringError(cfg, alias, err)
+	}
+	fmt.Fprintln(w, secret)
+	return nil
+}
+
+// ⭐ KEYRING-001: Removing secrets - 🔧
+// KeyringDelete removes the secret stored under alias.
+func KeyringDelete(cfg *Config, w io.Writer, alias string, dryRun bool) error {
+	if dryRun {
+		fmt.Fprintf(w, "Would delete the secret %q\n", alias)
+		return nil
+	}
+	if err := keyring.Delete(alias); err != nil {
+		return keyringError(cfg, alias, err)
+	}
+	fmt.Fprintf(w, "Deleted secret %q\n", alias)
+	return nil
+}
+
+// readSecret reads one line from in. On a terminal it prints prompt and
+// turns echo off with stty while the line is typed.
+func readSecret(in io.Reader, w io.Writer, prompt string) (string, error) {
+	if f, ok := in.(*os.File); ok {
+		if info, err := f.Stat(); err == nil && info.Mode()&os.ModeCharDevice != 0 {
+			fmt.Fprint(w, prompt)
+			if setTerminalEcho(f, false) == nil {
+				defer func() {
+					setTerminalEcho(f, true)
+					fmt.Fprintln(w)
+				}()
+			}
+		}
+	}
+	line, err := bufio.NewReader(in).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// setTerminalEcho switches echo of the terminal f with stty.
+func setTerminalEcho(f *os.File, on bool) error {
+	mode := "-echo"
+	if on {
+		mode = "echo"
+	}
+	cmd := exec.Command("stty", mode)
+	cmd.Stdin = f
+	return cmd.Run()
+}
@@ -0,0 +1,54 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the keyring command and the keychain
+// secret store. It verifies storing, reading and deleting secrets and that
+// configuration secrets resolve through secretref:keychain/ALIAS.
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"bkpdir/pkg/config"
+	"bkpdir/pkg/keyring"
+)
+
+// ⭐ KEYRING-001: Keyring command and secret store - 🧪
+func TestKeyringCommands(t *testing.T) {
+	defer keyring.SetProvider(keyring.SetProvider(keyring.NewMemoryProvider()))
+	registerSecretStores()
+	defer config.RegisterSecretResolver(keychainStore, nil)
+	cfg := DefaultConfig()
+
+	var out bytes.Buffer
+	if err := KeyringSet(cfg, strings.NewReader("hunter2\n"), &out, "backup", false); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "secretref:keychain/backup") {
+		t.Errorf("Expected the reference to be shown, got %q", out.String())
+	}
+	out.Reset()
+	if err := KeyringGet(cfg, &out, "backup"); err != nil || out.String() != "hunter2\n" {
+		t.Errorf("Expected the stored secret, got %q, %v", out.String(), err)
+	}
+
+	cfg.Encryption.Passphrase = "secretref:keychain/backup"
+	key, err := loadEncryptionKey(cfg)
+	if err != nil || key == nil || string(key.passphrase) != "hunter2" {
+		t.Errorf("Expected the passphrase from the keyring, got %v, %v", key, err)
+	}
+
+	if err := KeyringSet(cfg, strings.NewReader(""), &out, "empty", false); err == nil {
+		t.Error("Expected an empty secret to be refused")
+	}
+	if err := KeyringDelete(cfg, &out, "backup", false); err != nil {
+		t.Fatal(err)
+	}
+	if err := KeyringGet(cfg, &out, "backup"); err == nil || !strings.Contains(err.Error(), "No secret stored") {
+		t.Errorf("Expected a missing secret to be reported, got %v", err)
+	}
+	if _, err := loadEncryptionKey(cfg); err == nil || !strings.Contains(err.Error(), "not found") {
+		t.Errorf("Expected a missing keyring secret to fail the passphrase, got %v", err)
+	}
+}
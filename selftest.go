@@ -0,0 +1,337 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir selftest`, which archives synthetic data in
+// a temporary sandbox, verifies the archive, restores it and compares the
+// restored tree with the original, to validate an installation or the
+// storage an archive directory lives on.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/formatter"
+)
+
+// defaultSelftestSize is the amount of synthetic data selftest archives.
+const defaultSelftestSize = "4MB"
+
+// SelftestOptions holds the parameters of the selftest command.
+type SelftestOptions struct {
+	Config     *Config
+	Output     io.Writer
+	ArchiveDir string // Directory to test as archive storage; empty uses the sandbox
+	Size       string // Amount of synthetic data, e.g. "4MB"
+	Keep       bool   // Keep the sandbox for inspection
+	Report     string // FORMAT=FILE report of the steps, e.g. junit=selftest.xml
+}
+
+// selftestStep is one stage of the self-test; run returns a short summary.
+type selftestStep struct {
+	Name string
+	run  func() (string, error)
+}
+
+// ⭐ SELFTEST-001: End-to-end self-test - 🛡️
+// RunSelftest runs generate, create, verify, restore and compare in a
+// sandbox and stops at the first failing step. Only the archive settings
+// compression_level, archive_jobs and max_memory are taken from opts.Config;
+// everything else uses the defaults, so hooks, plugins, notifications and
+// the audit log are never involved.
+func RunSelftest(opts SelftestOptions) error {
+	out := opts.Output
+	size := opts.Size
+	if size == "" {
+		size = defaultSelftestSize
+	}
+	limit, err := ParseByteSize(size)
+	if err != nil || limit <= 0 {
+		return NewArchiveError(fmt.Sprintf("Invalid --size %q", size), opts.Config.StatusConfigError)
+	}
+	// ⭐ JUNIT-001: One test case per step
+	_, reportPath, err := parseReportSpec(opts.Config, opts.Report)
+	if err != nil {
+		return err
+	}
+	report := newJUnitReport("selftest", reportPath)
+	defer report.write()
+
+	sandbox, err := os.MkdirTemp("", "bkpdir-selftest-")
+	if err != nil {
+		report.errored("sandbox", 0, err)
+		return NewArchiveErrorWithCause("Cannot create the sandbox", opts.Config.StatusDirectoryNotFound, err)
+	}
+	archiveDir := filepath.Join(sandbox, "archives")
+	if opts.ArchiveDir != "" {
+		if archiveDir, err = os.MkdirTemp(opts.ArchiveDir, "bkpdir-selftest-"); err != nil {
+			os.RemoveAll(sandbox)
+			report.errored("sandbox", 0, err)
+			return NewArchiveErrorWithCause("Cannot write to "+opts.ArchiveDir, opts.Config.StatusPermissionDenied, err)
+		}
+	}
+	defer func() {
+		if opts.Keep {
+			fmt.Fprintf(out, "Kept %s\n", sandbox)
+			if opts.ArchiveDir != "" {
+				fmt.Fprintf(out, "Kept %s\n", archiveDir)
+			}
+			return
+		}
+		os.RemoveAll(sandbox)
+		os.RemoveAll(archiveDir)
+	}()
+
+	cfg := selftestConfig(opts.Config, archiveDir, sandbox)
+	source := filepath.Join(sandbox, "source")
+	restored := filepath.Join(sandbox, "restored")
+	var archivePath string
+	steps := []selftestStep{
+		{"generate", func() (string, error) {
+			files, total, err := generateSelftestData(source, limit)
+			return fmt.Sprintf("%d files, %s", files, formatHumanSize(total)), err
+		}},
+		{"create", func() (string, error) {
+			var err error
+			archivePath, err = createSelftestArchive(cfg, source)
+			if err != nil {
+				return "", err
+			}
+			info, err := os.Stat(archivePath)
+			if err != nil {
+				return "", err
+			}
+			return fmt.Sprintf("%s (%s)", filepath.Base(archivePath), formatHumanSize(info.Size())), nil
+		}},
+		{"verify", func() (string, error) {
+			status, err := VerifyArchive(archivePath)
+			if err != nil {
+				return "", err
+			}
+			if !status.IsVerified {
+				return "", fmt.Errorf("%s", strings.Join(status.Errors, "; "))
+			}
+			cmp, err := CompareArchiveToDir(archivePath, source, nil)
+			if err != nil {
+				return "", err
+			}
+			if !cmp.Matches() {
+				return "", fmt.Errorf("archive differs from the source: %s", strings.Join(cmp.Details(), "; "))
+			}
+			return fmt.Sprintf("%d entries match the source", cmp.Compared), nil
+		}},
+		{"restore", func() (string, error) {
+			err := RestoreArchive(RestoreOptions{Config: cfg, Output: io.Discard, Archive: archivePath,
+				Target: restored, Conflict: RestoreConflictFail})
+			return "to " + restored, err
+		}},
+		{"compare", func() (string, error) {
+			n, err := compareSelftestTrees(source, restored)
+			return fmt.Sprintf("%d files identical to the source", n), err
+		}},
+	}
+
+	fmt.Fprintf(out, "Self-test in %s\n", sandbox)
+	if opts.ArchiveDir != "" {
+		fmt.Fprintf(out, "Archive storage: %s\n", archiveDir)
+	}
+	for i, step := range steps {
+		start := time.Now()
+		summary, err := step.run()
+		if err != nil {
+			report.fail(step.Name, time.Since(start), err.Error())
+			for _, rest := range steps[i+1:] {
+				report.skip(rest.Name, "not run: "+step.Name+" failed")
+			}
+			fmt.Fprint(out, formatter.StyleStdout(fmt.Sprintf("  ❌ %-9s %v\n", step.Name, err)))
+			return NewArchiveErrorWithCause("Self-test failed at "+step.Name, 1, err)
+		}
+		report.pass(step.Name, time.Since(start))
+		fmt.Fprint(out, formatter.StyleStdout(fmt.Sprintf("  ✅ %-9s %s (%s)\n",
+			step.Name, summary, time.Since(start).Round(time.Millisecond))))
+	}
+	fmt.Fprintln(out, "Self-test passed")
+	return nil
+}
+
+// selftestConfig returns the default configuration with the archive settings
+// of cfg, archiving into archiveDir and trashing into the sandbox.
+func selftestConfig(cfg *Config, archiveDir, sandbox string) *Config {
+	test := DefaultConfig()
+	test.ArchiveDirPath = archiveDir
+	test.UseCurrentDirName = false
+	test.IncludeGitInfo = false
+	test.TrashDirPath = filepath.Join(sandbox, "trash")
+	test.CompressionLevel = cfg.CompressionLevel
+	test.ArchiveJobs = cfg.ArchiveJobs
+	test.MaxMemory = cfg.MaxMemory
+	return test
+}
+
+// generateSelftestData writes about limit bytes of synthetic files below
+// dir: compressible text, random data, an empty and an executable file,
+// nested directories, names with spaces and accents, and on Unix a symbolic
+// link. The data is the same on every run.
+func generateSelftestData(dir string, limit int64) (int, int64, error) {
+	rng := rand.New(rand.NewSource(1))
+	random := make([]byte, limit/2)
+	rng.Read(random)
+	var text bytes.Buffer
+	for i := 0; int64(text.Len()) < limit/4; i++ {
+		fmt.Fprintf(&text, "line %d of the bkpdir self-test\n", i)
+	}
+
+	files := map[string][]byte{
+		"random.bin":                 random,
+		"notes/text.txt":             text.Bytes(),
+		"notes/deeply/nested/a.txt":  text.Bytes()[:text.Len()/2],
+		"notes/deeply/nested/b.txt":  text.Bytes()[text.Len()/2:],
+		"empty.txt":                  nil,
+		"name with spaces.txt":       []byte("spaces\n"),
+		"café.txt":                   []byte("accents\n"),
+		"scripts/run.sh":             []byte("#!/bin/sh\necho selftest\n"),
+		"notes/deeply/nested/c.json": []byte(`{"selftest": true}` + "\n"),
+	}
+	var total int64
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			return 0, 0, err
+		}
+		mode := os.FileMode(0o644)
+		if strings.HasSuffix(name, ".sh") {
+			mode = 0o755
+		}
+		if err := os.WriteFile(path, data, mode); err != nil {
+			return 0, 0, err
+		}
+		if err := os.Chmod(path, mode); err != nil {
+			return 0, 0, err
+		}
+		total += int64(len(data))
+	}
+	count := len(files)
+	if runtime.GOOS != "windows" {
+		if err := os.Symlink("text.txt", filepath.Join(dir, "notes", "link.txt")); err != nil {
+			return 0, 0, err
+		}
+		count++
+	}
+	return count, total, nil
+}
+
+// createSelftestArchive creates a full archive of source with cfg and
+// returns its path. Archives are created from the working directory, which
+// is restored afterwards.
+func createSelftestArchive(cfg *Config, source string) (string, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	if err := os.Chdir(source); err != nil {
+		return "", err
+	}
+	err = CreateFullArchive(cfg, "selftest", false, false)
+	if chdirErr := os.Chdir(cwd); err == nil {
+		err = chdirErr
+	}
+	if err != nil {
+		return "", err
+	}
+	archives, err := listArchiveEntries(cfg.ArchiveDirPath)
+	if err != nil {
+		return "", err
+	}
+	if len(archives) != 1 {
+		return "", fmt.Errorf("expected one archive in %s, found %d", cfg.ArchiveDirPath, len(archives))
+	}
+	return archives[0].Path, nil
+}
+
+// compareSelftestTrees checks that restored holds the files and links of
+// source with the same content, permissions and link targets, and nothing
+// else. It returns the number of files and links compared.
+func compareSelftestTrees(source, restored string) (int, error) {
+	var diffs []string
+	seen := make(map[string]bool)
+	err := filepath.WalkDir(source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, _ := filepath.Rel(source, path)
+		seen[rel] = true
+		if diff := compareSelftestEntry(path, filepath.Join(restored, rel)); diff != "" {
+			diffs = append(diffs, rel+": "+diff)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	err = filepath.WalkDir(restored, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if rel, _ := filepath.Rel(restored, path); !seen[rel] {
+			diffs = append(diffs, rel+": not in the source")
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	if len(diffs) > 0 {
+		return 0, fmt.Errorf("%s", strings.Join(diffs, "; "))
+	}
+	return len(seen), nil
+}
+
+// compareSelftestEntry describes how the restored copy of a file or link
+// differs from the original; empty when it does not.
+func compareSelftestEntry(orig, copy string) string {
+	want, err := os.Lstat(orig)
+	if err != nil {
+		return err.Error()
+	}
+	got, err := os.Lstat(copy)
+	if err != nil {
+		return "missing"
+	}
+	if want.Mode().Type() != got.Mode().Type() {
+		return fmt.Sprintf("restored as %v instead of %v", got.Mode().Type(), want.Mode().Type())
+	}
+	if want.Mode()&os.ModeSymlink != 0 {
+		wantLink, _ := os.Readlink(orig)
+		gotLink, _ := os.Readlink(copy)
+		if wantLink != gotLink {
+			return fmt.Sprintf("links to %s instead of %s", gotLink, wantLink)
+		}
+		return ""
+	}
+	if runtime.GOOS != "windows" && want.Mode().Perm() != got.Mode().Perm() {
+		return fmt.Sprintf("mode %v instead of %v", got.Mode().Perm(), want.Mode().Perm())
+	}
+	wantData, err := os.ReadFile(orig)
+	if err != nil {
+		return err.Error()
+	}
+	gotData, err := os.ReadFile(copy)
+	if err != nil {
+		return err.Error()
+	}
+	if !bytes.Equal(wantData, gotData) {
+		return "content differs"
+	}
+	return ""
+}
@@ -0,0 +1,74 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the selftest command.
+// It verifies a full run and the detection of restore differences.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ SELFTEST-001: End-to-end self-test - 🧪
+func TestRunSelftest(t *testing.T) {
+	storage := t.TempDir()
+	cfg := uploadTestConfig(t, t.TempDir())
+	cfg.ArchiveJobs = "2"
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := RunSelftest(SelftestOptions{Config: cfg, Output: &out, ArchiveDir: storage, Size: "256KB"}); err != nil {
+		t.Fatalf("selftest failed: %v\n%s", err, out.String())
+	}
+	for _, step := range []string{"generate", "create", "verify", "restore", "compare", "Self-test passed"} {
+		if !strings.Contains(out.String(), step) {
+			t.Errorf("output lacks %q:\n%s", step, out.String())
+		}
+	}
+	if entries, _ := os.ReadDir(storage); len(entries) != 0 {
+		t.Errorf("test archive left in %s: %v", storage, entries)
+	}
+	if dir, _ := os.Getwd(); dir != cwd {
+		t.Errorf("working directory changed to %s", dir)
+	}
+
+	if err := RunSelftest(SelftestOptions{Config: cfg, Output: &out, Size: "lots"}); err == nil {
+		t.Error("an invalid size should fail")
+	}
+}
+
+// ⭐ SELFTEST-001: Restored tree comparison - 🧪
+func TestCompareSelftestTrees(t *testing.T) {
+	source := t.TempDir()
+	if _, _, err := generateSelftestData(source, 64<<10); err != nil {
+		t.Fatal(err)
+	}
+	// The data is the same on every run
+	restored := t.TempDir()
+	if _, _, err := generateSelftestData(restored, 64<<10); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := compareSelftestTrees(source, restored); err != nil {
+		t.Fatalf("identical trees differ: %v", err)
+	}
+
+	os.WriteFile(filepath.Join(restored, "empty.txt"), []byte("x"), 0o644)
+	os.Chmod(filepath.Join(restored, "scripts", "run.sh"), 0o644)
+	os.Remove(filepath.Join(restored, "café.txt"))
+	os.WriteFile(filepath.Join(restored, "extra.txt"), nil, 0o644)
+	_, err := compareSelftestTrees(source, restored)
+	if err == nil {
+		t.Fatal("expected differences")
+	}
+	for _, want := range []string{"empty.txt: content differs", "run.sh: mode", "café.txt: missing", "extra.txt: not in the source"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("%q lacks %q", err, want)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+// This file is part of bkpdir
+//
+// Package main provides collision-safe archive and backup names. Names that
+// would repeat within the timestamp granularity get a monotonic sequence
+// suffix after the timestamp, and the chosen name is reserved on disk so
+// concurrent invocations never write to the same file.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bkpdir/pkg/fileops"
+)
+
+// maxNameSequence bounds the number of names tried for one timestamp.
+const maxNameSequence = 9999
+
+// ⭐ ARCH-008: Sequence suffix - 📝
+// sequenceSuffix returns the suffix placed after the timestamp of the seq-th
+// name sharing that timestamp: nothing for the first, then "_1", "_2", ...
+func sequenceSuffix(seq int) string {
+	if seq <= 0 {
+		return ""
+	}
+	return "_" + strconv.Itoa(seq)
+}
+
+// ⭐ ARCH-008: Collision-free archive path - 🔧
+// claimArchivePath returns the path in dir of the first name generated from
+// nameCfg with increasing sequence numbers that is not taken. With reserve
+// set the path is also reserved through its temporary file, which the archive
+// writer then overwrites; dry runs only look.
+func claimArchivePath(dir string, nameCfg ArchiveConfig, reserve bool) (string, error) {
+	return claimUniquePath(func(seq int) string {
+		nameCfg.Sequence = seq
+		return filepath.Join(dir, GenerateArchiveName(nameCfg))
+	}, reserve)
+}
+
+// ⭐ ARCH-008: Collision-free backup path - 🔧
+// claimBackupPath is claimArchivePath for a file backup path produced by
+// generateBackupPath; the suffix goes between the timestamp and the note.
+func claimBackupPath(backupPath, note string, reserve bool) (string, error) {
+	stem := backupPath
+	if note != "" {
+		stem = strings.TrimSuffix(backupPath, "="+note)
+	}
+	return claimUniquePath(func(seq int) string {
+		path := stem + sequenceSuffix(seq)
+		if note != "" {
+			path += "=" + note
+		}
+		return path
+	}, reserve)
+}
+
+// claimUniquePath tries pathFor(0), pathFor(1), ... and returns the first
+// path that neither exists nor is being written.
+func claimUniquePath(pathFor func(seq int) string, reserve bool) (string, error) {
+	for seq := 0; seq <= maxNameSequence; seq++ {
+		path := pathFor(seq)
+		tempPath := path + ".tmp"
+		if !reserve {
+			if !pathExists(path) && !pathExists(tempPath) {
+				return path, nil
+			}
+			continue
+		}
+		ok, err := fileops.ReservePath(path, tempPath)
+		if err != nil {
+			return "", err
+		}
+		if ok {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no unused name left for %s", pathFor(0))
+}
+
+// pathExists reports whether anything exists at path.
+func pathExists(path string) bool {
+	_, err := os.Lstat(path)
+	return err == nil
+}
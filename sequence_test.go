@@ -0,0 +1,88 @@
+// This file is part of bkpdir
+
+// Package main provides tests for collision-safe archive and backup names.
+// It verifies sequence suffixes, their parsing and concurrent reservations.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"testing"
+)
+
+// ⭐ ARCH-008: Concurrent name reservation - 🧪
+func TestClaimArchivePathConcurrent(t *testing.T) {
+	dir := t.TempDir()
+	nameCfg := ArchiveConfig{Prefix: "proj", Timestamp: "2024-03-20-14-30", Note: "wip"}
+
+	const writers = 10
+	var wg sync.WaitGroup
+	paths := make(chan string, writers)
+	for i := 0; i < writers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := claimArchivePath(dir, nameCfg, true)
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			paths <- path
+		}()
+	}
+	wg.Wait()
+	close(paths)
+
+	seen := make(map[string]bool)
+	for path := range paths {
+		if seen[path] {
+			t.Errorf("Path %s was claimed twice", path)
+		}
+		seen[path] = true
+		if _, err := os.Stat(path + ".tmp"); err != nil {
+			t.Errorf("Expected %s to be reserved: %v", path, err)
+		}
+	}
+	for _, name := range []string{"proj-2024-03-20-14-30=wip.zip", "proj-2024-03-20-14-30_9=wip.zip"} {
+		if !seen[filepath.Join(dir, name)] {
+			t.Errorf("Expected %s among the claimed names", name)
+		}
+	}
+
+	// A committed archive keeps its name taken
+	first := filepath.Join(dir, "proj-2024-03-20-14-30=wip.zip")
+	if err := os.Rename(first+".tmp", first); err != nil {
+		t.Fatal(err)
+	}
+	path, err := claimArchivePath(dir, nameCfg, false)
+	if err != nil || filepath.Base(path) != "proj-2024-03-20-14-30_10=wip.zip" {
+		t.Errorf("Expected the next free sequence, got %s (%v)", path, err)
+	}
+}
+
+// ⭐ ARCH-008: Backup sequence placement and parsing - 🧪
+func TestClaimBackupPath(t *testing.T) {
+	dir := t.TempDir()
+	backupPath := filepath.Join(dir, "notes.txt-2024-03-20-14-30=a=b")
+	if err := os.WriteFile(backupPath, []byte("x"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	path, err := claimBackupPath(backupPath, "a=b", true)
+	if err != nil || filepath.Base(path) != "notes.txt-2024-03-20-14-30_1=a=b" {
+		t.Fatalf("claimBackupPath = %s (%v)", path, err)
+	}
+
+	cfg := DefaultConfig()
+	re := regexp.MustCompile(cfg.PatternBackupFilename)
+	match := re.FindStringSubmatch(filepath.Base(path))
+	if match == nil || match[re.SubexpIndex("sequence")] != "1" || match[re.SubexpIndex("note")] != "a=b" {
+		t.Errorf("Unexpected backup pattern match %q", match)
+	}
+	re = regexp.MustCompile(cfg.PatternArchiveFilename)
+	match = re.FindStringSubmatch("proj-2024-03-20-14-30_12=main=abc1234.zip")
+	if match == nil || match[re.SubexpIndex("sequence")] != "12" || match[re.SubexpIndex("minute")] != "30" {
+		t.Errorf("Unexpected archive pattern match %q", match)
+	}
+}
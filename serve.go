@@ -0,0 +1,334 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir serve`, a local HTTP API over the archives of
+// the current directory for integration with dashboards and scripts.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"bkpdir/pkg/cli"
+)
+
+// ⭐ SERVE-001: Serve defaults - 🔧
+const (
+	defaultServeAddr = "127.0.0.1:8089"
+	serveTokenEnv    = "BKPDIR_SERVE_TOKEN"
+	serveAPIPrefix   = "/api/v1/archives"
+)
+
+// archiveServer handles API requests for one archive directory.
+type archiveServer struct {
+	cfg        *Config
+	archiveDir string
+	token      string
+	// createMu serializes archive creation; concurrent runs would race on names.
+	createMu sync.Mutex
+}
+
+// createRequest is the body of POST /api/v1/archives.
+type createRequest struct {
+	Type string `json:"type"`
+	Note string `json:"note"`
+}
+
+// ⭐ SERVE-001: Server construction - 🔧
+// newArchiveServer returns a server for the archives of cfg. An empty token is
+// rejected so the API is never exposed without authentication.
+func newArchiveServer(cfg *Config, archiveDir, token string) (*archiveServer, error) {
+	if token == "" {
+		return nil, fmt.Errorf("an API token is required (set %s or use --token-file)", serveTokenEnv)
+	}
+	return &archiveServer{cfg: cfg, archiveDir: archiveDir, token: token}, nil
+}
+
+// ⭐ SERVE-001: Request routing - 🔧
+// Handler returns the HTTP handler serving the API:
+//
+//	GET  /api/v1/archives                        list archives
+//	POST /api/v1/archives                        create a full or incremental archive
+//	GET  /api/v1/archives/{name}/verification    verification status
+//	GET  /api/v1/archives/{name}/download        archive contents
+func (s *archiveServer) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.authorized(r) {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, "missing or invalid token")
+			return
+		}
+
+		if r.URL.Path == serveAPIPrefix {
+			switch r.Method {
+			case http.MethodGet:
+				s.handleList(w)
+			case http.MethodPost:
+				s.handleCreate(w, r)
+			default:
+				writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+			}
+			return
+		}
+
+		rest := strings.TrimPrefix(r.URL.Path, serveAPIPrefix+"/")
+		parts := strings.Split(rest, "/")
+		if rest == r.URL.Path || len(parts) != 2 {
+			writeAPIError(w, http.StatusNotFound, "not found")
+			return
+		}
+		if r.Method != http.MethodGet {
+			writeAPIError(w, http.StatusMethodNotAllowed, "method not allowed")
+			return
+		}
+		archive, ok := s.findArchive(parts[0])
+		if !ok {
+			writeAPIError(w, http.StatusNotFound, fmt.Sprintf("archive %q not found", parts[0]))
+			return
+		}
+		switch parts[1] {
+		case "verification":
+			writeAPIJSON(w, http.StatusOK, NewArchiveReport(archive))
+		case "download":
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", archive.Name))
+			http.ServeFile(w, r, archive.Path)
+		default:
+			writeAPIError(w, http.StatusNotFound, "not found")
+		}
+	})
+}
+
+// ⭐ SERVE-001: Token authentication - 🛡️
+// authorized checks the bearer token in constant time.
+func (s *archiveServer) authorized(r *http.Request) bool {
+	const prefix = "Bearer "
+	header := r.Header.Get("Authorization")
+	if !strings.HasPrefix(header, prefix) {
+		return false
+	}
+	given := strings.TrimPrefix(header, prefix)
+	return subtle.ConstantTimeCompare([]byte(given), []byte(s.token)) == 1
+}
+
+// archives returns the archives with metadata, most recent first.
+func (s *archiveServer) archives() ([]Archive, error) {
+	archives, err := ListArchives(s.archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].CreationTime.After(archives[j].CreationTime)
+	})
+	return archives, nil
+}
+
+// findArchive looks up an archive by name. Only names that appear in the
+// listing are accepted, so a request cannot reach other files.
+func (s *archiveServer) findArchive(name string) (Archive, bool) {
+	if name == "" || name != filepath.Base(name) {
+		return Archive{}, false
+	}
+	archives, err := s.archives()
+	if err != nil {
+		return Archive{}, false
+	}
+	for _, a := range archives {
+		if a.Name == name {
+			return a, true
+		}
+	}
+	return Archive{}, false
+}
+
+func (s *archiveServer) handleList(w http.ResponseWriter) {
+	archives, err := s.archives()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, NewArchiveListReport(s.archiveDir, archives))
+}
+
+// ⭐ SERVE-001: Archive creation endpoint - 🔧
+// handleCreate runs a full or incremental archive of the served directory and
+// reports the archive it produced.
+func (s *archiveServer) handleCreate(w http.ResponseWriter, r *http.Request) {
+	var req createRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("invalid request body: %v", err))
+		return
+	}
+	if req.Type == "" {
+		req.Type = "full"
+	}
+
+	var create func(ctx context.Context, cfg *Config, note string, dryRun bool, verify bool) error
+	switch req.Type {
+	case "full":
+		create = CreateFullArchiveWithContext
+	case "incremental":
+		create = CreateIncrementalArchiveWithContext
+	default:
+		writeAPIError(w, http.StatusBadRequest, fmt.Sprintf("unknown archive type %q (use full or incremental)", req.Type))
+		return
+	}
+
+	s.createMu.Lock()
+	defer s.createMu.Unlock()
+
+	before, err := s.archives()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+	if err := create(r.Context(), s.cfg, req.Note, false, false); err != nil {
+		status := http.StatusInternalServerError
+		var archiveErr *ArchiveError
+		if errors.As(err, &archiveErr) && archiveErr.StatusCode == s.cfg.StatusConfigError {
+			status = http.StatusBadRequest
+		}
+		writeAPIError(w, status, err.Error())
+		return
+	}
+	after, err := s.archives()
+	if err != nil {
+		writeAPIError(w, http.StatusInternalServerError, err.Error())
+		return
+	}
+
+	report := CreateReport{Type: req.Type}
+	if created, ok := newestNewArchive(before, after); ok {
+		ar := NewArchiveReport(created)
+		report.Created = true
+		report.Archive = &ar
+		writeAPIJSON(w, http.StatusCreated, report)
+		return
+	}
+	writeAPIJSON(w, http.StatusOK, report)
+}
+
+// newestNewArchive returns the most recent archive in after that is not in
+// before. Both listings are sorted most recent first.
+func newestNewArchive(before, after []Archive) (Archive, bool) {
+	seen := make(map[string]bool, len(before))
+	for _, a := range before {
+		seen[a.Name] = true
+	}
+	for _, a := range after {
+		if !seen[a.Name] {
+			return a, true
+		}
+	}
+	return Archive{}, false
+}
+
+func writeAPIJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = writeJSONReport(w, v)
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeAPIJSON(w, status, ErrorReport{Error: message})
+}
+
+// readServeToken returns the token from tokenFile, or from the environment
+// when no file is given.
+func readServeToken(tokenFile string) (string, error) {
+	if tokenFile == "" {
+		return os.Getenv(serveTokenEnv), nil
+	}
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read token file: %w", err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+// serveCmdOptions holds the flags of serve.
+type serveCmdOptions struct {
+	Addr      string
+	TokenFile string
+}
+
+// ⭐ SERVE-001: Serve command - 🔧
+func serveCmd() *cobra.Command {
+	var flags *cli.FlagBinding[serveCmdOptions]
+	cmd := &cobra.Command{
+		Use:   "serve",
+		Short: "Serve a local HTTP API for archive operations",
+		Long: `Serve a local HTTP API over the archives of the current directory.
+
+Every request must send "Authorization: Bearer TOKEN". The token is read from
+--token-file or from $BKPDIR_SERVE_TOKEN; the server refuses to start without one.
+
+Endpoints (responses use the same JSON documents as 'list --output json'):
+  GET  /api/v1/archives                       list archives
+  POST /api/v1/archives                       create; body {"type":"full|incremental","note":"..."}
+  GET  /api/v1/archives/NAME/verification     verification status
+  GET  /api/v1/archives/NAME/download         download the archive`,
+		Example: `  BKPDIR_SERVE_TOKEN=secret bkpdir serve --addr 127.0.0.1:8089
+  curl -H "Authorization: Bearer secret" http://127.0.0.1:8089/api/v1/archives`,
+		Args: cobra.NoArgs,
+		Run: func(cmd *cobra.Command, _ []string) {
+			opts := commandOptions(flags, cmd)
+			handleServeCommand(opts.Addr, opts.TokenFile)
+		},
+	}
+	flags = cli.BindFlags(cmd, serveCmdOptions{Addr: defaultServeAddr}).
+		String(func(o *serveCmdOptions) *string { return &o.Addr }, "addr", "", "Address to listen on").
+		String(func(o *serveCmdOptions) *string { return &o.TokenFile }, "token-file", "",
+			"Read the API token from FILE instead of $"+serveTokenEnv)
+	return cmd
+}
+
+func handleServeCommand(addr, tokenFile string) {
+	cwd, _ := os.Getwd()
+	cfg, err := LoadConfig(cwd)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error loading config: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cfg.StatusDirectoryNotFound)
+	}
+	token, err := readServeToken(tokenFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+	server, err := newArchiveServer(cfg, archiveDir, token)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(cfg.StatusConfigError)
+	}
+
+	httpServer := &http.Server{
+		Addr:              addr,
+		Handler:           server.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	fmt.Fprintf(os.Stderr, "Serving archives of %s on http://%s%s\n", cwd, addr, serveAPIPrefix)
+	if err := httpServer.ListenAndServe(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
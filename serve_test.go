@@ -0,0 +1,106 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the serve command's HTTP API.
+// It verifies token authentication, listing, status and download endpoints.
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ⭐ SERVE-001: HTTP API tests - 🔧
+func TestArchiveServer(t *testing.T) {
+	archiveDir := t.TempDir()
+	name := "proj-2024-01-01-10-00.zip"
+	if err := os.WriteFile(filepath.Join(archiveDir, name), []byte("zip-data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(filepath.Dir(archiveDir), "secret.zip"), []byte("x"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := newArchiveServer(DefaultConfig(), archiveDir, ""); err == nil {
+		t.Fatal("Expected an error for an empty token")
+	}
+	server, err := newArchiveServer(DefaultConfig(), archiveDir, "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := server.Handler()
+
+	do := func(method, path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	t.Run("Unauthorized", func(t *testing.T) {
+		for _, token := range []string{"", "wrong"} {
+			if rec := do(http.MethodGet, serveAPIPrefix, token); rec.Code != http.StatusUnauthorized {
+				t.Errorf("token %q: status = %d, want 401", token, rec.Code)
+			}
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		rec := do(http.MethodGet, serveAPIPrefix, "secret")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+		var report ArchiveListReport
+		if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+			t.Fatal(err)
+		}
+		if len(report.Archives) != 1 || report.Archives[0].Name != name {
+			t.Fatalf("Archives = %+v", report.Archives)
+		}
+		if report.Archives[0].Status != ArchiveStatusUnverified {
+			t.Errorf("Status = %q, want %q", report.Archives[0].Status, ArchiveStatusUnverified)
+		}
+	})
+
+	t.Run("Verification", func(t *testing.T) {
+		rec := do(http.MethodGet, serveAPIPrefix+"/"+name+"/verification", "secret")
+		if rec.Code != http.StatusOK {
+			t.Fatalf("status = %d, body %s", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("Download", func(t *testing.T) {
+		rec := do(http.MethodGet, serveAPIPrefix+"/"+name+"/download", "secret")
+		if rec.Code != http.StatusOK || rec.Body.String() != "zip-data" {
+			t.Fatalf("status = %d, body %q", rec.Code, rec.Body)
+		}
+	})
+
+	t.Run("UnknownArchive", func(t *testing.T) {
+		for _, path := range []string{
+			serveAPIPrefix + "/missing.zip/download",
+			serveAPIPrefix + "/..%2Fsecret.zip/download",
+			serveAPIPrefix + "/" + name + "/other",
+		} {
+			if rec := do(http.MethodGet, path, "secret"); rec.Code != http.StatusNotFound {
+				t.Errorf("%s: status = %d, want 404", path, rec.Code)
+			}
+		}
+	})
+
+	t.Run("InvalidCreate", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, serveAPIPrefix, nil)
+		req.Header.Set("Authorization", "Bearer secret")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("status = %d, want 400", rec.Code)
+		}
+	})
+}
@@ -0,0 +1,220 @@
+// This file is part of bkpdir
+//
+// Package main provides the settings for archive directories shared by
+// several users, such as a team NAS: a per-user namespace for archives, the
+// permissions of created archives, and listing archives by owner.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ SHARED-001: User namespaces - 🔧
+const (
+	// UserNamespaceNone stores the archives of all users side by side.
+	UserNamespaceNone = "none"
+	// UserNamespaceSubdir stores the archives of each user below
+	// archive_dir_path/USER.
+	UserNamespaceSubdir = "subdir"
+	// UserNamespacePrefix starts the archive names of each user with "USER-".
+	UserNamespacePrefix = "prefix"
+)
+
+// ⭐ SHARED-001: Shared archive directory configuration - 🔧
+// SharedConfig holds the settings of an archive directory used by several
+// users.
+type SharedConfig struct {
+	UserNamespace string `yaml:"user_namespace"` // none, subdir or prefix
+	Umask         string `yaml:"umask"`          // Octal mask for created files, e.g. "007"; empty keeps the process umask
+}
+
+// DefaultSharedConfig returns the settings of an unshared archive directory.
+func DefaultSharedConfig() *SharedConfig {
+	return &SharedConfig{UserNamespace: UserNamespaceNone}
+}
+
+// sharedSettings returns the shared section of cfg or the defaults.
+func sharedSettings(cfg *Config) *SharedConfig {
+	if cfg == nil || cfg.Shared == nil {
+		return DefaultSharedConfig()
+	}
+	return cfg.Shared
+}
+
+// archiveUser returns the name archives are namespaced by: $USER, or the
+// account name when it is not set.
+func archiveUser() string {
+	if name := os.Getenv("USER"); name != "" {
+		return name
+	}
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return "unknown"
+}
+
+// ⭐ SHARED-001: Namespaced archive root - 🔍
+// archiveRootDir returns the directory the archives of owner are stored
+// below: archive_dir_path, or archive_dir_path/OWNER with the subdir
+// namespace. The name of the archived directory is added to it when
+// use_current_dir_name is set.
+func archiveRootDir(cfg *Config, owner string) string {
+	root := cfg.ArchiveDirPath
+	if sharedSettings(cfg).UserNamespace != UserNamespaceSubdir {
+		return root
+	}
+	if isRemoteLocation(root) {
+		return strings.TrimSuffix(root, "/") + "/" + owner
+	}
+	return filepath.Join(root, owner)
+}
+
+// archiveNamePrefix returns the prefix of the archive names of cwd: the
+// directory name, preceded by the user with the prefix namespace.
+func archiveNamePrefix(shared *SharedConfig, cwd string) string {
+	if shared != nil && shared.UserNamespace == UserNamespacePrefix {
+		return archiveUser() + "-" + filepath.Base(cwd)
+	}
+	return filepath.Base(cwd)
+}
+
+// validateSharedConfig checks the namespace and umask settings.
+func validateSharedConfig(cfg *Config) error {
+	shared := sharedSettings(cfg)
+	switch shared.UserNamespace {
+	case "", UserNamespaceNone, UserNamespaceSubdir, UserNamespacePrefix:
+	default:
+		return NewArchiveError(fmt.Sprintf("Invalid shared.user_namespace %q (use none, subdir or prefix)", shared.UserNamespace), cfg.StatusConfigError)
+	}
+	if _, err := sharedUmask(shared); err != nil {
+		return NewArchiveErrorWithCause("Invalid shared.umask", cfg.StatusConfigError, err)
+	}
+	return nil
+}
+
+// sharedUmask parses the configured umask; -1 means none is configured.
+func sharedUmask(shared *SharedConfig) (int, error) {
+	if shared.Umask == "" {
+		return -1, nil
+	}
+	mask, err := strconv.ParseUint(shared.Umask, 8, 32)
+	if err != nil || mask > 0o777 {
+		return -1, fmt.Errorf("%q is not an octal mask such as 002 or 007", shared.Umask)
+	}
+	return int(mask), nil
+}
+
+// ⭐ SHARED-001: Permission-aware creation - 🛡️
+// applySharedUmask sets the configured umask for everything the command
+// creates.
+func applySharedUmask(cfg *Config) error {
+	if err := validateSharedConfig(cfg); err != nil {
+		return err
+	}
+	mask, _ := sharedUmask(sharedSettings(cfg))
+	if mask < 0 {
+		return nil
+	}
+	if err := setUmask(mask); err != nil {
+		return NewArchiveErrorWithCause("Cannot apply shared.umask", cfg.StatusConfigError, err)
+	}
+	return nil
+}
+
+// ⭐ SHARED-001: Shared modes of new archives - 🛡️
+// applySharedModes gives a new archive, its sidecars and the directories
+// below archive_dir_path leading to it the modes allowed by shared.umask.
+// Archives are written with fixed modes such as 0644, which a umask can only
+// narrow, so a mask of 002 needs this to make them group-writable. Paths
+// owned by other users are left alone.
+func applySharedModes(cfg *Config, archivePath string) {
+	mask, err := sharedUmask(sharedSettings(cfg))
+	if err != nil || mask < 0 {
+		return
+	}
+	fileMode, dirMode := os.FileMode(0o666&^mask), os.FileMode(0o777&^mask)
+	chmod := func(path string, mode os.FileMode) {
+		if info, err := os.Stat(path); err == nil {
+			if owner, ok := fileOwner(info); ok && owner != archiveUserAccount() {
+				return
+			}
+			fileops.Chmod(path, mode)
+		}
+	}
+
+	chmod(archivePath, fileMode)
+	archiveDir := filepath.Dir(archivePath)
+	sidecars, _ := filepath.Glob(filepath.Join(archiveDir, ".metadata", filepath.Base(archivePath)+"*"))
+	for _, path := range sidecars {
+		chmod(path, fileMode)
+	}
+	chmod(filepath.Join(archiveDir, ".metadata"), dirMode)
+
+	root, err := filepath.Abs(cfg.ArchiveDirPath)
+	if err != nil {
+		return
+	}
+	dir, err := filepath.Abs(archiveDir)
+	for err == nil && strings.HasPrefix(dir, root+string(filepath.Separator)) {
+		chmod(dir, dirMode)
+		dir = filepath.Dir(dir)
+	}
+}
+
+// archiveUserAccount returns the account name of the current user, which
+// owns the files it creates.
+func archiveUserAccount() string {
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+	return ""
+}
+
+// ⭐ SHARED-001: Listing by owner - 🔍
+// filterArchivesByOwner keeps the archives of owner: those whose names start
+// with "OWNER-" with the prefix namespace, and those whose file belongs to
+// owner otherwise. With the subdir namespace the listing already only holds
+// the archives of one user.
+func filterArchivesByOwner(cfg *Config, archives []Archive, owner string) ([]Archive, error) {
+	shared := sharedSettings(cfg)
+	var kept []Archive
+	switch shared.UserNamespace {
+	case UserNamespaceSubdir:
+		return archives, nil
+	case UserNamespacePrefix:
+		for _, a := range archives {
+			if strings.HasPrefix(a.Name, owner+"-") {
+				kept = append(kept, a)
+			}
+		}
+		return kept, nil
+	}
+	if isRemoteLocation(cfg.ArchiveDirPath) {
+		return nil, NewArchiveError("--owner of remote archives needs shared.user_namespace subdir or prefix", cfg.StatusConfigError)
+	}
+	for _, a := range archives {
+		info, err := os.Stat(a.Path)
+		if err != nil {
+			continue
+		}
+		name, ok := fileOwner(info)
+		if !ok {
+			return nil, NewArchiveError("--owner needs shared.user_namespace subdir or prefix on this system", cfg.StatusConfigError)
+		}
+		if name == owner {
+			kept = append(kept, a)
+		}
+	}
+	return kept, nil
+}
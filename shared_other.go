@@ -0,0 +1,36 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+// This file is part of bkpdir
+//
+// Package main provides the umask and file owner lookups of shared archive
+// directories on systems without Unix file ownership.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"errors"
+	"os"
+)
+
+// setUmask fails: there is no process umask on this system.
+func setUmask(int) error {
+	return errors.New("shared.umask is only supported on Unix systems")
+}
+
+// fileOwner reports that file owners are unknown on this system.
+func fileOwner(os.FileInfo) (string, bool) {
+	return "", false
+}
+
+// fileOwnerID reports that file owners are unknown on this system.
+func fileOwnerID(os.FileInfo) (int, bool) {
+	return 0, false
+}
+
+// fileGroupID reports that file groups are unknown on this system.
+func fileGroupID(os.FileInfo) (int, bool) {
+	return 0, false
+}
@@ -0,0 +1,105 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive directories shared by several
+// users. It verifies the per-user namespaces, listing by owner and the modes
+// of archives created with shared.umask.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ⭐ SHARED-001: Per-user namespaces - 🧪
+func TestSharedUserNamespaces(t *testing.T) {
+	t.Setenv("USER", "alice")
+
+	t.Run("subdir", func(t *testing.T) {
+		archiveDir := t.TempDir()
+		cfg := uploadTestConfig(t, archiveDir)
+		cfg.Shared = &SharedConfig{UserNamespace: UserNamespaceSubdir}
+		if err := os.WriteFile("notes.txt", []byte("alice's notes"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := CreateFullArchive(cfg, "", false, false); err != nil {
+			t.Fatal(err)
+		}
+		own, err := getArchiveDirectory(cfg)
+		if err != nil || own != filepath.Join(archiveDir, "alice") {
+			t.Fatalf("archive directory = %q, %v", own, err)
+		}
+		if archives, _ := listArchiveEntries(own); len(archives) != 1 {
+			t.Errorf("archives of alice = %+v", archives)
+		}
+		if got := archiveRootDir(cfg, "bob"); got != filepath.Join(archiveDir, "bob") {
+			t.Errorf("root of bob = %q", got)
+		}
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		archiveDir := t.TempDir()
+		cfg := uploadTestConfig(t, archiveDir)
+		cfg.Shared = &SharedConfig{UserNamespace: UserNamespacePrefix}
+		if err := os.WriteFile("notes.txt", []byte("alice's notes"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := CreateFullArchive(cfg, "", false, false); err != nil {
+			t.Fatal(err)
+		}
+		archives, err := listArchiveEntries(archiveDir)
+		if err != nil || len(archives) != 1 || !strings.HasPrefix(archives[0].Name, "alice-") {
+			t.Fatalf("archives = %+v, %v", archives, err)
+		}
+		archives = append(archives, Archive{Name: "bob-project-2024-03-20-14-30.zip"})
+		kept, err := filterArchivesByOwner(cfg, archives, "bob")
+		if err != nil || len(kept) != 1 || kept[0].Name != "bob-project-2024-03-20-14-30.zip" {
+			t.Errorf("archives of bob = %+v, %v", kept, err)
+		}
+	})
+
+	cfg := DefaultConfig()
+	cfg.Shared = &SharedConfig{UserNamespace: "per-user"}
+	if err := validateSharedConfig(cfg); err == nil {
+		t.Error("an unknown namespace should be rejected")
+	}
+}
+
+// ⭐ SHARED-001: Listing by file owner and shared modes - 🧪
+func TestSharedOwnersAndModes(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file owners and modes are Unix only")
+	}
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.Shared = &SharedConfig{Umask: "002"}
+	if err := os.WriteFile("notes.txt", []byte("team notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil || len(archives) != 1 {
+		t.Fatalf("archives = %+v, %v", archives, err)
+	}
+	info, err := os.Stat(archives[0].Path)
+	if err != nil || info.Mode().Perm() != 0o664 {
+		t.Errorf("archive mode = %v, %v; want 0664", info.Mode().Perm(), err)
+	}
+
+	kept, err := filterArchivesByOwner(cfg, archives, archiveUserAccount())
+	if err != nil || len(kept) != 1 {
+		t.Errorf("archives of the current user = %+v, %v", kept, err)
+	}
+	if kept, _ := filterArchivesByOwner(cfg, archives, "nobody-else"); len(kept) != 0 {
+		t.Errorf("archives of another user = %+v", kept)
+	}
+
+	cfg.Shared.Umask = "9"
+	if err := validateSharedConfig(cfg); err == nil {
+		t.Error("a non-octal umask should be rejected")
+	}
+}
@@ -0,0 +1,60 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+// This file is part of bkpdir
+//
+// Package main provides the umask and file owner lookups of shared archive
+// directories on Unix systems.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"os/user"
+	"strconv"
+	"syscall"
+)
+
+// ⭐ SHARED-001: Process umask - 🔧
+// setUmask replaces the file mode creation mask of the process.
+func setUmask(mask int) error {
+	syscall.Umask(mask)
+	return nil
+}
+
+// ⭐ SHARED-001: Archive owners - 🔍
+// fileOwner returns the name of the user owning info, or its uid when the
+// user is unknown.
+func fileOwner(info os.FileInfo) (string, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return "", false
+	}
+	uid := strconv.FormatUint(uint64(st.Uid), 10)
+	if u, err := user.LookupId(uid); err == nil {
+		return u.Username, true
+	}
+	return uid, true
+}
+
+// ⭐ SUDO-OWNER-001: Numeric file owners - 🔍
+// fileOwnerID returns the uid owning info.
+func fileOwnerID(info os.FileInfo) (int, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(st.Uid), true
+}
+
+// ⭐ META-BACKUP-001: Numeric file groups - 🔍
+// fileGroupID returns the gid owning info.
+func fileGroupID(info os.FileInfo) (int, bool) {
+	st, ok := info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0, false
+	}
+	return int(st.Gid), true
+}
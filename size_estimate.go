@@ -0,0 +1,265 @@
+// This file is part of bkpdir
+//
+// Package main provides size estimates of archive runs. Dry runs and
+// `bkpdir explain create` scan the directory through a cache of directory
+// listings kept under the user cache directory: a directory whose
+// fingerprint, its modification time and size, is unchanged is not read
+// again, so repeated estimates of an unchanged tree only stat directories.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+const (
+	// sizeCacheMaxAge is how long a cached directory listing is used. Files
+	// changed in place leave the fingerprint of their directory alone, so
+	// their sizes are refreshed when the listing expires.
+	sizeCacheMaxAge = time.Hour
+	// sizeCacheRacyWindow keeps directories modified this recently out of
+	// the cache, as a change in the same clock tick would go unnoticed.
+	sizeCacheRacyWindow = 2 * time.Second
+	// sizeEstimateTopTypes is the number of file types an estimate lists.
+	sizeEstimateTopTypes = 5
+)
+
+// SizeEstimate is the uncompressed size and composition of the files an
+// archive run would include.
+type SizeEstimate struct {
+	Files       int
+	Bytes       int64
+	Types       []FileTypeStats // By extension, largest first
+	Directories int             // Directories scanned
+	Cached      int             // Directories listed from the cache
+}
+
+// CachedScan is the result of a scan through the size estimate cache.
+type CachedScan struct {
+	Files       []string
+	Sizes       map[string]int64 // By entry in Files
+	Directories int
+	Cached      int
+}
+
+// FileTypeStats is the number and size of the files with one extension.
+type FileTypeStats struct {
+	Ext   string // Lowercase with the dot; empty for files without one
+	Files int
+	Bytes int64
+}
+
+// sizeCache holds the directory listings of one scanned tree by their slash
+// path relative to its root, "." for the root.
+type sizeCache struct {
+	Root string                   `json:"root"`
+	Dirs map[string]*sizeCacheDir `json:"dirs"`
+}
+
+// sizeCacheDir is the listing of a directory with its fingerprint.
+type sizeCacheDir struct {
+	ModTime int64            `json:"mod_time"` // Unix nanoseconds
+	Size    int64            `json:"size"`
+	Scanned time.Time        `json:"scanned"`
+	Entries []sizeCacheEntry `json:"entries"`
+}
+
+// sizeCacheEntry is a directory entry: a subdirectory or a file with its size.
+type sizeCacheEntry struct {
+	Name string `json:"name"`
+	Dir  bool   `json:"dir,omitempty"`
+	Size int64  `json:"size,omitempty"`
+}
+
+// sizeCachePath returns the cache file of the tree rooted at root.
+func sizeCachePath(root string) (string, error) {
+	dir, err := CacheDir()
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256([]byte(root))
+	return filepath.Join(dir, "size-estimates", hex.EncodeToString(sum[:8])+".json"), nil
+}
+
+// sizeScan is one scan of a tree through the cache.
+type sizeScan struct {
+	ctx     context.Context
+	matcher *fileops.PatternMatcher
+	now     time.Time
+	old     map[string]*sizeCacheDir
+	new     map[string]*sizeCacheDir
+	result  CachedScan
+}
+
+// ⭐ SIZE-CACHE-001: Cached archive scan - 🔍
+// scanArchiveFilesCached returns the files of dir not matching
+// excludePatterns, in the order collectFilesToArchive finds them, with their
+// sizes. Listings of directories with an unchanged fingerprint come from the
+// cache, which is updated afterwards unless in read-only mode; a cache that
+// cannot be read or written only costs a full scan.
+func scanArchiveFilesCached(ctx context.Context, dir string, excludePatterns []string) (*CachedScan, error) {
+	root, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+	s := &sizeScan{
+		ctx:     ctx,
+		matcher: fileops.NewPatternMatcher(excludePatterns),
+		now:     time.Now(),
+		new:     make(map[string]*sizeCacheDir),
+		result:  CachedScan{Sizes: make(map[string]int64)},
+	}
+	cachePath, cacheErr := sizeCachePath(root)
+	if cacheErr == nil {
+		var cache sizeCache
+		if data, err := os.ReadFile(cachePath); err == nil && json.Unmarshal(data, &cache) == nil && cache.Root == root {
+			s.old = cache.Dirs
+		}
+	}
+
+	if err := s.walk(".", root); err != nil {
+		return nil, err
+	}
+
+	if cacheErr == nil && !readOnly {
+		data, err := json.Marshal(&sizeCache{Root: root, Dirs: s.new})
+		if err == nil {
+			if err = fileops.MkdirAll(filepath.Dir(cachePath), 0o755); err == nil {
+				err = fileops.AtomicWriteFile(cachePath, data, 0o600)
+			}
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: cannot save the size estimate cache: %v\n", err)
+		}
+	}
+	return &s.result, nil
+}
+
+// Estimate returns the size and composition of files, the entries of the
+// scan an archive keeps.
+func (c *CachedScan) Estimate(files []string) *SizeEstimate {
+	e := &SizeEstimate{Files: len(files), Directories: c.Directories, Cached: c.Cached}
+	types := make(map[string]*FileTypeStats)
+	for _, f := range files {
+		size := c.Sizes[f]
+		e.Bytes += size
+		ext := strings.ToLower(filepath.Ext(f))
+		t := types[ext]
+		if t == nil {
+			t = &FileTypeStats{Ext: ext}
+			types[ext] = t
+		}
+		t.Files++
+		t.Bytes += size
+	}
+	for _, t := range types {
+		e.Types = append(e.Types, *t)
+	}
+	sort.Slice(e.Types, func(i, j int) bool {
+		a, b := e.Types[i], e.Types[j]
+		if a.Bytes != b.Bytes {
+			return a.Bytes > b.Bytes
+		}
+		return a.Ext < b.Ext
+	})
+	return e
+}
+
+// walk scans the directory at abs, rel from the root, and its subdirectories
+// in lexical order. Symbolic links are not followed.
+func (s *sizeScan) walk(rel, abs string) error {
+	if err := checkContextCancellation(s.ctx); err != nil {
+		return err
+	}
+	info, err := os.Lstat(abs)
+	if err != nil {
+		return err
+	}
+	listing := s.old[rel]
+	if listing != nil && listing.ModTime == info.ModTime().UnixNano() && listing.Size == info.Size() &&
+		s.now.Sub(listing.Scanned) < sizeCacheMaxAge {
+		s.result.Cached++
+	} else {
+		if listing, err = listDirectory(abs, info, s.now); err != nil {
+			return err
+		}
+	}
+	s.result.Directories++
+	if s.now.Sub(info.ModTime()) >= sizeCacheRacyWindow {
+		s.new[rel] = listing
+	}
+
+	for _, e := range listing.Entries {
+		entryRel := path.Join(rel, e.Name)
+		if e.Dir {
+			if err := s.walk(entryRel, filepath.Join(abs, e.Name)); err != nil {
+				return err
+			}
+			continue
+		}
+		if s.matcher.ShouldExclude(entryRel) {
+			continue
+		}
+		file := filepath.FromSlash(entryRel)
+		s.result.Files = append(s.result.Files, file)
+		s.result.Sizes[file] = e.Size
+	}
+	return nil
+}
+
+// listDirectory reads the directory at abs, whose information is info.
+func listDirectory(abs string, info os.FileInfo, now time.Time) (*sizeCacheDir, error) {
+	entries, err := os.ReadDir(abs)
+	if err != nil {
+		return nil, err
+	}
+	listing := &sizeCacheDir{ModTime: info.ModTime().UnixNano(), Size: info.Size(), Scanned: now}
+	for _, e := range entries {
+		entry := sizeCacheEntry{Name: e.Name(), Dir: e.IsDir()}
+		if !entry.Dir {
+			if fi, err := e.Info(); err == nil {
+				entry.Size = fi.Size()
+			}
+		}
+		listing.Entries = append(listing.Entries, entry)
+	}
+	return listing, nil
+}
+
+// ⭐ SIZE-CACHE-001: Size estimate output - 📝
+// printSizeEstimate writes the estimated size of an archive run and its
+// largest file types.
+func printSizeEstimate(w io.Writer, e *SizeEstimate) {
+	dirs := "directories"
+	if e.Directories == 1 {
+		dirs = "directory"
+	}
+	fmt.Fprintf(w, "Estimated size: %s in %d %s before compression (%d of %d %s from the cache)\n",
+		formatHumanSize(e.Bytes), e.Files, pluralFiles(e.Files), e.Cached, e.Directories, dirs)
+	for i, t := range e.Types {
+		if i == sizeEstimateTopTypes {
+			break
+		}
+		ext := t.Ext
+		if ext == "" {
+			ext = "(no extension)"
+		}
+		fmt.Fprintf(w, "  %-14s %8s  %d %s\n", ext, formatHumanSize(t.Bytes), t.Files, pluralFiles(t.Files))
+	}
+}
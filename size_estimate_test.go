@@ -0,0 +1,109 @@
+// This file is part of bkpdir
+
+// Package main provides tests for cached size estimates.
+// It verifies the cached scan matches the archive scan and reuses listings.
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ SIZE-CACHE-001: Cached scan tests - 🧪
+func TestScanArchiveFilesCached(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+	for name, size := range map[string]int{
+		"main.go":         100,
+		"README":          10,
+		"docs/guide.md":   300,
+		"docs/img/a.png":  2000,
+		"docs/img/b.PNG":  1000,
+		"logs/debug.log":  50,
+		"src/util/x.go":   200,
+		"src/util/y_test": 1,
+	} {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, bytes.Repeat([]byte("x"), size), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Directories modified just now are not cached
+	past := time.Now().Add(-time.Minute)
+	age := func() {
+		t.Helper()
+		if err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+			if err == nil && d.IsDir() {
+				err = os.Chtimes(path, past, past)
+			}
+			return err
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	age()
+	patterns := []string{"logs/"}
+
+	want, err := collectFilesToArchive(context.Background(), dir, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scan, err := scanArchiveFilesCached(context.Background(), dir, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Join(scan.Files, ",") != strings.Join(want, ",") {
+		t.Errorf("files = %v, want %v", scan.Files, want)
+	}
+	if scan.Directories != 6 || scan.Cached != 0 {
+		t.Errorf("first scan: %d directories, %d cached", scan.Directories, scan.Cached)
+	}
+
+	estimate := scan.Estimate(scan.Files)
+	if estimate.Files != 7 || estimate.Bytes != 3611 {
+		t.Errorf("estimate = %d files, %d bytes", estimate.Files, estimate.Bytes)
+	}
+	if len(estimate.Types) != 4 || estimate.Types[0] != (FileTypeStats{Ext: ".png", Files: 2, Bytes: 3000}) ||
+		estimate.Types[3] != (FileTypeStats{Ext: "", Files: 2, Bytes: 11}) {
+		t.Errorf("types = %+v", estimate.Types)
+	}
+	var out bytes.Buffer
+	printSizeEstimate(&out, estimate)
+	if !strings.HasPrefix(out.String(), "Estimated size: 3.5KB in 7 files before compression (0 of 6 directories from the cache)\n") ||
+		!strings.Contains(out.String(), "(no extension)") {
+		t.Errorf("output:\n%s", out.String())
+	}
+
+	// An unchanged tree is listed from the cache
+	scan, err = scanArchiveFilesCached(context.Background(), dir, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.Cached != 6 || len(scan.Files) != 7 {
+		t.Errorf("second scan: %d cached, %d files", scan.Cached, len(scan.Files))
+	}
+
+	// Only the changed directory is read again
+	if err := os.WriteFile(filepath.Join(dir, "src", "util", "z.go"), []byte("z"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	changed := past.Add(time.Second)
+	if err := os.Chtimes(filepath.Join(dir, "src", "util"), changed, changed); err != nil {
+		t.Fatal(err)
+	}
+	scan, err = scanArchiveFilesCached(context.Background(), dir, patterns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if scan.Cached != 5 || len(scan.Files) != 8 || scan.Sizes[filepath.Join("src", "util", "z.go")] != 1 {
+		t.Errorf("after a change: %d cached, files %v", scan.Cached, scan.Files)
+	}
+}
@@ -0,0 +1,197 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir full --split-by-dir`, which creates one full
+// archive per immediate subdirectory of the current directory, such as one
+// per project in ~/src, all sharing a run ID recorded in their manifests.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/formatter"
+)
+
+// splitRunKey is the context key of the run ID of a split run.
+type splitRunKey struct{}
+
+// splitRunID returns the run ID ctx carries, or "" outside a split run.
+func splitRunID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(splitRunKey{}).(string)
+	return id
+}
+
+// splitResult is the outcome of archiving one subdirectory.
+type splitResult struct {
+	Dir     string
+	Archive string
+	Size    int64
+	Elapsed time.Duration
+	Err     error
+}
+
+// splitSummary is the outcome of a split run.
+type splitSummary struct {
+	RunID   string
+	Dir     string
+	Results []splitResult
+	// LooseFiles counts files directly in Dir, which no archive holds
+	LooseFiles int
+}
+
+// ⭐ SPLIT-001: One archive per subdirectory - 🔧
+// CreateSplitArchives creates a full archive of each immediate subdirectory
+// of the current directory, in name order, and prints a combined summary to
+// out. Subdirectories matching exclude_patterns and those holding the
+// archive directory are skipped, and a failing subdirectory does not stop
+// the others. Relative archive and trash directories are resolved against
+// the current directory, so every archive lands where a full archive of the
+// current directory would. The error names the failed subdirectories and
+// has the status of the first failure.
+func CreateSplitArchives(ctx context.Context, cfg *Config, note string, dryRun bool, out io.Writer) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
+	}
+	runCfg := *cfg
+	runCfg.ArchiveDirPath = absoluteFrom(cwd, cfg.ArchiveDirPath)
+	if cfg.TrashDirPath != "" {
+		runCfg.TrashDirPath = absoluteFrom(cwd, cfg.TrashDirPath)
+	}
+	if isRemoteLocation(cfg.ArchiveDirPath) {
+		runCfg.ArchiveDirPath = cfg.ArchiveDirPath
+	}
+
+	dirs, loose, err := splitDirectories(cwd, runCfg.ArchiveDirPath, cfg.ExcludePatterns)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to read directory", cfg.StatusDirectoryNotFound, err)
+	}
+	if len(dirs) == 0 {
+		return NewArchiveError(fmt.Sprintf("No subdirectories to archive in %s", cwd), cfg.StatusDirectoryNotFound)
+	}
+
+	summary := &splitSummary{RunID: newRunID(), Dir: cwd, LooseFiles: loose}
+	runCtx := context.WithValue(ctx, splitRunKey{}, summary.RunID)
+	defer os.Chdir(cwd)
+	for _, dir := range dirs {
+		if err := checkContextCancellation(ctx); err != nil {
+			return err
+		}
+		result := splitResult{Dir: dir}
+		start := time.Now()
+		if result.Err = os.Chdir(filepath.Join(cwd, dir)); result.Err == nil {
+			result.Archive, result.Err = createFullArchive(runCtx, &runCfg, note, nil, dryRun, false)
+		}
+		result.Elapsed = time.Since(start)
+		if info, err := os.Stat(result.Archive); err == nil && !dryRun {
+			result.Size = info.Size()
+		}
+		summary.Results = append(summary.Results, result)
+	}
+	os.Chdir(cwd)
+
+	printSplitSummary(out, summary, dryRun)
+	var failed []string
+	var first error
+	for _, r := range summary.Results {
+		if r.Err != nil {
+			failed = append(failed, r.Dir)
+			if first == nil {
+				first = r.Err
+			}
+		}
+	}
+	if first == nil {
+		return nil
+	}
+	status := 1
+	if archiveErr, ok := first.(*ArchiveError); ok {
+		status = archiveErr.StatusCode
+	}
+	return NewArchiveError(fmt.Sprintf("Failed to archive %d of %d subdirectories: %s",
+		len(failed), len(summary.Results), strings.Join(failed, ", ")), status)
+}
+
+// absoluteFrom resolves a relative path against dir.
+func absoluteFrom(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+// splitDirectories returns the names of the subdirectories of dir to
+// archive and the number of other entries directly in dir. Symbolic links
+// are not followed.
+func splitDirectories(dir, archiveDir string, excludePatterns []string) ([]string, int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, 0, err
+	}
+	var dirs []string
+	loose := 0
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() {
+			if !ShouldExcludeFile(name, excludePatterns) {
+				loose++
+			}
+			continue
+		}
+		if ShouldExcludeFile(name, excludePatterns) || ShouldExcludeFile(name+"/", excludePatterns) {
+			continue
+		}
+		// The archive directory, or one of its parents, is not archived into itself
+		sub := filepath.Join(dir, name)
+		if rel, err := filepath.Rel(sub, archiveDir); err == nil && (rel == "." || !strings.HasPrefix(rel, "..")) {
+			continue
+		}
+		dirs = append(dirs, name)
+	}
+	return dirs, loose, nil
+}
+
+// printSplitSummary writes one line per subdirectory and the totals.
+func printSplitSummary(w io.Writer, s *splitSummary, dryRun bool) {
+	width := 0
+	for _, r := range s.Results {
+		width = max(width, len(r.Dir))
+	}
+	fmt.Fprintf(w, "\nSplit run %s of %s\n", s.RunID, s.Dir)
+	var created, failed int
+	var total int64
+	for _, r := range s.Results {
+		var line string
+		switch {
+		case r.Err != nil:
+			failed++
+			line = fmt.Sprintf("  ❌ %-*s  %v\n", width, r.Dir, r.Err)
+		case dryRun:
+			line = fmt.Sprintf("  ✅ %-*s  would create %s\n", width, r.Dir, filepath.Base(r.Archive))
+		default:
+			created++
+			total += r.Size
+			line = fmt.Sprintf("  ✅ %-*s  %s (%s, %s)\n", width, r.Dir, filepath.Base(r.Archive),
+				formatHumanSize(r.Size), r.Elapsed.Round(time.Millisecond))
+		}
+		fmt.Fprint(w, formatter.StyleStdout(line))
+	}
+	if !dryRun {
+		fmt.Fprintf(w, "%d archives created (%s), %d failed\n", created, formatHumanSize(total), failed)
+	}
+	if s.LooseFiles > 0 {
+		fmt.Fprintf(w, "%d %s directly in %s are in none of the archives\n", s.LooseFiles, pluralFiles(s.LooseFiles), s.Dir)
+	}
+}
@@ -0,0 +1,88 @@
+// This file is part of bkpdir
+
+// Package main provides tests for splitting a full archive by subdirectory.
+// It verifies which subdirectories are archived, the shared run ID and the
+// combined summary.
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// ⭐ SPLIT-001: Split runs - 🧪
+func TestCreateSplitArchives(t *testing.T) {
+	cfg := uploadTestConfig(t, "archives")
+	cfg.UseCurrentDirName = true
+	cfg.ExcludePatterns = []string{"node_modules/"}
+	cwd, _ := os.Getwd()
+	for _, name := range []string{"alpha/a.txt", "beta/sub/b.txt", "node_modules/m.js", "top.txt"} {
+		path := filepath.Join(cwd, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		if err := os.WriteFile(path, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := CreateSplitArchives(context.Background(), cfg, "", false, &out); err != nil {
+		t.Fatal(err)
+	}
+	if wd, _ := os.Getwd(); wd != cwd {
+		t.Errorf("working directory = %s, want %s", wd, cwd)
+	}
+
+	// The archive directory is resolved against the split directory, not
+	// against each subdirectory
+	runIDs := make(map[string]bool)
+	for _, dir := range []string{"alpha", "beta"} {
+		archives, err := listArchiveEntries(filepath.Join(cwd, "archives", dir))
+		if err != nil || len(archives) != 1 {
+			t.Fatalf("%s archives = %v (%v)", dir, archives, err)
+		}
+		manifest, err := LoadArchiveManifest(archives[0].Path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		runIDs[manifest.RunID] = true
+	}
+	if len(runIDs) != 1 || runIDs[""] {
+		t.Errorf("run IDs = %v, want one shared ID", runIDs)
+	}
+	for _, want := range []string{"✅ alpha", "✅ beta", "2 archives created", "1 file directly in"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("summary lacks %q:\n%s", want, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "node_modules") {
+		t.Errorf("summary lists an excluded directory:\n%s", out.String())
+	}
+}
+
+// ⭐ SPLIT-001: Skipped subdirectories - 🧪
+func TestSplitDirectories(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"b", "a", "cache", "backups/archives", "src/archives"} {
+		os.MkdirAll(filepath.Join(dir, name), 0o755)
+	}
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644)
+	os.WriteFile(filepath.Join(dir, "skip.log"), nil, 0o644)
+	os.Symlink("a", filepath.Join(dir, "link"))
+
+	dirs, loose, err := splitDirectories(dir, filepath.Join(dir, "backups", "archives"), []string{"cache/", "*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"a", "b", "src"}; !reflect.DeepEqual(dirs, want) {
+		t.Errorf("directories = %v, want %v", dirs, want)
+	}
+	// notes.txt and the symbolic link
+	if loose != 2 {
+		t.Errorf("loose entries = %d, want 2", loose)
+	}
+}
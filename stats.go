@@ -0,0 +1,268 @@
+// This file is part of bkpdir
+//
+// Package main provides archive storage statistics for `bkpdir stats`,
+// including the weekly growth trend rendered as sparklines and bar charts.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// defaultGrowthWeeks is the number of weeks shown by stats --growth.
+const defaultGrowthWeeks = 12
+
+// sparkLevels are the block characters used for sparklines, lowest first.
+var sparkLevels = []rune("▁▂▃▄▅▆▇█")
+
+// ⭐ STATS-001: Statistics report - 📝
+// StatsReport is the JSON form of `bkpdir stats`. Sizes are the sizes of the
+// archive files on disk; removed archives are not counted.
+type StatsReport struct {
+	ArchiveDir          string       `json:"archive_dir"`
+	Archives            int          `json:"archives"`
+	FullArchives        int          `json:"full_archives"`
+	IncrementalArchives int          `json:"incremental_archives"`
+	TotalBytes          int64        `json:"total_bytes"`
+	Oldest              *time.Time   `json:"oldest,omitempty"`
+	Newest              *time.Time   `json:"newest,omitempty"`
+	Growth              []GrowthWeek `json:"growth,omitempty"`
+	// ⭐ DEDUP-001: Duplicate files across archives, with --dedup
+	Dedup *DedupReport `json:"dedup,omitempty"`
+}
+
+// ⭐ STATS-001: Weekly growth series - 📝
+// GrowthWeek holds the archives created in one ISO week and the totals at
+// its end.
+type GrowthWeek struct {
+	Week          string    `json:"week"` // ISO week, e.g. 2026-W42
+	Start         time.Time `json:"start"`
+	Archives      int       `json:"archives"`
+	BytesAdded    int64     `json:"bytes_added"`
+	TotalArchives int       `json:"total_archives"`
+	TotalBytes    int64     `json:"total_bytes"`
+}
+
+// StatsOptions holds the parameters of the stats command.
+type StatsOptions struct {
+	Config *Config
+	Growth bool
+	Weeks  int
+	Output string
+	Dedup  bool      // Analyze duplicate files across archives
+	Top    int       // Duplicate groups listed by --dedup
+	Now    time.Time // End of the growth series; zero means time.Now()
+}
+
+// archiveSize is an archive with the size of its file.
+type archiveSize struct {
+	Archive
+	Size int64
+}
+
+// ⭐ STATS-001: Statistics command implementation - 🔧
+// ShowStats writes archive statistics for the current directory to w.
+func ShowStats(w io.Writer, opts StatsOptions) error {
+	cfg := opts.Config
+	if opts.Output != "" && opts.Output != OutputText && opts.Output != OutputJSON {
+		return NewArchiveError(fmt.Sprintf("Unknown output format %q (use text or json)", opts.Output), cfg.StatusConfigError)
+	}
+	if opts.Weeks <= 0 {
+		return NewArchiveError("--weeks must be positive", cfg.StatusConfigError)
+	}
+	if opts.Top < 0 {
+		return NewArchiveError("--top must not be negative", cfg.StatusConfigError)
+	}
+
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+
+	entries, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", 1, err)
+	}
+	archives := make([]archiveSize, 0, len(entries))
+	for _, a := range entries {
+		info, err := os.Stat(a.Path)
+		if err != nil {
+			continue // Removed while listing
+		}
+		archives = append(archives, archiveSize{Archive: a, Size: info.Size()})
+	}
+
+	now := opts.Now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	report := NewStatsReport(archiveDir, archives)
+	if opts.Growth {
+		report.Growth = weeklyGrowth(archives, opts.Weeks, now)
+	}
+	if opts.Dedup {
+		report.Dedup = analyzeArchiveDuplicates(archives, report, opts.Top, cfg)
+	}
+
+	if opts.Output == OutputJSON {
+		return writeJSONReport(w, report)
+	}
+	width := outputWidth()
+	writeStatsText(w, report, width)
+	if report.Dedup != nil {
+		writeDedupText(w, report.Dedup, width)
+	}
+	return nil
+}
+
+// NewStatsReport summarizes archives.
+func NewStatsReport(archiveDir string, archives []archiveSize) StatsReport {
+	report := StatsReport{ArchiveDir: archiveDir}
+	for _, a := range archives {
+		report.Archives++
+		if a.IsIncremental {
+			report.IncrementalArchives++
+		} else {
+			report.FullArchives++
+		}
+		report.TotalBytes += a.Size
+		created := a.CreationTime
+		if report.Oldest == nil || created.Before(*report.Oldest) {
+			report.Oldest = &created
+		}
+		if report.Newest == nil || created.After(*report.Newest) {
+			report.Newest = &created
+		}
+	}
+	return report
+}
+
+// ⭐ STATS-001: Weekly growth computation - 🔧
+// weeklyGrowth buckets archives into the ISO weeks ending with the week of
+// now. Archives created before the first week count towards its totals.
+func weeklyGrowth(archives []archiveSize, weeks int, now time.Time) []GrowthWeek {
+	current := weekStart(now)
+	series := make([]GrowthWeek, weeks)
+	for i := range series {
+		start := current.AddDate(0, 0, -7*(weeks-1-i))
+		year, week := start.ISOWeek()
+		series[i] = GrowthWeek{Week: fmt.Sprintf("%d-W%02d", year, week), Start: start}
+	}
+
+	var baseArchives int
+	var baseBytes int64
+	for _, a := range archives {
+		created := weekStart(a.CreationTime.In(now.Location()))
+		i := weeks - 1 - int(current.Sub(created).Hours()/(24*7)+0.5)
+		switch {
+		case i < 0:
+			baseArchives++
+			baseBytes += a.Size
+		case i < weeks:
+			series[i].Archives++
+			series[i].BytesAdded += a.Size
+		}
+	}
+
+	for i := range series {
+		series[i].TotalArchives, series[i].TotalBytes = baseArchives+series[i].Archives, baseBytes+series[i].BytesAdded
+		baseArchives, baseBytes = series[i].TotalArchives, series[i].TotalBytes
+	}
+	return series
+}
+
+// weekStart returns midnight of the Monday starting the ISO week of t.
+func weekStart(t time.Time) time.Time {
+	offset := (int(t.Weekday()) + 6) % 7 // Days since Monday
+	y, m, d := t.AddDate(0, 0, -offset).Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+// ⭐ STATS-001: Sparkline rendering - 🔧
+// sparkline renders values as block characters scaled to the largest value.
+// Zero values use the lowest block so every week stays visible.
+func sparkline(values []int64) string {
+	var max int64
+	for _, v := range values {
+		if v > max {
+			max = v
+		}
+	}
+	var b strings.Builder
+	for _, v := range values {
+		level := 0
+		if max > 0 {
+			level = int(v * int64(len(sparkLevels)-1) / max)
+		}
+		b.WriteRune(sparkLevels[level])
+	}
+	return b.String()
+}
+
+// bar renders v as a bar of up to width characters scaled to max.
+func bar(v, max int64, width int) string {
+	if max <= 0 || v <= 0 {
+		return ""
+	}
+	n := int(v * int64(width) / max)
+	if n == 0 {
+		n = 1
+	}
+	return strings.Repeat("█", n)
+}
+
+// ⭐ STATS-001: Text statistics output - 📝
+// writeStatsText writes the summary and, when present, the growth chart. The
+// chart bars use the room left by a terminal of the given width, or 20
+// columns when the width is unknown.
+func writeStatsText(w io.Writer, report StatsReport, width int) {
+	fmt.Fprintf(w, "Archive directory: %s\n", report.ArchiveDir)
+	fmt.Fprintf(w, "Archives: %d (%d full, %d incremental)\n",
+		report.Archives, report.FullArchives, report.IncrementalArchives)
+	fmt.Fprintf(w, "Total size: %s\n", formatHumanSize(report.TotalBytes))
+	if report.Oldest != nil {
+		fmt.Fprintf(w, "Oldest: %s\n", report.Oldest.Format("2006-01-02 15:04:05"))
+		fmt.Fprintf(w, "Newest: %s\n", report.Newest.Format("2006-01-02 15:04:05"))
+	}
+	if len(report.Growth) == 0 {
+		return
+	}
+
+	added := make([]int64, len(report.Growth))
+	counts := make([]int64, len(report.Growth))
+	totals := make([]int64, len(report.Growth))
+	var maxAdded int64
+	for i, g := range report.Growth {
+		added[i], counts[i], totals[i] = g.BytesAdded, int64(g.Archives), g.TotalBytes
+		if g.BytesAdded > maxAdded {
+			maxAdded = g.BytesAdded
+		}
+	}
+	last := report.Growth[len(report.Growth)-1]
+
+	fmt.Fprintf(w, "\nGrowth over %d weeks\n", len(report.Growth))
+	fmt.Fprintf(w, "  Total size  %s  %s\n", sparkline(totals), formatHumanSize(last.TotalBytes))
+	fmt.Fprintf(w, "  Added       %s\n", sparkline(added))
+	fmt.Fprintf(w, "  Archives    %s\n\n", sparkline(counts))
+
+	const columns = "%-8s  %8s  %9s  %9s  "
+	barWidth := 20
+	if width > 0 {
+		barWidth = width - textWidth(fmt.Sprintf(columns, "", "", "", ""))
+		if barWidth < 5 {
+			barWidth = 5
+		}
+	}
+	fmt.Fprintln(w, strings.TrimRight(fmt.Sprintf(columns, "Week", "Archives", "Added", "Total"), " "))
+	for _, g := range report.Growth {
+		line := fmt.Sprintf(columns, g.Week, fmt.Sprint(g.Archives), formatHumanSize(g.BytesAdded), formatHumanSize(g.TotalBytes))
+		fmt.Fprintln(w, strings.TrimRight(line+bar(g.BytesAdded, maxAdded, barWidth), " "))
+	}
+}
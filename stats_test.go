@@ -0,0 +1,72 @@
+// This file is part of bkpdir
+
+// Package main provides tests for archive storage statistics.
+// It verifies weekly bucketing, totals and the sparkline and chart output.
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ STATS-001: Weekly growth computation - 🧪
+func TestWeeklyGrowth(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) // Friday of 2026-W42
+	archive := func(created time.Time, size int64, incremental bool) archiveSize {
+		return archiveSize{Archive: Archive{CreationTime: created, IsIncremental: incremental}, Size: size}
+	}
+	archives := []archiveSize{
+		archive(now.AddDate(0, 0, -60), 1000, false),                        // before the series
+		archive(time.Date(2026, 10, 5, 0, 0, 0, 0, time.UTC), 300, false),   // Monday of W41
+		archive(time.Date(2026, 10, 11, 23, 59, 0, 0, time.UTC), 100, true), // Sunday of W41
+		archive(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC), 50, true),    // Monday of W42
+	}
+
+	series := weeklyGrowth(archives, 3, now)
+	want := []GrowthWeek{
+		{Week: "2026-W40", Archives: 0, BytesAdded: 0, TotalArchives: 1, TotalBytes: 1000},
+		{Week: "2026-W41", Archives: 2, BytesAdded: 400, TotalArchives: 3, TotalBytes: 1400},
+		{Week: "2026-W42", Archives: 1, BytesAdded: 50, TotalArchives: 4, TotalBytes: 1450},
+	}
+	for i, w := range want {
+		g := series[i]
+		if g.Week != w.Week || g.Archives != w.Archives || g.BytesAdded != w.BytesAdded ||
+			g.TotalArchives != w.TotalArchives || g.TotalBytes != w.TotalBytes {
+			t.Errorf("week %d = %+v, want %+v", i, g, w)
+		}
+	}
+	if !series[2].Start.Equal(time.Date(2026, 10, 12, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Expected W42 to start on Monday 2026-10-12, got %v", series[2].Start)
+	}
+
+	report := NewStatsReport("archives", archives)
+	if report.Archives != 4 || report.FullArchives != 2 || report.IncrementalArchives != 2 || report.TotalBytes != 1450 {
+		t.Errorf("Unexpected summary %+v", report)
+	}
+	report.Growth = series
+
+	var out bytes.Buffer
+	writeStatsText(&out, report, 60)
+	text := out.String()
+	for _, expected := range []string{"Archives: 4 (2 full, 2 incremental)", "Growth over 3 weeks", "2026-W41", "█"} {
+		if !strings.Contains(text, expected) {
+			t.Errorf("Expected %q in output:\n%s", expected, text)
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		if textWidth(line) > 60 {
+			t.Errorf("Line %q exceeds 60 columns", line)
+		}
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	if got := sparkline([]int64{0, 1, 2, 4, 8}); got != "▁▁▂▄█" {
+		t.Errorf("sparkline = %q", got)
+	}
+	if got := sparkline([]int64{0, 0}); got != "▁▁" {
+		t.Errorf("sparkline of zeros = %q", got)
+	}
+}
@@ -0,0 +1,203 @@
+// This file is part of bkpdir
+//
+// Package main provides backups of piped content for `bkpdir backup --stdin`,
+// which stores a stream such as command output or a database dump as a
+// timestamped file backup with a SHA-256 checksum, without saving it to a
+// file first.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/formatter"
+)
+
+// stdinChecksumSuffix is appended to the backup name for its checksum sidecar.
+const stdinChecksumSuffix = ".sha256"
+
+// StdinBackupOptions holds the parameters of a backup read from a stream.
+type StdinBackupOptions struct {
+	Context   context.Context
+	Config    *Config
+	Formatter formatter.OutputFormatterInterface
+	Input     io.Reader
+	Name      string // File name the backups are stored under
+	Note      string
+	DryRun    bool
+}
+
+// StdinBackupResult describes the outcome of a stream backup.
+type StdinBackupResult struct {
+	Path      string // The new backup, or the identical existing one
+	Size      int64
+	SHA256    string
+	Identical bool
+}
+
+// ⭐ STDIN-001: Stream backup - 🔧
+// CreateStdinBackup stores the stream as a backup of a file called
+// opts.Name in the current directory, so it is placed and named exactly like
+// `bkpdir backup NAME`. The stream is written to the reserved temporary file
+// while it is hashed; if it matches the most recent backup the copy is
+// dropped and that backup is returned as identical. The checksum is kept in
+// .metadata/<backup>.sha256 in the sha256sum format.
+func CreateStdinBackup(opts StdinBackupOptions) (*StdinBackupResult, error) {
+	cfg := opts.Config
+	if err := validateStdinBackupName(opts.Name); err != nil {
+		return nil, NewArchiveErrorWithCause("Invalid --name", cfg.StatusConfigError, err)
+	}
+	virtualPath, err := filepath.Abs(opts.Name)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to get current directory", cfg.StatusDirectoryNotFound, err)
+	}
+	backupPath, err := generateBackupPath(cfg, virtualPath, opts.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.DryRun {
+		// ⭐ ARCH-008: Show the name the backup would actually get
+		if backupPath, err = claimBackupPath(backupPath, opts.Note, false); err != nil {
+			return nil, err
+		}
+		return &StdinBackupResult{Path: backupPath}, handleDryRunBackup(opts.Formatter, backupPath)
+	}
+
+	backupDir := filepath.Dir(backupPath)
+	if err := SafeMkdirAll(backupDir, 0755, cfg); err != nil {
+		return nil, err
+	}
+	// ⭐ ARCH-008: Backups within the same minute get a sequence suffix
+	backupPath, err = claimBackupPath(backupPath, opts.Note, true)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to reserve backup name", cfg.StatusDiskFull, err)
+	}
+
+	rm := NewResourceManager()
+	defer rm.CleanupWithPanicRecovery()
+	tempFile := backupPath + ".tmp"
+	rm.AddTempFile(tempFile)
+
+	result, err := writeStdinBackup(opts.Context, opts.Input, tempFile)
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to create backup", cfg.StatusDiskFull, err)
+	}
+
+	// ⭐ FILE-003: Skip the backup when the stream matches the most recent one
+	if previous := latestCompleteBackup(backupDir, opts.Name); previous != nil && previous.Size == result.Size {
+		if identical, err := compareFiles(tempFile, previous.Path); err == nil && identical {
+			result.Path, result.Identical = previous.Path, true
+			return result, nil
+		}
+	}
+
+	if err := fileops.Rename(tempFile, backupPath); err != nil {
+		return nil, NewArchiveErrorWithCause("Failed to finalize backup", cfg.StatusDiskFull, err)
+	}
+	rm.RemoveResource(&TempFile{Path: tempFile})
+	result.Path = backupPath
+
+	if err := writeBackupChecksum(backupPath, result.SHA256); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record checksum for %s: %v\n", filepath.Base(backupPath), err)
+	}
+	// ⭐ SUDO-OWNER-001: Backups created through sudo belong to the invoking user
+	applyInvokingUserOwnership(cfg, backupPath)
+	return result, nil
+}
+
+// validateStdinBackupName accepts plain file names that backup names can be
+// built from.
+func validateStdinBackupName(name string) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("--stdin requires --name")
+	case name == "." || name == ".." || filepath.Base(name) != name || strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%q must be a file name without directories", name)
+	case strings.Contains(name, "="):
+		return fmt.Errorf("%q must not contain '=', which separates the note", name)
+	}
+	return nil
+}
+
+// writeStdinBackup copies r to path while hashing it, checking ctx between
+// reads so a cancelled run stops even while the producer is still writing.
+func writeStdinBackup(ctx context.Context, r io.Reader, path string) (*StdinBackupResult, error) {
+	f, err := fileops.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	w := io.MultiWriter(f, h)
+	buf := make([]byte, 32*1024)
+	var size int64
+	for {
+		if err := ctx.Err(); err != nil {
+			f.Close()
+			return nil, err
+		}
+		n, readErr := r.Read(buf)
+		if n > 0 {
+			if _, err := w.Write(buf[:n]); err != nil {
+				f.Close()
+				return nil, err
+			}
+			size += int64(n)
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to read input: %w", readErr)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return nil, err
+	}
+	return &StdinBackupResult{Size: size, SHA256: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// latestCompleteBackup returns the most recent backup of name, ignoring
+// backups still being written.
+func latestCompleteBackup(backupDir, name string) *BackupInfo {
+	backups, err := ListFileBackups(backupDir, name)
+	if err != nil {
+		return nil
+	}
+	for i := range backups {
+		if !strings.HasSuffix(backups[i].Name, ".tmp") {
+			return &backups[i]
+		}
+	}
+	return nil
+}
+
+// ⭐ STDIN-001: Backup checksum sidecar - 🔧
+// writeBackupChecksum records the checksum of a backup in the .metadata
+// directory next to it, where backup listings do not see it. The backup is
+// named relative to the sidecar so `bkpdir checksum verify` and
+// `sha256sum -c` run in .metadata both find it.
+func writeBackupChecksum(backupPath, sum string) error {
+	backupPath, err := filepath.Abs(backupPath)
+	if err != nil {
+		return err
+	}
+	metadataDir := filepath.Join(filepath.Dir(backupPath), ".metadata")
+	if err := fileops.MkdirAll(metadataDir, 0o755); err != nil {
+		return err
+	}
+	line := formatChecksumLine(ChecksumEntry{Sum: sum, Name: "../" + filepath.Base(backupPath)})
+	return fileops.AtomicWriteFile(filepath.Join(metadataDir, filepath.Base(backupPath)+stdinChecksumSuffix), []byte(line), 0o644)
+}
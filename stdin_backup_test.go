@@ -0,0 +1,75 @@
+// This file is part of bkpdir
+
+// Package main provides tests for backups of piped content.
+// It verifies naming, identical stream detection and the checksum sidecar.
+package main
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ STDIN-001: Stream backups - 🧪
+func TestCreateStdinBackup(t *testing.T) {
+	// The stream is named after a file in the working directory
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.BackupDirPath = t.TempDir()
+	cfg.UseCurrentDirNameForFiles = false
+
+	backup := func(content, note string) *StdinBackupResult {
+		t.Helper()
+		result, err := CreateStdinBackup(StdinBackupOptions{
+			Context: context.Background(),
+			Config:  cfg,
+			Input:   strings.NewReader(content),
+			Name:    "report.txt",
+			Note:    note,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return result
+	}
+
+	first := backup("nightly output\n", "nightly")
+	if first.Identical || !strings.HasPrefix(filepath.Base(first.Path), "report.txt-") || !strings.HasSuffix(first.Path, "=nightly") {
+		t.Fatalf("Unexpected first backup %+v", first)
+	}
+	if data, err := os.ReadFile(first.Path); err != nil || string(data) != "nightly output\n" {
+		t.Fatalf("Expected the stream in %s, got %q (%v)", first.Path, data, err)
+	}
+	sidecar := filepath.Join(cfg.BackupDirPath, ".metadata", filepath.Base(first.Path)+stdinChecksumSuffix)
+	if err := VerifyChecksumFile(io.Discard, sidecar); err != nil {
+		t.Errorf("Expected the checksum sidecar to verify: %v", err)
+	}
+
+	if again := backup("nightly output\n", ""); !again.Identical || again.Path != first.Path {
+		t.Errorf("Expected an identical stream to reuse %s, got %+v", first.Path, again)
+	}
+	changed := backup("changed output\n", "nightly")
+	if changed.Identical || changed.Path == first.Path || changed.SHA256 == first.SHA256 {
+		t.Errorf("Expected a new backup for changed content, got %+v", changed)
+	}
+
+	backups, err := ListFileBackups(cfg.BackupDirPath, "report.txt")
+	if err != nil || len(backups) != 2 {
+		t.Errorf("Expected two backups and no leftovers, got %+v (%v)", backups, err)
+	}
+
+	for _, name := range []string{"", "../x", "dir/x", "a=b"} {
+		_, err := CreateStdinBackup(StdinBackupOptions{Context: context.Background(), Config: cfg, Input: strings.NewReader("x"), Name: name})
+		if err == nil {
+			t.Errorf("Expected --name %q to be rejected", name)
+		}
+	}
+}
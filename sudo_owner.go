@@ -0,0 +1,107 @@
+// This file is part of bkpdir
+//
+// Package main provides sudo-safe ownership: with
+// preserve_invoking_user_ownership set, archives, backups and state files
+// created by a run through sudo are given back to the user who ran sudo, so
+// later unprivileged runs can still read, prune and extend them.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// effectiveUID returns the effective uid of the process; tests replace it.
+var effectiveUID = os.Geteuid
+
+// ⭐ SUDO-OWNER-001: Invoking user - 🔍
+// sudoOwner is the user, from SUDO_UID and SUDO_GID, who ran bkpdir through
+// sudo. A gid of -1 keeps the group.
+type sudoOwner struct {
+	uid, gid int
+}
+
+// invokingUserOwner returns the user who ran bkpdir through sudo, or nil
+// when preserve_invoking_user_ownership is off, the process does not run as
+// root, or SUDO_UID does not name another user.
+func invokingUserOwner(cfg *Config) *sudoOwner {
+	if cfg == nil || !cfg.PreserveInvokingUserOwnership || effectiveUID() != 0 {
+		return nil
+	}
+	uid, err := strconv.Atoi(os.Getenv("SUDO_UID"))
+	if err != nil || uid <= 0 {
+		return nil
+	}
+	gid, err := strconv.Atoi(os.Getenv("SUDO_GID"))
+	if err != nil || gid < 0 {
+		gid = -1
+	}
+	return &sudoOwner{uid: uid, gid: gid}
+}
+
+// ownedBy reports whether path exists and belongs to uid.
+func ownedBy(path string, uid int) bool {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false
+	}
+	owner, ok := fileOwnerID(info)
+	return ok && owner == uid
+}
+
+// chown gives path to the invoking user when root owns it; paths owned by
+// other users are left alone.
+func (o *sudoOwner) chown(path string) {
+	if ownedBy(path, effectiveUID()) {
+		os.Lchown(path, o.uid, o.gid)
+	}
+}
+
+// ⭐ SUDO-OWNER-001: Ownership of created artifacts - 🛡️
+// applyInvokingUserOwnership gives a file created through sudo, its sidecars
+// in .metadata and the directories created for it back to the invoking
+// user. Directories owned by root are only given back up to one the user
+// already owns, so a run writing below a system directory such as
+// /var/backups never hands over the directories it did not create.
+func applyInvokingUserOwnership(cfg *Config, path string) {
+	o := invokingUserOwner(cfg)
+	if o == nil {
+		return
+	}
+	o.chown(path)
+	dir := filepath.Dir(path)
+	sidecars, _ := filepath.Glob(filepath.Join(dir, ".metadata", globEscape(filepath.Base(path))+"*"))
+	for _, sidecar := range sidecars {
+		o.chown(sidecar)
+	}
+
+	o.chownCreatedDirs(dir)
+	if ownedBy(dir, o.uid) {
+		o.chown(filepath.Join(dir, ".metadata"))
+	}
+}
+
+// chownCreatedDirs gives dir and its ancestors owned by root to the invoking
+// user, provided the first ancestor not owned by root belongs to the user.
+func (o *sudoOwner) chownCreatedDirs(dir string) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return
+	}
+	var created []string
+	for ownedBy(dir, effectiveUID()) && filepath.Dir(dir) != dir {
+		created = append(created, dir)
+		dir = filepath.Dir(dir)
+	}
+	if !ownedBy(dir, o.uid) {
+		return
+	}
+	for _, dir := range created {
+		o.chown(dir)
+	}
+}
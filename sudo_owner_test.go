@@ -0,0 +1,166 @@
+// This file is part of bkpdir
+
+// Package main provides tests for sudo-safe ownership. It verifies the
+// invoking user detection and the owners of archives created through sudo.
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ⭐ SUDO-OWNER-001: Invoking user detection - 🧪
+func TestInvokingUserOwner(t *testing.T) {
+	defer func(f func() int) { effectiveUID = f }(effectiveUID)
+	cfg := DefaultConfig()
+	cfg.PreserveInvokingUserOwnership = true
+
+	for _, tc := range []struct {
+		euid     int
+		uid, gid string
+		want     *sudoOwner
+	}{
+		{0, "1000", "1001", &sudoOwner{uid: 1000, gid: 1001}},
+		{0, "1000", "", &sudoOwner{uid: 1000, gid: -1}},
+		{0, "0", "0", nil},
+		{0, "", "", nil},
+		{0, "bob", "", nil},
+		{1000, "1000", "1000", nil},
+	} {
+		effectiveUID = func() int { return tc.euid }
+		t.Setenv("SUDO_UID", tc.uid)
+		t.Setenv("SUDO_GID", tc.gid)
+		got := invokingUserOwner(cfg)
+		if (got == nil) != (tc.want == nil) || got != nil && *got != *tc.want {
+			t.Errorf("euid %d, SUDO_UID %q, SUDO_GID %q: owner = %+v, want %+v", tc.euid, tc.uid, tc.gid, got, tc.want)
+		}
+	}
+
+	effectiveUID = func() int { return 0 }
+	t.Setenv("SUDO_UID", "1000")
+	cfg.PreserveInvokingUserOwnership = false
+	if got := invokingUserOwner(cfg); got != nil {
+		t.Errorf("disabled: owner = %+v", got)
+	}
+}
+
+// ⭐ SUDO-OWNER-001: Owners of archives created through sudo - 🧪
+func TestArchiveOwnershipThroughSudo(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("needs root on a Unix system")
+	}
+	const uid, gid = 4321, 4322
+	t.Setenv("SUDO_UID", "4321")
+	t.Setenv("SUDO_GID", "4322")
+	owner := func(path string) int {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := fileOwnerID(info)
+		return id
+	}
+
+	// The user's home holds the archive directory created through sudo
+	home := t.TempDir()
+	os.Chown(home, uid, gid)
+	archiveDir := filepath.Join(home, ".bkpdir", "project")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.PreserveInvokingUserOwnership = true
+	cfg.AuditLog = true
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ := listArchiveEntries(archiveDir)
+	if len(archives) != 1 {
+		t.Fatalf("archives = %+v", archives)
+	}
+	for _, path := range []string{archives[0].Path, archiveDir, filepath.Dir(archiveDir),
+		filepath.Join(archiveDir, ".metadata"), auditLogPath(cfg)} {
+		if got := owner(path); got != uid {
+			t.Errorf("%s is owned by %d, want %d", path, got, uid)
+		}
+	}
+
+	// Below a directory of root, only the archive is given back
+	system := filepath.Join(t.TempDir(), "backups")
+	cfg.ArchiveDirPath = system
+	cfg.AuditLog = false
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ = listArchiveEntries(system)
+	if len(archives) != 1 || owner(archives[0].Path) != uid || owner(system) != 0 {
+		t.Errorf("system directory: archive owner %d, directory owner %d", owner(archives[0].Path), owner(system))
+	}
+
+	// Without the setting everything stays owned by root
+	cfg.ArchiveDirPath = filepath.Join(home, "other")
+	cfg.PreserveInvokingUserOwnership = false
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	if got := owner(cfg.ArchiveDirPath); got != 0 {
+		t.Errorf("disabled: archive directory owned by %d", got)
+	}
+}
+
+// ⭐ SUDO-OWNER-001: Owners of stream backups and journals created through sudo - 🧪
+func TestStateOwnershipThroughSudo(t *testing.T) {
+	if runtime.GOOS == "windows" || os.Geteuid() != 0 {
+		t.Skip("needs root on a Unix system")
+	}
+	const uid, gid = 4321, 4322
+	t.Setenv("SUDO_UID", "4321")
+	t.Setenv("SUDO_GID", "4322")
+	owner := func(path string) int {
+		info, err := os.Lstat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := fileOwnerID(info)
+		return id
+	}
+
+	home := t.TempDir()
+	os.Chown(home, uid, gid)
+	t.Setenv("BKPDIR_JOURNAL", filepath.Join(home, "journal.json"))
+	archiveDir := filepath.Join(home, "archives")
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.BackupDirPath = filepath.Join(home, "backups")
+	cfg.PreserveInvokingUserOwnership = true
+	cfg.ChangeJournal = true
+	cwd, _ := os.Getwd()
+
+	// Names with glob characters still find their sidecars
+	result, err := CreateStdinBackup(StdinBackupOptions{
+		Context: context.Background(), Config: cfg, Input: strings.NewReader("dump"), Name: "db[1].sql"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	backupDir := filepath.Dir(result.Path)
+	sidecar := filepath.Join(backupDir, ".metadata", filepath.Base(result.Path)+stdinChecksumSuffix)
+
+	os.WriteFile("a.txt", []byte("a"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	target := filepath.Join(home, "settings.yml")
+	os.WriteFile(target, []byte("a: 1\n"), 0o644)
+	if err := RecordFileChange(cfg, JournalOpConfigSet, "config a 1", target, nil, false); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{result.Path, sidecar, backupDir,
+		changeJournalPath(archiveDir, cwd), filepath.Join(home, "journal.json")} {
+		if got := owner(path); got != uid {
+			t.Errorf("%s is owned by %d, want %d", path, got, uid)
+		}
+	}
+}
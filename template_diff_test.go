@@ -0,0 +1,51 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the template diff.
+// It verifies unified diff hunks between configuration files.
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+// ⭐ CFG-TEMPLATE-003: Template diff tests - 🔍
+func TestUnifiedDiff(t *testing.T) {
+	if diff := unifiedDiff("a", "b", "same\n", "same\n"); diff != "" {
+		t.Errorf("equal texts gave %q", diff)
+	}
+
+	var old []string
+	for _, c := range "abcdefghijklmnop" {
+		old = append(old, string(c))
+	}
+	changed := append([]string{}, old...)
+	changed[1] = "B"
+	changed = append(changed[:12], append([]string{"new"}, changed[12:]...)...)
+	diff := unifiedDiff(".bkpdir.yml", "template", strings.Join(old, "\n")+"\n", strings.Join(changed, "\n")+"\n")
+	want := `--- .bkpdir.yml
++++ template
+@@ -1,5 +1,5 @@
+ a
+-b
++B
+ c
+ d
+ e
+@@ -10,6 +10,7 @@
+ j
+ k
+ l
++new
+ m
+ n
+ o
+`
+	if diff != want {
+		t.Errorf("diff =\n%s\nwant\n%s", diff, want)
+	}
+
+	if diff := unifiedDiff("a", "b", "", "key: 1\n"); diff != "--- a\n+++ b\n@@ -0,0 +1 @@\n+key: 1\n" {
+		t.Errorf("diff from empty file = %q", diff)
+	}
+}
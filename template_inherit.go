@@ -0,0 +1,239 @@
+// This file is part of bkpdir
+//
+// Package main provides the layered template of `bkpdir template --inherit`:
+// a child configuration that inherits a base file and sets only the keys whose
+// current values differ from the values inherited from it.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"bkpdir/pkg/config"
+)
+
+// ⭐ CFG-TEMPLATE-INHERIT-001: Effective configuration of a base file - 🔍
+// loadInheritedConfig returns the configuration a file inheriting base gets
+// before its own keys apply: the defaults merged with base and its own
+// inherit chain.
+func loadInheritedConfig(base string) (*Config, error) {
+	if _, err := os.Stat(base); err != nil {
+		return nil, err
+	}
+	fileOps := &configFileOperations{}
+	return loadConfigRecursive(base, newPathResolver(fileOps), newInheritanceChainBuilder(fileOps))
+}
+
+// inheritEntry returns the inherit entry naming base in a configuration file
+// written to target: relative to the directory of target when possible, since
+// relative entries are resolved from the inheriting file.
+func inheritEntry(target, base string) string {
+	absBase, err := filepath.Abs(base)
+	if err != nil {
+		return base
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return absBase
+	}
+	rel, err := filepath.Rel(filepath.Dir(absTarget), absBase)
+	if err != nil {
+		return absBase
+	}
+	return filepath.ToSlash(rel)
+}
+
+// ⭐ CFG-TEMPLATE-INHERIT-001: Layered template generation - 🔧
+// generateInheritingTemplate renders a configuration for target that inherits
+// base and sets the keys whose values in cfg differ from those inherited from
+// base, so loading it gives cfg again. Each key is preceded by its description
+// and the inherited value. Secrets are never written, nor are keys that
+// inheriting files cannot set back to their default values; both are listed
+// in comments at the end to be set by other means.
+func generateInheritingTemplate(cfg *Config, target, base string) (string, error) {
+	inherited, err := loadInheritedConfig(base)
+	if err != nil {
+		return "", fmt.Errorf("cannot load %s: %w", base, err)
+	}
+	inheritedValues := make(map[string]interface{})
+	for _, field := range GetAllConfigFields(inherited) {
+		inheritedValues[field.Path] = field.Value
+	}
+
+	var candidates, written, secrets []configFieldInfo
+	for _, field := range GetAllConfigFields(cfg) {
+		switch {
+		case field.YAMLName == "inherit":
+		case reflect.TypeOf(field.Value) == reflect.TypeOf(config.Secret("")):
+			if !reflect.DeepEqual(field.Value, inheritedValues[field.Path]) {
+				secrets = append(secrets, field)
+			}
+		default:
+			candidates = append(candidates, field)
+			if !reflect.DeepEqual(field.Value, inheritedValues[field.Path]) {
+				written = append(written, field)
+			}
+		}
+	}
+
+	// Merging skips values equal to the defaults and always applies a few keys
+	// of the inheriting file, so load the template over base until it gives
+	// cfg back: keys it loses are restated, keys it cannot set are dropped
+	entry := inheritEntry(target, base)
+	var fixed []configFieldInfo
+	tried := make(map[string]bool)
+	for {
+		for _, field := range written {
+			tried[field.Path] = true
+		}
+		loadedValues, err := loadTemplateOver(inherited, entry, written, inheritedValues)
+		if err != nil {
+			return "", err
+		}
+		var kept []configFieldInfo
+		for _, field := range written {
+			if reflect.DeepEqual(field.Value, loadedValues[field.Path]) {
+				kept = append(kept, field)
+			} else {
+				fixed = append(fixed, field)
+			}
+		}
+		added := false
+		for _, field := range candidates {
+			if !tried[field.Path] && !reflect.DeepEqual(field.Value, loadedValues[field.Path]) {
+				kept = append(kept, field)
+				added = true
+			}
+		}
+		written = kept
+		if !added {
+			break
+		}
+	}
+	root, err := inheritingTemplateNode(entry, written, inheritedValues)
+	if err != nil {
+		return "", err
+	}
+
+	header := []string{
+		"BkpDir configuration inheriting " + entry,
+		"Generated on: " + time.Now().Format("2006-01-02 15:04:05"),
+		"",
+		"Only the settings whose current values differ from the inherited ones, and",
+		"those an inheriting file would otherwise reset, are set here; everything",
+		"else comes from the inherit chain.",
+	}
+	if len(written) == 0 {
+		header = append(header, "No setting differs from the inherited values.")
+	}
+	doc := &yaml.Node{Kind: yaml.DocumentNode, HeadComment: strings.Join(header, "\n"), Content: []*yaml.Node{root}}
+	var footer []string
+	if len(secrets) > 0 {
+		footer = append(footer, "Secrets differ too but are not written; set them here or through their environment variables:")
+		for _, field := range secrets {
+			footer = append(footer, "  "+configYAMLPath(field.Path))
+		}
+	}
+	if len(fixed) > 0 {
+		footer = append(footer, "Inheriting files cannot set these back to their defaults; change them in the inherited files:")
+		for _, field := range fixed {
+			footer = append(footer, fmt.Sprintf("  %s: %s (inherited: %s)", configYAMLPath(field.Path),
+				formatFieldValue(field.Value, field.Kind), formatFieldValue(inheritedValues[field.Path], field.Kind)))
+		}
+	}
+	doc.FootComment = strings.Join(footer, "\n")
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(doc); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// loadTemplateOver returns the values of the fields of the configuration
+// loaded from the layered template of fields over inherited.
+func loadTemplateOver(inherited *Config, entry string, fields []configFieldInfo, inheritedValues map[string]interface{}) (map[string]interface{}, error) {
+	root, err := inheritingTemplateNode(entry, fields, inheritedValues)
+	if err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(root)
+	if err != nil {
+		return nil, err
+	}
+	child := DefaultConfig()
+	if err := unmarshalConfigYAML(data, child); err != nil {
+		return nil, err
+	}
+	loaded, err := applyMergeStrategies(inherited, child)
+	if err != nil {
+		return nil, err
+	}
+	values := make(map[string]interface{})
+	for _, field := range GetAllConfigFields(loaded) {
+		values[field.Path] = field.Value
+	}
+	return values, nil
+}
+
+// inheritingTemplateNode builds the mapping of a layered template: the inherit
+// entry followed by fields, nested under their sections, each with its
+// description and inherited value as comments.
+func inheritingTemplateNode(entry string, fields []configFieldInfo, inheritedValues map[string]interface{}) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	inheritValue := &yaml.Node{}
+	if err := inheritValue.Encode([]string{entry}); err != nil {
+		return nil, err
+	}
+	root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: "inherit"}, inheritValue)
+
+	// Keep nested fields together under their parent key
+	fields = slices.Clone(fields)
+	sort.SliceStable(fields, func(i, j int) bool {
+		return configYAMLPath(fields[i].Path) < configYAMLPath(fields[j].Path)
+	})
+	for _, field := range fields {
+		yamlPath := configYAMLPath(field.Path)
+		value := &yaml.Node{}
+		if err := value.Encode(field.Value); err != nil {
+			return nil, fmt.Errorf("cannot encode %s: %w", yamlPath, err)
+		}
+		parts := strings.Split(yamlPath, ".")
+		section := root
+		for _, name := range parts[:len(parts)-1] {
+			node := mappingValue(section, name)
+			if node == nil {
+				node = &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+				section.Content = append(section.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: name}, node)
+			}
+			section = node
+		}
+		key := &yaml.Node{
+			Kind:  yaml.ScalarNode,
+			Tag:   "!!str",
+			Value: parts[len(parts)-1],
+			HeadComment: describeConfigField(field).Description + "\ninherited: " +
+				formatFieldValue(inheritedValues[field.Path], field.Kind),
+		}
+		section.Content = append(section.Content, key, value)
+	}
+	return root, nil
+}
@@ -0,0 +1,80 @@
+// This file is part of bkpdir
+
+// Package main provides tests for layered template generation.
+// It verifies that inheriting templates only set keys differing from the base.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// ⭐ CFG-TEMPLATE-INHERIT-001: Layered template tests - 🧪
+func TestGenerateInheritingTemplate(t *testing.T) {
+	dir := t.TempDir()
+	project := filepath.Join(dir, "project")
+	if err := os.MkdirAll(project, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Join(dir, "team.yml")
+	if err := os.WriteFile(base, []byte("include_git_info: true\nexclude_patterns: ['*.log']\n"+
+		"verification:\n  verify_on_create: true\ngit:\n  command_timeout: 10s\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.IncludeGitInfo = true
+	cfg.ExcludePatterns = []string{"*.log", "tmp/"}
+	cfg.ArchiveDirPath = "/srv/archives"
+	cfg.Verification.VerifyOnCreate = true
+	cfg.HealthcheckURL = "https://hc.example.com/ping/secret"
+	inherited, err := loadInheritedConfig(base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg.Git = inherited.Git
+	cfg.Git.CommandTimeout = DefaultConfig().Git.CommandTimeout
+
+	target := filepath.Join(project, ".bkpdir.yml")
+	content, err := generateInheritingTemplate(cfg, target, base)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"inherit:\n  - ../team.yml\n",
+		"# inherited: [*.log]\nexclude_patterns:\n  - '*.log'\n  - tmp/\n",
+		"archive_dir_path: /srv/archives\n",
+		"#   healthcheck_url\n",
+		"#   git.command_timeout: 30s (inherited: 10s)\n",
+	} {
+		if !strings.Contains(content, want) {
+			t.Errorf("template lacks %q:\n%s", want, content)
+		}
+	}
+	for _, unwanted := range []string{"use_current_dir_name:", "hc.example.com", "\n  command_timeout:"} {
+		if strings.Contains(content, unwanted) {
+			t.Errorf("template contains %q:\n%s", unwanted, content)
+		}
+	}
+
+	// Loaded over the base, the template gives the configuration back
+	if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("BKPDIR_CONFIG", "./.bkpdir.yml")
+	loaded, err := LoadConfig(project)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.ArchiveDirPath != cfg.ArchiveDirPath || strings.Join(loaded.ExcludePatterns, ",") != "*.log,tmp/" ||
+		!loaded.IncludeGitInfo || !loaded.Verification.VerifyOnCreate {
+		t.Errorf("loaded %s %v %v %v", loaded.ArchiveDirPath, loaded.ExcludePatterns, loaded.IncludeGitInfo,
+			loaded.Verification.VerifyOnCreate)
+	}
+
+	if _, err := generateInheritingTemplate(cfg, target, filepath.Join(dir, "missing.yml")); err == nil {
+		t.Error("Expected a missing base to fail")
+	}
+}
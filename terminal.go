@@ -0,0 +1,157 @@
+// This file is part of bkpdir
+//
+// Package main provides terminal width detection, the wrapping and
+// truncation of long archive names, configuration values and inheritance
+// chains in text output, and the color and ASCII policy of the output.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"bkpdir/pkg/formatter"
+)
+
+// defaultTerminalWidth is used for terminals whose size cannot be queried.
+const defaultTerminalWidth = 80
+
+// minTruncatedWidth keeps truncated values recognizable on narrow terminals.
+const minTruncatedWidth = 12
+
+// ⭐ TERM-WIDTH-001: Disable wrapping and truncation; set from --no-truncate
+// before a command runs
+var noTruncate bool
+
+// ⭐ TERM-WIDTH-001: Output width selection - 🔍
+// outputWidth returns the width text output on stdout should fit into, or 0
+// when it must not be wrapped or truncated: with --no-truncate and when stdout
+// is not a terminal, so piped output stays complete.
+func outputWidth() int {
+	if noTruncate {
+		return 0
+	}
+	return terminalWidth(os.Stdout)
+}
+
+// terminalWidth returns the column count of f, or 0 if f is not a terminal.
+// COLUMNS takes precedence over the size reported by the terminal.
+func terminalWidth(f *os.File) int {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return 0
+	}
+	if cols, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && cols > 0 {
+		return cols
+	}
+	if cols := ttyColumns(f); cols > 0 {
+		return cols
+	}
+	return defaultTerminalWidth
+}
+
+// textWidth approximates the number of columns s occupies as its rune count.
+func textWidth(s string) int {
+	return utf8.RuneCountInString(s)
+}
+
+// ⭐ TERM-WIDTH-001: Middle truncation - 🔧
+// truncateMiddle shortens s to width columns by replacing its middle with an
+// ellipsis, keeping both the prefix and the distinguishing end (timestamps,
+// notes and extensions) of archive names visible.
+func truncateMiddle(s string, width int) string {
+	runes := []rune(s)
+	if width <= 0 || len(runes) <= width {
+		return s
+	}
+	if width == 1 {
+		return "…"
+	}
+	head := (width - 1) / 2
+	tail := width - 1 - head
+	return string(runes[:head]) + "…" + string(runes[len(runes)-tail:])
+}
+
+// ⭐ TERM-WIDTH-001: Word wrapping - 🔧
+// wrapText breaks s into lines of at most width columns at spaces; words
+// longer than a line are split. A width of 0 or less returns s unchanged.
+func wrapText(s string, width int) []string {
+	if width <= 0 || textWidth(s) <= width {
+		return []string{s}
+	}
+
+	var lines []string
+	line := ""
+	for _, word := range strings.Fields(s) {
+		for textWidth(word) > width {
+			if line != "" {
+				lines = append(lines, line)
+				line = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:width]))
+			word = string(runes[width:])
+		}
+		switch {
+		case line == "":
+			line = word
+		case textWidth(line)+1+textWidth(word) <= width:
+			line += " " + word
+		default:
+			lines = append(lines, line)
+			line = word
+		}
+	}
+	if line != "" {
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// ⭐ TERM-WIDTH-001: Configuration line fitting - 🔧
+// fitConfigLine renders "prefix name: value suffix" followed by an optional
+// chain note within width columns. The value is truncated in the middle when
+// the line is too long, and a chain that does not fit is wrapped onto
+// continuation lines starting with indent. A width of 0 keeps one line.
+func fitConfigLine(prefix, name, value, suffix, chain, indent string, width int) string {
+	line := prefix + name + ": " + value + suffix
+	if chain != "" {
+		chain = " " + chain
+	}
+	if width <= 0 || textWidth(line+chain) <= width {
+		return line + chain
+	}
+
+	if over := textWidth(line) - width; over > 0 {
+		valueWidth := textWidth(value) - over
+		if valueWidth < minTruncatedWidth {
+			valueWidth = minTruncatedWidth
+		}
+		line = prefix + name + ": " + truncateMiddle(value, valueWidth) + suffix
+	}
+	if chain == "" {
+		return line
+	}
+
+	var b strings.Builder
+	b.WriteString(line)
+	for _, part := range wrapText(strings.TrimSpace(chain), width-textWidth(indent)) {
+		b.WriteString("\n" + indent + part)
+	}
+	return b.String()
+}
+
+// ⭐ COLOR-001: Output style selection - 🔧
+// configureOutputStyle sets the color and ASCII policies of the formatter for
+// stdout and stderr. With the auto mode each stream keeps colors only when it
+// is a terminal.
+func configureOutputStyle(mode formatter.ColorMode, asciiOnly bool) {
+	formatter.SetOutputStyle(
+		formatter.NewStylePolicy(mode, asciiOnly, terminalWidth(os.Stdout) > 0, os.Getenv),
+		formatter.NewStylePolicy(mode, asciiOnly, terminalWidth(os.Stderr) > 0, os.Getenv))
+}
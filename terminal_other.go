@@ -0,0 +1,18 @@
+//go:build !(linux || darwin || freebsd || netbsd || openbsd)
+
+// This file is part of bkpdir
+//
+// Package main provides the terminal size fallback for systems without
+// TIOCGWINSZ; COLUMNS or the default width is used instead.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import "os"
+
+// ttyColumns returns 0: the size of the terminal is unknown.
+func ttyColumns(*os.File) int {
+	return 0
+}
@@ -0,0 +1,84 @@
+// This file is part of bkpdir
+
+// Package main provides tests for terminal width handling.
+// It verifies truncation, wrapping and that piped output is left unchanged.
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// ⭐ TERM-WIDTH-001: Truncation and wrapping helpers - 🧪
+func TestTruncateMiddle(t *testing.T) {
+	name := "project-2024-03-20-14-30=main=abc1234=release.zip"
+	if got := truncateMiddle(name, 0); got != name {
+		t.Errorf("Expected no truncation for width 0, got %q", got)
+	}
+	got := truncateMiddle(name, 21)
+	if got != "project-20…elease.zip" || textWidth(got) != 21 {
+		t.Errorf("truncateMiddle = %q", got)
+	}
+	if got := truncateMiddle("ünïcödé-näme", 5); textWidth(got) != 5 {
+		t.Errorf("Expected 5 runes, got %q", got)
+	}
+}
+
+func TestWrapText(t *testing.T) {
+	lines := wrapText("[chain: environment → ~/.bkpdir.yml → default]", 20)
+	for _, line := range lines {
+		if textWidth(line) > 20 {
+			t.Errorf("Line %q exceeds 20 columns", line)
+		}
+	}
+	if strings.Join(lines, " ") != "[chain: environment → ~/.bkpdir.yml → default]" {
+		t.Errorf("Expected wrapping to keep every word, got %q", lines)
+	}
+	if got := wrapText("abcdefghij", 4); strings.Join(got, "|") != "abcd|efgh|ij" {
+		t.Errorf("Expected long words to be split, got %q", got)
+	}
+}
+
+func TestFitConfigLine(t *testing.T) {
+	value := strings.Repeat("/very/long/path", 10)
+	chain := "[chain: env → /etc/bkpdir.yml → ~/.bkpdir.yml → default]"
+
+	if got := fitConfigLine("", "archive_dir_path", value, " (source: env)", chain, "    ", 0); strings.Contains(got, "\n") || !strings.Contains(got, value) {
+		t.Errorf("Expected one complete line without a width, got %q", got)
+	}
+
+	got := fitConfigLine("├── ", "archive_dir_path", value, " (source: env)", chain, "│       ", 60)
+	lines := strings.Split(got, "\n")
+	if len(lines) < 2 || !strings.HasSuffix(lines[0], " (source: env)") || !strings.Contains(lines[0], "…") {
+		t.Errorf("Expected a truncated value and a wrapped chain, got %q", got)
+	}
+	for _, line := range lines {
+		if textWidth(line) > 60 {
+			t.Errorf("Line %q exceeds 60 columns", line)
+		}
+	}
+	for _, line := range lines[1:] {
+		if !strings.HasPrefix(line, "│       ") {
+			t.Errorf("Expected continuation indent, got %q", line)
+		}
+	}
+}
+
+func TestOutputWidthNotTerminal(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "out")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	t.Setenv("COLUMNS", "40")
+	if w := terminalWidth(f); w != 0 {
+		t.Errorf("Expected width 0 for a regular file, got %d", w)
+	}
+
+	defer func(prev bool) { noTruncate = prev }(noTruncate)
+	noTruncate = true
+	if w := outputWidth(); w != 0 {
+		t.Errorf("Expected --no-truncate to disable fitting, got %d", w)
+	}
+}
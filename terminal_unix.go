@@ -0,0 +1,29 @@
+//go:build linux || darwin || freebsd || netbsd || openbsd
+
+// This file is part of bkpdir
+//
+// Package main provides terminal size detection on Unix systems.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"os"
+	"syscall"
+	"unsafe"
+)
+
+// ⭐ TERM-WIDTH-001: Terminal size query - 🔍
+// ttyColumns returns the column count of the terminal f, or 0 if unknown.
+func ttyColumns(f *os.File) int {
+	var ws struct {
+		Row, Col, Xpixel, Ypixel uint16
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, f.Fd(), uintptr(syscall.TIOCGWINSZ), uintptr(unsafe.Pointer(&ws)))
+	if errno != 0 {
+		return 0
+	}
+	return int(ws.Col)
+}
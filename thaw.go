@@ -0,0 +1,192 @@
+// This file is part of bkpdir
+//
+// Package main provides verification of archives moved to cold storage. By
+// default only their metadata is checked: the object on the cold remote and
+// the manifest and checksums kept in .metadata. `bkpdir verify --thaw`
+// requests retrieval from archival storage classes and, once the archive can
+// be read, downloads and verifies it in full.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/remote"
+)
+
+// Retrieval tiers of archival storage classes.
+const (
+	ThawTierExpedited = "Expedited"
+	ThawTierStandard  = "Standard"
+	ThawTierBulk      = "Bulk"
+)
+
+// thawTierCosts describes the speed and cost of each retrieval tier for the
+// retrieval warning.
+var thawTierCosts = map[string]string{
+	ThawTierExpedited: "takes minutes and costs the most",
+	ThawTierStandard:  "takes hours",
+	ThawTierBulk:      "takes the longest and costs the least",
+}
+
+// ⭐ THAW-001: Cold archive verification - 🛡️
+// verifyColdArchive verifies an archive moved to cold storage: its metadata,
+// or with opts.Thaw its content once it has been retrieved.
+func verifyColdArchive(ctx context.Context, w io.Writer, opts VerifyOptions, archiveDir string, c ColdArchive) error {
+	backend, err := openRemote(opts.Config, c.Remote)
+	if err != nil {
+		return err
+	}
+	if opts.Thaw {
+		return thawColdArchive(ctx, w, opts, archiveDir, backend, c)
+	}
+	return verifyColdMetadata(ctx, w, archiveDir, backend, c)
+}
+
+// ⭐ THAW-001: Metadata-only verification - 🛡️
+// verifyColdMetadata checks that the cold remote stores the archive with its
+// recorded size and that the checksums and manifest kept in .metadata can be
+// read. The stored verification status is not changed, since no content was
+// read.
+func verifyColdMetadata(ctx context.Context, w io.Writer, archiveDir string, backend remote.Backend, c ColdArchive) error {
+	obj, err := backend.Stat(ctx, c.Name)
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("%s is missing from cold storage at %s", c.Name, backend.URL()), 1, err)
+	}
+	if obj.Size != c.Size {
+		return NewArchiveError(fmt.Sprintf("%s has %d bytes in cold storage at %s, %d recorded", c.Name, obj.Size, backend.URL(), c.Size), 1)
+	}
+	local, err := remote.NewDirBackend(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Cannot read the archive metadata", 1, err)
+	}
+	digest, source, err := recordedArchiveDigest(ctx, local, c.Name)
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Cannot read the recorded checksum of %s", c.Name), 1, err)
+	}
+	path := filepath.Join(archiveDir, c.Name)
+	manifest, err := LoadArchiveManifest(path)
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Cannot read the manifest of %s", c.Name), 1, err)
+	}
+
+	class := c.StorageClass
+	if t, ok := backend.(remote.Thawer); ok {
+		if state, err := t.ThawState(ctx, c.Name); err == nil && state.StorageClass != "" {
+			class = state.StorageClass
+		}
+	}
+	if class == "" {
+		class = "default storage class"
+	}
+	fmt.Fprintf(w, "Archive %s in cold storage at %s (%s): metadata verified, size %s matches\n",
+		c.Name, backend.URL(), class, formatHumanSize(c.Size))
+	if digest != "" {
+		fmt.Fprintf(w, "  archive checksum recorded in %s\n", source)
+	} else {
+		fmt.Fprintf(w, "  no archive checksum recorded\n")
+	}
+	if manifest != nil && len(manifest.Files) > 0 {
+		fmt.Fprintf(w, "  %d file hashes recorded in the manifest\n", len(manifest.Files))
+	}
+	if status, err := LoadVerificationStatus(&Archive{Name: c.Name, Path: path}); err == nil && status != nil {
+		result := "passed"
+		if !status.IsVerified {
+			result = "failed"
+		}
+		fmt.Fprintf(w, "  last full verification %s on %s\n", result, status.VerifiedAt.Local().Format("2006-01-02 15:04"))
+	}
+	fmt.Fprintf(w, "  content not read; run 'bkpdir verify %s --thaw' to retrieve and verify it\n", c.Name)
+	return nil
+}
+
+// ⭐ THAW-001: Retrieval and full verification - 🛡️
+// thawColdArchive requests retrieval of an archive in an archival storage
+// class, or, once it can be read, downloads it to where it was stored,
+// verifies it like a local archive and removes the copy again. Retrieval
+// and downloads are announced with a cost warning on stderr.
+func thawColdArchive(ctx context.Context, w io.Writer, opts VerifyOptions, archiveDir string, backend remote.Backend, c ColdArchive) error {
+	cfg := opts.Config
+	tier := opts.ThawTier
+	if tier == "" {
+		tier = ThawTierStandard
+	}
+	if _, ok := thawTierCosts[tier]; !ok {
+		return NewArchiveError(fmt.Sprintf("Invalid --thaw-tier %q (use Expedited, Standard or Bulk)", tier), cfg.StatusConfigError)
+	}
+	days := opts.ThawDays
+	if days == 0 {
+		days = 1
+	}
+	if days < 0 {
+		return NewArchiveError(fmt.Sprintf("Invalid --thaw-days %d", days), cfg.StatusConfigError)
+	}
+
+	// Directory remotes have no archival classes and do not charge for reads
+	if t, ok := backend.(remote.Thawer); ok {
+		state, err := t.ThawState(ctx, c.Name)
+		if err != nil {
+			return NewArchiveErrorWithCause(fmt.Sprintf("Cannot check %s on %s", c.Name, backend.URL()), 1, err)
+		}
+		if !state.Readable() {
+			if state.Ongoing {
+				fmt.Fprintf(w, "Retrieval of %s from %s is in progress; run 'bkpdir verify %s --thaw' again once it completes\n",
+					c.Name, state.StorageClass, c.Name)
+				return nil
+			}
+			fmt.Fprintf(os.Stderr, "Warning: retrieving %s (%s) from %s is charged per GB retrieved, plus requests and transfer; the %s tier %s. The retrieved copy is kept, and billed, for %d day(s)\n",
+				c.Name, formatHumanSize(c.Size), state.StorageClass, tier, thawTierCosts[tier], days)
+			if err := t.Thaw(ctx, c.Name, days, tier); err != nil {
+				return NewArchiveErrorWithCause(fmt.Sprintf("Cannot request retrieval of %s", c.Name), 1, err)
+			}
+			fmt.Fprintf(w, "Requested retrieval of %s from %s (%s tier); run 'bkpdir verify %s --thaw' again once it completes\n",
+				c.Name, state.StorageClass, tier, c.Name)
+			return nil
+		}
+		fmt.Fprintf(os.Stderr, "Warning: downloading %s (%s) from %s; the provider may charge for the transfer\n",
+			c.Name, formatHumanSize(c.Size), backend.URL())
+	}
+	path := filepath.Join(archiveDir, c.Name)
+	if err := downloadColdArchive(ctx, backend, c.Name, path); err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Cannot download %s from %s", c.Name, backend.URL()), 1, err)
+	}
+	// The archive stays in cold storage; the downloaded copy is only verified
+	defer fileops.Remove(path)
+
+	status, err := verifyArchiveWithOptions(path, opts)
+	if err != nil {
+		return err
+	}
+	return handleVerificationResult(&Archive{Name: c.Name, Path: path}, status, c.Name)
+}
+
+// downloadColdArchive copies name from backend to path through a temporary
+// file, so an interrupted download leaves nothing at path.
+func downloadColdArchive(ctx context.Context, backend remote.Backend, name, path string) error {
+	rc, err := backend.Open(ctx, name)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".bkpdir-thaw-")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := io.Copy(tmp, rc); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return fileops.Rename(tmp.Name(), path)
+}
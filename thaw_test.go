@@ -0,0 +1,140 @@
+// This file is part of bkpdir
+
+// Package main provides tests for verifying archives in cold storage.
+// It verifies metadata-only verification, full verification of a retrieved
+// archive, and the retrieval requests made for archival storage classes.
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"bkpdir/pkg/remote"
+)
+
+// glacierBackend is a directory backend whose objects behave as if they were
+// in an archival storage class.
+type glacierBackend struct {
+	remote.Backend
+	state  remote.ThawState
+	thawed []string
+}
+
+func (g *glacierBackend) ThawState(context.Context, string) (remote.ThawState, error) {
+	return g.state, nil
+}
+
+func (g *glacierBackend) Thaw(_ context.Context, name string, days int, tier string) error {
+	g.thawed = append(g.thawed, name+" "+tier)
+	g.state.Ongoing = true
+	return nil
+}
+
+// tieredTestArchive creates an archive, moves it to a cold directory and
+// returns its catalog entry.
+func tieredTestArchive(t *testing.T) (*Config, string, ColdArchive) {
+	t.Helper()
+	archiveDir, coldDir := t.TempDir(), t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.Tiering = &TieringConfig{ColdRemote: coldDir, AfterDays: 30}
+	if err := os.WriteFile("notes.txt", []byte("cold notes"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	matches, _ := filepath.Glob(filepath.Join(archiveDir, "*.zip"))
+	old := time.Now().AddDate(0, 0, -60)
+	for _, m := range matches {
+		if err := os.Chtimes(m, old, old); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := TierArchives(TierOptions{Config: cfg, Output: &bytes.Buffer{}}); err != nil {
+		t.Fatal(err)
+	}
+	catalog, err := LoadColdCatalog(archiveDir)
+	if err != nil || len(catalog.Archives) != 1 {
+		t.Fatalf("catalog = %+v, %v", catalog, err)
+	}
+	return cfg, archiveDir, catalog.Archives[0]
+}
+
+// ⭐ THAW-001: Metadata and full verification of cold archives - 🧪
+func TestVerifyColdArchive(t *testing.T) {
+	cfg, archiveDir, c := tieredTestArchive(t)
+	ctx := context.Background()
+
+	var out bytes.Buffer
+	if err := verifyColdArchive(ctx, &out, VerifyOptions{Config: cfg}, archiveDir, c); err != nil {
+		t.Fatalf("metadata verification: %v", err)
+	}
+	if !strings.Contains(out.String(), "metadata verified") || !strings.Contains(out.String(), "--thaw") {
+		t.Errorf("metadata verification output:\n%s", out.String())
+	}
+
+	out.Reset()
+	opts := VerifyOptions{Config: cfg, Thaw: true}
+	if err := verifyColdArchive(ctx, &out, opts, archiveDir, c); err != nil {
+		t.Fatalf("thawed verification: %v", err)
+	}
+	path := filepath.Join(archiveDir, c.Name)
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("the retrieved copy was left in the archive directory")
+	}
+	status, err := LoadVerificationStatus(&Archive{Name: c.Name, Path: path})
+	if err != nil || status == nil || !status.IsVerified {
+		t.Errorf("stored status = %+v, %v", status, err)
+	}
+
+	// A truncated cold copy fails the metadata check
+	if err := os.WriteFile(filepath.Join(cfg.Tiering.ColdRemote, c.Name), []byte("short"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := verifyColdArchive(ctx, &out, VerifyOptions{Config: cfg}, archiveDir, c); err == nil {
+		t.Error("a cold copy of the wrong size should fail")
+	}
+}
+
+// ⭐ THAW-001: Retrieval from archival storage classes - 🧪
+func TestThawColdArchive(t *testing.T) {
+	cfg, archiveDir, c := tieredTestArchive(t)
+	ctx := context.Background()
+	dir, err := remote.NewDirBackend(cfg.Tiering.ColdRemote)
+	if err != nil {
+		t.Fatal(err)
+	}
+	glacier := &glacierBackend{Backend: dir, state: remote.ThawState{StorageClass: "GLACIER", Archived: true}}
+	opts := VerifyOptions{Config: cfg, Thaw: true, ThawTier: ThawTierBulk}
+
+	var out bytes.Buffer
+	if err := thawColdArchive(ctx, &out, opts, archiveDir, glacier, c); err != nil {
+		t.Fatal(err)
+	}
+	if len(glacier.thawed) != 1 || glacier.thawed[0] != c.Name+" Bulk" || !strings.Contains(out.String(), "Requested retrieval") {
+		t.Errorf("thaw requests %v, output:\n%s", glacier.thawed, out.String())
+	}
+
+	out.Reset()
+	if err := thawColdArchive(ctx, &out, opts, archiveDir, glacier, c); err != nil {
+		t.Fatal(err)
+	}
+	if len(glacier.thawed) != 1 || !strings.Contains(out.String(), "in progress") {
+		t.Errorf("an ongoing retrieval was requested again: %v\n%s", glacier.thawed, out.String())
+	}
+
+	glacier.state = remote.ThawState{StorageClass: "GLACIER", Archived: true, Restored: true}
+	if err := thawColdArchive(ctx, &out, opts, archiveDir, glacier, c); err != nil {
+		t.Fatalf("verifying the retrieved copy: %v", err)
+	}
+
+	opts.ThawTier = "Instant"
+	if err := thawColdArchive(ctx, &out, opts, archiveDir, glacier, c); err == nil {
+		t.Error("an unknown retrieval tier should fail")
+	}
+}
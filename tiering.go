@@ -0,0 +1,288 @@
+// This file is part of bkpdir
+//
+// Package main provides age-based tiering: `bkpdir tier` moves archives older
+// than tiering.after_days from the archive directory to a cold remote, and a
+// catalog in the archive directory records where each moved archive lives so
+// list and verify still know about it.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// coldCatalogName is the catalog of moved archives in .metadata.
+const coldCatalogName = "cold-storage.json"
+
+// ⭐ TIER-001: Tiering configuration - 🔧
+// TieringConfig moves archives to a cold remote once they are old enough.
+type TieringConfig struct {
+	ColdRemote   string `yaml:"cold_remote"`   // Remote URL archives are moved to; empty disables tiering
+	AfterDays    int    `yaml:"after_days"`    // Age in days after which archives are moved
+	StorageClass string `yaml:"storage_class"` // S3 storage class of moved archives, e.g. GLACIER
+}
+
+// DefaultTieringConfig returns the tiering settings used when none are
+// configured: no cold remote, and archives moved after 90 days once one is
+// set.
+func DefaultTieringConfig() *TieringConfig {
+	return &TieringConfig{AfterDays: 90}
+}
+
+// tieringSettings returns the tiering section of cfg or the defaults.
+func tieringSettings(cfg *Config) *TieringConfig {
+	if cfg.Tiering == nil {
+		return DefaultTieringConfig()
+	}
+	return cfg.Tiering
+}
+
+// coldStorageClass returns the storage class of uploads to url: the
+// configured one for the cold remote and the bucket default otherwise.
+func coldStorageClass(cfg *Config, url string) string {
+	settings := tieringSettings(cfg)
+	if settings.ColdRemote != "" && settings.ColdRemote == url {
+		return settings.StorageClass
+	}
+	return ""
+}
+
+// ⭐ TIER-001: Cold storage catalog - 📝
+// ColdArchive is an archive moved to cold storage. Created is the time the
+// archive was written, which orders it among the local archives.
+type ColdArchive struct {
+	Name         string    `json:"name"`
+	Remote       string    `json:"remote"`
+	Size         int64     `json:"size"`
+	Created      time.Time `json:"created"`
+	MovedAt      time.Time `json:"moved_at"`
+	StorageClass string    `json:"storage_class,omitempty"`
+}
+
+// ColdCatalog lists the archives of an archive directory that were moved to
+// cold storage. It is stored as .metadata/cold-storage.json.
+type ColdCatalog struct {
+	Archives []ColdArchive `json:"archives"`
+}
+
+// coldCatalogPath returns the catalog of archiveDir.
+func coldCatalogPath(archiveDir string) string {
+	return filepath.Join(archiveDir, ".metadata", coldCatalogName)
+}
+
+// LoadColdCatalog reads the catalog of archiveDir. A missing catalog has no
+// archives.
+func LoadColdCatalog(archiveDir string) (*ColdCatalog, error) {
+	data, err := os.ReadFile(coldCatalogPath(archiveDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return &ColdCatalog{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cold storage catalog: %w", err)
+	}
+	var catalog ColdCatalog
+	if err := json.Unmarshal(data, &catalog); err != nil {
+		return nil, fmt.Errorf("failed to decode cold storage catalog: %w", err)
+	}
+	return &catalog, nil
+}
+
+// storeColdCatalog replaces the catalog of archiveDir atomically.
+func storeColdCatalog(archiveDir string, catalog *ColdCatalog) error {
+	sort.Slice(catalog.Archives, func(i, j int) bool { return catalog.Archives[i].Name < catalog.Archives[j].Name })
+	data, err := json.MarshalIndent(catalog, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := coldCatalogPath(archiveDir)
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return fileops.AtomicWriteFile(path, data, 0o644)
+}
+
+// Find returns the catalog entry of the archive name, or nil.
+func (c *ColdCatalog) Find(name string) *ColdArchive {
+	for i := range c.Archives {
+		if c.Archives[i].Name == name {
+			return &c.Archives[i]
+		}
+	}
+	return nil
+}
+
+// add records a moved archive, replacing an earlier entry of the same name.
+func (c *ColdCatalog) add(archive ColdArchive) {
+	if existing := c.Find(archive.Name); existing != nil {
+		*existing = archive
+		return
+	}
+	c.Archives = append(c.Archives, archive)
+}
+
+// coldCatalogEntries returns the cataloged archives of archiveDir that are
+// not also stored locally, for example after being copied back.
+func coldCatalogEntries(archiveDir string, local []Archive) ([]ColdArchive, error) {
+	catalog, err := LoadColdCatalog(archiveDir)
+	if err != nil {
+		return nil, err
+	}
+	present := make(map[string]bool, len(local))
+	for _, a := range local {
+		present[a.Name] = true
+	}
+	var entries []ColdArchive
+	for _, c := range catalog.Archives {
+		if !present[c.Name] {
+			entries = append(entries, c)
+		}
+	}
+	return entries, nil
+}
+
+// ⭐ TIER-001: Cold archives in listings - 🔍
+// coldArchives returns the archives of coldCatalogEntries. Their Path is
+// where they were stored, so their sidecars are found as before.
+func coldArchives(archiveDir string, local []Archive) ([]Archive, error) {
+	entries, err := coldCatalogEntries(archiveDir, local)
+	if err != nil {
+		return nil, err
+	}
+	var archives []Archive
+	for _, c := range entries {
+		archive := Archive{
+			Name:         c.Name,
+			Path:         filepath.Join(archiveDir, c.Name),
+			CreationTime: c.Created,
+			Location:     c.Remote,
+		}
+		if base, _, found := strings.Cut(c.Name, "_update="); found {
+			archive.IsIncremental, archive.BaseArchive = true, base+".zip"
+		}
+		archives = append(archives, archive)
+	}
+	return archives, nil
+}
+
+// TierOptions configures `bkpdir tier`.
+type TierOptions struct {
+	Config  *Config
+	Output  io.Writer
+	Context context.Context
+	DryRun  bool // List the archives that would be moved
+}
+
+// ⭐ TIER-001: Archive tiering - 🔧
+// TierArchives moves the archives older than tiering.after_days to
+// tiering.cold_remote. Each archive is uploaded like `bkpdir upload`, checked
+// on the remote, recorded in the catalog and only then removed locally; its
+// sidecars stay in .metadata.
+func TierArchives(opts TierOptions) error {
+	cfg := opts.Config
+	settings := tieringSettings(cfg)
+	if settings.ColdRemote == "" {
+		return NewArchiveError("No cold remote configured; set tiering.cold_remote", cfg.StatusConfigError)
+	}
+	if settings.AfterDays < 0 {
+		return NewArchiveError(fmt.Sprintf("Invalid tiering.after_days %d", settings.AfterDays), cfg.StatusConfigError)
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	archives, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to list archives", cfg.StatusDirectoryNotFound, err)
+	}
+	// ⭐ FOREIGN-001: Foreign files stay where they are
+	archives = filterForeignArchives(cfg, archives)
+	cutoff := time.Now().AddDate(0, 0, -settings.AfterDays)
+	var due []Archive
+	for _, a := range archives {
+		if a.CreationTime.Before(cutoff) {
+			due = append(due, a)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool { return due[i].CreationTime.Before(due[j].CreationTime) })
+	if len(due) == 0 {
+		fmt.Fprintf(opts.Output, "No archives older than %d days\n", settings.AfterDays)
+		return nil
+	}
+	if opts.DryRun {
+		for _, a := range due {
+			fmt.Fprintf(opts.Output, "Would move %s (%d days old) to %s\n",
+				a.Name, int(time.Since(a.CreationTime).Hours()/24), settings.ColdRemote)
+		}
+		return nil
+	}
+
+	names := make([]string, len(due))
+	for i, a := range due {
+		names[i] = a.Name
+	}
+	upload := UploadOptions{Config: cfg, Output: opts.Output, Context: ctx, Remote: settings.ColdRemote, Archives: names}
+	if err := UploadArchives(upload); err != nil {
+		return err
+	}
+
+	backend, err := openRemote(cfg, settings.ColdRemote)
+	if err != nil {
+		return err
+	}
+	catalog, err := LoadColdCatalog(archiveDir)
+	if err != nil {
+		return NewArchiveErrorWithCause("Cannot update the cold storage catalog", 1, err)
+	}
+	for _, a := range due {
+		info, err := os.Stat(a.Path)
+		if err != nil {
+			return NewArchiveErrorWithCause(fmt.Sprintf("Archive not found: %s", a.Path), cfg.StatusFileNotFound, err)
+		}
+		obj, err := backend.Stat(ctx, a.Name)
+		if err != nil || obj.Size != info.Size() {
+			return NewArchiveErrorWithCause(fmt.Sprintf(
+				"%s is not stored completely on %s; it was kept", a.Name, backend.URL()), 1, err)
+		}
+		// The catalog is written before the archive is removed, so an
+		// interruption never loses track of an archive
+		catalog.add(ColdArchive{
+			Name:         a.Name,
+			Remote:       backend.URL(),
+			Size:         info.Size(),
+			Created:      a.CreationTime,
+			MovedAt:      time.Now().UTC(),
+			StorageClass: settings.StorageClass,
+		})
+		if err := storeColdCatalog(archiveDir, catalog); err != nil {
+			return NewArchiveErrorWithCause("Cannot update the cold storage catalog", 1, err)
+		}
+		if err := fileops.Remove(a.Path); err != nil {
+			return NewArchiveErrorWithCause(fmt.Sprintf("Cannot remove %s", a.Path), 1, err)
+		}
+		// ⭐ EVENT-001: Report the move to the system log
+		emitArchiveEvent(cfg.EventLog, OperationTier, a.Path, nil)
+		// ⭐ AUDIT-001: Record the move in the audit log
+		recordAudit(cfg, AuditActionTier, a.Path, "moved to "+backend.URL(), nil)
+		fmt.Fprintf(opts.Output, "Moved %s to %s\n", a.Name, backend.URL())
+	}
+	return nil
+}
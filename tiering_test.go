@@ -0,0 +1,100 @@
+// This file is part of bkpdir
+
+// Package main provides tests for tiering archives to cold storage.
+// It verifies that old archives are moved to the cold remote, recorded in
+// the catalog, and still known to listing and verification.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// ⭐ TIER-001: Age-based tiering - 🧪
+func TestTierArchives(t *testing.T) {
+	archiveDir, coldDir := t.TempDir(), t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.Tiering = &TieringConfig{ColdRemote: coldDir, AfterDays: 30, StorageClass: "GLACIER"}
+
+	old := time.Now().AddDate(0, 0, -60).Truncate(time.Second)
+	for name, stamp := range map[string]time.Time{
+		"project-2024-01-10-09-00.zip": old,
+		"project-2024-03-20-14-30.zip": time.Now(),
+	} {
+		path := filepath.Join(archiveDir, name)
+		if err := os.WriteFile(path, []byte("archive "+name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(path, stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := TierArchives(TierOptions{Config: cfg, Output: &out, DryRun: true}); err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !strings.Contains(out.String(), "Would move project-2024-01-10-09-00.zip") || strings.Contains(out.String(), "2024-03-20") {
+		t.Errorf("dry run output:\n%s", out.String())
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "project-2024-01-10-09-00.zip")); err != nil {
+		t.Fatal("dry run removed the archive")
+	}
+
+	out.Reset()
+	if err := TierArchives(TierOptions{Config: cfg, Output: &out}); err != nil {
+		t.Fatalf("TierArchives: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "project-2024-01-10-09-00.zip")); !os.IsNotExist(err) {
+		t.Error("the old archive is still stored locally")
+	}
+	if _, err := os.Stat(filepath.Join(archiveDir, "project-2024-03-20-14-30.zip")); err != nil {
+		t.Error("the recent archive was moved")
+	}
+	if data, err := os.ReadFile(filepath.Join(coldDir, "project-2024-01-10-09-00.zip")); err != nil || !strings.HasPrefix(string(data), "archive ") {
+		t.Errorf("cold copy: %q, %v", data, err)
+	}
+
+	local, err := listArchiveEntries(archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cold, err := coldArchives(archiveDir, local)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(cold) != 1 || cold[0].Location == "" || !cold[0].CreationTime.Equal(old) {
+		t.Fatalf("cold archives = %+v", cold)
+	}
+	catalog, err := LoadColdCatalog(archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c := catalog.Find(cold[0].Name); c == nil || c.StorageClass != "GLACIER" {
+		t.Errorf("catalog = %+v", catalog)
+	}
+
+	out.Reset()
+	if err := TierArchives(TierOptions{Config: cfg, Output: &out}); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+	if !strings.Contains(out.String(), "No archives older than 30 days") {
+		t.Errorf("second run output:\n%s", out.String())
+	}
+}
+
+// ⭐ TIER-001: Storage class of the cold remote only - 🧪
+func TestColdStorageClass(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Tiering = &TieringConfig{ColdRemote: "s3://cold/laptop", StorageClass: "DEEP_ARCHIVE"}
+	if got := coldStorageClass(cfg, "s3://cold/laptop"); got != "DEEP_ARCHIVE" {
+		t.Errorf("cold remote class = %q", got)
+	}
+	if got := coldStorageClass(cfg, "s3://hot/laptop"); got != "" {
+		t.Errorf("other remote class = %q", got)
+	}
+}
@@ -0,0 +1,263 @@
+// This file is part of bkpdir
+//
+// Package main provides OpenTelemetry tracing of the archive pipeline. Spans
+// for the scan, filter, compress, write and verify stages are collected per
+// run and exported with the OTLP/HTTP JSON protocol when an endpoint is set.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ⭐ TRACE-001: OTLP configuration - 🔧
+const (
+	otlpEndpointEnv       = "OTEL_EXPORTER_OTLP_ENDPOINT"
+	otlpTracesEndpointEnv = "OTEL_EXPORTER_OTLP_TRACES_ENDPOINT"
+	otlpHeadersEnv        = "OTEL_EXPORTER_OTLP_HEADERS"
+	otlpTracesPath        = "/v1/traces"
+	otlpExportTimeout     = 5 * time.Second
+)
+
+// Span attribute keys used by the pipeline stages.
+const (
+	traceAttrFilesScanned  = "bkpdir.files.scanned"
+	traceAttrFilesIncluded = "bkpdir.files.included"
+	traceAttrFilesExcluded = "bkpdir.files.excluded"
+	traceAttrBytesIn       = "bkpdir.bytes.uncompressed"
+	traceAttrBytesOut      = "bkpdir.bytes.compressed"
+	traceAttrArchive       = "bkpdir.archive"
+)
+
+// traceRecorder collects the spans of one traced run.
+type traceRecorder struct {
+	mu       sync.Mutex
+	traceID  [16]byte
+	endpoint string
+	spans    []*traceSpan
+}
+
+// traceSpan is one timed pipeline stage. A nil span is valid and ignores all
+// calls, so stages can be instrumented unconditionally.
+type traceSpan struct {
+	recorder *traceRecorder
+	name     string
+	spanID   [8]byte
+	parentID [8]byte
+	start    time.Time
+	end      time.Time
+	attrs    []traceAttr
+	err      error
+}
+
+type traceAttr struct {
+	key   string
+	value interface{}
+}
+
+type traceSpanKey struct{}
+
+// ⭐ TRACE-001: Endpoint resolution - 🔍
+// otlpTracesEndpoint returns the OTLP traces URL, or "" when tracing is off.
+// A configured otlp_endpoint wins over the standard OTEL_* variables.
+func otlpTracesEndpoint(cfg *Config) string {
+	base := cfg.OTLPEndpoint
+	if base == "" {
+		if full := os.Getenv(otlpTracesEndpointEnv); full != "" {
+			return full
+		}
+		base = os.Getenv(otlpEndpointEnv)
+	}
+	if base == "" {
+		return ""
+	}
+	return strings.TrimSuffix(base, "/") + otlpTracesPath
+}
+
+// ⭐ TRACE-001: Run tracing - 🔧
+// startArchiveTrace starts the root span of an archive operation. It returns
+// a nil span when no OTLP endpoint is configured.
+func startArchiveTrace(ctx context.Context, cfg *Config, operation string) (context.Context, *traceSpan) {
+	endpoint := otlpTracesEndpoint(cfg)
+	if endpoint == "" {
+		return ctx, nil
+	}
+	recorder := &traceRecorder{endpoint: endpoint}
+	_, _ = rand.Read(recorder.traceID[:])
+	return recorder.startSpan(ctx, "bkpdir."+operation, [8]byte{})
+}
+
+// finishArchiveTrace ends the root span and exports the run. Export failures
+// only warn; tracing never fails an archive operation.
+func finishArchiveTrace(root *traceSpan, err error) {
+	if root == nil {
+		return
+	}
+	root.End(err)
+	if exportErr := root.recorder.export(); exportErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not export trace: %v\n", exportErr)
+	}
+}
+
+// startSpan starts a child of the span in ctx. Without a traced parent it
+// returns ctx and a nil span.
+func startSpan(ctx context.Context, name string) (context.Context, *traceSpan) {
+	parent, _ := ctx.Value(traceSpanKey{}).(*traceSpan)
+	if parent == nil {
+		return ctx, nil
+	}
+	return parent.recorder.startSpan(ctx, name, parent.spanID)
+}
+
+func (r *traceRecorder) startSpan(ctx context.Context, name string, parentID [8]byte) (context.Context, *traceSpan) {
+	span := &traceSpan{recorder: r, name: name, parentID: parentID, start: time.Now()}
+	_, _ = rand.Read(span.spanID[:])
+	r.mu.Lock()
+	r.spans = append(r.spans, span)
+	r.mu.Unlock()
+	return context.WithValue(ctx, traceSpanKey{}, span), span
+}
+
+// SetAttr records an attribute; values are strings, bools or integers.
+func (s *traceSpan) SetAttr(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	s.attrs = append(s.attrs, traceAttr{key: key, value: value})
+}
+
+// End finishes the span, marking it failed when err is not nil.
+func (s *traceSpan) End(err error) {
+	if s == nil {
+		return
+	}
+	s.recorder.mu.Lock()
+	defer s.recorder.mu.Unlock()
+	if s.end.IsZero() {
+		s.end = time.Now()
+		s.err = err
+	}
+}
+
+// ⭐ TRACE-001: OTLP/HTTP JSON export - 🔧
+func (r *traceRecorder) export() error {
+	body, err := json.Marshal(r.otlpPayload())
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), otlpExportTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range parseOTLPHeaders(os.Getenv(otlpHeadersEnv)) {
+		req.Header.Set(key, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned %s", resp.Status)
+	}
+	return nil
+}
+
+// otlpPayload builds an ExportTraceServiceRequest in the OTLP JSON encoding.
+func (r *traceRecorder) otlpPayload() map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	traceID := hex.EncodeToString(r.traceID[:])
+	spans := make([]map[string]interface{}, 0, len(r.spans))
+	for _, s := range r.spans {
+		end := s.end
+		if end.IsZero() {
+			end = time.Now()
+		}
+		span := map[string]interface{}{
+			"traceId":           traceID,
+			"spanId":            hex.EncodeToString(s.spanID[:]),
+			"name":              s.name,
+			"kind":              1, // SPAN_KIND_INTERNAL
+			"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+			"endTimeUnixNano":   strconv.FormatInt(end.UnixNano(), 10),
+			"attributes":        otlpAttributes(s.attrs),
+			"status":            map[string]interface{}{"code": 1}, // STATUS_CODE_OK
+		}
+		if s.parentID != ([8]byte{}) {
+			span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+		}
+		if s.err != nil {
+			span["status"] = map[string]interface{}{"code": 2, "message": s.err.Error()} // STATUS_CODE_ERROR
+		}
+		spans = append(spans, span)
+	}
+
+	return map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes([]traceAttr{
+					{key: "service.name", value: "bkpdir"},
+					{key: "service.version", value: version},
+				}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "bkpdir"},
+				"spans": spans,
+			}},
+		}},
+	}
+}
+
+func otlpAttributes(attrs []traceAttr) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(attrs))
+	for _, a := range attrs {
+		var value map[string]interface{}
+		switch v := a.value.(type) {
+		case int:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(int64(v), 10)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]interface{}{"key": a.key, "value": value})
+	}
+	return out
+}
+
+// parseOTLPHeaders parses the comma-separated key=value list used by
+// OTEL_EXPORTER_OTLP_HEADERS.
+func parseOTLPHeaders(s string) map[string]string {
+	headers := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(key) == "" {
+			continue
+		}
+		headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return headers
+}
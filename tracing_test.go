@@ -0,0 +1,114 @@
+// This file is part of bkpdir
+
+// Package main provides tests for OpenTelemetry tracing of archive runs.
+// It verifies that pipeline stage spans reach an OTLP/HTTP collector.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// ⭐ TRACE-001: Pipeline span export tests - 🔧
+func TestArchiveTracing(t *testing.T) {
+	var payloads [][]byte
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != otlpTracesPath || r.Header.Get("X-Test") != "yes" {
+			t.Errorf("unexpected request %s with headers %v", r.URL.Path, r.Header)
+		}
+		body, _ := io.ReadAll(r.Body)
+		payloads = append(payloads, body)
+	}))
+	defer collector.Close()
+	t.Setenv(otlpHeadersEnv, "X-Test=yes")
+
+	tempDir := t.TempDir()
+	sourceDir := filepath.Join(tempDir, "source")
+	if err := os.MkdirAll(sourceDir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"a.txt", "b.txt", "skip.log"} {
+		if err := os.WriteFile(filepath.Join(sourceDir, name), []byte("content"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	origDir, _ := os.Getwd()
+	defer os.Chdir(origDir)
+	if err := os.Chdir(sourceDir); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = filepath.Join(tempDir, "archives")
+	cfg.UseCurrentDirName = false
+	cfg.ExcludePatterns = []string{"*.log"}
+	cfg.OTLPEndpoint = collector.URL
+
+	if err := CreateFullArchive(cfg, "", false, true); err != nil {
+		t.Fatalf("CreateFullArchive failed: %v", err)
+	}
+	if len(payloads) != 1 {
+		t.Fatalf("Expected one export, got %d", len(payloads))
+	}
+
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []struct {
+					Name         string `json:"name"`
+					ParentSpanID string `json:"parentSpanId"`
+					Attributes   []struct {
+						Key   string `json:"key"`
+						Value struct {
+							IntValue string `json:"intValue"`
+						} `json:"value"`
+					} `json:"attributes"`
+				} `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.Unmarshal(payloads[0], &request); err != nil {
+		t.Fatal(err)
+	}
+
+	attrs := make(map[string]map[string]string)
+	for _, span := range request.ResourceSpans[0].ScopeSpans[0].Spans {
+		attrs[span.Name] = make(map[string]string)
+		for _, a := range span.Attributes {
+			attrs[span.Name][a.Key] = a.Value.IntValue
+		}
+		if span.Name != "bkpdir.create.full" && span.ParentSpanID == "" {
+			t.Errorf("span %s has no parent", span.Name)
+		}
+	}
+	for _, name := range []string{"bkpdir.create.full", "scan", "filter", "compress", "write", "verify"} {
+		if _, ok := attrs[name]; !ok {
+			t.Errorf("missing span %s", name)
+		}
+	}
+	if got := attrs["filter"][traceAttrFilesExcluded]; got != "1" {
+		t.Errorf("excluded files = %q, want 1", got)
+	}
+	if got := attrs["compress"][traceAttrBytesIn]; got != "14" {
+		t.Errorf("uncompressed bytes = %q, want 14", got)
+	}
+}
+
+// TestArchiveTracingDisabled verifies no spans are recorded without an endpoint.
+func TestArchiveTracingDisabled(t *testing.T) {
+	t.Setenv(otlpEndpointEnv, "")
+	t.Setenv(otlpTracesEndpointEnv, "")
+	ctx, root := startArchiveTrace(context.Background(), DefaultConfig(), "create.full")
+	if root != nil {
+		t.Fatal("Expected no root span without an endpoint")
+	}
+	if _, span := startSpan(ctx, "scan"); span != nil {
+		t.Fatal("Expected no child span without a trace")
+	}
+}
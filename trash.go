@@ -0,0 +1,207 @@
+// This file is part of bkpdir
+//
+// Package main provides the trash area that removed archives are moved into
+// so they can be recovered within a retention window.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ TRASH-001: Trash metadata sidecar suffix - 🔧
+const trashInfoSuffix = ".trashinfo"
+
+// ⭐ TRASH-001: Trashed item metadata - 🔧
+// TrashItem describes one entry in the trash area. The metadata is stored next
+// to the trashed file as <Name>.trashinfo.
+type TrashItem struct {
+	Name         string    `json:"name"`
+	OriginalPath string    `json:"original_path"`
+	TrashedAt    time.Time `json:"trashed_at"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+// Expired reports whether the retention window of the item has passed.
+func (t TrashItem) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// ⭐ TRASH-001: Trash relocation - 🔧
+// MoveToTrash moves path into the configured trash directory instead of
+// deleting it and journals the move so `bkpdir undo` can put it back. A
+// relative trash_dir_path is resolved against the current directory.
+func MoveToTrash(cfg *Config, path string) (TrashItem, error) {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return TrashItem{}, fmt.Errorf("failed to resolve %s: %w", path, err)
+	}
+	trashDir, err := filepath.Abs(cfg.TrashDirPath)
+	if err != nil {
+		return TrashItem{}, fmt.Errorf("failed to resolve %s: %w", cfg.TrashDirPath, err)
+	}
+	if err := fileops.MkdirAll(trashDir, 0755); err != nil {
+		return TrashItem{}, fmt.Errorf("failed to create trash directory: %w", err)
+	}
+
+	now := time.Now()
+	item := TrashItem{
+		Name:         uniqueTrashName(trashDir, now.Format("20060102-150405")+"-"+filepath.Base(absPath)),
+		OriginalPath: absPath,
+		TrashedAt:    now,
+		ExpiresAt:    now.AddDate(0, 0, cfg.TrashRetentionDays),
+	}
+	trashPath := filepath.Join(trashDir, item.Name)
+
+	if err := writeTrashInfo(trashPath, item); err != nil {
+		return TrashItem{}, err
+	}
+	if err := fileops.Rename(absPath, trashPath); err != nil {
+		fileops.Remove(trashPath + trashInfoSuffix)
+		return TrashItem{}, fmt.Errorf("failed to move %s to trash: %w", path, err)
+	}
+
+	if err := recordTrashMove(cfg, item, trashPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: could not record trash move for undo: %v\n", err)
+	}
+	// ⭐ EVENT-001: Report the removal to the system log
+	emitArchiveEvent(cfg.EventLog, OperationTrash, absPath, nil)
+	// ⭐ AUDIT-001: Record the removal in the audit log
+	recordAudit(cfg, AuditActionTrash, absPath, "moved to "+trashPath, nil)
+	return item, nil
+}
+
+// uniqueTrashName appends a counter when name is already taken in dir.
+func uniqueTrashName(dir, name string) string {
+	candidate := name
+	for i := 1; ; i++ {
+		if _, err := os.Lstat(filepath.Join(dir, candidate)); os.IsNotExist(err) {
+			return candidate
+		}
+		candidate = fmt.Sprintf("%s.%d", name, i)
+	}
+}
+
+func writeTrashInfo(trashPath string, item TrashItem) error {
+	data, err := json.MarshalIndent(item, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode trash metadata: %w", err)
+	}
+	return fileops.AtomicWriteFile(trashPath+trashInfoSuffix, data, 0644)
+}
+
+// ⭐ TRASH-001: Trash listing - 🔧
+// ListTrash returns the items in the trash directory, oldest first.
+func ListTrash(cfg *Config) ([]TrashItem, error) {
+	entries, err := os.ReadDir(cfg.TrashDirPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trash directory: %w", err)
+	}
+
+	var items []TrashItem
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), trashInfoSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(cfg.TrashDirPath, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+		var item TrashItem
+		if err := json.Unmarshal(data, &item); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", entry.Name(), err)
+		}
+		items = append(items, item)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].TrashedAt.Before(items[j].TrashedAt)
+	})
+	return items, nil
+}
+
+// ⭐ TRASH-001: Trash recovery - 🔧
+// RestoreFromTrash moves the named item back to its original location.
+// It refuses to overwrite a file that has since been created there.
+func RestoreFromTrash(cfg *Config, name string) (TrashItem, error) {
+	trashPath := filepath.Join(cfg.TrashDirPath, filepath.Base(name))
+	data, err := os.ReadFile(trashPath + trashInfoSuffix)
+	if os.IsNotExist(err) {
+		return TrashItem{}, fmt.Errorf("no trashed item named %s", name)
+	}
+	if err != nil {
+		return TrashItem{}, fmt.Errorf("failed to read trash metadata: %w", err)
+	}
+	var item TrashItem
+	if err := json.Unmarshal(data, &item); err != nil {
+		return TrashItem{}, fmt.Errorf("failed to parse trash metadata: %w", err)
+	}
+
+	err = restoreTrashedPath(trashPath, item.OriginalPath)
+	// ⭐ AUDIT-001: Record the recovery in the audit log
+	recordAudit(cfg, AuditActionRestore, item.OriginalPath, "from trash item "+item.Name, err)
+	if err != nil {
+		return item, err
+	}
+	return item, nil
+}
+
+// restoreTrashedPath moves trashPath back to original and drops its metadata.
+func restoreTrashedPath(trashPath, original string) error {
+	if _, err := os.Lstat(original); err == nil {
+		return fmt.Errorf("cannot restore %s: destination already exists", original)
+	}
+	if err := fileops.MkdirAll(filepath.Dir(original), 0755); err != nil {
+		return fmt.Errorf("failed to create %s: %w", filepath.Dir(original), err)
+	}
+	if err := fileops.Rename(trashPath, original); err != nil {
+		return fmt.Errorf("failed to restore %s: %w", original, err)
+	}
+	if err := fileops.Remove(trashPath + trashInfoSuffix); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove trash metadata: %w", err)
+	}
+	return nil
+}
+
+// ⭐ TRASH-001: Trash purge - 🔧
+// EmptyTrash permanently deletes trashed items. With expiredOnly set, only
+// items whose retention window has passed at now are removed.
+func EmptyTrash(cfg *Config, expiredOnly bool, now time.Time) ([]TrashItem, error) {
+	items, err := ListTrash(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []TrashItem
+	for _, item := range items {
+		if expiredOnly && !item.Expired(now) {
+			continue
+		}
+		trashPath := filepath.Join(cfg.TrashDirPath, item.Name)
+		if err := fileops.RemoveAll(trashPath); err != nil {
+			recordAudit(cfg, AuditActionPrune, item.OriginalPath, "trash item "+item.Name, err)
+			return removed, fmt.Errorf("failed to delete %s: %w", item.Name, err)
+		}
+		if err := fileops.Remove(trashPath + trashInfoSuffix); err != nil && !os.IsNotExist(err) {
+			return removed, fmt.Errorf("failed to delete metadata for %s: %w", item.Name, err)
+		}
+		// ⭐ AUDIT-001: Record the permanent deletion in the audit log
+		recordAudit(cfg, AuditActionPrune, item.OriginalPath, "trash item "+item.Name, nil)
+		removed = append(removed, item)
+	}
+	return removed, nil
+}
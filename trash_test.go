@@ -0,0 +1,116 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the trash area.
+// It verifies moving, listing, restoring and purging trashed archives.
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// ⭐ TRASH-001: Trash lifecycle tests - 🔧
+func TestTrashLifecycle(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(journalEnvVar, filepath.Join(dir, "journal.json"))
+
+	cfg := DefaultConfig()
+	cfg.TrashDirPath = filepath.Join(dir, "trash")
+	cfg.TrashRetentionDays = 3
+
+	archive := filepath.Join(dir, "archives", "proj-2024-01-01-10-00.zip")
+	if err := os.MkdirAll(filepath.Dir(archive), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(archive, []byte("zip"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	item, err := MoveToTrash(cfg, archive)
+	if err != nil {
+		t.Fatalf("MoveToTrash failed: %v", err)
+	}
+	if _, err := os.Stat(archive); !os.IsNotExist(err) {
+		t.Error("Archive should no longer be at its original location")
+	}
+	if got := item.ExpiresAt.Sub(item.TrashedAt); got != 72*time.Hour {
+		t.Errorf("Expected 3 day retention, got %v", got)
+	}
+
+	items, err := ListTrash(cfg)
+	if err != nil || len(items) != 1 || items[0].OriginalPath != archive {
+		t.Fatalf("Unexpected trash listing: %+v (%v)", items, err)
+	}
+
+	t.Run("restore", func(t *testing.T) {
+		if _, err := RestoreFromTrash(cfg, item.Name); err != nil {
+			t.Fatalf("RestoreFromTrash failed: %v", err)
+		}
+		if data, err := os.ReadFile(archive); err != nil || string(data) != "zip" {
+			t.Errorf("Archive not restored: %q (%v)", data, err)
+		}
+		if items, _ := ListTrash(cfg); len(items) != 0 {
+			t.Errorf("Trash should be empty after restore, got %d items", len(items))
+		}
+	})
+
+	t.Run("undo", func(t *testing.T) {
+		if _, err := MoveToTrash(cfg, archive); err != nil {
+			t.Fatal(err)
+		}
+		path, _ := JournalPath()
+		j, err := LoadJournal(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		entry, err := j.Undo(false)
+		if err != nil || entry.Operation != JournalOpTrash {
+			t.Fatalf("Undo failed: %+v (%v)", entry, err)
+		}
+		if _, err := os.Stat(archive); err != nil {
+			t.Errorf("Undo should put the archive back: %v", err)
+		}
+	})
+
+	t.Run("empty expired only", func(t *testing.T) {
+		if _, err := MoveToTrash(cfg, archive); err != nil {
+			t.Fatal(err)
+		}
+		removed, err := EmptyTrash(cfg, true, time.Now())
+		if err != nil || len(removed) != 0 {
+			t.Errorf("Nothing should be expired yet: %+v (%v)", removed, err)
+		}
+		removed, err = EmptyTrash(cfg, true, time.Now().Add(4*24*time.Hour))
+		if err != nil || len(removed) != 1 {
+			t.Errorf("Expected one expired item removed: %+v (%v)", removed, err)
+		}
+		entries, _ := os.ReadDir(cfg.TrashDirPath)
+		if len(entries) != 0 {
+			t.Errorf("Trash directory should be empty, found %d entries", len(entries))
+		}
+	})
+}
+
+func TestRestoreFromTrashRefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(journalEnvVar, filepath.Join(dir, "journal.json"))
+	cfg := DefaultConfig()
+	cfg.TrashDirPath = filepath.Join(dir, "trash")
+
+	target := filepath.Join(dir, "a.zip")
+	os.WriteFile(target, []byte("old"), 0644)
+	item, err := MoveToTrash(cfg, target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(target, []byte("new"), 0644)
+
+	if _, err := RestoreFromTrash(cfg, item.Name); err == nil {
+		t.Error("Expected restore to refuse overwriting an existing file")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Error("Existing file must not be overwritten")
+	}
+}
@@ -0,0 +1,173 @@
+// This file is part of bkpdir
+//
+// Package main provides Unicode normalization of file names. macOS tools
+// often produce decomposed (NFD) names while Linux tools produce composed
+// (NFC) names, so the same file can appear under two byte sequences.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+// ⭐ UNICODE-001: Restore normalization modes - 🔧
+const (
+	// UnicodeNormalizationPreserve restores names with their original bytes.
+	UnicodeNormalizationPreserve = "preserve"
+	// UnicodeNormalizationNFC restores names in composed form, as Linux tools write them.
+	UnicodeNormalizationNFC = "nfc"
+	// UnicodeNormalizationNFD restores names in decomposed form, as HFS+ stores them.
+	UnicodeNormalizationNFD = "nfd"
+)
+
+// Normalization forms recorded in the manifest.
+const (
+	nameFormNFC   = "NFC"
+	nameFormNFD   = "NFD"
+	nameFormMixed = "mixed"
+)
+
+// canonicalCompositions is the inverse of canonicalDecompositions.
+var canonicalCompositions = func() map[[2]rune]rune {
+	m := make(map[[2]rune]rune, len(canonicalDecompositions))
+	for composed, pair := range canonicalDecompositions {
+		m[pair] = composed
+	}
+	return m
+}()
+
+// ⭐ UNICODE-001: Name normalization record - 📝
+// NameNormalization records an archive entry whose name is not in NFC. Path
+// is the NFC form used for comparisons and Original the exact stored bytes.
+type NameNormalization struct {
+	Path     string `json:"path"`
+	Original []byte `json:"original"`
+	Form     string `json:"form"`
+}
+
+// ⭐ UNICODE-001: Composed normalization - 🔧
+// NormalizeNFC returns s in composed form. Names that are not valid UTF-8
+// are returned unchanged.
+func NormalizeNFC(s string) string {
+	if isASCII(s) || !utf8.ValidString(s) {
+		return s
+	}
+	return string(composeRunes(decomposeRunes(s)))
+}
+
+// ⭐ UNICODE-001: Decomposed normalization - 🔧
+// NormalizeNFD returns s in decomposed form. Names that are not valid UTF-8
+// are returned unchanged.
+func NormalizeNFD(s string) string {
+	if isASCII(s) || !utf8.ValidString(s) {
+		return s
+	}
+	return string(decomposeRunes(s))
+}
+
+// ⭐ UNICODE-001: Restore name selection - 🔧
+// RestoreEntryName returns the name an archive entry is restored under for
+// the configured restore_unicode_normalization mode.
+func RestoreEntryName(name, mode string) string {
+	switch mode {
+	case UnicodeNormalizationNFC:
+		return NormalizeNFC(name)
+	case UnicodeNormalizationNFD:
+		return NormalizeNFD(name)
+	default:
+		return name
+	}
+}
+
+// ⭐ UNICODE-001: Manifest normalization records - 🔍
+// CollectNameNormalizations returns a record for every file whose name is not
+// already NFC, sorted by normalized path.
+func CollectNameNormalizations(files []string) []NameNormalization {
+	var records []NameNormalization
+	for _, f := range files {
+		nfc := NormalizeNFC(f)
+		if nfc == f {
+			continue
+		}
+		form := nameFormMixed
+		if NormalizeNFD(f) == f {
+			form = nameFormNFD
+		}
+		records = append(records, NameNormalization{Path: nfc, Original: []byte(f), Form: form})
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Path < records[j].Path
+	})
+	return records
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+// decomposeRunes fully decomposes s and puts combining marks in canonical order.
+func decomposeRunes(s string) []rune {
+	var out []rune
+	for _, r := range s {
+		out = appendDecomposed(out, r)
+	}
+
+	// Canonical ordering: stable sort each run of marks by combining class
+	for i := 0; i < len(out); {
+		if combiningClasses[out[i]] == 0 {
+			i++
+			continue
+		}
+		j := i
+		for j < len(out) && combiningClasses[out[j]] != 0 {
+			j++
+		}
+		run := out[i:j]
+		sort.SliceStable(run, func(a, b int) bool {
+			return combiningClasses[run[a]] < combiningClasses[run[b]]
+		})
+		i = j
+	}
+	return out
+}
+
+func appendDecomposed(out []rune, r rune) []rune {
+	if pair, ok := canonicalDecompositions[r]; ok {
+		out = appendDecomposed(out, pair[0])
+		return append(out, pair[1])
+	}
+	return append(out, r)
+}
+
+// composeRunes applies canonical composition to decomposed runes.
+func composeRunes(runes []rune) []rune {
+	out := make([]rune, 0, len(runes))
+	starter := -1
+	lastClass := -1 // class of the last rune after the starter, -1 when adjacent
+	for _, r := range runes {
+		class := int(combiningClasses[r])
+		if starter >= 0 && (lastClass == -1 || (lastClass != 0 && lastClass < class)) {
+			if composed, ok := canonicalCompositions[[2]rune{out[starter], r}]; ok {
+				out[starter] = composed
+				continue
+			}
+		}
+		out = append(out, r)
+		if class == 0 {
+			starter = len(out) - 1
+			lastClass = -1
+		} else {
+			lastClass = class
+		}
+	}
+	return out
+}
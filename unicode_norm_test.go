@@ -0,0 +1,68 @@
+// This file is part of bkpdir
+
+// Package main provides tests for file name Unicode normalization.
+// It verifies NFC/NFD conversion, restore modes and manifest records.
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+// ⭐ UNICODE-001: Normalization tests - 🔧
+func TestNormalizeFileNames(t *testing.T) {
+	tests := []struct {
+		name, nfc, nfd string
+	}{
+		{"latin-1", "café.txt", "café.txt"},
+		{"stacked marks", "Tiếng Việt", "Tiếng Việt"},
+		{"ring above", "Ångström", "Ångström"},
+		{"ascii", "plain.txt", "plain.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, in := range []string{tt.nfc, tt.nfd} {
+				if got := NormalizeNFC(in); got != tt.nfc {
+					t.Errorf("NormalizeNFC(%q) = %q, want %q", in, got, tt.nfc)
+				}
+				if got := NormalizeNFD(in); got != tt.nfd {
+					t.Errorf("NormalizeNFD(%q) = %q, want %q", in, got, tt.nfd)
+				}
+			}
+		})
+	}
+
+	invalid := "bad\xffname"
+	if NormalizeNFC(invalid) != invalid || NormalizeNFD(invalid) != invalid {
+		t.Error("Invalid UTF-8 names must be left unchanged")
+	}
+}
+
+func TestRestoreEntryName(t *testing.T) {
+	nfd := "résumé.pdf"
+	if got := RestoreEntryName(nfd, UnicodeNormalizationPreserve); got != nfd {
+		t.Errorf("preserve changed the name to %q", got)
+	}
+	if got := RestoreEntryName(nfd, UnicodeNormalizationNFC); got != "résumé.pdf" {
+		t.Errorf("nfc produced %q", got)
+	}
+	if got := RestoreEntryName("résumé.pdf", UnicodeNormalizationNFD); got != nfd {
+		t.Errorf("nfd produced %q", got)
+	}
+}
+
+func TestUnicodeManifestRecords(t *testing.T) {
+	nfd := "docs/café.md"
+	files := []string{"docs/café.md", nfd, "readme.md"}
+
+	records := CollectNameNormalizations(files)
+	want := []NameNormalization{{Path: "docs/café.md", Original: []byte(nfd), Form: "NFD"}}
+	if !reflect.DeepEqual(records, want) {
+		t.Errorf("CollectNameNormalizations = %+v, want %+v", records, want)
+	}
+
+	// Both spellings name the same file on APFS and must be reported together
+	if groups := DetectCaseCollisions(files); len(groups) != 1 || len(groups[0]) != 2 {
+		t.Errorf("Expected NFC/NFD spellings to collide, got %v", groups)
+	}
+}
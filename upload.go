@@ -0,0 +1,326 @@
+// This file is part of bkpdir
+//
+// Package main provides `bkpdir upload`, which copies archives to a remote
+// with multipart uploads. Every upload keeps a session journal in the archive
+// directory, so an interrupted upload continues with `bkpdir upload --resume`
+// after its last stored part instead of starting over.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/remote"
+)
+
+// uploadSessionDir is the directory below the archive directory that holds
+// the journals of unfinished uploads.
+const uploadSessionDir = ".upload-sessions"
+
+// UploadOptions configures an upload.
+type UploadOptions struct {
+	Config   *Config
+	Output   io.Writer
+	Context  context.Context
+	Remote   string   // Remote URL; may be empty with Resume to continue every unfinished upload
+	Archives []string // Archive names in the archive directory, or paths; empty uploads every archive
+	Resume   bool     // Continue unfinished uploads from their journal
+	Restart  bool     // Discard unfinished uploads and start them over
+	DryRun   bool     // Report the uploads without sending anything
+}
+
+// uploadJob is one archive to upload and its journal.
+type uploadJob struct {
+	backend remote.Backend
+	path    string
+	name    string
+	journal string
+	session *remote.Session
+}
+
+// ⭐ REMOTE-001: Upload command - 🔧
+// UploadArchives uploads archives to a remote. Archives stored there with
+// the same size are skipped. An unfinished upload is continued only with
+// Resume, or discarded with Restart; otherwise it is refused so that two
+// runs never upload the same archive at once.
+func UploadArchives(opts UploadOptions) error {
+	cfg := opts.Config
+	if opts.Resume && opts.Restart {
+		return NewArchiveError("--resume and --restart cannot be combined", cfg.StatusConfigError)
+	}
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	archiveDir, err := getArchiveDirectory(cfg)
+	if err != nil {
+		return err
+	}
+	journalDir := filepath.Join(archiveDir, uploadSessionDir)
+
+	var jobs []*uploadJob
+	if opts.Remote == "" {
+		if !opts.Resume {
+			return NewArchiveError("No remote given", cfg.StatusConfigError)
+		}
+		jobs, err = journaledUploads(cfg, journalDir)
+	} else {
+		jobs, err = plannedUploads(ctx, opts, archiveDir, journalDir)
+	}
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		fmt.Fprintln(opts.Output, "Nothing to upload")
+		return nil
+	}
+
+	partSize, err := uploadPartSize(cfg)
+	if err != nil {
+		return err
+	}
+	for _, job := range jobs {
+		if err := runUpload(ctx, opts, job, partSize); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// journaledUploads returns the unfinished uploads of every remote.
+func journaledUploads(cfg *Config, journalDir string) ([]*uploadJob, error) {
+	entries, err := os.ReadDir(journalDir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, NewArchiveErrorWithCause("Cannot read upload sessions", cfg.StatusDirectoryNotFound, err)
+	}
+	var jobs []*uploadJob
+	backends := map[string]remote.Backend{}
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		journal := filepath.Join(journalDir, entry.Name())
+		s, err := readUploadSession(journal)
+		if err != nil {
+			return nil, NewArchiveErrorWithCause(fmt.Sprintf("Invalid upload session %s", journal), cfg.StatusConfigError, err)
+		}
+		backend, ok := backends[s.Remote]
+		if !ok {
+			if backend, err = openRemote(cfg, s.Remote); err != nil {
+				return nil, err
+			}
+			backends[s.Remote] = backend
+		}
+		jobs = append(jobs, &uploadJob{backend: backend, path: s.Source, name: s.Name, journal: journal, session: s})
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].journal < jobs[j].journal })
+	return jobs, nil
+}
+
+// plannedUploads returns the archives to upload to opts.Remote with their
+// unfinished sessions, skipping archives the remote already stores.
+func plannedUploads(ctx context.Context, opts UploadOptions, archiveDir, journalDir string) ([]*uploadJob, error) {
+	cfg := opts.Config
+	backend, err := openRemote(cfg, opts.Remote)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	if len(opts.Archives) == 0 {
+		archives, err := listArchiveEntries(archiveDir)
+		if err != nil {
+			return nil, NewArchiveErrorWithCause("Failed to list archives", cfg.StatusDirectoryNotFound, err)
+		}
+		for _, archive := range archives {
+			paths = append(paths, archive.Path)
+		}
+	}
+	for _, name := range opts.Archives {
+		path, err := resolveRestoreArchive(cfg, name)
+		if err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	var jobs []*uploadJob
+	for _, path := range paths {
+		job := &uploadJob{backend: backend, path: path, name: filepath.Base(path)}
+		job.journal = uploadJournalPath(journalDir, backend.URL(), job.name)
+		if job.session, err = readUploadSession(job.journal); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, NewArchiveErrorWithCause(fmt.Sprintf("Invalid upload session %s", job.journal), cfg.StatusConfigError, err)
+		}
+		switch {
+		case job.session != nil && opts.Restart:
+			if !opts.DryRun {
+				discardUpload(ctx, job)
+			}
+			job.session = nil
+		case job.session != nil && !opts.Resume:
+			return nil, NewArchiveError(fmt.Sprintf(
+				"An interrupted upload of %s to %s exists; run 'bkpdir upload --resume' to continue it or 'bkpdir upload --restart' to start over",
+				job.name, backend.URL()), cfg.StatusConfigError)
+		}
+		if job.session == nil {
+			info, err := os.Stat(path)
+			if err != nil {
+				return nil, NewArchiveErrorWithCause(fmt.Sprintf("Archive not found: %s", path), cfg.StatusFileNotFound, err)
+			}
+			if obj, err := backend.Stat(ctx, job.name); err == nil && obj.Size == info.Size() {
+				fmt.Fprintf(opts.Output, "Already uploaded: %s\n", job.name)
+				continue
+			} else if err != nil && !errors.Is(err, remote.ErrNotExist) {
+				return nil, NewArchiveErrorWithCause(fmt.Sprintf("Cannot check %s on %s", job.name, backend.URL()), 1, err)
+			}
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
+
+// runUpload uploads one archive, continuing its session if it has one. A
+// session the remote no longer knows, for example because it expired, is
+// started over.
+func runUpload(ctx context.Context, opts UploadOptions, job *uploadJob, partSize int64) error {
+	cfg := opts.Config
+	f, err := os.Open(job.path)
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Archive not found: %s", job.path), cfg.StatusFileNotFound, err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Cannot read %s", job.path), 1, err)
+	}
+	if s := job.session; s != nil && (s.Size != info.Size() || !s.ModTime.Equal(info.ModTime())) {
+		return NewArchiveError(fmt.Sprintf(
+			"%s changed since its upload started; run 'bkpdir upload --restart %s' to start over",
+			job.path, s.Remote), cfg.StatusConfigError)
+	}
+
+	if opts.DryRun {
+		if job.session != nil {
+			fmt.Fprintf(opts.Output, "Would resume upload of %s to %s at %s of %s\n", job.name, job.backend.URL(),
+				formatHumanSize(job.session.Uploaded()), formatHumanSize(job.session.Size))
+		} else {
+			fmt.Fprintf(opts.Output, "Would upload %s to %s (%s)\n", job.name, job.backend.URL(), formatHumanSize(info.Size()))
+		}
+		return nil
+	}
+
+	// ⭐ TRANSIT-VERIFY-001: Hash the archive while it is sent and compare the recorded checksum
+	local, err := remote.NewDirBackend(filepath.Dir(job.path))
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Cannot read %s", job.path), 1, err)
+	}
+	digest, _, err := recordedArchiveDigest(ctx, local, job.name)
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf("Cannot read the recorded checksum of %s", job.name), 1, err)
+	}
+	newSession := func() *remote.Session {
+		s := remote.NewSession(job.backend.URL(), job.name, job.path, info.Size(), info.ModTime(), partSize)
+		s.SHA256 = digest
+		return s
+	}
+	if job.session == nil {
+		job.session = newSession()
+	} else {
+		fmt.Fprintf(opts.Output, "Resuming upload of %s at %s of %s\n", job.name,
+			formatHumanSize(job.session.Uploaded()), formatHumanSize(job.session.Size))
+	}
+	save := func(s *remote.Session) error { return writeUploadSession(job.journal, s) }
+	progress := func(n int64) {
+		fmt.Fprintf(opts.Output, "Uploading %s: %s of %s\n", job.name, formatHumanSize(n), formatHumanSize(info.Size()))
+	}
+	err = remote.Upload(ctx, job.backend, f, job.session, save, progress)
+	if errors.Is(err, remote.ErrUploadNotFound) {
+		fmt.Fprintf(opts.Output, "Upload of %s expired on the remote; starting over\n", job.name)
+		job.session = newSession()
+		err = remote.Upload(ctx, job.backend, f, job.session, save, progress)
+	}
+	if errors.Is(err, remote.ErrDigestMismatch) {
+		discardUpload(ctx, job)
+		return NewArchiveErrorWithCause(fmt.Sprintf(
+			"%s does not match its recorded checksum; it was not uploaded", job.path), 1, err)
+	}
+	if err != nil && ctx.Err() != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf(
+			"Upload of %s interrupted; run 'bkpdir upload --resume' to continue", job.name), 1, err)
+	}
+	if err != nil {
+		return NewArchiveErrorWithCause(fmt.Sprintf(
+			"Upload of %s failed; run 'bkpdir upload --resume' to retry", job.name), 1, err)
+	}
+	if err := os.Remove(job.journal); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return NewArchiveErrorWithCause("Cannot remove upload session", 1, err)
+	}
+	if job.session.SHA256 != "" {
+		fmt.Fprintf(opts.Output, "Uploaded %s to %s (checksum verified)\n", job.name, job.backend.URL())
+	} else {
+		fmt.Fprintf(opts.Output, "Uploaded %s to %s\n", job.name, job.backend.URL())
+	}
+	return nil
+}
+
+// discardUpload aborts the unfinished upload of job and removes its journal.
+// The remote may already have discarded the upload, so abort errors only
+// warn.
+func discardUpload(ctx context.Context, job *uploadJob) {
+	if job.session.UploadID != "" {
+		err := job.backend.AbortUpload(ctx, job.name, job.session.UploadID)
+		if err != nil && !errors.Is(err, remote.ErrUploadNotFound) {
+			fmt.Fprintf(os.Stderr, "Warning: cannot discard upload of %s: %v\n", job.name, err)
+		}
+	}
+	os.Remove(job.journal)
+}
+
+// uploadJournalPath returns the journal of the upload of name to a remote.
+func uploadJournalPath(journalDir, remoteURL, name string) string {
+	sum := sha256.Sum256([]byte(remoteURL + "\x00" + name))
+	return filepath.Join(journalDir, hex.EncodeToString(sum[:8])+".json")
+}
+
+// readUploadSession loads a journaled session.
+func readUploadSession(path string) (*remote.Session, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s remote.Session
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// writeUploadSession replaces the journal of a session atomically, so an
+// interruption leaves either the previous or the new state.
+func writeUploadSession(path string, s *remote.Session) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := fileops.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	return fileops.AtomicWriteFile(path, data, 0o600)
+}
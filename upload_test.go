@@ -0,0 +1,128 @@
+// This file is part of bkpdir
+
+// Package main provides tests for uploading archives to remotes.
+// It verifies that an interrupted upload is refused without --resume,
+// continues with it after the stored parts, and that uploaded archives are
+// skipped.
+package main
+
+import (
+	"bytes"
+	"context"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// cancelWriter cancels an upload when it reports progress.
+type cancelWriter struct {
+	bytes.Buffer
+	cancel context.CancelFunc
+}
+
+func (w *cancelWriter) Write(p []byte) (int, error) {
+	if bytes.HasPrefix(p, []byte("Uploading ")) {
+		w.cancel()
+	}
+	return w.Buffer.Write(p)
+}
+
+// uploadTestConfig returns a configuration that keeps archives in
+// archiveDir. The archive directory is resolved from the working directory,
+// which earlier tests may have removed, so the test runs in a fresh one.
+func uploadTestConfig(t *testing.T, archiveDir string) *Config {
+	t.Helper()
+	if wd, err := os.Getwd(); err == nil {
+		t.Cleanup(func() { os.Chdir(wd) })
+	}
+	if err := os.Chdir(t.TempDir()); err != nil {
+		t.Fatal(err)
+	}
+	cfg := DefaultConfig()
+	cfg.ArchiveDirPath = archiveDir
+	cfg.UseCurrentDirName = false
+	return cfg
+}
+
+// ⭐ REMOTE-001: Resumable uploads - 🧪
+func TestUploadArchivesResume(t *testing.T) {
+	archiveDir, remoteDir := t.TempDir(), t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.Remote = &RemoteConfig{UploadPartSize: "5MB"}
+
+	data := make([]byte, 12<<20)
+	rand.New(rand.NewSource(1)).Read(data)
+	archive := filepath.Join(archiveDir, "project-2024-03-20-14-30.zip")
+	if err := os.WriteFile(archive, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &cancelWriter{cancel: cancel}
+	err := UploadArchives(UploadOptions{Config: cfg, Output: out, Context: ctx, Remote: remoteDir})
+	if err == nil || !strings.Contains(err.Error(), "--resume") {
+		t.Fatalf("interrupted upload: err = %v, want a hint to resume", err)
+	}
+	if _, err := os.Stat(filepath.Join(remoteDir, filepath.Base(archive))); !os.IsNotExist(err) {
+		t.Fatalf("interrupted upload stored the archive: %v", err)
+	}
+
+	var buf bytes.Buffer
+	err = UploadArchives(UploadOptions{Config: cfg, Output: &buf, Remote: remoteDir})
+	if err == nil || !strings.Contains(err.Error(), "interrupted upload") {
+		t.Fatalf("upload over a session: err = %v, want a refusal", err)
+	}
+
+	buf.Reset()
+	if err := UploadArchives(UploadOptions{Config: cfg, Output: &buf, Resume: true}); err != nil {
+		t.Fatalf("resume: %v", err)
+	}
+	if !strings.Contains(buf.String(), "Resuming upload of project-2024-03-20-14-30.zip at 5.0MB") {
+		t.Errorf("resume did not continue after the stored part:\n%s", buf.String())
+	}
+	got, err := os.ReadFile(filepath.Join(remoteDir, filepath.Base(archive)))
+	if err != nil || !bytes.Equal(got, data) {
+		t.Fatalf("uploaded archive differs from the local one (err %v)", err)
+	}
+	if entries, _ := os.ReadDir(filepath.Join(archiveDir, uploadSessionDir)); len(entries) != 0 {
+		t.Errorf("session journal left after the upload: %d files", len(entries))
+	}
+
+	buf.Reset()
+	if err := UploadArchives(UploadOptions{Config: cfg, Output: &buf, Remote: remoteDir}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "Already uploaded: project-2024-03-20-14-30.zip") {
+		t.Errorf("uploaded archive was not skipped:\n%s", buf.String())
+	}
+}
+
+// ⭐ REMOTE-001: Restarted uploads - 🧪
+func TestUploadArchivesRestart(t *testing.T) {
+	archiveDir, remoteDir := t.TempDir(), t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	writeTestZip(t, filepath.Join(archiveDir, "a.zip"), map[string]string{"a.txt": "a"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	out := &cancelWriter{cancel: cancel}
+	if err := UploadArchives(UploadOptions{Config: cfg, Output: out, Context: ctx, Remote: remoteDir}); err == nil {
+		t.Fatal("interrupted upload succeeded")
+	}
+
+	var buf bytes.Buffer
+	err := UploadArchives(UploadOptions{Config: cfg, Output: &buf, Remote: remoteDir, Resume: true, Restart: true})
+	if err == nil {
+		t.Fatal("--resume with --restart was accepted")
+	}
+	if err := UploadArchives(UploadOptions{Config: cfg, Output: &buf, Remote: remoteDir, Restart: true}); err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	if strings.Contains(buf.String(), "Resuming") {
+		t.Errorf("restart resumed the old upload:\n%s", buf.String())
+	}
+	if entries, _ := os.ReadDir(filepath.Join(remoteDir, ".uploads")); len(entries) != 0 {
+		t.Errorf("restart left %d abandoned uploads on the remote", len(entries))
+	}
+}
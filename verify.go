@@ -9,14 +9,23 @@ package main
 
 import (
 	"archive/zip"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"math"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
+
+	"bkpdir/pkg/fileops"
+	"bkpdir/pkg/processing"
 )
 
 // VerificationStatus represents the result of an archive verification
@@ -25,6 +34,11 @@ type VerificationStatus struct {
 	IsVerified   bool      `json:"is_verified"`
 	HasChecksums bool      `json:"has_checksums"`
 	Errors       []string  `json:"errors,omitempty"`
+	// ⭐ ARCH-006: Sampling details, zero for full verification
+	SampledEntries int `json:"sampled_entries,omitempty"`
+	TotalEntries   int `json:"total_entries,omitempty"`
+	// ⭐ VERIFY-QUICK-001: Structural check only, never stored as a verification
+	Quick bool `json:"-"`
 }
 
 // VerifyArchive verifies the integrity of an archive
@@ -56,6 +70,108 @@ func VerifyArchive(archivePath string) (*VerificationStatus, error) {
 	return status, nil
 }
 
+// ⭐ LIST-VERIFY-001: Structural archive check - 🛡️
+// CheckArchiveStructure runs the checks of VerifyArchiveQuick and returns the
+// first problem found. It is much cheaper than VerifyArchive and catches
+// truncated or overwritten archives.
+func CheckArchiveStructure(archivePath string) error {
+	status, err := VerifyArchiveQuick(archivePath)
+	if err != nil {
+		return err
+	}
+	if !status.IsVerified {
+		return errors.New(status.Errors[0])
+	}
+	return nil
+}
+
+// ⭐ VERIFY-QUICK-001: Quick structural verification - 🛡️
+// VerifyArchiveQuick checks the structure of an archive without
+// decompressing any entry: the ZIP central directory must be readable, the
+// local header of every entry must be where the central directory says and
+// its data must end within the file, and the manifest in .metadata, when
+// there is one, must decode and only list files the archive holds, with
+// their sizes. Only headers are read, so an archive takes milliseconds
+// whatever its size.
+func VerifyArchiveQuick(archivePath string) (*VerificationStatus, error) {
+	status := &VerificationStatus{
+		VerifiedAt: time.Now(),
+		IsVerified: true,
+		Quick:      true,
+	}
+	fail := func(format string, args ...interface{}) {
+		status.IsVerified = false
+		status.Errors = append(status.Errors, fmt.Sprintf(format, args...))
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		fail("Failed to open archive: %v", err)
+		return status, nil
+	}
+	defer reader.Close()
+	info, err := os.Stat(archivePath)
+	if err != nil {
+		return nil, err
+	}
+
+	sizes := make(map[string]uint64, len(reader.File))
+	for _, file := range reader.File {
+		sizes[file.Name] = file.UncompressedSize64
+		offset, err := file.DataOffset()
+		if err != nil {
+			fail("Bad local header for %s: %v", file.Name, err)
+			continue
+		}
+		if end := offset + int64(file.CompressedSize64); end > info.Size() {
+			fail("Data of %s ends at byte %d, past the end of the archive (%d bytes)", file.Name, end, info.Size())
+		}
+	}
+
+	manifest, err := LoadArchiveManifest(archivePath)
+	if err != nil {
+		fail("Unreadable manifest: %v", err)
+		return status, nil
+	}
+	if manifest != nil {
+		for _, f := range manifest.Files {
+			size, ok := sizes[f.Path]
+			switch {
+			case !ok:
+				fail("Manifest lists %s, which is not in the archive", f.Path)
+			case f.Size >= 0 && uint64(f.Size) != size:
+				fail("Manifest records %d bytes for %s, the archive %d", f.Size, f.Path, size)
+			}
+		}
+	}
+	return status, nil
+}
+
+// ⭐ LIST-VERIFY-001: Budgeted inline verification - 🔍
+// checkArchiveStructures runs CheckArchiveStructure on archives that have no
+// recorded verification, in order, until budget is spent. It returns the
+// number of archives left unchecked. Results are only kept for display; the
+// stored verification status is not changed.
+func checkArchiveStructures(archives []Archive, budget time.Duration) int {
+	deadline := time.Now().Add(budget)
+	skipped := 0
+	for i := range archives {
+		// ⭐ TIER-001: Archives in cold storage are not read
+		if archives[i].VerificationStatus != nil || archives[i].Location != "" {
+			continue
+		}
+		if budget > 0 && !time.Now().Before(deadline) {
+			skipped++
+			continue
+		}
+		archives[i].StructureChecked = true
+		if err := CheckArchiveStructure(archives[i].Path); err != nil {
+			archives[i].StructureError = err.Error()
+		}
+	}
+	return skipped
+}
+
 // verifyFile verifies a single file in the archive
 func verifyFile(file *zip.File) error {
 	// Individual file verification
@@ -113,32 +229,33 @@ func StoreChecksums(archive *Archive, checksums map[string]string) error {
 	// Checksum storage in archive
 	// DECISION-REF: DEC-001, DEC-008
 	// Create a temporary file for checksums
-	tmpFile, err := createChecksumsTempFile(checksums)
+	tmpFile, err := createChecksumsTempFile(filepath.Dir(archive.Path), checksums)
 	if err != nil {
 		return err
 	}
-	defer os.Remove(tmpFile.Name())
+	defer fileops.Remove(tmpFile.Name())
 
 	// Create a new archive with the checksums file
 	newPath := archive.Path + ".new"
 	if err := createNewArchiveWithChecksums(archive.Path, newPath, tmpFile.Name()); err != nil {
 		return err
 	}
-	defer os.Remove(newPath)
+	defer fileops.Remove(newPath)
 
 	// Replace the original archive with the new one
-	if err := os.Rename(newPath, archive.Path); err != nil {
+	if err := fileops.Rename(newPath, archive.Path); err != nil {
 		return fmt.Errorf("failed to replace original archive: %w", err)
 	}
 
 	return nil
 }
 
-// createChecksumsTempFile creates a temporary file containing the checksums
-func createChecksumsTempFile(checksums map[string]string) (*os.File, error) {
+// createChecksumsTempFile creates a temporary file containing the checksums.
+// It is created in dir, the archive directory, so read-only mode allows it.
+func createChecksumsTempFile(dir string, checksums map[string]string) (*os.File, error) {
 	// Temporary checksum file creation
 	// DECISION-REF: DEC-008
-	tmpFile, err := os.CreateTemp("", "bkpdir-checksums-*.json")
+	tmpFile, err := fileops.CreateTemp(dir, ".bkpdir-checksums-*.json")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create temporary file: %w", err)
 	}
@@ -146,12 +263,12 @@ func createChecksumsTempFile(checksums map[string]string) (*os.File, error) {
 	encoder := json.NewEncoder(tmpFile)
 	if err := encoder.Encode(checksums); err != nil {
 		tmpFile.Close()
-		os.Remove(tmpFile.Name())
+		fileops.Remove(tmpFile.Name())
 		return nil, fmt.Errorf("failed to encode checksums: %w", err)
 	}
 
 	if err := tmpFile.Close(); err != nil {
-		os.Remove(tmpFile.Name())
+		fileops.Remove(tmpFile.Name())
 		return nil, fmt.Errorf("failed to close temporary file: %w", err)
 	}
 
@@ -170,7 +287,7 @@ func createNewArchiveWithChecksums(archivePath, newPath, checksumsPath string) e
 	defer reader.Close()
 
 	// Create the new archive
-	writer, err := os.Create(newPath)
+	writer, err := fileops.Create(newPath)
 	if err != nil {
 		return fmt.Errorf("failed to create new archive: %w", err)
 	}
@@ -281,6 +398,36 @@ func findChecksumsFile(reader *zip.ReadCloser) (*zip.File, error) {
 	return nil, fmt.Errorf("checksums file not found in archive")
 }
 
+// ⭐ ARCH-002: Stored checksums with fallbacks - 🔍
+// storedEntryChecksums returns the SHA-256 checksums to check the entries of
+// an archive against: its .checksums entry, otherwise the file hashes of its
+// manifest (recorded with manifest_file_hashes). It returns nil when the
+// archive has neither; its entries are then only checked against their CRC-32.
+func storedEntryChecksums(reader *zip.ReadCloser, archivePath string) (map[string]string, error) {
+	if file, err := findChecksumsFile(reader); err == nil {
+		return readChecksumsFromFile(file)
+	}
+	manifest, err := LoadArchiveManifest(archivePath)
+	if err != nil || manifest == nil || len(manifest.Files) == 0 {
+		return nil, err
+	}
+	checksums := make(map[string]string, len(manifest.Files))
+	for _, f := range manifest.Files {
+		checksums[f.Path] = f.SHA256
+	}
+	return checksums, nil
+}
+
+// verifyEntry checks an entry against checksums. Directories, and every
+// entry when there are no stored checksums, are read completely so their
+// CRC-32 is checked.
+func verifyEntry(file *zip.File, checksums map[string]string, progress processing.VerificationProgressCallback) error {
+	if checksums == nil || strings.HasSuffix(file.Name, "/") {
+		return readEntryFully(file, progress)
+	}
+	return verifyFileChecksum(file, checksums, progress)
+}
+
 // readChecksumsFromFile reads checksums from a file in the archive
 func readChecksumsFromFile(file *zip.File) (map[string]string, error) {
 	// Checksum data extraction from file
@@ -299,37 +446,13 @@ func readChecksumsFromFile(file *zip.File) (map[string]string, error) {
 	return checksums, nil
 }
 
-// VerifyChecksums verifies file checksums against stored values
+// VerifyChecksums verifies file checksums against stored values, stopping at
+// the first mismatch.
 func VerifyChecksums(archivePath string) (*VerificationStatus, error) {
 	// ⭐ ARCH-002: Complete checksum verification process - 🔍
 	// DECISION-REF: DEC-001
-	status := &VerificationStatus{
-		VerifiedAt: time.Now(),
-		IsVerified: true,
-	}
-
-	reader, err := zip.OpenReader(archivePath)
-	if err != nil {
-		return handleVerificationError(status, "Failed to open archive: %v", err)
-	}
-	defer reader.Close()
-
-	checksumFile, err := findChecksumsFile(reader)
-	if err != nil {
-		return handleVerificationError(status, "Checksums file not found in archive")
-	}
-
-	storedChecksums, err := readChecksumsFromFile(checksumFile)
-	if err != nil {
-		return handleVerificationError(status, "Failed to read checksums: %v", err)
-	}
-
-	if err := verifyArchiveChecksums(reader, storedChecksums, status); err != nil {
-		return handleVerificationError(status, err.Error())
-	}
-
-	status.HasChecksums = true
-	return status, nil
+	// ⭐ VERIFY-PROGRESS-001: Shares the streamed verification
+	return VerifyChecksumsWithProgress(archivePath, ChecksumProgress{FailFast: true})
 }
 
 // handleVerificationError handles verification errors
@@ -345,27 +468,14 @@ func handleVerificationError(
 	return status, nil
 }
 
-// verifyArchiveChecksums verifies checksums for all files in the archive
-func verifyArchiveChecksums(
-	reader *zip.ReadCloser,
-	storedChecksums map[string]string,
-	status *VerificationStatus,
-) error {
-	// Archive-wide checksum verification
-	for _, file := range reader.File {
-		if file.Name == ".checksums" {
-			continue
-		}
-
-		if err := verifyFileChecksum(file, storedChecksums, status); err != nil {
-			return err
-		}
+// verifyFileChecksum verifies the checksum of a single file, reporting the
+// bytes hashed so far to progress if it is set
+func verifyFileChecksum(file *zip.File, storedChecksums map[string]string, progress processing.VerificationProgressCallback) error {
+	storedChecksum, exists := storedChecksums[file.Name]
+	if !exists {
+		return fmt.Errorf("no stored checksum for %s", file.Name)
 	}
-	return nil
-}
 
-// verifyFileChecksum verifies the checksum of a single file
-func verifyFileChecksum(file *zip.File, storedChecksums map[string]string, _ *VerificationStatus) error {
 	// Individual file checksum verification
 	rc, err := file.Open()
 	if err != nil {
@@ -373,18 +483,13 @@ func verifyFileChecksum(file *zip.File, storedChecksums map[string]string, _ *Ve
 	}
 	defer rc.Close()
 
-	hash := sha256.New()
-	if _, err := io.Copy(hash, rc); err != nil {
+	// ⭐ EXTRACT-011: Hash through the streaming verifier so large entries report progress
+	result, err := processing.NewVerificationManager().VerifyWithAlgorithmStream(context.Background(), rc, storedChecksum, "sha256",
+		processing.StreamOptions{TotalBytes: int64(file.UncompressedSize64), Progress: progress})
+	if err != nil {
 		return fmt.Errorf("failed to calculate checksum for %s: %v", file.Name, err)
 	}
-
-	calculatedChecksum := hex.EncodeToString(hash.Sum(nil))
-	storedChecksum, exists := storedChecksums[file.Name]
-
-	if !exists {
-		return fmt.Errorf("no stored checksum for %s", file.Name)
-	}
-	if calculatedChecksum != storedChecksum {
+	if !result.IsValid {
 		return fmt.Errorf("checksum mismatch for %s", file.Name)
 	}
 
@@ -396,12 +501,12 @@ func StoreVerificationStatus(archive *Archive, status *VerificationStatus) error
 	// ⭐ ARCH-002: Verification status persistence - 🔧
 	// DECISION-REF: DEC-008
 	metadataDir := filepath.Join(filepath.Dir(archive.Path), ".metadata")
-	if err := os.MkdirAll(metadataDir, 0o755); err != nil {
+	if err := fileops.MkdirAll(metadataDir, 0o755); err != nil {
 		return fmt.Errorf("failed to create metadata directory: %w", err)
 	}
 
 	metadataPath := filepath.Join(metadataDir, archive.Name+".json")
-	file, err := os.Create(metadataPath)
+	file, err := fileops.Create(metadataPath)
 	if err != nil {
 		return fmt.Errorf("failed to create metadata file: %w", err)
 	}
@@ -439,3 +544,146 @@ func LoadVerificationStatus(archive *Archive) (*VerificationStatus, error) {
 
 	return &status, nil
 }
+
+// ⭐ ARCH-006: Sampled verification specification - 🔍
+// SampleSpec describes how many archive entries to verify: either a percentage
+// of the entries in each archive or a fixed entry count.
+type SampleSpec struct {
+	Percent float64
+	Count   int
+}
+
+// ParseSampleSpec parses a --sample value such as "10%" or "200".
+func ParseSampleSpec(value string) (SampleSpec, error) {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, "%") {
+		percent, err := strconv.ParseFloat(strings.TrimSuffix(value, "%"), 64)
+		if err != nil || percent <= 0 || percent > 100 {
+			return SampleSpec{}, fmt.Errorf("invalid sample percentage %q: must be in (0%%, 100%%]", value)
+		}
+		return SampleSpec{Percent: percent}, nil
+	}
+
+	count, err := strconv.Atoi(value)
+	if err != nil || count <= 0 {
+		return SampleSpec{}, fmt.Errorf("invalid sample size %q: use a percentage like 10%% or a positive count", value)
+	}
+	return SampleSpec{Count: count}, nil
+}
+
+// size returns the number of entries to sample out of total, at least one
+// when total is non-zero.
+func (s SampleSpec) size(total int) int {
+	n := s.Count
+	if s.Percent > 0 {
+		n = int(math.Ceil(float64(total) * s.Percent / 100))
+	}
+	if n < 1 {
+		n = 1
+	}
+	if n > total {
+		n = total
+	}
+	return n
+}
+
+// ⭐ ARCH-006: Sample confidence reporting - 📝
+// SampleConfidenceBound returns the largest fraction of corrupt entries that a
+// clean sample of the given size would miss with at most 5% probability.
+// A full sample bounds the corruption rate at zero.
+func SampleConfidenceBound(sampled, total int) float64 {
+	if sampled <= 0 {
+		return 1
+	}
+	if sampled >= total {
+		return 0
+	}
+	return 1 - math.Pow(0.05, 1/float64(sampled))
+}
+
+// ⭐ ARCH-006: Sampled archive verification - 🔍
+// VerifyArchiveSampled verifies a random sample of the entries in an archive.
+// Sampled entries are read completely so their CRC-32 is checked; with
+// withChecksum they are also compared against the stored SHA-256 checksums
+// when the archive has any.
+func VerifyArchiveSampled(archivePath string, withChecksum bool, spec SampleSpec, rng *rand.Rand) (*VerificationStatus, error) {
+	status := &VerificationStatus{
+		VerifiedAt: time.Now(),
+		IsVerified: true,
+	}
+
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return handleVerificationError(status, "Failed to open archive: %v", err)
+	}
+	defer reader.Close()
+
+	var storedChecksums map[string]string
+	if withChecksum {
+		storedChecksums, err = storedEntryChecksums(reader, archivePath)
+		if err != nil {
+			return handleVerificationError(status, "Failed to read checksums: %v", err)
+		}
+		status.HasChecksums = storedChecksums != nil
+	}
+
+	entries := make([]*zip.File, 0, len(reader.File))
+	for _, file := range reader.File {
+		if file.Name != ".checksums" {
+			entries = append(entries, file)
+		}
+	}
+
+	sample := selectSampleEntries(entries, spec, rng)
+	status.TotalEntries = len(entries)
+	status.SampledEntries = len(sample)
+
+	for _, file := range sample {
+		if verifyErr := verifyEntry(file, storedChecksums, nil); verifyErr != nil {
+			status.IsVerified = false
+			status.Errors = append(status.Errors, verifyErr.Error())
+		}
+	}
+
+	return status, nil
+}
+
+// selectSampleEntries picks a uniform random subset of entries without replacement.
+func selectSampleEntries(entries []*zip.File, spec SampleSpec, rng *rand.Rand) []*zip.File {
+	if len(entries) == 0 {
+		return nil
+	}
+	if rng == nil {
+		rng = rand.New(rand.NewSource(time.Now().UnixNano()))
+	}
+	n := spec.size(len(entries))
+	picked := make([]*zip.File, 0, n)
+	for _, i := range rng.Perm(len(entries))[:n] {
+		picked = append(picked, entries[i])
+	}
+	return picked
+}
+
+// readEntryFully reads an archive entry to EOF, which makes archive/zip
+// validate the entry's CRC-32, reporting the bytes read so far to progress if
+// it is set.
+func readEntryFully(file *zip.File, progress processing.VerificationProgressCallback) error {
+	rc, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("failed to open file %s: %v", file.Name, err)
+	}
+	defer rc.Close()
+
+	if progress == nil {
+		_, err = io.Copy(io.Discard, rc)
+	} else {
+		// ⭐ EXTRACT-011: Read through the streaming verifier so large entries report progress
+		reader := processing.NewSHA256Verifier().(processing.StreamingVerifierInterface)
+		_, err = reader.CalculateStream(context.Background(), rc,
+			processing.StreamOptions{TotalBytes: int64(file.UncompressedSize64), Progress: progress})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read file %s: %v", file.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// This file is part of bkpdir
+//
+// Package main provides comparison of a directory tree against the entries of
+// an archive, used to audit that a restore (or the original tree) matches.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ⭐ VERIFY-DIR-001: Tree comparison result - 📝
+// DirComparison lists the differences between a directory and an archive.
+// Paths are slash-separated and relative to the directory.
+type DirComparison struct {
+	Missing    []string // in the archive but not in the directory
+	Extra      []string // in the directory but not in the archive
+	Mismatched []string // present in both with different content
+	Compared   int      // archive entries checked
+}
+
+// Matches reports whether the directory matches the archive.
+func (c *DirComparison) Matches() bool {
+	return len(c.Missing) == 0 && len(c.Extra) == 0 && len(c.Mismatched) == 0
+}
+
+// Details returns one line per difference for reporting.
+func (c *DirComparison) Details() []string {
+	var details []string
+	for _, p := range c.Missing {
+		details = append(details, "missing: "+p)
+	}
+	for _, p := range c.Extra {
+		details = append(details, "extra: "+p)
+	}
+	for _, p := range c.Mismatched {
+		details = append(details, "content differs: "+p)
+	}
+	return details
+}
+
+// ⭐ VERIFY-DIR-001: Archive to directory comparison - 🛡️
+// CompareArchiveToDir compares the file entries of the archive at archivePath
+// with the files under dir. Contents are compared by SHA-256. Files matching
+// excludePatterns are never reported as extra, so the original tree can be
+// audited as well as a restore. Incremental archives only hold changed files,
+// so extra files are not reported for them.
+func CompareArchiveToDir(archivePath, dir string, excludePatterns []string) (*DirComparison, error) {
+	reader, err := zip.OpenReader(archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %w", err)
+	}
+	defer reader.Close()
+
+	result := &DirComparison{}
+	inArchive := make(map[string]bool, len(reader.File))
+	for _, entry := range reader.File {
+		if entry.FileInfo().IsDir() || entry.Name == ".checksums" {
+			continue
+		}
+		name := strings.TrimPrefix(entry.Name, "./")
+		inArchive[name] = true
+		result.Compared++
+
+		same, err := compareEntryWithFile(entry, filepath.Join(dir, filepath.FromSlash(name)))
+		switch {
+		case os.IsNotExist(err):
+			result.Missing = append(result.Missing, name)
+		case err != nil:
+			return nil, err
+		case !same:
+			result.Mismatched = append(result.Mismatched, name)
+		}
+	}
+
+	if !strings.Contains(filepath.Base(archivePath), "_update=") {
+		err = filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil || rel == "." || info.IsDir() {
+				return err
+			}
+			name := filepath.ToSlash(rel)
+			if !inArchive[name] && !ShouldExcludeFile(rel, excludePatterns) {
+				result.Extra = append(result.Extra, name)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan directory: %w", err)
+		}
+	}
+
+	sort.Strings(result.Missing)
+	sort.Strings(result.Extra)
+	sort.Strings(result.Mismatched)
+	return result, nil
+}
+
+// compareEntryWithFile reports whether the file at path has the entry's
+// content. A missing file is returned as an os.IsNotExist error.
+func compareEntryWithFile(entry *zip.File, path string) (bool, error) {
+	if entry.Mode()&os.ModeSymlink != 0 {
+		return compareLinkEntry(entry, path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.IsDir() || uint64(info.Size()) != entry.UncompressedSize64 {
+		return false, nil
+	}
+
+	rc, err := entry.Open()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from archive: %w", entry.Name, err)
+	}
+	defer rc.Close()
+	archived := sha256.New()
+	if _, err := io.Copy(archived, rc); err != nil {
+		return false, fmt.Errorf("failed to read %s from archive: %w", entry.Name, err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer f.Close()
+	onDisk := sha256.New()
+	if _, err := io.Copy(onDisk, f); err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	return bytes.Equal(archived.Sum(nil), onDisk.Sum(nil)), nil
+}
+
+// compareLinkEntry reports whether path is a symbolic link to the target
+// stored as the content of entry.
+func compareLinkEntry(entry *zip.File, path string) (bool, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return false, err
+	}
+	if info.Mode()&os.ModeSymlink == 0 {
+		return false, nil
+	}
+	rc, err := entry.Open()
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from archive: %w", entry.Name, err)
+	}
+	defer rc.Close()
+	target, err := io.ReadAll(rc)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s from archive: %w", entry.Name, err)
+	}
+	onDisk, err := os.Readlink(path)
+	if err != nil {
+		return false, err
+	}
+	return string(target) == onDisk, nil
+}
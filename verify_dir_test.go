@@ -0,0 +1,134 @@
+// This file is part of bkpdir
+
+// Package main provides tests for comparing directory trees with archives.
+// It verifies detection of missing, extra and changed files.
+package main
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// ⭐ VERIFY-DIR-001: Directory audit tests - 🛡️
+func TestCompareArchiveToDir(t *testing.T) {
+	tempDir := t.TempDir()
+	archivePath := filepath.Join(tempDir, "proj-2024-01-01-10-00.zip")
+	entries := map[string]string{
+		"same.txt":       "unchanged",
+		"changed.txt":    "original",
+		"resized.txt":    "short",
+		"sub/gone.txt":   "deleted later",
+		"sub/nested.txt": "nested",
+	}
+	writeTestZip(t, archivePath, entries)
+
+	dir := filepath.Join(tempDir, "restored")
+	files := map[string]string{
+		"same.txt":       "unchanged",
+		"changed.txt":    "modified",
+		"resized.txt":    "much longer now",
+		"sub/nested.txt": "nested",
+		"new.txt":        "extra",
+		"debug.log":      "excluded",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	result, err := CompareArchiveToDir(archivePath, dir, []string{"*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Matches() || result.Compared != len(entries) {
+		t.Fatalf("unexpected result %+v", result)
+	}
+	if want := []string{"sub/gone.txt"}; !reflect.DeepEqual(result.Missing, want) {
+		t.Errorf("Missing = %v, want %v", result.Missing, want)
+	}
+	if want := []string{"new.txt"}; !reflect.DeepEqual(result.Extra, want) {
+		t.Errorf("Extra = %v, want %v", result.Extra, want)
+	}
+	if want := []string{"changed.txt", "resized.txt"}; !reflect.DeepEqual(result.Mismatched, want) {
+		t.Errorf("Mismatched = %v, want %v", result.Mismatched, want)
+	}
+
+	// Incremental archives hold only changed files, so extras are ignored
+	incPath := filepath.Join(tempDir, "proj-2024-01-01-10-00_update=2024-01-02-10-00.zip")
+	writeTestZip(t, incPath, map[string]string{"same.txt": "unchanged"})
+	result, err = CompareArchiveToDir(incPath, dir, nil)
+	if err != nil || !result.Matches() {
+		t.Errorf("Expected incremental archive to match, got %+v (%v)", result, err)
+	}
+}
+
+// ⭐ VERIFY-DIR-001: Symbolic links are compared by target - 🛡️
+func TestCompareArchiveToDirSymlink(t *testing.T) {
+	tempDir := t.TempDir()
+	dir := filepath.Join(tempDir, "src")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "target.txt"), []byte("target content"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink("target.txt", filepath.Join(dir, "link.txt")); err != nil {
+		t.Skip("symbolic links not supported:", err)
+	}
+
+	archivePath := filepath.Join(tempDir, "src-2024-01-01-10-00.zip")
+	f, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	w, _ := zw.Create("target.txt")
+	w.Write([]byte("target content"))
+	hdr := &zip.FileHeader{Name: "link.txt"}
+	hdr.SetMode(os.ModeSymlink | 0o777)
+	w, _ = zw.CreateHeader(hdr)
+	w.Write([]byte("target.txt"))
+	zw.Close()
+	f.Close()
+
+	result, err := CompareArchiveToDir(archivePath, dir, nil)
+	if err != nil || !result.Matches() {
+		t.Fatalf("Expected link to match, got %+v (%v)", result, err)
+	}
+	os.Remove(filepath.Join(dir, "link.txt"))
+	os.Symlink("elsewhere.txt", filepath.Join(dir, "link.txt"))
+	result, err = CompareArchiveToDir(archivePath, dir, nil)
+	if err != nil || len(result.Mismatched) != 1 {
+		t.Errorf("Expected retargeted link to differ, got %+v (%v)", result, err)
+	}
+}
+
+func writeTestZip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	zw := zip.NewWriter(f)
+	for name, content := range entries {
+		w, err := zw.Create(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,181 @@
+// This file is part of bkpdir
+
+// Package main provides tests for streamed checksum verification.
+// It verifies that every corrupt entry is reported, that each entry is
+// streamed as it is checked and that --fail-fast stops at the first failure.
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"bkpdir/pkg/processing"
+)
+
+// checksummedTestZip writes an archive whose .checksums entry matches
+// good.txt but not bad1.txt and bad2.txt.
+func checksummedTestZip(t *testing.T) string {
+	t.Helper()
+	entries := map[string]string{"bad1.txt": "one", "good.txt": "fine", "bad2.txt": "two"}
+	sums := make(map[string]string)
+	for name, content := range entries {
+		sum := sha256.Sum256([]byte(content))
+		sums[name] = hex.EncodeToString(sum[:])
+	}
+	sums["bad1.txt"] = strings.Repeat("0", 64)
+	sums["bad2.txt"] = strings.Repeat("0", 64)
+	data, _ := json.Marshal(sums)
+	entries[".checksums"] = string(data)
+
+	path := filepath.Join(t.TempDir(), "test.zip")
+	writeTestZip(t, path, entries)
+	return path
+}
+
+// ⭐ VERIFY-PROGRESS-001: Streamed results and fail-fast - 🧪
+func TestVerifyChecksumsWithProgress(t *testing.T) {
+	path := checksummedTestZip(t)
+
+	var results []EntryResult
+	var lastDone, lastTotal int
+	status, err := VerifyChecksumsWithProgress(path, ChecksumProgress{Report: func(done, total int, r EntryResult) {
+		results = append(results, r)
+		lastDone, lastTotal = done, total
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.IsVerified || status.HasChecksums || len(status.Errors) != 2 {
+		t.Errorf("Expected both corrupt entries reported, got %+v", status)
+	}
+	if len(results) != 3 || lastDone != 3 || lastTotal != 3 {
+		t.Errorf("Expected 3 of 3 entries streamed, got %d (%d/%d)", len(results), lastDone, lastTotal)
+	}
+
+	results = nil
+	status, err = VerifyChecksumsWithProgress(path, ChecksumProgress{FailFast: true, Report: func(_, _ int, r EntryResult) {
+		results = append(results, r)
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var failed int
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	if len(status.Errors) != 1 || failed != 1 || results[len(results)-1].Err == nil {
+		t.Errorf("Expected fail-fast to stop at the first corrupt entry, got %v after %d entries", status.Errors, len(results))
+	}
+
+	// VerifyChecksums keeps stopping at the first mismatch
+	if status, err := VerifyChecksums(path); err != nil || len(status.Errors) != 1 {
+		t.Errorf("Expected VerifyChecksums to report one mismatch, got %+v, %v", status, err)
+	}
+}
+
+// ⭐ VERIFY-PROGRESS-001: Entry lines and progress bar - 🧪
+func TestVerifyProgressPrinter(t *testing.T) {
+	var out, bar bytes.Buffer
+	p := &verifyProgressPrinter{out: &out, bar: &bar, archive: "a.zip"}
+	p.Report(1, 2, EntryResult{Path: "x.txt"})
+	p.Report(2, 2, EntryResult{Path: "y.txt", Err: errors.New("checksum mismatch for y.txt")})
+	p.Finish()
+
+	if want := "ok    x.txt\nFAIL  y.txt: checksum mismatch for y.txt\n"; out.String() != want {
+		t.Errorf("Expected entry lines %q, got %q", want, out.String())
+	}
+	if !strings.Contains(bar.String(), "100% 2/2") || !strings.HasSuffix(bar.String(), "\r\033[K") {
+		t.Errorf("Expected a completed bar that is cleared at the end, got %q", bar.String())
+	}
+	if got := renderProgressBar("a.zip", 1, 4); got != "\rVerifying a.zip ["+strings.Repeat("=", 7)+strings.Repeat(" ", 23)+"] 25% 1/4" {
+		t.Errorf("Unexpected progress bar %q", got)
+	}
+}
+
+// ⭐ EXTRACT-011: Progress within large entries - 🧪
+func TestVerifyChecksumsReading(t *testing.T) {
+	path := checksummedTestZip(t)
+	var updates []processing.VerificationProgress
+	if _, err := VerifyChecksumsWithProgress(path, ChecksumProgress{Reading: func(done, total int, p processing.VerificationProgress) {
+		if total != 3 || done > 2 {
+			t.Errorf("Reading(%d, %d) outside the entries", done, total)
+		}
+		updates = append(updates, p)
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	if len(updates) < 3 || !updates[len(updates)-1].Done {
+		t.Errorf("Expected a final update per entry, got %+v", updates)
+	}
+
+	if got := renderEntryProgressBar("a.zip", 1, 4, 0.5); got != "\rVerifying a.zip ["+strings.Repeat("=", 11)+strings.Repeat(" ", 19)+"] 37% 1/4" {
+		t.Errorf("Unexpected progress bar %q", got)
+	}
+}
+
+// ⭐ VERIFY-PROGRESS-001: Streamed verification of archives made by create - 🧪
+func TestVerifyProgressCreatedArchive(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		os.WriteFile(name, bytes.Repeat([]byte(name), 4096), 0o644)
+	}
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ := listArchiveEntries(archiveDir)
+	if len(archives) != 1 {
+		t.Fatalf("archives = %+v", archives)
+	}
+	path := archives[0].Path
+
+	verify := func(failFast bool) (*VerificationStatus, []EntryResult, int) {
+		var results []EntryResult
+		var reading int
+		status, err := VerifyChecksumsWithProgress(path, ChecksumProgress{
+			FailFast: failFast,
+			Report:   func(_, _ int, r EntryResult) { results = append(results, r) },
+			Reading:  func(int, int, processing.VerificationProgress) { reading++ },
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return status, results, reading
+	}
+	status, results, reading := verify(false)
+	if !status.IsVerified || len(results) != 3 || reading < 3 {
+		t.Fatalf("Expected 3 verified entries with progress, got %+v, %+v, %d updates", status, results, reading)
+	}
+
+	// Corrupt the data of the first entry
+	r, err := zip.OpenReader(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	offset, err := r.File[0].DataOffset()
+	r.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	data[offset] ^= 0xff
+	data[offset+1] ^= 0xff
+	os.WriteFile(path, data, 0o644)
+
+	if status, results, _ = verify(false); status.IsVerified || len(results) != 3 || results[0].Err == nil {
+		t.Errorf("Expected the corrupt entry reported among 3, got %+v, %+v", status, results)
+	}
+	if status, results, _ = verify(true); len(results) != 1 || len(status.Errors) != 1 {
+		t.Errorf("Expected fail-fast to stop at the corrupt entry, got %+v, %+v", status, results)
+	}
+}
@@ -6,9 +6,14 @@ package main
 
 import (
 	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 // TestArchiveData holds test archive setup data
@@ -191,3 +196,272 @@ func TestVerifyCorruptedArchive(t *testing.T) {
 		t.Errorf("Expected errors for corrupted archive")
 	}
 }
+
+// TestVerifyArchiveSampled tests sampled verification for ARCH-006
+func TestVerifyArchiveSampled(t *testing.T) {
+	// ⭐ ARCH-006: Sampled verification validation - 🔍
+	archivePath := filepath.Join(t.TempDir(), "sample.zip")
+	zipFile, err := os.Create(archivePath)
+	if err != nil {
+		t.Fatalf("Failed to create zip file: %v", err)
+	}
+	zipWriter := zip.NewWriter(zipFile)
+	fileMap := make(map[string]string)
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprintf("file%02d.txt", i)
+		w, err := zipWriter.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to create entry: %v", err)
+		}
+		if _, err := w.Write([]byte("content " + name)); err != nil {
+			t.Fatalf("Failed to write entry: %v", err)
+		}
+		fileMap[name] = name
+	}
+	zipWriter.Close()
+	zipFile.Close()
+
+	rng := rand.New(rand.NewSource(1))
+
+	t.Run("percentage sample", func(t *testing.T) {
+		status, err := VerifyArchiveSampled(archivePath, false, SampleSpec{Percent: 10}, rng)
+		if err != nil {
+			t.Fatalf("VerifyArchiveSampled failed: %v", err)
+		}
+		if !status.IsVerified {
+			t.Errorf("Expected sample to verify, got errors: %v", status.Errors)
+		}
+		if status.SampledEntries != 2 || status.TotalEntries != 20 {
+			t.Errorf("Expected 2 of 20 entries sampled, got %d of %d", status.SampledEntries, status.TotalEntries)
+		}
+	})
+
+	t.Run("checksum sample without checksums", func(t *testing.T) {
+		status, err := VerifyArchiveSampled(archivePath, true, SampleSpec{Count: 5}, rng)
+		if err != nil {
+			t.Fatalf("VerifyArchiveSampled failed: %v", err)
+		}
+		if !status.IsVerified || status.HasChecksums {
+			t.Errorf("Expected a CRC-32 check without stored checksums, got verified=%v checksums=%v errors=%v",
+				status.IsVerified, status.HasChecksums, status.Errors)
+		}
+	})
+
+	t.Run("checksum sample with mismatch", func(t *testing.T) {
+		checksums := make(map[string]string)
+		for name := range fileMap {
+			checksums[name] = "bad"
+		}
+		if err := StoreChecksums(&Archive{Name: "sample.zip", Path: archivePath}, checksums); err != nil {
+			t.Fatalf("StoreChecksums failed: %v", err)
+		}
+		status, err := VerifyArchiveSampled(archivePath, true, SampleSpec{Count: 3}, rng)
+		if err != nil {
+			t.Fatalf("VerifyArchiveSampled failed: %v", err)
+		}
+		if status.IsVerified || len(status.Errors) != 3 {
+			t.Errorf("Expected 3 checksum mismatches, got verified=%v errors=%v", status.IsVerified, status.Errors)
+		}
+		if status.TotalEntries != 20 {
+			t.Errorf("Expected .checksums to be excluded from the entry count, got %d", status.TotalEntries)
+		}
+	})
+}
+
+// TestParseSampleSpec tests --sample value parsing for ARCH-006
+func TestParseSampleSpec(t *testing.T) {
+	// ⭐ ARCH-006: Sample specification parsing - 🔍
+	valid := map[string]SampleSpec{
+		"10%":  {Percent: 10},
+		"100%": {Percent: 100},
+		"250":  {Count: 250},
+	}
+	for input, want := range valid {
+		got, err := ParseSampleSpec(input)
+		if err != nil || got != want {
+			t.Errorf("ParseSampleSpec(%q) = %+v, %v; want %+v", input, got, err, want)
+		}
+	}
+
+	for _, input := range []string{"", "0%", "150%", "-3", "abc", "ten%"} {
+		if _, err := ParseSampleSpec(input); err == nil {
+			t.Errorf("ParseSampleSpec(%q) expected error", input)
+		}
+	}
+
+	if bound := SampleConfidenceBound(20, 20); bound != 0 {
+		t.Errorf("Full sample should bound corruption at 0, got %f", bound)
+	}
+	if bound := SampleConfidenceBound(100, 10000); bound < 0.029 || bound > 0.031 {
+		t.Errorf("Expected ~3%% bound for 100 clean samples, got %f", bound)
+	}
+}
+
+// TestCheckArchiveStructures tests list --verify-inline checks for LIST-VERIFY-001
+func TestCheckArchiveStructures(t *testing.T) {
+	// ⭐ LIST-VERIFY-001: Budgeted structural checks - 🛡️
+	tempDir := t.TempDir()
+	good := filepath.Join(tempDir, "good.zip")
+	writeTestZip(t, good, map[string]string{"a.txt": "alpha"})
+	truncated := filepath.Join(tempDir, "truncated.zip")
+	data, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(truncated, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	archives := []Archive{
+		{Name: "good.zip", Path: good},
+		{Name: "truncated.zip", Path: truncated},
+		{Name: "verified.zip", Path: truncated, VerificationStatus: &VerificationStatus{IsVerified: true}},
+	}
+	if skipped := checkArchiveStructures(archives, 0); skipped != 0 {
+		t.Errorf("Expected no skipped archives without a budget, got %d", skipped)
+	}
+	want := []string{ArchiveStatusReadable, ArchiveStatusFailed, ArchiveStatusVerified}
+	for i, a := range archives {
+		if got := archiveStatus(a); got != want[i] {
+			t.Errorf("%s: status %q, want %q", a.Name, got, want[i])
+		}
+	}
+	if archives[2].StructureChecked {
+		t.Error("Archives with recorded verification should not be checked")
+	}
+
+	// An exhausted budget leaves archives unverified
+	unchecked := []Archive{{Name: "good.zip", Path: good}}
+	if skipped := checkArchiveStructures(unchecked, time.Nanosecond); skipped != 1 {
+		t.Errorf("Expected the archive to be skipped, got %d", skipped)
+	}
+	if got := archiveStatus(unchecked[0]); got != ArchiveStatusUnverified {
+		t.Errorf("Skipped archive status %q, want %q", got, ArchiveStatusUnverified)
+	}
+}
+
+// TestVerifyArchiveQuick tests structural verification for VERIFY-QUICK-001
+func TestVerifyArchiveQuick(t *testing.T) {
+	// ⭐ VERIFY-QUICK-001: Quick structural verification - 🛡️
+	tempDir := t.TempDir()
+	good := filepath.Join(tempDir, "good.zip")
+	writeTestZip(t, good, map[string]string{"a.txt": "alpha"})
+	data, err := os.ReadFile(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	truncated := filepath.Join(tempDir, "truncated.zip")
+	if err := os.WriteFile(truncated, data[:len(data)/2], 0o644); err != nil {
+		t.Fatal(err)
+	}
+	badHeader := filepath.Join(tempDir, "bad-header.zip")
+	corrupt := append([]byte{}, data...)
+	copy(corrupt, "XXXX") // Local header signature of the first entry
+	if err := os.WriteFile(badHeader, corrupt, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := func(path, wantError string) {
+		t.Helper()
+		status, err := VerifyArchiveQuick(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !status.Quick {
+			t.Errorf("%s: status not marked quick", filepath.Base(path))
+		}
+		switch {
+		case wantError == "" && !status.IsVerified:
+			t.Errorf("%s: unexpected errors %v", filepath.Base(path), status.Errors)
+		case wantError != "" && (status.IsVerified || !strings.Contains(strings.Join(status.Errors, "\n"), wantError)):
+			t.Errorf("%s: errors %v, want %q", filepath.Base(path), status.Errors, wantError)
+		}
+	}
+	check(good, "")
+	check(truncated, "Failed to open archive")
+	check(badHeader, "Bad local header for a.txt")
+
+	// The manifest must only list archived files with their sizes
+	manifest := &ArchiveManifest{Files: []ManifestFile{{Path: "a.txt", Size: 5}}}
+	if err := StoreArchiveManifest(good, manifest); err != nil {
+		t.Fatal(err)
+	}
+	check(good, "")
+	manifest.Files = append(manifest.Files, ManifestFile{Path: "b.txt", Size: 1})
+	manifest.Files[0].Size = 4
+	if err := StoreArchiveManifest(good, manifest); err != nil {
+		t.Fatal(err)
+	}
+	check(good, "Manifest records 4 bytes for a.txt, the archive 5")
+	check(good, "Manifest lists b.txt, which is not in the archive")
+	if err := os.WriteFile(manifestPath(good), []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	check(good, "Unreadable manifest")
+
+	if err := CheckArchiveStructure(badHeader); err == nil || !strings.Contains(err.Error(), "Bad local header") {
+		t.Errorf("CheckArchiveStructure(%s) = %v", badHeader, err)
+	}
+}
+
+// ⭐ ARCH-002: Checksum verification of archives made by create - 🧪
+func TestVerifyCreatedArchiveChecksums(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	os.WriteFile("a.txt", []byte("alpha"), 0o644)
+	os.MkdirAll("sub", 0o755)
+	os.WriteFile(filepath.Join("sub", "b.txt"), []byte("beta"), 0o644)
+	verify := func(name string, sample *SampleSpec) error {
+		return VerifyArchiveEnhanced(VerifyOptions{Config: cfg, Formatter: NewOutputFormatter(cfg),
+			ArchiveName: name, WithChecksum: true, Sample: sample})
+	}
+
+	// Without .checksums or manifest hashes the entries are checked by CRC-32
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ := listArchiveEntries(archiveDir)
+	if len(archives) != 1 {
+		t.Fatalf("archives = %+v", archives)
+	}
+	plain := archives[0]
+	if err := verify(plain.Name, nil); err != nil {
+		t.Errorf("verify -c: %v", err)
+	}
+	if err := verify(plain.Name, &SampleSpec{Count: 2}); err != nil {
+		t.Errorf("verify -c --sample: %v", err)
+	}
+
+	// With manifest_file_hashes the entries are checked against the manifest
+	cfg.ManifestFileHashes = true
+	os.WriteFile("a.txt", []byte("alpha two"), 0o644)
+	if err := CreateFullArchive(cfg, "hashed", false, false); err != nil {
+		t.Fatal(err)
+	}
+	archives, _ = listArchiveEntries(archiveDir)
+	var hashed Archive
+	for _, a := range archives {
+		if a.Name != plain.Name {
+			hashed = a
+		}
+	}
+	if err := verify(hashed.Name, nil); err != nil {
+		t.Errorf("verify -c with manifest hashes: %v", err)
+	}
+	if status, err := LoadVerificationStatus(&hashed); err != nil || status == nil || !status.HasChecksums {
+		t.Errorf("stored status = %+v, %v; want checksums", status, err)
+	}
+
+	manifest, err := LoadArchiveManifest(hashed.Path)
+	if err != nil || manifest == nil || len(manifest.Files) == 0 {
+		t.Fatalf("manifest = %+v, %v", manifest, err)
+	}
+	for i := range manifest.Files {
+		manifest.Files[i].SHA256 = strings.Repeat("0", 64)
+	}
+	data, _ := json.Marshal(manifest)
+	os.WriteFile(manifestPath(hashed.Path), data, 0o644)
+	if err := verify(hashed.Name, nil); err == nil {
+		t.Error("verify -c should fail when the entries do not match the manifest hashes")
+	}
+}
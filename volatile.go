@@ -0,0 +1,40 @@
+// This file is part of bkpdir
+//
+// Package main provides the volatile file heuristics of incremental
+// archives: changes of files matching volatile_patterns, such as caches,
+// logs and editor swap files, do not make a new incremental archive alone.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"bkpdir/pkg/formatter"
+)
+
+// ⭐ VOLATILE-001: Volatile change detection - 🔍
+// onlyVolatileChanges reports whether every changed file matches one of
+// patterns, with the matching of exclude_patterns.
+func onlyVolatileChanges(changed []string, patterns []string) bool {
+	if len(patterns) == 0 {
+		return false
+	}
+	for _, rel := range changed {
+		if !ShouldExcludeFile(rel, patterns) {
+			return false
+		}
+	}
+	return true
+}
+
+// printNoSignificantChanges reports an incremental archive skipped because
+// only volatile files changed.
+func printNoSignificantChanges(changed []string) {
+	fmt.Fprint(os.Stdout, formatter.StyleStdout(fmt.Sprintf(
+		"No significant changes: only %d volatile %s changed; use --include-volatile to archive them\n",
+		len(changed), pluralFiles(len(changed)))))
+}
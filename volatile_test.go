@@ -0,0 +1,64 @@
+// This file is part of bkpdir
+
+// Package main provides tests for volatile file heuristics.
+// It verifies that changes of volatile files alone skip incremental archives.
+package main
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+)
+
+// ⭐ VOLATILE-001: Volatile change detection - 🧪
+func TestOnlyVolatileChanges(t *testing.T) {
+	patterns := []string{"*.log", "*.swp", ".cache/"}
+	tests := []struct {
+		changed []string
+		want    bool
+	}{
+		{[]string{"app.log", ".main.go.swp", ".cache", ".cache/index"}, true},
+		{[]string{"app.log", "main.go"}, false},
+		{[]string{"main.go"}, false},
+	}
+	for _, tt := range tests {
+		if got := onlyVolatileChanges(tt.changed, patterns); got != tt.want {
+			t.Errorf("onlyVolatileChanges(%v) = %v, want %v", tt.changed, got, tt.want)
+		}
+	}
+	if onlyVolatileChanges([]string{"app.log"}, nil) {
+		t.Error("without patterns every change is significant")
+	}
+}
+
+// ⭐ VOLATILE-001: Skipped incremental archives - 🧪
+func TestIncrementalArchiveSkipsVolatileChanges(t *testing.T) {
+	archiveDir := t.TempDir()
+	cfg := uploadTestConfig(t, archiveDir)
+	cfg.VolatilePatterns = []string{"*.log"}
+	os.WriteFile("main.go", []byte("package main"), 0o644)
+	os.WriteFile("app.log", []byte("started"), 0o644)
+	if err := CreateFullArchive(cfg, "", false, false); err != nil {
+		t.Fatal(err)
+	}
+
+	later := time.Now().Add(time.Hour)
+	os.WriteFile("app.log", []byte("started\nstopped"), 0o644)
+	os.Chtimes("app.log", later, later)
+	inc := IncrementalArchiveConfig{Config: cfg, Context: context.Background()}
+	if err := createIncrementalArchive(inc); err != nil {
+		t.Fatal(err)
+	}
+	if archives, _ := listArchiveEntries(archiveDir); len(archives) != 1 {
+		t.Fatalf("volatile changes created an archive: %v", archives)
+	}
+
+	inc.IncludeVolatile = true
+	if err := createIncrementalArchive(inc); err != nil {
+		t.Fatal(err)
+	}
+	if archives, _ := listArchiveEntries(archiveDir); len(archives) != 2 {
+		t.Fatalf("--include-volatile should create an archive, found %v", archives)
+	}
+}
@@ -0,0 +1,258 @@
+// This file is part of bkpdir
+//
+// Package main provides the user directories of bkpdir after the XDG base
+// directory specification: state such as the run history and the undo
+// journal, caches such as remote listings, and the user configuration file,
+// with their macOS equivalents. `bkpdir migrate-dirs` moves the files of
+// earlier layouts; until then they keep being used where they are.
+//
+// Copyright (c) 2024 BkpDir Contributors
+// Licensed under the MIT License
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+
+	"bkpdir/pkg/fileops"
+)
+
+// ⭐ XDG-001: User directory names - 🔧
+const (
+	// stateDirEnvVar overrides the state directory.
+	stateDirEnvVar = "BKPDIR_STATE_DIR"
+	// userDirName is the directory of bkpdir below each base directory.
+	userDirName = "bkpdir"
+	// userConfigName is the user configuration file in the config directory.
+	userConfigName = "config.yml"
+)
+
+// userDirsOS selects the platform conventions; tests replace it.
+var userDirsOS = runtime.GOOS
+
+// xdgBaseDir returns the base directory named by the environment variable
+// env, which the XDG specification ignores unless it is absolute, or the
+// fallback below the home directory.
+func xdgBaseDir(env string, fallback ...string) (string, error) {
+	if dir := os.Getenv(env); filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{home}, fallback...)...), nil
+}
+
+// xdgStateDir returns the state directory of the current layout:
+// $XDG_STATE_HOME/bkpdir, ~/Library/Application Support/bkpdir on macOS or
+// ~/.local/state/bkpdir.
+func xdgStateDir() (string, error) {
+	fallback := []string{".local", "state"}
+	if userDirsOS == "darwin" {
+		fallback = []string{"Library", "Application Support"}
+	}
+	dir, err := xdgBaseDir("XDG_STATE_HOME", fallback...)
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the state directory: %w", err)
+	}
+	return filepath.Join(dir, userDirName), nil
+}
+
+// legacyStateDir returns the state directory of earlier macOS layouts,
+// which used ~/.local/state like other systems; it is empty elsewhere.
+func legacyStateDir() string {
+	home, err := os.UserHomeDir()
+	if userDirsOS != "darwin" || err != nil || os.Getenv("XDG_STATE_HOME") != "" {
+		return ""
+	}
+	return filepath.Join(home, ".local", "state", userDirName)
+}
+
+// ⭐ XDG-001: State directory - 🔧
+// StateDir returns the directory of bkpdir's state, such as the run history
+// and the undo journal: $BKPDIR_STATE_DIR if set, otherwise the directory of
+// xdgStateDir, or the legacy state directory while it is not migrated.
+func StateDir() (string, error) {
+	if dir := os.Getenv(stateDirEnvVar); dir != "" {
+		return dir, nil
+	}
+	dir, err := xdgStateDir()
+	if err != nil {
+		return "", err
+	}
+	return unmigrated(dir, legacyStateDir()), nil
+}
+
+// ⭐ XDG-001: Cache directory - 🔧
+// CacheDir returns the directory of bkpdir's caches, which may be deleted at
+// any time: $XDG_CACHE_HOME/bkpdir, otherwise bkpdir in the user cache
+// directory such as ~/.cache or ~/Library/Caches.
+func CacheDir() (string, error) {
+	if dir := os.Getenv("XDG_CACHE_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, userDirName), nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate user cache directory: %w", err)
+	}
+	return filepath.Join(dir, userDirName), nil
+}
+
+// ⭐ XDG-001: Config directory - 🔧
+// ConfigDir returns the directory of the user configuration file:
+// $XDG_CONFIG_HOME/bkpdir, otherwise ~/.config/bkpdir, also on macOS where
+// command-line tools conventionally keep their configuration there.
+func ConfigDir() (string, error) {
+	dir, err := xdgBaseDir("XDG_CONFIG_HOME", ".config")
+	if err != nil {
+		return "", fmt.Errorf("failed to locate the config directory: %w", err)
+	}
+	return filepath.Join(dir, userDirName), nil
+}
+
+// userConfigSearchPath returns the entry of the user configuration file in
+// the default config search path.
+func userConfigSearchPath() string {
+	if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+		return filepath.Join(dir, userDirName, userConfigName)
+	}
+	return "~/.config/" + userDirName + "/" + userConfigName
+}
+
+// unmigrated returns legacy instead of path when only legacy exists, so
+// files of earlier layouts are used until `bkpdir migrate-dirs` moves them.
+func unmigrated(path, legacy string) string {
+	if legacy == "" {
+		return path
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	return path
+}
+
+// MigrateDirsOptions configures `bkpdir migrate-dirs`.
+type MigrateDirsOptions struct {
+	Output io.Writer
+	Config bool // Also move ~/.bkpdir.yml to the config directory
+	Apply  bool // Move the files instead of only listing the moves
+	DryRun bool
+}
+
+// dirMigration is the move of one file of an earlier layout.
+type dirMigration struct {
+	From, To string
+}
+
+// ⭐ XDG-001: Earlier layouts - 🔍
+// legacyMigrations lists the files of earlier layouts that exist: the undo
+// journal in the user configuration directory, the contents of the macOS
+// state directory below ~/.local/state and, with config, ~/.bkpdir.yml.
+// Locations overridden by environment variables are left alone.
+func legacyMigrations(config bool) ([]dirMigration, error) {
+	var migrations []dirMigration
+	add := func(from, to string) {
+		if _, err := os.Lstat(from); err == nil && from != to {
+			migrations = append(migrations, dirMigration{From: from, To: to})
+		}
+	}
+
+	if os.Getenv(stateDirEnvVar) == "" {
+		state, err := xdgStateDir()
+		if err != nil {
+			return nil, err
+		}
+		if legacy := legacyStateDir(); legacy != "" {
+			entries, _ := os.ReadDir(legacy)
+			for _, e := range entries {
+				add(filepath.Join(legacy, e.Name()), filepath.Join(state, e.Name()))
+			}
+		}
+		if os.Getenv(journalEnvVar) == "" {
+			if dir, err := os.UserConfigDir(); err == nil {
+				add(filepath.Join(dir, userDirName, journalName), filepath.Join(state, journalName))
+			}
+		}
+	}
+
+	if config && os.Getenv("BKPDIR_CONFIG") == "" {
+		dir, err := ConfigDir()
+		if err != nil {
+			return nil, err
+		}
+		add(expandPath("~/.bkpdir.yml"), filepath.Join(dir, userConfigName))
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].From < migrations[j].From })
+	return migrations, nil
+}
+
+// ⭐ XDG-001: Directory migration - 🔧
+// MigrateDirs moves the files of earlier layouts to the XDG directories.
+// Files whose destination already exists are left alone. Without Apply the
+// moves are only listed.
+func MigrateDirs(cfg *Config, opts MigrateDirsOptions) error {
+	migrations, err := legacyMigrations(opts.Config)
+	if err != nil {
+		return NewArchiveErrorWithCause("Failed to locate user directories", cfg.StatusConfigError, err)
+	}
+	w := opts.Output
+	var moves []dirMigration
+	taken := make(map[string]bool)
+	for _, m := range migrations {
+		if _, err := os.Lstat(m.To); err == nil || taken[m.To] {
+			fmt.Fprintf(w, "Left alone: %s (%s exists)\n", m.From, m.To)
+			continue
+		}
+		taken[m.To] = true
+		moves = append(moves, m)
+	}
+	if len(moves) == 0 {
+		fmt.Fprintln(w, "No files to migrate")
+		return nil
+	}
+	if !opts.Apply || opts.DryRun {
+		for _, m := range moves {
+			fmt.Fprintf(w, "%s -> %s\n", m.From, m.To)
+		}
+		fmt.Fprintf(w, "%d %s would be moved; run with --apply to move them\n", len(moves), pluralFiles(len(moves)))
+		return nil
+	}
+
+	for i, m := range moves {
+		if err := moveUserFile(m.From, m.To); err != nil {
+			return NewArchiveErrorWithCause(
+				fmt.Sprintf("Failed to move %s after moving %d of %d files", m.From, i, len(moves)), 1, err)
+		}
+		fmt.Fprintf(w, "Moved %s to %s\n", m.From, m.To)
+	}
+	fmt.Fprintf(w, "Moved %d %s\n", len(moves), pluralFiles(len(moves)))
+	return nil
+}
+
+// moveUserFile moves from to to, copying files when they are on different
+// filesystems.
+func moveUserFile(from, to string) error {
+	if err := fileops.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	err := fileops.Rename(from, to)
+	if err == nil {
+		return nil
+	}
+	if info, statErr := os.Lstat(from); statErr != nil || !info.Mode().IsRegular() {
+		return err
+	}
+	if err := fileops.AtomicCopy(from, to); err != nil {
+		return err
+	}
+	return fileops.Remove(from)
+}
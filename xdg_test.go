@@ -0,0 +1,114 @@
+// This file is part of bkpdir
+
+// Package main provides tests for the XDG user directories. It verifies the
+// state, cache and config locations and the migration of earlier layouts.
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// ⭐ XDG-001: User directories - 🧪
+func TestUserDirs(t *testing.T) {
+	defer func(goos string) { userDirsOS = goos }(userDirsOS)
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv(stateDirEnvVar, "")
+	t.Setenv("XDG_STATE_HOME", "")
+	t.Setenv("XDG_CONFIG_HOME", "relative/is/ignored")
+
+	check := func(name string, got func() (string, error), want string) {
+		t.Helper()
+		if dir, err := got(); err != nil || dir != want {
+			t.Errorf("%s = %q, %v; want %q", name, dir, err, want)
+		}
+	}
+	userDirsOS = "linux"
+	check("state", StateDir, filepath.Join(home, ".local", "state", "bkpdir"))
+	check("config", ConfigDir, filepath.Join(home, ".config", "bkpdir"))
+	if got := userConfigSearchPath(); got != "~/.config/bkpdir/config.yml" {
+		t.Errorf("config search path = %q", got)
+	}
+
+	// macOS keeps using ~/.local/state until it is migrated
+	userDirsOS = "darwin"
+	check("darwin state", StateDir, filepath.Join(home, "Library", "Application Support", "bkpdir"))
+	legacy := filepath.Join(home, ".local", "state", "bkpdir")
+	os.MkdirAll(legacy, 0o755)
+	check("unmigrated darwin state", StateDir, legacy)
+
+	xdg := t.TempDir()
+	t.Setenv("XDG_STATE_HOME", filepath.Join(xdg, "state"))
+	t.Setenv("XDG_CACHE_HOME", filepath.Join(xdg, "cache"))
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(xdg, "config"))
+	check("XDG state", StateDir, filepath.Join(xdg, "state", "bkpdir"))
+	check("XDG cache", CacheDir, filepath.Join(xdg, "cache", "bkpdir"))
+	check("XDG config", ConfigDir, filepath.Join(xdg, "config", "bkpdir"))
+	if got := userConfigSearchPath(); got != filepath.Join(xdg, "config", "bkpdir", "config.yml") {
+		t.Errorf("XDG config search path = %q", got)
+	}
+	t.Setenv(stateDirEnvVar, "/srv/bkpdir-state")
+	check("BKPDIR_STATE_DIR", StateDir, "/srv/bkpdir-state")
+}
+
+// ⭐ XDG-001: Migration of earlier layouts - 🧪
+func TestMigrateDirs(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("home-relative layouts are Unix only")
+	}
+	defer func(goos string) { userDirsOS = goos }(userDirsOS)
+	userDirsOS = "linux"
+	home := t.TempDir()
+	for env, value := range map[string]string{"HOME": home, stateDirEnvVar: "", journalEnvVar: "",
+		"BKPDIR_CONFIG": "", "XDG_STATE_HOME": "", "XDG_CONFIG_HOME": ""} {
+		t.Setenv(env, value)
+	}
+	configDir, _ := os.UserConfigDir()
+	legacyJournal := filepath.Join(configDir, "bkpdir", "journal.json")
+	os.MkdirAll(filepath.Dir(legacyJournal), 0o755)
+	os.WriteFile(legacyJournal, []byte(`{"entries":[]}`), 0o644)
+	os.WriteFile(filepath.Join(home, ".bkpdir.yml"), []byte("archive_dir_path: /srv/archives\n"), 0o644)
+	newJournal := filepath.Join(home, ".local", "state", "bkpdir", "journal.json")
+
+	if path, _ := JournalPath(); path != legacyJournal {
+		t.Errorf("unmigrated journal = %q", path)
+	}
+
+	var out bytes.Buffer
+	cfg := DefaultConfig()
+	if err := MigrateDirs(cfg, MigrateDirsOptions{Output: &out, Config: true}); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), legacyJournal+" -> "+newJournal) ||
+		!strings.Contains(out.String(), "2 files would be moved") {
+		t.Errorf("listing:\n%s", out.String())
+	}
+	if _, err := os.Stat(legacyJournal); err != nil {
+		t.Errorf("listing moved the journal: %v", err)
+	}
+
+	out.Reset()
+	if err := MigrateDirs(cfg, MigrateDirsOptions{Output: &out, Config: true, Apply: true}); err != nil {
+		t.Fatal(err)
+	}
+	if path, _ := JournalPath(); path != newJournal {
+		t.Errorf("migrated journal = %q", path)
+	}
+	if _, err := os.Stat(legacyJournal); !os.IsNotExist(err) {
+		t.Errorf("legacy journal still exists: %v", err)
+	}
+	loaded, err := LoadConfig(t.TempDir())
+	if err != nil || loaded.ArchiveDirPath != "/srv/archives" {
+		t.Errorf("config from ~/.config/bkpdir/config.yml: archive_dir_path = %q, %v", loaded.ArchiveDirPath, err)
+	}
+
+	out.Reset()
+	if err := MigrateDirs(cfg, MigrateDirsOptions{Output: &out, Config: true}); err != nil || out.String() != "No files to migrate\n" {
+		t.Errorf("second migration: %q, %v", out.String(), err)
+	}
+}